@@ -2,24 +2,37 @@
 package main
 
 import (
+	"context"
 	"os"
 
 	"github.com/bitrise-io/bitrise-plugins-codepush-cli/cmd"
+	"github.com/bitrise-io/bitrise-plugins-codepush-cli/cmd/setup"
+	"github.com/bitrise-io/bitrise-plugins-codepush-cli/internal/cmdutil"
+	"github.com/bitrise-io/bitrise-plugins-codepush-cli/internal/codepush"
 	"github.com/bitrise-io/bitrise-plugins-codepush-cli/internal/output"
 
 	_ "github.com/bitrise-io/bitrise-plugins-codepush-cli/cmd/debug"
 	_ "github.com/bitrise-io/bitrise-plugins-codepush-cli/cmd/deployment"
+	_ "github.com/bitrise-io/bitrise-plugins-codepush-cli/cmd/metrics"
 	_ "github.com/bitrise-io/bitrise-plugins-codepush-cli/cmd/release"
-	_ "github.com/bitrise-io/bitrise-plugins-codepush-cli/cmd/setup"
 	_ "github.com/bitrise-io/bitrise-plugins-codepush-cli/cmd/updatecmd"
 )
 
 func main() {
 	cmd.Out = output.New()
 	cmd.Version = version
+	cmd.Relogin = setup.Relogin
+	cmd.FirstRun = setup.FirstRun
 
-	if err := cmd.RootCmd.Execute(); err != nil {
-		cmd.Out.Error("%v", err)
-		os.Exit(1)
+	if err := cmd.Execute(); err != nil {
+		report := codepush.NewErrorReport(err)
+		if cmd.JSONOutput {
+			cmdutil.OutputErrorReport(report)
+		} else {
+			cmd.Out.Error("%v", err)
+			cmdutil.HandleUnauthorized(context.Background(), err, cmd.Out, cmd.Relogin)
+		}
+		cmdutil.AnnotateError(err, os.Stderr)
+		os.Exit(report.ExitCode)
 	}
 }
@@ -2,16 +2,22 @@ package main
 
 import (
 	"bytes"
+	"context"
+	"fmt"
 	"io"
 	"os"
 	"path/filepath"
+	"slices"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
 	"github.com/bitrise-io/bitrise-plugins-codepush-cli/cmd"
+	core "github.com/bitrise-io/bitrise-plugins-codepush-cli/internal/codepush"
+	"github.com/bitrise-io/bitrise-plugins-codepush-cli/internal/codepushtest"
 	"github.com/bitrise-io/bitrise-plugins-codepush-cli/internal/output"
+	"github.com/bitrise-io/bitrise-plugins-codepush-cli/pkg/codepush"
 )
 
 func TestMain(m *testing.M) {
@@ -93,9 +99,184 @@ func TestProgressStylePrecedence(t *testing.T) {
 	})
 }
 
+func TestRetriesFlag(t *testing.T) {
+	f := cmd.RootCmd.PersistentFlags().Lookup("retries")
+	require.NotNil(t, f, "--retries flag should be registered on root command")
+	assert.Equal(t, "3", f.DefValue)
+
+	run := func(t *testing.T, args ...string) error {
+		t.Helper()
+		t.Cleanup(func() {
+			_ = f.Value.Set(f.DefValue)
+			f.Changed = false
+		})
+		cmd.RootCmd.SetArgs(args)
+		return cmd.RootCmd.Execute()
+	}
+
+	t.Run("invalid environment value", func(t *testing.T) {
+		t.Setenv(cmd.RetriesEnvKey, "many")
+		assert.ErrorContains(t, run(t, "version"), cmd.RetriesEnvKey)
+	})
+
+	t.Run("flag wins over environment", func(t *testing.T) {
+		t.Setenv(cmd.RetriesEnvKey, "many")
+		assert.NoError(t, run(t, "version", "--retries", "1"))
+	})
+
+	t.Run("negative flag", func(t *testing.T) {
+		assert.ErrorContains(t, run(t, "version", "--retries", "-1"), "must not be negative")
+	})
+}
+
+func TestRateLimitFlag(t *testing.T) {
+	f := cmd.RootCmd.PersistentFlags().Lookup("rate-limit")
+	require.NotNil(t, f, "--rate-limit flag should be registered on root command")
+	assert.Equal(t, "10", f.DefValue)
+
+	run := func(t *testing.T, args ...string) error {
+		t.Helper()
+		t.Cleanup(func() {
+			_ = f.Value.Set(f.DefValue)
+			f.Changed = false
+		})
+		cmd.RootCmd.SetArgs(args)
+		return cmd.RootCmd.Execute()
+	}
+
+	t.Run("invalid environment value", func(t *testing.T) {
+		t.Setenv(cmd.RateLimitEnvKey, "fast")
+		assert.ErrorContains(t, run(t, "version"), cmd.RateLimitEnvKey)
+	})
+
+	t.Run("flag wins over environment", func(t *testing.T) {
+		t.Setenv(cmd.RateLimitEnvKey, "fast")
+		assert.NoError(t, run(t, "version", "--rate-limit", "2.5"))
+	})
+
+	t.Run("negative flag", func(t *testing.T) {
+		assert.ErrorContains(t, run(t, "version", "--rate-limit", "-1"), "must be a non-negative number")
+	})
+}
+
+func TestEnvFileFlag(t *testing.T) {
+	f := cmd.RootCmd.PersistentFlags().Lookup("env-file")
+	require.NotNil(t, f, "--env-file flag should be registered on root command")
+
+	run := func(t *testing.T, envFile string) error {
+		t.Helper()
+		t.Cleanup(func() {
+			_ = f.Value.Set(f.DefValue)
+			f.Changed = false
+		})
+		cmd.RootCmd.SetArgs([]string{"version", "--env-file", envFile})
+		return cmd.RootCmd.Execute()
+	}
+	path := filepath.Join(t.TempDir(), ".codepush.env")
+	require.NoError(t, os.WriteFile(path, []byte("# retries\nexport "+cmd.RetriesEnvKey+"=many\n"), 0o600))
+
+	t.Run("file sets unset variables", func(t *testing.T) {
+		t.Setenv(cmd.RetriesEnvKey, "")
+		assert.ErrorContains(t, run(t, path), cmd.RetriesEnvKey)
+		assert.Equal(t, "many", os.Getenv(cmd.RetriesEnvKey))
+	})
+
+	t.Run("environment wins over file", func(t *testing.T) {
+		t.Setenv(cmd.RetriesEnvKey, "1")
+		assert.NoError(t, run(t, path))
+		assert.Equal(t, "1", os.Getenv(cmd.RetriesEnvKey))
+	})
+
+	t.Run("missing file", func(t *testing.T) {
+		assert.ErrorContains(t, run(t, filepath.Join(t.TempDir(), "missing.env")), "reading env file")
+	})
+}
+
+func TestOutputFlag(t *testing.T) {
+	f := cmd.RootCmd.PersistentFlags().Lookup("output")
+	require.NotNil(t, f, "--output flag should be registered on root command")
+
+	run := func(t *testing.T, args ...string) error {
+		t.Helper()
+		t.Cleanup(func() {
+			_ = f.Value.Set(f.DefValue)
+			f.Changed = false
+			cmd.JSONOutput = false
+		})
+		cmd.RootCmd.SetArgs(args)
+		return cmd.RootCmd.Execute()
+	}
+
+	t.Run("json", func(t *testing.T) {
+		require.NoError(t, run(t, "version", "--output", "json"))
+		assert.True(t, cmd.JSONOutput)
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		assert.ErrorContains(t, run(t, "version", "--output", "xml"), "must be table, json, ndjson, yaml, or csv")
+	})
+}
+
+func TestLogLevelFlags(t *testing.T) {
+	names := []string{"quiet", "verbose", "log-level"}
+	for _, name := range names {
+		require.NotNil(t, cmd.RootCmd.PersistentFlags().Lookup(name), "--%s flag should be registered on root command", name)
+	}
+
+	run := func(t *testing.T, args ...string) error {
+		t.Helper()
+		t.Cleanup(func() {
+			for _, name := range names {
+				f := cmd.RootCmd.PersistentFlags().Lookup(name)
+				_ = f.Value.Set(f.DefValue)
+				f.Changed = false
+			}
+			cmd.Out.SetLevel(output.LevelInfo)
+		})
+		cmd.RootCmd.SetArgs(args)
+		return cmd.RootCmd.Execute()
+	}
+
+	tests := []struct {
+		name    string
+		args    []string
+		env     map[string]string
+		want    output.Level
+		wantErr string
+	}{
+		{name: "default", args: []string{"version"}, want: output.LevelInfo},
+		{name: "verbose", args: []string{"version", "--verbose"}, want: output.LevelDebug},
+		{name: "quiet", args: []string{"version", "-q"}, want: output.LevelError},
+		{name: "log level", args: []string{"version", "--log-level", "warning"}, want: output.LevelWarning},
+		{name: "debug environment", args: []string{"version"}, env: map[string]string{cmd.DebugEnvKey: "1"}, want: output.LevelDebug},
+		{name: "log level environment", args: []string{"version"}, env: map[string]string{cmd.LogLevelEnvKey: "error"}, want: output.LevelError},
+		{name: "flag overrides environment", args: []string{"version", "--verbose=false"}, env: map[string]string{cmd.DebugEnvKey: "1"}, want: output.LevelInfo},
+		{name: "quiet overrides environment", args: []string{"version", "--quiet"}, env: map[string]string{cmd.LogLevelEnvKey: "debug"}, want: output.LevelError},
+		{name: "conflicting flags", args: []string{"version", "--quiet", "--verbose"}, wantErr: "--quiet and --verbose cannot be used together"},
+		{name: "invalid log level", args: []string{"version", "--log-level", "loud"}, wantErr: "invalid log level"},
+		{name: "invalid debug environment", args: []string{"version"}, env: map[string]string{cmd.DebugEnvKey: "yes please"}, wantErr: "invalid CODEPUSH_DEBUG"},
+		{name: "invalid log level environment", args: []string{"version"}, env: map[string]string{cmd.LogLevelEnvKey: "loud"}, wantErr: "invalid CODEPUSH_LOG_LEVEL"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for k, v := range tt.env {
+				t.Setenv(k, v)
+			}
+			err := run(t, tt.args...)
+			if tt.wantErr != "" {
+				assert.ErrorContains(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, cmd.Out.Level())
+		})
+	}
+}
+
 func TestCommandRegistration(t *testing.T) {
 	commands := cmd.RootCmd.Commands()
-	wantNames := []string{"version", "bundle", "push", "rollback", "promote", "integrate", "auth"}
+	wantNames := []string{"version", "bundle", "push", "rollback", "promote", "integrate", "auth", "ping", "metrics", "cache", "overview", "schedule", "rollout", "package", "apps", "audit"}
 
 	found := make(map[string]bool)
 	for _, c := range commands {
@@ -106,3 +287,76 @@ func TestCommandRegistration(t *testing.T) {
 		assert.True(t, found[name], "command %q not registered on root command", name)
 	}
 }
+
+// startReleaseServer serves an app with two releases in Staging and an
+// empty Production deployment, and points the CLI at it.
+func startReleaseServer(t *testing.T) {
+	t.Helper()
+	s := codepushtest.NewServer()
+	s.Token = "test-token"
+	a := s.AddApp(core.App{Name: "Example", Platform: "ios"})
+	for _, name := range []string{"Staging", "Production"} {
+		_, err := s.AddDeployment(a.ID, name)
+		require.NoError(t, err)
+	}
+	apiURL := codepushtest.Start(t, s) + codepushtest.APIPath
+
+	r, err := codepush.New(codepush.Config{AppID: a.ID, Token: s.Token, APIURL: apiURL})
+	require.NoError(t, err)
+	for _, content := range []string{"console.log(1)", "console.log(2)"} {
+		dir := filepath.Join(t.TempDir(), "CodePush")
+		require.NoError(t, os.MkdirAll(dir, 0o755))
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "main.jsbundle"), []byte(content), 0o644))
+		_, err := r.Push(context.Background(), codepush.PushOptions{Deployment: "Staging", BundlePath: dir, AppVersion: "1.0.0", NoWait: true})
+		require.NoError(t, err)
+	}
+
+	t.Setenv("BITRISE_API_TOKEN", s.Token)
+	t.Setenv("CODEPUSH_API_URL", apiURL)
+	t.Setenv("CODEPUSH_APP_ID", a.ID)
+}
+
+func TestBitriseExport(t *testing.T) {
+	f := cmd.RootCmd.PersistentFlags().Lookup("no-bitrise-export")
+	require.NotNil(t, f, "--no-bitrise-export flag should be registered on root command")
+
+	tests := []struct {
+		name    string
+		args    []string
+		summary string
+	}{
+		{name: "promote", args: []string{"promote", "-s", "Staging", "-d", "Production", "--yes"}, summary: "codepush-promote-summary.json"},
+		{name: "rollback", args: []string{"rollback", "-d", "Staging"}, summary: "codepush-rollback-summary.json"},
+		{name: "patch", args: []string{"patch", "-d", "Staging", "--rollout", "50"}, summary: "codepush-patch-summary.json"},
+	}
+
+	for _, tt := range tests {
+		for _, noExport := range []bool{false, true} {
+			t.Run(fmt.Sprintf("%s/no-bitrise-export=%t", tt.name, noExport), func(t *testing.T) {
+				t.Chdir(t.TempDir())
+				startReleaseServer(t)
+				deployDir := t.TempDir()
+				t.Setenv("BITRISE_DEPLOY_DIR", deployDir)
+				t.Setenv("PATH", t.TempDir()) // no envman
+				t.Cleanup(func() {
+					_ = f.Value.Set(f.DefValue)
+					f.Changed = false
+				})
+
+				args := tt.args
+				if noExport {
+					args = append(slices.Clone(args), "--no-bitrise-export")
+				}
+				cmd.RootCmd.SetArgs(args)
+				require.NoError(t, cmd.RootCmd.Execute())
+
+				summaryPath := filepath.Join(deployDir, tt.summary)
+				if noExport {
+					assert.NoFileExists(t, summaryPath)
+				} else {
+					assert.FileExists(t, summaryPath)
+				}
+			})
+		}
+	}
+}
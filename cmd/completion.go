@@ -0,0 +1,146 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/spf13/cobra"
+
+	"github.com/bitrise-io/bitrise-plugins-codepush-cli/internal/cmdutil"
+	"github.com/bitrise-io/bitrise-plugins-codepush-cli/internal/codepush"
+)
+
+// completionTimeout bounds the API requests made to complete a value, so a
+// slow or unreachable server never stalls the shell.
+const completionTimeout = 3 * time.Second
+
+func init() {
+	RootCmd.SetCompletionCommandGroupID(GroupSetup)
+}
+
+// completionClient returns an API client and the app ID for dynamic
+// completion, or ok=false when no credentials are configured. Nothing is
+// printed: output would corrupt the shell's completion protocol.
+func completionClient(ctx context.Context) (client *codepush.HTTPClient, appID string, ok bool) {
+	appID = cmdutil.ResolveAppID(AppID, nil)
+	token := cmdutil.ResolveToken(ctx, nil, nil)
+	if appID == "" || token == "" {
+		return nil, "", false
+	}
+	return NewClient(cmdutil.ResolveAPIURL(APIURL, ServerURL, nil), token), appID, true
+}
+
+// CompleteDeployments completes a deployment flag with the names of the
+// app's deployments.
+func CompleteDeployments(c *cobra.Command, _ []string, toComplete string) ([]cobra.Completion, cobra.ShellCompDirective) {
+	client, appID, ok := completionClient(c.Context())
+	if !ok {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	ctx, cancel := context.WithTimeout(c.Context(), completionTimeout)
+	defer cancel()
+
+	deployments, err := client.ListDeployments(ctx, appID)
+	if err != nil {
+		cobra.CompDebugln(fmt.Sprintf("listing deployments: %v", err), true)
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	return deploymentCompletions(deployments, toComplete), cobra.ShellCompDirectiveNoFileComp
+}
+
+// CompleteDeploymentArg completes the optional [deployment] argument of a
+// command.
+func CompleteDeploymentArg(c *cobra.Command, args []string, toComplete string) ([]cobra.Completion, cobra.ShellCompDirective) {
+	if len(args) > 0 {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	return CompleteDeployments(c, args, toComplete)
+}
+
+// CompleteLabels returns a completion function for release labels. The
+// deployment is read from deploymentFlag, or from the first argument when
+// deploymentFlag is empty, falling back to CODEPUSH_DEPLOYMENT and the
+// default deployment in .codepush.json.
+func CompleteLabels(deploymentFlag string) cobra.CompletionFunc {
+	return func(c *cobra.Command, args []string, toComplete string) ([]cobra.Completion, cobra.ShellCompDirective) {
+		var deployment string
+		if deploymentFlag != "" {
+			deployment, _ = c.Flags().GetString(deploymentFlag)
+		} else if len(args) > 0 {
+			deployment = args[0]
+		}
+		deployment = cmdutil.ResolveDeploymentValue(deployment, cmdutil.DeploymentEnvKey, nil)
+		if deployment == "" {
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		}
+
+		client, appID, ok := completionClient(c.Context())
+		if !ok {
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		}
+		ctx, cancel := context.WithTimeout(c.Context(), completionTimeout)
+		defer cancel()
+
+		deploymentID, err := completionDeploymentID(ctx, client, appID, deployment)
+		if err != nil {
+			cobra.CompDebugln(err.Error(), true)
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		}
+		updates, err := client.ListUpdates(ctx, appID, deploymentID)
+		if err != nil {
+			cobra.CompDebugln(fmt.Sprintf("listing releases: %v", err), true)
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		}
+		return labelCompletions(updates, toComplete), cobra.ShellCompDirectiveNoFileComp | cobra.ShellCompDirectiveKeepOrder
+	}
+}
+
+// completionDeploymentID resolves a deployment name or UUID without the
+// progress output of codepush.ResolveDeployment.
+func completionDeploymentID(ctx context.Context, client *codepush.HTTPClient, appID, nameOrID string) (string, error) {
+	if _, err := uuid.Parse(nameOrID); err == nil {
+		return nameOrID, nil
+	}
+	deployments, err := client.ListDeployments(ctx, appID)
+	if err != nil {
+		return "", fmt.Errorf("listing deployments: %w", err)
+	}
+	for _, d := range deployments {
+		if d.Name == nameOrID {
+			return d.ID, nil
+		}
+	}
+	return "", fmt.Errorf("deployment %q not found", nameOrID)
+}
+
+// deploymentCompletions returns the deployment names starting with prefix.
+func deploymentCompletions(deployments []codepush.Deployment, prefix string) []cobra.Completion {
+	var completions []cobra.Completion
+	for _, d := range deployments {
+		if strings.HasPrefix(d.Name, prefix) {
+			completions = append(completions, d.Name)
+		}
+	}
+	return completions
+}
+
+// labelCompletions returns the labels starting with prefix, newest first,
+// described by their target app version and rollout.
+func labelCompletions(updates []codepush.Update, prefix string) []cobra.Completion {
+	var completions []cobra.Completion
+	for i := len(updates) - 1; i >= 0; i-- {
+		u := updates[i]
+		if !strings.HasPrefix(u.Label, prefix) {
+			continue
+		}
+		desc := fmt.Sprintf("app %s, %g%% rollout", u.AppVersion, u.Rollout)
+		if u.Disabled {
+			desc += ", disabled"
+		}
+		completions = append(completions, cobra.CompletionWithDesc(u.Label, desc))
+	}
+	return completions
+}
@@ -0,0 +1,76 @@
+package cmd
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDynamicCompletion(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/connected-apps/app-1/code-push/deployments":
+			_, _ = w.Write([]byte(`{"items":[{"id":"dep-staging","name":"Staging"},{"id":"dep-prod","name":"Production"}]}`))
+		case "/connected-apps/app-1/code-push/deployments/dep-staging/packages":
+			_, _ = w.Write([]byte(`{"items":[
+				{"label":"v1","app_version":"1.0.0","rollout":100},
+				{"label":"v2","app_version":"1.0.0","rollout":50,"disabled":true},
+				{"label":"v10","app_version":"1.1.0","rollout":100}]}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	t.Setenv("CODEPUSH_API_URL", server.URL)
+	t.Setenv("BITRISE_API_TOKEN", "test-token")
+	t.Setenv("CODEPUSH_DEPLOYMENT", "")
+	AppID = "app-1"
+	t.Cleanup(func() { AppID = "" })
+
+	newCommand := func() *cobra.Command {
+		c := &cobra.Command{Use: "test"}
+		c.Flags().String("deployment", "", "")
+		c.SetContext(context.Background())
+		return c
+	}
+
+	t.Run("deployments", func(t *testing.T) {
+		got, directive := CompleteDeployments(newCommand(), nil, "St")
+		assert.Equal(t, []cobra.Completion{"Staging"}, got)
+		assert.Equal(t, cobra.ShellCompDirectiveNoFileComp, directive)
+	})
+
+	t.Run("deployment argument only completes the first argument", func(t *testing.T) {
+		got, _ := CompleteDeploymentArg(newCommand(), []string{"Staging"}, "")
+		assert.Empty(t, got)
+	})
+
+	t.Run("labels from flag, newest first", func(t *testing.T) {
+		c := newCommand()
+		assert.NoError(t, c.Flags().Set("deployment", "Staging"))
+
+		got, directive := CompleteLabels("deployment")(c, nil, "v1")
+		assert.Equal(t, []cobra.Completion{"v10\tapp 1.1.0, 100% rollout", "v1\tapp 1.0.0, 100% rollout"}, got)
+		assert.NotZero(t, directive&cobra.ShellCompDirectiveKeepOrder)
+	})
+
+	t.Run("labels from argument", func(t *testing.T) {
+		got, _ := CompleteLabels("")(newCommand(), []string{"Staging"}, "v2")
+		assert.Equal(t, []cobra.Completion{"v2\tapp 1.0.0, 50% rollout, disabled"}, got)
+	})
+
+	t.Run("labels without a deployment", func(t *testing.T) {
+		got, _ := CompleteLabels("deployment")(newCommand(), nil, "")
+		assert.Empty(t, got)
+	})
+
+	t.Run("unknown deployment", func(t *testing.T) {
+		got, _ := CompleteLabels("")(newCommand(), []string{"Missing"}, "")
+		assert.Empty(t, got)
+	})
+}
@@ -14,6 +14,7 @@ import (
 
 	"github.com/bitrise-io/bitrise-plugins-codepush-cli/cmd"
 	"github.com/bitrise-io/bitrise-plugins-codepush-cli/internal/output"
+	"github.com/bitrise-io/bitrise-plugins-codepush-cli/internal/redact"
 )
 
 var debugCmd = &cobra.Command{
@@ -69,7 +70,7 @@ func runDebugAndroid(ctx context.Context, out *output.Writer) error {
 		scanner := bufio.NewScanner(stdout)
 		for scanner.Scan() {
 			ts := time.Now().Format("15:04:05.000")
-			_, _ = fmt.Fprintf(os.Stdout, "[%s] %s\n", ts, scanner.Text())
+			_, _ = fmt.Fprintf(os.Stdout, "[%s] %s\n", ts, redact.String(scanner.Text()))
 		}
 		scanErr := scanner.Err()
 		waitErr := c.Wait()
@@ -117,7 +118,7 @@ func runDebugIOS(ctx context.Context, out *output.Writer) error {
 		scanner := bufio.NewScanner(stdout)
 		for scanner.Scan() {
 			// Unified log stream lines already include native timestamps; pass them through as-is.
-			_, _ = fmt.Fprintln(os.Stdout, scanner.Text())
+			_, _ = fmt.Fprintln(os.Stdout, redact.String(scanner.Text()))
 		}
 		scanErr := scanner.Err()
 		waitErr := c.Wait()
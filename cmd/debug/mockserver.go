@@ -0,0 +1,148 @@
+package debug
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"os"
+	"os/signal"
+	"strconv"
+
+	"github.com/google/uuid"
+	"github.com/spf13/cobra"
+
+	"github.com/bitrise-io/bitrise-plugins-codepush-cli/cmd"
+	"github.com/bitrise-io/bitrise-plugins-codepush-cli/internal/cmdutil"
+	"github.com/bitrise-io/bitrise-plugins-codepush-cli/internal/codepush"
+	"github.com/bitrise-io/bitrise-plugins-codepush-cli/internal/codepushtest"
+	"github.com/bitrise-io/bitrise-plugins-codepush-cli/internal/output"
+)
+
+var (
+	mockHost            string
+	mockPort            int
+	mockPlatform        string
+	mockWorkspace       string
+	mockDeployments     []string
+	mockToken           string
+	mockScopes          []string
+	mockProcessingPolls int
+)
+
+var mockServerCmd = &cobra.Command{
+	Use:   "mock-server",
+	Short: "Run an in-memory Release Management API for tests",
+	Long: `Run a local, in-memory implementation of the Release Management API, for
+hermetic integration tests of automation built on this CLI.
+
+The server starts with one app and its deployments, and supports every
+request the CLI makes: deployments, releases, package upload and download,
+processing status, patch, promote, and rollback. Uploaded packages are
+processed right away; delta packages are applied to their base release.
+Nothing is persisted: stopping the server discards all state.
+
+The app gets the ID of --app-id or CODEPUSH_APP_ID, or a random one. Point
+the CLI at the printed server URL with CODEPUSH_SERVER_URL, and use the
+printed app ID. With --json, the server URL, app ID, and deployments
+are printed as one JSON object once the server is ready.
+
+Press Ctrl-C to stop the server.`,
+	Example: `  codepush mock-server --port 8080 --app-id 00000000-0000-0000-0000-000000000001
+  CODEPUSH_SERVER_URL=http://127.0.0.1:8080 BITRISE_API_TOKEN=test \
+    codepush push ./CodePush --app-id 00000000-0000-0000-0000-000000000001 --deployment Staging --app-version 1.0.0`,
+	GroupID: cmd.GroupDebug,
+	Args:    cobra.NoArgs,
+	RunE: func(c *cobra.Command, args []string) error {
+		server, info, err := newMockServer()
+		if err != nil {
+			return err
+		}
+
+		listener, err := net.Listen("tcp", net.JoinHostPort(mockHost, strconv.Itoa(mockPort)))
+		if err != nil {
+			return fmt.Errorf("listening on port %d: %w", mockPort, err)
+		}
+		info.ServerURL = "http://" + listener.Addr().String()
+
+		ctx, stop := signal.NotifyContext(c.Context(), os.Interrupt)
+		defer stop()
+		return runMockServer(ctx, listener, server, info, cmd.Out)
+	},
+}
+
+func init() {
+	mockServerCmd.Flags().StringVar(&mockHost, "host", "127.0.0.1", "address to listen on")
+	mockServerCmd.Flags().IntVar(&mockPort, "port", 8080, "port to listen on (0 picks a free port)")
+	mockServerCmd.Flags().StringVar(&mockPlatform, "platform", "ios", "platform of the app the server starts with")
+	mockServerCmd.Flags().StringVar(&mockWorkspace, "workspace", "", "workspace slug of the app the server starts with")
+	mockServerCmd.Flags().StringSliceVar(&mockDeployments, "deployment", []string{"Staging", "Production"}, "deployment the app starts with (repeatable)")
+	mockServerCmd.Flags().StringVar(&mockToken, "token", "", "only accept this API token (default: accept any)")
+	mockServerCmd.Flags().StringSliceVar(&mockScopes, "scope", nil, "report this token scope and reject writes without release_management:write (repeatable)")
+	mockServerCmd.Flags().IntVar(&mockProcessingPolls, "processing-polls", 0, "status checks that report an upload as processing before its result")
+	cmd.RootCmd.AddCommand(mockServerCmd)
+}
+
+// mockServerInfo describes a running mock server.
+type mockServerInfo struct {
+	ServerURL   string                `json:"server_url"`
+	AppID       string                `json:"app_id"`
+	Deployments []codepush.Deployment `json:"deployments"`
+}
+
+// newMockServer creates the server with the app and deployments of the
+// flags. The app gets the --app-id or CODEPUSH_APP_ID, or a random ID.
+func newMockServer() (*codepushtest.Server, *mockServerInfo, error) {
+	appID := cmdutil.ResolveFlag(cmd.AppID, "CODEPUSH_APP_ID")
+	if appID != "" {
+		if _, err := uuid.Parse(appID); err != nil {
+			return nil, nil, codepush.Invalid(fmt.Errorf("invalid app ID %q: must be a valid UUID", appID))
+		}
+	}
+	if mockProcessingPolls < 0 {
+		return nil, nil, codepush.Invalid(fmt.Errorf("--processing-polls must not be negative, got %d", mockProcessingPolls))
+	}
+
+	server := codepushtest.NewServer()
+	server.Token = mockToken
+	if len(mockScopes) > 0 {
+		server.Scopes = mockScopes
+	}
+	server.ProcessingPolls = mockProcessingPolls
+	app := server.AddApp(codepush.App{ID: appID, Name: "Mock App", Platform: mockPlatform, WorkspaceSlug: mockWorkspace})
+
+	info := &mockServerInfo{AppID: app.ID}
+	for _, name := range mockDeployments {
+		d, err := server.AddDeployment(app.ID, name)
+		if err != nil {
+			return nil, nil, codepush.Invalid(fmt.Errorf("--deployment: %w", err))
+		}
+		info.Deployments = append(info.Deployments, d)
+	}
+	return server, info, nil
+}
+
+// runMockServer serves the mock API on listener until ctx is done.
+func runMockServer(ctx context.Context, listener net.Listener, server *codepushtest.Server, info *mockServerInfo, out *output.Writer) error {
+	if cmd.JSONOutput {
+		if err := cmdutil.OutputResult(info); err != nil {
+			return err
+		}
+	} else {
+		out.Success("Mock API listening on %s", info.ServerURL)
+		pairs := []output.KeyValue{
+			{Key: "Server URL", Value: info.ServerURL},
+			{Key: "App ID", Value: info.AppID},
+		}
+		for _, d := range info.Deployments {
+			pairs = append(pairs, output.KeyValue{Key: d.Name, Value: fmt.Sprintf("%s (key %s)", d.ID, d.Key)})
+		}
+		out.Result(pairs)
+		out.Info("Set CODEPUSH_SERVER_URL=%s and CODEPUSH_APP_ID=%s to use it (Ctrl-C to stop)", info.ServerURL, info.AppID)
+	}
+
+	if err := serveUntilDone(ctx, listener, server.Handler()); err != nil {
+		return err
+	}
+	out.Info("Mock API stopped")
+	return nil
+}
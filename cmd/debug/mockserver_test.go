@@ -0,0 +1,70 @@
+package debug
+
+import (
+	"bytes"
+	"context"
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/bitrise-io/bitrise-plugins-codepush-cli/cmd"
+	"github.com/bitrise-io/bitrise-plugins-codepush-cli/internal/codepush"
+	"github.com/bitrise-io/bitrise-plugins-codepush-cli/internal/codepushtest"
+	"github.com/bitrise-io/bitrise-plugins-codepush-cli/internal/output"
+)
+
+func TestNewMockServer(t *testing.T) {
+	t.Run("seeds the app and deployments", func(t *testing.T) {
+		t.Setenv("CODEPUSH_APP_ID", "00000000-0000-0000-0000-000000000001")
+		_, info, err := newMockServer()
+		require.NoError(t, err)
+		assert.Equal(t, "00000000-0000-0000-0000-000000000001", info.AppID)
+		require.Len(t, info.Deployments, 2)
+		assert.Equal(t, "Staging", info.Deployments[0].Name)
+		assert.Equal(t, "Production", info.Deployments[1].Name)
+	})
+
+	t.Run("rejects an invalid app ID", func(t *testing.T) {
+		old := cmd.AppID
+		cmd.AppID = "not-a-uuid"
+		t.Cleanup(func() { cmd.AppID = old })
+
+		_, _, err := newMockServer()
+		var validationErr *codepush.ValidationError
+		assert.ErrorAs(t, err, &validationErr)
+	})
+
+	t.Run("rejects duplicate deployments", func(t *testing.T) {
+		old := mockDeployments
+		mockDeployments = []string{"Staging", "Staging"}
+		t.Cleanup(func() { mockDeployments = old })
+
+		_, _, err := newMockServer()
+		assert.ErrorContains(t, err, `deployment "Staging" already exists`)
+	})
+}
+
+func TestRunMockServer(t *testing.T) {
+	server, info, err := newMockServer()
+	require.NoError(t, err)
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	info.ServerURL = "http://" + listener.Addr().String()
+
+	var buf bytes.Buffer
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- runMockServer(ctx, listener, server, info, output.NewTest(&buf)) }()
+
+	client := codepush.NewHTTPClient(info.ServerURL+codepushtest.APIPath, "any-token", "test")
+	deployments, err := client.ListDeployments(context.Background(), info.AppID)
+	require.NoError(t, err)
+	assert.Len(t, deployments, 2)
+
+	cancel()
+	require.NoError(t, <-done)
+	assert.Contains(t, buf.String(), info.ServerURL)
+	assert.Contains(t, buf.String(), "CODEPUSH_APP_ID="+info.AppID)
+}
@@ -0,0 +1,125 @@
+package debug
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/bitrise-io/bitrise-plugins-codepush-cli/cmd"
+	"github.com/bitrise-io/bitrise-plugins-codepush-cli/internal/auth"
+	"github.com/bitrise-io/bitrise-plugins-codepush-cli/internal/cmdutil"
+	"github.com/bitrise-io/bitrise-plugins-codepush-cli/internal/codepush"
+	"github.com/bitrise-io/bitrise-plugins-codepush-cli/internal/output"
+)
+
+var pingSkipToken bool
+
+var pingCmd = &cobra.Command{
+	Use:   "ping",
+	Short: "Check connectivity to the Release Management API",
+	Long: `Check that the Release Management API is reachable and healthy.
+
+Resolves DNS, establishes the TLS connection, sends a request to the API,
+and reports the latency of each phase. When an API token is configured it
+is validated as well (skip with --skip-token).
+
+Exits non-zero when the API is unreachable, unhealthy, or the token is
+rejected, making it a cheap first step in CI before bundling.`,
+	GroupID: cmd.GroupDebug,
+	Args:    cobra.NoArgs,
+	RunE: func(c *cobra.Command, args []string) error {
+		out := cmd.Out
+
+		serverURL := cmdutil.ResolveServerURL(cmd.ServerURL, out)
+		client := cmd.NewClient(cmdutil.ResolveAPIURL(cmd.APIURL, cmd.ServerURL, out), "")
+
+		var result *codepush.PingResult
+		err := out.Indeterminate("Pinging "+client.BaseURL, func() error {
+			var pingErr error
+			result, pingErr = client.Ping(c.Context())
+			return pingErr
+		})
+		if err != nil {
+			return fmt.Errorf("ping failed: %w", err)
+		}
+
+		user, err := validatePingToken(c.Context(), serverURL, out)
+		if err != nil {
+			return err
+		}
+
+		if cmd.JSONOutput {
+			return cmdutil.OutputResult(struct {
+				*codepush.PingResult
+				User string `json:"user,omitempty"`
+			}{PingResult: result, User: user})
+		}
+
+		out.Success("API reachable (HTTP %d)", result.StatusCode)
+		out.Result(pingResultPairs(result, user))
+		return nil
+	},
+}
+
+func init() {
+	pingCmd.Flags().BoolVar(&pingSkipToken, "skip-token", false, "skip API token validation")
+	cmd.RootCmd.AddCommand(pingCmd)
+}
+
+// validatePingToken validates the configured token, if any, and returns the
+// authenticated username. Returns an empty username when validation is
+// skipped or no token is configured.
+func validatePingToken(ctx context.Context, serverURL string, out *output.Writer) (string, error) {
+	if pingSkipToken {
+		return "", nil
+	}
+
+	token := cmdutil.ResolveToken(ctx, out, cmd.Relogin)
+	if token == "" {
+		out.Info("No API token configured, skipping token validation")
+		return "", nil
+	}
+
+	var user *auth.UserInfo
+	err := out.Indeterminate("Validating token", func() error {
+		var valErr error
+		user, valErr = auth.ValidateToken(token, serverURL, cmd.HTTPClient())
+		return valErr
+	})
+	if err != nil {
+		return "", fmt.Errorf("token validation failed: %w", err)
+	}
+
+	return user.Username, nil
+}
+
+func pingResultPairs(r *codepush.PingResult, user string) []output.KeyValue {
+	pairs := []output.KeyValue{
+		{Key: "URL", Value: r.URL},
+		{Key: "DNS", Value: formatLatency(r.DNS)},
+		{Key: "Connect", Value: formatLatency(r.Connect)},
+	}
+	if r.TLSVersion != "" {
+		pairs = append(pairs, output.KeyValue{Key: "TLS", Value: fmt.Sprintf("%s (%s)", formatLatency(r.TLS), r.TLSVersion)})
+	}
+	pairs = append(pairs,
+		output.KeyValue{Key: "First byte", Value: formatLatency(r.FirstByte)},
+		output.KeyValue{Key: "Total", Value: formatLatency(r.Total)},
+	)
+	if r.APIVersion != "" {
+		pairs = append(pairs, output.KeyValue{Key: "API version", Value: r.APIVersion})
+	}
+	if r.Region != "" {
+		pairs = append(pairs, output.KeyValue{Key: "Region", Value: r.Region})
+	}
+	if user != "" {
+		pairs = append(pairs, output.KeyValue{Key: "Token user", Value: user})
+	}
+	return pairs
+}
+
+func formatLatency(d time.Duration) string {
+	return d.Round(time.Millisecond).String()
+}
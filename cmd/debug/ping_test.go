@@ -0,0 +1,74 @@
+package debug
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/bitrise-io/bitrise-plugins-codepush-cli/internal/codepush"
+)
+
+func TestPingCommand(t *testing.T) {
+	old := pingSkipToken
+	pingSkipToken = true
+	t.Cleanup(func() { pingSkipToken = old })
+	pingCmd.SetContext(context.Background())
+
+	t.Run("succeeds when API is reachable", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, "/release-management/v1/", r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+		}))
+		defer server.Close()
+		t.Setenv("CODEPUSH_SERVER_URL", server.URL)
+
+		require.NoError(t, pingCmd.RunE(pingCmd, nil))
+	})
+
+	t.Run("fails when API returns server error", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}))
+		defer server.Close()
+		t.Setenv("CODEPUSH_SERVER_URL", server.URL)
+
+		err := pingCmd.RunE(pingCmd, nil)
+		require.Error(t, err)
+		assert.ErrorContains(t, err, "ping failed")
+	})
+}
+
+func TestPingResultPairs(t *testing.T) {
+	t.Run("omits optional fields when empty", func(t *testing.T) {
+		pairs := pingResultPairs(&codepush.PingResult{URL: "http://x"}, "")
+		keys := make([]string, len(pairs))
+		for i, p := range pairs {
+			keys[i] = p.Key
+		}
+		assert.Equal(t, []string{"URL", "DNS", "Connect", "First byte", "Total"}, keys)
+	})
+
+	t.Run("includes TLS, API metadata, and user when present", func(t *testing.T) {
+		pairs := pingResultPairs(&codepush.PingResult{
+			URL:        "https://x",
+			TLS:        12 * time.Millisecond,
+			TLSVersion: "TLS 1.3",
+			APIVersion: "2.0",
+			Region:     "us-east-1",
+		}, "alice")
+
+		values := make(map[string]string, len(pairs))
+		for _, p := range pairs {
+			values[p.Key] = p.Value
+		}
+		assert.Equal(t, "12ms (TLS 1.3)", values["TLS"])
+		assert.Equal(t, "2.0", values["API version"])
+		assert.Equal(t, "us-east-1", values["Region"])
+		assert.Equal(t, "alice", values["Token user"])
+	})
+}
@@ -0,0 +1,198 @@
+package debug
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"strconv"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/bitrise-io/bitrise-plugins-codepush-cli/cmd"
+	"github.com/bitrise-io/bitrise-plugins-codepush-cli/internal/acquisition"
+	"github.com/bitrise-io/bitrise-plugins-codepush-cli/internal/cmdutil"
+	"github.com/bitrise-io/bitrise-plugins-codepush-cli/internal/codepush"
+	"github.com/bitrise-io/bitrise-plugins-codepush-cli/internal/output"
+	"github.com/bitrise-io/bitrise-plugins-codepush-cli/internal/zip"
+)
+
+var (
+	serveHost        string
+	servePort        int
+	serveLabel       string
+	serveAppVersion  string
+	serveDescription string
+	serveMandatory   bool
+)
+
+var serveCmd = &cobra.Command{
+	Use:   "serve <bundle-dir>",
+	Short: "Serve a bundle as a local CodePush update",
+	Long: `Serve a bundled output over a local HTTP server that emulates the CodePush
+acquisition API, so an app can download and install it before anything is
+uploaded.
+
+Point the app's CodePush server URL (CodePushServerURL in Info.plist,
+CodePushServerUrl in strings.xml) at one of the printed URLs. Every update
+check the app makes is offered the bundle, unless the app already runs it
+or --app-version targets another binary version. Update checks, downloads,
+and status reports are logged as they arrive.
+
+Press Ctrl-C to stop the server.`,
+	Example: `  codepush bundle --platform ios
+  codepush serve ./CodePush --app-version 1.2.0`,
+	GroupID: cmd.GroupDebug,
+	Args:    cobra.ExactArgs(1),
+	RunE: func(c *cobra.Command, args []string) error {
+		out := cmd.Out
+
+		release, cleanup, err := prepareServeRelease(args[0])
+		if err != nil {
+			return err
+		}
+		defer cleanup()
+
+		listener, err := net.Listen("tcp", net.JoinHostPort(serveHost, strconv.Itoa(servePort)))
+		if err != nil {
+			return fmt.Errorf("listening on port %d: %w", servePort, err)
+		}
+
+		ctx, stop := signal.NotifyContext(c.Context(), os.Interrupt)
+		defer stop()
+		return runServe(ctx, listener, release, out)
+	},
+}
+
+func init() {
+	serveCmd.Flags().StringVar(&serveHost, "host", "0.0.0.0", "address to listen on")
+	serveCmd.Flags().IntVar(&servePort, "port", 3000, "port to listen on")
+	serveCmd.Flags().StringVar(&serveLabel, "label", "preview", "release label reported to the app")
+	serveCmd.Flags().StringVar(&serveAppVersion, "app-version", "", "binary version the update targets (default: any)")
+	serveCmd.Flags().StringVar(&serveDescription, "description", "", "release description reported to the app")
+	serveCmd.Flags().BoolVar(&serveMandatory, "mandatory", false, "offer the update as mandatory")
+	cmd.RootCmd.AddCommand(serveCmd)
+}
+
+// prepareServeRelease zips bundleDir and describes it as the release to
+// serve. cleanup removes the zip.
+func prepareServeRelease(bundleDir string) (acquisition.Release, func(), error) {
+	info, err := os.Stat(bundleDir)
+	if err != nil {
+		return acquisition.Release{}, nil, codepush.Invalid(fmt.Errorf("bundle directory: %w", err))
+	}
+	if !info.IsDir() {
+		return acquisition.Release{}, nil, codepush.Invalid(fmt.Errorf("%s is not a directory", bundleDir))
+	}
+
+	hash, err := codepush.ContentHash(bundleDir)
+	if err != nil {
+		return acquisition.Release{}, nil, fmt.Errorf("hashing bundle: %w", err)
+	}
+
+	zipPath, err := zip.Directory(cmd.Workspace, bundleDir)
+	if err != nil {
+		return acquisition.Release{}, nil, fmt.Errorf("packaging bundle: %w", err)
+	}
+	cleanup := func() { cmd.Workspace.Remove(zipPath) }
+
+	zipInfo, err := os.Stat(zipPath)
+	if err != nil {
+		cleanup()
+		return acquisition.Release{}, nil, fmt.Errorf("reading package: %w", err)
+	}
+
+	return acquisition.Release{
+		Label:       serveLabel,
+		Description: serveDescription,
+		Mandatory:   serveMandatory,
+		AppVersion:  serveAppVersion,
+		PackageHash: hash,
+		PackagePath: zipPath,
+		PackageSize: zipInfo.Size(),
+	}, cleanup, nil
+}
+
+// runServe serves release on listener until ctx is done.
+func runServe(ctx context.Context, listener net.Listener, release acquisition.Release, out *output.Writer) error {
+	server := acquisition.NewServer(release)
+	server.OnEvent = func(e acquisition.Event) { out.Info("%s", describeEvent(e)) }
+
+	port := listener.Addr().(*net.TCPAddr).Port
+	out.Success("Serving %s (%s) on port %d", release.Label, cmdutil.FormatBytes(release.PackageSize), port)
+	pairs := []output.KeyValue{{Key: "Package hash", Value: release.PackageHash}}
+	for _, addr := range serveAddresses(listener.Addr().(*net.TCPAddr)) {
+		pairs = append(pairs, output.KeyValue{Key: "Server URL", Value: fmt.Sprintf("http://%s", net.JoinHostPort(addr, strconv.Itoa(port)))})
+	}
+	out.Result(pairs)
+	out.Info("Set the app's CodePush server URL to one of the URLs above (Ctrl-C to stop)")
+
+	if err := serveUntilDone(ctx, listener, server.Handler()); err != nil {
+		return err
+	}
+	out.Info("Server stopped")
+	return nil
+}
+
+// serveUntilDone serves handler on listener until ctx is done, then shuts
+// the server down gracefully.
+func serveUntilDone(ctx context.Context, listener net.Listener, handler http.Handler) error {
+	httpServer := &http.Server{Handler: handler, ReadHeaderTimeout: 10 * time.Second}
+	errCh := make(chan error, 1)
+	go func() { errCh <- httpServer.Serve(listener) }()
+
+	select {
+	case err := <-errCh:
+		return fmt.Errorf("serving: %w", err)
+	case <-ctx.Done():
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := httpServer.Shutdown(shutdownCtx); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return fmt.Errorf("stopping server: %w", err)
+	}
+	return nil
+}
+
+// serveAddresses returns the addresses an app can reach the server at:
+// the listening address itself, or every IPv4 address of the machine when
+// listening on all interfaces.
+func serveAddresses(addr *net.TCPAddr) []string {
+	if !addr.IP.IsUnspecified() {
+		return []string{addr.IP.String()}
+	}
+	addrs := []string{"localhost"}
+	ifaceAddrs, err := net.InterfaceAddrs()
+	if err != nil {
+		return addrs
+	}
+	for _, a := range ifaceAddrs {
+		ipNet, ok := a.(*net.IPNet)
+		if !ok || ipNet.IP.IsLoopback() || ipNet.IP.To4() == nil {
+			continue
+		}
+		addrs = append(addrs, ipNet.IP.String())
+	}
+	return addrs
+}
+
+func describeEvent(e acquisition.Event) string {
+	switch e.Kind {
+	case "check":
+		if e.Available {
+			return fmt.Sprintf("Update check from app version %s: offered the update", e.AppVersion)
+		}
+		return fmt.Sprintf("Update check from app version %s: up to date", e.AppVersion)
+	case "download":
+		return "Package downloaded"
+	case "report_download":
+		return fmt.Sprintf("Download reported for %s", e.Label)
+	default:
+		return fmt.Sprintf("Deploy reported for app version %s: %s", e.AppVersion, e.Status)
+	}
+}
@@ -0,0 +1,76 @@
+package debug
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/bitrise-io/bitrise-plugins-codepush-cli/internal/acquisition"
+	"github.com/bitrise-io/bitrise-plugins-codepush-cli/internal/codepush"
+	"github.com/bitrise-io/bitrise-plugins-codepush-cli/internal/output"
+)
+
+func TestPrepareServeRelease(t *testing.T) {
+	t.Run("packages the bundle", func(t *testing.T) {
+		dir := filepath.Join(t.TempDir(), "bundle")
+		require.NoError(t, os.MkdirAll(dir, 0o755))
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "main.jsbundle"), []byte("console.log(1)"), 0o644))
+
+		release, cleanup, err := prepareServeRelease(dir)
+		require.NoError(t, err)
+
+		hash, err := codepush.ContentHash(dir)
+		require.NoError(t, err)
+		assert.Equal(t, hash, release.PackageHash)
+		assert.Equal(t, "preview", release.Label)
+		assert.FileExists(t, release.PackagePath)
+		assert.Positive(t, release.PackageSize)
+
+		cleanup()
+		assert.NoFileExists(t, release.PackagePath)
+	})
+
+	t.Run("rejects a missing directory", func(t *testing.T) {
+		_, _, err := prepareServeRelease(filepath.Join(t.TempDir(), "missing"))
+		var validationErr *codepush.ValidationError
+		assert.ErrorAs(t, err, &validationErr)
+	})
+}
+
+func TestRunServe(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	release := acquisition.Release{Label: "preview", PackageHash: "abc123", PackagePath: filepath.Join(t.TempDir(), "package.zip")}
+	require.NoError(t, os.WriteFile(release.PackagePath, []byte("zip"), 0o644))
+
+	var buf bytes.Buffer
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- runServe(ctx, listener, release, output.NewTest(&buf)) }()
+
+	resp, err := http.Get("http://" + listener.Addr().String() + acquisition.UpdateCheckPath + "?app_version=1.0.0")
+	require.NoError(t, err)
+	var body struct {
+		UpdateInfo struct {
+			IsAvailable bool `json:"is_available"`
+		} `json:"update_info"`
+	}
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+	resp.Body.Close()
+	assert.True(t, body.UpdateInfo.IsAvailable)
+
+	cancel()
+	require.NoError(t, <-done)
+	assert.Contains(t, buf.String(), "http://"+listener.Addr().String())
+	assert.Contains(t, buf.String(), "Update check from app version 1.0.0: offered the update")
+	assert.Contains(t, buf.String(), "Server stopped")
+}
@@ -0,0 +1,100 @@
+package deployment
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/bitrise-io/bitrise-plugins-codepush-cli/cmd"
+	"github.com/bitrise-io/bitrise-plugins-codepush-cli/internal/cmdutil"
+	"github.com/bitrise-io/bitrise-plugins-codepush-cli/internal/codepush"
+)
+
+var (
+	auditFormat string
+	auditOutput string
+)
+
+var auditCmd = &cobra.Command{
+	Use:   "audit [deployment]",
+	Short: "Export the audit trail of a deployment",
+	Long: `Export who did what in a deployment: every release with its creator and
+metadata, merged with the patches, rollbacks, and other events the server
+records, oldest first.
+
+The trail is written as CSV (default) or JSON (--format json or --json) to
+stdout, or to the --output file. Servers that do not record events only
+yield the releases; a warning says so.`,
+	GroupID: cmd.GroupDeployment,
+	Args:    cobra.MaximumNArgs(1),
+	RunE: func(c *cobra.Command, args []string) error {
+		out := cmd.Out
+
+		format := auditFormat
+		if cmd.JSONOutput {
+			format = codepush.AuditFormatJSON
+		}
+		if format != codepush.AuditFormatCSV && format != codepush.AuditFormatJSON {
+			return codepush.Invalid(fmt.Errorf("invalid format %q: must be csv or json", auditFormat))
+		}
+
+		appID, token, err := cmdutil.RequireCredentials(c.Context(), cmd.AppID, out, cmd.Relogin, cmd.FirstRun)
+		if err != nil {
+			return err
+		}
+
+		client := cmd.NewClient(cmdutil.ResolveAPIURL(cmd.APIURL, cmd.ServerURL, out), token)
+
+		var argValue string
+		if len(args) > 0 {
+			argValue = args[0]
+		}
+
+		deploymentID, err := cmdutil.ResolveDeploymentInteractive(c.Context(), client, appID, argValue, "CODEPUSH_DEPLOYMENT", out)
+		if err != nil {
+			return err
+		}
+
+		var trail *codepush.AuditTrail
+		err = out.Indeterminate("Fetching release history and events", func() error {
+			var fetchErr error
+			trail, fetchErr = codepush.BuildAuditTrail(c.Context(), client, appID, deploymentID)
+			return fetchErr
+		})
+		if err != nil {
+			return err
+		}
+		if !trail.EventsAvailable {
+			out.Warning("the server does not record deployment events: the audit trail only lists releases, not patches or rollbacks")
+		}
+
+		var buf bytes.Buffer
+		if format == codepush.AuditFormatJSON {
+			err = cmdutil.WriteJSON(&buf, trail)
+		} else {
+			err = codepush.WriteAuditCSV(&buf, trail)
+		}
+		if err != nil {
+			return err
+		}
+
+		if auditOutput == "" {
+			_, err := os.Stdout.Write(buf.Bytes())
+			return err
+		}
+		if err := os.WriteFile(auditOutput, buf.Bytes(), 0o644); err != nil {
+			return fmt.Errorf("writing audit trail: %w", err)
+		}
+		out.Success("Audit trail of %s written to %s (%d entries)", trail.Deployment, auditOutput, len(trail.Entries))
+		return nil
+	},
+}
+
+func init() {
+	auditCmd.Flags().StringVar(&auditFormat, "format", codepush.AuditFormatCSV, "output format: csv or json")
+	auditCmd.Flags().StringVarP(&auditOutput, "output", "o", "", "write to this file instead of stdout")
+	auditCmd.ValidArgsFunction = cmd.CompleteDeploymentArg
+	cmd.RootCmd.AddCommand(auditCmd)
+}
@@ -0,0 +1,114 @@
+package deployment
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/bitrise-io/bitrise-plugins-codepush-cli/cmd"
+	"github.com/bitrise-io/bitrise-plugins-codepush-cli/internal/cmdutil"
+	"github.com/bitrise-io/bitrise-plugins-codepush-cli/internal/codepush"
+	"github.com/bitrise-io/bitrise-plugins-codepush-cli/internal/output"
+)
+
+var compareCmd = &cobra.Command{
+	Use:   "compare <source> <target>",
+	Short: "Compare the live releases of two deployments",
+	Long: `Compare two deployments, such as Staging and Production, side by side.
+
+For every app version, the release each deployment serves (its newest
+release that is not disabled) is shown with its state:
+
+  in sync        both serve the same content
+  not promoted   the release the source serves is not in the target
+  diverged       the target serves other content, but already received the
+                 source's release earlier
+  target only    only the target serves the app version
+
+Releases are matched by package hash, so a promoted release matches its
+source whatever its label. Releases of the source whose content is not in the
+target are listed, followed by the promote commands that bring the target
+up to date. Nothing is changed.`,
+	Example: `  codepush deployment compare Staging Production
+  codepush deployment compare Staging Production --json`,
+	Args: cobra.ExactArgs(2),
+	RunE: func(c *cobra.Command, args []string) error {
+		out := cmd.Out
+
+		appID, token, err := cmdutil.RequireCredentials(c.Context(), cmd.AppID, out, cmd.Relogin, cmd.FirstRun)
+		if err != nil {
+			return err
+		}
+
+		client := cmd.NewClient(cmdutil.ResolveAPIURL(cmd.APIURL, cmd.ServerURL, out), token)
+
+		result, err := codepush.CompareDeployments(c.Context(), client, appID, args[0], args[1], out)
+		if err != nil {
+			return err
+		}
+
+		if cmd.JSONOutput {
+			return cmdutil.OutputResult(result)
+		}
+		printComparison(out, result)
+		return nil
+	},
+	ValidArgsFunction: func(c *cobra.Command, args []string, toComplete string) ([]cobra.Completion, cobra.ShellCompDirective) {
+		if len(args) > 1 {
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		}
+		return cmd.CompleteDeployments(c, args, toComplete)
+	},
+}
+
+func init() {
+	deploymentCmd.AddCommand(compareCmd)
+}
+
+// printComparison prints the app version table, the releases that are not
+// promoted, and the promote commands.
+func printComparison(out *output.Writer, r *codepush.DeploymentComparison) {
+	if len(r.AppVersions) == 0 {
+		out.Info("Neither deployment has releases.")
+		return
+	}
+
+	rows := make([][]string, len(r.AppVersions))
+	for i, v := range r.AppVersions {
+		rows[i] = []string{v.AppVersion, comparedRelease(v.Source), comparedRelease(v.Target), strings.ReplaceAll(v.State, "_", " ")}
+	}
+	out.Table([]string{"APP VERSION", strings.ToUpper(r.Source), strings.ToUpper(r.Target), "STATE"}, rows)
+
+	if len(r.NotPromoted) == 0 {
+		out.Success("Every release of %s is in %s", r.Source, r.Target)
+		return
+	}
+
+	out.Warning("%d release(s) of %s are not in %s:", len(r.NotPromoted), r.Source, r.Target)
+	promoteRows := make([][]string, len(r.NotPromoted))
+	for i, u := range r.NotPromoted {
+		promoteRows[i] = []string{u.Label, u.AppVersion, u.CreatedAt, cmdutil.Truncate(u.Description, 30)}
+	}
+	out.Table([]string{"LABEL", "APP VERSION", "CREATED", "DESCRIPTION"}, promoteRows)
+
+	if len(r.Commands) > 0 {
+		out.Info("To promote the releases %s serves to %s, run:", r.Source, r.Target)
+		for _, command := range r.Commands {
+			out.Println("  %s", command)
+		}
+	}
+}
+
+// comparedRelease renders the release a deployment serves to an app
+// version, or "-" when it serves none.
+func comparedRelease(u *codepush.Update) string {
+	if u == nil {
+		return "-"
+	}
+	s := u.Label
+	if u.Rollout < 100 {
+		s += fmt.Sprintf(" (%.0f%%)", u.Rollout)
+	}
+	return s
+}
@@ -1,8 +1,13 @@
 package deployment
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"slices"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/spf13/cobra"
 
@@ -17,9 +22,25 @@ var (
 	removeYes            bool
 	historyMax           int
 	addKey               string
+	addCloneFrom         string
+	addLabels            []string
 	listDisplayKeys      bool
 	historyDisplayAuthor bool
+	historyAll           bool
+	historyAllReleases   bool
+	historyParallel      int
+	historyAppVersion    string
+	historyMandatoryOnly bool
+	historySince         string
+	historyCreatedBy     string
+	historyLabelRange    string
+	historyTag           string
+	historySort          string
 	clearYes             bool
+	clearParallel        int
+	renameForce          bool
+	removeForce          bool
+	activeDays           int
 )
 
 var deploymentCmd = &cobra.Command{
@@ -35,19 +56,19 @@ var listCmd = &cobra.Command{
 	RunE: func(c *cobra.Command, args []string) error {
 		out := cmd.Out
 
-		appID, token, err := cmdutil.RequireCredentials(cmd.AppID, out)
+		appID, token, err := cmdutil.RequireCredentials(c.Context(), cmd.AppID, out, cmd.Relogin, cmd.FirstRun)
 		if err != nil {
 			return err
 		}
 
-		client := codepush.NewHTTPClient(cmdutil.APIURL(cmdutil.ResolveServerURL(cmd.ServerURL, out)), token, cmd.Version)
+		client := cmd.NewClient(cmdutil.ResolveAPIURL(cmd.APIURL, cmd.ServerURL, out), token)
 		deployments, err := client.ListDeployments(c.Context(), appID)
 		if err != nil {
 			return fmt.Errorf("listing deployments: %w", err)
 		}
 
 		if cmd.JSONOutput {
-			return cmdutil.OutputJSON(deployments)
+			return cmdutil.OutputResult(deployments)
 		}
 
 		if len(deployments) == 0 {
@@ -76,11 +97,25 @@ var listCmd = &cobra.Command{
 var addCmd = &cobra.Command{
 	Use:   "add [name]",
 	Short: "Create a new deployment",
-	Args:  cobra.MaximumNArgs(1),
+	Long: `Create a new deployment.
+
+With --clone-from, the new deployment is seeded with the latest release of
+another deployment, or with the releases given by --labels, copied with the
+promote API.
+
+Examples:
+  codepush deployment add QA-Alice
+  codepush deployment add QA-Alice --clone-from Staging
+  codepush deployment add QA-Alice --clone-from Staging --labels v3,v5`,
+	Args: cobra.MaximumNArgs(1),
 	RunE: func(c *cobra.Command, args []string) error {
 		out := cmd.Out
 
-		appID, token, err := cmdutil.RequireCredentials(cmd.AppID, out)
+		if len(addLabels) > 0 && addCloneFrom == "" {
+			return codepush.Invalid(errors.New("--labels requires --clone-from"))
+		}
+
+		appID, token, err := cmdutil.RequireCredentials(c.Context(), cmd.AppID, out, cmd.Relogin, cmd.FirstRun)
 		if err != nil {
 			return err
 		}
@@ -94,14 +129,20 @@ var addCmd = &cobra.Command{
 			return err
 		}
 
-		client := codepush.NewHTTPClient(cmdutil.APIURL(cmdutil.ResolveServerURL(cmd.ServerURL, out)), token, cmd.Version)
-		dep, err := client.CreateDeployment(c.Context(), appID, codepush.CreateDeploymentRequest{Name: name, Key: addKey})
+		client := cmd.NewClient(cmdutil.ResolveAPIURL(cmd.APIURL, cmd.ServerURL, out), token)
+		req := codepush.CreateDeploymentRequest{Name: name, Key: addKey}
+		if addCloneFrom != "" {
+			return cloneDeployment(c.Context(), client, appID, req, out)
+		}
+
+		dep, err := client.CreateDeployment(c.Context(), appID, req)
 		if err != nil {
 			return fmt.Errorf("creating deployment: %w", err)
 		}
 
+		cmdutil.ExportStepOutputs("codepush-deployment-summary.json", dep, deploymentStepOutputs(dep), out)
 		if cmd.JSONOutput {
-			return cmdutil.OutputJSON(dep)
+			return cmdutil.OutputResult(dep)
 		}
 
 		out.Success("Deployment %q created (ID: %s)", dep.Name, dep.ID)
@@ -116,12 +157,12 @@ var infoCmd = &cobra.Command{
 	RunE: func(c *cobra.Command, args []string) error {
 		out := cmd.Out
 
-		appID, token, err := cmdutil.RequireCredentials(cmd.AppID, out)
+		appID, token, err := cmdutil.RequireCredentials(c.Context(), cmd.AppID, out, cmd.Relogin, cmd.FirstRun)
 		if err != nil {
 			return err
 		}
 
-		client := codepush.NewHTTPClient(cmdutil.APIURL(cmdutil.ResolveServerURL(cmd.ServerURL, out)), token, cmd.Version)
+		client := cmd.NewClient(cmdutil.ResolveAPIURL(cmd.APIURL, cmd.ServerURL, out), token)
 
 		var argValue string
 		if len(args) > 0 {
@@ -139,7 +180,7 @@ var infoCmd = &cobra.Command{
 		}
 
 		if cmd.JSONOutput {
-			return cmdutil.OutputJSON(dep)
+			return cmdutil.OutputResult(dep)
 		}
 
 		out.Step("Deployment: %s", dep.Name)
@@ -177,23 +218,31 @@ var renameCmd = &cobra.Command{
 	RunE: func(c *cobra.Command, args []string) error {
 		out := cmd.Out
 
-		appID, token, err := cmdutil.RequireCredentials(cmd.AppID, out)
+		appID, token, err := cmdutil.RequireCredentials(c.Context(), cmd.AppID, out, cmd.Relogin, cmd.FirstRun)
 		if err != nil {
 			return err
 		}
 
-		client := codepush.NewHTTPClient(cmdutil.APIURL(cmdutil.ResolveServerURL(cmd.ServerURL, out)), token, cmd.Version)
+		client := cmd.NewClient(cmdutil.ResolveAPIURL(cmd.APIURL, cmd.ServerURL, out), token)
 
 		var argValue string
 		if len(args) > 0 {
 			argValue = args[0]
 		}
 
-		deploymentID, err := cmdutil.ResolveDeploymentInteractive(c.Context(), client, appID, argValue, "CODEPUSH_DEPLOYMENT", out)
+		deploymentID, err := cmdutil.ResolveDeploymentForWrite(c.Context(), client, appID, argValue, "CODEPUSH_DEPLOYMENT", out)
 		if err != nil {
 			return err
 		}
 
+		displayName := argValue
+		if displayName == "" {
+			displayName = deploymentID
+		}
+		if err := confirmNoActivePipelines(c.Context(), client, appID, deploymentID, displayName, renameForce, out); err != nil {
+			return err
+		}
+
 		newName, err := cmdutil.ResolveInputInteractive(renameName, "Enter new deployment name", "e.g. Staging, Production", out)
 		if err != nil {
 			return err
@@ -205,7 +254,7 @@ var renameCmd = &cobra.Command{
 		}
 
 		if cmd.JSONOutput {
-			return cmdutil.OutputJSON(dep)
+			return cmdutil.OutputResult(dep)
 		}
 
 		out.Success("Deployment renamed to %q", dep.Name)
@@ -220,19 +269,19 @@ var removeCmd = &cobra.Command{
 	RunE: func(c *cobra.Command, args []string) error {
 		out := cmd.Out
 
-		appID, token, err := cmdutil.RequireCredentials(cmd.AppID, out)
+		appID, token, err := cmdutil.RequireCredentials(c.Context(), cmd.AppID, out, cmd.Relogin, cmd.FirstRun)
 		if err != nil {
 			return err
 		}
 
-		client := codepush.NewHTTPClient(cmdutil.APIURL(cmdutil.ResolveServerURL(cmd.ServerURL, out)), token, cmd.Version)
+		client := cmd.NewClient(cmdutil.ResolveAPIURL(cmd.APIURL, cmd.ServerURL, out), token)
 
 		var argValue string
 		if len(args) > 0 {
 			argValue = args[0]
 		}
 
-		deploymentID, err := cmdutil.ResolveDeploymentInteractive(c.Context(), client, appID, argValue, "CODEPUSH_DEPLOYMENT", out)
+		deploymentID, err := cmdutil.ResolveDeploymentForWrite(c.Context(), client, appID, argValue, "CODEPUSH_DEPLOYMENT", out)
 		if err != nil {
 			return err
 		}
@@ -242,6 +291,10 @@ var removeCmd = &cobra.Command{
 			displayName = deploymentID
 		}
 
+		if err := confirmNoActivePipelines(c.Context(), client, appID, deploymentID, displayName, removeForce, out); err != nil {
+			return err
+		}
+
 		if err := out.ConfirmDestructive(
 			fmt.Sprintf("This will permanently delete deployment %q and all its releases", displayName),
 			removeYes,
@@ -254,7 +307,7 @@ var removeCmd = &cobra.Command{
 		}
 
 		if cmd.JSONOutput {
-			return cmdutil.OutputJSON(struct {
+			return cmdutil.OutputResult(struct {
 				Deleted string `json:"deleted"`
 			}{Deleted: deploymentID})
 		}
@@ -267,16 +320,46 @@ var removeCmd = &cobra.Command{
 var historyCmd = &cobra.Command{
 	Use:   "history [deployment]",
 	Short: "Show release history for a deployment",
-	Args:  cobra.MaximumNArgs(1),
+	Long: `Show the release history of a deployment, oldest first. Only the
+--limit newest releases are shown; pass --all to show every release.
+
+With --all-deployments, the releases of every deployment are fetched
+concurrently and merged into one timeline sorted by creation time. The
+LIVE column marks the release each deployment currently serves.
+
+Filter releases with --app-version, --mandatory-only, --since (a date such
+as 2024-06-01), --created-by (part of the author's username or email), and
+--label-range (v10..v20, v10.., or ..v20), and --tag (see package tag). Filters are applied before
+--limit, so --limit keeps the newest matching releases. --sort orders the
+result by creation time or package size, oldest or smallest first.`,
+	Args: cobra.MaximumNArgs(1),
 	RunE: func(c *cobra.Command, args []string) error {
 		out := cmd.Out
 
-		appID, token, err := cmdutil.RequireCredentials(cmd.AppID, out)
+		if historyAll && len(args) > 0 {
+			return errors.New("--all-deployments cannot be combined with a deployment argument")
+		}
+		if historyParallel < 1 {
+			return fmt.Errorf("--parallel must be at least 1, got %d", historyParallel)
+		}
+		if historyAllReleases {
+			historyMax = 0
+		}
+		filter, err := historyFilter()
+		if err != nil {
+			return codepush.Invalid(err)
+		}
+
+		appID, token, err := cmdutil.RequireCredentials(c.Context(), cmd.AppID, out, cmd.Relogin, cmd.FirstRun)
 		if err != nil {
 			return err
 		}
 
-		client := codepush.NewHTTPClient(cmdutil.APIURL(cmdutil.ResolveServerURL(cmd.ServerURL, out)), token, cmd.Version)
+		client := cmd.NewClient(cmdutil.ResolveAPIURL(cmd.APIURL, cmd.ServerURL, out), token)
+
+		if historyAll {
+			return showTimeline(c.Context(), client, appID, filter, out)
+		}
 
 		var argValue string
 		if len(args) > 0 {
@@ -293,12 +376,14 @@ var historyCmd = &cobra.Command{
 			return fmt.Errorf("listing updates: %w", err)
 		}
 
+		updates = slices.DeleteFunc(updates, func(u codepush.Update) bool { return !filter.Match(u) })
 		if historyMax > 0 && len(updates) > historyMax {
 			updates = updates[len(updates)-historyMax:]
 		}
+		slices.SortStableFunc(updates, filter.Compare)
 
 		if cmd.JSONOutput {
-			return cmdutil.OutputJSON(updates)
+			return cmdutil.OutputResult(updates)
 		}
 
 		if len(updates) == 0 {
@@ -310,22 +395,22 @@ var historyCmd = &cobra.Command{
 		if historyDisplayAuthor {
 			headers = append(headers, "AUTHOR")
 		}
+		tagged := slices.ContainsFunc(updates, func(u codepush.Update) bool { return len(u.Tags()) > 0 })
+		if tagged {
+			headers = append(headers, "TAGS")
+		}
 		rows := make([][]string, len(updates))
 		for i, u := range updates {
 			row := []string{
 				u.Label, u.AppVersion, strconv.FormatBool(u.Mandatory),
 				fmt.Sprintf("%.0f%%", u.Rollout), strconv.FormatBool(u.Disabled),
-				cmdutil.Truncate(u.Description, 30), u.CreatedAt,
+				cmdutil.Truncate(u.DescriptionText(), 30), u.CreatedAt,
 			}
 			if historyDisplayAuthor {
-				author := ""
-				if u.CreatedBy != nil {
-					author = u.CreatedBy.Username
-					if author == "" {
-						author = u.CreatedBy.Email
-					}
-				}
-				row = append(row, author)
+				row = append(row, updateAuthor(u))
+			}
+			if tagged {
+				row = append(row, strings.Join(u.Tags(), ", "))
 			}
 			rows[i] = row
 		}
@@ -335,30 +420,172 @@ var historyCmd = &cobra.Command{
 	},
 }
 
+// deploymentStepOutputs returns the Bitrise step outputs of a created
+// deployment.
+func deploymentStepOutputs(d *codepush.Deployment) cmdutil.StepOutputs {
+	return cmdutil.StepOutputs{DeploymentID: d.ID, DeploymentKey: d.Key}
+}
+
+// cloneDeployment creates a deployment seeded with releases of --clone-from.
+func cloneDeployment(ctx context.Context, client *codepush.HTTPClient, appID string, req codepush.CreateDeploymentRequest, out *output.Writer) error {
+	result, err := codepush.CloneDeployment(ctx, client, appID, req, addCloneFrom, addLabels, out)
+	if err != nil {
+		return err
+	}
+
+	cmdutil.ExportStepOutputs("codepush-deployment-summary.json", result, deploymentStepOutputs(result.Deployment), out)
+	if cmd.JSONOutput {
+		return cmdutil.OutputResult(result)
+	}
+
+	out.Success("Deployment %q created (ID: %s) from %s", result.Deployment.Name, result.Deployment.ID, addCloneFrom)
+	rows := make([][]string, len(result.Releases))
+	for i, r := range result.Releases {
+		label := r.Label
+		if r.Skipped {
+			label = "skipped (identical content)"
+		}
+		rows[i] = []string{r.SourceLabel, label}
+	}
+	out.Table([]string{"SOURCE LABEL", "LABEL"}, rows)
+	return nil
+}
+
+// historyFilter returns the filter set by the history flags.
+func historyFilter() (codepush.HistoryFilter, error) {
+	filter := codepush.HistoryFilter{
+		AppVersion:    historyAppVersion,
+		MandatoryOnly: historyMandatoryOnly,
+		CreatedBy:     historyCreatedBy,
+		Tag:           historyTag,
+		Sort:          historySort,
+	}
+	if err := codepush.ValidateHistorySort(historySort); err != nil {
+		return filter, err
+	}
+	if historySince != "" {
+		since, err := codepush.ParseSince(historySince)
+		if err != nil {
+			return filter, fmt.Errorf("--since: %w", err)
+		}
+		filter.Since = since
+	}
+	if historyLabelRange != "" {
+		from, to, err := codepush.ParseLabelRange(historyLabelRange)
+		if err != nil {
+			return filter, fmt.Errorf("--label-range: %w", err)
+		}
+		filter.LabelFrom, filter.LabelTo = from, to
+	}
+	return filter, nil
+}
+
+// showTimeline prints the merged release history of every deployment.
+func showTimeline(ctx context.Context, client *codepush.HTTPClient, appID string, filter codepush.HistoryFilter, out *output.Writer) error {
+	var timeline *codepush.Timeline
+	err := out.Indeterminate("Fetching release history of all deployments", func() error {
+		var fetchErr error
+		timeline, fetchErr = codepush.FetchTimeline(ctx, client, appID, historyParallel)
+		return fetchErr
+	})
+	if err != nil {
+		return err
+	}
+
+	timeline.Entries = slices.DeleteFunc(timeline.Entries, func(e codepush.TimelineEntry) bool { return !filter.Match(e.Update) })
+	if historyMax > 0 && len(timeline.Entries) > historyMax {
+		timeline.Entries = timeline.Entries[len(timeline.Entries)-historyMax:]
+	}
+	slices.SortStableFunc(timeline.Entries, func(a, b codepush.TimelineEntry) int { return filter.Compare(a.Update, b.Update) })
+
+	if cmd.JSONOutput {
+		if f := cmdutil.OutputFormat(); f == cmdutil.FormatCSV || f == cmdutil.FormatNDJSON {
+			// Row formats get a record per release; failures go to stderr.
+			for _, failure := range timeline.Failures {
+				out.Warning("%s: %s", failure.Deployment, failure.Error)
+			}
+			return cmdutil.OutputResult(timeline.Entries)
+		}
+		return cmdutil.OutputResult(timeline)
+	}
+
+	if len(timeline.Entries) == 0 {
+		out.Info("No releases found.")
+	} else {
+		headers := []string{"CREATED", "DEPLOYMENT", "LABEL", "APP VERSION", "ROLLOUT", "LIVE", "DISABLED", "DESCRIPTION"}
+		if historyDisplayAuthor {
+			headers = append(headers, "AUTHOR")
+		}
+		tagged := slices.ContainsFunc(timeline.Entries, func(e codepush.TimelineEntry) bool { return len(e.Tags()) > 0 })
+		if tagged {
+			headers = append(headers, "TAGS")
+		}
+		rows := make([][]string, len(timeline.Entries))
+		for i, e := range timeline.Entries {
+			live := ""
+			if e.Live {
+				live = "live"
+			}
+			row := []string{
+				e.CreatedAt, e.Deployment, e.Label, e.AppVersion,
+				fmt.Sprintf("%.0f%%", e.Rollout), live, strconv.FormatBool(e.Disabled),
+				cmdutil.Truncate(e.DescriptionText(), 30),
+			}
+			if historyDisplayAuthor {
+				row = append(row, updateAuthor(e.Update))
+			}
+			if tagged {
+				row = append(row, strings.Join(e.Tags(), ", "))
+			}
+			rows[i] = row
+		}
+		out.Table(headers, rows)
+	}
+
+	for _, f := range timeline.Failures {
+		out.Warning("%s: %s", f.Deployment, f.Error)
+	}
+	return nil
+}
+
+// updateAuthor returns who created an update, or "" if unknown.
+func updateAuthor(u codepush.Update) string {
+	if u.CreatedBy == nil {
+		return ""
+	}
+	if u.CreatedBy.Username != "" {
+		return u.CreatedBy.Username
+	}
+	return u.CreatedBy.Email
+}
+
 var clearCmd = &cobra.Command{
 	Use:   "clear [deployment]",
 	Short: "Delete all updates from a deployment",
 	Long: `Delete all updates (releases) from a deployment.
 
 This is a destructive operation that removes all release history.
-Requires --yes to confirm.`,
+Requires --yes to confirm.
+
+The releases are deleted in one request. On servers without the bulk
+delete endpoint, they are deleted one by one, --parallel at a time.`,
 	Args: cobra.MaximumNArgs(1),
 	RunE: func(c *cobra.Command, args []string) error {
 		out := cmd.Out
 
-		appID, token, err := cmdutil.RequireCredentials(cmd.AppID, out)
+		appID, token, err := cmdutil.RequireCredentials(c.Context(), cmd.AppID, out, cmd.Relogin, cmd.FirstRun)
 		if err != nil {
 			return err
 		}
 
-		client := codepush.NewHTTPClient(cmdutil.APIURL(cmdutil.ResolveServerURL(cmd.ServerURL, out)), token, cmd.Version)
+		client := cmd.NewClient(cmdutil.ResolveAPIURL(cmd.APIURL, cmd.ServerURL, out), token)
 
 		var argValue string
 		if len(args) > 0 {
 			argValue = args[0]
 		}
 
-		deploymentID, err := cmdutil.ResolveDeploymentInteractive(c.Context(), client, appID, argValue, "CODEPUSH_DEPLOYMENT", out)
+		deploymentID, err := cmdutil.ResolveDeploymentForWrite(c.Context(), client, appID, argValue, "CODEPUSH_DEPLOYMENT", out)
 		if err != nil {
 			return err
 		}
@@ -385,16 +612,13 @@ Requires --yes to confirm.`,
 			return nil
 		}
 
-		deleted := 0
-		for _, u := range updates {
-			if err := client.DeleteUpdate(c.Context(), appID, deploymentID, u.ID); err != nil {
-				return fmt.Errorf("deleting update %s: %w", u.Label, err)
-			}
-			deleted++
+		deleted, err := codepush.ClearDeployment(c.Context(), client, appID, deploymentID, updates, clearParallel, out)
+		if err != nil {
+			return err
 		}
 
 		if cmd.JSONOutput {
-			return cmdutil.OutputJSON(struct {
+			return cmdutil.OutputResult(struct {
 				Deployment string `json:"deployment"`
 				Deleted    int    `json:"deleted"`
 			}{Deployment: deploymentID, Deleted: deleted})
@@ -405,16 +629,73 @@ Requires --yes to confirm.`,
 	},
 }
 
+// confirmNoActivePipelines warns when the deployment received releases in the
+// last --active-days days, since CI pipelines may still push to it, and asks
+// for confirmation unless force is set. Failures to check are only warned
+// about so they never block the operation.
+func confirmNoActivePipelines(ctx context.Context, client codepush.Client, appID, deploymentID, displayName string, force bool, out *output.Writer) error {
+	if activeDays <= 0 {
+		return nil
+	}
+
+	recent, err := codepush.RecentReleases(ctx, client, appID, deploymentID, time.Duration(activeDays)*24*time.Hour, time.Now())
+	if err != nil {
+		out.Warning("could not check recent releases: %v", err)
+		return nil
+	}
+	if len(recent) == 0 {
+		return nil
+	}
+
+	const maxShown = 3
+	for _, r := range recent[:min(len(recent), maxShown)] {
+		out.Warning("deployment %q %s", displayName, r)
+	}
+	if len(recent) > maxShown {
+		out.Warning("... and %d more release(s)", len(recent)-maxShown)
+	}
+
+	return out.ConfirmWithFlag(
+		fmt.Sprintf("Deployment %q received %d release(s) in the last %d days; pipelines may still push to it", displayName, len(recent), activeDays),
+		"--force", force,
+	)
+}
+
 func init() {
 	cmd.RootCmd.AddGroup(&cobra.Group{ID: cmd.GroupDeployment, Title: "Deployment Management:"})
 
 	addCmd.Flags().StringVarP(&addKey, "key", "k", "", "custom deployment key (server assigns one if not specified)")
+	addCmd.Flags().StringVar(&addCloneFrom, "clone-from", "", "seed the new deployment with releases copied from this deployment")
+	addCmd.Flags().StringSliceVar(&addLabels, "labels", nil, "with --clone-from: release labels to copy (e.g. v3,v5; default: the latest release)")
+	_ = addCmd.RegisterFlagCompletionFunc("clone-from", cmd.CompleteDeployments)
+	_ = addCmd.RegisterFlagCompletionFunc("labels", cmd.CompleteLabels("clone-from"))
 	listCmd.Flags().BoolVarP(&listDisplayKeys, "display-keys", "k", false, "include the deployment key column in the list table")
 	renameCmd.Flags().StringVarP(&renameName, "name", "n", "", "new deployment name (required)")
 	removeCmd.Flags().BoolVarP(&removeYes, "yes", "y", false, "skip confirmation prompt")
+	renameCmd.Flags().BoolVar(&renameForce, "force", false, "proceed even if the deployment received releases recently")
+	removeCmd.Flags().BoolVar(&removeForce, "force", false, "proceed even if the deployment received releases recently")
+	for _, c := range []*cobra.Command{renameCmd, removeCmd} {
+		c.Flags().IntVar(&activeDays, "active-days", 7, "warn about releases received within this many days (0 disables the check)")
+	}
 	historyCmd.Flags().IntVarP(&historyMax, "limit", "n", 10, "maximum number of releases to show")
+	historyCmd.Flags().BoolVar(&historyAllReleases, "all", false, "show every release instead of the --limit newest")
+	historyCmd.MarkFlagsMutuallyExclusive("limit", "all")
 	historyCmd.Flags().BoolVarP(&historyDisplayAuthor, "display-author", "a", false, "include the author column in the history table")
+	historyCmd.Flags().BoolVar(&historyAll, "all-deployments", false, "merge the history of every deployment into one timeline")
+	historyCmd.Flags().StringVar(&historyAppVersion, "app-version", "", "only show releases targeting this app version")
+	historyCmd.Flags().BoolVar(&historyMandatoryOnly, "mandatory-only", false, "only show mandatory releases")
+	historyCmd.Flags().StringVar(&historySince, "since", "", "only show releases created on or after this date, e.g. 2024-06-01")
+	historyCmd.Flags().StringVar(&historyCreatedBy, "created-by", "", "only show releases whose author's username or email contains this text, e.g. alice@")
+	historyCmd.Flags().StringVar(&historyLabelRange, "label-range", "", "only show releases in this label range, e.g. v10..v20, v10.., or ..v20")
+	historyCmd.Flags().StringVar(&historyTag, "tag", "", "only show releases with this tag (see package tag)")
+	historyCmd.Flags().StringVar(&historySort, "sort", "", "sort releases by created or size, oldest or smallest first (default: server order)")
+	historyCmd.Flags().IntVar(&historyParallel, "parallel", codepush.DefaultOverviewParallelism, "maximum number of deployments fetched at once with --all-deployments")
 	clearCmd.Flags().BoolVarP(&clearYes, "yes", "y", false, "skip confirmation prompt")
+	clearCmd.Flags().IntVar(&clearParallel, "parallel", codepush.DefaultClearParallelism, "maximum number of releases deleted at once when the server has no bulk delete endpoint")
+
+	for _, c := range []*cobra.Command{infoCmd, renameCmd, removeCmd, historyCmd, clearCmd} {
+		c.ValidArgsFunction = cmd.CompleteDeploymentArg
+	}
 
 	deploymentCmd.AddCommand(listCmd, addCmd, infoCmd, renameCmd, removeCmd, historyCmd, clearCmd)
 	cmd.RootCmd.AddCommand(deploymentCmd)
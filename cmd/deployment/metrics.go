@@ -0,0 +1,88 @@
+package deployment
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/spf13/cobra"
+
+	"github.com/bitrise-io/bitrise-plugins-codepush-cli/cmd"
+	"github.com/bitrise-io/bitrise-plugins-codepush-cli/internal/cmdutil"
+	"github.com/bitrise-io/bitrise-plugins-codepush-cli/internal/codepush"
+)
+
+var metricsMax int
+
+var metricsCmd = &cobra.Command{
+	Use:   "metrics [deployment]",
+	Short: "Show install metrics per release",
+	Long: `Show install and adoption metrics for each release in a deployment:
+active installs and their share of the deployment, downloads, successful
+installs, and failed installs. A failed install is rolled back on the device,
+so a rising failure rate is a signal to halt a rollout before raising it.`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(c *cobra.Command, args []string) error {
+		out := cmd.Out
+
+		appID, token, err := cmdutil.RequireCredentials(c.Context(), cmd.AppID, out, cmd.Relogin, cmd.FirstRun)
+		if err != nil {
+			return err
+		}
+
+		client := cmd.NewClient(cmdutil.ResolveAPIURL(cmd.APIURL, cmd.ServerURL, out), token)
+
+		var argValue string
+		if len(args) > 0 {
+			argValue = args[0]
+		}
+
+		deploymentID, err := cmdutil.ResolveDeploymentInteractive(c.Context(), client, appID, argValue, "CODEPUSH_DEPLOYMENT", out)
+		if err != nil {
+			return err
+		}
+
+		releases, err := codepush.DeploymentReleaseMetrics(c.Context(), client, appID, deploymentID)
+		if err != nil {
+			return err
+		}
+
+		if metricsMax > 0 && len(releases) > metricsMax {
+			releases = releases[len(releases)-metricsMax:]
+		}
+
+		if cmd.JSONOutput {
+			return cmdutil.OutputResult(releases)
+		}
+
+		if len(releases) == 0 {
+			out.Info("No releases found.")
+			return nil
+		}
+
+		headers := []string{"LABEL", "APP VERSION", "ROLLOUT", "ACTIVE", "DOWNLOADS", "INSTALLS", "FAILED", "FAILURE RATE"}
+		rows := make([][]string, len(releases))
+		for i, r := range releases {
+			rollout := fmt.Sprintf("%.0f%%", r.Rollout)
+			if r.Disabled {
+				rollout = "disabled"
+			}
+			rows[i] = []string{
+				r.Label, r.AppVersion, rollout,
+				fmt.Sprintf("%d (%.0f%%)", r.Active, r.ActiveShare),
+				strconv.FormatInt(r.Downloaded, 10),
+				strconv.FormatInt(r.Installed, 10),
+				strconv.FormatInt(r.Failed, 10),
+				fmt.Sprintf("%.1f%%", r.FailureRate),
+			}
+		}
+		out.Table(headers, rows)
+
+		return nil
+	},
+}
+
+func init() {
+	metricsCmd.Flags().IntVarP(&metricsMax, "limit", "n", 10, "maximum number of releases to show")
+	metricsCmd.ValidArgsFunction = cmd.CompleteDeploymentArg
+	deploymentCmd.AddCommand(metricsCmd)
+}
@@ -0,0 +1,98 @@
+package deployment
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/bitrise-io/bitrise-plugins-codepush-cli/cmd"
+	"github.com/bitrise-io/bitrise-plugins-codepush-cli/internal/bitrise"
+	"github.com/bitrise-io/bitrise-plugins-codepush-cli/internal/cmdutil"
+	"github.com/bitrise-io/bitrise-plugins-codepush-cli/internal/codepush"
+)
+
+var (
+	openDeployment string
+	openLabel      string
+	openPrint      bool
+)
+
+var openCmd = &cobra.Command{
+	Use:   "open",
+	Short: "Open the app, a deployment, or a release in Release Management",
+	Long: `Open the Bitrise Release Management page of the app, a deployment, or a
+release in the browser.
+
+Without a deployment (from --deployment, CODEPUSH_DEPLOYMENT, or the
+default in .codepush.json), the app's CodePush page opens; with one, the
+deployment's page; with --label as well, the release's page.
+
+When no browser can be opened, such as over SSH or in CI, the URL is
+printed instead. --print only prints it.`,
+	Example: `  codepush open
+  codepush open --deployment Production
+  codepush open --deployment Production --label v12
+  codepush open -d Staging --print`,
+	GroupID: cmd.GroupDeployment,
+	Args:    cobra.NoArgs,
+	RunE: func(c *cobra.Command, args []string) error {
+		out := cmd.Out
+
+		deployment := cmdutil.ResolveDeploymentValue(openDeployment, cmdutil.DeploymentEnvKey, out)
+		if openLabel != "" && deployment == "" {
+			return codepush.Invalid(errors.New("--label requires a deployment: set --deployment or CODEPUSH_DEPLOYMENT"))
+		}
+
+		var appID, deploymentID, updateID string
+		if deployment == "" {
+			// The app page needs no API calls, so no token either.
+			if appID = cmdutil.ResolveAppID(cmd.AppID, out); appID == "" {
+				return codepush.Invalid(errors.New("app ID is required: set --app-id, CODEPUSH_APP_ID, or run 'codepush init'"))
+			}
+		} else {
+			var token string
+			var err error
+			appID, token, err = cmdutil.RequireCredentials(c.Context(), cmd.AppID, out, cmd.Relogin, cmd.FirstRun)
+			if err != nil {
+				return err
+			}
+			client := cmd.NewClient(cmdutil.ResolveAPIURL(cmd.APIURL, cmd.ServerURL, out), token)
+
+			if deploymentID, err = codepush.ResolveDeployment(c.Context(), client, appID, deployment, out); err != nil {
+				return err
+			}
+			if openLabel != "" {
+				if updateID, _, err = codepush.ResolveUpdateForPatch(c.Context(), client, appID, deploymentID, openLabel, out); err != nil {
+					return err
+				}
+			}
+		}
+
+		url := bitrise.ReleaseManagementURL(appID, deploymentID, updateID)
+		if !openPrint {
+			if err := cmdutil.OpenBrowser(url); err != nil {
+				out.Warning("could not open a browser: %v", err)
+			} else if !cmd.JSONOutput {
+				out.Info("Opened %s", url)
+				return nil
+			}
+		}
+
+		if cmd.JSONOutput {
+			return cmdutil.OutputResult(map[string]string{"url": url})
+		}
+		_, err := fmt.Fprintln(os.Stdout, url)
+		return err
+	},
+}
+
+func init() {
+	openCmd.Flags().StringVarP(&openDeployment, "deployment", "d", "", "deployment name or UUID (env: CODEPUSH_DEPLOYMENT)")
+	openCmd.Flags().StringVarP(&openLabel, "label", "l", "", "release label to open (e.g. v12)")
+	openCmd.Flags().BoolVar(&openPrint, "print", false, "print the URL without opening a browser")
+	_ = openCmd.RegisterFlagCompletionFunc("deployment", cmd.CompleteDeployments)
+	_ = openCmd.RegisterFlagCompletionFunc("label", cmd.CompleteLabels("deployment"))
+	cmd.RootCmd.AddCommand(openCmd)
+}
@@ -0,0 +1,102 @@
+package deployment
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/bitrise-io/bitrise-plugins-codepush-cli/cmd"
+	"github.com/bitrise-io/bitrise-plugins-codepush-cli/internal/cmdutil"
+	"github.com/bitrise-io/bitrise-plugins-codepush-cli/internal/codepush"
+)
+
+var overviewParallel int
+
+var overviewCmd = &cobra.Command{
+	Use:   "overview",
+	Short: "Show the latest release of every deployment",
+	Long: `Show the latest release of every deployment of the app in one table:
+label, app version, rollout, status, and age.
+
+Deployments are fetched concurrently; --parallel bounds the number of
+deployments fetched at once.`,
+	GroupID: cmd.GroupDeployment,
+	Args:    cobra.NoArgs,
+	RunE: func(c *cobra.Command, args []string) error {
+		out := cmd.Out
+
+		if overviewParallel < 1 {
+			return fmt.Errorf("--parallel must be at least 1, got %d", overviewParallel)
+		}
+
+		appID, token, err := cmdutil.RequireCredentials(c.Context(), cmd.AppID, out, cmd.Relogin, cmd.FirstRun)
+		if err != nil {
+			return err
+		}
+
+		client := cmd.NewClient(cmdutil.ResolveAPIURL(cmd.APIURL, cmd.ServerURL, out), token)
+
+		var overview []codepush.DeploymentOverview
+		err = out.Indeterminate("Fetching deployments", func() error {
+			var fetchErr error
+			overview, fetchErr = codepush.Overview(c.Context(), client, appID, overviewParallel)
+			return fetchErr
+		})
+		if err != nil {
+			return err
+		}
+
+		if cmd.JSONOutput {
+			return cmdutil.OutputResult(overview)
+		}
+
+		if len(overview) == 0 {
+			out.Info("No deployments found.")
+			return nil
+		}
+
+		rows := make([][]string, len(overview))
+		for i, o := range overview {
+			rows[i] = overviewRow(o, time.Now())
+		}
+		out.Table([]string{"DEPLOYMENT", "LABEL", "APP VERSION", "ROLLOUT", "STATUS", "AGE"}, rows)
+
+		for _, o := range overview {
+			if o.Error != "" {
+				out.Warning("%s: %s", o.Deployment, o.Error)
+			}
+		}
+		return nil
+	},
+}
+
+func init() {
+	overviewCmd.Flags().IntVar(&overviewParallel, "parallel", codepush.DefaultOverviewParallelism, "maximum number of deployments fetched at once")
+	cmd.RootCmd.AddCommand(overviewCmd)
+}
+
+// overviewRow renders one deployment for the overview table.
+func overviewRow(o codepush.DeploymentOverview, now time.Time) []string {
+	switch {
+	case o.Error != "":
+		return []string{o.Deployment, "-", "-", "-", "error", "-"}
+	case o.Latest == nil:
+		return []string{o.Deployment, "-", "-", "-", "no releases", "-"}
+	}
+
+	u := o.Latest
+	status := o.Status
+	if u.Disabled {
+		status = "disabled"
+	} else if status == "" {
+		status = "unknown"
+	}
+
+	age := "-"
+	if created, err := time.Parse(time.RFC3339, u.CreatedAt); err == nil {
+		age = codepush.HumanAge(now.Sub(created)) + " ago"
+	}
+
+	return []string{o.Deployment, u.Label, u.AppVersion, fmt.Sprintf("%.0f%%", u.Rollout), status, age}
+}
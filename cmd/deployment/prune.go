@@ -0,0 +1,201 @@
+package deployment
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/bitrise-io/bitrise-plugins-codepush-cli/cmd"
+	"github.com/bitrise-io/bitrise-plugins-codepush-cli/internal/cmdutil"
+	"github.com/bitrise-io/bitrise-plugins-codepush-cli/internal/codepush"
+	"github.com/bitrise-io/bitrise-plugins-codepush-cli/internal/output"
+)
+
+var (
+	pruneKeep      int
+	pruneOlderThan string
+	pruneDryRun    bool
+	pruneYes       bool
+)
+
+var pruneCmd = &cobra.Command{
+	Use:   "prune [deployment]",
+	Short: "Delete old releases from a deployment",
+	Long: `Delete all but the --keep newest releases of a deployment, or only those
+older than --older-than (e.g. 90d). With both, a release is deleted only when
+it is outside the newest --keep and older than --older-than. The newest
+release is never deleted.
+
+Use --dry-run to list the releases that would be deleted. In an interactive
+terminal you can deselect releases before confirming; otherwise --yes is
+required.`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(c *cobra.Command, args []string) error {
+		out := cmd.Out
+
+		opts, err := pruneOptions()
+		if err != nil {
+			return err
+		}
+
+		appID, token, err := cmdutil.RequireCredentials(c.Context(), cmd.AppID, out, cmd.Relogin, cmd.FirstRun)
+		if err != nil {
+			return err
+		}
+
+		client := cmd.NewClient(cmdutil.ResolveAPIURL(cmd.APIURL, cmd.ServerURL, out), token)
+
+		var argValue string
+		if len(args) > 0 {
+			argValue = args[0]
+		}
+
+		deploymentID, err := cmdutil.ResolveDeploymentForWrite(c.Context(), client, appID, argValue, "CODEPUSH_DEPLOYMENT", out)
+		if err != nil {
+			return err
+		}
+
+		displayName := argValue
+		if displayName == "" {
+			displayName = deploymentID
+		}
+		return runPrune(c.Context(), client, appID, deploymentID, displayName, opts, out)
+	},
+}
+
+// pruneOptions returns the prune options given by --keep and --older-than.
+func pruneOptions() (codepush.PruneOptions, error) {
+	opts := codepush.PruneOptions{Keep: pruneKeep}
+	if pruneOlderThan != "" {
+		age, err := cmdutil.ParseAge(pruneOlderThan)
+		if err != nil {
+			return opts, fmt.Errorf("--older-than: %w", err)
+		}
+		opts.OlderThan = age
+	}
+	return opts, opts.Validate()
+}
+
+// runPrune deletes the releases of a deployment that opts selects, or lists
+// them with --dry-run.
+func runPrune(ctx context.Context, client codepush.Client, appID, deploymentID, displayName string, opts codepush.PruneOptions, out *output.Writer) error {
+	updates, err := client.ListUpdates(ctx, appID, deploymentID)
+	if err != nil {
+		return fmt.Errorf("listing updates: %w", err)
+	}
+
+	candidates := codepush.PruneCandidates(updates, opts, time.Now())
+	if len(candidates) == 0 {
+		if cmd.JSONOutput {
+			return outputPruneJSON(deploymentID, nil, 0)
+		}
+		out.Info("No releases to prune.")
+		return nil
+	}
+
+	if pruneDryRun {
+		if cmd.JSONOutput {
+			return outputPruneJSON(deploymentID, candidates, 0)
+		}
+		printPruneTable(out, candidates)
+		out.Info("Dry run: %d of %d release(s) would be deleted from %q", len(candidates), len(updates), displayName)
+		return nil
+	}
+
+	candidates, err = confirmPrune(out, candidates, displayName)
+	if err != nil || len(candidates) == 0 {
+		return err
+	}
+
+	deleted, err := codepush.DeleteUpdates(ctx, client, appID, deploymentID, candidates)
+	if err != nil {
+		if deleted > 0 {
+			out.Warning("deleted %d of %d release(s) before the failure", deleted, len(candidates))
+		}
+		return err
+	}
+
+	if cmd.JSONOutput {
+		return outputPruneJSON(deploymentID, candidates, deleted)
+	}
+
+	out.Success("Deleted %d release(s) from %q", deleted, displayName)
+	return nil
+}
+
+// confirmPrune lets an interactive user deselect releases, then asks to
+// confirm the deletion unless --yes is given. It returns the releases to
+// delete, none when the user deselected them all.
+func confirmPrune(out *output.Writer, candidates []codepush.Update, displayName string) ([]codepush.Update, error) {
+	if out.IsInteractive() && !pruneYes {
+		var err error
+		candidates, err = selectPruneReleases(out, candidates)
+		if err != nil {
+			return nil, err
+		}
+		if len(candidates) == 0 {
+			out.Info("No releases selected.")
+			return nil, nil
+		}
+	}
+
+	if err := out.ConfirmDestructive(
+		fmt.Sprintf("This will permanently delete %d release(s) from %q", len(candidates), displayName),
+		pruneYes,
+	); err != nil {
+		return nil, err
+	}
+	return candidates, nil
+}
+
+// selectPruneReleases lets the user deselect releases; all start selected.
+func selectPruneReleases(out *output.Writer, candidates []codepush.Update) ([]codepush.Update, error) {
+	options := make([]output.SelectOption, len(candidates))
+	ids := make([]string, len(candidates))
+	for i, u := range candidates {
+		label := u.Label + "  " + u.AppVersion
+		if u.CreatedAt != "" {
+			label += "  " + u.CreatedAt
+		}
+		options[i] = output.SelectOption{Label: label, Value: u.ID}
+		ids[i] = u.ID
+	}
+
+	chosen, err := out.MultiSelect("Releases to delete", options, ids)
+	if err != nil {
+		return nil, err
+	}
+	return slices.DeleteFunc(candidates, func(u codepush.Update) bool { return !slices.Contains(chosen, u.ID) }), nil
+}
+
+func printPruneTable(out *output.Writer, updates []codepush.Update) {
+	rows := make([][]string, len(updates))
+	for i, u := range updates {
+		rows[i] = []string{u.Label, u.AppVersion, u.CreatedAt}
+	}
+	out.Table([]string{"LABEL", "APP VERSION", "CREATED"}, rows)
+}
+
+func outputPruneJSON(deploymentID string, releases []codepush.Update, deleted int) error {
+	if releases == nil {
+		releases = []codepush.Update{}
+	}
+	return cmdutil.OutputResult(struct {
+		Deployment string            `json:"deployment"`
+		DryRun     bool              `json:"dry_run"`
+		Releases   []codepush.Update `json:"releases"`
+		Deleted    int               `json:"deleted"`
+	}{Deployment: deploymentID, DryRun: pruneDryRun, Releases: releases, Deleted: deleted})
+}
+
+func init() {
+	pruneCmd.Flags().IntVar(&pruneKeep, "keep", 0, "number of newest releases to keep")
+	pruneCmd.Flags().StringVar(&pruneOlderThan, "older-than", "", "only delete releases older than this age, e.g. 90d, 2w, or 36h")
+	pruneCmd.Flags().BoolVar(&pruneDryRun, "dry-run", false, "list the releases that would be deleted without deleting them")
+	pruneCmd.Flags().BoolVarP(&pruneYes, "yes", "y", false, "skip the selection and confirmation prompts")
+	pruneCmd.ValidArgsFunction = cmd.CompleteDeploymentArg
+	deploymentCmd.AddCommand(pruneCmd)
+}
@@ -0,0 +1,122 @@
+package deployment
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/bitrise-io/bitrise-plugins-codepush-cli/cmd"
+	"github.com/bitrise-io/bitrise-plugins-codepush-cli/internal/cmdutil"
+	"github.com/bitrise-io/bitrise-plugins-codepush-cli/internal/codepush"
+	"github.com/bitrise-io/bitrise-plugins-codepush-cli/internal/integrate"
+	"github.com/bitrise-io/bitrise-plugins-codepush-cli/internal/output"
+)
+
+var (
+	rotateYes           bool
+	rotateUpdateProject bool
+	rotateProjectDir    string
+)
+
+var rotateKeyCmd = &cobra.Command{
+	Use:   "rotate-key [deployment]",
+	Short: "Replace a deployment's key, e.g. after it leaked",
+	Long: `Replace a deployment's key with a new one.
+
+The API cannot change the key of a deployment, so the deployment is renamed,
+a new deployment with a new key is created under its name, its releases are
+copied over in order, and the old deployment is deleted. Pipelines that
+address the deployment by name keep working; the deployment UUID changes.
+Apps built with the old key stop receiving updates until they ship with the
+new key.
+
+With --update-project, the old key is replaced with the new one in the
+project's Info.plist, strings.xml, Gradle, xcconfig, app.json, and .env files.
+
+Examples:
+  codepush deployment rotate-key Production --yes
+  codepush deployment rotate-key Production --update-project --project-dir ./app`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(c *cobra.Command, args []string) error {
+		out := cmd.Out
+
+		appID, token, err := cmdutil.RequireCredentials(c.Context(), cmd.AppID, out, cmd.Relogin, cmd.FirstRun)
+		if err != nil {
+			return err
+		}
+
+		client := cmd.NewClient(cmdutil.ResolveAPIURL(cmd.APIURL, cmd.ServerURL, out), token)
+
+		var argValue string
+		if len(args) > 0 {
+			argValue = args[0]
+		}
+
+		deploymentID, err := cmdutil.ResolveDeploymentForWrite(c.Context(), client, appID, argValue, "CODEPUSH_DEPLOYMENT", out)
+		if err != nil {
+			return err
+		}
+
+		displayName := argValue
+		if displayName == "" {
+			displayName = deploymentID
+		}
+
+		if err := out.ConfirmDestructive(
+			fmt.Sprintf("This will replace deployment %q with a copy that has a new key; apps built with the current key stop receiving updates", displayName),
+			rotateYes,
+		); err != nil {
+			return err
+		}
+
+		result, err := codepush.RotateDeploymentKey(c.Context(), client, appID, deploymentID, out)
+		if err != nil {
+			return err
+		}
+
+		var updatedFiles []string
+		if rotateUpdateProject {
+			projectDir := rotateProjectDir
+			if projectDir == "" {
+				projectDir = "."
+			}
+			updatedFiles, err = integrate.ReplaceDeploymentKey(projectDir, result.PreviousKey, result.Deployment.Key)
+			if err != nil {
+				out.Warning("%v", err)
+			}
+		}
+
+		if cmd.JSONOutput {
+			return cmdutil.OutputResult(struct {
+				*codepush.RotateKeyResult
+				UpdatedFiles []string `json:"updated_files,omitempty"`
+			}{result, updatedFiles})
+		}
+
+		out.Success("Deployment %q has a new key", result.Deployment.Name)
+		out.Result([]output.KeyValue{
+			{Key: "Deployment ID", Value: result.Deployment.ID},
+			{Key: "New key", Value: result.Deployment.Key},
+			{Key: "Releases copied", Value: fmt.Sprint(len(result.Releases))},
+		})
+		switch {
+		case !rotateUpdateProject:
+			out.Info("Replace the old key in your app's native configuration and ship a new build")
+		case len(updatedFiles) == 0:
+			out.Warning("the old key was not found in any project file: update the native configuration by hand")
+		default:
+			for _, f := range updatedFiles {
+				out.Info("Updated %s", f)
+			}
+		}
+		return nil
+	},
+}
+
+func init() {
+	rotateKeyCmd.Flags().BoolVarP(&rotateYes, "yes", "y", false, "skip confirmation prompt")
+	rotateKeyCmd.Flags().BoolVar(&rotateUpdateProject, "update-project", false, "replace the old key with the new one in the project's native configuration files")
+	rotateKeyCmd.Flags().StringVar(&rotateProjectDir, "project-dir", "", "project root for --update-project (defaults to current directory)")
+	rotateKeyCmd.ValidArgsFunction = cmd.CompleteDeploymentArg
+	deploymentCmd.AddCommand(rotateKeyCmd)
+}
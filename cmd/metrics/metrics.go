@@ -0,0 +1,149 @@
+package metrics
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/bitrise-io/bitrise-plugins-codepush-cli/cmd"
+	"github.com/bitrise-io/bitrise-plugins-codepush-cli/internal/cmdutil"
+	"github.com/bitrise-io/bitrise-plugins-codepush-cli/internal/codepush"
+	"github.com/bitrise-io/bitrise-plugins-codepush-cli/internal/output"
+)
+
+var (
+	exportFormat string
+	exportOutput string
+	exportLoop   time.Duration
+)
+
+var metricsCmd = &cobra.Command{
+	Use:     "metrics",
+	Short:   "Export deployment metrics",
+	Long:    `Export install statistics of CodePush deployments to monitoring systems.`,
+	GroupID: cmd.GroupDeployment,
+}
+
+var exportCmd = &cobra.Command{
+	Use:   "export",
+	Short: "Export metrics in Prometheus or OpenMetrics format",
+	Long: `Export active installs, downloads, installs, and failures per deployment
+and release label in the Prometheus text format (or OpenMetrics).
+
+Output goes to stdout by default. Use --output to write a file for the
+node_exporter textfile collector; the file is replaced atomically.
+Use --loop to re-export on an interval until interrupted.`,
+	Args: cobra.NoArgs,
+	RunE: func(c *cobra.Command, args []string) error {
+		out := cmd.Out
+
+		if exportFormat != codepush.MetricsFormatPrometheus && exportFormat != codepush.MetricsFormatOpenMetrics {
+			return fmt.Errorf("invalid format %q: must be prometheus or openmetrics", exportFormat)
+		}
+		if exportLoop < 0 {
+			return fmt.Errorf("loop interval must be positive, got %s", exportLoop)
+		}
+
+		appID, token, err := cmdutil.RequireCredentials(c.Context(), cmd.AppID, out, cmd.Relogin, cmd.FirstRun)
+		if err != nil {
+			return err
+		}
+
+		client := cmd.NewClient(cmdutil.ResolveAPIURL(cmd.APIURL, cmd.ServerURL, out), token)
+
+		if exportLoop == 0 {
+			return exportOnce(c.Context(), client, appID)
+		}
+
+		ctx, stop := signal.NotifyContext(c.Context(), os.Interrupt)
+		defer stop()
+
+		out.Info("Exporting metrics every %s, press Ctrl-C to stop", exportLoop)
+		ticker := time.NewTicker(exportLoop)
+		defer ticker.Stop()
+		for {
+			if err := exportOnce(ctx, client, appID); err != nil {
+				if ctx.Err() != nil {
+					return nil
+				}
+				out.Warning("metrics export failed: %v", err)
+			}
+
+			select {
+			case <-ctx.Done():
+				return nil
+			case <-ticker.C:
+			}
+		}
+	},
+}
+
+// exportOnce collects metrics and writes them to stdout or the output file.
+func exportOnce(ctx context.Context, client *codepush.HTTPClient, appID string) error {
+	metrics, err := codepush.CollectMetrics(ctx, client, appID)
+	if err != nil {
+		return err
+	}
+
+	if cmd.JSONOutput && exportOutput == "" {
+		return cmdutil.OutputResult(metrics)
+	}
+
+	var buf bytes.Buffer
+	if err := codepush.WriteMetrics(&buf, exportFormat, appID, metrics); err != nil {
+		return err
+	}
+
+	if exportOutput == "" {
+		_, err := os.Stdout.Write(buf.Bytes())
+		return err
+	}
+
+	if err := writeFileAtomic(exportOutput, buf.Bytes()); err != nil {
+		return err
+	}
+	cmd.Out.Success("Metrics written to %s (%s)", exportOutput, output.HumanBytes(int64(buf.Len())))
+	return nil
+}
+
+// writeFileAtomic writes data to a temp file in the target directory and
+// renames it into place, so collectors never read a partial file.
+func writeFileAtomic(path string, data []byte) error {
+	f, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*")
+	if err != nil {
+		return fmt.Errorf("creating temp file: %w", err)
+	}
+	defer func() { _ = os.Remove(f.Name()) }()
+
+	if _, err := f.Write(data); err != nil {
+		_ = f.Close()
+		return fmt.Errorf("writing metrics: %w", err)
+	}
+	if err := f.Chmod(0o644); err != nil {
+		_ = f.Close()
+		return fmt.Errorf("writing metrics: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("writing metrics: %w", err)
+	}
+
+	if err := os.Rename(f.Name(), path); err != nil {
+		return fmt.Errorf("writing metrics: %w", err)
+	}
+	return nil
+}
+
+func init() {
+	exportCmd.Flags().StringVar(&exportFormat, "format", codepush.MetricsFormatPrometheus, "output format: prometheus or openmetrics")
+	exportCmd.Flags().StringVarP(&exportOutput, "output", "o", "", "write to this file instead of stdout (replaced atomically)")
+	exportCmd.Flags().DurationVar(&exportLoop, "loop", 0, "re-export on this interval until interrupted (e.g. 60s)")
+
+	metricsCmd.AddCommand(exportCmd)
+	cmd.RootCmd.AddCommand(metricsCmd)
+}
@@ -0,0 +1,249 @@
+package release
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/bitrise-io/bitrise-plugins-codepush-cli/cmd"
+	"github.com/bitrise-io/bitrise-plugins-codepush-cli/internal/bundler"
+	"github.com/bitrise-io/bitrise-plugins-codepush-cli/internal/cmdutil"
+	"github.com/bitrise-io/bitrise-plugins-codepush-cli/internal/codepush"
+	"github.com/bitrise-io/bitrise-plugins-codepush-cli/internal/output"
+	"github.com/bitrise-io/bitrise-plugins-codepush-cli/internal/releasefile"
+)
+
+var (
+	applyFile           string
+	applyDryRun         bool
+	applyTimeout        time.Duration
+	applyOverridePolicy bool
+	applyOverrideFreeze string
+)
+
+var applyCmd = &cobra.Command{
+	Use:   "apply",
+	Short: "Release the update described by a release manifest",
+	Long: `Release the update described by a release manifest.
+
+The manifest is a YAML or JSON file giving the app, deployment, bundle,
+app version, description, mandatory and disabled flags, rollout, and
+targeting of a release, the settings 'codepush push' takes as flags. The
+bundle is either a directory built beforehand or built from the manifest's
+bundle settings.
+
+Applying is idempotent: when the latest release in the deployment already
+has the bundle's content hash, nothing is pushed and the command succeeds.
+This makes it safe to apply the same manifest on every pipeline run.
+
+Examples:
+  codepush apply -f release.yaml
+  codepush apply -f release.json --dry-run`,
+	GroupID: cmd.GroupRelease,
+	Args:    cobra.NoArgs,
+	RunE: func(c *cobra.Command, _ []string) error {
+		freezeReason, err := parseOverrideFreeze(c, applyOverrideFreeze)
+		if err != nil {
+			return err
+		}
+		return runApply(c.Context(), freezeReason, cmd.Out)
+	},
+}
+
+// applyResult is the outcome of applying a release manifest.
+type applyResult struct {
+	// Applied is set when a release was pushed.
+	Applied    bool   `json:"applied"`
+	DryRun     bool   `json:"dry_run"`
+	Deployment string `json:"deployment"`
+	Hash       string `json:"hash"`
+	// ExistingLabel is the release that already has the content, when
+	// nothing was pushed for that reason.
+	ExistingLabel string               `json:"existing_label,omitempty"`
+	Push          *codepush.PushResult `json:"push,omitempty"`
+}
+
+func runApply(ctx context.Context, freezeReason string, out *output.Writer) error {
+	release, targeting, err := loadApplyManifest()
+	if err != nil {
+		return err
+	}
+	bundlePath, sourcemapPath, err := applyBundle(ctx, release, out)
+	if err != nil {
+		return err
+	}
+
+	appID := release.App
+	if appID == "" {
+		appID = cmd.AppID
+	}
+	appID, token, err := cmdutil.RequireCredentials(ctx, appID, out, cmd.Relogin, cmd.FirstRun)
+	if err != nil {
+		return err
+	}
+	client := cmd.NewClient(cmdutil.ResolveAPIURL(cmd.APIURL, cmd.ServerURL, out), token)
+
+	deploymentID, err := cmdutil.ResolveDeploymentForWrite(ctx, client, appID, release.Deployment, "", out)
+	if err != nil {
+		return err
+	}
+	if err := checkBundlePlatform(ctx, client, appID, bundlePath, release.Bundle.Platform, out); err != nil {
+		return err
+	}
+
+	result := &applyResult{DryRun: applyDryRun, Deployment: release.Deployment}
+	if result.Hash, err = codepush.ContentHash(bundlePath); err != nil {
+		return err
+	}
+	existing, err := codepush.LatestReleaseWithHash(ctx, client, appID, deploymentID, result.Hash)
+	if err != nil {
+		return fmt.Errorf("checking released content: %w", err)
+	}
+	if existing != nil {
+		result.ExistingLabel = existing.Label
+		return reportApply(result, out)
+	}
+
+	if err := enforceSourcemapPolicy(ctx, client, appID, deploymentID, sourcemapPath, bundlePath, out); err != nil {
+		return err
+	}
+	opts := &codepush.PushOptions{
+		AppID:        appID,
+		DeploymentID: deploymentID,
+		Token:        token,
+		AppVersion:   release.AppVersion,
+		Description:  release.Description,
+		Mandatory:    release.Mandatory,
+		Rollout:      release.RolloutPercent(),
+		Disabled:     release.Disabled,
+		BundlePath:   bundlePath,
+		Targeting:    targeting,
+		Provenance:   codepush.CollectProvenance(ctx, release.Bundle.ProjectDir),
+		Workspace:    cmd.Workspace,
+	}
+	return pushApply(ctx, client, opts, result, freezeReason, out)
+}
+
+// loadApplyManifest reads and validates the --file release manifest and
+// returns it with its targeting.
+func loadApplyManifest() (*releasefile.Release, codepush.Targeting, error) {
+	if applyTimeout <= 0 {
+		return nil, codepush.Targeting{}, codepush.Invalid(errors.New("--timeout must be positive"))
+	}
+	release, err := releasefile.Load(applyFile)
+	if err != nil {
+		return nil, codepush.Targeting{}, codepush.Invalid(err)
+	}
+	appVersion, err := codepush.NormalizeAppVersion(release.AppVersion)
+	if err != nil {
+		return nil, codepush.Targeting{}, codepush.Invalid(fmt.Errorf("release manifest: %w", err))
+	}
+	release.AppVersion = appVersion
+	t := release.Targeting
+	targeting, err := codepush.NewTargeting(t.OSVersion, t.DeviceModels, t.Countries)
+	if err != nil {
+		return nil, codepush.Targeting{}, codepush.Invalid(fmt.Errorf("release manifest: %w", err))
+	}
+	return release, targeting, nil
+}
+
+// pushApply enforces the release policy and freeze windows, then pushes the
+// manifest's release unless --dry-run is given, and reports the result.
+func pushApply(ctx context.Context, client codepush.Client, opts *codepush.PushOptions, result *applyResult, freezeReason string, out *output.Writer) error {
+	if err := enforcePushPolicy(ctx, client, opts, applyOverridePolicy, out); err != nil {
+		return err
+	}
+	if err := enforceFreeze(ctx, client, "apply", opts.AppID, opts.DeploymentID, freezeReason, out); err != nil {
+		return err
+	}
+	if applyDryRun {
+		return reportApply(result, out)
+	}
+
+	var err error
+	result.Push, err = codepush.PushWithConfig(ctx, client, opts, codepush.PollConfigFor(applyTimeout), out)
+	if err != nil {
+		return fmt.Errorf("push failed: %w", err)
+	}
+	result.Applied = true
+
+	cmdutil.ExportStepOutputs("codepush-push-summary.json", result.Push, pushStepOutputs(result.Push), out)
+	cmdutil.AnnotateRelease(pushAnnotation(result.Push, result.Deployment), out)
+	return reportApply(result, out)
+}
+
+// applyBundle returns the bundle directory of the manifest, building it
+// when the manifest gives build settings instead of a path, along with the
+// sourcemap of the build.
+func applyBundle(ctx context.Context, release *releasefile.Release, out *output.Writer) (string, string, error) {
+	if release.Bundle.Path != "" {
+		return release.Bundle.Path, "", nil
+	}
+
+	b := release.Bundle
+	if err := bundler.ValidatePlatform(bundler.Platform(b.Platform)); err != nil {
+		return "", "", codepush.Invalid(fmt.Errorf("release manifest: %w", err))
+	}
+	if b.Hermes == "" {
+		b.Hermes = string(bundler.HermesModeAuto)
+	}
+	if err := bundler.ValidateHermesMode(bundler.HermesMode(b.Hermes)); err != nil {
+		return "", "", codepush.Invalid(fmt.Errorf("release manifest: %w", err))
+	}
+	opts := &bundler.BundleOptions{
+		Platform:    bundler.Platform(b.Platform),
+		EntryFile:   b.EntryFile,
+		OutputDir:   b.OutputDir,
+		Minify:      b.Minify,
+		ResetCache:  true,
+		Sourcemap:   true,
+		HermesMode:  bundler.HermesMode(b.Hermes),
+		ProjectDir:  b.ProjectDir,
+		SkipInstall: b.SkipInstall,
+		LogFile:     bundleLogPath(),
+		Workspace:   cmd.Workspace,
+	}
+	result, err := bundler.Run(ctx, opts, out)
+	reportBundleLog(opts, err, out)
+	if err != nil {
+		return "", "", fmt.Errorf("bundling failed: %w", err)
+	}
+	out.Info("Bundle created at: %s", result.OutputDir)
+	return result.OutputDir, result.SourcemapPath, nil
+}
+
+func reportApply(result *applyResult, out *output.Writer) error {
+	if cmd.JSONOutput {
+		return cmdutil.OutputResult(result)
+	}
+
+	switch {
+	case result.ExistingLabel != "":
+		out.Success("Release %s in %s already has this content, nothing to apply", result.ExistingLabel, result.Deployment)
+	case result.DryRun:
+		out.Info("Dry run: a new release would be pushed to %s", result.Deployment)
+	default:
+		out.Success("Applied: pushed a new release to %s", result.Deployment)
+		out.Result([]output.KeyValue{
+			{Key: "Update ID", Value: result.Push.UpdateID},
+			{Key: "App version", Value: result.Push.AppVersion},
+			{Key: "Status", Value: result.Push.Status},
+		})
+	}
+	out.Info("Content hash: %s", result.Hash)
+	return nil
+}
+
+func init() {
+	applyCmd.Flags().StringVarP(&applyFile, "file", "f", "", "release manifest, YAML or JSON")
+	applyCmd.Flags().BoolVar(&applyDryRun, "dry-run", false, "check the manifest and report whether a release would be pushed, without pushing")
+	applyCmd.Flags().DurationVar(&applyTimeout, "timeout", 2*time.Minute, "how long to wait for the update to be processed (e.g. 10m)")
+	applyCmd.Flags().BoolVar(&applyOverridePolicy, "override-policy", false, "create the release even if it violates the policy in .codepush.json; the override is logged")
+	applyCmd.Flags().StringVar(&applyOverrideFreeze, "override-freeze", "", "change the deployment even inside a freeze window in .codepush.json, giving the reason; the override is logged")
+	applyCmd.Flags().BoolVar(&pushAllowPlatformMismatch, "allow-platform-mismatch", false, "warn instead of failing when the bundle looks built for another platform")
+	_ = applyCmd.MarkFlagRequired("file")
+	cmd.RootCmd.AddCommand(applyCmd)
+}
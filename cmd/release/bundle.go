@@ -1,15 +1,19 @@
 package release
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"path/filepath"
+	"strconv"
 
 	"github.com/spf13/cobra"
 
 	"github.com/bitrise-io/bitrise-plugins-codepush-cli/cmd"
-	"github.com/bitrise-io/bitrise-plugins-codepush-cli/internal/bitrise"
 	"github.com/bitrise-io/bitrise-plugins-codepush-cli/internal/bundler"
 	"github.com/bitrise-io/bitrise-plugins-codepush-cli/internal/cmdutil"
 	"github.com/bitrise-io/bitrise-plugins-codepush-cli/internal/output"
+	"github.com/bitrise-io/bitrise-plugins-codepush-cli/internal/sourcemaps"
 )
 
 var bundleCmd = &cobra.Command{
@@ -19,67 +23,91 @@ var bundleCmd = &cobra.Command{
 
 Auto-detects the project type, entry file, and Hermes configuration.
 Produces a directory containing the bundle, assets, and optional source maps
-ready for use with 'codepush push'.`,
+ready for use with 'codepush push'.
+
+With --platform all (or ios,android), both platforms are bundled at once
+into <output-dir>/ios/<name> and <output-dir>/android/<name>, sharing the
+dependency install.`,
 	GroupID: cmd.GroupRelease,
 	RunE: func(c *cobra.Command, args []string) error {
 		out := cmd.Out
-		return runBundle(out)
+		return runBundle(c.Context(), out)
 	},
 }
 
+var (
+	bundleVerifyDeterminism bool
+	bundleHermetic          bool
+)
+
 func init() {
 	registerBundleFlagsOn(bundleCmd)
+	bundleCmd.Flags().BoolVar(&bundleVerifyDeterminism, "verify-determinism", false, "bundle twice and fail if the outputs differ")
+	bundleCmd.Flags().BoolVar(&bundleHermetic, "hermetic", false, "with --verify-determinism: reset the Metro cache and pin the build environment")
 	cmd.RootCmd.AddCommand(bundleCmd)
 }
 
-func runBundle(out *output.Writer) error {
-	platform, err := cmdutil.ResolvePlatformInteractive(bundlePlatform, out)
+func runBundle(ctx context.Context, out *output.Writer) error {
+	platforms, err := resolveBundlePlatforms(out)
 	if err != nil {
 		return err
 	}
-	bundlePlatform = platform
+	if bundleVerifyDeterminism {
+		if len(platforms) > 1 {
+			return errors.New("--verify-determinism checks one platform at a time: pass --platform ios or --platform android")
+		}
+		return runVerifyDeterminism(ctx, platforms[0], out)
+	}
 
-	if err := bundler.ValidatePlatform(bundler.Platform(bundlePlatform)); err != nil {
+	uploader, err := newSourcemapUploader(false, out)
+	if err != nil {
 		return err
 	}
-	if err := bundler.ValidateHermesMode(bundler.HermesMode(bundleHermes)); err != nil {
+	results, err := bundleProject(ctx, platforms, out)
+	if err != nil {
 		return err
 	}
+	if uploader != nil {
+		for _, result := range results {
+			if err := uploadSourcemaps(ctx, uploader, result, sourcemaps.Release{}); err != nil {
+				if len(results) > 1 {
+					return fmt.Errorf("%s: %w", result.Platform, err)
+				}
+				return err
+			}
+		}
+	}
 
-	result, err := runBundleWithOpts(out)
-	if err != nil {
-		return err
+	if len(results) > 1 {
+		return reportPlatformBundles(results, out)
 	}
+	return reportBundle(results[0], out)
+}
 
-	if bundlePrivateKeyPath != "" {
-		stepSign := out.StartStep("Signing bundle")
-		if err := bundler.SignBundle(result.OutputDir, bundlePrivateKeyPath, cmd.Version); err != nil {
-			stepSign.Cancel()
-			return fmt.Errorf("signing bundle: %w", err)
-		}
-		stepSign.Done()
-		out.Info("Signed: %s/.codepushrelease", result.OutputDir)
+// resolveBundlePlatforms returns the platforms to bundle for, prompting for
+// --platform when it is not set, and validates the other bundle flags.
+func resolveBundlePlatforms(out *output.Writer) ([]bundler.Platform, error) {
+	platform, err := cmdutil.ResolvePlatformInteractive(bundlePlatform, out)
+	if err != nil {
+		return nil, err
+	}
+	platforms, err := bundler.ParsePlatforms(platform)
+	if err != nil {
+		return nil, err
+	}
+	if err := bundler.ValidateHermesMode(bundler.HermesMode(bundleHermes)); err != nil {
+		return nil, err
+	}
+	if bundleHermetic && !bundleVerifyDeterminism {
+		return nil, errors.New("--hermetic requires --verify-determinism")
 	}
+	return platforms, nil
+}
 
+// reportBundle reports the output of a bundle for one platform.
+func reportBundle(result *bundler.BundleResult, out *output.Writer) error {
 	if cmd.JSONOutput {
-		summary := struct {
-			Platform      string `json:"platform"`
-			ProjectType   string `json:"project_type"`
-			OutputDir     string `json:"output_dir"`
-			BundlePath    string `json:"bundle_path"`
-			AssetsDir     string `json:"assets_dir"`
-			SourcemapPath string `json:"sourcemap_path,omitempty"`
-			HermesApplied bool   `json:"hermes_applied"`
-		}{
-			Platform:      string(result.Platform),
-			ProjectType:   result.ProjectType.String(),
-			OutputDir:     result.OutputDir,
-			BundlePath:    result.BundlePath,
-			AssetsDir:     result.AssetsDir,
-			SourcemapPath: result.SourcemapPath,
-			HermesApplied: result.HermesApplied,
-		}
-		return cmdutil.OutputJSON(summary)
+		return cmdutil.OutputResult(newBundleSummary(result))
 	}
 
 	out.Success("Bundle created successfully")
@@ -93,24 +121,133 @@ func runBundle(out *output.Writer) error {
 	if result.HermesApplied {
 		out.Info("Hermes: compiled")
 	}
+	if result.RuntimeVersion != "" {
+		out.Info("Runtime version: %s", result.RuntimeVersion)
+	}
 
-	if bitrise.IsBitriseEnvironment() {
+	if cmdutil.CI() == cmdutil.CIBitrise {
 		cmdutil.ExportDeploySummary("codepush-bundle-summary.json", struct {
-			Platform      string `json:"platform"`
-			ProjectType   string `json:"project_type"`
-			BundlePath    string `json:"bundle_path"`
-			AssetsDir     string `json:"assets_dir"`
-			SourcemapPath string `json:"sourcemap_path,omitempty"`
-			HermesApplied bool   `json:"hermes_applied"`
+			Platform       string `json:"platform"`
+			ProjectType    string `json:"project_type"`
+			BundlePath     string `json:"bundle_path"`
+			AssetsDir      string `json:"assets_dir"`
+			SourcemapPath  string `json:"sourcemap_path,omitempty"`
+			HermesApplied  bool   `json:"hermes_applied"`
+			RuntimeVersion string `json:"runtime_version,omitempty"`
 		}{
-			Platform:      string(result.Platform),
-			ProjectType:   result.ProjectType.String(),
-			BundlePath:    result.BundlePath,
-			AssetsDir:     result.AssetsDir,
-			SourcemapPath: result.SourcemapPath,
-			HermesApplied: result.HermesApplied,
+			Platform:       string(result.Platform),
+			ProjectType:    result.ProjectType.String(),
+			BundlePath:     result.BundlePath,
+			AssetsDir:      result.AssetsDir,
+			SourcemapPath:  result.SourcemapPath,
+			HermesApplied:  result.HermesApplied,
+			RuntimeVersion: result.RuntimeVersion,
 		}, out)
 	}
+	return nil
+}
+
+// bundleSummary is the JSON output of the bundle command for one platform.
+type bundleSummary struct {
+	Platform       string               `json:"platform"`
+	ProjectType    string               `json:"project_type"`
+	OutputDir      string               `json:"output_dir"`
+	BundlePath     string               `json:"bundle_path"`
+	AssetsDir      string               `json:"assets_dir"`
+	SourcemapPath  string               `json:"sourcemap_path,omitempty"`
+	HermesApplied  bool                 `json:"hermes_applied"`
+	RuntimeVersion string               `json:"runtime_version,omitempty"`
+	Assets         *bundler.AssetReport `json:"assets,omitempty"`
+}
+
+func newBundleSummary(result *bundler.BundleResult) bundleSummary {
+	return bundleSummary{
+		Platform:       string(result.Platform),
+		ProjectType:    result.ProjectType.String(),
+		OutputDir:      result.OutputDir,
+		BundlePath:     result.BundlePath,
+		AssetsDir:      result.AssetsDir,
+		SourcemapPath:  result.SourcemapPath,
+		HermesApplied:  result.HermesApplied,
+		RuntimeVersion: result.RuntimeVersion,
+		Assets:         result.Assets,
+	}
+}
+
+// reportPlatformBundles reports the output of a bundle for several
+// platforms.
+func reportPlatformBundles(results []*bundler.BundleResult, out *output.Writer) error {
+	summaries := make([]bundleSummary, len(results))
+	for i, result := range results {
+		summaries[i] = newBundleSummary(result)
+	}
+	report := struct {
+		Bundles []bundleSummary `json:"bundles"`
+	}{summaries}
 
+	if cmdutil.CI() == cmdutil.CIBitrise {
+		cmdutil.ExportDeploySummary("codepush-bundle-summary.json", report, out)
+	}
+	if cmd.JSONOutput {
+		return cmdutil.OutputResult(report)
+	}
+
+	rows := make([][]string, len(results))
+	for i, result := range results {
+		hermes := "off"
+		if result.HermesApplied {
+			hermes = "compiled"
+		}
+		rows[i] = []string{string(result.Platform), result.OutputDir, filepath.Base(result.BundlePath), hermes}
+	}
+	out.Success("Bundles created successfully")
+	out.Table([]string{"PLATFORM", "OUTPUT", "BUNDLE", "HERMES"}, rows)
+	return nil
+}
+
+// runVerifyDeterminism bundles the project for platform twice and reports
+// output files that differ between the builds.
+func runVerifyDeterminism(ctx context.Context, platform bundler.Platform, out *output.Writer) error {
+	opts := bundleOptions(platform)
+	result, report, err := bundler.VerifyDeterminism(ctx, opts, bundleHermetic, out)
+	reportBundleLog(opts, err, out)
+	if err != nil {
+		return err
+	}
+
+	if cmd.JSONOutput {
+		if err := cmdutil.OutputResult(struct {
+			OutputDir     string                     `json:"output_dir"`
+			Deterministic bool                       `json:"deterministic"`
+			Hermetic      bool                       `json:"hermetic"`
+			Report        *bundler.DeterminismReport `json:"report"`
+		}{
+			OutputDir:     result.OutputDir,
+			Deterministic: report.Deterministic(),
+			Hermetic:      bundleHermetic,
+			Report:        report,
+		}); err != nil {
+			return err
+		}
+	} else if report.Deterministic() {
+		out.Success("Bundle output is deterministic (%d files compared)", report.FilesCompared)
+	} else {
+		rows := make([][]string, 0, len(report.Differences))
+		for _, d := range report.Differences {
+			offset := "-"
+			if d.Reason == bundler.DiffContent || d.Reason == bundler.DiffSize {
+				offset = strconv.FormatInt(d.Offset, 10)
+			}
+			rows = append(rows, []string{d.Path, d.Reason, offset})
+		}
+		out.Table([]string{"File", "Difference", "First differing byte"}, rows)
+		if !bundleHermetic {
+			out.Info("Re-run with --hermetic to rule out cache and environment differences")
+		}
+	}
+
+	if !report.Deterministic() {
+		return fmt.Errorf("bundle output is not deterministic: %d files differ between builds", len(report.Differences))
+	}
 	return nil
 }
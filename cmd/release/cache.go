@@ -0,0 +1,139 @@
+package release
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/bitrise-io/bitrise-plugins-codepush-cli/cmd"
+	"github.com/bitrise-io/bitrise-plugins-codepush-cli/internal/bundlecache"
+	"github.com/bitrise-io/bitrise-plugins-codepush-cli/internal/cmdutil"
+	"github.com/bitrise-io/bitrise-plugins-codepush-cli/internal/output"
+)
+
+var (
+	cacheDir     string
+	cacheMaxSize string
+)
+
+var cacheCmd = &cobra.Command{
+	Use:   "cache",
+	Short: "Inspect and prune the local bundle cache",
+	Long: `Inspect and prune the local bundle cache.
+
+The cache lives under $BITRISE_CACHE_DIR on Bitrise and under the user cache
+directory elsewhere; override it with --cache-dir. It is safe to share
+between concurrent jobs on the same agent.`,
+	GroupID: cmd.GroupRelease,
+}
+
+var cacheStatsCmd = &cobra.Command{
+	Use:   "stats",
+	Short: "Show cache size, entries, and hit rate",
+	Args:  cobra.NoArgs,
+	RunE: func(c *cobra.Command, args []string) error {
+		out := cmd.Out
+
+		cache, err := openBundleCache()
+		if err != nil {
+			return err
+		}
+		stats, err := cache.Stats()
+		if err != nil {
+			return err
+		}
+
+		if cmd.JSONOutput {
+			return cmdutil.OutputResult(stats)
+		}
+
+		size := cmdutil.FormatBytes(stats.Size)
+		if stats.MaxSize > 0 {
+			size += " of " + cmdutil.FormatBytes(stats.MaxSize)
+		}
+		out.Result([]output.KeyValue{
+			{Key: "Directory", Value: stats.Dir},
+			{Key: "Entries", Value: strconv.Itoa(len(stats.Entries))},
+			{Key: "Size", Value: size},
+			{Key: "Hit rate", Value: fmt.Sprintf("%.0f%% (%d hits, %d misses)", stats.HitRate()*100, stats.Hits, stats.Misses)},
+		})
+
+		if len(stats.Entries) == 0 {
+			return nil
+		}
+		rows := make([][]string, len(stats.Entries))
+		for i, e := range stats.Entries {
+			rows[i] = []string{
+				cmdutil.Truncate(e.Key, 16),
+				cmdutil.FormatBytes(e.Size),
+				strconv.Itoa(e.Hits),
+				e.LastUsed.Local().Format(time.DateTime),
+			}
+		}
+		out.Table([]string{"Key", "Size", "Hits", "Last used"}, rows)
+		return nil
+	},
+}
+
+var cachePruneCmd = &cobra.Command{
+	Use:   "prune",
+	Short: "Evict least recently used entries until the cache fits --cache-max-size",
+	Args:  cobra.NoArgs,
+	RunE: func(c *cobra.Command, args []string) error {
+		out := cmd.Out
+
+		if cacheMaxSize == "" {
+			return errors.New("--cache-max-size is required: e.g. --cache-max-size 5GB")
+		}
+		cache, err := openBundleCache()
+		if err != nil {
+			return err
+		}
+		evicted, err := cache.Prune()
+		if err != nil {
+			return err
+		}
+
+		if cmd.JSONOutput {
+			return cmdutil.OutputResult(map[string]any{"evicted": evicted})
+		}
+
+		var freed int64
+		for _, e := range evicted {
+			freed += e.Size
+		}
+		out.Success("Evicted %d entries (%s)", len(evicted), cmdutil.FormatBytes(freed))
+		return nil
+	},
+}
+
+func init() {
+	cacheCmd.PersistentFlags().StringVar(&cacheDir, "cache-dir", "", "bundle cache directory (default: under $BITRISE_CACHE_DIR or the user cache directory)")
+	cacheCmd.PersistentFlags().StringVar(&cacheMaxSize, "cache-max-size", "", "cache size cap, e.g. 5GB; least recently used entries are evicted beyond it")
+	cacheCmd.AddCommand(cacheStatsCmd, cachePruneCmd)
+	cmd.RootCmd.AddCommand(cacheCmd)
+}
+
+// openBundleCache returns the bundle cache selected by --cache-dir and
+// --cache-max-size.
+func openBundleCache() (*bundlecache.Cache, error) {
+	dir := cacheDir
+	if dir == "" {
+		var err error
+		if dir, err = bundlecache.DefaultDir(); err != nil {
+			return nil, err
+		}
+	}
+
+	var maxSize int64
+	if cacheMaxSize != "" {
+		var err error
+		if maxSize, err = cmdutil.ParseBytes(cacheMaxSize); err != nil {
+			return nil, fmt.Errorf("--cache-max-size: %w", err)
+		}
+	}
+	return bundlecache.New(dir, maxSize), nil
+}
@@ -0,0 +1,375 @@
+package release
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/bitrise-io/bitrise-plugins-codepush-cli/cmd"
+	"github.com/bitrise-io/bitrise-plugins-codepush-cli/internal/cmdutil"
+	"github.com/bitrise-io/bitrise-plugins-codepush-cli/internal/codepush"
+	"github.com/bitrise-io/bitrise-plugins-codepush-cli/internal/output"
+)
+
+var (
+	packageLabel    string
+	packageFollow   bool
+	packageDiffFrom string
+	packageDiffTo   string
+
+	packageTagDeployment string
+	packageTagAdd        []string
+	packageTagRemove     []string
+)
+
+var packageCmd = &cobra.Command{
+	Use:     "package",
+	Short:   "Inspect released packages",
+	Long:    `Inspect the packages of released updates.`,
+	GroupID: cmd.GroupRelease,
+}
+
+var packageInfoCmd = &cobra.Command{
+	Use:   "info [deployment]",
+	Short: "Show a released package and the sources it was built from",
+	Long: `Show a released package with the git branch, commit, and CI build it was
+pushed from, recorded automatically by push. A commit marked with
+uncommitted changes was pushed from a modified checkout, so its sources may
+not match the commit.
+
+By default shows the latest release. Use --label to specify a version.`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(c *cobra.Command, args []string) error {
+		out := cmd.Out
+
+		appID, token, err := cmdutil.RequireCredentials(c.Context(), cmd.AppID, out, cmd.Relogin, cmd.FirstRun)
+		if err != nil {
+			return err
+		}
+
+		client := cmd.NewClient(cmdutil.ResolveAPIURL(cmd.APIURL, cmd.ServerURL, out), token)
+
+		var argValue string
+		if len(args) > 0 {
+			argValue = args[0]
+		}
+
+		deploymentID, err := cmdutil.ResolveDeploymentInteractive(c.Context(), client, appID, argValue, "CODEPUSH_DEPLOYMENT", out)
+		if err != nil {
+			return err
+		}
+
+		updateID, _, err := codepush.ResolveUpdateForPatch(c.Context(), client, appID, deploymentID, packageLabel, out)
+		if err != nil {
+			return err
+		}
+
+		pkg, err := client.GetUpdate(c.Context(), appID, deploymentID, updateID)
+		if err != nil {
+			return fmt.Errorf("getting update: %w", err)
+		}
+
+		if cmd.JSONOutput {
+			return cmdutil.OutputResult(pkg)
+		}
+
+		pairs := []output.KeyValue{
+			{Key: "Release", Value: pkg.Label},
+			{Key: "ID", Value: pkg.ID},
+			{Key: "App version", Value: pkg.AppVersion},
+			{Key: "Size", Value: cmdutil.FormatBytes(pkg.FileSizeBytes)},
+		}
+		if pkg.Hash != "" {
+			pairs = append(pairs, output.KeyValue{Key: "Hash", Value: pkg.Hash})
+		}
+		if pkg.CreatedAt != "" {
+			pairs = append(pairs, output.KeyValue{Key: "Created", Value: pkg.CreatedAt})
+		}
+		if tags := pkg.Tags(); len(tags) > 0 {
+			pairs = append(pairs, output.KeyValue{Key: "Tags", Value: strings.Join(tags, ", ")})
+		}
+		source := cmdutil.ProvenancePairs(pkg.Provenance)
+		pairs = append(pairs, source...)
+		out.Result(pairs)
+		if len(source) == 0 {
+			out.Info("No source information was recorded for %s", pkg.Label)
+		}
+		return nil
+	},
+}
+
+var packageVerifyCmd = &cobra.Command{
+	Use:   "verify [deployment]",
+	Short: "Verify the integrity of a released package",
+	Long: `Download a released package, recompute its content hash, and compare it to
+the hash recorded by the server. Bundles compiled to Hermes bytecode also have
+their headers checked against the file contents.
+
+By default verifies the latest release. Use --label to specify a version.
+Exits with an error when the package does not match.`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(c *cobra.Command, args []string) error {
+		out := cmd.Out
+
+		appID, token, err := cmdutil.RequireCredentials(c.Context(), cmd.AppID, out, cmd.Relogin, cmd.FirstRun)
+		if err != nil {
+			return err
+		}
+
+		client := cmd.NewClient(cmdutil.ResolveAPIURL(cmd.APIURL, cmd.ServerURL, out), token)
+
+		var argValue string
+		if len(args) > 0 {
+			argValue = args[0]
+		}
+
+		deploymentID, err := cmdutil.ResolveDeploymentInteractive(c.Context(), client, appID, argValue, "CODEPUSH_DEPLOYMENT", out)
+		if err != nil {
+			return err
+		}
+
+		updateID, _, err := codepush.ResolveUpdateForPatch(c.Context(), client, appID, deploymentID, packageLabel, out)
+		if err != nil {
+			return err
+		}
+
+		step := out.StartStep("Downloading and hashing package")
+		result, err := codepush.VerifyPackage(c.Context(), client, cmd.Workspace, codepush.UpdateRef{AppID: appID, DeploymentID: deploymentID, UpdateID: updateID})
+		if err != nil {
+			step.Cancel()
+			return err
+		}
+		step.Done()
+
+		if cmd.JSONOutput {
+			if err := cmdutil.OutputResult(struct {
+				*codepush.VerifyResult
+				Valid bool `json:"valid"`
+			}{result, result.Valid()}); err != nil {
+				return err
+			}
+		} else {
+			printVerifyResult(out, result)
+		}
+
+		switch {
+		case !result.HashMatch:
+			return fmt.Errorf("package %s does not match its recorded content hash", result.Label)
+		case !result.Valid():
+			return fmt.Errorf("package %s has invalid Hermes bytecode headers", result.Label)
+		}
+		return nil
+	},
+}
+
+func printVerifyResult(out *output.Writer, result *codepush.VerifyResult) {
+	out.Result([]output.KeyValue{
+		{Key: "Release", Value: result.Label},
+		{Key: "Files", Value: strconv.Itoa(result.Files)},
+		{Key: "Expected hash", Value: result.ExpectedHash},
+		{Key: "Actual hash", Value: result.ActualHash},
+	})
+
+	if len(result.Hermes) > 0 {
+		rows := make([][]string, len(result.Hermes))
+		for i, h := range result.Hermes {
+			status := "ok"
+			if h.Error != "" {
+				status = h.Error
+			}
+			rows[i] = []string{h.Path, strconv.FormatUint(uint64(h.Version), 10), status}
+		}
+		out.Table([]string{"HERMES BUNDLE", "BYTECODE VERSION", "HEADER"}, rows)
+	}
+
+	if result.Valid() {
+		out.Success("Package %s verified", result.Label)
+	}
+}
+
+var packageDiffCmd = &cobra.Command{
+	Use:   "diff [deployment]",
+	Short: "Compare the files of two released packages",
+	Long: `Download two released packages and list the files added, removed, or
+modified between them with their uncompressed size change, largest change
+first, followed by the total size change.
+
+By default compares the latest release with the one before it. Use --from
+and --to to pick the releases by label.`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(c *cobra.Command, args []string) error {
+		out := cmd.Out
+
+		appID, token, err := cmdutil.RequireCredentials(c.Context(), cmd.AppID, out, cmd.Relogin, cmd.FirstRun)
+		if err != nil {
+			return err
+		}
+
+		client := cmd.NewClient(cmdutil.ResolveAPIURL(cmd.APIURL, cmd.ServerURL, out), token)
+
+		var argValue string
+		if len(args) > 0 {
+			argValue = args[0]
+		}
+
+		deploymentID, err := cmdutil.ResolveDeploymentInteractive(c.Context(), client, appID, argValue, "CODEPUSH_DEPLOYMENT", out)
+		if err != nil {
+			return err
+		}
+
+		step := out.StartStep("Downloading and comparing packages")
+		diff, err := codepush.DiffPackages(c.Context(), client, &codepush.PackageDiffOptions{
+			AppID:        appID,
+			DeploymentID: deploymentID,
+			From:         packageDiffFrom,
+			To:           packageDiffTo,
+			Workspace:    cmd.Workspace,
+		})
+		if err != nil {
+			step.Cancel()
+			return err
+		}
+		step.Done()
+
+		if cmd.JSONOutput {
+			return cmdutil.OutputResult(diff)
+		}
+		printPackageDiff(out, diff)
+		return nil
+	},
+}
+
+func printPackageDiff(out *output.Writer, diff *codepush.PackageDiff) {
+	if len(diff.Files) == 0 {
+		out.Info("Releases %s and %s have identical files.", diff.From.Label, diff.To.Label)
+	} else {
+		rows := make([][]string, len(diff.Files))
+		for i, f := range diff.Files {
+			rows[i] = []string{f.Path, f.Change, cmdutil.FormatBytes(f.FromSize), cmdutil.FormatBytes(f.ToSize), formatSizeDelta(f.Delta)}
+		}
+		out.Table([]string{"FILE", "CHANGE", "FROM", "TO", "DELTA"}, rows)
+	}
+
+	out.Result([]output.KeyValue{
+		{Key: "From", Value: fmt.Sprintf("%s (%s)", diff.From.Label, cmdutil.FormatBytes(diff.FromSize))},
+		{Key: "To", Value: fmt.Sprintf("%s (%s)", diff.To.Label, cmdutil.FormatBytes(diff.ToSize))},
+		{Key: "Files", Value: fmt.Sprintf("%d added, %d removed, %d modified, %d unchanged", diff.Added, diff.Removed, diff.Modified, diff.Unchanged)},
+		{Key: "Size change", Value: formatSizeDelta(diff.SizeDelta)},
+	})
+}
+
+// formatSizeDelta returns a signed human-readable size change.
+func formatSizeDelta(delta int64) string {
+	if delta < 0 {
+		return "-" + cmdutil.FormatBytes(-delta)
+	}
+	return "+" + cmdutil.FormatBytes(delta)
+}
+
+var packageTagCmd = &cobra.Command{
+	Use:   "tag <label>",
+	Short: "Add or remove tags of a release",
+	Long: `Attach free-form tags to a release, such as a ticket ID, to correlate it
+with your issue tracker. Without --add or --remove, the tags are listed.
+
+Tags are letters, digits, and - _ . : / #. The API has no tags, so they are
+kept on a "Tags: hotfix, jira-1234" line at the end of the release
+description, which promote copies along with the release. Setting a new
+description with patch --description replaces the line.
+
+Filter releases by tag with deployment history --tag.`,
+	Example: `  codepush package tag v12 --deployment Production --add hotfix,jira-1234
+  codepush package tag v12 --deployment Production --remove hotfix
+  codepush package tag v12 --deployment Production`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(c *cobra.Command, args []string) error {
+		out := cmd.Out
+
+		for _, t := range slices.Concat(packageTagAdd, packageTagRemove) {
+			if err := codepush.ValidateTag(t); err != nil {
+				return codepush.Invalid(err)
+			}
+		}
+
+		appID, token, err := cmdutil.RequireCredentials(c.Context(), cmd.AppID, out, cmd.Relogin, cmd.FirstRun)
+		if err != nil {
+			return err
+		}
+
+		client := cmd.NewClient(cmdutil.ResolveAPIURL(cmd.APIURL, cmd.ServerURL, out), token)
+
+		deploymentID, err := cmdutil.ResolveDeploymentForWrite(c.Context(), client, appID, packageTagDeployment, "CODEPUSH_DEPLOYMENT", out)
+		if err != nil {
+			return err
+		}
+
+		updateID, _, err := codepush.ResolveUpdateForPatch(c.Context(), client, appID, deploymentID, args[0], out)
+		if err != nil {
+			return err
+		}
+
+		pkg, err := codepush.TagRelease(c.Context(), client, &codepush.TagOptions{
+			AppID:        appID,
+			DeploymentID: deploymentID,
+			UpdateID:     updateID,
+			Add:          packageTagAdd,
+			Remove:       packageTagRemove,
+		}, out)
+		if err != nil {
+			return err
+		}
+
+		tags := pkg.Tags()
+		if cmd.JSONOutput {
+			if tags == nil {
+				tags = []string{}
+			}
+			return cmdutil.OutputResult(struct {
+				UpdateID string   `json:"package_id"`
+				Label    string   `json:"label"`
+				Tags     []string `json:"tags"`
+			}{pkg.ID, pkg.Label, tags})
+		}
+
+		if len(tags) == 0 {
+			out.Info("%s has no tags", pkg.Label)
+			return nil
+		}
+		out.Result([]output.KeyValue{
+			{Key: "Release", Value: pkg.Label},
+			{Key: "Tags", Value: strings.Join(tags, ", ")},
+		})
+		return nil
+	},
+}
+
+func init() {
+	packageInfoCmd.Flags().StringVarP(&packageLabel, "label", "l", "", "specific release label (defaults to latest)")
+	packageVerifyCmd.Flags().StringVarP(&packageLabel, "label", "l", "", "specific release label (defaults to latest)")
+	packageDiffCmd.Flags().StringVar(&packageDiffFrom, "from", "", "release label to compare from (defaults to the release before --to)")
+	packageDiffCmd.Flags().StringVar(&packageDiffTo, "to", "", "release label to compare to (defaults to latest)")
+	packageInfoCmd.ValidArgsFunction = cmd.CompleteDeploymentArg
+	packageVerifyCmd.ValidArgsFunction = cmd.CompleteDeploymentArg
+	packageDiffCmd.ValidArgsFunction = cmd.CompleteDeploymentArg
+	_ = packageInfoCmd.RegisterFlagCompletionFunc("label", cmd.CompleteLabels(""))
+	_ = packageVerifyCmd.RegisterFlagCompletionFunc("label", cmd.CompleteLabels(""))
+	_ = packageDiffCmd.RegisterFlagCompletionFunc("from", cmd.CompleteLabels(""))
+	_ = packageDiffCmd.RegisterFlagCompletionFunc("to", cmd.CompleteLabels(""))
+
+	packageTagCmd.Flags().StringVarP(&packageTagDeployment, "deployment", "d", "", "deployment name or UUID (env: CODEPUSH_DEPLOYMENT)")
+	packageTagCmd.Flags().StringSliceVar(&packageTagAdd, "add", nil, "tags to add, comma-separated (e.g. hotfix,jira-1234)")
+	packageTagCmd.Flags().StringSliceVar(&packageTagRemove, "remove", nil, "tags to remove, comma-separated")
+	_ = packageTagCmd.RegisterFlagCompletionFunc("deployment", cmd.CompleteDeployments)
+	packageTagCmd.ValidArgsFunction = func(c *cobra.Command, args []string, toComplete string) ([]cobra.Completion, cobra.ShellCompDirective) {
+		if len(args) > 0 {
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		}
+		return cmd.CompleteLabels("deployment")(c, args, toComplete)
+	}
+
+	packageCmd.AddCommand(packageInfoCmd, packageVerifyCmd, packageDiffCmd, packageTagCmd)
+	cmd.RootCmd.AddCommand(packageCmd)
+}
@@ -0,0 +1,112 @@
+package release
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/bitrise-io/bitrise-plugins-codepush-cli/cmd"
+	"github.com/bitrise-io/bitrise-plugins-codepush-cli/internal/cmdutil"
+	"github.com/bitrise-io/bitrise-plugins-codepush-cli/internal/codepush"
+	"github.com/bitrise-io/bitrise-plugins-codepush-cli/internal/output"
+)
+
+var packageLogsCmd = &cobra.Command{
+	Use:   "logs [deployment]",
+	Short: "Show server-side processing logs of a released package",
+	Long: `Show the server-side processing and validation log of a released package.
+
+By default shows the latest release. Use --label to specify a version.
+Use --follow to stream new entries until processing completes; the command
+exits non-zero if the server rejects the update.`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(c *cobra.Command, args []string) error {
+		out := cmd.Out
+
+		appID, token, err := cmdutil.RequireCredentials(c.Context(), cmd.AppID, out, cmd.Relogin, cmd.FirstRun)
+		if err != nil {
+			return err
+		}
+
+		client := cmd.NewClient(cmdutil.ResolveAPIURL(cmd.APIURL, cmd.ServerURL, out), token)
+
+		var argValue string
+		if len(args) > 0 {
+			argValue = args[0]
+		}
+
+		deploymentID, err := cmdutil.ResolveDeploymentInteractive(c.Context(), client, appID, argValue, "CODEPUSH_DEPLOYMENT", out)
+		if err != nil {
+			return err
+		}
+
+		updateID, label, err := codepush.ResolveUpdateForPatch(c.Context(), client, appID, deploymentID, packageLabel, out)
+		if err != nil {
+			return err
+		}
+
+		if !packageFollow {
+			entries, err := client.ListUpdateLogs(c.Context(), appID, deploymentID, updateID)
+			if err != nil {
+				return err
+			}
+			if cmd.JSONOutput {
+				return cmdutil.OutputResult(entries)
+			}
+			if len(entries) == 0 {
+				out.Info("No processing logs for release %s", label)
+				return nil
+			}
+			for _, e := range entries {
+				printLogEntry(e, out)
+			}
+			return nil
+		}
+
+		ref := codepush.UpdateRef{AppID: appID, DeploymentID: deploymentID, UpdateID: updateID}
+		var entries []codepush.UpdateLogEntry
+		status, err := codepush.FollowLogs(c.Context(), client, ref, codepush.DefaultPollConfig, func(e codepush.UpdateLogEntry) {
+			if cmd.JSONOutput {
+				entries = append(entries, e)
+				return
+			}
+			printLogEntry(e, out)
+		})
+		if err != nil {
+			return err
+		}
+
+		if cmd.JSONOutput {
+			if err := cmdutil.OutputResult(struct {
+				Status       string                    `json:"status"`
+				StatusReason string                    `json:"status_reason,omitempty"`
+				Logs         []codepush.UpdateLogEntry `json:"logs"`
+			}{Status: status.Status, StatusReason: status.StatusReason, Logs: entries}); err != nil {
+				return err
+			}
+		}
+
+		if status.Status == codepush.StatusProcessedError {
+			return fmt.Errorf("%w: %s", codepush.ErrProcessingFailed, status.StatusReason)
+		}
+		if !cmd.JSONOutput {
+			out.Success("Release %s processed", label)
+		}
+		return nil
+	},
+}
+
+// printLogEntry prints one processing log entry as a plain line; the writer
+// masks secrets in it.
+func printLogEntry(e codepush.UpdateLogEntry, out *output.Writer) {
+	out.Println("%s", codepush.FormatLogEntry(e))
+}
+
+func init() {
+	packageLogsCmd.Flags().StringVarP(&packageLabel, "label", "l", "", "specific release label (defaults to latest)")
+	packageLogsCmd.Flags().BoolVarP(&packageFollow, "follow", "f", false, "stream new log entries until processing completes")
+	packageLogsCmd.ValidArgsFunction = cmd.CompleteDeploymentArg
+	_ = packageLogsCmd.RegisterFlagCompletionFunc("label", cmd.CompleteLabels(""))
+
+	packageCmd.AddCommand(packageLogsCmd)
+}
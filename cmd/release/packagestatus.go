@@ -0,0 +1,127 @@
+package release
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/bitrise-io/bitrise-plugins-codepush-cli/cmd"
+	"github.com/bitrise-io/bitrise-plugins-codepush-cli/internal/cmdutil"
+	"github.com/bitrise-io/bitrise-plugins-codepush-cli/internal/codepush"
+	"github.com/bitrise-io/bitrise-plugins-codepush-cli/internal/output"
+)
+
+var packageStatusTimeout time.Duration
+
+var packageStatusCmd = &cobra.Command{
+	Use:   "status [deployment]",
+	Short: "Show the processing status of a released package",
+	Long: `Show the server-side processing status of a released package.
+
+By default shows the latest release. Use --label to specify a version.
+Use --follow to wait until processing completes, printing each status change
+with its time, for example in a later workflow step after 'push --no-wait'.
+Following gives up after --timeout, and exits non-zero if the server rejects
+the update or it is still being processed.`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(c *cobra.Command, args []string) error {
+		out := cmd.Out
+
+		if packageFollow && packageStatusTimeout <= 0 {
+			return codepush.Invalid(fmt.Errorf("--timeout must be positive, got %s", packageStatusTimeout))
+		}
+
+		appID, token, err := cmdutil.RequireCredentials(c.Context(), cmd.AppID, out, cmd.Relogin, cmd.FirstRun)
+		if err != nil {
+			return err
+		}
+
+		client := cmd.NewClient(cmdutil.ResolveAPIURL(cmd.APIURL, cmd.ServerURL, out), token)
+
+		var argValue string
+		if len(args) > 0 {
+			argValue = args[0]
+		}
+
+		deploymentID, err := cmdutil.ResolveDeploymentInteractive(c.Context(), client, appID, argValue, "CODEPUSH_DEPLOYMENT", out)
+		if err != nil {
+			return err
+		}
+
+		updateID, label, err := codepush.ResolveUpdateForPatch(c.Context(), client, appID, deploymentID, packageLabel, out)
+		if err != nil {
+			return err
+		}
+		if packageFollow {
+			ref := codepush.UpdateRef{AppID: appID, DeploymentID: deploymentID, UpdateID: updateID}
+			return followStatus(c.Context(), client, ref, label, out)
+		}
+
+		status, err := client.GetUpdateStatus(c.Context(), appID, deploymentID, updateID)
+		if err != nil {
+			return fmt.Errorf("getting update status: %w", err)
+		}
+
+		if cmd.JSONOutput {
+			return cmdutil.OutputResult(status)
+		}
+
+		pairs := []output.KeyValue{
+			{Key: "Release", Value: label},
+			{Key: "Status", Value: status.Status},
+		}
+		if status.StatusReason != "" {
+			pairs = append(pairs, output.KeyValue{Key: "Reason", Value: status.StatusReason})
+		}
+		out.Result(pairs)
+		return nil
+	},
+}
+
+// followStatus waits for a release to finish processing, printing each
+// status change, and fails when the server rejects it.
+func followStatus(ctx context.Context, client codepush.Client, ref codepush.UpdateRef, label string, out *output.Writer) error {
+	var changes []codepush.StatusChange
+	status, err := codepush.FollowStatus(ctx, client, ref, codepush.PollConfigFor(packageStatusTimeout), func(ch codepush.StatusChange) {
+		out.Record("status_change", ch)
+		if cmd.JSONOutput {
+			changes = append(changes, ch)
+			return
+		}
+		out.Println("%s", codepush.FormatStatusChange(ch))
+	})
+	if err != nil {
+		return err
+	}
+
+	if cmd.JSONOutput {
+		if err := cmdutil.OutputResult(struct {
+			Update       string                  `json:"update"`
+			Status       string                  `json:"status"`
+			StatusReason string                  `json:"status_reason,omitempty"`
+			Changes      []codepush.StatusChange `json:"changes"`
+		}{Update: label, Status: status.Status, StatusReason: status.StatusReason, Changes: changes}); err != nil {
+			return err
+		}
+	}
+
+	if status.Status == codepush.StatusProcessedError {
+		return &codepush.ProcessingError{UpdateID: ref.UpdateID, Reason: status.StatusReason}
+	}
+	if !cmd.JSONOutput {
+		out.Success("Release %s processed", label)
+	}
+	return nil
+}
+
+func init() {
+	packageStatusCmd.Flags().StringVarP(&packageLabel, "label", "l", "", "specific release label (defaults to latest)")
+	packageStatusCmd.Flags().BoolVarP(&packageFollow, "follow", "f", false, "wait until processing completes, printing each status change")
+	packageStatusCmd.Flags().DurationVar(&packageStatusTimeout, "timeout", 2*time.Minute, "with --follow: how long to wait for the release to be processed (e.g. 10m)")
+	packageStatusCmd.ValidArgsFunction = cmd.CompleteDeploymentArg
+	_ = packageStatusCmd.RegisterFlagCompletionFunc("label", cmd.CompleteLabels(""))
+
+	packageCmd.AddCommand(packageStatusCmd)
+}
@@ -7,7 +7,6 @@ import (
 	"github.com/spf13/cobra"
 
 	"github.com/bitrise-io/bitrise-plugins-codepush-cli/cmd"
-	"github.com/bitrise-io/bitrise-plugins-codepush-cli/internal/bitrise"
 	"github.com/bitrise-io/bitrise-plugins-codepush-cli/internal/cmdutil"
 	"github.com/bitrise-io/bitrise-plugins-codepush-cli/internal/codepush"
 	"github.com/bitrise-io/bitrise-plugins-codepush-cli/internal/output"
@@ -21,6 +20,10 @@ var (
 	patchDisabled    string
 	patchDescription string
 	patchAppVersion  string
+
+	patchExpectCurrentRollout string
+	patchOverrideFreeze       string
+	patchTargeting            targetingFlags
 )
 
 var patchCmd = &cobra.Command{
@@ -35,23 +38,31 @@ By default, patches the latest release. Use --label to target a specific version
 
 Examples:
   codepush patch --deployment Production --rollout 50
-  codepush patch --deployment Staging --label v5 --mandatory true --disabled false`,
+  codepush patch --deployment Staging --label v5 --mandatory true --disabled false
+  codepush patch --deployment Production --expect-current-rollout 50 --rollout 100`,
 	GroupID: cmd.GroupRelease,
 	RunE: func(c *cobra.Command, args []string) error {
 		out := cmd.Out
 
-		appID, token, err := cmdutil.RequireCredentials(cmd.AppID, out)
+		freezeReason, err := parseOverrideFreeze(c, patchOverrideFreeze)
+		if err != nil {
+			return err
+		}
+
+		appID, token, err := cmdutil.RequireCredentials(c.Context(), cmd.AppID, out, cmd.Relogin, cmd.FirstRun)
 		if err != nil {
 			return err
 		}
 
-		serverURL := cmdutil.ResolveServerURL(cmd.ServerURL, out)
-		client := codepush.NewHTTPClient(cmdutil.APIURL(serverURL), token, cmd.Version)
+		client := cmd.NewClient(cmdutil.ResolveAPIURL(cmd.APIURL, cmd.ServerURL, out), token)
 
-		deploymentID, err := cmdutil.ResolveDeploymentInteractive(c.Context(), client, appID, patchDeployment, "CODEPUSH_DEPLOYMENT", out)
+		deploymentID, err := cmdutil.ResolveDeploymentForWrite(c.Context(), client, appID, patchDeployment, "CODEPUSH_DEPLOYMENT", out)
 		if err != nil {
 			return err
 		}
+		if err := enforceFreeze(c.Context(), client, "patch", appID, deploymentID, freezeReason, out); err != nil {
+			return err
+		}
 
 		opts := &codepush.PatchOptions{
 			AppID:        appID,
@@ -63,15 +74,33 @@ Examples:
 			Disabled:     patchDisabled,
 			Description:  patchDescription,
 			AppVersion:   patchAppVersion,
+
+			ExpectCurrentRollout: patchExpectCurrentRollout,
 		}
+		patchTargeting.applyToPatch(c, opts)
 
 		result, err := codepush.Patch(c.Context(), client, opts, out)
 		if err != nil {
 			return fmt.Errorf("patch failed: %w", err)
 		}
 
+		cmdutil.ExportStepOutputs("codepush-patch-summary.json", result, cmdutil.StepOutputs{
+			UpdateID:     result.UpdateID,
+			Label:        result.Label,
+			AppVersion:   result.AppVersion,
+			DeploymentID: result.DeploymentID,
+		}, out)
+		cmdutil.AnnotateRelease(cmdutil.ReleaseAnnotation{
+			Title:        fmt.Sprintf("Patched %s in %s", result.Label, deploymentDisplayName(patchDeployment, result.DeploymentID)),
+			AppID:        result.AppID,
+			DeploymentID: result.DeploymentID,
+			UpdateID:     result.UpdateID,
+			Label:        result.Label,
+			AppVersion:   result.AppVersion,
+			Rollout:      &result.Rollout,
+		}, out)
 		if cmd.JSONOutput {
-			return cmdutil.OutputJSON(result)
+			return cmdutil.OutputResult(result)
 		}
 
 		out.Success("Patch successful")
@@ -82,17 +111,9 @@ Examples:
 			{Key: "Rollout", Value: fmt.Sprintf("%d%%", result.Rollout)},
 			{Key: "Mandatory", Value: strconv.FormatBool(result.Mandatory)},
 			{Key: "Disabled", Value: strconv.FormatBool(result.Disabled)},
+			{Key: "Targeting", Value: targetingSummary(result.Targeting)},
 		})
 
-		if bitrise.IsBitriseEnvironment() {
-			cmdutil.ExportDeploySummary("codepush-patch-summary.json", result, out)
-			cmdutil.ExportEnvVars(map[string]string{
-				"CODEPUSH_UPDATE_ID":   result.UpdateID,
-				"CODEPUSH_LABEL":       result.Label,
-				"CODEPUSH_APP_VERSION": result.AppVersion,
-			}, out)
-		}
-
 		return nil
 	},
 }
@@ -104,6 +125,11 @@ func init() {
 	patchCmd.Flags().StringVarP(&patchMandatory, "mandatory", "m", "", "mark update as mandatory (true/false)")
 	patchCmd.Flags().StringVarP(&patchDisabled, "disabled", "x", "", "disable update (true/false)")
 	patchCmd.Flags().StringVar(&patchDescription, "description", "", "update description")
-	patchCmd.Flags().StringVarP(&patchAppVersion, "app-version", "t", "", "target app version")
+	patchCmd.Flags().StringVarP(&patchAppVersion, "app-version", "t", "", "target app version, an exact version or a semver range; four-part versions such as 1.0.0.1 are rejected")
+	patchCmd.Flags().StringVar(&patchOverrideFreeze, "override-freeze", "", "change the deployment even inside a freeze window in .codepush.json, giving the reason; the override is logged")
+	patchCmd.Flags().StringVar(&patchExpectCurrentRollout, "expect-current-rollout", "", "abort unless the release is currently at this rollout percentage")
+	registerTargetingFlagsOn(patchCmd, &patchTargeting)
+	_ = patchCmd.RegisterFlagCompletionFunc("deployment", cmd.CompleteDeployments)
+	_ = patchCmd.RegisterFlagCompletionFunc("label", cmd.CompleteLabels("deployment"))
 	cmd.RootCmd.AddCommand(patchCmd)
 }
@@ -0,0 +1,61 @@
+package release
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/bitrise-io/bitrise-plugins-codepush-cli/internal/codepush"
+	"github.com/bitrise-io/bitrise-plugins-codepush-cli/internal/output"
+)
+
+// checkBundlePlatform fails the push when the bundle directory looks like it
+// was built for a different platform than the given one (--platform) or, when
+// it is empty, the connected app's platform. --allow-platform-mismatch downgrades the
+// failure to a warning.
+func checkBundlePlatform(ctx context.Context, client codepush.Client, appID, bundlePath, platform string, out *output.Writer) error {
+	detected, err := codepush.DetectBundlePlatform(bundlePath)
+	if err != nil {
+		out.Warning("skipping platform check: %v", err)
+		return nil
+	}
+	if detected.Platform == "" {
+		if len(detected.Evidence) > 0 {
+			out.Warning("bundle contains artifacts for both platforms (%s): make sure it was built for a single platform",
+				strings.Join(detected.Evidence, ", "))
+		}
+		return nil
+	}
+
+	expected, source := codepush.NormalizePlatform(platform), "--platform"
+	if platform != "" && expected == "" {
+		return fmt.Errorf("invalid platform %q: must be ios or android", platform)
+	}
+	if expected == "" {
+		expected, err = codepush.AppPlatform(ctx, client, appID)
+		if err != nil {
+			out.Warning("skipping platform check: %v", err)
+			return nil
+		}
+		source = "the connected app"
+	}
+	if expected == "" || expected == detected.Platform {
+		return nil
+	}
+
+	msg := fmt.Sprintf("bundle looks like a %s bundle (%s) but %s targets %s: bundle with --platform %s, or push the %s output directory",
+		platformName(detected.Platform), strings.Join(detected.Evidence, ", "), source, platformName(expected), expected, platformName(expected))
+	if pushAllowPlatformMismatch {
+		out.Warning("%s", msg)
+		return nil
+	}
+	return fmt.Errorf("%s (pass --allow-platform-mismatch to push anyway)", msg)
+}
+
+// platformName returns the display name of a normalized platform.
+func platformName(p string) string {
+	if p == codepush.PlatformIOS {
+		return "iOS"
+	}
+	return "Android"
+}
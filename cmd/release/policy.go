@@ -0,0 +1,188 @@
+package release
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/bitrise-io/bitrise-plugins-codepush-cli/internal/codepush"
+	"github.com/bitrise-io/bitrise-plugins-codepush-cli/internal/config"
+	"github.com/bitrise-io/bitrise-plugins-codepush-cli/internal/output"
+	"github.com/bitrise-io/bitrise-plugins-codepush-cli/internal/policylog"
+)
+
+// enforcePushPolicy checks a push against the policy in .codepush.json.
+func enforcePushPolicy(ctx context.Context, client codepush.Client, opts *codepush.PushOptions, override bool, out *output.Writer) error {
+	cfg, err := config.Load()
+	if err != nil {
+		return err
+	}
+	if cfg == nil || cfg.Policy == nil {
+		return nil
+	}
+
+	dep, err := client.GetDeployment(ctx, opts.AppID, opts.DeploymentID)
+	if err != nil {
+		return fmt.Errorf("checking release policy: %w", err)
+	}
+	release := config.PolicyRelease{
+		Deployment:  dep.Name,
+		Rollout:     opts.Rollout,
+		Mandatory:   opts.Mandatory,
+		Description: opts.Description,
+	}
+	return checkPolicy(cfg, &policyCheck{
+		command:    "push",
+		appID:      opts.AppID,
+		release:    release,
+		override:   override,
+		provenance: opts.Provenance,
+	}, out)
+}
+
+// enforcePromotePolicy checks a promote against the policy in .codepush.json,
+// using the metadata the promoted release will have after overrides.
+func enforcePromotePolicy(ctx context.Context, client codepush.Client, opts *codepush.PromoteOptions, override bool, out *output.Writer) error {
+	cfg, err := config.Load()
+	if err != nil {
+		return err
+	}
+	if cfg == nil || cfg.Policy == nil {
+		return nil
+	}
+
+	source, err := client.GetDeployment(ctx, opts.AppID, opts.SourceDeploymentID)
+	if err != nil {
+		return fmt.Errorf("checking release policy: %w", err)
+	}
+	preview, err := codepush.PreviewPromote(ctx, client, opts, out)
+	if err != nil {
+		return fmt.Errorf("checking release policy: %w", err)
+	}
+	release := config.PolicyRelease{
+		Deployment:       preview.Destination,
+		SourceDeployment: source.Name,
+		Rollout:          int(math.Round(preview.Release.Rollout)),
+		Mandatory:        preview.Release.Mandatory,
+		Description:      preview.Release.Description,
+	}
+	return checkPolicy(cfg, &policyCheck{
+		command:    "promote",
+		appID:      opts.AppID,
+		release:    release,
+		override:   override,
+		provenance: codepush.CollectProvenance(ctx, "."),
+	}, out)
+}
+
+// policyCheck is a release checked against the policy by checkPolicy.
+type policyCheck struct {
+	// command is the command releasing it, "push" or "promote".
+	command string
+	appID   string
+	release config.PolicyRelease
+	// override is --override-policy.
+	override bool
+	// provenance is recorded with an override; nil records none.
+	provenance *codepush.Provenance
+}
+
+// checkPolicy fails with a PolicyError when the release of check violates
+// the policy. With check.override, each violation is logged as a warning
+// and the override is recorded in the policy override log instead; if it
+// cannot be recorded, the release is refused.
+func checkPolicy(cfg *config.ProjectConfig, check *policyCheck, out *output.Writer) error {
+	release := check.release
+	violations := cfg.PolicyViolations(release)
+	if len(violations) == 0 {
+		return nil
+	}
+	if !check.override {
+		return &codepush.PolicyError{Violations: violations}
+	}
+
+	entry := policylog.Override{
+		Command:          check.command,
+		AppID:            check.appID,
+		Deployment:       release.Deployment,
+		SourceDeployment: release.SourceDeployment,
+		Violations:       violations,
+	}
+	if check.provenance != nil {
+		entry.BuildURL = check.provenance.BuildURL
+	}
+	path, err := policylog.Record(entry)
+	if err != nil {
+		return fmt.Errorf("recording policy override: %w", err)
+	}
+	out.Warning("release policy overridden (--override-policy) for %s %s: %s", check.command, release.Deployment, strings.Join(violations, "; "))
+	out.Info("Override recorded in %s", path)
+	return nil
+}
+
+// parseOverrideFreeze returns the reason given with --override-freeze, or ""
+// when the flag is not set. The value may also be written as
+// reason="sev1 hotfix".
+func parseOverrideFreeze(c *cobra.Command, value string) (string, error) {
+	if !c.Flags().Changed("override-freeze") {
+		return "", nil
+	}
+	reason := strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(value), "reason="))
+	if reason == "" {
+		return "", codepush.Invalid(errors.New(`--override-freeze requires a reason, e.g. --override-freeze "sev1 hotfix"`))
+	}
+	return reason, nil
+}
+
+// enforceFreeze fails with a FreezeError when the deployment is inside a
+// freeze window declared in .codepush.json. With a reason from
+// --override-freeze, the change goes ahead and the override is recorded in
+// the policy override log instead; if it cannot be recorded, the change is
+// refused.
+func enforceFreeze(ctx context.Context, client codepush.Client, command, appID, deploymentID, reason string, out *output.Writer) error {
+	cfg, err := config.Load()
+	if err != nil {
+		return err
+	}
+	if cfg == nil || len(cfg.FreezeWindows) == 0 {
+		return nil
+	}
+
+	dep, err := client.GetDeployment(ctx, appID, deploymentID)
+	if err != nil {
+		return fmt.Errorf("checking freeze windows: %w", err)
+	}
+	freeze, err := cfg.ActiveFreeze(dep.Name, time.Now())
+	if err != nil {
+		return codepush.Invalid(fmt.Errorf("%s: %w", config.FileName, err))
+	}
+	if freeze == nil {
+		return nil
+	}
+	if reason == "" {
+		return &codepush.FreezeError{Deployment: dep.Name, Window: freeze.Window.String(), Until: freeze.Until}
+	}
+
+	entry := policylog.Override{
+		Command:    command,
+		AppID:      appID,
+		Deployment: dep.Name,
+		Violations: []string{"inside freeze window " + freeze.Window.String()},
+		Reason:     reason,
+	}
+	if prov := codepush.CollectProvenance(ctx, "."); prov != nil {
+		entry.BuildURL = prov.BuildURL
+	}
+	path, err := policylog.Record(entry)
+	if err != nil {
+		return fmt.Errorf("recording freeze override: %w", err)
+	}
+	out.Warning("freeze window %s overridden (--override-freeze) for %s %s: %s", freeze.Window, command, dep.Name, reason)
+	out.Info("Override recorded in %s", path)
+	return nil
+}
@@ -1,16 +1,21 @@
 package release
 
 import (
+	"context"
 	"errors"
 	"fmt"
+	"strconv"
+	"time"
 
 	"github.com/spf13/cobra"
 
 	"github.com/bitrise-io/bitrise-plugins-codepush-cli/cmd"
-	"github.com/bitrise-io/bitrise-plugins-codepush-cli/internal/bitrise"
 	"github.com/bitrise-io/bitrise-plugins-codepush-cli/internal/cmdutil"
 	"github.com/bitrise-io/bitrise-plugins-codepush-cli/internal/codepush"
+	"github.com/bitrise-io/bitrise-plugins-codepush-cli/internal/config"
 	"github.com/bitrise-io/bitrise-plugins-codepush-cli/internal/output"
+	"github.com/bitrise-io/bitrise-plugins-codepush-cli/internal/releaser"
+	"github.com/bitrise-io/bitrise-plugins-codepush-cli/internal/schedule"
 )
 
 var (
@@ -23,6 +28,12 @@ var (
 	promoteDisabled         string
 	promoteRollout          string
 	promoteNoDuplicateError bool
+	promoteExpectSourceHash string
+	promoteActivateAt       string
+	promoteOverrideFreeze   string
+	promoteNotes            descriptionFlags
+	promoteYes              bool
+	promoteOverridePolicy   bool
 )
 
 var promoteCmd = &cobra.Command{
@@ -34,25 +45,34 @@ Copies the latest (or specified) release from the source deployment to the
 destination deployment. Override metadata like rollout percentage, mandatory
 flag, or description for the promoted release.
 
+Promoting to a protected deployment (Production, or protected_deployments in
+.codepush.json) shows how its latest release would change and asks you to
+type the deployment name. Pass --yes to skip the confirmation, which is
+required in non-interactive mode.
+
 Example: promote from Staging to Production after testing.`,
 	GroupID: cmd.GroupRelease,
 	RunE: func(c *cobra.Command, args []string) error {
 		out := cmd.Out
 
-		appID, token, err := cmdutil.RequireCredentials(cmd.AppID, out)
+		flags, err := parsePromoteFlags(c)
 		if err != nil {
 			return err
 		}
 
-		serverURL := cmdutil.ResolveServerURL(cmd.ServerURL, out)
-		client := codepush.NewHTTPClient(cmdutil.APIURL(serverURL), token, cmd.Version)
+		appID, token, err := cmdutil.RequireCredentials(c.Context(), cmd.AppID, out, cmd.Relogin, cmd.FirstRun)
+		if err != nil {
+			return err
+		}
 
-		sourceDeploymentID, err := cmdutil.ResolveDeploymentInteractive(c.Context(), client, appID, promoteSourceDeployment, "CODEPUSH_DEPLOYMENT", out)
+		client := cmd.NewClient(cmdutil.ResolveAPIURL(cmd.APIURL, cmd.ServerURL, out), token)
+
+		sourceDeploymentID, err := cmdutil.ResolveDeploymentForWrite(c.Context(), client, appID, promoteSourceDeployment, "CODEPUSH_DEPLOYMENT", out)
 		if err != nil {
 			return err
 		}
 
-		destDeploymentID, err := cmdutil.ResolveDeploymentInteractive(c.Context(), client, appID, promoteDestDeployment, "", out)
+		destDeploymentID, err := cmdutil.ResolveDeploymentForWrite(c.Context(), client, appID, promoteDestDeployment, "", out)
 		if err != nil {
 			return err
 		}
@@ -64,53 +84,209 @@ Example: promote from Staging to Production after testing.`,
 			Token:              token,
 			Label:              promoteLabel,
 			AppVersion:         promoteAppVersion,
-			Description:        promoteDescription,
+			Description:        flags.description,
 			Mandatory:          promoteMandatory,
-			Disabled:           promoteDisabled,
+			Disabled:           flags.disabled,
 			Rollout:            promoteRollout,
+			ExpectSourceHash:   promoteExpectSourceHash,
 		}
 
-		result, err := codepush.Promote(c.Context(), client, opts, out)
+		result, err := runPromote(c.Context(), client, opts, flags, out)
 		if err != nil {
 			if promoteNoDuplicateError && errors.Is(err, codepush.ErrDuplicateRelease) {
 				out.Warning("Duplicate release: identical content already exists in target deployment, skipping")
 				return nil
 			}
-			return fmt.Errorf("promote failed: %w", err)
+			return err
 		}
+		return reportPromote(result, out)
+	},
+}
+
+// promoteFlags holds the promote flags that are resolved before the
+// deployments are.
+type promoteFlags struct {
+	activateAt   time.Time
+	freezeReason string
+	disabled     string
+	description  string
+}
 
-		if cmd.JSONOutput {
-			return cmdutil.OutputJSON(result)
+// parsePromoteFlags validates --activate-at, --override-freeze and
+// --disabled and resolves the release description.
+func parsePromoteFlags(c *cobra.Command) (promoteFlags, error) {
+	activateAt, err := parseActivateAt(c, promoteActivateAt)
+	if err != nil {
+		return promoteFlags{}, err
+	}
+	freezeReason, err := parseOverrideFreeze(c, promoteOverrideFreeze)
+	if err != nil {
+		return promoteFlags{}, err
+	}
+	disabled := promoteDisabled
+	if !activateAt.IsZero() {
+		if promoteDisabled != "" {
+			flag := activateAtFlag(c)
+			return promoteFlags{}, fmt.Errorf("%s and --disabled cannot be used together: %s already creates the release disabled", flag, flag)
 		}
+		disabled = "true"
+	}
+
+	description, err := promoteNotes.resolve(c.Context(), promoteDescription)
+	if err != nil {
+		return promoteFlags{}, codepush.Invalid(err)
+	}
+	return promoteFlags{
+		activateAt:   activateAt,
+		freezeReason: freezeReason,
+		disabled:     disabled,
+		description:  description,
+	}, nil
+}
+
+// runPromote checks the release policy, freeze windows and protected
+// deployments, then promotes and schedules the activation of the promoted
+// release.
+func runPromote(ctx context.Context, client codepush.Client, opts *codepush.PromoteOptions, flags promoteFlags, out *output.Writer) (*codepush.PromoteResult, error) {
+	if err := enforcePromotePolicy(ctx, client, opts, promoteOverridePolicy, out); err != nil {
+		return nil, err
+	}
+	if err := enforceFreeze(ctx, client, "promote", opts.AppID, opts.DestDeploymentID, flags.freezeReason, out); err != nil {
+		return nil, err
+	}
+	if err := confirmProtectedPromote(ctx, client, opts, out); err != nil {
+		return nil, err
+	}
+
+	result, err := releaser.New(opts.AppID, opts.Token, client, out).Promote(ctx, opts)
+	if err != nil {
+		return nil, fmt.Errorf("promote failed: %w", err)
+	}
 
-		out.Success("Promote successful")
-		out.Result([]output.KeyValue{
-			{Key: "Update ID", Value: result.UpdateID},
-			{Key: "Label", Value: result.Label},
-			{Key: "App version", Value: result.AppVersion},
-			{Key: "Destination", Value: result.DestDeployment},
-		})
-
-		if bitrise.IsBitriseEnvironment() {
-			cmdutil.ExportEnvVars(map[string]string{
-				"CODEPUSH_UPDATE_ID":   result.UpdateID,
-				"CODEPUSH_APP_VERSION": result.AppVersion,
-			}, out)
+	if !flags.activateAt.IsZero() {
+		if err := scheduleActivation(schedule.Activation{
+			AppID:        opts.AppID,
+			DeploymentID: opts.DestDeploymentID,
+			Deployment:   promoteDestDeployment,
+			UpdateID:     result.UpdateID,
+			Label:        result.Label,
+			ActivateAt:   flags.activateAt,
+		}); err != nil {
+			return nil, err
 		}
+	}
+	return result, nil
+}
+
+// reportPromote exports step outputs, annotates the build and prints the
+// promoted release.
+func reportPromote(result *codepush.PromoteResult, out *output.Writer) error {
+	cmdutil.ExportStepOutputs("codepush-promote-summary.json", result, cmdutil.StepOutputs{
+		UpdateID:     result.UpdateID,
+		Label:        result.Label,
+		AppVersion:   result.AppVersion,
+		DeploymentID: result.DestDeployment,
+	}, out)
+	cmdutil.AnnotateRelease(cmdutil.ReleaseAnnotation{
+		Title:        fmt.Sprintf("Promoted %s to %s", result.Label, promoteDestDeployment),
+		AppID:        result.AppID,
+		DeploymentID: result.DestDeployment,
+		UpdateID:     result.UpdateID,
+		Label:        result.Label,
+		AppVersion:   result.AppVersion,
+	}, out)
+	if cmd.JSONOutput {
+		return cmdutil.OutputResult(result)
+	}
 
+	out.Success("Promote successful")
+	out.Result([]output.KeyValue{
+		{Key: "Update ID", Value: result.UpdateID},
+		{Key: "Label", Value: result.Label},
+		{Key: "App version", Value: result.AppVersion},
+		{Key: "Destination", Value: result.DestDeployment},
+	})
+
+	return nil
+}
+
+// confirmProtectedPromote requires typed confirmation before a promote to a
+// protected deployment, showing how its latest release would change.
+func confirmProtectedPromote(ctx context.Context, client codepush.Client, opts *codepush.PromoteOptions, out *output.Writer) error {
+	if promoteYes {
 		return nil
-	},
+	}
+	cfg, err := config.Load()
+	if err != nil {
+		return err
+	}
+
+	dest, err := client.GetDeployment(ctx, opts.AppID, opts.DestDeploymentID)
+	if err != nil {
+		return fmt.Errorf("getting destination deployment: %w", err)
+	}
+	if !cfg.IsProtected(dest.Name) {
+		return nil
+	}
+
+	preview, err := codepush.PreviewPromote(ctx, client, opts, out)
+	if err != nil {
+		return err
+	}
+
+	if out.IsInteractive() {
+		printPromotePreview(out, preview)
+	}
+	return out.ConfirmTyped(
+		fmt.Sprintf("%s is a protected deployment: this promotes %s to it", preview.Destination, preview.Source.Label),
+		preview.Destination,
+		promoteYes,
+	)
+}
+
+// printPromotePreview compares the destination's latest release with the
+// release the promote creates.
+func printPromotePreview(out *output.Writer, preview *codepush.PromotePreview) {
+	current := codepush.Update{Label: "(none)"}
+	if preview.Current != nil {
+		current = *preview.Current
+	}
+	release := preview.Release
+	rows := [][]string{
+		{"Release", current.Label, "new release from " + preview.Source.Label},
+		{"App version", current.AppVersion, release.AppVersion},
+		{"Size", cmdutil.FormatBytes(current.FileSizeBytes), cmdutil.FormatBytes(release.FileSizeBytes)},
+		{"Mandatory", strconv.FormatBool(current.Mandatory), strconv.FormatBool(release.Mandatory)},
+		{"Disabled", strconv.FormatBool(current.Disabled), strconv.FormatBool(release.Disabled)},
+		{"Rollout", fmt.Sprintf("%.0f%%", current.Rollout), fmt.Sprintf("%.0f%%", release.Rollout)},
+		{"Description", cmdutil.Truncate(current.Description, 40), cmdutil.Truncate(release.Description, 40)},
+	}
+	if preview.Current == nil {
+		for _, row := range rows[1:] {
+			row[1] = ""
+		}
+	}
+	out.Table([]string{"", preview.Destination + " now", "after promote"}, rows)
 }
 
 func init() {
 	promoteCmd.Flags().StringVarP(&promoteSourceDeployment, "source-deployment", "s", "", "source deployment name or UUID (env: CODEPUSH_DEPLOYMENT)")
 	promoteCmd.Flags().StringVarP(&promoteDestDeployment, "destination-deployment", "d", "", "destination deployment name or UUID (required)")
 	promoteCmd.Flags().StringVarP(&promoteLabel, "label", "l", "", "specific release label to promote (e.g. v5)")
-	promoteCmd.Flags().StringVarP(&promoteAppVersion, "app-version", "t", "", "override target app version")
+	promoteCmd.Flags().StringVarP(&promoteAppVersion, "app-version", "t", "", "override target app version, an exact version or a semver range; four-part versions such as 1.0.0.1 are rejected")
 	promoteCmd.Flags().StringVar(&promoteDescription, "description", "", "override release description")
+	registerDescriptionFlagsOn(promoteCmd, &promoteNotes)
+	promoteCmd.Flags().BoolVarP(&promoteYes, "yes", "y", false, "skip the confirmation for protected deployments")
+	promoteCmd.Flags().BoolVar(&promoteOverridePolicy, "override-policy", false, "promote even if the release violates the policy in .codepush.json; the override is logged")
+	promoteCmd.Flags().StringVar(&promoteOverrideFreeze, "override-freeze", "", "change the deployment even inside a freeze window in .codepush.json, giving the reason; the override is logged")
 	promoteCmd.Flags().StringVarP(&promoteMandatory, "mandatory", "m", "", "override mandatory flag (true/false)")
 	promoteCmd.Flags().StringVarP(&promoteDisabled, "disabled", "x", "", "override disabled flag (true/false)")
 	promoteCmd.Flags().StringVarP(&promoteRollout, "rollout", "r", "", "override rollout percentage (0-100)")
 	promoteCmd.Flags().BoolVar(&promoteNoDuplicateError, "no-duplicate-release-error", false, "exit 0 with a warning instead of an error when the target deployment already contains identical content")
+	promoteCmd.Flags().StringVar(&promoteExpectSourceHash, "expect-source-hash", "", "abort unless the release being promoted has this package hash")
+	addActivateAtFlags(promoteCmd, &promoteActivateAt, "create the promoted release disabled and schedule its activation")
+	_ = promoteCmd.RegisterFlagCompletionFunc("source-deployment", cmd.CompleteDeployments)
+	_ = promoteCmd.RegisterFlagCompletionFunc("destination-deployment", cmd.CompleteDeployments)
+	_ = promoteCmd.RegisterFlagCompletionFunc("label", cmd.CompleteLabels("source-deployment"))
 	cmd.RootCmd.AddCommand(promoteCmd)
 }
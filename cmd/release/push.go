@@ -1,28 +1,64 @@
 package release
 
 import (
+	"context"
 	"errors"
 	"fmt"
+	"os"
 	"path/filepath"
+	"slices"
+	"strings"
+	"time"
 
 	"github.com/spf13/cobra"
 
 	"github.com/bitrise-io/bitrise-plugins-codepush-cli/cmd"
-	"github.com/bitrise-io/bitrise-plugins-codepush-cli/internal/bitrise"
 	"github.com/bitrise-io/bitrise-plugins-codepush-cli/internal/bundler"
 	"github.com/bitrise-io/bitrise-plugins-codepush-cli/internal/cmdutil"
 	"github.com/bitrise-io/bitrise-plugins-codepush-cli/internal/codepush"
+	"github.com/bitrise-io/bitrise-plugins-codepush-cli/internal/config"
 	"github.com/bitrise-io/bitrise-plugins-codepush-cli/internal/output"
+	"github.com/bitrise-io/bitrise-plugins-codepush-cli/internal/releaser"
+	"github.com/bitrise-io/bitrise-plugins-codepush-cli/internal/schedule"
+	"github.com/bitrise-io/bitrise-plugins-codepush-cli/internal/session"
+	"github.com/bitrise-io/bitrise-plugins-codepush-cli/internal/sourcemaps"
+	ziputil "github.com/bitrise-io/bitrise-plugins-codepush-cli/internal/zip"
 )
 
 var (
 	pushAutoBundle  bool
-	pushDeployment  string
+	pushDeployments []string
 	pushAppVersion  string
 	pushDescription string
 	pushMandatory   bool
 	pushRollout     int
 	pushDisabled    bool
+
+	pushCheckNativeChanges  bool
+	pushFailOnNativeChange  bool
+	pushUploadStrategy      string
+	pushSkipSourcemapPolicy bool
+	pushOverridePolicy      bool
+	pushOverrideFreeze      string
+	pushExpectLabel         string
+
+	pushAllowPlatformMismatch bool
+	pushActivateAt            string
+	pushFull                  bool
+	pushCompression           string
+	pushNoWait                bool
+	pushTimeout               time.Duration
+	pushTargeting             targetingFlags
+	pushDetectAppVersion      bool
+	pushNotes                 descriptionFlags
+
+	pushResume  bool
+	pushDiscard bool
+
+	pushAppsFile    string
+	pushConcurrency int
+
+	pushAllowDuplicate bool
 )
 
 var pushCmd = &cobra.Command{
@@ -33,117 +69,848 @@ var pushCmd = &cobra.Command{
 Uploads the specified bundle and deploys it to the CodePush server
 for distribution to connected devices.
 
-Use --bundle to automatically generate the JavaScript bundle before pushing.`,
-	GroupID: cmd.GroupRelease,
-	Args:    cobra.MaximumNArgs(1),
+When the deployment already has a release, only the files that changed
+since it are uploaded as a delta package. Use --full to upload the whole
+package. When the latest release already has the bundle's content, the push
+fails before uploading anything; with --allow-duplicate it is skipped and
+the command succeeds.
+
+Use --bundle to automatically generate the JavaScript bundle before pushing.
+
+To release the same bundle to several deployments, give them as a
+comma-separated list or repeat --deployment. The bundle is uploaded once, to
+the first deployment, and the release is promoted to the others with the
+same settings. A deployment that fails does not stop the others; the
+command then fails and reports each deployment's result.
+
+For white-label builds that ship one bundle as several apps, repeat --app-id
+or list the apps in --apps-file, one app UUID per line optionally followed by
+a name. The bundle is pushed to the deployments of the same name in each app,
+with up to --concurrency apps at once. An app that fails does not stop the
+others; the command then fails, listing only the failed apps.
+
+With --bundle --platform all (or ios,android), both platforms are bundled
+at once and each app gets the bundle built for its platform, so give an iOS
+and an Android app.`,
+	GroupID:     cmd.GroupRelease,
+	Annotations: map[string]string{cmd.AnnotationMultiApp: "true"},
+	Args:        cobra.MaximumNArgs(1),
 	RunE: func(c *cobra.Command, args []string) error {
 		out := cmd.Out
 
-		if pushAutoBundle {
-			platform, err := cmdutil.ResolvePlatformInteractive(bundlePlatform, out)
-			if err != nil {
+		if pushDiscard {
+			if err := session.ClearPush(); err != nil {
 				return err
 			}
-			bundlePlatform = platform
+			out.Success("Discarded saved push session")
+			return nil
+		}
+
+		state, err := loadPushSession(out)
+		if err != nil {
+			return err
+		}
 
-			result, err := runBundleWithOpts(out)
-			if err != nil {
-				return fmt.Errorf("bundling failed: %w", err)
+		if err := runPush(c, args, state); err != nil {
+			if out.IsInteractive() && !state.IsEmpty() {
+				if saveErr := session.SavePush(state); saveErr != nil {
+					out.Warning("could not save push session: %v", saveErr)
+				} else {
+					out.Info("Answers saved: run 'codepush push --resume' to continue or 'codepush push --discard' to clear them")
+				}
 			}
+			return err
+		}
 
-			out.Info("Bundle created at: %s", result.OutputDir)
-			args = []string{result.OutputDir}
+		if err := session.ClearPush(); err != nil {
+			out.Warning("could not clear push session: %v", err)
 		}
+		return nil
+	},
+}
 
-		if len(args) == 0 {
-			return errors.New("bundle path is required: provide as argument or use --bundle to generate one")
+// loadPushSession returns the wizard state to start the push with. With
+// --resume it is the saved session; otherwise an interactive user is offered
+// to resume a saved session, and a fresh state is returned if they decline.
+func loadPushSession(out *output.Writer) (*session.PushState, error) {
+	saved, err := session.LoadPush()
+	if err != nil {
+		if pushResume {
+			return nil, err
 		}
+		out.Warning("ignoring saved push session: %v", err)
+		saved = nil
+	}
 
-		bundlePath, err := filepath.Abs(args[0])
+	if pushResume {
+		if saved == nil {
+			return nil, errors.New("no saved push session to resume: sessions are saved when an interactive push is interrupted")
+		}
+		out.Info("Resuming push session from %s", saved.SavedAt.Local().Format(time.DateTime))
+		return saved, nil
+	}
+
+	if saved != nil && !saved.IsEmpty() && out.IsInteractive() {
+		choice, err := out.Select(
+			fmt.Sprintf("Resume the interrupted push from %s?", saved.SavedAt.Local().Format(time.DateTime)),
+			[]output.SelectOption{
+				{Label: describePushState(saved), Value: "resume"},
+				{Label: "Start over", Value: "new"},
+			},
+		)
 		if err != nil {
-			return fmt.Errorf("resolving bundle path: %w", err)
+			return nil, err
+		}
+		if choice == "resume" {
+			return saved, nil
 		}
+	}
 
-		if bundlePrivateKeyPath != "" {
-			stepSign := out.StartStep("Signing bundle")
-			if err := bundler.SignBundle(bundlePath, bundlePrivateKeyPath, cmd.Version); err != nil {
-				stepSign.Cancel()
-				return fmt.Errorf("signing bundle: %w", err)
-			}
-			stepSign.Done()
-			out.Info("Signed: %s/.codepushrelease", bundlePath)
+	return &session.PushState{}, nil
+}
+
+// describePushState summarises the answers in a saved push session.
+func describePushState(s *session.PushState) string {
+	var parts []string
+	if s.Platform != "" {
+		parts = append(parts, s.Platform)
+	}
+	if s.Deployment != "" {
+		parts = append(parts, "deployment "+s.Deployment)
+	}
+	if s.AppVersion != "" {
+		parts = append(parts, "version "+s.AppVersion)
+	}
+	return "Resume (" + strings.Join(parts, ", ") + ")"
+}
+
+// pushFlags are the push settings parsed and validated from the flags by
+// validatePushFlags.
+type pushFlags struct {
+	// appVersion is the normalized --app-version; empty when it is not set.
+	appVersion   string
+	activateAt   time.Time
+	freezeReason string
+	compression  ziputil.Compression
+	description  string
+	targeting    codepush.Targeting
+	deployments  []string
+	apps         []codepush.AppTarget
+	uploader     sourcemaps.Uploader
+}
+
+// pushBundle is the bundle a push releases: a directory given as an
+// argument, or the output of --bundle for one or several platforms.
+type pushBundle struct {
+	// platform is the platform the bundle is checked against and the app
+	// version is detected for. It is empty when --platform is not set, or
+	// when bundling for several platforms, where each app's bundle is
+	// checked against the app's own platform.
+	platform string
+	// platforms are the platforms --bundle builds for.
+	platforms []bundler.Platform
+	// path is the bundle directory; it is empty for several platforms.
+	path string
+	// result is the --bundle output for one platform, and results the
+	// output for each of several platforms.
+	result  *bundler.BundleResult
+	results []*bundler.BundleResult
+}
+
+func runPush(c *cobra.Command, args []string, state *session.PushState) error {
+	ctx, out := c.Context(), cmd.Out
+
+	flags, err := validatePushFlags(c, out)
+	if err != nil {
+		return err
+	}
+	bundle, err := resolvePushBundle(args, flags, state, out)
+	if err != nil {
+		return err
+	}
+
+	// Credentials are checked before bundling, so a token that cannot
+	// release fails now rather than after the bundle is built and uploaded.
+	credentialsAppID := cmd.AppID
+	if len(flags.apps) > 0 {
+		credentialsAppID = flags.apps[0].ID
+	}
+	appID, token, err := cmdutil.RequireCredentials(ctx, credentialsAppID, out, cmd.Relogin, cmd.FirstRun)
+	if err != nil {
+		return err
+	}
+	client := cmd.NewClient(cmdutil.ResolveAPIURL(cmd.APIURL, cmd.ServerURL, out), token)
+	if err := checkWriteScope(ctx, client, appID, flags.apps, out); err != nil {
+		return err
+	}
+
+	if err := bundleForPush(ctx, bundle, out); err != nil {
+		return err
+	}
+	apps, err := resolvePushTargets(ctx, client, appID, flags, bundle, state, out)
+	if err != nil {
+		return err
+	}
+	targets := apps[0].Deployments
+	opts, err := newPushOptions(ctx, appID, token, flags, bundle, state, out)
+	if err != nil {
+		return err
+	}
+	opts.DeploymentID = targets[0].ID
+	if err := enforcePushChecks(ctx, client, opts, apps, flags.freezeReason, out); err != nil {
+		return err
+	}
+
+	switch {
+	case len(apps) > 1:
+		return runMultiAppPush(ctx, client, opts, apps, flags.activateAt, out)
+	case len(targets) > 1:
+		return runFanOutPush(ctx, client, &fanOutPush{
+			opts:         opts,
+			targets:      targets,
+			activateAt:   flags.activateAt,
+			uploader:     flags.uploader,
+			bundleResult: bundle.result,
+		}, out)
+	}
+	return pushOneTarget(ctx, client, opts, targets[0].Name, flags, bundle.result, out)
+}
+
+// validatePushFlags parses the push flags, applies the deployment defaults
+// of .codepush.json, and rejects invalid values and combinations.
+func validatePushFlags(c *cobra.Command, out *output.Writer) (*pushFlags, error) {
+	if err := codepush.ValidateUploadStrategy(codepush.UploadStrategy(pushUploadStrategy)); err != nil {
+		return nil, codepush.Invalid(err)
+	}
+	if err := applyDeploymentDefaults(c, pushDeploymentValues(), out); err != nil {
+		return nil, err
+	}
+
+	f := &pushFlags{deployments: pushDeploymentValues()}
+	var err error
+	if f.activateAt, err = parseActivateAt(c, pushActivateAt); err != nil {
+		return nil, codepush.Invalid(err)
+	}
+	if f.freezeReason, err = parseOverrideFreeze(c, pushOverrideFreeze); err != nil {
+		return nil, err
+	}
+	if f.compression, err = ziputil.ParseCompression(pushCompression); err != nil {
+		return nil, codepush.Invalid(err)
+	}
+	if pushTimeout <= 0 {
+		return nil, codepush.Invalid(errors.New("--timeout must be positive"))
+	}
+	if pushAppVersion != "" {
+		if f.appVersion, err = codepush.NormalizeAppVersion(pushAppVersion); err != nil {
+			return nil, codepush.Invalid(err)
 		}
+	}
+	if f.description, err = pushNotes.resolve(c.Context(), pushDescription); err != nil {
+		return nil, codepush.Invalid(err)
+	}
+	if f.targeting, err = pushTargeting.targeting(); err != nil {
+		return nil, codepush.Invalid(err)
+	}
+	if f.apps, err = pushAppList(); err != nil {
+		return nil, codepush.Invalid(err)
+	}
+	if err := checkPushFlagConflicts(c, f); err != nil {
+		return nil, err
+	}
+	if f.uploader, err = newSourcemapUploader(true, out); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
 
-		appID, token, err := cmdutil.RequireCredentials(cmd.AppID, out)
-		if err != nil {
+// checkPushFlagConflicts rejects flags that cannot be used together, or
+// with several deployments or apps.
+func checkPushFlagConflicts(c *cobra.Command, f *pushFlags) error {
+	if !f.activateAt.IsZero() && pushDisabled {
+		flag := activateAtFlag(c)
+		return codepush.Invalid(fmt.Errorf("%s and --disabled cannot be used together: %s already creates the release disabled", flag, flag))
+	}
+	if len(f.deployments) > 1 {
+		if pushExpectLabel != "" {
+			return codepush.Invalid(errors.New("--expect-label cannot be used with several deployments: their labels differ"))
+		}
+		if pushNoWait {
+			return codepush.Invalid(errors.New("--no-wait cannot be used with several deployments: the release must be processed before it is promoted"))
+		}
+	}
+	if len(f.apps) > 1 {
+		if err := validateMultiAppPush(f.deployments); err != nil {
 			return err
 		}
+	}
+	if bundleUploadSourcemaps != "" && !pushAutoBundle {
+		return codepush.Invalid(errors.New("--sourcemap-provider requires --bundle"))
+	}
+	return nil
+}
+
+// resolvePushBundle returns the bundle to push: with --bundle the platforms
+// to build for, prompting for --platform when it is not set, and otherwise
+// the bundle directory given as an argument or saved in the session.
+func resolvePushBundle(args []string, f *pushFlags, state *session.PushState, out *output.Writer) (*pushBundle, error) {
+	if !pushAutoBundle {
+		if len(args) == 0 && state.BundlePath != "" {
+			args = []string{state.BundlePath}
+		}
+		if len(args) == 0 {
+			return nil, errors.New("bundle path is required: provide as argument or use --bundle to generate one")
+		}
+		path, err := filepath.Abs(args[0])
+		if err != nil {
+			return nil, fmt.Errorf("resolving bundle path: %w", err)
+		}
+		state.BundlePath = path
+		return &pushBundle{platform: bundlePlatform, path: path}, nil
+	}
+
+	platform := bundlePlatform
+	if platform == "" {
+		platform = state.Platform
+	}
+	platform, err := cmdutil.ResolvePlatformInteractive(platform, out)
+	if err != nil {
+		return nil, err
+	}
+	state.Platform = platform
+	platforms, err := bundler.ParsePlatforms(platform)
+	if err != nil {
+		return nil, codepush.Invalid(err)
+	}
+	if len(platforms) > 1 {
+		if len(f.apps) < 2 {
+			return nil, codepush.Invalid(errors.New("pushing several platforms needs an app for each: repeat --app-id or use --apps-file"))
+		}
+		return &pushBundle{platforms: platforms}, nil
+	}
+	return &pushBundle{platform: platform, platforms: platforms}, nil
+}
+
+// bundleForPush builds the bundle with --bundle, or else prepares the given
+// bundle directory, warning about SDK features and signing it.
+func bundleForPush(ctx context.Context, b *pushBundle, out *output.Writer) error {
+	if !pushAutoBundle {
+		return prepareBundles([]string{b.path}, out)
+	}
 
-		serverURL := cmdutil.ResolveServerURL(cmd.ServerURL, out)
-		client := codepush.NewHTTPClient(cmdutil.APIURL(serverURL), token, cmd.Version)
+	results, err := bundleProject(ctx, b.platforms, out)
+	if err != nil {
+		return fmt.Errorf("bundling failed: %w", err)
+	}
+	if len(results) > 1 {
+		b.results = results
+		return nil
+	}
+
+	b.result = results[0]
+	out.Info("Bundle created at: %s", b.result.OutputDir)
+	if b.path, err = filepath.Abs(b.result.OutputDir); err != nil {
+		return fmt.Errorf("resolving bundle path: %w", err)
+	}
+	return nil
+}
 
-		deploymentID, err := cmdutil.ResolveDeploymentInteractive(c.Context(), client, appID, pushDeployment, "CODEPUSH_DEPLOYMENT", out)
+// resolvePushTargets resolves the apps and deployments to push to, checks
+// the bundle platform against each app, and enforces the sourcemap policy
+// of each deployment. A push to a single app returns that app alone.
+func resolvePushTargets(ctx context.Context, client *codepush.HTTPClient, appID string, f *pushFlags, b *pushBundle, state *session.PushState, out *output.Writer) ([]codepush.AppTarget, error) {
+	apps := f.apps
+	var appBundles map[string]*bundler.BundleResult
+	if len(apps) > 1 {
+		if b.results != nil {
+			var err error
+			if appBundles, err = assignPlatformBundles(ctx, client, apps, b.results); err != nil {
+				return nil, err
+			}
+		}
+		if err := resolvePushApps(ctx, client, apps, f.deployments, b, out); err != nil {
+			return nil, err
+		}
+	} else {
+		if state.AppID != "" && state.AppID != appID {
+			out.Warning("saved push session was for app %s, not reusing its deployment", state.AppID)
+			state.Deployment = ""
+		}
+		state.AppID = appID
+
+		if err := checkBundlePlatform(ctx, client, appID, b.path, b.platform, out); err != nil {
+			return nil, err
+		}
+		targets, err := resolveDeploymentTargets(ctx, client, appID, f.deployments, state, out)
 		if err != nil {
+			return nil, err
+		}
+		apps = []codepush.AppTarget{{ID: appID, Name: appID, Deployments: targets}}
+	}
+
+	sourcemapPath := ""
+	if b.result != nil {
+		sourcemapPath = b.result.SourcemapPath
+	}
+	for _, app := range apps {
+		appBundlePath, appSourcemapPath := b.path, sourcemapPath
+		if result := appBundles[app.ID]; result != nil {
+			appBundlePath, appSourcemapPath = result.OutputDir, result.SourcemapPath
+		}
+		for _, target := range app.Deployments {
+			if err := enforceSourcemapPolicy(ctx, client, app.ID, target.ID, appSourcemapPath, appBundlePath, out); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return apps, nil
+}
+
+// newPushOptions resolves the app version and description, prompting when
+// they are not set, and returns the push options shared by every
+// deployment pushed to.
+func newPushOptions(ctx context.Context, appID, token string, f *pushFlags, b *pushBundle, state *session.PushState, out *output.Writer) (*codepush.PushOptions, error) {
+	appVersion := f.appVersion
+	if appVersion == "" && pushDetectAppVersion {
+		var err error
+		if appVersion, err = detectAppVersion(b.platform, out); err != nil {
+			return nil, err
+		}
+	}
+	if appVersion == "" {
+		appVersion = state.AppVersion
+	}
+	appVersion, err := cmdutil.ResolveInputInteractive(appVersion, "App version", "1.0.0", out)
+	if err != nil {
+		return nil, err
+	}
+	state.AppVersion = appVersion
+
+	description := f.description
+	if description == "" {
+		description = state.Description
+	}
+	state.Description = description
+
+	runtimeVersion := ""
+	if b.result != nil {
+		runtimeVersion = b.result.RuntimeVersion
+	}
+	return &codepush.PushOptions{
+		AppID:       appID,
+		Token:       token,
+		AppVersion:  appVersion,
+		Description: description,
+		Mandatory:   pushMandatory,
+		Rollout:     pushRollout,
+		Disabled:    pushDisabled || !f.activateAt.IsZero(),
+		BundlePath:  b.path,
+
+		CheckNativeChanges: pushCheckNativeChanges,
+		FailOnNativeChange: pushFailOnNativeChange,
+		UploadStrategy:     codepush.UploadStrategy(pushUploadStrategy),
+		RuntimeVersion:     runtimeVersion,
+		ExpectLabel:        pushExpectLabel,
+		Full:               pushFull,
+		Compression:        f.compression,
+		NoWait:             pushNoWait,
+		Targeting:          f.targeting,
+		Provenance:         codepush.CollectProvenance(ctx, bundleProjectDir),
+		AllowDuplicate:     pushAllowDuplicate,
+		Workspace:          cmd.Workspace,
+	}, nil
+}
+
+// enforcePushChecks enforces the release policy and freeze windows of every
+// deployment pushed to, before anything is uploaded.
+func enforcePushChecks(ctx context.Context, client *codepush.HTTPClient, opts *codepush.PushOptions, apps []codepush.AppTarget, freezeReason string, out *output.Writer) error {
+	for _, app := range apps {
+		for _, target := range app.Deployments {
+			targetOpts := *opts
+			targetOpts.AppID, targetOpts.DeploymentID = app.ID, target.ID
+			if app.BundlePath != "" {
+				targetOpts.BundlePath = app.BundlePath
+			}
+			if err := enforcePushPolicy(ctx, client, &targetOpts, pushOverridePolicy, out); err != nil {
+				return err
+			}
+			if err := enforceFreeze(ctx, client, "push", app.ID, target.ID, freezeReason, out); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// pushOneTarget pushes to the deployment of opts, named deployment on the
+// command line, then schedules its activation, uploads source maps, and
+// reports the release.
+func pushOneTarget(ctx context.Context, client *codepush.HTTPClient, opts *codepush.PushOptions, deployment string, f *pushFlags, bundleResult *bundler.BundleResult, out *output.Writer) error {
+	result, err := releaser.New(opts.AppID, opts.Token, client, out).Push(ctx, opts, codepush.PollConfigFor(pushTimeout))
+	if err != nil {
+		return fmt.Errorf("push failed: %w", err)
+	}
+	if result.DuplicateOf != "" {
+		if cmd.JSONOutput {
+			return cmdutil.OutputResult(result)
+		}
+		out.Success("Nothing to push: release %s already has this content", result.DuplicateOf)
+		return nil
+	}
+
+	if !f.activateAt.IsZero() {
+		// The server labels the release while processing it; with --no-wait
+		// the label may not be known yet.
+		label := ""
+		if u, err := client.GetUpdate(ctx, opts.AppID, opts.DeploymentID, result.UpdateID); err == nil {
+			label = u.Label
+		}
+		if err := scheduleActivation(schedule.Activation{
+			AppID:        opts.AppID,
+			DeploymentID: opts.DeploymentID,
+			Deployment:   deployment,
+			UpdateID:     result.UpdateID,
+			Label:        label,
+			ActivateAt:   f.activateAt,
+		}); err != nil {
 			return err
 		}
+	}
+
+	if f.uploader != nil {
+		if err := uploadPushedSourcemaps(ctx, client, f.uploader, bundleResult, result); err != nil {
+			return fmt.Errorf("release was pushed but uploading source maps failed: %w", err)
+		}
+	}
+
+	cmdutil.ExportStepOutputs("codepush-push-summary.json", result, pushStepOutputs(result), out)
+	cmdutil.AnnotateRelease(pushAnnotation(result, deployment), out)
+	if cmd.JSONOutput {
+		return cmdutil.OutputResult(result)
+	}
+	reportPush(result, out)
+	return nil
+}
+
+// reportPush prints the result of a push to one deployment.
+func reportPush(result *codepush.PushResult, out *output.Writer) {
+	out.Success("Push successful")
+	kvs := []output.KeyValue{
+		{Key: "Update ID", Value: result.UpdateID},
+		{Key: "App version", Value: result.AppVersion},
+		{Key: "Status", Value: result.Status},
+	}
+	if result.RuntimeVersion != "" {
+		kvs = append(kvs, output.KeyValue{Key: "Runtime version", Value: result.RuntimeVersion})
+	}
+	if result.DiffAgainst != "" {
+		kvs = append(kvs, output.KeyValue{Key: "Delta against", Value: result.DiffAgainst})
+	}
+	kvs = append(kvs, cmdutil.ProvenancePairs(result.Provenance)...)
+	if result.Targeted() {
+		kvs = append(kvs, output.KeyValue{Key: "Targeting", Value: result.TargetingSummary()})
+	}
+	if result.Rollout < 100 {
+		kvs = append(kvs, output.KeyValue{Key: "Rollout", Value: fmt.Sprintf("%d%%", result.Rollout)})
+	}
+	out.Result(kvs)
+}
+
+// pushAnnotation returns the build annotation of a release pushed to
+// deployment.
+func pushAnnotation(result *codepush.PushResult, deployment string) cmdutil.ReleaseAnnotation {
+	rollout := result.Rollout
+	return cmdutil.ReleaseAnnotation{
+		Title:        "Pushed an update to " + deployment,
+		AppID:        result.AppID,
+		DeploymentID: result.DeploymentID,
+		UpdateID:     result.UpdateID,
+		AppVersion:   result.AppVersion,
+		Rollout:      &rollout,
+		SizeBytes:    result.FileSizeBytes,
+	}
+}
+
+// pushStepOutputs returns the Bitrise step outputs of a pushed release.
+func pushStepOutputs(result *codepush.PushResult) cmdutil.StepOutputs {
+	return cmdutil.StepOutputs{
+		UpdateID:     result.UpdateID,
+		AppVersion:   result.AppVersion,
+		DeploymentID: result.DeploymentID,
+	}
+}
+
+// pushDeploymentValues returns the deployments given by --deployment, or
+// else by CODEPUSH_DEPLOYMENT as a comma-separated list, without
+// duplicates.
+func pushDeploymentValues() []string {
+	values := pushDeployments
+	if len(values) == 0 {
+		if env := os.Getenv("CODEPUSH_DEPLOYMENT"); env != "" {
+			values = strings.Split(env, ",")
+		}
+	}
+	var result []string
+	for _, v := range values {
+		if v = strings.TrimSpace(v); v != "" && !slices.Contains(result, v) {
+			result = append(result, v)
+		}
+	}
+	return result
+}
+
+// defaultFlags are the flags that override each setting of
+// deployment_defaults in .codepush.json.
+var defaultFlags = map[string][]string{
+	"rollout":            {"rollout"},
+	"mandatory":          {"mandatory"},
+	"description":        {"description", "description-file", "description-from-git"},
+	"detect_app_version": {"detect-app-version", "app-version"},
+	"hermes":             {"hermes"},
+}
+
+// applyDeploymentDefaults sets the push settings whose flags were not given
+// from the deployment_defaults in .codepush.json for the deployments pushed
+// to. A setting that differs between several deployments must be given as a
+// flag.
+func applyDeploymentDefaults(c *cobra.Command, deployments []string, out *output.Writer) error {
+	if len(deployments) == 0 {
+		if v := cmdutil.ResolveDeploymentValue("", cmdutil.DeploymentEnvKey, nil); v != "" {
+			deployments = []string{v}
+		}
+	}
+	cfg, err := config.Load()
+	if err != nil {
+		return err
+	}
+	defaults, conflicts := cfg.DefaultsFor(deployments)
+
+	changed := func(setting string) bool {
+		return slices.ContainsFunc(defaultFlags[setting], c.Flags().Changed)
+	}
+	for _, setting := range conflicts {
+		if !changed(setting) {
+			return codepush.Invalid(fmt.Errorf("deployment_defaults in %s set different %s for %s: set --%s", config.FileName, setting, strings.Join(deployments, ", "), defaultFlags[setting][0]))
+		}
+	}
+
+	var applied []string
+	if defaults.Rollout != nil && !changed("rollout") {
+		pushRollout = *defaults.Rollout
+		applied = append(applied, fmt.Sprintf("rollout %d%%", pushRollout))
+	}
+	if defaults.Mandatory != nil && !changed("mandatory") {
+		pushMandatory = *defaults.Mandatory
+		applied = append(applied, fmt.Sprintf("mandatory %t", pushMandatory))
+	}
+	if defaults.Description != "" && !changed("description") {
+		pushDescription = defaults.Description
+		applied = append(applied, "description")
+	}
+	if defaults.DetectAppVersion != nil && !changed("detect_app_version") {
+		pushDetectAppVersion = *defaults.DetectAppVersion
+		applied = append(applied, fmt.Sprintf("detect app version %t", pushDetectAppVersion))
+	}
+	if defaults.Hermes != "" && !changed("hermes") {
+		if err := bundler.ValidateHermesMode(bundler.HermesMode(defaults.Hermes)); err != nil {
+			return codepush.Invalid(fmt.Errorf("deployment_defaults in %s: %w", config.FileName, err))
+		}
+		bundleHermes = defaults.Hermes
+		applied = append(applied, "hermes "+bundleHermes)
+	}
+	if len(applied) > 0 {
+		out.Info("Using defaults for %s from %s: %s", strings.Join(deployments, ", "), config.FileName, strings.Join(applied, ", "))
+	}
+	return nil
+}
 
-		appVersion, err := cmdutil.ResolveInputInteractive(pushAppVersion, "App version", "1.0.0", out)
+// checkWriteScope verifies that the token may release to appID, or to each
+// app of a multi-app push.
+func checkWriteScope(ctx context.Context, client *codepush.HTTPClient, appID string, apps []codepush.AppTarget, out *output.Writer) error {
+	appIDs := []string{appID}
+	if len(apps) > 1 {
+		appIDs = appIDs[:0]
+		for _, app := range apps {
+			appIDs = append(appIDs, app.ID)
+		}
+	}
+	for _, id := range appIDs {
+		access, err := codepush.CheckScope(ctx, client, id, codepush.ScopeReleaseWrite)
 		if err != nil {
 			return err
 		}
-
-		opts := &codepush.PushOptions{
-			AppID:        appID,
-			DeploymentID: deploymentID,
-			Token:        token,
-			AppVersion:   appVersion,
-			Description:  pushDescription,
-			Mandatory:    pushMandatory,
-			Rollout:      pushRollout,
-			Disabled:     pushDisabled,
-			BundlePath:   bundlePath,
+		if _, known := access.HasScope(codepush.ScopeReleaseWrite); !known {
+			out.Debug("token scopes not reported, checked app access only", "app", id)
 		}
+	}
+	return nil
+}
 
-		result, err := codepush.Push(c.Context(), client, opts, out)
+// resolveDeploymentTargets resolves the deployments of a single app to push
+// to. A single deployment, or none, is resolved like other write commands
+// do, falling back to the saved session, the project config, and a prompt.
+// Several deployments must each be given explicitly.
+func resolveDeploymentTargets(ctx context.Context, client codepush.Client, appID string, values []string, state *session.PushState, out *output.Writer) ([]codepush.FanOutTarget, error) {
+	if len(values) <= 1 {
+		value := state.Deployment
+		if len(values) == 1 {
+			value = values[0]
+		}
+		id, err := cmdutil.ResolveDeploymentForWrite(ctx, client, appID, value, "CODEPUSH_DEPLOYMENT", out)
 		if err != nil {
-			return fmt.Errorf("push failed: %w", err)
+			return nil, err
 		}
+		state.Deployment = id
+		return []codepush.FanOutTarget{{Name: value, ID: id}}, nil
+	}
 
-		if cmd.JSONOutput {
-			return cmdutil.OutputJSON(result)
+	targets := make([]codepush.FanOutTarget, 0, len(values))
+	for _, value := range values {
+		id, err := cmdutil.ResolveDeploymentForWrite(ctx, client, appID, value, "", out)
+		if err != nil {
+			return nil, fmt.Errorf("resolving deployment %s: %w", value, err)
+		}
+		for _, t := range targets {
+			if t.ID == id {
+				return nil, codepush.Invalid(fmt.Errorf("deployments %s and %s are the same deployment", t.Name, value))
+			}
 		}
+		targets = append(targets, codepush.FanOutTarget{Name: value, ID: id})
+	}
+	return targets, nil
+}
+
+// fanOutPush is a push to several deployments and its per-deployment
+// follow-ups.
+type fanOutPush struct {
+	opts    *codepush.PushOptions
+	targets []codepush.FanOutTarget
+	// activateAt schedules the activation of each release when set.
+	activateAt time.Time
+	// uploader uploads the source maps of bundleResult for each release
+	// when set.
+	uploader     sourcemaps.Uploader
+	bundleResult *bundler.BundleResult
+}
 
-		out.Success("Push successful")
-		kvs := []output.KeyValue{
-			{Key: "Update ID", Value: result.UpdateID},
-			{Key: "App version", Value: result.AppVersion},
-			{Key: "Status", Value: result.Status},
+// runFanOutPush pushes to several deployments and reports the result of
+// each. The per-deployment follow-ups, scheduling the activation and
+// uploading source maps, run for each deployment the release reached.
+func runFanOutPush(ctx context.Context, client codepush.Client, f *fanOutPush, out *output.Writer) error {
+	result, err := codepush.PushFanOut(ctx, client, f.opts, f.targets, codepush.PollConfigFor(pushTimeout), out)
+	if result == nil {
+		return err
+	}
+
+	for _, r := range result.Deployments {
+		if r.Error != "" || (!r.Promoted && result.Push.DuplicateOf != "") {
+			continue
 		}
-		if result.Rollout < 100 {
-			kvs = append(kvs, output.KeyValue{Key: "Rollout", Value: fmt.Sprintf("%d%%", result.Rollout)})
+		if !f.activateAt.IsZero() {
+			if err := scheduleActivation(schedule.Activation{
+				AppID:        f.opts.AppID,
+				DeploymentID: r.DeploymentID,
+				Deployment:   r.Deployment,
+				UpdateID:     r.UpdateID,
+				Label:        r.Label,
+				ActivateAt:   f.activateAt,
+			}); err != nil {
+				return err
+			}
+		}
+		if f.uploader != nil {
+			release := sourcemaps.Release{AppVersion: result.Push.AppVersion, Label: r.Label}
+			if err := uploadSourcemaps(ctx, f.uploader, f.bundleResult, release); err != nil {
+				return fmt.Errorf("release was pushed but uploading source maps for %s failed: %w", r.Deployment, err)
+			}
 		}
-		out.Result(kvs)
 
-		if bitrise.IsBitriseEnvironment() {
-			cmdutil.ExportDeploySummary("codepush-push-summary.json", result, out)
-			cmdutil.ExportEnvVars(map[string]string{
-				"CODEPUSH_UPDATE_ID":   result.UpdateID,
-				"CODEPUSH_APP_VERSION": result.AppVersion,
-			}, out)
+		annotation := pushAnnotation(result.Push, r.Deployment)
+		annotation.Title = fmt.Sprintf("Released %s to %s", r.Label, r.Deployment)
+		annotation.DeploymentID, annotation.UpdateID, annotation.Label = r.DeploymentID, r.UpdateID, r.Label
+		cmdutil.AnnotateRelease(annotation, out)
+	}
+
+	cmdutil.ExportStepOutputs("codepush-push-summary.json", result, pushStepOutputs(result.Push), out)
+	return reportFanOutPush(result, err, out)
+}
+
+// reportFanOutPush prints the result of each deployment of a fan-out push
+// and returns pushErr, the error of the push.
+func reportFanOutPush(result *codepush.FanOutResult, pushErr error, out *output.Writer) error {
+	if cmd.JSONOutput {
+		if err := cmdutil.OutputResult(result); err != nil {
+			return err
 		}
+		return pushErr
+	}
 
-		return nil
-	},
+	rows := make([][]string, len(result.Deployments))
+	for i, r := range result.Deployments {
+		status := "uploaded"
+		switch {
+		case r.Error != "":
+			status = "failed: " + r.Error
+		case r.Promoted:
+			status = "promoted"
+		case result.Push.DuplicateOf != "":
+			status = "unchanged"
+		}
+		rows[i] = []string{r.Deployment, r.Label, r.UpdateID, status}
+	}
+	out.Table([]string{"DEPLOYMENT", "LABEL", "PACKAGE ID", "RESULT"}, rows)
+	if pushErr != nil {
+		return pushErr
+	}
+	out.Success("Pushed to %d deployments", result.Succeeded)
+	return nil
+}
+
+// detectAppVersion reads the binary version from the native project files
+// of platform, or of both platforms when it is empty.
+func detectAppVersion(platform string, out *output.Writer) (string, error) {
+	projectDir := bundleProjectDir
+	if projectDir == "" {
+		projectDir = "."
+	}
+	detected, err := bundler.DetectAppVersion(projectDir, bundler.Platform(platform), bundleGradleFile)
+	if err != nil {
+		return "", codepush.Invalid(fmt.Errorf("detecting app version: %w", err))
+	}
+	out.Info("Detected app version %s from %s", detected.Version, detected.Source)
+	return detected.Version, nil
 }
 
 func init() {
 	pushCmd.Flags().BoolVar(&pushAutoBundle, "bundle", false, "bundle JavaScript before pushing")
 	registerPushBundleFlagsOn(pushCmd)
-	pushCmd.Flags().StringVarP(&pushDeployment, "deployment", "d", "", "deployment name or UUID (env: CODEPUSH_DEPLOYMENT)")
-	pushCmd.Flags().StringVarP(&pushAppVersion, "app-version", "t", "", "target app version (e.g. 1.0.0)")
+	pushCmd.Flags().StringSliceVarP(&pushDeployments, "deployment", "d", nil, "deployment name or UUID; repeat or separate with commas to push to several (env: CODEPUSH_DEPLOYMENT)")
+	pushCmd.Flags().StringVarP(&pushAppVersion, "app-version", "t", "", "target app version: an exact version such as 1.0.0 or a semver range such as ^1.0.0; four-part versions such as 1.0.0.1 are rejected")
 	pushCmd.Flags().StringVar(&pushDescription, "description", "", "update description")
+	registerDescriptionFlagsOn(pushCmd, &pushNotes)
 	pushCmd.Flags().BoolVarP(&pushMandatory, "mandatory", "m", false, "mark update as mandatory")
 	pushCmd.Flags().IntVarP(&pushRollout, "rollout", "r", 100, "rollout percentage (0-100)")
 	pushCmd.Flags().BoolVarP(&pushDisabled, "disabled", "x", false, "disable update after upload")
+	pushCmd.Flags().BoolVar(&pushCheckNativeChanges, "check-native-changes", false, "warn when the bundle references native modules the previous release did not; downloads the previous release")
+	pushCmd.Flags().BoolVar(&pushFailOnNativeChange, "fail-on-native-change", false, "like --check-native-changes, but fail instead of warn, also when the bundles cannot be compared")
+	pushCmd.Flags().StringVar(&pushUploadStrategy, "upload-strategy", string(codepush.UploadStrategyAuto), "upload strategy: auto, single, or parallel; the server sets the part size of multipart uploads, and auto retries failed parts one at a time before a single request")
+	pushCmd.Flags().BoolVar(&pushSkipSourcemapPolicy, "no-sourcemap-policy-check", false, "skip the sourcemap_policy in .codepush.json (emergencies only)")
+	pushCmd.Flags().BoolVar(&pushOverridePolicy, "override-policy", false, "create the release even if it violates the policy in .codepush.json; the override is logged")
+	pushCmd.Flags().StringVar(&pushOverrideFreeze, "override-freeze", "", "change the deployment even inside a freeze window in .codepush.json, giving the reason; the override is logged")
+	pushCmd.Flags().StringVar(&pushExpectLabel, "expect-label", "", "abort unless the new release will be labeled this (e.g. v13)")
+	pushCmd.Flags().BoolVar(&pushAllowDuplicate, "allow-duplicate", false, "skip the push instead of failing when the latest release already has the bundle's content")
+	pushCmd.Flags().BoolVar(&pushAllowPlatformMismatch, "allow-platform-mismatch", false, "warn instead of failing when the bundle looks built for another platform")
+	addActivateAtFlags(pushCmd, &pushActivateAt, "create the release disabled and schedule its activation")
+	pushCmd.Flags().BoolVar(&pushFull, "full", false, "upload the full package instead of a delta against the latest release")
+	pushCmd.Flags().StringVar(&pushCompression, "compression", "deflate", "package compression: deflate, deflate:<1-9> (9 is smallest), or store")
+	pushCmd.Flags().BoolVar(&pushNoWait, "no-wait", false, "return after the upload without waiting for the server to process the update")
+	pushCmd.Flags().DurationVar(&pushTimeout, "timeout", 2*time.Minute, "how long to wait for the update to be processed (e.g. 10m)")
+	pushCmd.Flags().BoolVar(&pushDetectAppVersion, "detect-app-version", false, "without --app-version, read the version from Info.plist or build.gradle")
+	registerTargetingFlagsOn(pushCmd, &pushTargeting)
+	pushCmd.Flags().BoolVar(&pushResume, "resume", false, "resume an interrupted interactive push with its saved answers")
+	pushCmd.Flags().BoolVar(&pushDiscard, "discard", false, "discard the saved push session and exit")
+	pushCmd.Flags().StringVar(&pushAppsFile, "apps-file", "", "file listing the apps to push to, one app UUID per line optionally followed by a name")
+	pushCmd.Flags().IntVar(&pushConcurrency, "concurrency", codepush.DefaultAppParallelism, "number of apps pushed to at once when pushing to several apps")
+	pushCmd.MarkFlagsMutuallyExclusive("resume", "discard")
+	_ = pushCmd.RegisterFlagCompletionFunc("deployment", cmd.CompleteDeployments)
 	cmd.RootCmd.AddCommand(pushCmd)
 }
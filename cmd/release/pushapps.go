@@ -0,0 +1,190 @@
+package release
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/bitrise-io/bitrise-plugins-codepush-cli/cmd"
+	"github.com/bitrise-io/bitrise-plugins-codepush-cli/internal/bundler"
+	"github.com/bitrise-io/bitrise-plugins-codepush-cli/internal/cmdutil"
+	"github.com/bitrise-io/bitrise-plugins-codepush-cli/internal/codepush"
+	"github.com/bitrise-io/bitrise-plugins-codepush-cli/internal/output"
+	"github.com/bitrise-io/bitrise-plugins-codepush-cli/internal/schedule"
+)
+
+// pushAppList returns the apps given by repeating --app-id and by
+// --apps-file, without duplicates. A push to more than one app releases the
+// bundle to each of them.
+func pushAppList() ([]codepush.AppTarget, error) {
+	var apps []codepush.AppTarget
+	seen := make(map[string]bool)
+	add := func(app codepush.AppTarget) {
+		if !seen[app.ID] {
+			seen[app.ID] = true
+			apps = append(apps, app)
+		}
+	}
+	if len(cmd.AppIDs) > 1 || pushAppsFile != "" {
+		for _, id := range cmd.AppIDs {
+			add(codepush.AppTarget{ID: id, Name: id})
+		}
+	}
+	if pushAppsFile != "" {
+		manifest, err := codepush.LoadAppManifest(pushAppsFile)
+		if err != nil {
+			return nil, err
+		}
+		for _, app := range manifest {
+			add(app)
+		}
+	}
+	return apps, nil
+}
+
+// validateMultiAppPush rejects the push options that cannot apply to
+// several apps. Deployments must be given by name, since a deployment UUID
+// belongs to a single app.
+func validateMultiAppPush(deploymentValues []string) error {
+	if len(deploymentValues) == 0 {
+		return codepush.Invalid(errors.New("--deployment is required when pushing to several apps"))
+	}
+	for _, value := range deploymentValues {
+		if _, err := uuid.Parse(value); err == nil {
+			return codepush.Invalid(fmt.Errorf("deployment %s is a UUID: give deployments by name when pushing to several apps", value))
+		}
+	}
+	if pushExpectLabel != "" {
+		return codepush.Invalid(errors.New("--expect-label cannot be used with several apps: their labels differ"))
+	}
+	if bundleUploadSourcemaps != "" {
+		return codepush.Invalid(errors.New("--sourcemap-provider cannot be used with several apps"))
+	}
+	if pushConcurrency <= 0 {
+		return codepush.Invalid(errors.New("--concurrency must be positive"))
+	}
+	return nil
+}
+
+// assignPlatformBundles gives each app the bundle built for its platform and
+// returns the bundle of each app by app ID. It fails when the platform of an
+// app is unknown or was not built, or when no app takes a bundle.
+func assignPlatformBundles(ctx context.Context, client codepush.Client, apps []codepush.AppTarget, results []*bundler.BundleResult) (map[string]*bundler.BundleResult, error) {
+	byPlatform := make(map[string]*bundler.BundleResult, len(results))
+	for _, result := range results {
+		byPlatform[string(result.Platform)] = result
+	}
+
+	appBundles := make(map[string]*bundler.BundleResult, len(apps))
+	used := make(map[string]bool)
+	for i := range apps {
+		app := &apps[i]
+		platform, err := codepush.AppPlatform(ctx, client, app.ID)
+		if err != nil {
+			return nil, fmt.Errorf("app %s: %w", app.Name, err)
+		}
+		result := byPlatform[platform]
+		if result == nil {
+			if platform == "" {
+				return nil, fmt.Errorf("app %s: its platform is unknown, so it cannot be given the bundle of either platform", app.Name)
+			}
+			return nil, codepush.Invalid(fmt.Errorf("app %s is a %s app but no %s bundle was built", app.Name, platformName(platform), platformName(platform)))
+		}
+		app.BundlePath, app.RuntimeVersion = result.OutputDir, result.RuntimeVersion
+		appBundles[app.ID] = result
+		used[platform] = true
+	}
+	for _, result := range results {
+		if !used[string(result.Platform)] {
+			return nil, codepush.Invalid(fmt.Errorf("no app takes the %s bundle: give an app of each platform built", platformName(string(result.Platform))))
+		}
+	}
+	return appBundles, nil
+}
+
+// resolvePushApps checks the platform of b against each app and resolves
+// the deployments by name in each of them. An app with its own bundle is
+// checked against that bundle. It fails before anything is pushed if a
+// deployment is missing in any app.
+func resolvePushApps(ctx context.Context, client codepush.Client, apps []codepush.AppTarget, deploymentValues []string, b *pushBundle, out *output.Writer) error {
+	for i := range apps {
+		app := &apps[i]
+		appBundlePath := b.path
+		if app.BundlePath != "" {
+			appBundlePath = app.BundlePath
+		}
+		if err := checkBundlePlatform(ctx, client, app.ID, appBundlePath, b.platform, out); err != nil {
+			return fmt.Errorf("app %s: %w", app.Name, err)
+		}
+		app.Deployments = make([]codepush.FanOutTarget, 0, len(deploymentValues))
+		for _, name := range deploymentValues {
+			id, err := codepush.ResolveDeployment(ctx, client, app.ID, name, out)
+			if err != nil {
+				return fmt.Errorf("app %s: %w", app.Name, err)
+			}
+			app.Deployments = append(app.Deployments, codepush.FanOutTarget{Name: name, ID: id})
+		}
+	}
+	return nil
+}
+
+// runMultiAppPush pushes to several apps and reports the result of each.
+// The command fails if any app failed, naming only those apps.
+func runMultiAppPush(ctx context.Context, client codepush.Client, opts *codepush.PushOptions, apps []codepush.AppTarget, activateAt time.Time, out *output.Writer) error {
+	result, err := codepush.PushApps(ctx, client, opts, apps, pushConcurrency, codepush.PollConfigFor(pushTimeout), out)
+
+	if !activateAt.IsZero() {
+		for _, app := range result.Apps {
+			for _, r := range app.Deployments {
+				if r.Error != "" {
+					continue
+				}
+				if err := scheduleActivation(schedule.Activation{
+					AppID:        app.AppID,
+					DeploymentID: r.DeploymentID,
+					Deployment:   r.Deployment,
+					UpdateID:     r.UpdateID,
+					Label:        r.Label,
+					ActivateAt:   activateAt,
+				}); err != nil {
+					return fmt.Errorf("app %s: %w", app.Name, err)
+				}
+			}
+		}
+	}
+
+	cmdutil.ExportStepOutputs("codepush-push-summary.json", result, cmdutil.StepOutputs{AppVersion: opts.AppVersion}, out)
+
+	if cmd.JSONOutput {
+		if outErr := cmdutil.OutputResult(result); outErr != nil {
+			return outErr
+		}
+		return err
+	}
+
+	rows := make([][]string, len(result.Apps))
+	for i, app := range result.Apps {
+		var deployments, labels []string
+		for _, r := range app.Deployments {
+			if r.Error == "" {
+				deployments = append(deployments, r.Deployment)
+				labels = append(labels, r.Label)
+			}
+		}
+		status := "ok"
+		if app.Error != "" {
+			status = "failed: " + app.Error
+		}
+		rows[i] = []string{app.Name, strings.Join(deployments, ", "), strings.Join(labels, ", "), status}
+	}
+	out.Table([]string{"APP", "DEPLOYMENTS", "LABELS", "RESULT"}, rows)
+	if err != nil {
+		return err
+	}
+	out.Success("Pushed to %d apps", result.Succeeded)
+	return nil
+}
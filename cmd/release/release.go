@@ -1,11 +1,24 @@
 package release
 
 import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
 	"github.com/spf13/cobra"
 
 	"github.com/bitrise-io/bitrise-plugins-codepush-cli/cmd"
+	"github.com/bitrise-io/bitrise-plugins-codepush-cli/internal/bitrise"
 	"github.com/bitrise-io/bitrise-plugins-codepush-cli/internal/bundler"
+	"github.com/bitrise-io/bitrise-plugins-codepush-cli/internal/cmdutil"
+	"github.com/bitrise-io/bitrise-plugins-codepush-cli/internal/codepush"
 	"github.com/bitrise-io/bitrise-plugins-codepush-cli/internal/output"
+	"github.com/bitrise-io/bitrise-plugins-codepush-cli/internal/releasenotes"
+	"github.com/bitrise-io/bitrise-plugins-codepush-cli/internal/sourcemaps"
 )
 
 // Shared bundle flags: used by both "bundle" and "push --bundle" commands.
@@ -15,7 +28,12 @@ var (
 	bundleOutputDir        string
 	bundleBundleName       string
 	bundleDev              bool
-	bundleMinify           bool
+	bundleMinify           optionalBool
+	bundleRamBundle        bool
+	bundleHermesFlags      hermesFlagsValue
+	bundleMaxWorkers       int
+	bundleTimeout          time.Duration
+	bundleLogFile          string
 	bundleResetCache       bool
 	bundleSourcemap        bool
 	bundleSourcemapOutput  string
@@ -25,9 +43,17 @@ var (
 	bundleProjectDir       string
 	bundleMetroConfig      string
 	bundleSkipInstall      bool
+	bundlePackageManager   string
+	bundleSkipNodeCheck    bool
+	bundleUseNodeVersion   bool
 	bundleGradleFile       string
 	bundlePodFile          string
 	bundlePrivateKeyPath   string
+	bundleUploadSourcemaps string
+	bundleSourcemapOpts    sourcemaps.Options
+	bundleOptimizeAssets   bool
+	bundleAssetQuality     int
+	bundleCache            bool
 )
 
 func init() {
@@ -36,60 +62,387 @@ func init() {
 
 // registerBundleFlagsOn registers the full set of bundle flags on a command.
 func registerBundleFlagsOn(c *cobra.Command) {
-	c.Flags().StringVarP(&bundlePlatform, "platform", "p", "", "target platform: ios or android")
+	c.Flags().StringVarP(&bundlePlatform, "platform", "p", "", "target platform: ios, android, or all to bundle both at once")
 	c.Flags().StringVarP(&bundleEntryFile, "entry-file", "e", "", "path to the entry JS file (auto-detected if not set)")
 	c.Flags().StringVarP(&bundleOutputDir, "output-dir", "o", bundler.DefaultOutputDir, "output directory for the bundle")
 	c.Flags().StringVarP(&bundleBundleName, "bundle-name", "b", "", "custom bundle filename (platform default if not set)")
 	c.Flags().BoolVar(&bundleDev, "dev", false, "enable development mode (also controls minification on React Native: false = minified)")
-	c.Flags().BoolVar(&bundleMinify, "minify", false, "minify the bundle (Expo only)")
-	c.Flags().BoolVar(&bundleResetCache, "reset-cache", true, "clear Metro bundler cache before bundling")
+	c.Flags().BoolVar(&bundleResetCache, "reset-cache", true, "clear Metro bundler cache before bundling (ignored by Re.Pack)")
 	c.Flags().BoolVar(&bundleSourcemap, "sourcemap", true, "generate source maps")
 	c.Flags().StringVarP(&bundleSourcemapOutput, "sourcemap-output", "s", "", "override sourcemap output path (implies --sourcemap)")
 	c.Flags().StringVar(&bundleHermes, "hermes", "auto", "Hermes bytecode compilation: auto, on, or off")
 	c.Flags().StringArrayVar(&bundleExtraBundlerOpts, "extra-bundler-option", nil, "additional flags passed to the bundler (repeatable)")
 	c.Flags().StringArrayVar(&bundleExtraHermesFlags, "extra-hermes-flag", nil, "additional flags passed to hermesc (repeatable; distinct from --extra-bundler-option which targets Metro)")
 	c.Flags().StringVar(&bundleProjectDir, "project-dir", "", "project root directory (defaults to current directory)")
-	c.Flags().StringVarP(&bundleMetroConfig, "config", "c", "", "path to Metro config file (auto-detected if not set)")
+	c.Flags().StringVarP(&bundleMetroConfig, "config", "c", "", "path to Metro config file, or webpack/Rspack config for Re.Pack projects (auto-detected if not set)")
 	c.Flags().BoolVar(&bundleSkipInstall, "skip-install", false, "skip running package manager install before bundling")
+	c.Flags().StringVar(&bundlePackageManager, "package-manager", "", "package manager installing dependencies and running the bundler: npm, yarn, pnpm, or bun (default: detected from package.json and lock files)")
+	c.Flags().BoolVar(&bundleSkipNodeCheck, "skip-node-check", false, "don't check the Node.js version against engines.node in package.json and .nvmrc")
+	c.Flags().BoolVar(&bundleUseNodeVersion, "use-node-version", false, "when the Node.js version does not match, run the bundler through fnm or nvm with the .nvmrc version")
 	c.Flags().StringVarP(&bundleGradleFile, "gradle-file", "g", "", "override path to build.gradle used for Android Hermes auto-detection")
 	c.Flags().StringVar(&bundlePodFile, "pod-file", "", "override path to Podfile used for iOS Hermes auto-detection")
 	c.Flags().StringVarP(&bundlePrivateKeyPath, "private-key-path", "k", "", "sign bundle with RSA private key (PEM); output directory must be named CodePush")
+	registerSourcemapFlagsOn(c, "upload source maps after bundling: "+strings.Join(sourcemaps.Providers, ", "))
+	registerTuningFlagsOn(c, "")
+	registerAssetFlagsOn(c, "")
+	registerCacheFlagsOn(c, "")
 }
 
 // registerPushBundleFlagsOn registers the subset of bundle flags used by push --bundle.
 func registerPushBundleFlagsOn(c *cobra.Command) {
-	c.Flags().StringVarP(&bundlePlatform, "platform", "p", "", "target platform for bundling: ios or android")
+	c.Flags().StringVarP(&bundlePlatform, "platform", "p", "", "target platform: ios or android (bundles for it with --bundle; the bundle is checked against it); with --bundle, all bundles both for an iOS and an Android app")
 	c.Flags().StringVarP(&bundleOutputDir, "output-dir", "o", bundler.DefaultOutputDir, "output directory for the bundle")
 	c.Flags().StringVar(&bundleHermes, "hermes", "auto", "Hermes bytecode compilation: auto, on, or off")
-	c.Flags().BoolVar(&bundleMinify, "minify", false, "minify the bundle (Expo only)")
-	c.Flags().BoolVar(&bundleResetCache, "reset-cache", true, "clear Metro bundler cache before bundling")
+	c.Flags().BoolVar(&bundleResetCache, "reset-cache", true, "clear Metro bundler cache before bundling (ignored by Re.Pack)")
 	c.Flags().StringVar(&bundleProjectDir, "project-dir", "", "project root directory (defaults to current directory)")
 	c.Flags().BoolVar(&bundleSkipInstall, "skip-install", false, "skip running package manager install before bundling")
+	c.Flags().StringVar(&bundlePackageManager, "package-manager", "", "package manager installing dependencies and running the bundler: npm, yarn, pnpm, or bun (default: detected from package.json and lock files)")
+	c.Flags().BoolVar(&bundleSkipNodeCheck, "skip-node-check", false, "don't check the Node.js version against engines.node in package.json and .nvmrc")
+	c.Flags().BoolVar(&bundleUseNodeVersion, "use-node-version", false, "when the Node.js version does not match, run the bundler through fnm or nvm with the .nvmrc version")
 	c.Flags().StringVarP(&bundleGradleFile, "gradle-file", "g", "", "override path to build.gradle used for Android Hermes auto-detection")
 	c.Flags().StringVar(&bundlePodFile, "pod-file", "", "override path to Podfile used for iOS Hermes auto-detection")
 	c.Flags().StringVarP(&bundlePrivateKeyPath, "private-key-path", "k", "", "sign bundle with RSA private key (PEM); output directory must be named CodePush")
+	registerSourcemapFlagsOn(c, "with --bundle: upload source maps for the pushed release: "+strings.Join(sourcemaps.Providers, ", "))
+	registerTuningFlagsOn(c, "with --bundle: ")
+	registerAssetFlagsOn(c, "with --bundle: ")
+	registerCacheFlagsOn(c, "with --bundle: ")
+}
+
+// registerTuningFlagsOn registers the flags passed on to Metro and hermesc,
+// prefixing their usage with usagePrefix.
+func registerTuningFlagsOn(c *cobra.Command, usagePrefix string) {
+	minify := c.Flags().VarPF(&bundleMinify, "minify", "", usagePrefix+"minify the bundle, or --minify=false to not minify it (default: Metro minifies unless --dev, Expo does not)")
+	minify.NoOptDefVal = "true"
+	c.Flags().BoolVar(&bundleRamBundle, "ram-bundle", false, usagePrefix+"build a RAM bundle (React Native without Hermes only)")
+	c.Flags().Var(&bundleHermesFlags, "hermes-flags", usagePrefix+`flags passed to hermesc, separated by spaces (e.g. "-O -output-source-map")`)
+	c.Flags().IntVar(&bundleMaxWorkers, "max-workers", 0, usagePrefix+"number of bundler workers (default: the bundler's)")
+	c.Flags().DurationVar(&bundleTimeout, "bundler-timeout", bundler.DefaultCommandTimeout, usagePrefix+"stop each bundler command (install, Metro, hermesc) that runs longer than this, 0 for no limit")
+	c.Flags().StringVar(&bundleLogFile, "bundler-log", "", usagePrefix+"append the full output of the bundler commands to this file (default on Bitrise: "+bundleLogName+" in the deploy directory)")
+}
+
+// optionalBool is a bool flag that tells whether it was given.
+type optionalBool struct {
+	value *bool
+}
+
+func (b *optionalBool) String() string {
+	if b.value == nil {
+		return ""
+	}
+	return strconv.FormatBool(*b.value)
+}
+
+func (b *optionalBool) Set(s string) error {
+	v, err := strconv.ParseBool(s)
+	if err != nil {
+		return err
+	}
+	b.value = &v
+	return nil
+}
+
+func (b *optionalBool) Type() string { return "bool" }
+
+// hermesFlagsValue is the --hermes-flags flag, validated when it is parsed.
+type hermesFlagsValue struct {
+	raw   string
+	flags []string
+}
+
+func (h *hermesFlagsValue) String() string { return h.raw }
+
+func (h *hermesFlagsValue) Set(s string) error {
+	flags, err := bundler.ParseHermesFlags(s)
+	if err != nil {
+		return err
+	}
+	h.raw, h.flags = s, flags
+	return nil
+}
+
+func (h *hermesFlagsValue) Type() string { return "string" }
+
+// registerAssetFlagsOn registers the asset optimization flags, prefixing
+// their usage with usagePrefix.
+func registerAssetFlagsOn(c *cobra.Command, usagePrefix string) {
+	c.Flags().BoolVar(&bundleOptimizeAssets, "optimize-assets", false, usagePrefix+"recompress PNG/JPEG/WebP assets and strip unused density variants")
+	c.Flags().IntVar(&bundleAssetQuality, "quality", 0, usagePrefix+"lossy JPEG/WebP quality 1-100 for --optimize-assets (default lossless)")
+}
+
+// registerCacheFlagsOn registers the bundle cache flags, prefixing their
+// usage with usagePrefix.
+func registerCacheFlagsOn(c *cobra.Command, usagePrefix string) {
+	c.Flags().BoolVar(&bundleCache, "cache", false, usagePrefix+"reuse the output of an earlier build of the same sources and options from the bundle cache")
+	c.Flags().StringVar(&cacheDir, "cache-dir", "", usagePrefix+"bundle cache directory, implies --cache (default: under $BITRISE_CACHE_DIR or the user cache directory)")
+	c.Flags().StringVar(&cacheMaxSize, "cache-max-size", "", usagePrefix+"bundle cache size cap, e.g. 5GB; least recently used entries are evicted beyond it")
+}
+
+// descriptionFlags holds the values of the release notes flags of a command.
+type descriptionFlags struct {
+	file    string
+	fromGit bool
+}
+
+// registerDescriptionFlagsOn registers the release notes flags next to the
+// command's --description flag, binding them to f.
+func registerDescriptionFlagsOn(c *cobra.Command, f *descriptionFlags) {
+	c.Flags().StringVar(&f.file, "description-file", "", "read the description from a file; for a Markdown changelog, its newest entry")
+	c.Flags().BoolVar(&f.fromGit, "description-from-git", false, "generate the description from the commits since the last git tag")
+	c.MarkFlagsMutuallyExclusive("description", "description-file")
+}
+
+// resolve returns the release description from --description and the
+// release notes flags, rendering templates such as {{.CommitCount}}.
+func (f *descriptionFlags) resolve(ctx context.Context, description string) (string, error) {
+	return releasenotes.Resolve(ctx, releasenotes.Options{
+		Description: description,
+		File:        f.file,
+		FromGit:     f.fromGit,
+	})
+}
+
+// targetingFlags holds the values of the --target-* flags of a command.
+type targetingFlags struct {
+	osVersion    string
+	deviceModels []string
+	countries    []string
+}
+
+// registerTargetingFlagsOn registers the audience targeting flags, binding
+// them to f.
+func registerTargetingFlagsOn(c *cobra.Command, f *targetingFlags) {
+	c.Flags().StringVar(&f.osVersion, "target-os-version", "", `only offer the release to OS versions in this range (e.g. ">=14.0 <17")`)
+	c.Flags().StringArrayVar(&f.deviceModels, "target-device-model", nil, "only offer the release to this device model, e.g. iPhone14,2 (repeatable)")
+	c.Flags().StringSliceVar(&f.countries, "target-country", nil, "only offer the release in these ISO 3166-1 alpha-2 countries (e.g. DE,AT)")
+}
+
+// targeting returns the validated targeting conditions.
+func (f *targetingFlags) targeting() (codepush.Targeting, error) {
+	return codepush.NewTargeting(f.osVersion, f.deviceModels, f.countries)
+}
+
+// applyToPatch sets the targeting changes of the flags given on c in opts.
+// An empty value removes the condition.
+func (f *targetingFlags) applyToPatch(c *cobra.Command, opts *codepush.PatchOptions) {
+	if c.Flags().Changed("target-os-version") {
+		opts.TargetOSVersion = &f.osVersion
+	}
+	if c.Flags().Changed("target-device-model") {
+		models := slices.DeleteFunc(slices.Clone(f.deviceModels), func(m string) bool { return m == "" })
+		opts.TargetDeviceModels = &models
+	}
+	if c.Flags().Changed("target-country") {
+		opts.TargetCountries = &f.countries
+	}
+}
+
+// targetingSummary describes targeting conditions for display.
+func targetingSummary(t codepush.Targeting) string {
+	if !t.Targeted() {
+		return "all devices"
+	}
+	return t.TargetingSummary()
 }
 
-func runBundleWithOpts(out *output.Writer) (*bundler.BundleResult, error) {
-	opts := &bundler.BundleOptions{
-		Platform:         bundler.Platform(bundlePlatform),
+// deploymentDisplayName returns the deployment given by flag or
+// CODEPUSH_DEPLOYMENT, or else the resolved ID, for messages.
+func deploymentDisplayName(flag, deploymentID string) string {
+	if v := cmdutil.ResolveFlag(flag, "CODEPUSH_DEPLOYMENT"); v != "" {
+		return v
+	}
+	return deploymentID
+}
+
+// registerSourcemapFlagsOn registers --sourcemap-provider and the
+// provider-specific flags.
+func registerSourcemapFlagsOn(c *cobra.Command, usage string) {
+	c.Flags().StringVar(&bundleUploadSourcemaps, "sourcemap-provider", "", usage)
+	c.Flags().StringVar(&bundleUploadSourcemaps, "upload-sourcemaps", "", usage)
+	_ = c.Flags().MarkDeprecated("upload-sourcemaps", "use --sourcemap-provider instead")
+	c.Flags().StringVar(&bundleSourcemapOpts.BugsnagAPIKey, "bugsnag-api-key", "", "Bugsnag project API key (env: "+sourcemaps.BugsnagAPIKeyEnvKey+")")
+	c.Flags().StringVar(&bundleSourcemapOpts.BugsnagCodeBundleID, "bugsnag-code-bundle-id", "", "Bugsnag code bundle ID (default: <app version>+codepush:<label>)")
+	c.Flags().StringVar(&bundleSourcemapOpts.DatadogService, "datadog-service", "", "Datadog RUM service name")
+	c.Flags().StringVar(&bundleSourcemapOpts.DatadogReleaseVersion, "datadog-release-version", "", "Datadog release version (default: <app version>-codepush.<label>)")
+	c.Flags().StringVar(&bundleSourcemapOpts.DatadogBuildVersion, "datadog-build-version", "", "native build number reported to Datadog")
+}
+
+// runBundleWithOpts bundles for platform with the shared bundle flags,
+// through the bundle cache when --cache or --cache-dir is given.
+func runBundleWithOpts(ctx context.Context, platform bundler.Platform, out *output.Writer) (*bundler.BundleResult, error) {
+	cache, err := bundleCacheOpt()
+	if err != nil {
+		return nil, err
+	}
+	opts := bundleOptions(platform)
+	var result *bundler.BundleResult
+	if cache == nil {
+		result, err = bundler.Run(ctx, opts, out)
+	} else {
+		result, err = bundler.RunCached(ctx, opts, cache, cmd.Version, out)
+	}
+	reportBundleLog(opts, err, out)
+	return result, err
+}
+
+// bundleProject bundles the project for platforms with the shared bundle
+// flags, then warns about SDK features the project cannot use and signs
+// each output. It is the bundling step of both 'codepush bundle' and
+// 'codepush push --bundle'.
+func bundleProject(ctx context.Context, platforms []bundler.Platform, out *output.Writer) ([]*bundler.BundleResult, error) {
+	var results []*bundler.BundleResult
+	if len(platforms) > 1 {
+		var err error
+		if results, err = runPlatformBundles(ctx, platforms, out); err != nil {
+			return nil, err
+		}
+	} else {
+		result, err := runBundleWithOpts(ctx, platforms[0], out)
+		if err != nil {
+			return nil, err
+		}
+		results = []*bundler.BundleResult{result}
+	}
+
+	dirs := make([]string, len(results))
+	for i, result := range results {
+		dirs[i] = result.OutputDir
+	}
+	if err := prepareBundles(dirs, out); err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+// prepareBundles warns about SDK features the project cannot use and signs
+// the bundle in each of dirs, before the bundles are reported or pushed.
+func prepareBundles(dirs []string, out *output.Writer) error {
+	warnSDKCompatibility(out)
+	for _, dir := range dirs {
+		if err := signBundleDir(dir, out); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// runPlatformBundles bundles for several platforms at once with the shared
+// bundle flags, through the bundle cache when --cache or --cache-dir is
+// given.
+func runPlatformBundles(ctx context.Context, platforms []bundler.Platform, out *output.Writer) ([]*bundler.BundleResult, error) {
+	cache, err := bundleCacheOpt()
+	if err != nil {
+		return nil, err
+	}
+	opts := bundleOptions("")
+	results, err := bundler.RunPlatforms(ctx, opts, platforms, cache, cmd.Version, out)
+	reportBundleLog(opts, err, out)
+	return results, err
+}
+
+// bundleCacheOpt returns the bundle cache to build through, or nil when
+// neither --cache nor --cache-dir is given.
+func bundleCacheOpt() (bundler.Cache, error) {
+	if !bundleCache && cacheDir == "" {
+		return nil, nil
+	}
+	cache, err := openBundleCache()
+	if err != nil {
+		return nil, err
+	}
+	return cache, nil
+}
+
+// bundleOptions builds bundler options for platform from the shared bundle
+// flags.
+func bundleOptions(platform bundler.Platform) *bundler.BundleOptions {
+	return &bundler.BundleOptions{
+		Platform:         platform,
 		EntryFile:        bundleEntryFile,
 		OutputDir:        bundleOutputDir,
 		BundleName:       bundleBundleName,
 		Dev:              bundleDev,
-		Minify:           bundleMinify,
+		Minify:           bundleMinify.value,
+		RamBundle:        bundleRamBundle,
+		MaxWorkers:       bundleMaxWorkers,
 		ResetCache:       bundleResetCache,
 		Sourcemap:        bundleSourcemap,
 		SourcemapOutput:  bundleSourcemapOutput,
 		HermesMode:       bundler.HermesMode(bundleHermes),
 		ExtraBundlerOpts: bundleExtraBundlerOpts,
-		ExtraHermesFlags: bundleExtraHermesFlags,
+		ExtraHermesFlags: append(slices.Clone(bundleExtraHermesFlags), bundleHermesFlags.flags...),
 		ProjectDir:       bundleProjectDir,
 		MetroConfig:      bundleMetroConfig,
 		SkipInstall:      bundleSkipInstall,
+		PackageManager:   bundler.PackageManager(bundlePackageManager),
+		SkipNodeCheck:    bundleSkipNodeCheck,
+		UseNodeVersion:   bundleUseNodeVersion,
 		GradleFile:       bundleGradleFile,
 		PodFile:          bundlePodFile,
+		OptimizeAssets:   bundleOptimizeAssets,
+		AssetQuality:     bundleAssetQuality,
+		CommandTimeout:   bundleTimeout,
+		LogFile:          bundleLogPath(),
+		Workspace:        cmd.Workspace,
 	}
+}
+
+// bundleLogName is the name of the bundler log in the Bitrise deploy
+// directory.
+const bundleLogName = "codepush-bundle.log"
 
-	return bundler.Run(opts, out)
+// bundleLogPath returns the file to log the bundler commands to: the
+// --bundler-log file or, on Bitrise, the log next to the bundle summary in
+// the deploy directory, so failed builds can be debugged from their
+// artifacts.
+func bundleLogPath() string {
+	if bundleLogFile != "" {
+		return bundleLogFile
+	}
+	if deployDir := bitrise.GetBuildMetadata().DeployDir; deployDir != "" {
+		return filepath.Join(deployDir, bundleLogName)
+	}
+	return ""
+}
+
+// reportBundleLog points to the bundler log after a failed build.
+func reportBundleLog(opts *bundler.BundleOptions, err error, out *output.Writer) {
+	if err != nil && opts.LogFile != "" {
+		out.Info("Bundler log: %s", opts.LogFile)
+	}
+}
+
+// signBundleDir signs the bundle in dir with --private-key-path, when given.
+func signBundleDir(dir string, out *output.Writer) error {
+	if bundlePrivateKeyPath == "" {
+		return nil
+	}
+	stepSign := out.StartStep("Signing bundle")
+	if err := bundler.SignBundle(dir, bundlePrivateKeyPath, cmd.Version); err != nil {
+		stepSign.Cancel()
+		return fmt.Errorf("signing bundle: %w", err)
+	}
+	stepSign.Done()
+	out.Info("Signed: %s/.codepushrelease", dir)
+	return nil
+}
+
+// warnSDKCompatibility warns when a requested feature is not supported by
+// the CodePush SDK version installed in the project. Projects without a
+// detectable SDK are skipped.
+func warnSDKCompatibility(out *output.Writer) {
+	var features []bundler.Feature
+	if bundlePrivateKeyPath != "" {
+		features = append(features, bundler.FeatureCodeSigning)
+	}
+	if len(features) == 0 {
+		return
+	}
+
+	projectDir := bundleProjectDir
+	if projectDir == "" {
+		projectDir = "."
+	}
+	for _, issue := range bundler.CheckSDKCompatibility(bundler.DetectSDK(projectDir), features) {
+		out.Warning("%s", issue)
+	}
 }
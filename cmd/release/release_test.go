@@ -1,16 +1,21 @@
 package release
 
 import (
+	"context"
 	"io"
 	"os"
+	"path/filepath"
 	"testing"
 
+	"github.com/spf13/cobra"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
 	"github.com/bitrise-io/bitrise-plugins-codepush-cli/cmd"
 	"github.com/bitrise-io/bitrise-plugins-codepush-cli/internal/bundler"
+	"github.com/bitrise-io/bitrise-plugins-codepush-cli/internal/codepush"
 	"github.com/bitrise-io/bitrise-plugins-codepush-cli/internal/output"
+	"github.com/bitrise-io/bitrise-plugins-codepush-cli/internal/session"
 )
 
 func TestMain(m *testing.M) {
@@ -34,7 +39,7 @@ func TestRunBundleValidation(t *testing.T) {
 		bundlePlatform = "windows"
 		defer func() { bundlePlatform = old }()
 
-		err := runBundle(cmd.Out)
+		err := runBundle(context.Background(), cmd.Out)
 		require.Error(t, err)
 		assert.ErrorContains(t, err, "platform")
 	})
@@ -49,7 +54,7 @@ func TestRunBundleValidation(t *testing.T) {
 			bundleHermes = oldHermes
 		}()
 
-		err := runBundle(cmd.Out)
+		err := runBundle(context.Background(), cmd.Out)
 		require.Error(t, err)
 		assert.ErrorContains(t, err, "hermes")
 	})
@@ -97,3 +102,290 @@ func TestValidateHermesMode(t *testing.T) {
 		}
 	}
 }
+
+func TestDescribePushState(t *testing.T) {
+	got := describePushState(&session.PushState{Platform: "ios", Deployment: "dep-1", AppVersion: "1.2.0"})
+	assert.Equal(t, "Resume (ios, deployment dep-1, version 1.2.0)", got)
+}
+
+func TestArchiveSourcemap(t *testing.T) {
+	writeMap := func(t *testing.T) string {
+		t.Helper()
+		path := filepath.Join(t.TempDir(), "index.android.bundle.map")
+		require.NoError(t, os.WriteFile(path, []byte(`{"version":3}`), 0o644))
+		return path
+	}
+
+	t.Run("archives to the deploy dir", func(t *testing.T) {
+		deployDir := t.TempDir()
+		t.Setenv("BITRISE_DEPLOY_DIR", deployDir)
+
+		require.NoError(t, archiveSourcemap("Production", writeMap(t), cmd.Out))
+		assert.FileExists(t, filepath.Join(deployDir, "index.android.bundle.map"))
+	})
+
+	t.Run("fails without a sourcemap", func(t *testing.T) {
+		t.Setenv("BITRISE_DEPLOY_DIR", t.TempDir())
+
+		err := archiveSourcemap("Production", "", cmd.Out)
+		require.Error(t, err)
+		assert.ErrorContains(t, err, "requires a sourcemap")
+		assert.ErrorContains(t, err, "--no-sourcemap-policy-check")
+	})
+
+	t.Run("fails outside Bitrise", func(t *testing.T) {
+		t.Setenv("BITRISE_DEPLOY_DIR", "")
+
+		err := archiveSourcemap("Production", writeMap(t), cmd.Out)
+		require.Error(t, err)
+		assert.ErrorContains(t, err, "BITRISE_DEPLOY_DIR is not set")
+	})
+}
+
+func TestFindSourcemap(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "index.ios.bundle"), []byte("bundle"), 0o644))
+	assert.Empty(t, findSourcemap(dir))
+
+	mapPath := filepath.Join(dir, "index.ios.bundle.map")
+	require.NoError(t, os.WriteFile(mapPath, []byte("{}"), 0o644))
+	assert.Equal(t, mapPath, findSourcemap(dir))
+}
+
+func TestPushDeploymentValues(t *testing.T) {
+	tests := []struct {
+		name string
+		flag []string
+		env  string
+		want []string
+	}{
+		{name: "none"},
+		{name: "flag list", flag: []string{"Staging", " QA", "Staging"}, env: "Production", want: []string{"Staging", "QA"}},
+		{name: "env list", env: "Staging, QA,,Beta", want: []string{"Staging", "QA", "Beta"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("CODEPUSH_DEPLOYMENT", tt.env)
+			pushDeployments = tt.flag
+			t.Cleanup(func() { pushDeployments = nil })
+
+			assert.Equal(t, tt.want, pushDeploymentValues())
+		})
+	}
+}
+
+func TestPushRejectsExpectLabelWithSeveralDeployments(t *testing.T) {
+	pushDeployments, pushExpectLabel = []string{"Staging", "QA"}, "v3"
+	t.Cleanup(func() { pushDeployments, pushExpectLabel = nil, "" })
+
+	err := pushCmd.RunE(pushCmd, []string{t.TempDir()})
+	require.Error(t, err)
+	assert.ErrorContains(t, err, "--expect-label cannot be used with several deployments")
+}
+
+func TestPushAppList(t *testing.T) {
+	manifest := filepath.Join(t.TempDir(), "apps.txt")
+	require.NoError(t, os.WriteFile(manifest, []byte("app-b Brand B\napp-c\n"), 0o644))
+
+	tests := []struct {
+		name   string
+		appIDs []string
+		file   string
+		want   []codepush.AppTarget
+	}{
+		{name: "single app-id", appIDs: []string{"app-a"}},
+		{
+			name:   "repeated app-id",
+			appIDs: []string{"app-a", "app-b", "app-a"},
+			want:   []codepush.AppTarget{{ID: "app-a", Name: "app-a"}, {ID: "app-b", Name: "app-b"}},
+		},
+		{
+			name:   "app-id and manifest",
+			appIDs: []string{"app-b"},
+			file:   manifest,
+			want:   []codepush.AppTarget{{ID: "app-b", Name: "app-b"}, {ID: "app-c", Name: "app-c"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cmd.AppIDs, pushAppsFile = tt.appIDs, tt.file
+			t.Cleanup(func() { cmd.AppIDs, pushAppsFile = nil, "" })
+
+			apps, err := pushAppList()
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, apps)
+		})
+	}
+}
+
+func TestValidateMultiAppPush(t *testing.T) {
+	tests := []struct {
+		name        string
+		deployments []string
+		expectLabel string
+		wantErr     string
+	}{
+		{name: "valid", deployments: []string{"Production"}},
+		{name: "no deployment", wantErr: "--deployment is required"},
+		{name: "deployment uuid", deployments: []string{"6f1c3a2e-8d4b-4e2a-9c1f-2b3d4e5f6a7b"}, wantErr: "give deployments by name"},
+		{name: "expect label", deployments: []string{"Production"}, expectLabel: "v3", wantErr: "--expect-label cannot be used with several apps"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pushExpectLabel = tt.expectLabel
+			t.Cleanup(func() { pushExpectLabel = "" })
+
+			err := validateMultiAppPush(tt.deployments)
+			if tt.wantErr == "" {
+				require.NoError(t, err)
+				return
+			}
+			require.Error(t, err)
+			assert.ErrorContains(t, err, tt.wantErr)
+			var validationErr *codepush.ValidationError
+			assert.ErrorAs(t, err, &validationErr)
+		})
+	}
+}
+
+func TestApplyRejectsInvalidManifest(t *testing.T) {
+	tests := []struct {
+		name     string
+		manifest string
+		wantErr  string
+	}{
+		{
+			name:     "invalid targeting",
+			manifest: "deployment: Staging\napp_version: 1.0.0\nbundle: {path: CodePush}\ntargeting: {countries: [Germany]}\n",
+			wantErr:  "release manifest",
+		},
+		{
+			name:     "four-part app version",
+			manifest: "deployment: Staging\napp_version: 1.0.0.1\nbundle: {path: CodePush}\n",
+			wantErr:  `release manifest: invalid app version "1.0.0.1"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "release.yaml")
+			require.NoError(t, os.WriteFile(path, []byte(tt.manifest), 0o644))
+			applyFile = path
+			t.Cleanup(func() { applyFile = "" })
+
+			err := runApply(context.Background(), "", cmd.Out)
+			require.Error(t, err)
+			assert.ErrorContains(t, err, tt.wantErr)
+			var validationErr *codepush.ValidationError
+			assert.ErrorAs(t, err, &validationErr)
+		})
+	}
+}
+
+func TestSeveralPlatformsValidation(t *testing.T) {
+	oldPlatform, oldAutoBundle, oldVerify := bundlePlatform, pushAutoBundle, bundleVerifyDeterminism
+	defer func() {
+		bundlePlatform, pushAutoBundle, bundleVerifyDeterminism = oldPlatform, oldAutoBundle, oldVerify
+	}()
+	bundlePlatform = "all"
+
+	t.Run("bundle with --verify-determinism", func(t *testing.T) {
+		bundleVerifyDeterminism = true
+		defer func() { bundleVerifyDeterminism = false }()
+
+		err := runBundle(context.Background(), cmd.Out)
+		assert.ErrorContains(t, err, "one platform at a time")
+	})
+
+	t.Run("push to a single app", func(t *testing.T) {
+		pushAutoBundle = true
+		err := pushCmd.RunE(pushCmd, []string{})
+		var validationErr *codepush.ValidationError
+		require.ErrorAs(t, err, &validationErr)
+		assert.ErrorContains(t, err, "needs an app for each")
+	})
+}
+
+func TestParseOverrideFreeze(t *testing.T) {
+	tests := []struct {
+		name    string
+		args    []string
+		want    string
+		wantErr bool
+	}{
+		{name: "not set"},
+		{name: "reason", args: []string{"--override-freeze", "sev1 hotfix"}, want: "sev1 hotfix"},
+		{name: "reason= form", args: []string{`--override-freeze=reason=sev1 hotfix`}, want: "sev1 hotfix"},
+		{name: "empty reason", args: []string{"--override-freeze", " "}, wantErr: true},
+		{name: "empty reason= form", args: []string{"--override-freeze", "reason="}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var value string
+			c := &cobra.Command{}
+			c.Flags().StringVar(&value, "override-freeze", "", "")
+			require.NoError(t, c.Flags().Parse(tt.args))
+
+			got, err := parseOverrideFreeze(c, value)
+			if tt.wantErr {
+				var validationErr *codepush.ValidationError
+				assert.ErrorAs(t, err, &validationErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestApplyDeploymentDefaults(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.Mkdir(filepath.Join(dir, ".git"), 0o755))
+	config := `{"deployment_defaults":{"Staging":{"rollout":100,"hermes":"on"},"Production":{"rollout":10,"mandatory":true,"description":"Weekly release","hermes":"on"}}}`
+	require.NoError(t, os.WriteFile(filepath.Join(dir, ".codepush.json"), []byte(config), 0o644))
+	t.Chdir(dir)
+
+	oldRollout, oldMandatory, oldDescription, oldHermes := pushRollout, pushMandatory, pushDescription, bundleHermes
+	t.Cleanup(func() {
+		pushRollout, pushMandatory, pushDescription, bundleHermes = oldRollout, oldMandatory, oldDescription, oldHermes
+	})
+
+	tests := []struct {
+		name            string
+		deployments     []string
+		args            []string
+		wantRollout     int
+		wantMandatory   bool
+		wantDescription string
+		wantErr         string
+	}{
+		{name: "one deployment", deployments: []string{"production"}, wantRollout: 10, wantMandatory: true, wantDescription: "Weekly release"},
+		{name: "flags win", deployments: []string{"Production"}, args: []string{"--rollout", "50", "--description-from-git"}, wantRollout: 50, wantMandatory: true},
+		{name: "conflicting deployments", deployments: []string{"Staging", "Production"}, wantErr: "set different rollout for Staging, Production: set --rollout"},
+		{name: "conflicts resolved by flags", deployments: []string{"Staging", "Production"}, args: []string{"-r", "5", "-m", "--description", "Hotfix"}, wantRollout: 5, wantMandatory: true, wantDescription: "Hotfix"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := &cobra.Command{}
+			c.Flags().IntVarP(&pushRollout, "rollout", "r", 100, "")
+			c.Flags().BoolVarP(&pushMandatory, "mandatory", "m", false, "")
+			c.Flags().StringVar(&pushDescription, "description", "", "")
+			c.Flags().Bool("description-from-git", false, "")
+			c.Flags().StringVar(&bundleHermes, "hermes", "auto", "")
+			require.NoError(t, c.Flags().Parse(tt.args))
+
+			err := applyDeploymentDefaults(c, tt.deployments, cmd.Out)
+			if tt.wantErr != "" {
+				assert.ErrorContains(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantRollout, pushRollout)
+			assert.Equal(t, tt.wantMandatory, pushMandatory)
+			assert.Equal(t, tt.wantDescription, pushDescription)
+			assert.Equal(t, "on", bundleHermes)
+		})
+	}
+}
@@ -2,19 +2,21 @@ package release
 
 import (
 	"fmt"
+	"strconv"
 
 	"github.com/spf13/cobra"
 
 	"github.com/bitrise-io/bitrise-plugins-codepush-cli/cmd"
-	"github.com/bitrise-io/bitrise-plugins-codepush-cli/internal/bitrise"
 	"github.com/bitrise-io/bitrise-plugins-codepush-cli/internal/cmdutil"
 	"github.com/bitrise-io/bitrise-plugins-codepush-cli/internal/codepush"
 	"github.com/bitrise-io/bitrise-plugins-codepush-cli/internal/output"
+	"github.com/bitrise-io/bitrise-plugins-codepush-cli/internal/releaser"
 )
 
 var (
 	rollbackDeployment    string
 	rollbackTargetRelease string
+	rollbackDryRun        bool
 )
 
 var rollbackCmd = &cobra.Command{
@@ -24,20 +26,21 @@ var rollbackCmd = &cobra.Command{
 
 Creates a new release that mirrors a previous version. By default,
 rolls back to the immediately previous release. Use --target-release
-to specify a specific version label (e.g. v3).`,
+to specify a specific version label (e.g. v3).
+
+Use --dry-run to show the release that would be created without creating it.`,
 	GroupID: cmd.GroupRelease,
 	RunE: func(c *cobra.Command, args []string) error {
 		out := cmd.Out
 
-		appID, token, err := cmdutil.RequireCredentials(cmd.AppID, out)
+		appID, token, err := cmdutil.RequireCredentials(c.Context(), cmd.AppID, out, cmd.Relogin, cmd.FirstRun)
 		if err != nil {
 			return err
 		}
 
-		serverURL := cmdutil.ResolveServerURL(cmd.ServerURL, out)
-		client := codepush.NewHTTPClient(cmdutil.APIURL(serverURL), token, cmd.Version)
+		client := cmd.NewClient(cmdutil.ResolveAPIURL(cmd.APIURL, cmd.ServerURL, out), token)
 
-		deploymentID, err := cmdutil.ResolveDeploymentInteractive(c.Context(), client, appID, rollbackDeployment, "CODEPUSH_DEPLOYMENT", out)
+		deploymentID, err := cmdutil.ResolveDeploymentForWrite(c.Context(), client, appID, rollbackDeployment, "CODEPUSH_DEPLOYMENT", out)
 		if err != nil {
 			return err
 		}
@@ -49,13 +52,39 @@ to specify a specific version label (e.g. v3).`,
 			TargetLabel:  rollbackTargetRelease,
 		}
 
-		result, err := codepush.Rollback(c.Context(), client, opts, out)
+		if rollbackDryRun {
+			preview, err := codepush.PreviewRollback(c.Context(), client, opts, out)
+			if err != nil {
+				return err
+			}
+			if cmd.JSONOutput {
+				return cmdutil.OutputResult(preview)
+			}
+			printRollbackPreview(out, preview)
+			return nil
+		}
+
+		result, err := releaser.New(appID, token, client, out).Rollback(c.Context(), opts)
 		if err != nil {
 			return fmt.Errorf("rollback failed: %w", err)
 		}
 
+		cmdutil.ExportStepOutputs("codepush-rollback-summary.json", result, cmdutil.StepOutputs{
+			UpdateID:     result.UpdateID,
+			Label:        result.Label,
+			AppVersion:   result.AppVersion,
+			DeploymentID: result.DeploymentID,
+		}, out)
+		cmdutil.AnnotateRelease(cmdutil.ReleaseAnnotation{
+			Title:        "Rolled back " + deploymentDisplayName(rollbackDeployment, result.DeploymentID),
+			AppID:        result.AppID,
+			DeploymentID: result.DeploymentID,
+			UpdateID:     result.UpdateID,
+			Label:        result.Label,
+			AppVersion:   result.AppVersion,
+		}, out)
 		if cmd.JSONOutput {
-			return cmdutil.OutputJSON(result)
+			return cmdutil.OutputResult(result)
 		}
 
 		out.Success("Rollback successful")
@@ -65,19 +94,36 @@ to specify a specific version label (e.g. v3).`,
 			{Key: "App version", Value: result.AppVersion},
 		})
 
-		if bitrise.IsBitriseEnvironment() {
-			cmdutil.ExportEnvVars(map[string]string{
-				"CODEPUSH_UPDATE_ID":   result.UpdateID,
-				"CODEPUSH_APP_VERSION": result.AppVersion,
-			}, out)
-		}
-
 		return nil
 	},
 }
 
+func printRollbackPreview(out *output.Writer, preview *codepush.RollbackPreview) {
+	label := preview.Label
+	if label == "" {
+		label = "(assigned by the server)"
+	}
+	target := preview.Target
+	pairs := []output.KeyValue{
+		{Key: "New release", Value: label},
+		{Key: "Restores", Value: target.Label},
+		{Key: "Replaces", Value: preview.Current.Label},
+		{Key: "App version", Value: target.AppVersion},
+		{Key: "Size", Value: cmdutil.FormatBytes(target.FileSizeBytes)},
+		{Key: "Mandatory", Value: strconv.FormatBool(target.Mandatory)},
+	}
+	if target.Description != "" {
+		pairs = append(pairs, output.KeyValue{Key: "Description", Value: target.Description})
+	}
+	out.Result(pairs)
+	out.Info("Dry run: no release was created")
+}
+
 func init() {
 	rollbackCmd.Flags().StringVarP(&rollbackDeployment, "deployment", "d", "", "deployment name or UUID (env: CODEPUSH_DEPLOYMENT)")
 	rollbackCmd.Flags().StringVarP(&rollbackTargetRelease, "target-release", "r", "", "specific release label to rollback to (e.g. v3)")
+	rollbackCmd.Flags().BoolVar(&rollbackDryRun, "dry-run", false, "show the release that would be created without creating it")
+	_ = rollbackCmd.RegisterFlagCompletionFunc("deployment", cmd.CompleteDeployments)
+	_ = rollbackCmd.RegisterFlagCompletionFunc("target-release", cmd.CompleteLabels("deployment"))
 	cmd.RootCmd.AddCommand(rollbackCmd)
 }
@@ -0,0 +1,407 @@
+package release
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/bitrise-io/bitrise-plugins-codepush-cli/cmd"
+	"github.com/bitrise-io/bitrise-plugins-codepush-cli/internal/cmdutil"
+	"github.com/bitrise-io/bitrise-plugins-codepush-cli/internal/codepush"
+	"github.com/bitrise-io/bitrise-plugins-codepush-cli/internal/output"
+	"github.com/bitrise-io/bitrise-plugins-codepush-cli/internal/rollout"
+)
+
+var (
+	rolloutSteps          string
+	rolloutInterval       time.Duration
+	rolloutLabel          string
+	rolloutMaxFailureRate float64
+	rolloutHealthURL      string
+	rolloutDetach         bool
+	rolloutRunLoop        time.Duration
+)
+
+// rolloutRetryDelay is how long a waiting rollout pauses after an API error.
+const rolloutRetryDelay = time.Minute
+
+var rolloutCmd = &cobra.Command{
+	Use:   "rollout",
+	Short: "Automate staged rollouts",
+	Long: `Raise a release's rollout percentage in steps on a timer, checking the
+release between steps and halting on failures.
+
+'rollout start' applies the first step at once and keeps running until the
+last step, unless --detach is set. Rollouts are recorded in a state file in
+the user config directory, so 'rollout run' (from cron, or with --loop) can
+continue them after the starting process exits.`,
+	GroupID: cmd.GroupRelease,
+}
+
+var rolloutStartCmd = &cobra.Command{
+	Use:   "start [deployment]",
+	Short: "Start a staged rollout of a release",
+	Long: `Start a staged rollout of the latest release (or --label) in a deployment.
+
+Before each step after the first, the rollout halts if the release was
+disabled or removed, its failure rate exceeds --max-failure-rate, or
+--health-url does not respond with a 2xx status. A halted rollout keeps its
+current percentage; use 'rollback' or 'patch' to act on it.`,
+	Example: `  codepush rollout start Production --steps 10,25,50,100 --interval 30m
+  codepush rollout start Production --steps 5,20,100 --interval 2h --max-failure-rate 2 --detach`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(c *cobra.Command, args []string) error {
+		out := cmd.Out
+
+		steps, err := parseRolloutFlags()
+		if err != nil {
+			return err
+		}
+
+		appID, token, err := cmdutil.RequireCredentials(c.Context(), cmd.AppID, out, cmd.Relogin, cmd.FirstRun)
+		if err != nil {
+			return err
+		}
+
+		client := cmd.NewClient(cmdutil.ResolveAPIURL(cmd.APIURL, cmd.ServerURL, out), token)
+
+		var argValue string
+		if len(args) > 0 {
+			argValue = args[0]
+		}
+		deploymentID, err := cmdutil.ResolveDeploymentForWrite(c.Context(), client, appID, argValue, "CODEPUSH_DEPLOYMENT", out)
+		if err != nil {
+			return err
+		}
+		deploymentName := argValue
+		if deploymentName == "" {
+			deploymentName = cmdutil.ResolveFlag("", "CODEPUSH_DEPLOYMENT")
+		}
+
+		r, err := startRollout(c.Context(), client, appID, deploymentID, deploymentName, steps, out)
+		if err != nil {
+			return err
+		}
+		if r, err = runStartedRollout(c.Context(), client, r, out); err != nil {
+			return err
+		}
+
+		if cmd.JSONOutput {
+			return cmdutil.OutputResult(r)
+		}
+		return nil
+	},
+}
+
+// parseRolloutFlags validates the rollout flags and returns the rollout
+// percentages of --steps.
+func parseRolloutFlags() ([]int, error) {
+	steps, err := rollout.ParseSteps(rolloutSteps)
+	if err != nil {
+		return nil, fmt.Errorf("--steps: %w", err)
+	}
+	if rolloutInterval <= 0 {
+		return nil, fmt.Errorf("interval must be positive, got %s", rolloutInterval)
+	}
+	if rolloutMaxFailureRate < 0 || rolloutMaxFailureRate > 100 {
+		return nil, fmt.Errorf("max failure rate must be between 0 and 100, got %g", rolloutMaxFailureRate)
+	}
+	return steps, nil
+}
+
+// startRollout records a rollout of the latest release (or --label) of a
+// deployment and applies its first step.
+func startRollout(ctx context.Context, client *codepush.HTTPClient, appID, deploymentID, deploymentName string, steps []int, out *output.Writer) (*rollout.Rollout, error) {
+	updateID, label, err := codepush.ResolveUpdateForPatch(ctx, client, appID, deploymentID, rolloutLabel, out)
+	if err != nil {
+		return nil, err
+	}
+	update, err := client.GetUpdate(ctx, appID, deploymentID, updateID)
+	if err != nil {
+		return nil, fmt.Errorf("getting release %s: %w", label, err)
+	}
+	if current := int(update.Rollout); steps[0] <= current {
+		return nil, fmt.Errorf("release %s is already at %d%% rollout: the first step must be higher (push with --rollout to stage a new release)", label, current)
+	}
+
+	r, err := rollout.Add(rollout.Rollout{
+		AppID:          appID,
+		DeploymentID:   deploymentID,
+		Deployment:     deploymentName,
+		UpdateID:       updateID,
+		Label:          label,
+		APIURL:         client.BaseURL,
+		Steps:          steps,
+		Interval:       rolloutInterval,
+		MaxFailureRate: rolloutMaxFailureRate,
+		HealthURL:      rolloutHealthURL,
+	})
+	if err != nil {
+		return nil, err
+	}
+	out.Success("Started rollout %s of release %s: %s every %s", r.ID, label, formatSteps(steps), rolloutInterval)
+	return advanceRollout(ctx, client, r.ID)
+}
+
+// runStartedRollout follows a running rollout until its last step, unless
+// --detach is set, in which case it tells when to apply the next step.
+func runStartedRollout(ctx context.Context, client *codepush.HTTPClient, r *rollout.Rollout, out *output.Writer) (*rollout.Rollout, error) {
+	if r.Status != rollout.StatusRunning {
+		return r, nil
+	}
+	if rolloutDetach {
+		out.Info("Next step %d%% at %s; run 'codepush rollout run' at or after that time to apply it",
+			r.Next(), r.NextAt.Local().Format(time.DateTime))
+		return r, nil
+	}
+
+	ctx, stop := signal.NotifyContext(ctx, os.Interrupt)
+	defer stop()
+	r, err := followRollout(ctx, client, r)
+	if err != nil {
+		return nil, err
+	}
+	if ctx.Err() != nil {
+		out.Info("Stopped following; rollout %s continues with 'codepush rollout run'", r.ID)
+	}
+	return r, nil
+}
+
+var rolloutStatusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "List rollouts and their progress",
+	Args:  cobra.NoArgs,
+	RunE: func(c *cobra.Command, args []string) error {
+		out := cmd.Out
+
+		rollouts, err := rollout.List()
+		if err != nil {
+			return err
+		}
+
+		if cmd.JSONOutput {
+			if rollouts == nil {
+				rollouts = []rollout.Rollout{}
+			}
+			return cmdutil.OutputResult(rollouts)
+		}
+
+		if len(rollouts) == 0 {
+			out.Info("No rollouts.")
+			return nil
+		}
+
+		rows := make([][]string, len(rollouts))
+		for i, r := range rollouts {
+			deployment := r.Deployment
+			if deployment == "" {
+				deployment = r.DeploymentID
+			}
+			next := "-"
+			if r.Status == rollout.StatusRunning && r.Next() > 0 {
+				next = fmt.Sprintf("%d%% at %s", r.Next(), r.NextAt.Local().Format(time.DateTime))
+			}
+			status := r.Status
+			if r.Reason != "" {
+				status += ": " + r.Reason
+			}
+			rows[i] = []string{r.ID, deployment, r.Label, fmt.Sprintf("%d%%", r.Current()), formatSteps(r.Steps), next, status}
+		}
+		out.Table([]string{"ID", "DEPLOYMENT", "RELEASE", "ROLLOUT", "STEPS", "NEXT", "STATUS"}, rows)
+		return nil
+	},
+}
+
+var rolloutAbortCmd = &cobra.Command{
+	Use:   "abort <id>",
+	Short: "Stop a running rollout (the release keeps its current percentage)",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(c *cobra.Command, args []string) error {
+		r, err := rollout.Get(args[0])
+		if err != nil {
+			return err
+		}
+		if r.Status != rollout.StatusRunning {
+			return fmt.Errorf("rollout %s is already %s", r.ID, r.Status)
+		}
+
+		r.Status = rollout.StatusAborted
+		r.UpdatedAt = time.Now().UTC()
+		if err := rollout.Update(r); err != nil {
+			return err
+		}
+		cmd.Out.Success("Aborted rollout %s; release %s stays at %d%%", r.ID, r.Label, r.Current())
+		return nil
+	},
+}
+
+var rolloutRunCmd = &cobra.Command{
+	Use:   "run",
+	Short: "Apply due steps of running rollouts",
+	Args:  cobra.NoArgs,
+	RunE: func(c *cobra.Command, args []string) error {
+		out := cmd.Out
+
+		if rolloutRunLoop < 0 {
+			return fmt.Errorf("loop interval must be positive, got %s", rolloutRunLoop)
+		}
+
+		token := cmdutil.ResolveToken(c.Context(), out, cmd.Relogin)
+		if token == "" {
+			return fmt.Errorf("%w: set BITRISE_API_TOKEN or run 'codepush auth login'", codepush.ErrMissingToken)
+		}
+
+		if rolloutRunLoop == 0 {
+			return runDueRollouts(c.Context(), token)
+		}
+
+		ctx, stop := signal.NotifyContext(c.Context(), os.Interrupt)
+		defer stop()
+
+		out.Info("Checking rollouts every %s, press Ctrl-C to stop", rolloutRunLoop)
+		ticker := time.NewTicker(rolloutRunLoop)
+		defer ticker.Stop()
+		for {
+			if err := runDueRollouts(ctx, token); err != nil {
+				if ctx.Err() != nil {
+					return nil
+				}
+				out.Warning("%v", err)
+			}
+
+			select {
+			case <-ctx.Done():
+				return nil
+			case <-ticker.C:
+			}
+		}
+	},
+}
+
+func init() {
+	rolloutStartCmd.Flags().StringVar(&rolloutSteps, "steps", "", "rollout percentages to apply in order (e.g. 10,25,50,100)")
+	rolloutStartCmd.Flags().DurationVar(&rolloutInterval, "interval", 30*time.Minute, "time between steps")
+	rolloutStartCmd.Flags().StringVarP(&rolloutLabel, "label", "l", "", "release label to roll out (default: latest)")
+	rolloutStartCmd.Flags().Float64Var(&rolloutMaxFailureRate, "max-failure-rate", 0, "halt when the release's failed install rate exceeds this percentage (0 disables)")
+	rolloutStartCmd.Flags().StringVar(&rolloutHealthURL, "health-url", "", "halt unless a GET of this URL returns 2xx before each step")
+	rolloutStartCmd.Flags().BoolVar(&rolloutDetach, "detach", false, "apply the first step and exit; continue with 'rollout run'")
+	_ = rolloutStartCmd.MarkFlagRequired("steps")
+	rolloutRunCmd.Flags().DurationVar(&rolloutRunLoop, "loop", 0, "keep running and check for due steps on this interval (e.g. 1m)")
+
+	rolloutStartCmd.ValidArgsFunction = cmd.CompleteDeploymentArg
+	_ = rolloutStartCmd.RegisterFlagCompletionFunc("label", cmd.CompleteLabels(""))
+
+	rolloutCmd.AddCommand(rolloutStartCmd, rolloutStatusCmd, rolloutAbortCmd, rolloutRunCmd)
+	cmd.RootCmd.AddCommand(rolloutCmd)
+}
+
+// followRollout waits for and applies each step of r until it stops running
+// or ctx is canceled. The stored rollout is re-read before each step, so an
+// abort from another process is honored.
+func followRollout(ctx context.Context, client rollout.Client, r *rollout.Rollout) (*rollout.Rollout, error) {
+	for r.Status == rollout.StatusRunning {
+		cmd.Out.Info("Next step %d%% at %s, press Ctrl-C to stop following", r.Next(), r.NextAt.Local().Format(time.DateTime))
+
+		timer := time.NewTimer(time.Until(r.NextAt))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return r, nil
+		case <-timer.C:
+		}
+
+		next, err := advanceRollout(ctx, client, r.ID)
+		for err != nil {
+			if ctx.Err() != nil {
+				return r, nil
+			}
+			cmd.Out.Warning("%v; retrying in %s", err, rolloutRetryDelay)
+			select {
+			case <-ctx.Done():
+				return r, nil
+			case <-time.After(rolloutRetryDelay):
+			}
+			next, err = advanceRollout(ctx, client, r.ID)
+		}
+		r = next
+	}
+	return r, nil
+}
+
+// advanceRollout applies the next step of the stored rollout if it is due,
+// saves the result, and reports it.
+func advanceRollout(ctx context.Context, client rollout.Client, id string) (*rollout.Rollout, error) {
+	out := cmd.Out
+
+	r, err := rollout.Get(id)
+	if err != nil {
+		return nil, err
+	}
+
+	event, err := rollout.Advance(ctx, client, r, time.Now().UTC(), rollout.CheckHealth(cmd.HTTPClient()))
+	if err != nil {
+		return nil, fmt.Errorf("rollout %s (%s): %w", r.ID, r.Label, err)
+	}
+	if event == rollout.EventNone {
+		return r, nil
+	}
+	if err := rollout.Update(r); err != nil {
+		return nil, err
+	}
+
+	switch event {
+	case rollout.EventStepped:
+		out.Success("Rollout %s: release %s now at %d%%", r.ID, r.Label, r.Current())
+	case rollout.EventCompleted:
+		out.Success("Rollout %s complete: release %s at %d%%", r.ID, r.Label, r.Current())
+	case rollout.EventHalted:
+		out.Warning("rollout %s halted at %d%%: %s", r.ID, r.Current(), r.Reason)
+	}
+	return r, nil
+}
+
+// runDueRollouts applies the due step of every running rollout. Failed steps
+// are retried on the next run.
+func runDueRollouts(ctx context.Context, token string) error {
+	out := cmd.Out
+
+	rollouts, err := rollout.List()
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	var errs []error
+	due := 0
+	for _, r := range rollouts {
+		if r.Status != rollout.StatusRunning || now.Before(r.NextAt) {
+			continue
+		}
+		due++
+
+		client := cmd.NewClient(storedAPIURL(r.APIURL, r.ServerURL, out), token)
+		if _, err := advanceRollout(ctx, client, r.ID); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	if due == 0 {
+		out.Info("No rollout steps due.")
+	}
+	return errors.Join(errs...)
+}
+
+func formatSteps(steps []int) string {
+	parts := make([]string, len(steps))
+	for i, s := range steps {
+		parts[i] = strconv.Itoa(s) + "%"
+	}
+	return strings.Join(parts, ", ")
+}
@@ -0,0 +1,307 @@
+package release
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/bitrise-io/bitrise-plugins-codepush-cli/cmd"
+	"github.com/bitrise-io/bitrise-plugins-codepush-cli/internal/cmdutil"
+	"github.com/bitrise-io/bitrise-plugins-codepush-cli/internal/codepush"
+	"github.com/bitrise-io/bitrise-plugins-codepush-cli/internal/output"
+	"github.com/bitrise-io/bitrise-plugins-codepush-cli/internal/schedule"
+)
+
+var scheduleLoop time.Duration
+
+var scheduleCmd = &cobra.Command{
+	Use:   "schedule",
+	Short: "Manage scheduled release activations",
+	Long: `Manage releases scheduled for activation with push or promote --activate-at
+(or --release-at).
+
+Such releases are created disabled and recorded in a schedule file in the
+user config directory. 'schedule run' enables every release whose activation
+time has passed; run it from cron or with --loop on a machine that keeps the
+schedule file, since CI agents usually do not. Alternatively, 'schedule
+workflow' generates a Bitrise workflow that enables one release, to run as a
+scheduled build; on Bitrise it is exported to the deploy directory when the
+activation is scheduled.`,
+	GroupID: cmd.GroupRelease,
+}
+
+var scheduleListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List pending activations",
+	Args:  cobra.NoArgs,
+	RunE: func(c *cobra.Command, args []string) error {
+		out := cmd.Out
+
+		activations, err := schedule.List()
+		if err != nil {
+			return err
+		}
+
+		if cmd.JSONOutput {
+			if activations == nil {
+				activations = []schedule.Activation{}
+			}
+			return cmdutil.OutputResult(activations)
+		}
+
+		if len(activations) == 0 {
+			out.Info("No scheduled activations.")
+			return nil
+		}
+
+		rows := make([][]string, len(activations))
+		for i, a := range activations {
+			deployment := a.Deployment
+			if deployment == "" {
+				deployment = a.DeploymentID
+			}
+			label := a.Label
+			if label == "" {
+				label = a.UpdateID
+			}
+			rows[i] = []string{a.ID, deployment, label, a.ActivateAt.Local().Format(time.DateTime)}
+		}
+		out.Table([]string{"ID", "DEPLOYMENT", "RELEASE", "ACTIVATE AT"}, rows)
+		return nil
+	},
+}
+
+var scheduleCancelCmd = &cobra.Command{
+	Use:   "cancel <id>",
+	Short: "Cancel a pending activation (the release stays disabled)",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(c *cobra.Command, args []string) error {
+		if err := schedule.Remove(args[0]); err != nil {
+			return err
+		}
+		cmd.Out.Success("Canceled activation %s; the release stays disabled", args[0])
+		return nil
+	},
+}
+
+var scheduleRunCmd = &cobra.Command{
+	Use:   "run",
+	Short: "Enable releases whose activation time has passed",
+	Args:  cobra.NoArgs,
+	RunE: func(c *cobra.Command, args []string) error {
+		out := cmd.Out
+
+		if scheduleLoop < 0 {
+			return fmt.Errorf("loop interval must be positive, got %s", scheduleLoop)
+		}
+
+		token := cmdutil.ResolveToken(c.Context(), out, cmd.Relogin)
+		if token == "" {
+			return fmt.Errorf("%w: set BITRISE_API_TOKEN or run 'codepush auth login'", codepush.ErrMissingToken)
+		}
+
+		if scheduleLoop == 0 {
+			return runDueActivations(c.Context(), token)
+		}
+
+		ctx, stop := signal.NotifyContext(c.Context(), os.Interrupt)
+		defer stop()
+
+		out.Info("Checking scheduled activations every %s, press Ctrl-C to stop", scheduleLoop)
+		ticker := time.NewTicker(scheduleLoop)
+		defer ticker.Stop()
+		for {
+			if err := runDueActivations(ctx, token); err != nil {
+				if ctx.Err() != nil {
+					return nil
+				}
+				out.Warning("%v", err)
+			}
+
+			select {
+			case <-ctx.Done():
+				return nil
+			case <-ticker.C:
+			}
+		}
+	},
+}
+
+var scheduleWorkflowCmd = &cobra.Command{
+	Use:   "workflow <id>",
+	Short: "Print a Bitrise workflow that enables a scheduled release",
+	Long: `Print a bitrise.yml workflow that enables the release of a pending
+activation. Add it to bitrise.yml and start it from a Bitrise scheduled build
+at the activation time; unlike 'schedule run', it does not need the schedule
+file. The workflow fails without enabling the release if it runs early.
+
+Releases pushed with --no-wait get their label while the server processes
+them; the label is then looked up, which needs an API token.`,
+	Example: `  codepush schedule workflow 3f2a9c1d > activation.yml`,
+	Args:    cobra.ExactArgs(1),
+	RunE: func(c *cobra.Command, args []string) error {
+		out := cmd.Out
+
+		a, err := schedule.Get(args[0])
+		if err != nil {
+			return err
+		}
+
+		if a.Label == "" {
+			token := cmdutil.ResolveToken(c.Context(), out, cmd.Relogin)
+			if token == "" {
+				return fmt.Errorf("%w: set BITRISE_API_TOKEN or run 'codepush auth login' to look up the release label", codepush.ErrMissingToken)
+			}
+			client := cmd.NewClient(storedAPIURL(a.APIURL, a.ServerURL, out), token)
+			u, err := client.GetUpdate(c.Context(), a.AppID, a.DeploymentID, a.UpdateID)
+			if err != nil {
+				return fmt.Errorf("getting update: %w", err)
+			}
+			a.Label = u.Label
+		}
+
+		workflow, err := schedule.BitriseWorkflow(*a)
+		if err != nil {
+			return fmt.Errorf("%s: %w", describeActivation(*a), err)
+		}
+		_, err = fmt.Fprint(os.Stdout, workflow)
+		return err
+	},
+}
+
+func init() {
+	scheduleRunCmd.Flags().DurationVar(&scheduleLoop, "loop", 0, "keep running and check for due activations on this interval (e.g. 1m)")
+	scheduleCmd.AddCommand(scheduleListCmd, scheduleCancelCmd, scheduleRunCmd, scheduleWorkflowCmd)
+	cmd.RootCmd.AddCommand(scheduleCmd)
+}
+
+// runDueActivations enables every due release and removes it from the
+// schedule. Failed activations stay scheduled and are retried on the next run.
+func runDueActivations(ctx context.Context, token string) error {
+	out := cmd.Out
+
+	activations, err := schedule.List()
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	var errs []error
+	ran := 0
+	for _, a := range activations {
+		if !a.Due(now) {
+			continue
+		}
+		ran++
+
+		client := cmd.NewClient(storedAPIURL(a.APIURL, a.ServerURL, out), token)
+
+		enabled := false
+		if _, err := client.PatchUpdate(ctx, a.AppID, a.DeploymentID, a.UpdateID, codepush.PatchRequest{Disabled: &enabled}); err != nil {
+			errs = append(errs, fmt.Errorf("activating %s (%s): %w", a.ID, describeActivation(a), err))
+			continue
+		}
+		if err := schedule.Remove(a.ID); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		out.Success("Activated %s", describeActivation(a))
+	}
+
+	if ran == 0 {
+		out.Info("No activations due.")
+	}
+	return errors.Join(errs...)
+}
+
+// scheduleActivation records that a release created disabled by push or
+// promote should be enabled at activation.ActivateAt. The API URL of the
+// run is recorded with it.
+func scheduleActivation(activation schedule.Activation) error {
+	activation.APIURL = cmdutil.ResolveAPIURL(cmd.APIURL, cmd.ServerURL, cmd.Out)
+	a, err := schedule.Add(activation)
+	if err != nil {
+		return fmt.Errorf("release was created disabled but scheduling its activation failed: %w", err)
+	}
+	cmd.Out.Info("Release is disabled until %s (activation %s); run 'codepush schedule run' at or after that time to enable it",
+		a.ActivateAt.Local().Format(time.DateTime), a.ID)
+	if cmdutil.CI() == cmdutil.CIBitrise {
+		exportActivationWorkflow(*a, cmd.Out)
+	}
+	return nil
+}
+
+// exportActivationWorkflow writes the Bitrise workflow that enables a's
+// release to the deploy directory, since the schedule file does not outlive
+// the build.
+func exportActivationWorkflow(a schedule.Activation, out *output.Writer) {
+	workflow, err := schedule.BitriseWorkflow(a)
+	if err != nil {
+		out.Warning("activation workflow not exported: %v; generate it later with 'codepush schedule workflow %s'", err, a.ID)
+		return
+	}
+	if path := cmdutil.ExportDeployFile("codepush-activation-"+a.ID+".yml", []byte(workflow), out); path != "" {
+		out.Info("Activation workflow exported to: %s; add it to bitrise.yml and schedule a build of %s at the activation time", path, schedule.WorkflowID(a))
+	}
+}
+
+// storedAPIURL returns the API URL recorded with a scheduled activation or
+// rollout. Records from older versions only carry the server URL; records
+// with neither use the current configuration.
+func storedAPIURL(apiURL, serverURL string, out *output.Writer) string {
+	switch {
+	case apiURL != "":
+		return apiURL
+	case serverURL != "":
+		return cmdutil.APIURL(serverURL)
+	default:
+		return cmdutil.ResolveAPIURL(cmd.APIURL, cmd.ServerURL, out)
+	}
+}
+
+// parseActivateAt validates the --activate-at or --release-at flag of c. An
+// empty value returns the zero time.
+func parseActivateAt(c *cobra.Command, value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	t, err := schedule.ParseActivateAt(value, time.Now())
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%s: %w", activateAtFlag(c), err)
+	}
+	return t, nil
+}
+
+// activateAtFlag returns the name of the flag that scheduled the activation
+// on c: --release-at, or its equivalent --activate-at.
+func activateAtFlag(c *cobra.Command) string {
+	if c.Flags().Changed("release-at") {
+		return "--release-at"
+	}
+	return "--activate-at"
+}
+
+// addActivateAtFlags adds --activate-at and its alias --release-at to c,
+// both setting p.
+func addActivateAtFlags(c *cobra.Command, p *string, usage string) {
+	c.Flags().StringVar(p, "activate-at", "", usage+" (e.g. 2024-07-01T09:00Z)")
+	c.Flags().StringVar(p, "release-at", "", "same as --activate-at")
+	c.MarkFlagsMutuallyExclusive("activate-at", "release-at")
+}
+
+func describeActivation(a schedule.Activation) string {
+	release := a.Label
+	if release == "" {
+		release = a.UpdateID
+	}
+	deployment := a.Deployment
+	if deployment == "" {
+		deployment = a.DeploymentID
+	}
+	return fmt.Sprintf("release %s in %s", release, deployment)
+}
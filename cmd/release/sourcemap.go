@@ -0,0 +1,138 @@
+package release
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"strings"
+
+	"github.com/bitrise-io/bitrise-plugins-codepush-cli/internal/bitrise"
+	"github.com/bitrise-io/bitrise-plugins-codepush-cli/internal/bundler"
+	"github.com/bitrise-io/bitrise-plugins-codepush-cli/internal/codepush"
+	"github.com/bitrise-io/bitrise-plugins-codepush-cli/internal/config"
+	"github.com/bitrise-io/bitrise-plugins-codepush-cli/internal/output"
+	"github.com/bitrise-io/bitrise-plugins-codepush-cli/internal/sourcemaps"
+)
+
+// enforceSourcemapPolicy fails the push when .codepush.json marks the target
+// deployment as production-like and no sourcemap can be archived for it.
+// sourcemapPath is the sourcemap produced by push --bundle; when empty, the
+// bundle directory is searched for one.
+func enforceSourcemapPolicy(ctx context.Context, client codepush.Client, appID, deploymentID, sourcemapPath, bundlePath string, out *output.Writer) error {
+	cfg, err := config.Load()
+	if err != nil {
+		return err
+	}
+	if cfg == nil || cfg.SourcemapPolicy == nil {
+		return nil
+	}
+
+	dep, err := client.GetDeployment(ctx, appID, deploymentID)
+	if err != nil {
+		return fmt.Errorf("checking sourcemap policy: %w", err)
+	}
+	if !cfg.RequiresSourcemap(dep.Name) {
+		return nil
+	}
+	if pushSkipSourcemapPolicy {
+		out.Warning("skipping the sourcemap policy for deployment %s (--no-sourcemap-policy-check)", dep.Name)
+		return nil
+	}
+
+	if sourcemapPath == "" {
+		sourcemapPath = findSourcemap(bundlePath)
+	}
+	return archiveSourcemap(dep.Name, sourcemapPath, out)
+}
+
+// archiveSourcemap copies the sourcemap into the Bitrise deploy directory,
+// returning an error that explains how to satisfy the policy if it cannot.
+func archiveSourcemap(deployment, sourcemapPath string, out *output.Writer) error {
+	if sourcemapPath == "" {
+		return fmt.Errorf("deployment %s requires a sourcemap (sourcemap_policy in %s), but none was found: bundle with --sourcemap, or pass --no-sourcemap-policy-check in an emergency",
+			deployment, config.FileName)
+	}
+	if bitrise.GetBuildMetadata().DeployDir == "" {
+		return fmt.Errorf("deployment %s requires the sourcemap to be archived, but BITRISE_DEPLOY_DIR is not set: push from a Bitrise build, or pass --no-sourcemap-policy-check in an emergency",
+			deployment)
+	}
+
+	path, err := bitrise.CopyToDeployDir(sourcemapPath, filepath.Base(sourcemapPath))
+	if err != nil {
+		return fmt.Errorf("archiving sourcemap: %w", err)
+	}
+	out.Info("Sourcemap archived to: %s", path)
+	return nil
+}
+
+// newSourcemapUploader returns the uploader selected by --sourcemap-provider,
+// or nil when none is. It checks the upload can succeed before bundling.
+// labelKnown reports whether the bundle will be pushed, which determines the
+// release label.
+func newSourcemapUploader(labelKnown bool, out *output.Writer) (sourcemaps.Uploader, error) {
+	if bundleUploadSourcemaps == "" {
+		return nil, nil //nolint:nilnil // no upload requested
+	}
+	if !bundleSourcemap && bundleSourcemapOutput == "" {
+		return nil, errors.New("--sourcemap-provider requires source maps: remove --sourcemap=false")
+	}
+
+	uploader, err := sourcemaps.NewUploader(bundleUploadSourcemaps, bundleSourcemapOpts, &bundler.DefaultExecutor{}, out)
+	if err != nil {
+		return nil, err
+	}
+	projectDir, err := filepath.Abs(bundleProjectDir)
+	if err != nil {
+		return nil, fmt.Errorf("resolving project directory: %w", err)
+	}
+	if err := uploader.Check(projectDir, labelKnown); err != nil {
+		return nil, err
+	}
+	return uploader, nil
+}
+
+// uploadSourcemaps uploads the source map of a bundle built from the shared
+// bundle flags.
+func uploadSourcemaps(ctx context.Context, uploader sourcemaps.Uploader, result *bundler.BundleResult, release sourcemaps.Release) error {
+	projectDir, err := filepath.Abs(bundleProjectDir)
+	if err != nil {
+		return fmt.Errorf("resolving project directory: %w", err)
+	}
+	return uploader.Upload(ctx, sourcemaps.Artifact{
+		ProjectDir:    projectDir,
+		Platform:      string(result.Platform),
+		BundlePath:    result.BundlePath,
+		SourcemapPath: result.SourcemapPath,
+	}, release)
+}
+
+// uploadPushedSourcemaps uploads the source map of a bundle pushed by
+// push --bundle, associating it with the new release's label.
+func uploadPushedSourcemaps(ctx context.Context, client codepush.Client, uploader sourcemaps.Uploader, bundled *bundler.BundleResult, pushed *codepush.PushResult) error {
+	update, err := client.GetUpdate(ctx, pushed.AppID, pushed.DeploymentID, pushed.UpdateID)
+	if err != nil {
+		return fmt.Errorf("getting release label: %w", err)
+	}
+	return uploadSourcemaps(ctx, uploader, bundled, sourcemaps.Release{AppVersion: pushed.AppVersion, Label: update.Label})
+}
+
+// errFound stops findSourcemap's walk at the first match.
+var errFound = errors.New("found")
+
+// findSourcemap returns the first .map file in dir, or "" if there is none.
+func findSourcemap(dir string) string {
+	var found string
+	_ = filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() && strings.HasSuffix(d.Name(), ".map") {
+			found = path
+			return errFound
+		}
+		return nil
+	})
+	return found
+}
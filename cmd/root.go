@@ -1,13 +1,57 @@
 package cmd
 
 import (
+	"context"
+	"fmt"
+	"math"
+	"net/http"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
 	"github.com/spf13/cobra"
 
+	"github.com/bitrise-io/bitrise-plugins-codepush-cli/internal/cmdutil"
+	"github.com/bitrise-io/bitrise-plugins-codepush-cli/internal/codepush"
 	"github.com/bitrise-io/bitrise-plugins-codepush-cli/internal/config"
+	"github.com/bitrise-io/bitrise-plugins-codepush-cli/internal/envfile"
 	"github.com/bitrise-io/bitrise-plugins-codepush-cli/internal/output"
+	"github.com/bitrise-io/bitrise-plugins-codepush-cli/internal/redact"
+	"github.com/bitrise-io/bitrise-plugins-codepush-cli/internal/transport"
+	"github.com/bitrise-io/bitrise-plugins-codepush-cli/internal/workspace"
+)
+
+var (
+	outputFormat       string
+	progressStyle      string
+	retries            int
+	rateLimit          float64
+	caCert             string
+	insecureSkipVerify bool
+	verbose            bool
+	keepArtifacts      bool
+	noBitriseExport    bool
+	ciService          string
+	quiet              bool
+	logLevel           string
+	envFile            string
 )
 
-var progressStyle string
+// RetriesEnvKey is the environment variable setting --retries.
+const RetriesEnvKey = "CODEPUSH_HTTP_RETRIES"
+
+// RateLimitEnvKey is the environment variable setting --rate-limit.
+const RateLimitEnvKey = "CODEPUSH_RATE_LIMIT"
+
+// DebugEnvKey is the environment variable enabling --verbose, e.g.
+// CODEPUSH_DEBUG=1.
+const DebugEnvKey = "CODEPUSH_DEBUG"
+
+// LogLevelEnvKey is the environment variable setting --log-level.
+const LogLevelEnvKey = "CODEPUSH_LOG_LEVEL"
 
 // GroupID is a typed alias for command group identifiers.
 type GroupID = string
@@ -27,13 +71,114 @@ var Out *output.Writer
 // Version is the CLI version string. Set by main() before Execute().
 var Version string
 
-// Global flag values, bound to RootCmd's persistent flags.
+// Relogin runs the login flow again when the stored token has expired or
+// was rejected. Set by main() before Execute(); nil disables the offer.
+var Relogin cmdutil.ReloginFunc
+
+// FirstRun runs the guided first-run setup when no credentials are
+// configured. Set by main() before Execute(); nil skips it.
+var FirstRun cmdutil.FirstRunFunc
+
+// Workspace holds the temporary artifacts of the run. The root pre-run
+// hook creates it, and Execute removes it when the command returns.
+var Workspace *workspace.Workspace
+
+// httpClient and clientOptions configure every request of a run. The root
+// pre-run hook sets them from the global flags.
 var (
-	AppID      string
-	JSONOutput bool
-	ServerURL  string
+	httpClient    *http.Client
+	clientOptions []codepush.ClientOption
 )
 
+// NewClient returns an API client for apiURL, configured by the global
+// flags.
+func NewClient(apiURL, token string) *codepush.HTTPClient {
+	return codepush.NewHTTPClient(apiURL, token, Version, clientOptions...)
+}
+
+// HTTPClient returns the HTTP client for requests outside the API client,
+// such as logins and health checks, configured by the proxy and TLS flags.
+func HTTPClient() *http.Client {
+	if httpClient == nil {
+		return transport.Client()
+	}
+	return httpClient
+}
+
+// configureClients builds the HTTP client and API client options of the
+// run from the retry, rate limit, and TLS flags.
+func configureClients(c *cobra.Command) error {
+	n, err := resolveRetries(c)
+	if err != nil {
+		return err
+	}
+	rps, err := resolveRateLimit(c)
+	if err != nil {
+		return err
+	}
+
+	if insecureSkipVerify {
+		Out.Warning("TLS certificate verification is disabled (--insecure-skip-verify): API traffic and your token can be intercepted. Use only in lab environments.")
+	}
+	transportOpts := transport.Options{
+		CACertFile:         cmdutil.ResolveFlag(caCert, transport.CABundleEnvKey),
+		InsecureSkipVerify: insecureSkipVerify,
+	}
+	if Out.DebugEnabled() {
+		transportOpts.Debug = Out.Debug
+	}
+	hc, err := transport.New(transportOpts)
+	if err != nil {
+		return err
+	}
+
+	httpClient = hc
+	// One limiter for the run, so clients of commands that fan out over
+	// deployments or apps share the request budget.
+	clientOptions = []codepush.ClientOption{
+		codepush.WithHTTPClient(hc),
+		codepush.WithRetry(codepush.APIRetries(n)),
+		codepush.WithRateLimiter(codepush.NewRateLimiter(rps)),
+	}
+	return nil
+}
+
+// Global flag values, bound to RootCmd's persistent flags. JSONOutput is also
+// set by a machine-readable --output format; commands then write their result
+// with cmdutil.OutputResult.
+var (
+	AppID string
+	// AppIDs holds every --app-id given, in order, for commands that push to
+	// several apps. AppID is the last of them.
+	AppIDs       []string
+	JSONOutput   bool
+	ServerURL    string
+	APIURL       string
+	NoOnboarding bool
+)
+
+// AnnotationMultiApp marks a command that accepts --app-id more than once.
+// Other commands fail when it is repeated.
+const AnnotationMultiApp = "codepush_multi_app"
+
+// appIDValue is the --app-id flag. Like a string flag it sets AppID to the
+// last value, and it keeps every value in AppIDs.
+type appIDValue struct{}
+
+func (appIDValue) String() string { return AppID }
+
+func (appIDValue) Set(v string) error {
+	AppID = v
+	AppIDs = append(AppIDs, v)
+	return nil
+}
+
+func (appIDValue) Type() string { return "string" }
+
+// running is set once the root pre-run hook has succeeded, so Execute can
+// tell usage errors from errors returned by the command itself.
+var running bool
+
 // RootCmd is the top-level cobra command.
 var RootCmd = &cobra.Command{
 	Use:   "codepush",
@@ -45,6 +190,29 @@ Use as a standalone CLI or as a Bitrise plugin (bitrise :codepush).`,
 	SilenceUsage:  true,
 	SilenceErrors: true,
 	PersistentPreRunE: func(c *cobra.Command, _ []string) error {
+		// First, so the file's variables apply to every setting below.
+		envKeys, err := loadEnvFile(envFile)
+		if err != nil {
+			return err
+		}
+		if err := cmdutil.SetCI(ciService); err != nil {
+			return err
+		}
+		if err := applyOutputFormat(); err != nil {
+			return err
+		}
+		if len(AppIDs) > 1 && c.Annotations[AnnotationMultiApp] == "" {
+			return fmt.Errorf("--app-id can be given only once for %s", c.CommandPath())
+		}
+		level, err := resolveLogLevel(c)
+		if err != nil {
+			return err
+		}
+		Out.SetLevel(level)
+		if envFile != "" {
+			Out.Debug("loaded env file", "path", envFile, "set", strings.Join(envKeys, ","))
+		}
+
 		style := progressStyle
 		if !c.Root().PersistentFlags().Changed("progress-style") {
 			if cfg, err := config.Load(); err != nil {
@@ -58,13 +226,183 @@ Use as a standalone CLI or as a Bitrise plugin (bitrise :codepush).`,
 			}
 		}
 		Out.SetBarStyle(output.ParseBarStyle(style))
+
+		if err := configureClients(c); err != nil {
+			return err
+		}
+
+		cmdutil.SetBitriseExport(!noBitriseExport)
+		Workspace = workspace.New(workspace.Options{Keep: keepArtifacts})
+		if removed, err := Workspace.Collect(workspace.DefaultMaxAge, time.Now()); err != nil {
+			Out.Debug("collecting stale workspaces failed", "error", err)
+		} else if len(removed) > 0 {
+			Out.Debug("removed stale workspaces", "count", len(removed))
+		}
+		running = true
 		return nil
 	},
 }
 
+// Execute runs RootCmd. Errors returned before the command starts running,
+// such as unknown commands or flags, wrong argument counts, and invalid
+// global flags, are marked as validation errors.
+//
+// The command runs with a context that is canceled on SIGINT or SIGTERM, so
+// Ctrl-C stops requests, polling, and bundler commands, and lets deferred
+// cleanup run. A second Ctrl-C quits immediately. The workspace of the run
+// is removed when the command returns, unless --keep-artifacts is set.
+func Execute() error {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	go func() {
+		<-ctx.Done()
+		stop()
+	}()
+
+	err := RootCmd.ExecuteContext(ctx)
+	if kept, cleanupErr := Workspace.Cleanup(); cleanupErr != nil {
+		Out.Warning("%v", cleanupErr)
+	} else if kept != "" {
+		Out.Info("Kept temporary artifacts in %s", kept)
+	}
+	if err != nil && !running {
+		return codepush.Invalid(err)
+	}
+	return err
+}
+
+// loadEnvFile sets the variables of the --env-file that are not already set
+// in the environment, giving the precedence flag > environment > env file >
+// .codepush.json. Secret-looking values are registered for redaction. It
+// returns the names of the variables it set.
+func loadEnvFile(path string) ([]string, error) {
+	if path == "" {
+		return nil, nil
+	}
+	vars, err := envfile.Read(path)
+	if err != nil {
+		return nil, err
+	}
+	set, err := envfile.Apply(vars)
+	if err != nil {
+		return nil, err
+	}
+	for _, key := range set {
+		redact.RegisterVar(key, os.Getenv(key))
+	}
+	return set, nil
+}
+
+// applyOutputFormat applies --output. Every format except table makes
+// commands write machine-readable results, like --json.
+func applyOutputFormat() error {
+	if err := cmdutil.SetOutputFormat(outputFormat, Out); err != nil {
+		return err
+	}
+	if outputFormat != "" && outputFormat != cmdutil.FormatTable {
+		JSONOutput = true
+	}
+	return nil
+}
+
+// resolveLogLevel returns the level set by --quiet, --verbose, or
+// --log-level, which are mutually exclusive. Without them it uses DebugEnvKey,
+// then LogLevelEnvKey, and defaults to info.
+func resolveLogLevel(c *cobra.Command) (output.Level, error) {
+	flags := c.Root().PersistentFlags()
+	var set []string
+	for _, name := range []string{"quiet", "verbose", "log-level"} {
+		if flags.Changed(name) {
+			set = append(set, "--"+name)
+		}
+	}
+	if len(set) > 1 {
+		return output.LevelInfo, fmt.Errorf("%s cannot be used together", strings.Join(set, " and "))
+	}
+
+	switch {
+	case flags.Changed("quiet") && quiet:
+		return output.LevelError, nil
+	case flags.Changed("verbose") && verbose:
+		return output.LevelDebug, nil
+	case flags.Changed("log-level"):
+		return output.ParseLevel(logLevel)
+	case flags.Changed("quiet"), flags.Changed("verbose"):
+		return output.LevelInfo, nil
+	}
+
+	if v := os.Getenv(DebugEnvKey); v != "" {
+		enabled, err := strconv.ParseBool(v)
+		if err != nil {
+			return output.LevelInfo, fmt.Errorf("invalid %s %q: must be 1, 0, true, or false", DebugEnvKey, v)
+		}
+		if enabled {
+			return output.LevelDebug, nil
+		}
+	}
+	if v := os.Getenv(LogLevelEnvKey); v != "" {
+		level, err := output.ParseLevel(v)
+		if err != nil {
+			return output.LevelInfo, fmt.Errorf("invalid %s: %w", LogLevelEnvKey, err)
+		}
+		return level, nil
+	}
+	return output.LevelInfo, nil
+}
+
+// resolveRetries returns the --retries flag, or RetriesEnvKey when the flag
+// is not set.
+func resolveRetries(c *cobra.Command) (int, error) {
+	n := retries
+	if !c.Root().PersistentFlags().Changed("retries") {
+		if v := os.Getenv(RetriesEnvKey); v != "" {
+			var err error
+			if n, err = strconv.Atoi(v); err != nil {
+				return 0, fmt.Errorf("invalid %s %q: must be a number", RetriesEnvKey, v)
+			}
+		}
+	}
+	if n < 0 {
+		return 0, fmt.Errorf("--retries must not be negative, got %d", n)
+	}
+	return n, nil
+}
+
+// resolveRateLimit returns the --rate-limit flag, or RateLimitEnvKey when
+// the flag is not set.
+func resolveRateLimit(c *cobra.Command) (float64, error) {
+	rps := rateLimit
+	if !c.Root().PersistentFlags().Changed("rate-limit") {
+		if v := os.Getenv(RateLimitEnvKey); v != "" {
+			var err error
+			if rps, err = strconv.ParseFloat(v, 64); err != nil {
+				return 0, fmt.Errorf("invalid %s %q: must be a number", RateLimitEnvKey, v)
+			}
+		}
+	}
+	if rps < 0 || math.IsNaN(rps) || math.IsInf(rps, 0) {
+		return 0, fmt.Errorf("--rate-limit must be a non-negative number, got %v", rps)
+	}
+	return rps, nil
+}
+
 func init() {
-	RootCmd.PersistentFlags().StringVar(&AppID, "app-id", "", "release management app UUID (env: CODEPUSH_APP_ID)")
+	RootCmd.PersistentFlags().Var(appIDValue{}, "app-id", "release management app UUID (env: CODEPUSH_APP_ID)")
 	RootCmd.PersistentFlags().BoolVarP(&JSONOutput, "json", "j", false, "output results as JSON to stdout")
+	RootCmd.PersistentFlags().StringVar(&outputFormat, "output", "", "output format on stdout: table, json, ndjson (one JSON object per line as records become available), yaml, or csv")
 	RootCmd.PersistentFlags().StringVar(&ServerURL, "server-url", "", "API server base URL (env: CODEPUSH_SERVER_URL)")
+	RootCmd.PersistentFlags().StringVar(&APIURL, "api-url", "", "CodePush API base URL, overriding the one derived from --server-url (env: CODEPUSH_API_URL)")
+	RootCmd.PersistentFlags().BoolVar(&NoOnboarding, "no-onboarding", false, "never offer the guided first-run setup")
 	RootCmd.PersistentFlags().StringVar(&progressStyle, "progress-style", "bar", "progress indicator style: bar, spinner, counter")
+	RootCmd.PersistentFlags().StringVar(&caCert, "ca-cert", "", "PEM file with extra root CAs to trust, e.g. for a TLS-intercepting proxy (env: "+transport.CABundleEnvKey+")")
+	RootCmd.PersistentFlags().BoolVar(&insecureSkipVerify, "insecure-skip-verify", false, "disable TLS certificate verification (lab environments only)")
+	RootCmd.PersistentFlags().BoolVar(&verbose, "verbose", false, "log HTTP requests, bundler commands, and push phase timings to stderr for troubleshooting (env: "+DebugEnvKey+"=1)")
+	RootCmd.PersistentFlags().BoolVarP(&quiet, "quiet", "q", false, "print only results and errors, without progress, info, or warnings (same as --log-level error)")
+	RootCmd.PersistentFlags().StringVar(&logLevel, "log-level", "info", "minimum level of messages on stderr: debug, info, warning, or error; results are always printed (env: "+LogLevelEnvKey+")")
+	RootCmd.PersistentFlags().StringVar(&ciService, "ci", cmdutil.CIAuto, "CI service to export step outputs and summaries to: bitrise, github, none, or auto to detect it from the environment")
+	RootCmd.PersistentFlags().BoolVar(&noBitriseExport, "no-bitrise-export", false, "on Bitrise, do not export step outputs with envman, write summaries to the deploy directory, or annotate the build")
+	RootCmd.PersistentFlags().StringVar(&envFile, "env-file", "", "read environment variables, such as BITRISE_API_TOKEN and CODEPUSH_APP_ID, from a .env file; variables already set in the environment take precedence")
+	RootCmd.PersistentFlags().BoolVar(&keepArtifacts, "keep-artifacts", false, "keep temporary artifacts such as package zips and delta packages after the command, for debugging")
+	RootCmd.PersistentFlags().IntVar(&retries, "retries", codepush.DefaultAPIRetryConfig.MaxAttempts-1, "times to retry API requests that fail with a transient error (env: "+RetriesEnvKey+")")
+	RootCmd.PersistentFlags().Float64Var(&rateLimit, "rate-limit", codepush.DefaultAPIRateLimit, "maximum API requests per second, shared by all requests of the command; 0 disables the limit (env: "+RateLimitEnvKey+")")
 }
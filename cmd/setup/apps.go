@@ -0,0 +1,167 @@
+package setup
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	"github.com/spf13/cobra"
+
+	"github.com/bitrise-io/bitrise-plugins-codepush-cli/cmd"
+	"github.com/bitrise-io/bitrise-plugins-codepush-cli/internal/appcatalog"
+	"github.com/bitrise-io/bitrise-plugins-codepush-cli/internal/cmdutil"
+	"github.com/bitrise-io/bitrise-plugins-codepush-cli/internal/codepush"
+	"github.com/bitrise-io/bitrise-plugins-codepush-cli/internal/output"
+)
+
+var (
+	appsWorkspace string
+	appsRefresh   bool
+	appsSearch    string
+)
+
+var appsCmd = &cobra.Command{
+	Use:   "apps",
+	Short: "List connected apps",
+	Long: `List and inspect the Release Management connected apps your token can
+access, to find the app UUID to pass as --app-id.`,
+	GroupID: cmd.GroupSetup,
+}
+
+var appsListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List connected apps",
+	Long: `List the connected apps your token can access, optionally only those of one
+Bitrise workspace (organization) and those matching a search.
+
+The list is cached locally for an hour per token and workspace, so
+repeated lookups in accounts with many apps are instant; --refresh fetches
+it again. Set CODEPUSH_APPS_CACHE_TTL to change the cache lifetime (e.g.
+10m), or to 0 to disable the cache.
+
+--search matches app names by their characters in order, ignoring case,
+so "shpstg" finds "Shop Staging"; it also matches the start of an app ID.
+The best matches are listed first.`,
+	Example: `  codepush apps list
+  codepush apps list --workspace acme-mobile
+  codepush apps list --search shop --refresh`,
+	Args: cobra.NoArgs,
+	RunE: func(c *cobra.Command, args []string) error {
+		out := cmd.Out
+
+		client, err := newAppsClient(c.Context(), out)
+		if err != nil {
+			return err
+		}
+
+		catalog := cmdutil.NewAppCatalog(client, cmdutil.ResolveFlag(appsWorkspace, cmdutil.WorkspaceEnvKey), out)
+		catalog.Refresh = appsRefresh
+		apps, err := catalog.ListApps(c.Context())
+		if err != nil {
+			return fmt.Errorf("listing apps: %w", err)
+		}
+		apps = appcatalog.Search(apps, appsSearch)
+
+		if cmd.JSONOutput {
+			return cmdutil.OutputResult(apps)
+		}
+
+		if len(apps) == 0 {
+			if appsSearch != "" {
+				out.Info("No connected apps match %q.", appsSearch)
+			} else {
+				out.Info("No connected apps found.")
+			}
+			return nil
+		}
+
+		headers := []string{"NAME", "PLATFORM", "ID"}
+		withWorkspace := catalog.Workspace == "" && slices.ContainsFunc(apps, func(a codepush.App) bool { return a.WorkspaceSlug != "" })
+		if withWorkspace {
+			headers = append(headers, "WORKSPACE")
+		}
+		rows := make([][]string, len(apps))
+		for i, a := range apps {
+			rows[i] = []string{a.Name, a.Platform, a.ID}
+			if withWorkspace {
+				rows[i] = append(rows[i], a.WorkspaceSlug)
+			}
+		}
+		out.Table(headers, rows)
+
+		return nil
+	},
+}
+
+var appsInfoCmd = &cobra.Command{
+	Use:   "info [app-id]",
+	Short: "Show connected app details",
+	Long: `Show details of a connected app. Defaults to the configured app ID, or
+lets you pick one of your apps in an interactive terminal, from the apps
+of --workspace when it is given. With many apps, you can search them by
+name before picking one.`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(c *cobra.Command, args []string) error {
+		out := cmd.Out
+
+		client, err := newAppsClient(c.Context(), out)
+		if err != nil {
+			return err
+		}
+
+		appID := cmd.AppID
+		if len(args) > 0 {
+			appID = args[0]
+		}
+		catalog := cmdutil.NewAppCatalog(client, cmdutil.ResolveFlag(appsWorkspace, cmdutil.WorkspaceEnvKey), out)
+		appID, err = cmdutil.SelectAppInteractive(c.Context(), catalog, appID, out)
+		if err != nil {
+			return err
+		}
+
+		app, err := client.GetApp(c.Context(), appID)
+		if err != nil {
+			return err
+		}
+
+		if cmd.JSONOutput {
+			return cmdutil.OutputResult(app)
+		}
+
+		out.Step("App: %s", cmdutil.AppLabel(*app))
+		pairs := []output.KeyValue{
+			{Key: "ID", Value: app.ID},
+			{Key: "Name", Value: app.Name},
+			{Key: "Platform", Value: app.Platform},
+		}
+		if app.StoreAppID != "" {
+			pairs = append(pairs, output.KeyValue{Key: "Store app ID", Value: app.StoreAppID})
+		}
+		if app.WorkspaceSlug != "" {
+			pairs = append(pairs, output.KeyValue{Key: "Workspace", Value: app.WorkspaceSlug})
+		}
+		out.Result(pairs)
+
+		return nil
+	},
+}
+
+// newAppsClient returns an API client for the apps commands, which need a
+// token but no app ID.
+func newAppsClient(ctx context.Context, out *output.Writer) (*codepush.HTTPClient, error) {
+	token := cmdutil.ResolveToken(ctx, out, cmd.Relogin)
+	if token == "" {
+		return nil, fmt.Errorf("%w: set BITRISE_API_TOKEN or run 'codepush auth login'", codepush.ErrMissingToken)
+	}
+	return cmd.NewClient(cmdutil.ResolveAPIURL(cmd.APIURL, cmd.ServerURL, out), token), nil
+}
+
+func init() {
+	for _, c := range []*cobra.Command{appsListCmd, appsInfoCmd} {
+		c.Flags().StringVar(&appsWorkspace, "workspace", "", "only apps of this Bitrise workspace slug (env: CODEPUSH_WORKSPACE)")
+	}
+	appsListCmd.Flags().BoolVar(&appsRefresh, "refresh", false, "fetch the app list even when a cached one is fresh")
+	appsListCmd.Flags().StringVar(&appsSearch, "search", "", "only apps whose name matches, best matches first")
+	appsCmd.AddCommand(appsListCmd, appsInfoCmd)
+	cmd.RootCmd.AddCommand(appsCmd)
+}
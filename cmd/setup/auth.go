@@ -1,6 +1,7 @@
 package setup
 
 import (
+	"context"
 	"errors"
 	"fmt"
 
@@ -9,9 +10,16 @@ import (
 	"github.com/bitrise-io/bitrise-plugins-codepush-cli/cmd"
 	"github.com/bitrise-io/bitrise-plugins-codepush-cli/internal/auth"
 	"github.com/bitrise-io/bitrise-plugins-codepush-cli/internal/cmdutil"
+	"github.com/bitrise-io/bitrise-plugins-codepush-cli/internal/codepush"
+	"github.com/bitrise-io/bitrise-plugins-codepush-cli/internal/output"
+	"github.com/bitrise-io/bitrise-plugins-codepush-cli/internal/redact"
 )
 
-var authLoginToken string
+var (
+	authLoginToken  string
+	authLoginWeb    bool
+	authLoginDevice bool
+)
 
 var authCmd = &cobra.Command{
 	Use:     "auth",
@@ -30,60 +38,188 @@ by commands that require authentication (push, rollback).
 
 Generate a personal access token at: ` + auth.TokenGenerationURL + `
 
+Or sign in with --web instead: the Bitrise authorization page opens in a
+browser and the resulting token is stored. On machines without a browser,
+such as SSH sessions, --web falls back to a device code that you enter on
+another device; --device selects that flow directly.
+
+When an app ID is configured (--app-id, CODEPUSH_APP_ID, or .codepush.json),
+login also checks that the token has the ` + codepush.ScopeReleaseWrite + ` scope
+for the app, and warns if it does not.
+
 Token resolution order: --token flag > BITRISE_API_TOKEN env var > stored config.`,
 	RunE: func(c *cobra.Command, args []string) error {
 		out := cmd.Out
-		token := authLoginToken
-		if token == "" {
+		if (authLoginWeb || authLoginDevice) && authLoginToken != "" {
+			return errors.New("--token cannot be combined with --web or --device")
+		}
+
+		login := auth.Config{Token: authLoginToken, Method: auth.MethodToken}
+		if authLoginWeb || authLoginDevice {
+			webConfig, err := webLogin(c.Context(), authLoginDevice, out)
+			if err != nil {
+				return err
+			}
+			login = webConfig
+		} else if login.Token == "" {
 			if !out.IsInteractive() {
 				return errors.New("token is required: set --token or BITRISE_API_TOKEN")
 			}
-			out.Println("")
-			out.Info("Generate a token at: %s", auth.TokenGenerationURL)
-
-			input, err := out.SecureInput("Paste your personal access token", "")
+			input, err := promptToken(out)
 			if err != nil {
-				return fmt.Errorf("reading token: %w", err)
+				return err
 			}
-			token = input
+			login.Token = input
 		}
 
-		if token == "" {
-			return errors.New("token is required: provide --token flag or enter interactively")
-		}
+		return storeLogin(c.Context(), login, out)
+	},
+}
 
-		serverURL := cmdutil.ResolveServerURL(cmd.ServerURL, out)
+// promptToken asks for a personal access token in an interactive terminal.
+func promptToken(out *output.Writer) (string, error) {
+	out.Println("")
+	out.Info("Generate a token at: %s", auth.TokenGenerationURL)
 
-		var userInfo *auth.UserInfo
-		err := out.Indeterminate("Validating token", func() error {
-			var valErr error
-			userInfo, valErr = auth.ValidateToken(token, serverURL)
-			return valErr
+	token, err := out.SecureInput("Paste your personal access token", "")
+	if err != nil {
+		return "", fmt.Errorf("reading token: %w", err)
+	}
+	return token, nil
+}
+
+// webLogin signs in through the browser, or with a device code when
+// device is set or no browser can be opened, and returns the token with
+// the method used and its expiry.
+func webLogin(ctx context.Context, device bool, out *output.Writer) (auth.Config, error) {
+	ep := auth.EndpointsFor(cmdutil.ResolveServerURL(cmd.ServerURL, out))
+
+	if !device {
+		token, err := auth.WebLogin(ctx, cmd.HTTPClient(), ep, cmdutil.OpenBrowser, func(url string) {
+			out.Info("Opening the Bitrise authorization page. If it does not open, visit:\n  %s", url)
+			out.Info("Waiting for browser login...")
 		})
-		if err != nil {
-			return fmt.Errorf("token validation failed: %w\n\n  Generate a new token at: %s", err, auth.TokenGenerationURL)
+		if err == nil {
+			return auth.Config{Token: token.AccessToken, Method: auth.MethodWeb, ExpiresAt: token.ExpiresAt}, nil
 		}
-
-		if err := auth.SaveToken(token); err != nil {
-			return fmt.Errorf("saving token: %w", err)
+		if !errors.Is(err, auth.ErrBrowserUnavailable) {
+			return auth.Config{}, err
 		}
+		out.Warning("%v, signing in with a device code instead", err)
+	}
 
-		if userInfo != nil && userInfo.Username != "" {
-			if userInfo.Email != "" {
-				out.Success("Logged in as %s (%s)", userInfo.Username, userInfo.Email)
-			} else {
-				out.Success("Logged in as %s", userInfo.Username)
-			}
+	token, err := auth.DeviceLogin(ctx, cmd.HTTPClient(), ep, func(dc *auth.DeviceCode) {
+		out.Info("Open %s on any device and enter the code: %s", dc.VerificationURI, dc.UserCode)
+		if dc.VerificationURIComplete != "" {
+			out.Info("Or open: %s", dc.VerificationURIComplete)
 		}
+		out.Info("Waiting for device login...")
+	})
+	if err != nil {
+		return auth.Config{}, err
+	}
+	return auth.Config{Token: token.AccessToken, Method: auth.MethodDevice, ExpiresAt: token.ExpiresAt}, nil
+}
 
-		configPath, err := auth.ConfigFilePath()
-		if err != nil {
-			out.Warning("could not determine config path: %v", err)
+// Relogin runs the login again with the method the stored token was
+// obtained with, and returns the new token. main sets it as cmd.Relogin.
+func Relogin(ctx context.Context, out *output.Writer) (string, error) {
+	login := auth.Config{Method: auth.MethodToken}
+	if stored, err := auth.LoadConfig(); err == nil && stored != nil && stored.Method != "" {
+		login.Method = stored.Method
+	}
+
+	var err error
+	if login.Method == auth.MethodWeb || login.Method == auth.MethodDevice {
+		login, err = webLogin(ctx, login.Method == auth.MethodDevice, out)
+	} else {
+		login.Token, err = promptToken(out)
+	}
+	if err != nil {
+		return "", err
+	}
+	if err := storeLogin(ctx, login, out); err != nil {
+		return "", err
+	}
+	return login.Token, nil
+}
+
+// storeLogin validates the token of login against the server and stores it
+// with its metadata.
+func storeLogin(ctx context.Context, login auth.Config, out *output.Writer) error {
+	token := login.Token
+	if token == "" {
+		return errors.New("token is required: provide --token flag or enter interactively")
+	}
+	redact.Register(token)
+
+	serverURL := cmdutil.ResolveServerURL(cmd.ServerURL, out)
+
+	var userInfo *auth.UserInfo
+	err := out.Indeterminate("Validating token", func() error {
+		var valErr error
+		userInfo, valErr = auth.ValidateToken(token, serverURL, cmd.HTTPClient())
+		return valErr
+	})
+	if err != nil {
+		return fmt.Errorf("token validation failed: %w\n\n  Generate a new token at: %s", err, auth.TokenGenerationURL)
+	}
+
+	if err := auth.SaveConfig(login); err != nil {
+		return fmt.Errorf("saving token: %w", err)
+	}
+
+	if userInfo != nil && userInfo.Username != "" {
+		if userInfo.Email != "" {
+			out.Success("Logged in as %s (%s)", userInfo.Username, userInfo.Email)
 		} else {
-			out.Info("Token saved to: %s", configPath)
+			out.Success("Logged in as %s", userInfo.Username)
 		}
-		return nil
-	},
+	}
+	if !login.ExpiresAt.IsZero() {
+		out.Info("Token expires on %s", login.ExpiresAt.Local().Format("Mon 2006-01-02 15:04 MST"))
+	}
+	reportAppScope(ctx, token, out)
+
+	configPath, err := auth.ConfigFilePath()
+	if err != nil {
+		out.Warning("could not determine config path: %v", err)
+	} else {
+		out.Info("Token saved to: %s", configPath)
+	}
+	return nil
+}
+
+// reportAppScope shows whether token may release to the project's app, when
+// one is configured. A missing scope is only a warning: the token is still
+// stored, since it may be meant for other apps.
+func reportAppScope(ctx context.Context, token string, out *output.Writer) {
+	appID := cmdutil.ResolveAppID(cmd.AppID, out)
+	if appID == "" {
+		return
+	}
+
+	client := cmd.NewClient(cmdutil.ResolveAPIURL(cmd.APIURL, cmd.ServerURL, out), token)
+	access, err := codepush.CheckScope(ctx, client, appID, codepush.ScopeReleaseWrite)
+	var scopeErr *codepush.ScopeError
+	switch {
+	case errors.As(err, &scopeErr):
+		out.Warning("%v: push, promote, and other release commands will fail for it", err)
+		return
+	case err != nil:
+		out.Warning("could not check the token's access to app %s: %v", appID, err)
+		return
+	}
+
+	app := appID
+	if access.App.Name != "" {
+		app = fmt.Sprintf("%s (%s)", access.App.Name, appID)
+	}
+	if _, known := access.HasScope(codepush.ScopeReleaseWrite); known {
+		out.Success("Token has scope %s for app %s", codepush.ScopeReleaseWrite, app)
+	} else {
+		out.Info("Token can access app %s", app)
+	}
 }
 
 var authRevokeCmd = &cobra.Command{
@@ -107,6 +243,8 @@ a --token flag or BITRISE_API_TOKEN environment variable.`,
 
 func init() {
 	authLoginCmd.Flags().StringVarP(&authLoginToken, "token", "t", "", "Bitrise API token")
+	authLoginCmd.Flags().BoolVar(&authLoginWeb, "web", false, "sign in through the browser instead of pasting a token")
+	authLoginCmd.Flags().BoolVar(&authLoginDevice, "device", false, "sign in with a code entered on another device (for machines without a browser)")
 	authCmd.AddCommand(authLoginCmd, authRevokeCmd)
 	cmd.RootCmd.AddCommand(authCmd)
 }
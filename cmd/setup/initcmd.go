@@ -1,62 +1,141 @@
 package setup
 
 import (
+	"context"
 	"fmt"
-	"os"
+	"path/filepath"
+	"strings"
 
 	"github.com/spf13/cobra"
 
 	"github.com/bitrise-io/bitrise-plugins-codepush-cli/cmd"
 	"github.com/bitrise-io/bitrise-plugins-codepush-cli/internal/cmdutil"
+	"github.com/bitrise-io/bitrise-plugins-codepush-cli/internal/codepush"
 	"github.com/bitrise-io/bitrise-plugins-codepush-cli/internal/config"
 	"github.com/bitrise-io/bitrise-plugins-codepush-cli/internal/output"
 )
 
-var initForce bool
+var (
+	initForce      bool
+	initDeployment string
+	initWorkspace  string
+)
+
+// noDefaultDeployment marks the "no default deployment" option in the picker.
+const noDefaultDeployment = "\x00none"
 
 var initCmd = &cobra.Command{
 	Use:   "init",
 	Short: "Initialize project configuration",
-	Long: `Create a .codepush.json file in the current directory.
+	Long: `Create a .codepush.json file in the project root (the nearest directory
+with a package.json or .git, or the current directory).
+
+This stores the app ID, and optionally a default deployment, so you don't
+need to pass --app-id and --deployment on every command. When an API token
+is available, both are validated against the API first. The file is safe to
+commit to version control.
 
-This stores the app ID so you don't need to pass --app-id on every command.
-The file is safe to commit to version control.`,
+Without --app-id, an interactive terminal offers the apps your token can
+access, or only those of --workspace; with many apps, you can search them
+by name first.`,
 	GroupID: cmd.GroupSetup,
 	RunE: func(c *cobra.Command, args []string) error {
 		out := cmd.Out
 
-		appID, err := cmdutil.ResolveAppIDInteractive(cmd.AppID, out)
+		token := cmdutil.ResolveToken(c.Context(), out, cmd.Relogin)
+		if token == "" {
+			appID, err := cmdutil.ResolveAppIDInteractive(cmd.AppID, out)
+			if err != nil {
+				return err
+			}
+			out.Warning("no API token found: skipping validation of the app ID and deployment")
+			return writeProjectConfig(appID, initDeployment, out)
+		}
+
+		client := cmd.NewClient(cmdutil.ResolveAPIURL(cmd.APIURL, cmd.ServerURL, out), token)
+		catalog := cmdutil.NewAppCatalog(client, cmdutil.ResolveFlag(initWorkspace, cmdutil.WorkspaceEnvKey), out)
+		appID, err := cmdutil.SelectAppInteractive(c.Context(), catalog, cmd.AppID, out)
+		if err != nil {
+			return err
+		}
+		deployment, err := validateInitTarget(c.Context(), client, appID, initDeployment, out)
 		if err != nil {
 			return err
 		}
 
-		return writeProjectConfig(appID, out)
+		return writeProjectConfig(appID, deployment, out)
 	},
 }
 
-func writeProjectConfig(appID string, out *output.Writer) error {
-	dir, err := os.Getwd()
+type deploymentLister interface {
+	ListDeployments(ctx context.Context, appID string) ([]codepush.Deployment, error)
+}
+
+// validateInitTarget checks that the app exists and the token can access it,
+// and that deployment (a name or UUID) belongs to it. Without a deployment,
+// an interactive user may pick a default one. It returns the deployment to
+// save.
+func validateInitTarget(ctx context.Context, client deploymentLister, appID, deployment string, out *output.Writer) (string, error) {
+	var deployments []codepush.Deployment
+	err := out.Indeterminate("Validating app ID", func() error {
+		var listErr error
+		deployments, listErr = client.ListDeployments(ctx, appID)
+		return listErr
+	})
+	if err != nil {
+		return "", fmt.Errorf("validating app ID %s: %w", appID, err)
+	}
+
+	if deployment != "" {
+		for _, d := range deployments {
+			if d.Name == deployment || d.ID == deployment {
+				return deployment, nil
+			}
+		}
+		names := make([]string, len(deployments))
+		for i, d := range deployments {
+			names[i] = d.Name
+		}
+		return "", fmt.Errorf("deployment %q not found in app %s (available: %s)", deployment, appID, strings.Join(names, ", "))
+	}
+
+	if !out.IsInteractive() || len(deployments) == 0 {
+		return "", nil
+	}
+
+	options := []output.SelectOption{{Label: "No default deployment", Value: noDefaultDeployment}}
+	for _, d := range deployments {
+		options = append(options, output.SelectOption{Label: d.Name, Value: d.Name})
+	}
+	choice, err := out.Select("Default deployment", options)
 	if err != nil {
-		return fmt.Errorf("determining working directory: %w", err)
+		return "", err
+	}
+	if choice == noDefaultDeployment {
+		return "", nil
 	}
+	return choice, nil
+}
 
+func writeProjectConfig(appID, deployment string, out *output.Writer) error {
 	cfgPath, err := config.FilePath()
 	if err != nil {
 		return fmt.Errorf("resolving config path: %w", err)
 	}
 
 	if !initForce {
-		if _, err := os.Stat(cfgPath); err == nil {
-			return fmt.Errorf("%s already exists: use --force to overwrite", config.FileName)
+		if cfg, err := config.Load(); err == nil && cfg != nil {
+			return fmt.Errorf("%s already exists at %s: use --force to overwrite", config.FileName, cfgPath)
 		}
 	}
 
 	serverURL := cmdutil.ResolveServerURL(cmd.ServerURL, out)
 
-	cfg := &config.ProjectConfig{AppID: appID}
+	cfg := &config.ProjectConfig{AppID: appID, Deployment: deployment}
 	if serverURL != cmdutil.DefaultServerURL {
 		cfg.ServerURL = serverURL
 	}
+	cfg.APIURL = cmdutil.ResolveFlag(cmd.APIURL, "CODEPUSH_API_URL")
 	if cmd.RootCmd.PersistentFlags().Changed("progress-style") {
 		style, _ := cmd.RootCmd.PersistentFlags().GetString("progress-style")
 		if !output.IsValidBarStyle(style) {
@@ -64,19 +143,25 @@ func writeProjectConfig(appID string, out *output.Writer) error {
 		}
 		cfg.ProgressStyle = style
 	}
-	if err := config.Save(dir, cfg); err != nil {
+	if err := config.Save(filepath.Dir(cfgPath), cfg); err != nil {
 		return err
 	}
 
 	if cmd.JSONOutput {
-		return cmdutil.OutputJSON(cfg)
+		return cmdutil.OutputResult(cfg)
 	}
 
 	out.Success("Created %s", config.FileName)
 	out.Info("App ID: %s", appID)
+	if cfg.Deployment != "" {
+		out.Info("Default deployment: %s", cfg.Deployment)
+	}
 	if cfg.ServerURL != "" {
 		out.Info("Server: %s", cfg.ServerURL)
 	}
+	if cfg.APIURL != "" {
+		out.Info("API URL: %s", cfg.APIURL)
+	}
 	if cfg.ProgressStyle != "" {
 		out.Info("Progress style: %s", cfg.ProgressStyle)
 	}
@@ -86,5 +171,8 @@ func writeProjectConfig(appID string, out *output.Writer) error {
 
 func init() {
 	initCmd.Flags().BoolVarP(&initForce, "force", "f", false, "overwrite existing config file")
+	initCmd.Flags().StringVarP(&initDeployment, "deployment", "d", "", "default deployment name or UUID for commands that take --deployment")
+	initCmd.Flags().StringVar(&initWorkspace, "workspace", "", "offer only apps of this Bitrise workspace slug (env: CODEPUSH_WORKSPACE)")
+	_ = initCmd.RegisterFlagCompletionFunc("deployment", cmd.CompleteDeployments)
 	cmd.RootCmd.AddCommand(initCmd)
 }
@@ -1,11 +1,28 @@
 package setup
 
 import (
+	"context"
 	"errors"
+	"fmt"
+	"strings"
 
 	"github.com/spf13/cobra"
 
 	"github.com/bitrise-io/bitrise-plugins-codepush-cli/cmd"
+	"github.com/bitrise-io/bitrise-plugins-codepush-cli/internal/bundler"
+	"github.com/bitrise-io/bitrise-plugins-codepush-cli/internal/cmdutil"
+	"github.com/bitrise-io/bitrise-plugins-codepush-cli/internal/integrate"
+	"github.com/bitrise-io/bitrise-plugins-codepush-cli/internal/output"
+)
+
+var (
+	integrateDeployment    string
+	integrateDeploymentKey string
+	integrateProjectDir    string
+	integrateDryRun        bool
+	integrateNative        bool
+	integratePlatform      string
+	integratePatchDelegate bool
 )
 
 var integrateCmd = &cobra.Command{
@@ -13,14 +30,208 @@ var integrateCmd = &cobra.Command{
 	Short: "Integrate CodePush SDK into a mobile project",
 	Long: `Integrate the Bitrise CodePush SDK into your mobile project.
 
-Detects the project type (React Native, Flutter, native iOS/Android)
-and configures the SDK accordingly.`,
+For Expo projects, the CodePush config plugin is added to app.json (or
+app.config.js/ts), and the deployment key and server URL are set under
+extra.codePush. The Expo SDK version is checked against the plugin's
+requirements. Run "npx expo prebuild" afterwards to apply the plugin to the
+native projects.
+
+With --native, the native project of a brownfield or bare React Native app is
+edited instead. For --platform ios, the CodePush pod is added to the Podfile,
+and the deployment key and server URL are set in Info.plist as
+CodePushDeploymentKey and CodePushServerURL. With --patch-app-delegate,
+AppDelegate.swift or AppDelegate.m(m) is changed to return the CodePush
+bundle URL. For --platform android, the SDK's Gradle script (and without
+autolinking the SDK library) is added to the build, the key and server URL
+are set in strings.xml (or gradle.properties when it already holds the key),
+and MainApplication returns the CodePush bundle from getJSBundleFile. Each
+modified file is first copied to a .codepush.bak backup.
+
+The deployment key is looked up from --deployment, or given directly with
+--deployment-key. The server URL is the CodePush API URL resolved from
+--api-url or --server-url. Running integrate again only changes what is out
+of date. Use --dry-run to print the changes as a diff without writing them.
+
+Examples:
+  codepush integrate --deployment Staging --dry-run
+  codepush integrate --deployment-key <KEY> --project-dir ./app
+  codepush integrate --native --platform ios --deployment Production --patch-app-delegate`,
 	GroupID: cmd.GroupSetup,
-	RunE: func(c *cobra.Command, args []string) error {
-		return errors.New("integrate command is not yet implemented")
+	Args:    cobra.NoArgs,
+	RunE: func(c *cobra.Command, _ []string) error {
+		out := cmd.Out
+
+		projectDir := integrateProjectDir
+		if projectDir == "" {
+			projectDir = "."
+		}
+		if integrateNative {
+			return runNativeIntegration(c.Context(), out, projectDir)
+		}
+		if c.Flags().Changed("platform") || integratePatchDelegate {
+			return errors.New("--platform and --patch-app-delegate require --native")
+		}
+
+		projectType, err := bundler.DetectProjectType(projectDir)
+		if err != nil {
+			return err
+		}
+		if projectType != bundler.ProjectTypeExpo {
+			return fmt.Errorf("integrate does not support %s projects yet: only Expo projects can be integrated", projectType)
+		}
+
+		key, err := resolveIntegrateKey(c.Context(), out)
+		if err != nil {
+			return err
+		}
+
+		plan, err := integrate.PlanExpo(integrate.Options{
+			ProjectDir:    projectDir,
+			DeploymentKey: key,
+			ServerURL:     cmdutil.ResolveAPIURL(cmd.APIURL, cmd.ServerURL, out),
+		})
+		if err != nil {
+			return err
+		}
+		return reportIntegration(out, projectType.String(), "", plan)
 	},
 }
 
+// runNativeIntegration integrates the SDK into the native project of the
+// platform given by --platform.
+func runNativeIntegration(ctx context.Context, out *output.Writer, projectDir string) error {
+	platform, err := cmdutil.ResolvePlatformInteractive(integratePlatform, out)
+	if err != nil {
+		return err
+	}
+	if err := bundler.ValidatePlatform(bundler.Platform(platform)); err != nil {
+		return err
+	}
+	if integratePatchDelegate && bundler.Platform(platform) != bundler.PlatformIOS {
+		return errors.New("--patch-app-delegate only applies to --platform ios")
+	}
+
+	key, err := resolveIntegrateKey(ctx, out)
+	if err != nil {
+		return err
+	}
+	opts := integrate.Options{
+		ProjectDir:       projectDir,
+		DeploymentKey:    key,
+		ServerURL:        cmdutil.ResolveAPIURL(cmd.APIURL, cmd.ServerURL, out),
+		PatchAppDelegate: integratePatchDelegate,
+	}
+	planNative := integrate.PlanIOS
+	if bundler.Platform(platform) == bundler.PlatformAndroid {
+		planNative = integrate.PlanAndroid
+	}
+	plan, err := planNative(opts)
+	if err != nil {
+		return err
+	}
+	return reportIntegration(out, "native", platform, plan)
+}
+
+// resolveIntegrateKey returns --deployment-key, or the key of the
+// deployment named by --deployment.
+func resolveIntegrateKey(ctx context.Context, out *output.Writer) (string, error) {
+	if integrateDeploymentKey != "" {
+		return integrateDeploymentKey, nil
+	}
+
+	appID, token, err := cmdutil.RequireCredentials(ctx, cmd.AppID, out, cmd.Relogin, cmd.FirstRun)
+	if err != nil {
+		return "", err
+	}
+	client := cmd.NewClient(cmdutil.ResolveAPIURL(cmd.APIURL, cmd.ServerURL, out), token)
+	deploymentID, err := cmdutil.ResolveDeploymentInteractive(ctx, client, appID, integrateDeployment, "CODEPUSH_DEPLOYMENT", out)
+	if err != nil {
+		return "", err
+	}
+	dep, err := client.GetDeployment(ctx, appID, deploymentID)
+	if err != nil {
+		return "", fmt.Errorf("getting deployment: %w", err)
+	}
+	if dep.Key == "" {
+		return "", fmt.Errorf("deployment %q has no key", dep.Name)
+	}
+	return dep.Key, nil
+}
+
+// integrateChange is a file changed by integrate in --json output.
+type integrateChange struct {
+	Path   string `json:"path"`
+	Diff   string `json:"diff"`
+	Backup string `json:"backup,omitempty"`
+}
+
+// backupPath returns the backup Apply wrote for ch, or "" when there is
+// none.
+func backupPath(plan *integrate.Plan, ch integrate.FileChange, dryRun bool) string {
+	if dryRun || !plan.Backup || ch.Before == nil {
+		return ""
+	}
+	return ch.Path + integrate.BackupSuffix
+}
+
+// reportIntegration prints the plan with --dry-run, or applies it. mode is
+// the project type, or "native" with the platform.
+func reportIntegration(out *output.Writer, mode, platform string, plan *integrate.Plan) error {
+	if !integrateDryRun {
+		if _, err := plan.Apply(); err != nil {
+			return err
+		}
+	}
+
+	if cmd.JSONOutput {
+		changes := make([]integrateChange, len(plan.Changes))
+		for i, ch := range plan.Changes {
+			changes[i] = integrateChange{Path: ch.Path, Diff: ch.Diff(), Backup: backupPath(plan, ch, integrateDryRun)}
+		}
+		notes := plan.Notes
+		if notes == nil {
+			notes = []string{}
+		}
+		return cmdutil.OutputResult(struct {
+			Mode     string            `json:"mode"`
+			Platform string            `json:"platform,omitempty"`
+			DryRun   bool              `json:"dry_run"`
+			Changes  []integrateChange `json:"changes"`
+			Notes    []string          `json:"notes"`
+		}{mode, platform, integrateDryRun, changes, notes})
+	}
+
+	switch {
+	case len(plan.Changes) == 0:
+		out.Success("CodePush is already integrated")
+	case integrateDryRun:
+		out.Println("%s", strings.TrimRight(plan.Diff(), "\n"))
+		out.Info("Dry run: %d file(s) would change", len(plan.Changes))
+	default:
+		out.Success("Integrated CodePush")
+		for _, ch := range plan.Changes {
+			if backup := backupPath(plan, ch, integrateDryRun); backup != "" {
+				out.Info("Updated %s (backup: %s)", ch.Path, backup)
+			} else {
+				out.Info("Updated %s", ch.Path)
+			}
+		}
+	}
+	for _, note := range plan.Notes {
+		out.Info("Next: %s", note)
+	}
+	return nil
+}
+
 func init() {
+	integrateCmd.Flags().StringVarP(&integrateDeployment, "deployment", "d", "", "deployment whose key the app uses, name or UUID (env: CODEPUSH_DEPLOYMENT)")
+	integrateCmd.Flags().StringVar(&integrateDeploymentKey, "deployment-key", "", "deployment key to configure, instead of looking it up from --deployment")
+	integrateCmd.Flags().StringVar(&integrateProjectDir, "project-dir", "", "project root directory (defaults to current directory)")
+	integrateCmd.Flags().BoolVar(&integrateDryRun, "dry-run", false, "print the changes as a diff without writing them")
+	integrateCmd.Flags().BoolVar(&integrateNative, "native", false, "integrate into the native project of a brownfield or bare React Native app")
+	integrateCmd.Flags().StringVar(&integratePlatform, "platform", "", "native platform to integrate with --native: ios or android")
+	integrateCmd.Flags().BoolVar(&integratePatchDelegate, "patch-app-delegate", false, "with --native, make AppDelegate return the CodePush bundle URL")
+	integrateCmd.MarkFlagsMutuallyExclusive("deployment", "deployment-key")
+	_ = integrateCmd.RegisterFlagCompletionFunc("deployment", cmd.CompleteDeployments)
 	cmd.RootCmd.AddCommand(integrateCmd)
 }
@@ -0,0 +1,236 @@
+package setup
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/bitrise-io/bitrise-plugins-codepush-cli/cmd"
+	"github.com/bitrise-io/bitrise-plugins-codepush-cli/internal/appcenter"
+	"github.com/bitrise-io/bitrise-plugins-codepush-cli/internal/cmdutil"
+	"github.com/bitrise-io/bitrise-plugins-codepush-cli/internal/codepush"
+	"github.com/bitrise-io/bitrise-plugins-codepush-cli/internal/integrate"
+	"github.com/bitrise-io/bitrise-plugins-codepush-cli/internal/output"
+	"github.com/bitrise-io/bitrise-plugins-codepush-cli/internal/redact"
+)
+
+var (
+	migrateAppCenterApp   string
+	migrateAppCenterToken string
+	migrateAppCenterURL   string
+	migrateExport         string
+	migrateReleases       int
+	migrateKeepKeys       bool
+	migrateRewriteKeys    bool
+	migrateProjectDir     string
+	migrateDryRun         bool
+)
+
+var migrateCmd = &cobra.Command{
+	Use:     "migrate",
+	Short:   "Migrate to Bitrise CodePush from another update service",
+	GroupID: cmd.GroupSetup,
+}
+
+var migrateAppCenterCmd = &cobra.Command{
+	Use:   "appcenter",
+	Short: "Migrate deployments and releases from App Center CodePush",
+	Long: `Migrate an App Center CodePush app to the configured Bitrise connected app.
+
+Each App Center deployment is recreated on Bitrise, or reused when a
+deployment with the same name exists. With --releases N, the latest N
+releases of each deployment are copied by downloading their packages and
+pushing them again with the same app version, description, mandatory and
+disabled flags, and rollout, oldest first.
+
+The App Center app is read from the API with an App Center API token (from
+--appcenter-token, APPCENTER_ACCESS_TOKEN, or 'appcenter tokens create'), or
+from a JSON export given with --export.
+
+Apps in the field keep their App Center deployment keys. With --keep-keys,
+the deployments are created with the same keys. Otherwise --rewrite-keys
+replaces the old keys with the new ones in the project's configuration and
+source files, backing up each modified file. Either way, point the SDK at
+the Bitrise server afterwards, for example with 'codepush integrate'.
+
+Examples:
+  codepush migrate appcenter --app my-org/my-app --dry-run
+  codepush migrate appcenter --app my-org/my-app --releases 3 --rewrite-keys
+  codepush migrate appcenter --export appcenter.json --keep-keys`,
+	Args: cobra.NoArgs,
+	RunE: func(c *cobra.Command, _ []string) error {
+		out := cmd.Out
+
+		if migrateReleases < 0 {
+			return codepush.Invalid(fmt.Errorf("--releases must be 0 or more, got %d", migrateReleases))
+		}
+		if (migrateAppCenterApp == "") == (migrateExport == "") {
+			return codepush.Invalid(errors.New("set either --app or --export"))
+		}
+		source, err := appCenterSource()
+		if err != nil {
+			return codepush.Invalid(err)
+		}
+
+		appID, token, err := cmdutil.RequireCredentials(c.Context(), cmd.AppID, out, cmd.Relogin, cmd.FirstRun)
+		if err != nil {
+			return err
+		}
+		client := cmd.NewClient(cmdutil.ResolveAPIURL(cmd.APIURL, cmd.ServerURL, out), token)
+
+		result, err := appcenter.Migrate(c.Context(), source, client, appcenter.MigrateOptions{
+			AppID:      appID,
+			Token:      token,
+			Releases:   migrateReleases,
+			KeepKeys:   migrateKeepKeys,
+			DryRun:     migrateDryRun,
+			PollConfig: codepush.DefaultPollConfig,
+			Workspace:  cmd.Workspace,
+		}, out)
+		if err != nil {
+			if result != nil && len(result.Deployments) > 0 {
+				reportMigratedDeployments(out, result)
+			}
+			return err
+		}
+
+		var keyPlan *integrate.Plan
+		if migrateRewriteKeys {
+			projectDir := migrateProjectDir
+			if projectDir == "" {
+				projectDir = "."
+			}
+			if keyPlan, err = integrate.RewriteKeys(projectDir, result.KeyMap()); err != nil {
+				return err
+			}
+			if !migrateDryRun {
+				if _, err := keyPlan.Apply(); err != nil {
+					return err
+				}
+			}
+		}
+		return reportMigration(out, result, keyPlan)
+	},
+}
+
+// appCenterSource returns the App Center API client for --app, or the
+// export read from --export.
+func appCenterSource() (appcenter.Source, error) {
+	if migrateExport != "" {
+		return appcenter.LoadExport(migrateExport, cmd.HTTPClient())
+	}
+	token := migrateAppCenterToken
+	if token == "" {
+		token = os.Getenv(appcenter.TokenEnvKey)
+	}
+	redact.Register(token)
+	return appcenter.NewClient(migrateAppCenterURL, token, migrateAppCenterApp, cmd.HTTPClient())
+}
+
+// reportMigration prints the migrated deployments and the key rewrite.
+func reportMigration(out *output.Writer, result *appcenter.MigrateResult, keyPlan *integrate.Plan) error {
+	if cmd.JSONOutput {
+		changes := []integrateChange{}
+		if keyPlan != nil {
+			for _, ch := range keyPlan.Changes {
+				changes = append(changes, integrateChange{Path: ch.Path, Diff: ch.Diff(), Backup: backupPath(keyPlan, ch, migrateDryRun)})
+			}
+		}
+		return cmdutil.OutputResult(struct {
+			*appcenter.MigrateResult
+			KeyChanges []integrateChange `json:"key_changes"`
+		}{result, changes})
+	}
+
+	reportMigratedDeployments(out, result)
+	if migrateDryRun {
+		out.Info("Dry run: nothing was changed")
+	} else {
+		out.Success("Migrated %d deployment(s)", len(result.Deployments))
+	}
+
+	keys := result.KeyMap()
+	switch {
+	case keyPlan == nil && len(keys) > 0:
+		out.Info("Next: replace the App Center deployment keys in your app, or rerun with --rewrite-keys:")
+		for _, from := range sortedKeys(keys) {
+			out.Println("  %s -> %s", from, keys[from])
+		}
+	case keyPlan != nil && len(keyPlan.Changes) == 0:
+		out.Info("No App Center deployment keys found in the project")
+	case keyPlan != nil && migrateDryRun:
+		out.Println("%s", strings.TrimRight(keyPlan.Diff(), "\n"))
+		out.Info("Dry run: %d file(s) would change", len(keyPlan.Changes))
+	case keyPlan != nil:
+		for _, ch := range keyPlan.Changes {
+			out.Info("Updated %s (backup: %s)", ch.Path, backupPath(keyPlan, ch, false))
+		}
+	}
+	if migrateDryRun && !migrateKeepKeys {
+		for _, d := range result.Deployments {
+			if d.Created {
+				out.Info("The keys of deployments that would be created are assigned by the server and not rewritten in a dry run")
+				break
+			}
+		}
+	}
+	out.Info("Next: point the SDK at the Bitrise server, for example with 'codepush integrate'")
+	return nil
+}
+
+// reportMigratedDeployments prints a table of the migrated deployments.
+func reportMigratedDeployments(out *output.Writer, result *appcenter.MigrateResult) {
+	rows := make([][]string, len(result.Deployments))
+	for i, d := range result.Deployments {
+		status := "exists"
+		switch {
+		case d.Created && result.DryRun:
+			status = "would create"
+		case d.Created:
+			status = "created"
+		}
+		labels := make([]string, len(d.Releases))
+		for j, r := range d.Releases {
+			switch {
+			case r.Skipped:
+				labels[j] = r.SourceLabel + " (skipped)"
+			case r.Label != "":
+				labels[j] = r.SourceLabel + " -> " + r.Label
+			default:
+				labels[j] = r.SourceLabel
+			}
+		}
+		rows[i] = []string{d.Name, status, d.Key, strings.Join(labels, ", ")}
+	}
+	out.Table([]string{"DEPLOYMENT", "STATUS", "KEY", "RELEASES"}, rows)
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func init() {
+	f := migrateAppCenterCmd.Flags()
+	f.StringVar(&migrateAppCenterApp, "app", "", "App Center app to migrate, as owner/app")
+	f.StringVar(&migrateAppCenterToken, "appcenter-token", "", "App Center API token (env: "+appcenter.TokenEnvKey+")")
+	f.StringVar(&migrateAppCenterURL, "appcenter-url", appcenter.DefaultBaseURL, "App Center API URL")
+	f.StringVar(&migrateExport, "export", "", "read the App Center app from a JSON export instead of the API")
+	f.IntVar(&migrateReleases, "releases", 0, "number of latest releases to copy per deployment")
+	f.BoolVar(&migrateKeepKeys, "keep-keys", false, "create deployments with their App Center keys")
+	f.BoolVar(&migrateRewriteKeys, "rewrite-keys", false, "replace App Center deployment keys with the new keys in the project")
+	f.StringVar(&migrateProjectDir, "project-dir", "", "project root directory for --rewrite-keys (defaults to current directory)")
+	f.BoolVar(&migrateDryRun, "dry-run", false, "show what would be migrated without changing anything")
+	migrateAppCenterCmd.MarkFlagsMutuallyExclusive("app", "export")
+	migrateAppCenterCmd.MarkFlagsMutuallyExclusive("keep-keys", "rewrite-keys")
+	migrateCmd.AddCommand(migrateAppCenterCmd)
+	cmd.RootCmd.AddCommand(migrateCmd)
+}
@@ -0,0 +1,260 @@
+package setup
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/bitrise-io/bitrise-plugins-codepush-cli/cmd"
+	"github.com/bitrise-io/bitrise-plugins-codepush-cli/internal/bundler"
+	"github.com/bitrise-io/bitrise-plugins-codepush-cli/internal/cmdutil"
+	"github.com/bitrise-io/bitrise-plugins-codepush-cli/internal/codepush"
+	"github.com/bitrise-io/bitrise-plugins-codepush-cli/internal/integrate"
+	"github.com/bitrise-io/bitrise-plugins-codepush-cli/internal/output"
+)
+
+var (
+	migrateExpoDeployment string
+	migrateExpoMap        map[string]string
+	migrateExpoCreate     bool
+	migrateExpoProjectDir string
+	migrateExpoDryRun     bool
+)
+
+// productionProfile is the EAS build profile whose channel's deployment is
+// integrated when --deployment is not set.
+const productionProfile = "production"
+
+var migrateExpoUpdatesCmd = &cobra.Command{
+	Use:   "expo-updates",
+	Short: "Migrate an Expo project from expo-updates to CodePush",
+	Long: `Migrate an Expo project from expo-updates (EAS Update) to CodePush.
+
+The expo-updates setup is detected from the expo-updates dependency, the
+updates block of the Expo config, and the channels of the build profiles in
+eas.json. Each channel is mapped to the deployment of the same name, ignoring
+case, or to the deployment given with --map channel=deployment. With
+--create-deployments, a deployment is created for each channel left
+unmapped.
+
+The Expo config is then changed to disable expo-updates, removing its URL
+and config plugin, and the project is integrated like 'codepush integrate'
+does, with the key of --deployment, or else of the deployment mapped from the
+channel of the production build profile. Each modified file is first copied
+to a .codepush.bak backup. Steps that cannot be done safely, like
+uninstalling expo-updates, are reported as manual follow-ups.
+
+Examples:
+  codepush migrate expo-updates --dry-run
+  codepush migrate expo-updates --map preview=Staging --deployment Production
+  codepush migrate expo-updates --create-deployments`,
+	Args: cobra.NoArgs,
+	RunE: func(c *cobra.Command, _ []string) error {
+		ctx := c.Context()
+		out := cmd.Out
+
+		projectDir := migrateExpoProjectDir
+		if projectDir == "" {
+			projectDir = "."
+		}
+		projectType, err := bundler.DetectProjectType(projectDir)
+		if err != nil {
+			return err
+		}
+		if projectType != bundler.ProjectTypeExpo {
+			return fmt.Errorf("migrate expo-updates requires an Expo project, found a %s project", projectType)
+		}
+		setup, err := integrate.DetectExpoUpdates(projectDir)
+		if err != nil {
+			return err
+		}
+
+		appID, token, err := cmdutil.RequireCredentials(ctx, cmd.AppID, out, cmd.Relogin, cmd.FirstRun)
+		if err != nil {
+			return err
+		}
+		client := cmd.NewClient(cmdutil.ResolveAPIURL(cmd.APIURL, cmd.ServerURL, out), token)
+
+		channels, err := mapExpoChannels(ctx, client, appID, setup.Channels, out)
+		if err != nil {
+			return err
+		}
+		key, err := resolveMigrateExpoKey(ctx, client, appID, setup.Channels, channels, out)
+		if err != nil {
+			return err
+		}
+
+		plan, err := integrate.PlanExpoUpdatesMigration(integrate.Options{
+			ProjectDir:    projectDir,
+			DeploymentKey: key,
+			ServerURL:     cmdutil.ResolveAPIURL(cmd.APIURL, cmd.ServerURL, out),
+		}, setup)
+		if err != nil {
+			return err
+		}
+		for _, ch := range channels {
+			if ch.Deployment == "" {
+				plan.Notes = append(plan.Notes, fmt.Sprintf("channel %s has no deployment: rerun with --map %s=<deployment> or --create-deployments", ch.Channel, ch.Channel))
+			}
+		}
+		if !migrateExpoDryRun {
+			if _, err := plan.Apply(); err != nil {
+				return err
+			}
+		}
+		return reportExpoMigration(out, setup, channels, plan)
+	},
+}
+
+// migratedChannel is an EAS Update channel and its CodePush deployment.
+type migratedChannel struct {
+	Channel    string   `json:"channel"`
+	Profiles   []string `json:"profiles"`
+	Deployment string   `json:"deployment,omitempty"`
+	Key        string   `json:"key,omitempty"`
+	Created    bool     `json:"created"`
+}
+
+// mapExpoChannels maps each channel to a deployment, creating deployments
+// for unmapped channels with --create-deployments.
+func mapExpoChannels(ctx context.Context, client codepush.Client, appID string, channels []integrate.Channel, out *output.Writer) ([]migratedChannel, error) {
+	deployments, err := client.ListDeployments(ctx, appID)
+	if err != nil {
+		return nil, fmt.Errorf("listing deployments: %w", err)
+	}
+	names := make([]string, len(deployments))
+	keys := make(map[string]string, len(deployments))
+	for i, d := range deployments {
+		names[i] = d.Name
+		keys[d.Name] = d.Key
+	}
+	for channel, name := range migrateExpoMap {
+		if !slices.Contains(names, name) {
+			return nil, codepush.Invalid(fmt.Errorf("deployment %q given for channel %s not found", name, channel))
+		}
+	}
+
+	mapped, _ := integrate.MapChannels(channels, names, migrateExpoMap)
+	result := make([]migratedChannel, len(channels))
+	for i, ch := range channels {
+		m := migratedChannel{Channel: ch.Name, Profiles: ch.Profiles}
+		if name, ok := mapped[ch.Name]; ok {
+			m.Deployment, m.Key = name, keys[name]
+		} else if migrateExpoCreate {
+			m.Deployment, m.Created = ch.Name, true
+			if !migrateExpoDryRun {
+				step := out.StartStep("Creating deployment %q", ch.Name)
+				dep, err := client.CreateDeployment(ctx, appID, codepush.CreateDeploymentRequest{Name: ch.Name})
+				if err != nil {
+					step.Cancel()
+					return nil, fmt.Errorf("creating deployment %s: %w", ch.Name, err)
+				}
+				step.Done()
+				m.Key = dep.Key
+			}
+		}
+		result[i] = m
+	}
+	return result, nil
+}
+
+// resolveMigrateExpoKey returns the key of --deployment, or else of the
+// deployment mapped from the production build profile's channel, or else
+// of the deployment picked interactively.
+func resolveMigrateExpoKey(ctx context.Context, client codepush.Client, appID string, channels []integrate.Channel, mapped []migratedChannel, out *output.Writer) (string, error) {
+	if migrateExpoDeployment == "" {
+		for i, ch := range channels {
+			if slices.Contains(ch.Profiles, productionProfile) && mapped[i].Key != "" {
+				out.Info("Integrating deployment %s, mapped from the %s channel", mapped[i].Deployment, ch.Name)
+				return mapped[i].Key, nil
+			}
+		}
+	}
+
+	deploymentID, err := cmdutil.ResolveDeploymentInteractive(ctx, client, appID, migrateExpoDeployment, "CODEPUSH_DEPLOYMENT", out)
+	if err != nil {
+		return "", err
+	}
+	dep, err := client.GetDeployment(ctx, appID, deploymentID)
+	if err != nil {
+		return "", fmt.Errorf("getting deployment: %w", err)
+	}
+	if dep.Key == "" {
+		return "", fmt.Errorf("deployment %q has no key", dep.Name)
+	}
+	return dep.Key, nil
+}
+
+// reportExpoMigration prints the channel mapping, the changes, and the
+// manual follow-ups.
+func reportExpoMigration(out *output.Writer, setup *integrate.ExpoUpdates, channels []migratedChannel, plan *integrate.Plan) error {
+	notes := plan.Notes
+	if notes == nil {
+		notes = []string{}
+	}
+	if cmd.JSONOutput {
+		changes := make([]integrateChange, len(plan.Changes))
+		for i, ch := range plan.Changes {
+			changes[i] = integrateChange{Path: ch.Path, Diff: ch.Diff(), Backup: backupPath(plan, ch, migrateExpoDryRun)}
+		}
+		return cmdutil.OutputResult(struct {
+			DryRun    bool                   `json:"dry_run"`
+			Setup     *integrate.ExpoUpdates `json:"expo_updates"`
+			Channels  []migratedChannel      `json:"channels"`
+			Changes   []integrateChange      `json:"changes"`
+			FollowUps []string               `json:"follow_ups"`
+		}{migrateExpoDryRun, setup, channels, changes, notes})
+	}
+
+	if len(channels) > 0 {
+		rows := make([][]string, len(channels))
+		for i, ch := range channels {
+			deployment := ch.Deployment
+			switch {
+			case deployment == "":
+				deployment = "(unmapped)"
+			case ch.Created && migrateExpoDryRun:
+				deployment += " (would create)"
+			case ch.Created:
+				deployment += " (created)"
+			}
+			rows[i] = []string{ch.Channel, strings.Join(ch.Profiles, ", "), deployment}
+		}
+		out.Table([]string{"CHANNEL", "BUILD PROFILES", "DEPLOYMENT"}, rows)
+	}
+
+	switch {
+	case len(plan.Changes) == 0:
+		out.Success("The Expo config is already migrated")
+	case migrateExpoDryRun:
+		out.Println("%s", strings.TrimRight(plan.Diff(), "\n"))
+		out.Info("Dry run: %d file(s) would change", len(plan.Changes))
+	default:
+		out.Success("Migrated from expo-updates to CodePush")
+		for _, ch := range plan.Changes {
+			if backup := backupPath(plan, ch, false); backup != "" {
+				out.Info("Updated %s (backup: %s)", ch.Path, backup)
+			} else {
+				out.Info("Updated %s", ch.Path)
+			}
+		}
+	}
+	for _, note := range notes {
+		out.Info("Follow-up: %s", note)
+	}
+	return nil
+}
+
+func init() {
+	f := migrateExpoUpdatesCmd.Flags()
+	f.StringVarP(&migrateExpoDeployment, "deployment", "d", "", "deployment whose key the app uses, name or UUID (env: CODEPUSH_DEPLOYMENT)")
+	f.StringToStringVar(&migrateExpoMap, "map", nil, "map an EAS Update channel to a deployment, as channel=deployment (repeatable)")
+	f.BoolVar(&migrateExpoCreate, "create-deployments", false, "create a deployment named after each unmapped channel")
+	f.StringVar(&migrateExpoProjectDir, "project-dir", "", "project root directory (defaults to current directory)")
+	f.BoolVar(&migrateExpoDryRun, "dry-run", false, "print the changes as a diff without writing them or creating deployments")
+	_ = migrateExpoUpdatesCmd.RegisterFlagCompletionFunc("deployment", cmd.CompleteDeployments)
+	migrateCmd.AddCommand(migrateExpoUpdatesCmd)
+}
@@ -0,0 +1,145 @@
+package setup
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/bitrise-io/bitrise-plugins-codepush-cli/cmd"
+	"github.com/bitrise-io/bitrise-plugins-codepush-cli/internal/auth"
+	"github.com/bitrise-io/bitrise-plugins-codepush-cli/internal/cmdutil"
+	"github.com/bitrise-io/bitrise-plugins-codepush-cli/internal/codepush"
+	"github.com/bitrise-io/bitrise-plugins-codepush-cli/internal/config"
+	"github.com/bitrise-io/bitrise-plugins-codepush-cli/internal/output"
+)
+
+// onboardingDeployment is the deployment the first-run setup offers to create.
+const onboardingDeployment = "Staging"
+
+// createDeploymentValue marks the "create a deployment" option in the picker.
+const createDeploymentValue = "\x00create"
+
+// FirstRun walks a new user through login, app selection, deployment
+// selection, and writing .codepush.json. It is skipped with --no-onboarding
+// and in non-interactive terminals (including CI), returning empty values.
+// main sets it as cmd.FirstRun.
+func FirstRun(ctx context.Context, out *output.Writer) (string, string, error) {
+	if cmd.NoOnboarding || !out.IsInteractive() {
+		return "", "", nil
+	}
+
+	out.Info("No CodePush configuration found (no API token, app ID, or %s).", config.FileName)
+	choice, err := out.Select("Set up CodePush for this project now?", []output.SelectOption{
+		{Label: "Yes, run guided setup", Value: "setup"},
+		{Label: "No, skip (pass --no-onboarding to stop asking)", Value: "skip"},
+	})
+	if err != nil {
+		return "", "", err
+	}
+	if choice != "setup" {
+		return "", "", nil
+	}
+
+	out.Step("Step 1/4: Log in")
+	token := cmdutil.ResolveToken(ctx, out, cmd.Relogin)
+	if token == "" {
+		if token, err = promptToken(out); err != nil {
+			return "", "", err
+		}
+		if err := storeLogin(ctx, auth.Config{Token: token, Method: auth.MethodToken}, out); err != nil {
+			return "", "", err
+		}
+	}
+
+	client := cmd.NewClient(cmdutil.ResolveAPIURL(cmd.APIURL, cmd.ServerURL, out), token)
+
+	out.Step("Step 2/4: Choose an app")
+	catalog := cmdutil.NewAppCatalog(client, cmdutil.ResolveFlag("", cmdutil.WorkspaceEnvKey), out)
+	appID, err := cmdutil.SelectAppInteractive(ctx, catalog, "", out)
+	if err != nil {
+		return "", "", err
+	}
+
+	out.Step("Step 3/4: Choose a deployment")
+	deployment, err := pickDeployment(ctx, client, appID, out)
+	if err != nil {
+		return "", "", err
+	}
+
+	out.Step("Step 4/4: Save project configuration")
+	if err := saveAppID(appID, out); err != nil {
+		return "", "", err
+	}
+
+	out.Success("Setup complete")
+	out.Info("Push to it with: codepush push --deployment %s", deployment)
+	return appID, token, nil
+}
+
+// pickDeployment lets the user select an existing deployment or create the
+// Staging deployment, and returns the chosen deployment's name.
+func pickDeployment(ctx context.Context, client codepush.Client, appID string, out *output.Writer) (string, error) {
+	deployments, err := client.ListDeployments(ctx, appID)
+	if err != nil {
+		return "", fmt.Errorf("listing deployments: %w", err)
+	}
+
+	var options []output.SelectOption
+	hasStaging := false
+	for _, d := range deployments {
+		if strings.EqualFold(d.Name, onboardingDeployment) {
+			hasStaging = true
+			options = append([]output.SelectOption{{Label: d.Name, Value: d.Name}}, options...)
+			continue
+		}
+		options = append(options, output.SelectOption{Label: d.Name, Value: d.Name})
+	}
+	if !hasStaging {
+		options = append([]output.SelectOption{{Label: fmt.Sprintf("Create %q", onboardingDeployment), Value: createDeploymentValue}}, options...)
+	}
+
+	choice, err := out.Select("Select deployment", options)
+	if err != nil {
+		return "", err
+	}
+	if choice != createDeploymentValue {
+		return choice, nil
+	}
+
+	dep, err := client.CreateDeployment(ctx, appID, codepush.CreateDeploymentRequest{Name: onboardingDeployment})
+	if err != nil {
+		return "", fmt.Errorf("creating deployment: %w", err)
+	}
+	out.Success("Created deployment %q", dep.Name)
+	return dep.Name, nil
+}
+
+// saveAppID records appID in .codepush.json, keeping any other settings.
+func saveAppID(appID string, out *output.Writer) error {
+	cfgPath, err := config.FilePath()
+	if err != nil {
+		return fmt.Errorf("resolving config path: %w", err)
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		return err
+	}
+	if cfg == nil {
+		cfg = &config.ProjectConfig{}
+	}
+	cfg.AppID = appID
+	if serverURL := cmdutil.ResolveServerURL(cmd.ServerURL, out); serverURL != cmdutil.DefaultServerURL && cfg.ServerURL == "" {
+		cfg.ServerURL = serverURL
+	}
+	if apiURL := cmdutil.ResolveFlag(cmd.APIURL, "CODEPUSH_API_URL"); apiURL != "" && cfg.APIURL == "" {
+		cfg.APIURL = apiURL
+	}
+
+	if err := config.Save(filepath.Dir(cfgPath), cfg); err != nil {
+		return err
+	}
+	out.Success("Saved %s", config.FileName)
+	return nil
+}
@@ -1,14 +1,19 @@
 package setup
 
 import (
+	"context"
+	"errors"
 	"io"
 	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
 	"github.com/bitrise-io/bitrise-plugins-codepush-cli/cmd"
+	"github.com/bitrise-io/bitrise-plugins-codepush-cli/internal/codepush"
+	"github.com/bitrise-io/bitrise-plugins-codepush-cli/internal/config"
 	"github.com/bitrise-io/bitrise-plugins-codepush-cli/internal/output"
 )
 
@@ -17,10 +22,65 @@ func TestMain(m *testing.M) {
 	os.Exit(m.Run())
 }
 
-func TestIntegrateReturnsError(t *testing.T) {
+func TestIntegrateRejectsNonExpoProject(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "package.json"), []byte(`{"dependencies":{"react-native":"0.74.0"}}`), 0o644))
+	integrateProjectDir = dir
+	t.Cleanup(func() { integrateProjectDir = "" })
+
 	err := integrateCmd.RunE(integrateCmd, nil)
 	require.Error(t, err)
-	assert.ErrorContains(t, err, "not yet implemented")
+	assert.ErrorContains(t, err, "integrate does not support react-native projects yet")
+}
+
+func TestIntegrateNativeFlags(t *testing.T) {
+	tests := []struct {
+		name    string
+		native  bool
+		patch   bool
+		wantErr string
+	}{
+		{name: "patch without native", patch: true, wantErr: "--platform and --patch-app-delegate require --native"},
+		{name: "patch for android", native: true, patch: true, wantErr: "--patch-app-delegate only applies to --platform ios"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			integrateNative, integratePatchDelegate, integratePlatform = tt.native, tt.patch, "android"
+			t.Cleanup(func() { integrateNative, integratePatchDelegate, integratePlatform = false, false, "" })
+
+			err := integrateCmd.RunE(integrateCmd, nil)
+			require.Error(t, err)
+			assert.ErrorContains(t, err, tt.wantErr)
+		})
+	}
+}
+
+func TestIntegrateDryRunLeavesConfig(t *testing.T) {
+	dir := t.TempDir()
+	appJSON := `{"expo":{"name":"demo"}}`
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "package.json"), []byte(`{"dependencies":{"expo":"51.0.0"}}`), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "app.json"), []byte(appJSON), 0o644))
+	integrateProjectDir, integrateDeploymentKey, integrateDryRun = dir, "dep-key", true
+	t.Cleanup(func() { integrateProjectDir, integrateDeploymentKey, integrateDryRun = "", "", false })
+
+	require.NoError(t, integrateCmd.RunE(integrateCmd, nil))
+
+	data, err := os.ReadFile(filepath.Join(dir, "app.json"))
+	require.NoError(t, err)
+	assert.Equal(t, appJSON, string(data))
+}
+
+func TestIntegrateVerifyFailsOnMismatch(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "package.json"), []byte(`{"dependencies":{"expo":"51.0.0","@code-push-next/react-native-code-push":"10.0.0"}}`), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "app.json"), []byte(`{"expo":{"extra":{"codePush":{"deploymentKey":"old","serverUrl":"https://api.bitrise.io"}}}}`), 0o644))
+	verifyProjectDir, verifyDeploymentKey = dir, "dep-key"
+	t.Cleanup(func() { verifyProjectDir, verifyDeploymentKey = "", "" })
+
+	err := integrateVerifyCmd.RunE(integrateVerifyCmd, nil)
+	require.Error(t, err)
+	assert.ErrorContains(t, err, "checks failed")
 }
 
 func TestAuthSubcommands(t *testing.T) {
@@ -33,3 +93,97 @@ func TestAuthSubcommands(t *testing.T) {
 	assert.True(t, found["login"], "auth login subcommand not registered")
 	assert.True(t, found["revoke"], "auth revoke subcommand not registered")
 }
+
+func TestFirstRunSkippedWhenNonInteractive(t *testing.T) {
+	appID, token, err := FirstRun(context.Background(), cmd.Out)
+	require.NoError(t, err)
+	assert.Empty(t, appID)
+	assert.Empty(t, token)
+}
+
+func TestSaveAppIDKeepsExistingSettings(t *testing.T) {
+	dir := t.TempDir()
+	t.Chdir(dir)
+	t.Setenv("CODEPUSH_SERVER_URL", "")
+	require.NoError(t, os.WriteFile(filepath.Join(dir, config.FileName), []byte(`{"app_id":"","progress_style":"spinner"}`), 0o644))
+
+	require.NoError(t, saveAppID("550e8400-e29b-41d4-a716-446655440000", cmd.Out))
+
+	cfg, err := config.Load()
+	require.NoError(t, err)
+	require.NotNil(t, cfg)
+	assert.Equal(t, "550e8400-e29b-41d4-a716-446655440000", cfg.AppID)
+	assert.Equal(t, "spinner", cfg.ProgressStyle)
+}
+
+type fakeDeploymentLister struct {
+	deployments []codepush.Deployment
+	err         error
+}
+
+func (f fakeDeploymentLister) ListDeployments(_ context.Context, _ string) ([]codepush.Deployment, error) {
+	return f.deployments, f.err
+}
+
+func TestValidateInitTarget(t *testing.T) {
+	client := fakeDeploymentLister{deployments: []codepush.Deployment{
+		{ID: "dep-1", Name: "Staging"},
+		{ID: "dep-2", Name: "Production"},
+	}}
+
+	tests := []struct {
+		name       string
+		client     fakeDeploymentLister
+		deployment string
+		want       string
+		wantErr    string
+	}{
+		{name: "deployment by name", client: client, deployment: "Staging", want: "Staging"},
+		{name: "deployment by ID", client: client, deployment: "dep-2", want: "dep-2"},
+		{name: "no deployment when non-interactive", client: client, want: ""},
+		{name: "unknown deployment", client: client, deployment: "QA", wantErr: `deployment "QA" not found in app app-1 (available: Staging, Production)`},
+		{name: "invalid app ID", client: fakeDeploymentLister{err: errors.New("404 not found")}, deployment: "Staging", wantErr: "validating app ID app-1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := validateInitTarget(context.Background(), tt.client, "app-1", tt.deployment, cmd.Out)
+			if tt.wantErr != "" {
+				require.ErrorContains(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestWriteProjectConfigAtProjectRoot(t *testing.T) {
+	root := t.TempDir()
+	sub := filepath.Join(root, "src", "screens")
+	require.NoError(t, os.MkdirAll(sub, 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(root, "package.json"), []byte(`{}`), 0o644))
+	t.Chdir(sub)
+	t.Setenv("CODEPUSH_SERVER_URL", "")
+
+	require.NoError(t, writeProjectConfig("550e8400-e29b-41d4-a716-446655440000", "Staging", cmd.Out))
+
+	data, err := os.ReadFile(filepath.Join(root, config.FileName))
+	require.NoError(t, err)
+	assert.Contains(t, string(data), `"deployment": "Staging"`)
+
+	err = writeProjectConfig("550e8400-e29b-41d4-a716-446655440000", "", cmd.Out)
+	assert.ErrorContains(t, err, "use --force to overwrite")
+}
+
+func TestMigrateExpoUpdatesRejectsProjectWithoutExpoUpdates(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "package.json"), []byte(`{"dependencies":{"expo":"~51.0.0"}}`), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "app.json"), []byte(`{"expo":{"name":"demo"}}`), 0o644))
+	migrateExpoProjectDir = dir
+	t.Cleanup(func() { migrateExpoProjectDir = "" })
+
+	err := migrateExpoUpdatesCmd.RunE(migrateExpoUpdatesCmd, nil)
+	require.Error(t, err)
+	assert.ErrorContains(t, err, "no expo-updates setup found")
+}
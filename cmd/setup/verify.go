@@ -0,0 +1,143 @@
+package setup
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/bitrise-io/bitrise-plugins-codepush-cli/cmd"
+	"github.com/bitrise-io/bitrise-plugins-codepush-cli/internal/cmdutil"
+	"github.com/bitrise-io/bitrise-plugins-codepush-cli/internal/integrate"
+	"github.com/bitrise-io/bitrise-plugins-codepush-cli/internal/output"
+)
+
+var (
+	verifyDeployment    string
+	verifyDeploymentKey string
+	verifyProjectDir    string
+)
+
+var integrateVerifyCmd = &cobra.Command{
+	Use:   "verify",
+	Short: "Check that a project is integrated correctly",
+	Long: `Check an integrated project and report a pass or fail result per check:
+
+  SDK             a CodePush SDK dependency is installed, supports the
+                  project type, and supports code signing if a public key
+                  is configured
+  Deployment key  every configuration (the Expo config, Info.plist,
+                  strings.xml or gradle.properties) has the key of the
+                  deployment given by --deployment or --deployment-key
+  Server URL      every configuration points at the CodePush API URL
+                  resolved from --api-url or --server-url
+  Hermes          hermesc is installed when a native project enables Hermes,
+                  with a warning when iOS and Android disagree
+
+With --deployment, the key is looked up from the API, and a mismatching key
+is reported with the name of the deployment it belongs to. The command fails
+when any check fails.
+
+Examples:
+  codepush integrate verify --deployment Production
+  codepush integrate verify --deployment-key <KEY> --json`,
+	Args: cobra.NoArgs,
+	RunE: func(c *cobra.Command, _ []string) error {
+		out := cmd.Out
+
+		projectDir := verifyProjectDir
+		if projectDir == "" {
+			projectDir = "."
+		}
+		key, deployments, err := resolveVerifyKey(c.Context(), out)
+		if err != nil {
+			return err
+		}
+
+		checks := integrate.Verify(integrate.VerifyOptions{
+			ProjectDir:    projectDir,
+			DeploymentKey: key,
+			ServerURL:     cmdutil.ResolveAPIURL(cmd.APIURL, cmd.ServerURL, out),
+			Deployments:   deployments,
+		})
+		return reportChecks(out, checks)
+	},
+}
+
+// resolveVerifyKey returns --deployment-key, or the key of the deployment
+// named by --deployment along with the names of all deployments by key.
+func resolveVerifyKey(ctx context.Context, out *output.Writer) (string, map[string]string, error) {
+	if verifyDeploymentKey != "" {
+		return verifyDeploymentKey, nil, nil
+	}
+
+	appID, token, err := cmdutil.RequireCredentials(ctx, cmd.AppID, out, cmd.Relogin, cmd.FirstRun)
+	if err != nil {
+		return "", nil, err
+	}
+	client := cmd.NewClient(cmdutil.ResolveAPIURL(cmd.APIURL, cmd.ServerURL, out), token)
+	deploymentID, err := cmdutil.ResolveDeploymentInteractive(ctx, client, appID, verifyDeployment, "CODEPUSH_DEPLOYMENT", out)
+	if err != nil {
+		return "", nil, err
+	}
+	list, err := client.ListDeployments(ctx, appID)
+	if err != nil {
+		return "", nil, fmt.Errorf("listing deployments: %w", err)
+	}
+
+	key := ""
+	names := make(map[string]string, len(list))
+	for _, d := range list {
+		names[d.Key] = d.Name
+		if d.ID == deploymentID {
+			key = d.Key
+		}
+	}
+	if key == "" {
+		return "", nil, fmt.Errorf("deployment %s has no key", deploymentID)
+	}
+	return key, names, nil
+}
+
+// reportChecks prints the checks and fails when any of them failed.
+func reportChecks(out *output.Writer, checks []integrate.Check) error {
+	failed := 0
+	for _, ch := range checks {
+		if ch.Status == integrate.CheckFail {
+			failed++
+		}
+	}
+
+	if cmd.JSONOutput {
+		if err := cmdutil.OutputResult(struct {
+			Passed bool              `json:"passed"`
+			Checks []integrate.Check `json:"checks"`
+		}{failed == 0, checks}); err != nil {
+			return err
+		}
+	} else {
+		rows := make([][]string, len(checks))
+		for i, ch := range checks {
+			rows[i] = []string{ch.Name, strings.ToUpper(string(ch.Status)), ch.Message}
+		}
+		out.Table([]string{"CHECK", "STATUS", "DETAILS"}, rows)
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("%d of %d checks failed", failed, len(checks))
+	}
+	if !cmd.JSONOutput {
+		out.Success("All checks passed")
+	}
+	return nil
+}
+
+func init() {
+	integrateVerifyCmd.Flags().StringVarP(&verifyDeployment, "deployment", "d", "", "deployment whose key the app should use, name or UUID (env: CODEPUSH_DEPLOYMENT)")
+	integrateVerifyCmd.Flags().StringVar(&verifyDeploymentKey, "deployment-key", "", "deployment key the app should use, instead of looking it up from --deployment")
+	integrateVerifyCmd.Flags().StringVar(&verifyProjectDir, "project-dir", "", "project root directory (defaults to current directory)")
+	integrateVerifyCmd.MarkFlagsMutuallyExclusive("deployment", "deployment-key")
+	_ = integrateVerifyCmd.RegisterFlagCompletionFunc("deployment", cmd.CompleteDeployments)
+	integrateCmd.AddCommand(integrateVerifyCmd)
+}
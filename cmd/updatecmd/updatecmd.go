@@ -35,12 +35,12 @@ By default shows the latest update. Use --label to specify a version.`,
 	RunE: func(c *cobra.Command, args []string) error {
 		out := cmd.Out
 
-		appID, token, err := cmdutil.RequireCredentials(cmd.AppID, out)
+		appID, token, err := cmdutil.RequireCredentials(c.Context(), cmd.AppID, out, cmd.Relogin, cmd.FirstRun)
 		if err != nil {
 			return err
 		}
 
-		client := codepush.NewHTTPClient(cmdutil.APIURL(cmdutil.ResolveServerURL(cmd.ServerURL, out)), token, cmd.Version)
+		client := cmd.NewClient(cmdutil.ResolveAPIURL(cmd.APIURL, cmd.ServerURL, out), token)
 
 		var argValue string
 		if len(args) > 0 {
@@ -63,7 +63,7 @@ By default shows the latest update. Use --label to specify a version.`,
 		}
 
 		if cmd.JSONOutput {
-			return cmdutil.OutputJSON(pkg)
+			return cmdutil.OutputResult(pkg)
 		}
 
 		out.Step("Update: %s", pkg.Label)
@@ -74,6 +74,9 @@ By default shows the latest update. Use --label to specify a version.`,
 			{Key: "Disabled", Value: strconv.FormatBool(pkg.Disabled)},
 			{Key: "Rollout", Value: fmt.Sprintf("%.0f%%", pkg.Rollout)},
 		}
+		if pkg.Targeted() {
+			pairs = append(pairs, output.KeyValue{Key: "Targeting", Value: pkg.TargetingSummary()})
+		}
 		if pkg.Description != "" {
 			pairs = append(pairs, output.KeyValue{Key: "Description", Value: pkg.Description})
 		}
@@ -87,6 +90,7 @@ By default shows the latest update. Use --label to specify a version.`,
 		if pkg.CreatedBy != nil && pkg.CreatedBy.Email != "" {
 			pairs = append(pairs, output.KeyValue{Key: "Created by", Value: pkg.CreatedBy.Email})
 		}
+		pairs = append(pairs, cmdutil.ProvenancePairs(pkg.Provenance)...)
 		out.Result(pairs)
 
 		return nil
@@ -103,12 +107,12 @@ By default shows the latest update. Use --label to specify a version.`,
 	RunE: func(c *cobra.Command, args []string) error {
 		out := cmd.Out
 
-		appID, token, err := cmdutil.RequireCredentials(cmd.AppID, out)
+		appID, token, err := cmdutil.RequireCredentials(c.Context(), cmd.AppID, out, cmd.Relogin, cmd.FirstRun)
 		if err != nil {
 			return err
 		}
 
-		client := codepush.NewHTTPClient(cmdutil.APIURL(cmdutil.ResolveServerURL(cmd.ServerURL, out)), token, cmd.Version)
+		client := cmd.NewClient(cmdutil.ResolveAPIURL(cmd.APIURL, cmd.ServerURL, out), token)
 
 		var argValue string
 		if len(args) > 0 {
@@ -131,7 +135,7 @@ By default shows the latest update. Use --label to specify a version.`,
 		}
 
 		if cmd.JSONOutput {
-			return cmdutil.OutputJSON(status)
+			return cmdutil.OutputResult(status)
 		}
 
 		pairs := []output.KeyValue{
@@ -157,7 +161,7 @@ Requires --label to identify the update and --yes to confirm deletion.`,
 	RunE: func(c *cobra.Command, args []string) error {
 		out := cmd.Out
 
-		appID, token, err := cmdutil.RequireCredentials(cmd.AppID, out)
+		appID, token, err := cmdutil.RequireCredentials(c.Context(), cmd.AppID, out, cmd.Relogin, cmd.FirstRun)
 		if err != nil {
 			return err
 		}
@@ -172,14 +176,14 @@ Requires --label to identify the update and --yes to confirm deletion.`,
 			return err
 		}
 
-		client := codepush.NewHTTPClient(cmdutil.APIURL(cmdutil.ResolveServerURL(cmd.ServerURL, out)), token, cmd.Version)
+		client := cmd.NewClient(cmdutil.ResolveAPIURL(cmd.APIURL, cmd.ServerURL, out), token)
 
 		var argValue string
 		if len(args) > 0 {
 			argValue = args[0]
 		}
 
-		deploymentID, err := cmdutil.ResolveDeploymentInteractive(c.Context(), client, appID, argValue, "CODEPUSH_DEPLOYMENT", out)
+		deploymentID, err := cmdutil.ResolveDeploymentForWrite(c.Context(), client, appID, argValue, "CODEPUSH_DEPLOYMENT", out)
 		if err != nil {
 			return err
 		}
@@ -194,7 +198,7 @@ Requires --label to identify the update and --yes to confirm deletion.`,
 		}
 
 		if cmd.JSONOutput {
-			return cmdutil.OutputJSON(struct {
+			return cmdutil.OutputResult(struct {
 				Deleted string `json:"deleted"`
 				Label   string `json:"label"`
 			}{Deleted: updateID, Label: updateLabel})
@@ -213,6 +217,11 @@ func init() {
 	removeCmd.Flags().StringVarP(&updateLabel, "label", "l", "", "release label to delete (required)")
 	removeCmd.Flags().BoolVarP(&updateRemoveYes, "yes", "y", false, "skip confirmation prompt")
 
+	for _, c := range []*cobra.Command{infoCmd, statusCmd, removeCmd} {
+		c.ValidArgsFunction = cmd.CompleteDeploymentArg
+		_ = c.RegisterFlagCompletionFunc("label", cmd.CompleteLabels(""))
+	}
+
 	updateCmd.AddCommand(infoCmd, statusCmd, removeCmd)
 	cmd.RootCmd.AddCommand(updateCmd)
 }
@@ -0,0 +1,261 @@
+// Package acquisition emulates the CodePush acquisition API, the endpoints
+// the SDK calls on device to check for updates, download them, and report
+// their status. It serves a single local release, so an app pointed at it
+// can test an update before anything is uploaded.
+package acquisition
+
+import (
+	"encoding/json"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+)
+
+// Endpoint paths. The SDK uses the v0.1 paths; older SDKs use the legacy
+// camelCase ones.
+const (
+	UpdateCheckPath      = "/v0.1/public/codepush/update_check"
+	ReportDeployPath     = "/v0.1/public/codepush/report_status/deploy"
+	ReportDownloadPath   = "/v0.1/public/codepush/report_status/download"
+	legacyUpdateCheck    = "/updateCheck"
+	legacyReportDeploy   = "/reportStatus/deploy"
+	legacyReportDownload = "/reportStatus/download"
+	// PackagePath serves the package zip of the release.
+	PackagePath = "/package.zip"
+)
+
+// Release is the release the server offers.
+type Release struct {
+	Label       string
+	Description string
+	Mandatory   bool
+	// AppVersion is the binary version the release targets; empty or "*"
+	// targets every version.
+	AppVersion string
+	// PackageHash is the content hash of the package, as the SDK computes
+	// it on device.
+	PackageHash string
+	// PackagePath is the package zip.
+	PackagePath string
+	PackageSize int64
+}
+
+// Event is a request of the SDK the server answered.
+type Event struct {
+	Time time.Time
+	// Kind is "check", "download", "report_download", or "report_deploy".
+	Kind          string
+	DeploymentKey string
+	AppVersion    string
+	// PackageHash is the hash of the package the app runs, for checks.
+	PackageHash string
+	// Available is set for checks that were offered the release.
+	Available bool
+	// Status is the reported status, e.g. "DeploymentSucceeded".
+	Status string
+	Label  string
+}
+
+// Server serves a Release over the acquisition API.
+type Server struct {
+	release Release
+	// OnEvent, when set, is called for every request answered.
+	OnEvent func(Event)
+
+	mu sync.Mutex
+}
+
+// NewServer returns a Server offering release.
+func NewServer(release Release) *Server {
+	return &Server{release: release}
+}
+
+// Handler returns the HTTP handler of the acquisition API.
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET "+UpdateCheckPath, func(w http.ResponseWriter, r *http.Request) {
+		s.updateCheck(w, r, false)
+	})
+	mux.HandleFunc("GET "+legacyUpdateCheck, func(w http.ResponseWriter, r *http.Request) {
+		s.updateCheck(w, r, true)
+	})
+	for _, path := range []string{ReportDeployPath, legacyReportDeploy} {
+		mux.HandleFunc("POST "+path, func(w http.ResponseWriter, r *http.Request) {
+			s.reportStatus(w, r, "report_deploy")
+		})
+	}
+	for _, path := range []string{ReportDownloadPath, legacyReportDownload} {
+		mux.HandleFunc("POST "+path, func(w http.ResponseWriter, r *http.Request) {
+			s.reportStatus(w, r, "report_download")
+		})
+	}
+	mux.HandleFunc("GET "+PackagePath, s.download)
+	return mux
+}
+
+// updateInfo is the update_info object of an update check response.
+type updateInfo struct {
+	DownloadURL            string `json:"download_url,omitempty"`
+	Description            string `json:"description,omitempty"`
+	IsAvailable            bool   `json:"is_available"`
+	IsDisabled             bool   `json:"is_disabled"`
+	IsMandatory            bool   `json:"is_mandatory"`
+	TargetBinaryRange      string `json:"target_binary_range"`
+	Label                  string `json:"label,omitempty"`
+	PackageHash            string `json:"package_hash,omitempty"`
+	PackageSize            int64  `json:"package_size,omitempty"`
+	ShouldRunBinaryVersion bool   `json:"should_run_binary_version"`
+	UpdateAppVersion       bool   `json:"update_app_version"`
+}
+
+// legacyUpdateInfo is the updateInfo object of a legacy update check
+// response.
+type legacyUpdateInfo struct {
+	DownloadURL            string `json:"downloadURL,omitempty"`
+	Description            string `json:"description,omitempty"`
+	IsAvailable            bool   `json:"isAvailable"`
+	IsDisabled             bool   `json:"isDisabled"`
+	IsMandatory            bool   `json:"isMandatory"`
+	AppVersion             string `json:"appVersion"`
+	Label                  string `json:"label,omitempty"`
+	PackageHash            string `json:"packageHash,omitempty"`
+	PackageSize            int64  `json:"packageSize,omitempty"`
+	ShouldRunBinaryVersion bool   `json:"shouldRunBinaryVersion"`
+	UpdateAppVersion       bool   `json:"updateAppVersion"`
+}
+
+// updateCheck offers the release unless the app already runs it or its
+// binary version is not targeted.
+func (s *Server) updateCheck(w http.ResponseWriter, r *http.Request, legacy bool) {
+	q := r.URL.Query()
+	param := func(name, legacyName string) string {
+		if legacy {
+			return q.Get(legacyName)
+		}
+		return q.Get(name)
+	}
+	appVersion := param("app_version", "appVersion")
+	packageHash := param("package_hash", "packageHash")
+	if appVersion == "" {
+		http.Error(w, "app_version is required", http.StatusBadRequest)
+		return
+	}
+
+	targetRange := s.release.AppVersion
+	if targetRange == "" {
+		targetRange = "*"
+	}
+	info := updateInfo{TargetBinaryRange: targetRange}
+	switch {
+	case targetRange != "*" && targetRange != appVersion:
+		// The app must update its binary to get the release.
+		info.UpdateAppVersion = true
+	case packageHash == s.release.PackageHash:
+		// The app already runs the release.
+	default:
+		info = updateInfo{
+			DownloadURL:       "http://" + r.Host + PackagePath,
+			Description:       s.release.Description,
+			IsAvailable:       true,
+			IsMandatory:       s.release.Mandatory,
+			TargetBinaryRange: targetRange,
+			Label:             s.release.Label,
+			PackageHash:       s.release.PackageHash,
+			PackageSize:       s.release.PackageSize,
+		}
+	}
+
+	s.emit(Event{
+		Kind:          "check",
+		DeploymentKey: param("deployment_key", "deploymentKey"),
+		AppVersion:    appVersion,
+		PackageHash:   packageHash,
+		Available:     info.IsAvailable,
+		Label:         param("label", "label"),
+	})
+
+	if legacy {
+		writeJSON(w, map[string]legacyUpdateInfo{"updateInfo": {
+			DownloadURL:            info.DownloadURL,
+			Description:            info.Description,
+			IsAvailable:            info.IsAvailable,
+			IsDisabled:             info.IsDisabled,
+			IsMandatory:            info.IsMandatory,
+			AppVersion:             info.TargetBinaryRange,
+			Label:                  info.Label,
+			PackageHash:            info.PackageHash,
+			PackageSize:            info.PackageSize,
+			ShouldRunBinaryVersion: info.ShouldRunBinaryVersion,
+			UpdateAppVersion:       info.UpdateAppVersion,
+		}})
+		return
+	}
+	writeJSON(w, map[string]updateInfo{"update_info": info})
+}
+
+// statusReport is the body of a status report. The SDK sends the keys in
+// snake_case to the v0.1 endpoints and in camelCase to the legacy ones.
+type statusReport struct {
+	DeploymentKey       string `json:"deployment_key"`
+	LegacyDeploymentKey string `json:"deploymentKey"`
+	AppVersion          string `json:"app_version"`
+	LegacyAppVersion    string `json:"appVersion"`
+	Label               string `json:"label"`
+	Status              string `json:"status"`
+}
+
+func (s *Server) reportStatus(w http.ResponseWriter, r *http.Request, kind string) {
+	var report statusReport
+	if err := json.NewDecoder(r.Body).Decode(&report); err != nil {
+		http.Error(w, "invalid status report: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	s.emit(Event{
+		Kind:          kind,
+		DeploymentKey: firstNonEmpty(report.DeploymentKey, report.LegacyDeploymentKey),
+		AppVersion:    firstNonEmpty(report.AppVersion, report.LegacyAppVersion),
+		Status:        report.Status,
+		Label:         report.Label,
+	})
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write([]byte("OK"))
+}
+
+func (s *Server) download(w http.ResponseWriter, r *http.Request) {
+	f, err := os.Open(s.release.PackagePath)
+	if err != nil {
+		http.Error(w, "package not available", http.StatusNotFound)
+		return
+	}
+	defer f.Close()
+
+	s.emit(Event{Kind: "download", Label: s.release.Label})
+	w.Header().Set("Content-Type", "application/zip")
+	http.ServeContent(w, r, "package.zip", time.Time{}, f)
+}
+
+// emit passes e to OnEvent, one event at a time.
+func (s *Server) emit(e Event) {
+	if s.OnEvent == nil {
+		return
+	}
+	e.Time = time.Now()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.OnEvent(e)
+}
+
+func writeJSON(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
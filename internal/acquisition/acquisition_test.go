@@ -0,0 +1,147 @@
+package acquisition
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestServer(t *testing.T, release Release) (*httptest.Server, *[]Event) {
+	t.Helper()
+	if release.PackagePath == "" {
+		release.PackagePath = filepath.Join(t.TempDir(), "package.zip")
+		require.NoError(t, os.WriteFile(release.PackagePath, []byte("zip content"), 0o644))
+	}
+	s := NewServer(release)
+	var events []Event
+	s.OnEvent = func(e Event) { events = append(events, e) }
+	ts := httptest.NewServer(s.Handler())
+	t.Cleanup(ts.Close)
+	return ts, &events
+}
+
+func getJSON(t *testing.T, url string, v any) int {
+	t.Helper()
+	resp, err := http.Get(url)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusOK {
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(v))
+	}
+	return resp.StatusCode
+}
+
+func TestUpdateCheck(t *testing.T) {
+	release := Release{Label: "preview", Description: "Fix login", Mandatory: true, PackageHash: "abc123", PackageSize: 11}
+
+	tests := []struct {
+		name          string
+		appVersion    string
+		query         string
+		wantAvailable bool
+		wantUpdateApp bool
+	}{
+		{name: "offers the release", query: "app_version=1.0.0&package_hash=old", wantAvailable: true},
+		{name: "offers the release to an app without updates", query: "app_version=1.0.0", wantAvailable: true},
+		{name: "app already runs the release", query: "app_version=1.0.0&package_hash=abc123"},
+		{name: "targeted app version", appVersion: "1.0.0", query: "app_version=1.0.0", wantAvailable: true},
+		{name: "other app version", appVersion: "2.0.0", query: "app_version=1.0.0", wantUpdateApp: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := release
+			r.AppVersion = tt.appVersion
+			ts, events := newTestServer(t, r)
+
+			var resp struct {
+				UpdateInfo map[string]any `json:"update_info"`
+			}
+			require.Equal(t, http.StatusOK, getJSON(t, ts.URL+UpdateCheckPath+"?deployment_key=key&"+tt.query, &resp))
+
+			info := resp.UpdateInfo
+			assert.Equal(t, tt.wantAvailable, info["is_available"])
+			assert.Equal(t, tt.wantUpdateApp, info["update_app_version"])
+			if tt.wantAvailable {
+				assert.Equal(t, ts.URL+PackagePath, info["download_url"])
+				assert.Equal(t, "preview", info["label"])
+				assert.Equal(t, "abc123", info["package_hash"])
+				assert.Equal(t, true, info["is_mandatory"])
+				assert.Equal(t, "Fix login", info["description"])
+				assert.InDelta(t, 11, info["package_size"], 0)
+			} else {
+				assert.NotContains(t, info, "download_url")
+			}
+
+			require.Len(t, *events, 1)
+			assert.Equal(t, "check", (*events)[0].Kind)
+			assert.Equal(t, "key", (*events)[0].DeploymentKey)
+			assert.Equal(t, tt.wantAvailable, (*events)[0].Available)
+		})
+	}
+
+	t.Run("requires the app version", func(t *testing.T) {
+		ts, _ := newTestServer(t, release)
+		assert.Equal(t, http.StatusBadRequest, getJSON(t, ts.URL+UpdateCheckPath, nil))
+	})
+
+	t.Run("legacy endpoint", func(t *testing.T) {
+		ts, _ := newTestServer(t, release)
+		var resp struct {
+			UpdateInfo map[string]any `json:"updateInfo"`
+		}
+		require.Equal(t, http.StatusOK, getJSON(t, ts.URL+"/updateCheck?deploymentKey=key&appVersion=1.0.0&packageHash=old", &resp))
+		assert.Equal(t, true, resp.UpdateInfo["isAvailable"])
+		assert.Equal(t, ts.URL+PackagePath, resp.UpdateInfo["downloadURL"])
+		assert.Equal(t, "abc123", resp.UpdateInfo["packageHash"])
+	})
+}
+
+func TestReportStatus(t *testing.T) {
+	ts, events := newTestServer(t, Release{Label: "preview"})
+
+	resp, err := http.Post(ts.URL+ReportDeployPath, "application/json",
+		strings.NewReader(`{"deployment_key": "key", "app_version": "1.0.0", "label": "preview", "status": "DeploymentSucceeded"}`))
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	resp, err = http.Post(ts.URL+"/reportStatus/download", "application/json", strings.NewReader(`{"deploymentKey": "key", "label": "preview"}`))
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	resp, err = http.Post(ts.URL+ReportDeployPath, "application/json", strings.NewReader(`not json`))
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+	require.Len(t, *events, 2)
+	assert.Equal(t, Event{Time: (*events)[0].Time, Kind: "report_deploy", DeploymentKey: "key", AppVersion: "1.0.0", Label: "preview", Status: "DeploymentSucceeded"}, (*events)[0])
+	assert.Equal(t, "report_download", (*events)[1].Kind)
+	assert.Equal(t, "key", (*events)[1].DeploymentKey)
+}
+
+func TestDownload(t *testing.T) {
+	ts, events := newTestServer(t, Release{Label: "preview"})
+
+	resp, err := http.Get(ts.URL + PackagePath)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "application/zip", resp.Header.Get("Content-Type"))
+	assert.Equal(t, "zip content", string(body))
+	require.Len(t, *events, 1)
+	assert.Equal(t, "download", (*events)[0].Kind)
+}
@@ -0,0 +1,138 @@
+// Package appcatalog caches the connected apps a token can access, per
+// Bitrise workspace, so app pickers and listings of accounts with many apps
+// do not fetch the full list on every run. It also ranks apps against a
+// search query, so an app can be found by a fragment of its name instead
+// of its UUID.
+package appcatalog
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/bitrise-io/bitrise-plugins-codepush-cli/internal/codepush"
+)
+
+// DefaultTTL is how long a cached app list is used before it is fetched
+// again.
+const DefaultTTL = time.Hour
+
+// Lister lists the apps of a workspace, or of all workspaces for an empty
+// workspace.
+type Lister interface {
+	ListWorkspaceApps(ctx context.Context, workspace string) ([]codepush.App, error)
+}
+
+// Catalog is an app list cache rooted at Dir. Entries older than TTL are
+// refetched; a TTL of 0 disables the cache.
+type Catalog struct {
+	Dir string
+	TTL time.Duration
+
+	// now allows tests to control timestamps.
+	now func() time.Time
+}
+
+// New returns a catalog rooted at dir.
+func New(dir string, ttl time.Duration) *Catalog {
+	return &Catalog{Dir: dir, TTL: ttl, now: time.Now}
+}
+
+// DefaultDir returns the catalog directory under the user cache directory.
+func DefaultDir() (string, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", fmt.Errorf("determining cache directory: %w", err)
+	}
+	return filepath.Join(dir, "codepush", "apps"), nil
+}
+
+// entry is a cached app list.
+type entry struct {
+	FetchedAt time.Time      `json:"fetched_at"`
+	Workspace string         `json:"workspace,omitempty"`
+	Apps      []codepush.App `json:"apps"`
+}
+
+// Apps returns the apps of workspace that the token of account can access.
+// account identifies the server and token the list was fetched with, so
+// tokens with different access never share an entry; it is only stored
+// hashed. A fresh cached list is returned unless refresh is set; otherwise
+// the list is fetched with client and cached. cached reports whether the
+// list came from the cache.
+func (c *Catalog) Apps(ctx context.Context, client Lister, account, workspace string, refresh bool) (apps []codepush.App, cached bool, err error) {
+	path := c.entryPath(account, workspace)
+	if c.TTL > 0 && !refresh {
+		if e, err := readEntry(path); err == nil && c.now().Sub(e.FetchedAt) < c.TTL {
+			return e.Apps, true, nil
+		}
+	}
+
+	apps, err = client.ListWorkspaceApps(ctx, workspace)
+	if err != nil {
+		return nil, false, err
+	}
+	if c.TTL > 0 {
+		if err := writeEntry(path, entry{FetchedAt: c.now().UTC(), Workspace: workspace, Apps: apps}); err != nil {
+			return apps, false, fmt.Errorf("caching app list: %w", err)
+		}
+	}
+	return apps, false, nil
+}
+
+// Clear removes all cached app lists.
+func (c *Catalog) Clear() error {
+	if err := os.RemoveAll(c.Dir); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("clearing app cache: %w", err)
+	}
+	return nil
+}
+
+func (c *Catalog) entryPath(account, workspace string) string {
+	sum := sha256.Sum256([]byte(account + "\x00" + workspace))
+	return filepath.Join(c.Dir, hex.EncodeToString(sum[:12])+".json")
+}
+
+func readEntry(path string) (*entry, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var e entry
+	if err := json.Unmarshal(data, &e); err != nil {
+		return nil, err
+	}
+	return &e, nil
+}
+
+// writeEntry writes e through a temporary file, so concurrent runs never
+// read a partial entry.
+func writeEntry(path string, e entry) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return err
+	}
+	data, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer func() { _ = os.Remove(tmp.Name()) }()
+	if _, err := tmp.Write(data); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
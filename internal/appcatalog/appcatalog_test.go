@@ -0,0 +1,130 @@
+package appcatalog
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/bitrise-io/bitrise-plugins-codepush-cli/internal/codepush"
+)
+
+type fakeLister struct {
+	apps      map[string][]codepush.App
+	err       error
+	calls     int
+	workspace string
+}
+
+func (f *fakeLister) ListWorkspaceApps(_ context.Context, workspace string) ([]codepush.App, error) {
+	f.calls++
+	f.workspace = workspace
+	return f.apps[workspace], f.err
+}
+
+func newTestCatalog(t *testing.T, ttl time.Duration) (*Catalog, *time.Time) {
+	t.Helper()
+	now := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+	c := New(t.TempDir(), ttl)
+	c.now = func() time.Time { return now }
+	return c, &now
+}
+
+func TestCatalogApps(t *testing.T) {
+	ctx := context.Background()
+	lister := &fakeLister{apps: map[string][]codepush.App{
+		"":     {{ID: "app-1", Name: "Shop"}, {ID: "app-2", Name: "Bank"}},
+		"acme": {{ID: "app-1", Name: "Shop"}},
+	}}
+
+	t.Run("caches until the TTL passes", func(t *testing.T) {
+		c, now := newTestCatalog(t, time.Hour)
+		lister.calls = 0
+
+		apps, cached, err := c.Apps(ctx, lister, "token-a", "", false)
+		require.NoError(t, err)
+		assert.False(t, cached)
+		assert.Len(t, apps, 2)
+
+		apps, cached, err = c.Apps(ctx, lister, "token-a", "", false)
+		require.NoError(t, err)
+		assert.True(t, cached)
+		assert.Len(t, apps, 2)
+		assert.Equal(t, 1, lister.calls)
+
+		*now = now.Add(time.Hour)
+		_, cached, err = c.Apps(ctx, lister, "token-a", "", false)
+		require.NoError(t, err)
+		assert.False(t, cached)
+		assert.Equal(t, 2, lister.calls)
+	})
+
+	t.Run("keeps accounts and workspaces apart", func(t *testing.T) {
+		c, _ := newTestCatalog(t, time.Hour)
+		lister.calls = 0
+
+		_, _, err := c.Apps(ctx, lister, "token-a", "", false)
+		require.NoError(t, err)
+		_, cached, err := c.Apps(ctx, lister, "token-b", "", false)
+		require.NoError(t, err)
+		assert.False(t, cached)
+
+		apps, cached, err := c.Apps(ctx, lister, "token-a", "acme", false)
+		require.NoError(t, err)
+		assert.False(t, cached)
+		assert.Equal(t, "acme", lister.workspace)
+		assert.Len(t, apps, 1)
+	})
+
+	t.Run("refresh bypasses the cache", func(t *testing.T) {
+		c, _ := newTestCatalog(t, time.Hour)
+		lister.calls = 0
+
+		_, _, err := c.Apps(ctx, lister, "token-a", "", false)
+		require.NoError(t, err)
+		_, cached, err := c.Apps(ctx, lister, "token-a", "", true)
+		require.NoError(t, err)
+		assert.False(t, cached)
+		assert.Equal(t, 2, lister.calls)
+	})
+
+	t.Run("zero TTL disables the cache", func(t *testing.T) {
+		c, _ := newTestCatalog(t, 0)
+		lister.calls = 0
+
+		_, _, err := c.Apps(ctx, lister, "token-a", "", false)
+		require.NoError(t, err)
+		_, cached, err := c.Apps(ctx, lister, "token-a", "", false)
+		require.NoError(t, err)
+		assert.False(t, cached)
+		assert.Equal(t, 2, lister.calls)
+	})
+
+	t.Run("does not cache errors", func(t *testing.T) {
+		c, _ := newTestCatalog(t, time.Hour)
+		failing := &fakeLister{err: errors.New("HTTP 500")}
+
+		_, _, err := c.Apps(ctx, failing, "token-a", "", false)
+		require.EqualError(t, err, "HTTP 500")
+		_, _, err = c.Apps(ctx, failing, "token-a", "", false)
+		require.Error(t, err)
+		assert.Equal(t, 2, failing.calls)
+	})
+}
+
+func TestCatalogClear(t *testing.T) {
+	ctx := context.Background()
+	c, _ := newTestCatalog(t, time.Hour)
+	lister := &fakeLister{apps: map[string][]codepush.App{"": {{ID: "app-1"}}}}
+
+	_, _, err := c.Apps(ctx, lister, "token-a", "", false)
+	require.NoError(t, err)
+	require.NoError(t, c.Clear())
+
+	_, cached, err := c.Apps(ctx, lister, "token-a", "", false)
+	require.NoError(t, err)
+	assert.False(t, cached)
+}
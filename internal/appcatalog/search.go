@@ -0,0 +1,79 @@
+package appcatalog
+
+import (
+	"slices"
+	"strings"
+	"unicode"
+
+	"github.com/bitrise-io/bitrise-plugins-codepush-cli/internal/codepush"
+)
+
+// Search returns the apps matching query, best matches first. An app
+// matches when its name contains the characters of the query in order,
+// ignoring case and spaces, so "shpstg" finds "Shop Staging", or when its
+// ID starts with the query. Names that contain the query as is rank first,
+// then matches with longer runs of consecutive characters and more matches
+// at word starts. An empty query returns all apps.
+func Search(apps []codepush.App, query string) []codepush.App {
+	query = strings.ToLower(strings.Join(strings.Fields(query), ""))
+	if query == "" {
+		return apps
+	}
+
+	type match struct {
+		app   codepush.App
+		score int
+	}
+	var matches []match
+	for _, a := range apps {
+		score, ok := matchScore(a.Name, query)
+		if strings.HasPrefix(strings.ToLower(a.ID), query) {
+			score, ok = max(score, 1000), true
+		}
+		if ok {
+			matches = append(matches, match{app: a, score: score})
+		}
+	}
+
+	slices.SortStableFunc(matches, func(a, b match) int {
+		if a.score != b.score {
+			return b.score - a.score
+		}
+		return strings.Compare(strings.ToLower(a.app.Name), strings.ToLower(b.app.Name))
+	})
+	result := make([]codepush.App, len(matches))
+	for i, m := range matches {
+		result[i] = m.app
+	}
+	return result
+}
+
+// matchScore scores name against a lowercase query without spaces, and
+// reports whether every query character was found in order.
+func matchScore(name, query string) (int, bool) {
+	lower := []rune(strings.ToLower(name))
+	q := []rune(query)
+
+	score := 0
+	if strings.Contains(strings.ToLower(name), query) {
+		score += 100
+	}
+	qi, run := 0, 0
+	for i, r := range lower {
+		if qi == len(q) {
+			break
+		}
+		if unicode.IsSpace(r) || r != q[qi] {
+			run = 0
+			continue
+		}
+		score++
+		run++
+		score += 2 * (run - 1)
+		if i == 0 || !unicode.IsLetter(lower[i-1]) && !unicode.IsDigit(lower[i-1]) {
+			score += 5
+		}
+		qi++
+	}
+	return score, qi == len(q)
+}
@@ -0,0 +1,42 @@
+package appcatalog
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/bitrise-io/bitrise-plugins-codepush-cli/internal/codepush"
+)
+
+func TestSearch(t *testing.T) {
+	apps := []codepush.App{
+		{ID: "9f1c0000-0000-0000-0000-000000000001", Name: "Shop Staging"},
+		{ID: "2b7e0000-0000-0000-0000-000000000002", Name: "Shop"},
+		{ID: "4d3a0000-0000-0000-0000-000000000003", Name: "Bank Android"},
+		{ID: "7c550000-0000-0000-0000-000000000004", Name: "Workshop Tools"},
+	}
+
+	tests := []struct {
+		name  string
+		query string
+		want  []string
+	}{
+		{name: "empty query", query: " ", want: []string{"Shop Staging", "Shop", "Bank Android", "Workshop Tools"}},
+		{name: "substring ranks first", query: "shop", want: []string{"Shop", "Shop Staging", "Workshop Tools"}},
+		{name: "case and spaces ignored", query: "SHOP st", want: []string{"Shop Staging"}},
+		{name: "subsequence", query: "shpstg", want: []string{"Shop Staging"}},
+		{name: "word starts", query: "ba", want: []string{"Bank Android"}},
+		{name: "ID prefix", query: "4d3a", want: []string{"Bank Android"}},
+		{name: "no match", query: "xyz"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			for _, a := range Search(apps, tt.query) {
+				got = append(got, a.Name)
+			}
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
@@ -0,0 +1,205 @@
+// Package appcenter reads CodePush deployments and releases from App Center,
+// either from its API or from an exported JSON file, for migrating them to
+// Bitrise.
+package appcenter
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// DefaultBaseURL is the App Center API.
+const DefaultBaseURL = "https://api.appcenter.ms"
+
+// TokenEnvKey is the environment variable holding an App Center API token,
+// the same one the appcenter CLI reads.
+const TokenEnvKey = "APPCENTER_ACCESS_TOKEN"
+
+// Deployment is an App Center CodePush deployment.
+type Deployment struct {
+	Name string `json:"name"`
+	Key  string `json:"key"`
+	// Releases are in release order, oldest first.
+	Releases []Release `json:"releases,omitempty"`
+}
+
+// Release is a CodePush release of an App Center deployment.
+type Release struct {
+	Label             string `json:"label"`
+	TargetBinaryRange string `json:"targetBinaryRange"`
+	Description       string `json:"description,omitempty"`
+	IsMandatory       bool   `json:"isMandatory"`
+	IsDisabled        bool   `json:"isDisabled"`
+	// Rollout is the rollout percentage, nil for a complete rollout.
+	Rollout *int `json:"rollout,omitempty"`
+	// BlobURL is where App Center serves the package.
+	BlobURL string `json:"blobUrl,omitempty"`
+	// Package is the path of a downloaded package in an export, relative
+	// to the export file. It takes precedence over BlobURL.
+	Package string `json:"package,omitempty"`
+}
+
+// Source provides the deployments to migrate and their release packages.
+type Source interface {
+	Deployments(ctx context.Context) ([]Deployment, error)
+	// OpenPackage opens the package zip of a release.
+	OpenPackage(ctx context.Context, r Release) (io.ReadCloser, error)
+}
+
+// Client reads an App Center app through the API.
+type Client struct {
+	BaseURL string
+	token   string
+	owner   string
+	app     string
+	client  *http.Client
+}
+
+// NewClient creates a client for the app named "owner/app" that sends its
+// requests with hc.
+func NewClient(baseURL, token, app string, hc *http.Client) (*Client, error) {
+	owner, name, ok := strings.Cut(app, "/")
+	if !ok || owner == "" || name == "" || strings.Contains(name, "/") {
+		return nil, fmt.Errorf("invalid App Center app %q: use owner/app", app)
+	}
+	if token == "" {
+		return nil, fmt.Errorf("an App Center API token is required: set --appcenter-token or %s", TokenEnvKey)
+	}
+	return &Client{BaseURL: strings.TrimRight(baseURL, "/"), token: token, owner: owner, app: name, client: hc}, nil
+}
+
+// Deployments returns the app's deployments with their release history.
+func (c *Client) Deployments(ctx context.Context) ([]Deployment, error) {
+	var deployments []Deployment
+	if err := c.get(ctx, c.appPath("deployments"), &deployments); err != nil {
+		return nil, fmt.Errorf("listing deployments: %w", err)
+	}
+	for i, d := range deployments {
+		if err := c.get(ctx, c.appPath("deployments", d.Name, "releases"), &deployments[i].Releases); err != nil {
+			return nil, fmt.Errorf("listing releases of %s: %w", d.Name, err)
+		}
+	}
+	return deployments, nil
+}
+
+// OpenPackage downloads the package of r from its blob URL.
+func (c *Client) OpenPackage(ctx context.Context, r Release) (io.ReadCloser, error) {
+	return download(ctx, c.client, r)
+}
+
+func (c *Client) appPath(elems ...string) string {
+	path := "/v0.1/apps/" + url.PathEscape(c.owner) + "/" + url.PathEscape(c.app)
+	for _, e := range elems {
+		path += "/" + url.PathEscape(e)
+	}
+	return path
+}
+
+func (c *Client) get(ctx context.Context, path string, v any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.BaseURL+path, nil)
+	if err != nil {
+		return fmt.Errorf("creating request: %w", err)
+	}
+	req.Header.Set("X-API-Token", c.token)
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("sending request to %s: %w", path, err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		return fmt.Errorf("App Center API returned HTTP %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return fmt.Errorf("decoding response: %w", err)
+	}
+	return nil
+}
+
+func download(ctx context.Context, client *http.Client, r Release) (io.ReadCloser, error) {
+	if r.BlobURL == "" {
+		return nil, fmt.Errorf("release %s has no package URL", r.Label)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, r.BlobURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("creating download request: %w", err)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("downloading %s: %w", r.Label, err)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		_ = resp.Body.Close()
+		return nil, fmt.Errorf("downloading %s: HTTP %d", r.Label, resp.StatusCode)
+	}
+	return resp.Body, nil
+}
+
+// Export is an App Center app exported to a JSON file, for migrating when
+// the API is not available:
+//
+//	{"deployments": [{"name": "Production", "key": "...", "releases": [...]}]}
+//
+// Releases have the fields of the App Center API, and a release's package
+// is read from its "package" path, relative to the file, or downloaded from
+// its "blobUrl".
+type Export struct {
+	Items  []Deployment `json:"deployments"`
+	dir    string
+	client *http.Client
+}
+
+// LoadExport reads an export file. Packages that are not in the export are
+// downloaded with hc.
+func LoadExport(path string, hc *http.Client) (*Export, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading export: %w", err)
+	}
+	var e Export
+	if err := json.Unmarshal(data, &e); err != nil {
+		return nil, fmt.Errorf("parsing export %s: %w", path, err)
+	}
+	if len(e.Items) == 0 {
+		return nil, fmt.Errorf("export %s has no deployments", path)
+	}
+	for _, d := range e.Items {
+		if d.Name == "" {
+			return nil, errors.New("export has a deployment without a name")
+		}
+	}
+	e.dir = filepath.Dir(path)
+	e.client = hc
+	return &e, nil
+}
+
+// Deployments returns the exported deployments.
+func (e *Export) Deployments(context.Context) ([]Deployment, error) {
+	return e.Items, nil
+}
+
+// OpenPackage opens the exported package of r, or downloads it.
+func (e *Export) OpenPackage(ctx context.Context, r Release) (io.ReadCloser, error) {
+	if r.Package == "" {
+		return download(ctx, e.client, r)
+	}
+	path := r.Package
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(e.dir, path)
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("opening package of %s: %w", r.Label, err)
+	}
+	return f, nil
+}
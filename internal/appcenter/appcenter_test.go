@@ -0,0 +1,123 @@
+package appcenter
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewClient(t *testing.T) {
+	tests := []struct {
+		name    string
+		token   string
+		app     string
+		wantErr string
+	}{
+		{name: "valid", token: "tok", app: "my-org/my-app"},
+		{name: "missing owner", token: "tok", app: "my-app", wantErr: "use owner/app"},
+		{name: "extra segment", token: "tok", app: "a/b/c", wantErr: "use owner/app"},
+		{name: "missing token", app: "my-org/my-app", wantErr: TokenEnvKey},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewClient(DefaultBaseURL, tt.token, tt.app, http.DefaultClient)
+			if tt.wantErr != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+		})
+	}
+}
+
+func TestClientDeployments(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "secret", r.Header.Get("X-API-Token"))
+		switch r.URL.Path {
+		case "/v0.1/apps/my-org/my-app/deployments":
+			_ = json.NewEncoder(w).Encode([]Deployment{{Name: "Production", Key: "prod-key"}})
+		case "/v0.1/apps/my-org/my-app/deployments/Production/releases":
+			_, _ = io.WriteString(w, `[{"label":"v1","targetBinaryRange":"1.0.0","isMandatory":true,"rollout":50,"blobUrl":"https://blob/v1"}]`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	client, err := NewClient(server.URL, "secret", "my-org/my-app", server.Client())
+	require.NoError(t, err)
+	deployments, err := client.Deployments(context.Background())
+	require.NoError(t, err)
+
+	require.Len(t, deployments, 1)
+	assert.Equal(t, "prod-key", deployments[0].Key)
+	require.Len(t, deployments[0].Releases, 1)
+	r := deployments[0].Releases[0]
+	assert.Equal(t, "1.0.0", r.TargetBinaryRange)
+	assert.True(t, r.IsMandatory)
+	require.NotNil(t, r.Rollout)
+	assert.Equal(t, 50, *r.Rollout)
+}
+
+func TestClientDeploymentsHTTPError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+	}))
+	defer server.Close()
+
+	client, err := NewClient(server.URL, "secret", "my-org/my-app", server.Client())
+	require.NoError(t, err)
+	_, err = client.Deployments(context.Background())
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "HTTP 401")
+}
+
+func TestLoadExport(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "v1.zip"), []byte("zip"), 0o644))
+	path := filepath.Join(dir, "export.json")
+	require.NoError(t, os.WriteFile(path, []byte(`{"deployments":[{"name":"Staging","key":"stg","releases":[{"label":"v1","package":"v1.zip"}]}]}`), 0o644))
+
+	export, err := LoadExport(path, http.DefaultClient)
+	require.NoError(t, err)
+	deployments, err := export.Deployments(context.Background())
+	require.NoError(t, err)
+	require.Len(t, deployments, 1)
+
+	rc, err := export.OpenPackage(context.Background(), deployments[0].Releases[0])
+	require.NoError(t, err)
+	defer func() { _ = rc.Close() }()
+	data, err := io.ReadAll(rc)
+	require.NoError(t, err)
+	assert.Equal(t, "zip", string(data))
+}
+
+func TestLoadExportInvalid(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		wantErr string
+	}{
+		{name: "malformed", content: "{", wantErr: "parsing export"},
+		{name: "empty", content: `{"deployments":[]}`, wantErr: "no deployments"},
+		{name: "unnamed deployment", content: `{"deployments":[{"key":"k"}]}`, wantErr: "without a name"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "export.json")
+			require.NoError(t, os.WriteFile(path, []byte(tt.content), 0o644))
+			_, err := LoadExport(path, http.DefaultClient)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.wantErr)
+		})
+	}
+}
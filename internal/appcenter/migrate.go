@@ -0,0 +1,224 @@
+package appcenter
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/bitrise-io/bitrise-plugins-codepush-cli/internal/codepush"
+	"github.com/bitrise-io/bitrise-plugins-codepush-cli/internal/output"
+	"github.com/bitrise-io/bitrise-plugins-codepush-cli/internal/workspace"
+	ziputil "github.com/bitrise-io/bitrise-plugins-codepush-cli/internal/zip"
+)
+
+// MigrateOptions configures a migration to a Bitrise connected app.
+type MigrateOptions struct {
+	AppID string
+	Token string
+	// Releases is how many of the latest releases of each deployment are
+	// copied. Zero copies none.
+	Releases int
+	// KeepKeys creates the deployments with their App Center keys, so apps
+	// already in the field keep receiving updates.
+	KeepKeys bool
+	// DryRun reports what would be done without changing anything.
+	DryRun bool
+	// PollConfig is how long to wait for each copied release to be
+	// processed.
+	PollConfig codepush.PollConfig
+	// Workspace holds the downloaded packages; nil downloads them to the
+	// system temp directory.
+	Workspace *workspace.Workspace
+}
+
+// MigratedRelease is an App Center release copied to Bitrise.
+type MigratedRelease struct {
+	SourceLabel string `json:"source_label"`
+	// Label and UpdateID identify the copy; both are empty in a dry run and
+	// when the copy was skipped as identical to the previous release.
+	Label    string `json:"label,omitempty"`
+	UpdateID string `json:"package_id,omitempty"`
+	Skipped  bool   `json:"skipped,omitempty"`
+}
+
+// MigratedDeployment is an App Center deployment and its Bitrise
+// counterpart.
+type MigratedDeployment struct {
+	Name         string `json:"name"`
+	AppCenterKey string `json:"appcenter_key"`
+	// ID and Key are empty for a deployment a dry run would create,
+	// except that Key is known with KeepKeys.
+	ID       string            `json:"id,omitempty"`
+	Key      string            `json:"key,omitempty"`
+	Created  bool              `json:"created"`
+	Releases []MigratedRelease `json:"releases"`
+}
+
+// MigrateResult is the output of Migrate.
+type MigrateResult struct {
+	DryRun      bool                 `json:"dry_run"`
+	Deployments []MigratedDeployment `json:"deployments"`
+}
+
+// KeyMap maps each App Center deployment key to the key of its Bitrise
+// deployment, for the deployments whose key changes.
+func (r *MigrateResult) KeyMap() map[string]string {
+	keys := make(map[string]string)
+	for _, d := range r.Deployments {
+		if d.AppCenterKey != "" && d.Key != "" && d.AppCenterKey != d.Key {
+			keys[d.AppCenterKey] = d.Key
+		}
+	}
+	return keys
+}
+
+// Migrate recreates the deployments of source in a Bitrise connected app,
+// reusing deployments that already exist with the same name, and copies
+// the latest opts.Releases releases of each by re-pushing their packages
+// with the same app version, description, flags, and rollout. Releases are
+// copied oldest first, so the newest is live after the migration.
+func Migrate(ctx context.Context, source Source, client codepush.Client, opts MigrateOptions, out *output.Writer) (*MigrateResult, error) {
+	deployments, err := source.Deployments(ctx)
+	if err != nil {
+		return nil, err
+	}
+	existing, err := client.ListDeployments(ctx, opts.AppID)
+	if err != nil {
+		return nil, fmt.Errorf("listing deployments: %w", err)
+	}
+	byName := make(map[string]codepush.Deployment, len(existing))
+	for _, d := range existing {
+		byName[d.Name] = d
+	}
+
+	result := &MigrateResult{DryRun: opts.DryRun}
+	for _, src := range deployments {
+		migrated, err := migrateDeployment(ctx, source, client, src, byName, opts, out)
+		if migrated != nil {
+			result.Deployments = append(result.Deployments, *migrated)
+		}
+		if err != nil {
+			return result, err
+		}
+	}
+	return result, nil
+}
+
+func migrateDeployment(ctx context.Context, source Source, client codepush.Client, src Deployment, byName map[string]codepush.Deployment, opts MigrateOptions, out *output.Writer) (*MigratedDeployment, error) {
+	m := &MigratedDeployment{Name: src.Name, AppCenterKey: src.Key, Releases: []MigratedRelease{}}
+	if dep, ok := byName[src.Name]; ok {
+		m.ID, m.Key = dep.ID, dep.Key
+		if opts.KeepKeys && dep.Key != src.Key {
+			out.Warning("Deployment %s already exists with a different key: apps using the App Center key will not find it", src.Name)
+		}
+	} else {
+		m.Created = true
+		req := codepush.CreateDeploymentRequest{Name: src.Name}
+		if opts.KeepKeys {
+			req.Key = src.Key
+			m.Key = src.Key
+		}
+		if !opts.DryRun {
+			step := out.StartStep("Creating deployment %q", src.Name)
+			dep, err := client.CreateDeployment(ctx, opts.AppID, req)
+			if err != nil {
+				step.Cancel()
+				return nil, fmt.Errorf("creating deployment %s: %w", src.Name, err)
+			}
+			step.Done()
+			m.ID, m.Key = dep.ID, dep.Key
+		}
+	}
+
+	releases := src.Releases
+	if opts.Releases < len(releases) {
+		releases = releases[len(releases)-opts.Releases:]
+	}
+	for _, r := range releases {
+		if opts.DryRun {
+			m.Releases = append(m.Releases, MigratedRelease{SourceLabel: r.Label})
+			continue
+		}
+		copied, err := copyRelease(ctx, source, client, m.ID, r, opts, out)
+		if err != nil {
+			return m, fmt.Errorf("copying %s %s: %w", src.Name, r.Label, err)
+		}
+		m.Releases = append(m.Releases, *copied)
+	}
+	return m, nil
+}
+
+// copyRelease downloads the package of r and pushes its contents to the
+// deployment.
+func copyRelease(ctx context.Context, source Source, client codepush.Client, deploymentID string, r Release, opts MigrateOptions, out *output.Writer) (*MigratedRelease, error) {
+	dir, err := opts.Workspace.MkdirTemp("migrate-*")
+	if err != nil {
+		return nil, fmt.Errorf("creating temp directory: %w", err)
+	}
+	defer opts.Workspace.Remove(dir)
+
+	step := out.StartStep("Downloading %s", r.Label)
+	zipPath := filepath.Join(dir, "package.zip")
+	if err := savePackage(ctx, source, r, zipPath); err != nil {
+		step.Cancel()
+		return nil, err
+	}
+	bundleDir := filepath.Join(dir, "package")
+	if err := ziputil.Extract(zipPath, bundleDir); err != nil {
+		step.Cancel()
+		return nil, fmt.Errorf("extracting package: %w", err)
+	}
+	step.Done()
+
+	rollout := 100
+	if r.Rollout != nil {
+		rollout = *r.Rollout
+	}
+	result, err := codepush.PushWithConfig(ctx, client, &codepush.PushOptions{
+		AppID:        opts.AppID,
+		DeploymentID: deploymentID,
+		Token:        opts.Token,
+		AppVersion:   r.TargetBinaryRange,
+		Description:  r.Description,
+		Mandatory:    r.IsMandatory,
+		Disabled:     r.IsDisabled,
+		Rollout:      rollout,
+		BundlePath:   bundleDir,
+		Full:         true,
+		Workspace:    opts.Workspace,
+	}, opts.PollConfig, out)
+	if errors.Is(err, codepush.ErrDuplicateRelease) {
+		out.Warning("%s skipped: its content is identical to the previous release", r.Label)
+		return &MigratedRelease{SourceLabel: r.Label, Skipped: true}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	copied := &MigratedRelease{SourceLabel: r.Label, UpdateID: result.UpdateID}
+	if u, err := client.GetUpdate(ctx, opts.AppID, deploymentID, result.UpdateID); err == nil {
+		copied.Label = u.Label
+	}
+	return copied, nil
+}
+
+func savePackage(ctx context.Context, source Source, r Release, path string) error {
+	rc, err := source.OpenPackage(ctx, r)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = rc.Close() }()
+
+	f, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("creating package file: %w", err)
+	}
+	if _, err := io.Copy(f, rc); err != nil {
+		_ = f.Close()
+		return fmt.Errorf("downloading package: %w", err)
+	}
+	return f.Close()
+}
@@ -0,0 +1,94 @@
+package appcenter
+
+import (
+	"context"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/bitrise-io/bitrise-plugins-codepush-cli/internal/codepush"
+	"github.com/bitrise-io/bitrise-plugins-codepush-cli/internal/output"
+)
+
+// fakeClient implements the deployment calls of codepush.Client that
+// Migrate makes without copying releases.
+type fakeClient struct {
+	codepush.Client
+	deployments []codepush.Deployment
+	created     []codepush.CreateDeploymentRequest
+}
+
+func (f *fakeClient) ListDeployments(context.Context, string) ([]codepush.Deployment, error) {
+	return f.deployments, nil
+}
+
+func (f *fakeClient) CreateDeployment(_ context.Context, _ string, req codepush.CreateDeploymentRequest) (*codepush.Deployment, error) {
+	f.created = append(f.created, req)
+	key := req.Key
+	if key == "" {
+		key = "new-" + req.Name
+	}
+	return &codepush.Deployment{ID: "id-" + req.Name, Name: req.Name, Key: key}, nil
+}
+
+var testOut = output.NewTest(io.Discard)
+
+func testSource() *Export {
+	return &Export{Items: []Deployment{
+		{Name: "Staging", Key: "ac-stg", Releases: []Release{{Label: "v1"}, {Label: "v2"}, {Label: "v3"}}},
+		{Name: "Production", Key: "ac-prod"},
+	}}
+}
+
+func TestMigrate(t *testing.T) {
+	tests := []struct {
+		name        string
+		opts        MigrateOptions
+		wantCreated []codepush.CreateDeploymentRequest
+		wantKeys    map[string]string
+		wantLabels  []string
+	}{
+		{
+			name:        "creates missing deployments",
+			opts:        MigrateOptions{AppID: "app"},
+			wantCreated: []codepush.CreateDeploymentRequest{{Name: "Production"}},
+			wantKeys:    map[string]string{"ac-stg": "bitrise-stg", "ac-prod": "new-Production"},
+			wantLabels:  []string{},
+		},
+		{
+			name:        "keeps keys",
+			opts:        MigrateOptions{AppID: "app", KeepKeys: true},
+			wantCreated: []codepush.CreateDeploymentRequest{{Name: "Production", Key: "ac-prod"}},
+			wantKeys:    map[string]string{"ac-stg": "bitrise-stg"},
+			wantLabels:  []string{},
+		},
+		{
+			name:       "dry run lists latest releases",
+			opts:       MigrateOptions{AppID: "app", DryRun: true, Releases: 2},
+			wantKeys:   map[string]string{"ac-stg": "bitrise-stg"},
+			wantLabels: []string{"v2", "v3"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := &fakeClient{deployments: []codepush.Deployment{{ID: "stg", Name: "Staging", Key: "bitrise-stg"}}}
+
+			result, err := Migrate(context.Background(), testSource(), client, tt.opts, testOut)
+			require.NoError(t, err)
+
+			assert.Equal(t, tt.wantCreated, client.created)
+			assert.Equal(t, tt.wantKeys, result.KeyMap())
+			require.Len(t, result.Deployments, 2)
+			assert.False(t, result.Deployments[0].Created)
+			assert.True(t, result.Deployments[1].Created)
+
+			labels := []string{}
+			for _, r := range result.Deployments[0].Releases {
+				labels = append(labels, r.SourceLabel)
+			}
+			assert.Equal(t, tt.wantLabels, labels)
+		})
+	}
+}
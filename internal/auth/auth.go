@@ -9,6 +9,7 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"time"
 
 	"golang.org/x/term"
 )
@@ -22,6 +23,30 @@ const (
 // Config represents the persisted CLI configuration.
 type Config struct {
 	Token string `json:"token"`
+	// Method is how the token was obtained: MethodToken, MethodWeb, or
+	// MethodDevice. Empty for configs stored by older versions.
+	Method string `json:"method,omitempty"`
+	// CreatedAt is when auth login stored the token.
+	CreatedAt time.Time `json:"created_at,omitzero"`
+	// ExpiresAt is when the token expires, or zero when the login flow did
+	// not report it, as for personal access tokens.
+	ExpiresAt time.Time `json:"expires_at,omitzero"`
+}
+
+// Login methods recorded in Config.Method.
+const (
+	MethodToken  = "token"
+	MethodWeb    = "web"
+	MethodDevice = "device"
+)
+
+// ExpiryWarningPeriod is how long before a stored token expires that
+// commands start to warn about it.
+const ExpiryWarningPeriod = 7 * 24 * time.Hour
+
+// ExpiresWithin reports whether the token has a known expiry before now+d.
+func (c *Config) ExpiresWithin(d time.Duration, now time.Time) bool {
+	return !c.ExpiresAt.IsZero() && c.ExpiresAt.Before(now.Add(d))
 }
 
 // configDirFunc allows tests to override the config directory.
@@ -45,6 +70,12 @@ func configFilePath() (string, error) {
 
 // SaveToken persists the API token to the config file.
 func SaveToken(token string) error {
+	return SaveConfig(Config{Token: token})
+}
+
+// SaveConfig persists the token and its metadata to the config file.
+// CreatedAt defaults to the current time.
+func SaveConfig(config Config) error {
 	path, err := configFilePath()
 	if err != nil {
 		return err
@@ -55,7 +86,9 @@ func SaveToken(token string) error {
 		return fmt.Errorf("creating config directory: %w", err)
 	}
 
-	config := Config{Token: token}
+	if config.CreatedAt.IsZero() {
+		config.CreatedAt = time.Now().UTC()
+	}
 	data, err := json.MarshalIndent(config, "", "  ")
 	if err != nil {
 		return fmt.Errorf("encoding config: %w", err)
@@ -71,25 +104,35 @@ func SaveToken(token string) error {
 // LoadToken reads the stored API token from the config file.
 // Returns an empty string and no error if the config file does not exist.
 func LoadToken() (string, error) {
+	config, err := LoadConfig()
+	if err != nil || config == nil {
+		return "", err
+	}
+	return config.Token, nil
+}
+
+// LoadConfig reads the stored token and its metadata from the config file.
+// Returns nil and no error if the config file does not exist.
+func LoadConfig() (*Config, error) {
 	path, err := configFilePath()
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 
 	data, err := os.ReadFile(path)
 	if err != nil {
 		if errors.Is(err, os.ErrNotExist) {
-			return "", nil
+			return nil, nil //nolint:nilnil // no stored token
 		}
-		return "", fmt.Errorf("reading config file: %w", err)
+		return nil, fmt.Errorf("reading config file: %w", err)
 	}
 
 	var config Config
 	if err := json.Unmarshal(data, &config); err != nil {
-		return "", fmt.Errorf("decoding config file: %w", err)
+		return nil, fmt.Errorf("decoding config file: %w", err)
 	}
 
-	return config.Token, nil
+	return &config, nil
 }
 
 // RemoveToken deletes the config file, effectively revoking the stored token.
@@ -124,10 +167,11 @@ type UserInfo struct {
 	Email    string `json:"email"`
 }
 
-// ValidateToken checks the token against the Bitrise API at the given server URL.
-// Returns the authenticated user's info, or an error if the token is invalid.
-func ValidateToken(token, serverURL string) (*UserInfo, error) {
-	return validateTokenWithURL(token, serverURL+authPath, &http.Client{})
+// ValidateToken checks the token against the Bitrise API at the given server
+// URL, sending the request with client. Returns the authenticated user's
+// info, or an error if the token is invalid.
+func ValidateToken(token, serverURL string, client *http.Client) (*UserInfo, error) {
+	return validateTokenWithURL(token, serverURL+authPath, client)
 }
 
 func validateTokenWithURL(token, url string, client *http.Client) (*UserInfo, error) {
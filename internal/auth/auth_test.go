@@ -7,6 +7,7 @@ import (
 	"os"
 	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -121,6 +122,43 @@ func TestRemoveToken(t *testing.T) {
 	})
 }
 
+func TestSaveConfig(t *testing.T) {
+	setupTestDir(t)
+	expiresAt := time.Date(2026, 10, 20, 12, 0, 0, 0, time.UTC)
+
+	require.NoError(t, SaveConfig(Config{Token: "web-token", Method: MethodWeb, ExpiresAt: expiresAt}))
+
+	config, err := LoadConfig()
+	require.NoError(t, err)
+	require.NotNil(t, config)
+	assert.Equal(t, "web-token", config.Token)
+	assert.Equal(t, MethodWeb, config.Method)
+	assert.True(t, expiresAt.Equal(config.ExpiresAt))
+	assert.WithinDuration(t, time.Now(), config.CreatedAt, time.Minute)
+}
+
+func TestConfigExpiresWithin(t *testing.T) {
+	now := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name      string
+		expiresAt time.Time
+		want      bool
+	}{
+		{name: "unknown expiry"},
+		{name: "expired", expiresAt: now.Add(-time.Hour), want: true},
+		{name: "within period", expiresAt: now.Add(6 * 24 * time.Hour), want: true},
+		{name: "after period", expiresAt: now.Add(8 * 24 * time.Hour)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := &Config{Token: "token", ExpiresAt: tt.expiresAt}
+			assert.Equal(t, tt.want, config.ExpiresWithin(ExpiryWarningPeriod, now))
+		})
+	}
+}
+
 func TestConfigFilePath(t *testing.T) {
 	dir := setupTestDir(t)
 
@@ -209,7 +247,7 @@ func TestValidateTokenAppendsAuthPath(t *testing.T) {
 	}))
 	defer server.Close()
 
-	_, err := ValidateToken("token", server.URL)
+	_, err := ValidateToken("token", server.URL, server.Client())
 	require.NoError(t, err)
 	assert.Equal(t, "/v0.1/me", receivedPath)
 }
@@ -0,0 +1,349 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// OAuth client registration of the CLI.
+const (
+	oauthClientID  = "codepush-cli"
+	authorizePath  = "/oauth/authorize"
+	tokenPath      = "/oauth/token"
+	deviceCodePath = "/oauth/device/code"
+	callbackPath   = "/callback"
+
+	deviceCodeGrant = "urn:ietf:params:oauth:grant-type:device_code"
+)
+
+// loginTimeout bounds how long a browser or device login waits for the user.
+const loginTimeout = 10 * time.Minute
+
+// deviceIntervalUnit is the unit of the device flow polling interval; tests
+// shorten it.
+var deviceIntervalUnit = time.Second
+
+// ErrBrowserUnavailable is returned by WebLogin when no browser could be
+// opened. Fall back to DeviceLogin.
+var ErrBrowserUnavailable = errors.New("could not open a browser")
+
+// Endpoints are the OAuth endpoints of a Bitrise instance.
+type Endpoints struct {
+	AuthorizeURL  string
+	TokenURL      string
+	DeviceCodeURL string
+}
+
+// EndpointsFor returns the OAuth endpoints of the Bitrise instance whose API
+// is served at serverURL. The authorization page is served by the web app,
+// whose host is the API host with "api." replaced by "app.".
+func EndpointsFor(serverURL string) Endpoints {
+	webURL := serverURL
+	if u, err := url.Parse(serverURL); err == nil && strings.HasPrefix(u.Host, "api.") {
+		u.Host = "app." + strings.TrimPrefix(u.Host, "api.")
+		webURL = u.String()
+	}
+	return Endpoints{
+		AuthorizeURL:  webURL + authorizePath,
+		TokenURL:      serverURL + tokenPath,
+		DeviceCodeURL: serverURL + deviceCodePath,
+	}
+}
+
+// WebLogin runs the OAuth authorization code flow with PKCE: it opens the
+// authorization page in a browser via openBrowser and receives the result
+// on a localhost callback server. showURL is called with the page URL
+// before the browser is opened, so it can be visited manually. The token
+// request is sent with client. Returns the access token.
+func WebLogin(ctx context.Context, client *http.Client, ep Endpoints, openBrowser func(string) error, showURL func(string)) (*Token, error) {
+	ctx, cancel := context.WithTimeout(ctx, loginTimeout)
+	defer cancel()
+
+	verifier, err := randomString(32)
+	if err != nil {
+		return nil, err
+	}
+	state, err := randomString(16)
+	if err != nil {
+		return nil, err
+	}
+
+	callback, err := startCallbackServer(state)
+	if err != nil {
+		return nil, err
+	}
+	defer callback.close()
+
+	authorizeURL := ep.AuthorizeURL + "?" + url.Values{
+		"response_type":         {"code"},
+		"client_id":             {oauthClientID},
+		"redirect_uri":          {callback.redirectURI},
+		"state":                 {state},
+		"code_challenge":        {pkceChallenge(verifier)},
+		"code_challenge_method": {"S256"},
+	}.Encode()
+
+	showURL(authorizeURL)
+	if err := openBrowser(authorizeURL); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrBrowserUnavailable, err)
+	}
+
+	code, err := callback.wait(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return exchangeCode(ctx, client, ep, code, callback.redirectURI, verifier)
+}
+
+// callbackServer receives the result of a browser login on localhost.
+type callbackServer struct {
+	redirectURI string
+	server      *http.Server
+	results     chan callbackResult
+}
+
+type callbackResult struct {
+	code string
+	err  error
+}
+
+// startCallbackServer starts serving the login callback on a free
+// localhost port. Callbacks without the expected state are rejected.
+func startCallbackServer(state string) (*callbackServer, error) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, fmt.Errorf("starting login callback server: %w", err)
+	}
+
+	cb := &callbackServer{
+		redirectURI: fmt.Sprintf("http://%s%s", listener.Addr(), callbackPath),
+		results:     make(chan callbackResult, 1),
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc(callbackPath, func(w http.ResponseWriter, r *http.Request) {
+		res := parseCallback(r.URL.Query(), state)
+		if res.err != nil {
+			http.Error(w, "Login failed: "+res.err.Error(), http.StatusBadRequest)
+		} else {
+			_, _ = io.WriteString(w, "Login complete. You can close this window and return to the terminal.\n")
+		}
+		select {
+		case cb.results <- res:
+		default:
+		}
+	})
+	cb.server = &http.Server{Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+	go func() { _ = cb.server.Serve(listener) }()
+	return cb, nil
+}
+
+// parseCallback returns the authorization code of a login callback, or the
+// error it reports.
+func parseCallback(q url.Values, state string) callbackResult {
+	switch {
+	case q.Get("state") != state:
+		return callbackResult{err: errors.New("login callback has an unexpected state: retry the login")}
+	case q.Get("error") != "":
+		return callbackResult{err: oauthError(q.Get("error"), q.Get("error_description"))}
+	case q.Get("code") == "":
+		return callbackResult{err: errors.New("login callback has no authorization code")}
+	}
+	return callbackResult{code: q.Get("code")}
+}
+
+// wait returns the authorization code of the first callback, or an error
+// when it reports one or ctx is done first.
+func (cb *callbackServer) wait(ctx context.Context) (string, error) {
+	select {
+	case res := <-cb.results:
+		return res.code, res.err
+	case <-ctx.Done():
+		return "", fmt.Errorf("waiting for browser login: %w", ctx.Err())
+	}
+}
+
+func (cb *callbackServer) close() {
+	_ = cb.server.Close()
+}
+
+// exchangeCode exchanges the authorization code of a browser login for an
+// access token.
+func exchangeCode(ctx context.Context, client *http.Client, ep Endpoints, code, redirectURI, verifier string) (*Token, error) {
+	return requestToken(ctx, client, ep.TokenURL, url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {code},
+		"redirect_uri":  {redirectURI},
+		"client_id":     {oauthClientID},
+		"code_verifier": {verifier},
+	})
+}
+
+// DeviceCode is the response that starts a device authorization flow.
+type DeviceCode struct {
+	DeviceCode              string `json:"device_code"`
+	UserCode                string `json:"user_code"`
+	VerificationURI         string `json:"verification_uri"`
+	VerificationURIComplete string `json:"verification_uri_complete,omitempty"`
+	ExpiresIn               int    `json:"expires_in"`
+	Interval                int    `json:"interval,omitempty"`
+}
+
+// DeviceLogin runs the OAuth device authorization flow for machines without
+// a browser. showCode is called with the code the user enters on another
+// device; DeviceLogin then polls until the user approves, sending its
+// requests with client. Returns the access token.
+func DeviceLogin(ctx context.Context, client *http.Client, ep Endpoints, showCode func(*DeviceCode)) (*Token, error) {
+	ctx, cancel := context.WithTimeout(ctx, loginTimeout)
+	defer cancel()
+
+	var dc DeviceCode
+	if err := postForm(ctx, client, ep.DeviceCodeURL, url.Values{"client_id": {oauthClientID}}, &dc); err != nil {
+		return nil, fmt.Errorf("starting device login: %w", err)
+	}
+	if dc.DeviceCode == "" || dc.UserCode == "" {
+		return nil, errors.New("starting device login: response has no device code")
+	}
+	showCode(&dc)
+
+	interval := time.Duration(max(dc.Interval, 5)) * deviceIntervalUnit
+	if dc.ExpiresIn > 0 {
+		var expCancel context.CancelFunc
+		ctx, expCancel = context.WithTimeout(ctx, time.Duration(dc.ExpiresIn)*deviceIntervalUnit)
+		defer expCancel()
+	}
+
+	for {
+		timer := time.NewTimer(interval)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, fmt.Errorf("device login expired before it was approved: %w", ctx.Err())
+		case <-timer.C:
+		}
+
+		token, err := requestToken(ctx, client, ep.TokenURL, url.Values{
+			"grant_type":  {deviceCodeGrant},
+			"device_code": {dc.DeviceCode},
+			"client_id":   {oauthClientID},
+		})
+		var oe *OAuthError
+		switch {
+		case err == nil:
+			return token, nil
+		case errors.As(err, &oe) && oe.Code == "authorization_pending":
+		case errors.As(err, &oe) && oe.Code == "slow_down":
+			interval += 5 * deviceIntervalUnit
+		default:
+			return nil, err
+		}
+	}
+}
+
+// OAuthError is an error response from an OAuth endpoint.
+type OAuthError struct {
+	Code        string `json:"error"`
+	Description string `json:"error_description"`
+}
+
+func (e *OAuthError) Error() string {
+	switch e.Code {
+	case "access_denied":
+		return "login was denied"
+	case "expired_token":
+		return "login code expired: run the login again"
+	}
+	if e.Description != "" {
+		return fmt.Sprintf("login failed: %s (%s)", e.Description, e.Code)
+	}
+	return "login failed: " + e.Code
+}
+
+func oauthError(code, description string) error {
+	return &OAuthError{Code: code, Description: description}
+}
+
+// Token is an access token issued by a login flow.
+type Token struct {
+	AccessToken string
+	// ExpiresAt is when the token expires, or zero when the server did not
+	// report it.
+	ExpiresAt time.Time
+}
+
+// requestToken exchanges a grant for an access token.
+func requestToken(ctx context.Context, client *http.Client, tokenURL string, form url.Values) (*Token, error) {
+	var result struct {
+		AccessToken string `json:"access_token"`
+		ExpiresIn   int    `json:"expires_in"`
+	}
+	if err := postForm(ctx, client, tokenURL, form, &result); err != nil {
+		return nil, err
+	}
+	if result.AccessToken == "" {
+		return nil, errors.New("login response has no access token")
+	}
+	token := &Token{AccessToken: result.AccessToken}
+	if result.ExpiresIn > 0 {
+		token.ExpiresAt = time.Now().Add(time.Duration(result.ExpiresIn) * time.Second).UTC()
+	}
+	return token, nil
+}
+
+// postForm posts form to endpoint and decodes the JSON response into v. An
+// OAuth error response is returned as *OAuthError.
+func postForm(ctx context.Context, client *http.Client, endpoint string, form url.Values, v any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return fmt.Errorf("creating request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("sending request to %s: %w", endpoint, err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("reading response: %w", err)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		var oe OAuthError
+		if json.Unmarshal(body, &oe) == nil && oe.Code != "" {
+			return &oe
+		}
+		return fmt.Errorf("%s returned HTTP %d", endpoint, resp.StatusCode)
+	}
+	if err := json.Unmarshal(body, v); err != nil {
+		return fmt.Errorf("decoding response: %w", err)
+	}
+	return nil
+}
+
+// randomString returns n random bytes, base64url encoded.
+func randomString(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("generating login secret: %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// pkceChallenge returns the S256 code challenge for verifier (RFC 7636).
+func pkceChallenge(verifier string) string {
+	sum := sha256.Sum256([]byte(verifier))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
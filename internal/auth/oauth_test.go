@@ -0,0 +1,144 @@
+package auth
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEndpointsFor(t *testing.T) {
+	ep := EndpointsFor("https://api.bitrise.io")
+	assert.Equal(t, "https://app.bitrise.io/oauth/authorize", ep.AuthorizeURL)
+	assert.Equal(t, "https://api.bitrise.io/oauth/token", ep.TokenURL)
+	assert.Equal(t, "https://api.bitrise.io/oauth/device/code", ep.DeviceCodeURL)
+
+	assert.Equal(t, "https://rm.example.com/oauth/authorize", EndpointsFor("https://rm.example.com").AuthorizeURL)
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func TestWebLogin(t *testing.T) {
+	var challenge string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.NoError(t, r.ParseForm())
+		assert.Equal(t, "/oauth/token", r.URL.Path)
+		assert.Equal(t, "authorization_code", r.Form.Get("grant_type"))
+		assert.Equal(t, "auth-code", r.Form.Get("code"))
+		assert.Equal(t, challenge, pkceChallenge(r.Form.Get("code_verifier")))
+		writeJSON(w, http.StatusOK, map[string]any{"access_token": "web-token", "expires_in": 3600})
+	}))
+	defer server.Close()
+	ep := Endpoints{AuthorizeURL: "https://app.example.com/oauth/authorize", TokenURL: server.URL + "/oauth/token"}
+
+	// browser simulates the user approving the login on the authorization page.
+	browser := func(params url.Values) func(string) error {
+		return func(authorizeURL string) error {
+			u, err := url.Parse(authorizeURL)
+			require.NoError(t, err)
+			q := u.Query()
+			assert.Equal(t, "S256", q.Get("code_challenge_method"))
+			challenge = q.Get("code_challenge")
+
+			params.Set("state", cmp.Or(params.Get("state"), q.Get("state")))
+			go func() {
+				resp, err := http.Get(q.Get("redirect_uri") + "?" + params.Encode())
+				if err == nil {
+					_ = resp.Body.Close()
+				}
+			}()
+			return nil
+		}
+	}
+
+	t.Run("exchanges the authorization code", func(t *testing.T) {
+		var shown string
+		token, err := WebLogin(context.Background(), server.Client(), ep, browser(url.Values{"code": {"auth-code"}}), func(u string) { shown = u })
+		require.NoError(t, err)
+		assert.Equal(t, "web-token", token.AccessToken)
+		assert.WithinDuration(t, time.Now().Add(time.Hour), token.ExpiresAt, time.Minute)
+		assert.Contains(t, shown, ep.AuthorizeURL)
+	})
+
+	t.Run("denied", func(t *testing.T) {
+		_, err := WebLogin(context.Background(), server.Client(), ep, browser(url.Values{"error": {"access_denied"}}), func(string) {})
+		assert.EqualError(t, err, "login was denied")
+	})
+
+	t.Run("state mismatch", func(t *testing.T) {
+		_, err := WebLogin(context.Background(), server.Client(), ep, browser(url.Values{"code": {"auth-code"}, "state": {"forged"}}), func(string) {})
+		assert.ErrorContains(t, err, "unexpected state")
+	})
+
+	t.Run("browser unavailable", func(t *testing.T) {
+		_, err := WebLogin(context.Background(), server.Client(), ep, func(string) error { return errors.New("no graphical session") }, func(string) {})
+		assert.ErrorIs(t, err, ErrBrowserUnavailable)
+	})
+}
+
+func TestDeviceLogin(t *testing.T) {
+	saved := deviceIntervalUnit
+	deviceIntervalUnit = time.Millisecond
+	t.Cleanup(func() { deviceIntervalUnit = saved })
+
+	newServer := func(t *testing.T, tokenResponses ...func(w http.ResponseWriter)) *httptest.Server {
+		t.Helper()
+		var polls atomic.Int32
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			require.NoError(t, r.ParseForm())
+			switch r.URL.Path {
+			case "/oauth/device/code":
+				writeJSON(w, http.StatusOK, DeviceCode{DeviceCode: "dev-123", UserCode: "ABCD-EFGH", VerificationURI: "https://app.example.com/device", ExpiresIn: 600, Interval: 1})
+			case "/oauth/token":
+				assert.Equal(t, deviceCodeGrant, r.Form.Get("grant_type"))
+				assert.Equal(t, "dev-123", r.Form.Get("device_code"))
+				n := int(polls.Add(1))
+				tokenResponses[min(n, len(tokenResponses))-1](w)
+			}
+		}))
+		t.Cleanup(server.Close)
+		return server
+	}
+	pending := func(code string) func(w http.ResponseWriter) {
+		return func(w http.ResponseWriter) { writeJSON(w, http.StatusBadRequest, map[string]string{"error": code}) }
+	}
+	granted := func(w http.ResponseWriter) {
+		writeJSON(w, http.StatusOK, map[string]string{"access_token": "device-token"})
+	}
+
+	t.Run("polls until approved", func(t *testing.T) {
+		server := newServer(t, pending("authorization_pending"), pending("slow_down"), granted)
+
+		var shown *DeviceCode
+		token, err := DeviceLogin(context.Background(), server.Client(), EndpointsFor(server.URL), func(dc *DeviceCode) { shown = dc })
+		require.NoError(t, err)
+		assert.Equal(t, "device-token", token.AccessToken)
+		assert.True(t, token.ExpiresAt.IsZero())
+		assert.Equal(t, "ABCD-EFGH", shown.UserCode)
+	})
+
+	t.Run("denied", func(t *testing.T) {
+		server := newServer(t, pending("access_denied"))
+		_, err := DeviceLogin(context.Background(), server.Client(), EndpointsFor(server.URL), func(*DeviceCode) {})
+		assert.EqualError(t, err, "login was denied")
+	})
+
+	t.Run("expired", func(t *testing.T) {
+		server := newServer(t, pending("expired_token"))
+		_, err := DeviceLogin(context.Background(), server.Client(), EndpointsFor(server.URL), func(*DeviceCode) {})
+		assert.ErrorContains(t, err, "expired")
+	})
+}
@@ -0,0 +1,35 @@
+package bitrise
+
+import (
+	"fmt"
+	"os/exec"
+	"strings"
+
+	"github.com/bitrise-io/bitrise-plugins-codepush-cli/internal/redact"
+)
+
+// Styles of a build annotation, which set its color and icon on the build
+// page.
+const (
+	AnnotationInfo    = "info"
+	AnnotationSuccess = "success"
+	AnnotationWarning = "warning"
+	AnnotationError   = "error"
+)
+
+// Annotate adds a markdown annotation to the build page with the
+// annotations plugin of the bitrise CLI. An annotation replaces the one
+// added earlier in the build with the same context. Secrets are masked.
+// Skips silently if the bitrise CLI is not available on PATH.
+func Annotate(markdown, style, context string) error {
+	bitrisePath, err := exec.LookPath("bitrise")
+	if err != nil {
+		return nil //nolint:nilerr // bitrise CLI not available, skip silently
+	}
+
+	cmd := exec.Command(bitrisePath, ":annotations", "annotate", redact.String(markdown), "--style", style, "--context", context)
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("bitrise :annotations annotate: %w: %s", err, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
@@ -0,0 +1,49 @@
+package bitrise
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAnnotate(t *testing.T) {
+	t.Run("skips silently when the bitrise CLI is not on PATH", func(t *testing.T) {
+		t.Setenv("PATH", t.TempDir())
+
+		require.NoError(t, Annotate("**released**", AnnotationSuccess, "codepush"))
+	})
+
+	t.Run("calls the annotations plugin", func(t *testing.T) {
+		if runtime.GOOS == "windows" {
+			t.Skip("uses a shell script as the bitrise CLI")
+		}
+		dir := t.TempDir()
+		argsFile := filepath.Join(dir, "args")
+		script := "#!/bin/sh\nfor a in \"$@\"; do echo \"$a\"; done > " + argsFile + "\n"
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "bitrise"), []byte(script), 0o755))
+		t.Setenv("PATH", dir)
+
+		require.NoError(t, Annotate("**released**", AnnotationSuccess, "codepush-staging"))
+
+		data, err := os.ReadFile(argsFile)
+		require.NoError(t, err)
+		assert.Equal(t, ":annotations\nannotate\n**released**\n--style\nsuccess\n--context\ncodepush-staging\n", string(data))
+	})
+
+	t.Run("reports plugin failures", func(t *testing.T) {
+		if runtime.GOOS == "windows" {
+			t.Skip("uses a shell script as the bitrise CLI")
+		}
+		dir := t.TempDir()
+		script := "#!/bin/sh\necho 'plugin not installed' >&2\nexit 1\n"
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "bitrise"), []byte(script), 0o755))
+		t.Setenv("PATH", dir)
+
+		err := Annotate("**released**", AnnotationSuccess, "codepush")
+		assert.ErrorContains(t, err, "plugin not installed")
+	})
+}
@@ -0,0 +1,22 @@
+package bitrise
+
+import "net/url"
+
+// DashboardURL is the base URL of the Release Management web UI.
+const DashboardURL = "https://app.bitrise.io/release-management"
+
+// ReleaseManagementURL returns the Release Management page of an app, one of
+// its deployments, or one release of a deployment. Trailing empty IDs make
+// the page less specific: without deploymentID it is the app's CodePush
+// page, without updateID the deployment's.
+func ReleaseManagementURL(appID, deploymentID, updateID string) string {
+	u := DashboardURL + "/apps/" + url.PathEscape(appID) + "/codepush"
+	if deploymentID == "" {
+		return u
+	}
+	u += "/deployments/" + url.PathEscape(deploymentID)
+	if updateID == "" {
+		return u
+	}
+	return u + "/releases/" + url.PathEscape(updateID)
+}
@@ -0,0 +1,40 @@
+package bitrise
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReleaseManagementURL(t *testing.T) {
+	tests := []struct {
+		name                          string
+		appID, deploymentID, updateID string
+		want                          string
+	}{
+		{
+			name:  "app",
+			appID: "app-1",
+			want:  "https://app.bitrise.io/release-management/apps/app-1/codepush",
+		},
+		{
+			name:         "deployment",
+			appID:        "app-1",
+			deploymentID: "dep-1",
+			want:         "https://app.bitrise.io/release-management/apps/app-1/codepush/deployments/dep-1",
+		},
+		{
+			name:         "release",
+			appID:        "app-1",
+			deploymentID: "dep-1",
+			updateID:     "pkg-1",
+			want:         "https://app.bitrise.io/release-management/apps/app-1/codepush/deployments/dep-1/releases/pkg-1",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.want, ReleaseManagementURL(tc.appID, tc.deploymentID, tc.updateID))
+		})
+	}
+}
@@ -7,13 +7,18 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
+
+	"github.com/bitrise-io/bitrise-plugins-codepush-cli/internal/redact"
 )
 
 // BuildMetadata contains information about the current Bitrise build.
 type BuildMetadata struct {
 	DeployDir   string
 	BuildNumber string
+	BuildURL    string
 	CommitHash  string
+	Branch      string
+	Workflow    string
 }
 
 // IsBitriseEnvironment returns true if running inside a Bitrise CI build.
@@ -26,12 +31,15 @@ func GetBuildMetadata() BuildMetadata {
 	return BuildMetadata{
 		DeployDir:   os.Getenv("BITRISE_DEPLOY_DIR"),
 		BuildNumber: os.Getenv("BITRISE_BUILD_NUMBER"),
+		BuildURL:    os.Getenv("BITRISE_BUILD_URL"),
 		CommitHash:  os.Getenv("GIT_CLONE_COMMIT_HASH"),
+		Branch:      os.Getenv("BITRISE_GIT_BRANCH"),
+		Workflow:    os.Getenv("BITRISE_TRIGGERED_WORKFLOW_ID"),
 	}
 }
 
-// WriteToDeployDir writes data to a file in the Bitrise deploy directory.
-// Returns the full path of the written file.
+// WriteToDeployDir writes data to a file in the Bitrise deploy directory,
+// with secrets masked. Returns the full path of the written file.
 func WriteToDeployDir(filename string, data []byte) (string, error) {
 	deployDir := os.Getenv("BITRISE_DEPLOY_DIR")
 	if deployDir == "" {
@@ -43,13 +51,23 @@ func WriteToDeployDir(filename string, data []byte) (string, error) {
 	}
 
 	destPath := filepath.Join(deployDir, filename)
-	if err := os.WriteFile(destPath, data, 0o644); err != nil {
+	if err := os.WriteFile(destPath, redact.Bytes(data), 0o644); err != nil {
 		return "", fmt.Errorf("failed to write to deploy directory: %w", err)
 	}
 
 	return destPath, nil
 }
 
+// CopyToDeployDir copies the file at srcPath into the Bitrise deploy
+// directory under filename. Returns the full path of the copy.
+func CopyToDeployDir(srcPath, filename string) (string, error) {
+	data, err := os.ReadFile(srcPath)
+	if err != nil {
+		return "", fmt.Errorf("reading %s: %w", srcPath, err)
+	}
+	return WriteToDeployDir(filename, data)
+}
+
 // ExportEnvVar exports an environment variable using envman so that
 // downstream Bitrise steps can access it. Skips silently if envman
 // is not available on PATH.
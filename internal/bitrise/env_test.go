@@ -60,13 +60,17 @@ func TestIsBitriseEnvironment(t *testing.T) {
 func TestGetBuildMetadata(t *testing.T) {
 	t.Setenv("BITRISE_DEPLOY_DIR", "/tmp/deploy")
 	t.Setenv("BITRISE_BUILD_NUMBER", "42")
+	t.Setenv("BITRISE_BUILD_URL", "https://app.bitrise.io/build/xyz")
 	t.Setenv("GIT_CLONE_COMMIT_HASH", "abc123")
+	t.Setenv("BITRISE_TRIGGERED_WORKFLOW_ID", "release")
 
 	meta := GetBuildMetadata()
 
 	assert.Equal(t, "/tmp/deploy", meta.DeployDir)
 	assert.Equal(t, "42", meta.BuildNumber)
+	assert.Equal(t, "https://app.bitrise.io/build/xyz", meta.BuildURL)
 	assert.Equal(t, "abc123", meta.CommitHash)
+	assert.Equal(t, "release", meta.Workflow)
 }
 
 func TestWriteToDeployDir(t *testing.T) {
@@ -85,6 +89,18 @@ func TestWriteToDeployDir(t *testing.T) {
 		assert.JSONEq(t, `{"key": "value"}`, string(data))
 	})
 
+	t.Run("masks secrets", func(t *testing.T) {
+		dir := t.TempDir()
+		t.Setenv("BITRISE_DEPLOY_DIR", dir)
+
+		path, err := WriteToDeployDir("summary.json", []byte(`{"url": "https://s3.example.com/p?X-Amz-Signature=abcdef0123456789"}`))
+		require.NoError(t, err)
+
+		data, err := os.ReadFile(path)
+		require.NoError(t, err)
+		assert.NotContains(t, string(data), "abcdef0123456789")
+	})
+
 	t.Run("creates deploy directory if missing", func(t *testing.T) {
 		dir := filepath.Join(t.TempDir(), "nested", "deploy")
 		t.Setenv("BITRISE_DEPLOY_DIR", dir)
@@ -104,6 +120,30 @@ func TestWriteToDeployDir(t *testing.T) {
 	})
 }
 
+func TestCopyToDeployDir(t *testing.T) {
+	t.Run("copies file", func(t *testing.T) {
+		deployDir := t.TempDir()
+		t.Setenv("BITRISE_DEPLOY_DIR", deployDir)
+		src := filepath.Join(t.TempDir(), "index.android.bundle.map")
+		require.NoError(t, os.WriteFile(src, []byte(`{"version":3}`), 0o644))
+
+		path, err := CopyToDeployDir(src, "codepush.map")
+		require.NoError(t, err)
+		assert.Equal(t, filepath.Join(deployDir, "codepush.map"), path)
+
+		data, err := os.ReadFile(path)
+		require.NoError(t, err)
+		assert.Equal(t, `{"version":3}`, string(data))
+	})
+
+	t.Run("error when source is missing", func(t *testing.T) {
+		t.Setenv("BITRISE_DEPLOY_DIR", t.TempDir())
+
+		_, err := CopyToDeployDir(filepath.Join(t.TempDir(), "missing.map"), "codepush.map")
+		require.Error(t, err)
+	})
+}
+
 func TestExportEnvVar(t *testing.T) {
 	t.Run("skips silently when envman not on PATH", func(t *testing.T) {
 		// Use a PATH that definitely doesn't contain envman
@@ -0,0 +1,327 @@
+// Package bundlecache stores bundler outputs on disk, keyed by a caller
+// computed hash, with LRU eviction under a size cap. The cache may be shared
+// by concurrent CI jobs on the same agent; every operation holds an
+// exclusive file lock on the cache directory.
+package bundlecache
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+)
+
+const (
+	indexFile  = "index.json"
+	lockFile   = ".lock"
+	entriesDir = "entries"
+)
+
+// Entry describes one cached bundle output.
+type Entry struct {
+	Key      string    `json:"key"`
+	Size     int64     `json:"size"`
+	Created  time.Time `json:"created"`
+	LastUsed time.Time `json:"last_used"`
+	Hits     int       `json:"hits"`
+}
+
+// index is the persisted cache bookkeeping.
+type index struct {
+	Entries map[string]*Entry `json:"entries"`
+	Hits    int64             `json:"hits"`
+	Misses  int64             `json:"misses"`
+}
+
+// Stats summarizes the cache contents and effectiveness.
+type Stats struct {
+	Dir     string  `json:"dir"`
+	Entries []Entry `json:"entries"`
+	Size    int64   `json:"size_bytes"`
+	MaxSize int64   `json:"max_size_bytes,omitempty"`
+	Hits    int64   `json:"hits"`
+	Misses  int64   `json:"misses"`
+}
+
+// HitRate returns the fraction of lookups that were hits, or 0 when the cache
+// has not been used.
+func (s *Stats) HitRate() float64 {
+	if s.Hits+s.Misses == 0 {
+		return 0
+	}
+	return float64(s.Hits) / float64(s.Hits+s.Misses)
+}
+
+// Cache is a bundle cache rooted at Dir. MaxSize caps the total size of all
+// entries in bytes; 0 means unbounded.
+type Cache struct {
+	Dir     string
+	MaxSize int64
+
+	// now allows tests to control timestamps.
+	now func() time.Time
+}
+
+// New returns a cache rooted at dir.
+func New(dir string, maxSize int64) *Cache {
+	return &Cache{Dir: dir, MaxSize: maxSize, now: time.Now}
+}
+
+// DefaultDir returns the cache directory used when none is configured: under
+// BITRISE_CACHE_DIR on Bitrise, so the Bitrise cache steps can persist it, or
+// under the user cache directory elsewhere.
+func DefaultDir() (string, error) {
+	if dir := os.Getenv("BITRISE_CACHE_DIR"); dir != "" {
+		return filepath.Join(dir, "codepush-bundles"), nil
+	}
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", fmt.Errorf("determining cache directory: %w", err)
+	}
+	return filepath.Join(dir, "codepush", "bundles"), nil
+}
+
+// Get copies the entry for key into dest and reports whether it was found.
+// Lookups are counted towards the hit rate.
+func (c *Cache) Get(key, dest string) (bool, error) {
+	var hit bool
+	err := c.withIndex(func(idx *index) error {
+		e, ok := idx.Entries[key]
+		if !ok {
+			idx.Misses++
+			return nil
+		}
+		if err := copyDir(c.entryPath(key), dest); err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				delete(idx.Entries, key)
+				idx.Misses++
+				return nil
+			}
+			return fmt.Errorf("restoring cache entry: %w", err)
+		}
+		hit = true
+		idx.Hits++
+		e.Hits++
+		e.LastUsed = c.now().UTC()
+		return nil
+	})
+	return hit, err
+}
+
+// Put stores a copy of src under key, replacing any existing entry, then
+// evicts least recently used entries until the cache fits MaxSize.
+func (c *Cache) Put(key, src string) error {
+	return c.withIndex(func(idx *index) error {
+		tmp, err := os.MkdirTemp(filepath.Join(c.Dir, entriesDir), ".tmp-*")
+		if err != nil {
+			return fmt.Errorf("creating cache entry: %w", err)
+		}
+		defer func() { _ = os.RemoveAll(tmp) }()
+
+		if err := copyDir(src, tmp); err != nil {
+			return fmt.Errorf("storing cache entry: %w", err)
+		}
+		size, err := dirSize(tmp)
+		if err != nil {
+			return err
+		}
+
+		dst := c.entryPath(key)
+		if err := os.RemoveAll(dst); err != nil {
+			return fmt.Errorf("replacing cache entry: %w", err)
+		}
+		if err := os.Rename(tmp, dst); err != nil {
+			return fmt.Errorf("storing cache entry: %w", err)
+		}
+
+		now := c.now().UTC()
+		idx.Entries[key] = &Entry{Key: key, Size: size, Created: now, LastUsed: now}
+		_, err = c.evict(idx)
+		return err
+	})
+}
+
+// Prune evicts least recently used entries until the cache fits MaxSize and
+// returns the evicted entries.
+func (c *Cache) Prune() ([]Entry, error) {
+	var evicted []Entry
+	err := c.withIndex(func(idx *index) error {
+		var err error
+		evicted, err = c.evict(idx)
+		return err
+	})
+	return evicted, err
+}
+
+// Stats returns the cache contents, most recently used first.
+func (c *Cache) Stats() (*Stats, error) {
+	stats := &Stats{Dir: c.Dir, MaxSize: c.MaxSize, Entries: []Entry{}}
+	err := c.withIndex(func(idx *index) error {
+		for _, e := range idx.Entries {
+			stats.Entries = append(stats.Entries, *e)
+			stats.Size += e.Size
+		}
+		stats.Hits, stats.Misses = idx.Hits, idx.Misses
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	slices.SortFunc(stats.Entries, func(a, b Entry) int { return b.LastUsed.Compare(a.LastUsed) })
+	return stats, nil
+}
+
+// evict removes least recently used entries from idx and disk until the
+// total size fits MaxSize.
+func (c *Cache) evict(idx *index) ([]Entry, error) {
+	if c.MaxSize <= 0 {
+		return nil, nil
+	}
+
+	var total int64
+	lru := make([]*Entry, 0, len(idx.Entries))
+	for _, e := range idx.Entries {
+		total += e.Size
+		lru = append(lru, e)
+	}
+	slices.SortFunc(lru, func(a, b *Entry) int { return a.LastUsed.Compare(b.LastUsed) })
+
+	var evicted []Entry
+	for _, e := range lru {
+		if total <= c.MaxSize {
+			break
+		}
+		if err := os.RemoveAll(c.entryPath(e.Key)); err != nil {
+			return evicted, fmt.Errorf("evicting cache entry %s: %w", e.Key, err)
+		}
+		delete(idx.Entries, e.Key)
+		total -= e.Size
+		evicted = append(evicted, *e)
+	}
+	return evicted, nil
+}
+
+// withIndex runs fn with the cache locked and the index loaded, and persists
+// the index afterwards if fn succeeds.
+func (c *Cache) withIndex(fn func(idx *index) error) error {
+	if err := os.MkdirAll(filepath.Join(c.Dir, entriesDir), 0o755); err != nil {
+		return fmt.Errorf("creating cache directory: %w", err)
+	}
+
+	lock, err := os.OpenFile(filepath.Join(c.Dir, lockFile), os.O_CREATE|os.O_RDWR, 0o644)
+	if err != nil {
+		return fmt.Errorf("opening cache lock: %w", err)
+	}
+	defer func() { _ = lock.Close() }()
+	if err := lockFileExclusive(lock); err != nil {
+		return fmt.Errorf("locking cache: %w", err)
+	}
+	defer func() { _ = unlockFile(lock) }()
+
+	idx, err := c.loadIndex()
+	if err != nil {
+		return err
+	}
+	if err := fn(idx); err != nil {
+		return err
+	}
+	return c.saveIndex(idx)
+}
+
+func (c *Cache) loadIndex() (*index, error) {
+	idx := &index{Entries: map[string]*Entry{}}
+	data, err := os.ReadFile(filepath.Join(c.Dir, indexFile))
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return idx, nil
+		}
+		return nil, fmt.Errorf("reading cache index: %w", err)
+	}
+	if err := json.Unmarshal(data, idx); err != nil {
+		return nil, fmt.Errorf("parsing cache index: %w", err)
+	}
+	if idx.Entries == nil {
+		idx.Entries = map[string]*Entry{}
+	}
+	return idx, nil
+}
+
+// saveIndex writes the index atomically so a crash never leaves it truncated.
+func (c *Cache) saveIndex(idx *index) error {
+	data, err := json.MarshalIndent(idx, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encoding cache index: %w", err)
+	}
+	tmp := filepath.Join(c.Dir, indexFile+".tmp")
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return fmt.Errorf("writing cache index: %w", err)
+	}
+	if err := os.Rename(tmp, filepath.Join(c.Dir, indexFile)); err != nil {
+		return fmt.Errorf("writing cache index: %w", err)
+	}
+	return nil
+}
+
+func (c *Cache) entryPath(key string) string {
+	return filepath.Join(c.Dir, entriesDir, key)
+}
+
+// copyDir copies the contents of src into dst, creating dst if needed.
+func copyDir(src, dst string) error {
+	return filepath.WalkDir(src, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dst, rel)
+		if d.IsDir() {
+			return os.MkdirAll(target, 0o755)
+		}
+		return copyFile(path, target)
+	})
+}
+
+func copyFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = in.Close() }()
+
+	out, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		_ = out.Close()
+		return err
+	}
+	return out.Close()
+}
+
+func dirSize(dir string) (int64, error) {
+	var size int64
+	err := filepath.WalkDir(dir, func(_ string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		size += info.Size()
+		return nil
+	})
+	if err != nil {
+		return 0, fmt.Errorf("measuring cache entry: %w", err)
+	}
+	return size, nil
+}
@@ -0,0 +1,142 @@
+package bundlecache
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestCache returns a cache whose clock advances one minute per call.
+func newTestCache(t *testing.T, maxSize int64) *Cache {
+	t.Helper()
+	c := New(t.TempDir(), maxSize)
+	clock := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	c.now = func() time.Time {
+		clock = clock.Add(time.Minute)
+		return clock
+	}
+	return c
+}
+
+// bundleDir creates a bundle output directory with one file of size bytes.
+func bundleDir(t *testing.T, size int) string {
+	t.Helper()
+	dir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "assets"), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "assets", "index.bundle"), []byte(strings.Repeat("x", size)), 0o644))
+	return dir
+}
+
+func TestGetPut(t *testing.T) {
+	c := newTestCache(t, 0)
+
+	hit, err := c.Get("k1", t.TempDir())
+	require.NoError(t, err)
+	assert.False(t, hit)
+
+	require.NoError(t, c.Put("k1", bundleDir(t, 10)))
+
+	dest := t.TempDir()
+	hit, err = c.Get("k1", dest)
+	require.NoError(t, err)
+	assert.True(t, hit)
+	data, err := os.ReadFile(filepath.Join(dest, "assets", "index.bundle"))
+	require.NoError(t, err)
+	assert.Len(t, data, 10)
+
+	stats, err := c.Stats()
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), stats.Hits)
+	assert.Equal(t, int64(1), stats.Misses)
+	assert.InDelta(t, 0.5, stats.HitRate(), 0.001)
+	require.Len(t, stats.Entries, 1)
+	assert.Equal(t, int64(10), stats.Size)
+	assert.Equal(t, 1, stats.Entries[0].Hits)
+}
+
+func TestPutEvictsLeastRecentlyUsed(t *testing.T) {
+	c := newTestCache(t, 25)
+
+	require.NoError(t, c.Put("old", bundleDir(t, 10)))
+	require.NoError(t, c.Put("used", bundleDir(t, 10)))
+	// Touch "old" so "used" becomes the least recently used entry.
+	_, err := c.Get("old", t.TempDir())
+	require.NoError(t, err)
+
+	require.NoError(t, c.Put("new", bundleDir(t, 10)))
+
+	stats, err := c.Stats()
+	require.NoError(t, err)
+	var keys []string
+	for _, e := range stats.Entries {
+		keys = append(keys, e.Key)
+	}
+	assert.ElementsMatch(t, []string{"old", "new"}, keys)
+	assert.NoDirExists(t, c.entryPath("used"))
+}
+
+func TestPrune(t *testing.T) {
+	c := newTestCache(t, 0)
+	require.NoError(t, c.Put("a", bundleDir(t, 10)))
+	require.NoError(t, c.Put("b", bundleDir(t, 10)))
+
+	evicted, err := c.Prune()
+	require.NoError(t, err)
+	assert.Empty(t, evicted, "unbounded cache evicts nothing")
+
+	c.MaxSize = 15
+	evicted, err = c.Prune()
+	require.NoError(t, err)
+	require.Len(t, evicted, 1)
+	assert.Equal(t, "a", evicted[0].Key)
+}
+
+func TestGetMissingEntryDirectory(t *testing.T) {
+	c := newTestCache(t, 0)
+	require.NoError(t, c.Put("k", bundleDir(t, 10)))
+	require.NoError(t, os.RemoveAll(c.entryPath("k")))
+
+	hit, err := c.Get("k", t.TempDir())
+	require.NoError(t, err)
+	assert.False(t, hit)
+
+	stats, err := c.Stats()
+	require.NoError(t, err)
+	assert.Empty(t, stats.Entries)
+}
+
+func TestConcurrentAccess(t *testing.T) {
+	dir := t.TempDir()
+	src := bundleDir(t, 100)
+
+	var wg sync.WaitGroup
+	for i := range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			c := New(dir, 0)
+			assert.NoError(t, c.Put("shared", src))
+			_, err := c.Get("shared", filepath.Join(t.TempDir(), "out"))
+			assert.NoError(t, err, "worker %d", i)
+		}()
+	}
+	wg.Wait()
+
+	stats, err := New(dir, 0).Stats()
+	require.NoError(t, err)
+	require.Len(t, stats.Entries, 1)
+	assert.Equal(t, int64(8), stats.Hits)
+}
+
+func TestDefaultDir(t *testing.T) {
+	t.Setenv("BITRISE_CACHE_DIR", "/bitrise/cache")
+	dir, err := DefaultDir()
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join("/bitrise/cache", "codepush-bundles"), dir)
+}
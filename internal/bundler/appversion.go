@@ -0,0 +1,139 @@
+package bundler
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+var (
+	reShortVersionString = regexp.MustCompile(`<key>CFBundleShortVersionString</key>\s*<string>([^<]*)</string>`)
+	reMarketingVersion   = regexp.MustCompile(`MARKETING_VERSION = "?([^";]+)"?;`)
+	reBuildSetting       = regexp.MustCompile(`^\$[({](\w+)[)}]$`)
+	reVersionName        = regexp.MustCompile(`versionName\s*=?\s*["']([^"']+)["']`)
+)
+
+// DetectedAppVersion is a binary version read from native project files.
+type DetectedAppVersion struct {
+	Version string
+	// Source is the file the version was read from.
+	Source string
+}
+
+// DetectAppVersion reads the binary version of the app in projectDir: the
+// CFBundleShortVersionString of ios/*/Info.plist, resolving
+// $(MARKETING_VERSION) from the Xcode project, or the versionName of
+// android/app/build.gradle (or gradleFile). With an empty platform both are
+// read, and they must agree.
+func DetectAppVersion(projectDir string, platform Platform, gradleFile string) (DetectedAppVersion, error) {
+	switch platform {
+	case PlatformIOS:
+		return detectAppVersionIOS(projectDir)
+	case PlatformAndroid:
+		return detectAppVersionAndroid(projectDir, gradleFile)
+	}
+
+	ios, iosErr := detectAppVersionIOS(projectDir)
+	android, androidErr := detectAppVersionAndroid(projectDir, gradleFile)
+	switch {
+	case iosErr != nil && androidErr != nil:
+		return DetectedAppVersion{}, errors.Join(iosErr, androidErr)
+	case iosErr != nil:
+		return android, nil
+	case androidErr != nil:
+		return ios, nil
+	case ios.Version != android.Version:
+		return DetectedAppVersion{}, fmt.Errorf("iOS version %s (%s) and Android version %s (%s) differ: set --platform",
+			ios.Version, ios.Source, android.Version, android.Source)
+	}
+	return ios, nil
+}
+
+func detectAppVersionIOS(projectDir string) (DetectedAppVersion, error) {
+	plists, _ := filepath.Glob(filepath.Join(projectDir, "ios", "*", "Info.plist"))
+	plists = slices.DeleteFunc(plists, func(p string) bool {
+		return strings.HasSuffix(filepath.Base(filepath.Dir(p)), "Tests")
+	})
+
+	var found []DetectedAppVersion
+	for _, plist := range plists {
+		data, err := os.ReadFile(plist)
+		if err != nil {
+			continue
+		}
+		m := reShortVersionString.FindSubmatch(data)
+		if m == nil {
+			continue
+		}
+		version := strings.TrimSpace(string(m[1]))
+		if s := reBuildSetting.FindStringSubmatch(version); s != nil {
+			if s[1] != "MARKETING_VERSION" {
+				return DetectedAppVersion{}, fmt.Errorf("%s sets CFBundleShortVersionString from build setting %s: set --app-version", plist, s[1])
+			}
+			return detectMarketingVersion(projectDir)
+		}
+		found = append(found, DetectedAppVersion{Version: version, Source: plist})
+	}
+	return singleVersion(found, "no CFBundleShortVersionString found in ios/*/Info.plist")
+}
+
+// detectMarketingVersion reads the MARKETING_VERSION build setting from the
+// Xcode projects in ios/.
+func detectMarketingVersion(projectDir string) (DetectedAppVersion, error) {
+	projects, _ := filepath.Glob(filepath.Join(projectDir, "ios", "*.xcodeproj", "project.pbxproj"))
+	var found []DetectedAppVersion
+	for _, project := range projects {
+		data, err := os.ReadFile(project)
+		if err != nil {
+			continue
+		}
+		for _, m := range reMarketingVersion.FindAllSubmatch(data, -1) {
+			found = append(found, DetectedAppVersion{Version: strings.TrimSpace(string(m[1])), Source: project})
+		}
+	}
+	return singleVersion(found, "no MARKETING_VERSION found in ios/*.xcodeproj")
+}
+
+func detectAppVersionAndroid(projectDir, gradleFile string) (DetectedAppVersion, error) {
+	gradlePaths := []string{
+		filepath.Join(projectDir, "android", "app", "build.gradle"),
+		filepath.Join(projectDir, "android", "app", "build.gradle.kts"),
+	}
+	if gradleFile != "" {
+		if !filepath.IsAbs(gradleFile) {
+			gradleFile = filepath.Join(projectDir, gradleFile)
+		}
+		gradlePaths = []string{gradleFile}
+	}
+
+	var found []DetectedAppVersion
+	for _, gradlePath := range gradlePaths {
+		data, err := os.ReadFile(gradlePath)
+		if err != nil {
+			continue
+		}
+		for _, m := range reVersionName.FindAllSubmatch(data, -1) {
+			found = append(found, DetectedAppVersion{Version: strings.TrimSpace(string(m[1])), Source: gradlePath})
+		}
+	}
+	return singleVersion(found, "no versionName found in android/app/build.gradle")
+}
+
+// singleVersion returns the version all candidates agree on. Build variants
+// or targets with different versions are ambiguous.
+func singleVersion(found []DetectedAppVersion, notFound string) (DetectedAppVersion, error) {
+	if len(found) == 0 {
+		return DetectedAppVersion{}, errors.New(notFound)
+	}
+	for _, v := range found[1:] {
+		if v.Version != found[0].Version {
+			return DetectedAppVersion{}, fmt.Errorf("found different versions %s (%s) and %s (%s): set --app-version",
+				found[0].Version, found[0].Source, v.Version, v.Source)
+		}
+	}
+	return found[0], nil
+}
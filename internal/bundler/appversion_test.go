@@ -0,0 +1,146 @@
+package bundler
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testInfoPlist = `<?xml version="1.0" encoding="UTF-8"?>
+<plist version="1.0">
+<dict>
+	<key>CFBundleShortVersionString</key>
+	<string>%s</string>
+</dict>
+</plist>`
+
+func infoPlist(version string) string {
+	return fmt.Sprintf(testInfoPlist, version)
+}
+
+func TestDetectAppVersion(t *testing.T) {
+	tests := []struct {
+		name     string
+		files    map[string]string
+		platform Platform
+		want     string
+		wantErr  string
+	}{
+		{
+			name:     "iOS literal version",
+			files:    map[string]string{"ios/MyApp/Info.plist": infoPlist("1.4.0")},
+			platform: PlatformIOS,
+			want:     "1.4.0",
+		},
+		{
+			name: "iOS marketing version",
+			files: map[string]string{
+				"ios/MyApp/Info.plist":                infoPlist("$(MARKETING_VERSION)"),
+				"ios/MyApp.xcodeproj/project.pbxproj": "MARKETING_VERSION = 2.1;\nMARKETING_VERSION = 2.1;\n",
+			},
+			platform: PlatformIOS,
+			want:     "2.1",
+		},
+		{
+			name: "iOS test targets are ignored",
+			files: map[string]string{
+				"ios/MyApp/Info.plist":      infoPlist("1.4.0"),
+				"ios/MyAppTests/Info.plist": infoPlist("1.0"),
+			},
+			platform: PlatformIOS,
+			want:     "1.4.0",
+		},
+		{
+			name: "iOS differing marketing versions",
+			files: map[string]string{
+				"ios/MyApp/Info.plist":                infoPlist("$(MARKETING_VERSION)"),
+				"ios/MyApp.xcodeproj/project.pbxproj": "MARKETING_VERSION = 2.1;\nMARKETING_VERSION = 2.2;\n",
+			},
+			platform: PlatformIOS,
+			wantErr:  "found different versions 2.1",
+		},
+		{
+			name:     "iOS other build setting",
+			files:    map[string]string{"ios/MyApp/Info.plist": infoPlist("${APP_VERSION}")},
+			platform: PlatformIOS,
+			wantErr:  "build setting APP_VERSION",
+		},
+		{
+			name:     "Android groovy",
+			files:    map[string]string{"android/app/build.gradle": "defaultConfig {\n    versionCode 12\n    versionName \"3.0.1\"\n}"},
+			platform: PlatformAndroid,
+			want:     "3.0.1",
+		},
+		{
+			name:     "Android kotlin DSL",
+			files:    map[string]string{"android/app/build.gradle.kts": "defaultConfig {\n    versionName = \"3.0.2\"\n}"},
+			platform: PlatformAndroid,
+			want:     "3.0.2",
+		},
+		{
+			name:     "Android missing",
+			platform: PlatformAndroid,
+			wantErr:  "no versionName found",
+		},
+		{
+			name: "both platforms agree",
+			files: map[string]string{
+				"ios/MyApp/Info.plist":     infoPlist("1.4.0"),
+				"android/app/build.gradle": `versionName "1.4.0"`,
+			},
+			want: "1.4.0",
+		},
+		{
+			name: "both platforms differ",
+			files: map[string]string{
+				"ios/MyApp/Info.plist":     infoPlist("1.4.0"),
+				"android/app/build.gradle": `versionName "1.5.0"`,
+			},
+			wantErr: "differ: set --platform",
+		},
+		{
+			name:  "only one platform present",
+			files: map[string]string{"android/app/build.gradle": `versionName "1.5.0"`},
+			want:  "1.5.0",
+		},
+		{
+			name:    "nothing found",
+			wantErr: "no CFBundleShortVersionString found",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			for name, content := range tt.files {
+				path := filepath.Join(dir, name)
+				require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+				writeFile(t, path, content)
+			}
+
+			got, err := DetectAppVersion(dir, tt.platform, "")
+			if tt.wantErr != "" {
+				assert.ErrorContains(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got.Version)
+			assert.NotEmpty(t, got.Source)
+		})
+	}
+
+	t.Run("gradle file override", func(t *testing.T) {
+		dir := t.TempDir()
+		require.NoError(t, os.MkdirAll(filepath.Join(dir, "android", "app"), 0o755))
+		writeFile(t, filepath.Join(dir, "android", "app", "build.gradle"), `versionName "1.0.0"`)
+		writeFile(t, filepath.Join(dir, "android", "app", "custom.gradle"), `versionName "9.9.9"`)
+
+		got, err := DetectAppVersion(dir, PlatformAndroid, "android/app/custom.gradle")
+		require.NoError(t, err)
+		assert.Equal(t, "9.9.9", got.Version)
+	})
+}
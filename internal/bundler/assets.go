@@ -0,0 +1,293 @@
+package bundler
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"image/jpeg"
+	"image/png"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/bitrise-io/bitrise-plugins-codepush-cli/internal/output"
+)
+
+// External tools used for formats the standard library cannot re-encode
+// losslessly. Assets of a format whose tool is missing are left as they are.
+const (
+	jpegtranTool = "jpegtran"
+	cwebpTool    = "cwebp"
+)
+
+// lookPath finds optimizer tools; tests override it.
+var lookPath = exec.LookPath
+
+// iosScaleSuffix matches the scale suffix of an iOS asset variant, such as
+// "@2x" in "logo@2x.png".
+var iosScaleSuffix = regexp.MustCompile(`@[0-9.]+x$`)
+
+// AssetReport summarizes an asset optimization pass.
+type AssetReport struct {
+	Recompressed int   `json:"recompressed"`
+	Stripped     int   `json:"stripped"`
+	BytesBefore  int64 `json:"bytes_before"`
+	BytesAfter   int64 `json:"bytes_after"`
+	// Skipped lists image formats that were not recompressed because the
+	// tool needed for them is not installed.
+	Skipped []string `json:"skipped,omitempty"`
+}
+
+// Saved returns the number of bytes the pass removed.
+func (r *AssetReport) Saved() int64 {
+	return r.BytesBefore - r.BytesAfter
+}
+
+// OptimizeAssets shrinks the image assets under assetsDir in place. It
+// removes density variants no supported device loads, then recompresses
+// PNG, JPEG, and WebP files, keeping a result only when it is smaller.
+//
+// With quality 0 recompression is lossless: PNGs are re-encoded at the best
+// compression level, JPEGs are optimized with jpegtran, and WebPs are
+// re-encoded losslessly with cwebp. A quality from 1 to 100 re-encodes JPEG
+// and WebP files lossily at that quality; PNGs stay lossless.
+func OptimizeAssets(ctx context.Context, assetsDir string, platform Platform, quality int, executor CommandExecutor, out *output.Writer) (*AssetReport, error) {
+	report := &AssetReport{}
+
+	var images []string
+	err := filepath.WalkDir(assetsDir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() || imageFormat(path) == "" {
+			return err
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		report.BytesBefore += info.Size()
+		images = append(images, path)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("reading assets: %w", err)
+	}
+
+	images, err = stripDensityVariants(images, platform, report)
+	if err != nil {
+		return nil, err
+	}
+
+	opt := &imageOptimizer{quality: quality, executor: executor, tools: map[string]string{}, report: report}
+	for _, path := range images {
+		changed, err := opt.recompress(ctx, path, imageFormat(path))
+		if err != nil {
+			out.Warning("could not optimize %s: %v", filepath.Base(path), err)
+		} else if changed {
+			report.Recompressed++
+		}
+
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, fmt.Errorf("reading asset %s: %w", path, err)
+		}
+		report.BytesAfter += info.Size()
+	}
+
+	return report, nil
+}
+
+// imageFormat returns the optimizable image format of path by extension, or
+// "" for other files.
+func imageFormat(path string) string {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".png":
+		return "png"
+	case ".jpg", ".jpeg":
+		return "jpeg"
+	case ".webp":
+		return "webp"
+	}
+	return ""
+}
+
+// stripDensityVariants deletes density variants that no device supported by
+// React Native loads, when a higher density of the same asset exists: @1x
+// variants on iOS, whose supported devices are all @2x or @3x, and ldpi
+// variants on Android. Returns the remaining images.
+func stripDensityVariants(images []string, platform Platform, report *AssetReport) ([]string, error) {
+	present := make(map[string]bool, len(images))
+	for _, path := range images {
+		present[path] = true
+	}
+
+	kept := images[:0]
+	for _, path := range images {
+		if !isUnusedVariant(path, platform, present) {
+			kept = append(kept, path)
+			continue
+		}
+		if err := os.Remove(path); err != nil {
+			return nil, fmt.Errorf("removing unused asset variant: %w", err)
+		}
+		report.Stripped++
+	}
+	return kept, nil
+}
+
+func isUnusedVariant(path string, platform Platform, present map[string]bool) bool {
+	dir, name := filepath.Split(path)
+	ext := filepath.Ext(name)
+	base := strings.TrimSuffix(name, ext)
+
+	switch platform {
+	case PlatformIOS:
+		if iosScaleSuffix.MatchString(base) {
+			return false
+		}
+		return present[filepath.Join(dir, base+"@2x"+ext)] || present[filepath.Join(dir, base+"@3x"+ext)]
+	case PlatformAndroid:
+		if filepath.Base(dir) != "drawable-ldpi" {
+			return false
+		}
+		parent := filepath.Dir(filepath.Clean(dir))
+		for _, density := range []string{"mdpi", "hdpi", "xhdpi", "xxhdpi", "xxxhdpi"} {
+			if present[filepath.Join(parent, "drawable-"+density, name)] {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// imageOptimizer recompresses the images of one OptimizeAssets pass.
+type imageOptimizer struct {
+	quality  int
+	executor CommandExecutor
+	// tools caches the resolved path of each external tool, "" when it is
+	// not installed.
+	tools  map[string]string
+	report *AssetReport
+}
+
+// recompress re-encodes one image and replaces it when the result is
+// smaller. Reports whether the file was replaced.
+func (o *imageOptimizer) recompress(ctx context.Context, path, format string) (bool, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return false, err
+	}
+
+	var optimized []byte
+	switch {
+	case format == "png":
+		optimized, err = recompressPNG(data)
+	case format == "jpeg" && o.quality > 0:
+		optimized, err = recompressJPEG(data, o.quality)
+	case format == "jpeg":
+		optimized, err = o.run(ctx, path, jpegtranTool, "-optimize", "-copy", "none", path)
+	case o.quality > 0:
+		optimized, err = o.run(ctx, path, cwebpTool, "-quiet", "-q", fmt.Sprint(o.quality), path, "-o", "-")
+	default:
+		optimized, err = o.run(ctx, path, cwebpTool, "-quiet", "-lossless", "-exact", path, "-o", "-")
+	}
+	if err != nil || optimized == nil || len(optimized) >= len(data) {
+		return false, err
+	}
+
+	if err := os.WriteFile(path, optimized, 0o644); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// recompressPNG re-encodes a PNG at the best compression level. Animated
+// PNGs are returned as nil, since the decoder only keeps the first frame.
+func recompressPNG(data []byte) ([]byte, error) {
+	if isAnimatedPNG(data) {
+		return nil, nil
+	}
+	img, err := png.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("decoding PNG: %w", err)
+	}
+	var buf bytes.Buffer
+	enc := png.Encoder{CompressionLevel: png.BestCompression}
+	if err := enc.Encode(&buf, img); err != nil {
+		return nil, fmt.Errorf("encoding PNG: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// isAnimatedPNG reports whether data has an APNG animation control chunk
+// before its image data.
+func isAnimatedPNG(data []byte) bool {
+	end := bytes.Index(data, []byte("IDAT"))
+	if end < 0 {
+		end = len(data)
+	}
+	return bytes.Contains(data[:end], []byte("acTL"))
+}
+
+// recompressJPEG re-encodes a JPEG at quality. JPEGs with EXIF metadata are
+// returned as nil: re-encoding drops the orientation tag, which would
+// display the image rotated.
+func recompressJPEG(data []byte, quality int) ([]byte, error) {
+	if bytes.Contains(data, []byte("Exif\x00\x00")) {
+		return nil, nil
+	}
+	img, err := jpeg.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("decoding JPEG: %w", err)
+	}
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, img, &jpeg.Options{Quality: quality}); err != nil {
+		return nil, fmt.Errorf("encoding JPEG: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// run runs an external optimizer that writes the result to stdout. When
+// the tool is not installed it returns nil and records the skip once.
+func (o *imageOptimizer) run(ctx context.Context, path, tool string, args ...string) ([]byte, error) {
+	toolPath, ok := o.tools[tool]
+	if !ok {
+		resolved, err := lookPath(tool)
+		if err != nil {
+			o.report.Skipped = append(o.report.Skipped, imageFormat(path))
+		}
+		toolPath = resolved
+		o.tools[tool] = toolPath
+	}
+	if toolPath == "" {
+		return nil, nil
+	}
+
+	var stdout, stderr bytes.Buffer
+	if err := o.executor.Run(ctx, filepath.Dir(path), &stdout, &stderr, toolPath, args...); err != nil {
+		msg := strings.TrimSpace(stderr.String())
+		if msg == "" || hasOutput(err) {
+			return nil, fmt.Errorf("%s failed: %w", tool, err)
+		}
+		return nil, fmt.Errorf("%s failed: %w: %s", tool, err, msg)
+	}
+	if stdout.Len() == 0 {
+		return nil, errors.New(tool + " produced no output")
+	}
+	return stdout.Bytes(), nil
+}
+
+// skippedFormatsMessage describes formats left unoptimized for lack of a tool.
+func skippedFormatsMessage(skipped []string) string {
+	hints := make([]string, len(skipped))
+	for i, format := range skipped {
+		tool := cwebpTool
+		if format == "jpeg" {
+			tool = jpegtranTool
+		}
+		hints[i] = fmt.Sprintf("%s assets were not recompressed: install %s", strings.ToUpper(format), tool)
+	}
+	return strings.Join(hints, "; ")
+}
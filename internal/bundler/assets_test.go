@@ -0,0 +1,191 @@
+package bundler
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/bitrise-io/bitrise-plugins-codepush-cli/internal/output"
+)
+
+// testImage returns a smooth gradient that compresses well.
+func testImage() image.Image {
+	img := image.NewRGBA(image.Rect(0, 0, 64, 64))
+	for y := range 64 {
+		for x := range 64 {
+			img.Set(x, y, color.RGBA{R: uint8(x * 4), G: uint8(y * 4), B: 128, A: 255})
+		}
+	}
+	return img
+}
+
+// uncompressedPNG encodes a PNG without compression, so recompression
+// always shrinks it.
+func uncompressedPNG(t *testing.T) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	enc := png.Encoder{CompressionLevel: png.NoCompression}
+	require.NoError(t, enc.Encode(&buf, testImage()))
+	return buf.Bytes()
+}
+
+func writeAsset(t *testing.T, path string, data []byte) {
+	t.Helper()
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+	require.NoError(t, os.WriteFile(path, data, 0o644))
+}
+
+func withLookPath(t *testing.T, fn func(string) (string, error)) {
+	t.Helper()
+	orig := lookPath
+	lookPath = fn
+	t.Cleanup(func() { lookPath = orig })
+}
+
+func notFound(string) (string, error) { return "", errors.New("not found") }
+
+func TestOptimizeAssets(t *testing.T) {
+	out := output.NewTest(io.Discard)
+
+	t.Run("recompresses PNGs losslessly", func(t *testing.T) {
+		withLookPath(t, notFound)
+		dir := t.TempDir()
+		original := uncompressedPNG(t)
+		path := filepath.Join(dir, "assets", "logo.png")
+		writeAsset(t, path, original)
+		writeAsset(t, filepath.Join(dir, "assets", "data.json"), []byte("{}"))
+
+		report, err := OptimizeAssets(context.Background(), dir, PlatformAndroid, 0, &mockExecutor{}, out)
+		require.NoError(t, err)
+
+		assert.Equal(t, 1, report.Recompressed)
+		assert.Equal(t, int64(len(original)), report.BytesBefore)
+		assert.Positive(t, report.Saved())
+		assert.Empty(t, report.Skipped)
+
+		optimized, err := os.ReadFile(path)
+		require.NoError(t, err)
+		before, err := png.Decode(bytes.NewReader(original))
+		require.NoError(t, err)
+		after, err := png.Decode(bytes.NewReader(optimized))
+		require.NoError(t, err)
+		for y := range 64 {
+			for x := range 64 {
+				require.Equal(t, before.At(x, y), after.At(x, y))
+			}
+		}
+	})
+
+	t.Run("keeps files that would grow", func(t *testing.T) {
+		withLookPath(t, notFound)
+		dir := t.TempDir()
+		var buf bytes.Buffer
+		require.NoError(t, (&png.Encoder{CompressionLevel: png.BestCompression}).Encode(&buf, testImage()))
+		writeAsset(t, filepath.Join(dir, "logo.png"), buf.Bytes())
+
+		report, err := OptimizeAssets(context.Background(), dir, PlatformAndroid, 0, &mockExecutor{}, out)
+		require.NoError(t, err)
+		assert.Zero(t, report.Recompressed)
+		assert.Zero(t, report.Saved())
+	})
+
+	t.Run("lossy JPEG with quality", func(t *testing.T) {
+		withLookPath(t, notFound)
+		dir := t.TempDir()
+		var buf bytes.Buffer
+		require.NoError(t, jpeg.Encode(&buf, testImage(), &jpeg.Options{Quality: 100}))
+		writeAsset(t, filepath.Join(dir, "photo.jpg"), buf.Bytes())
+
+		report, err := OptimizeAssets(context.Background(), dir, PlatformIOS, 50, &mockExecutor{}, out)
+		require.NoError(t, err)
+		assert.Equal(t, 1, report.Recompressed)
+		assert.Positive(t, report.Saved())
+	})
+
+	t.Run("lossless JPEG and WebP use external tools", func(t *testing.T) {
+		withLookPath(t, func(name string) (string, error) { return "/usr/bin/" + name, nil })
+		dir := t.TempDir()
+		writeAsset(t, filepath.Join(dir, "photo.jpg"), bytes.Repeat([]byte("j"), 100))
+		writeAsset(t, filepath.Join(dir, "icon.webp"), bytes.Repeat([]byte("w"), 100))
+
+		executor := &mockExecutor{stdout: func(name string, args ...string) string { return "small" }}
+		report, err := OptimizeAssets(context.Background(), dir, PlatformIOS, 0, executor, out)
+		require.NoError(t, err)
+
+		assert.Equal(t, 2, report.Recompressed)
+		require.Len(t, executor.commands, 2)
+		names := []string{executor.commands[0].name, executor.commands[1].name}
+		assert.ElementsMatch(t, []string{"/usr/bin/jpegtran", "/usr/bin/cwebp"}, names)
+		for _, c := range executor.commands {
+			if c.name == "/usr/bin/cwebp" {
+				assert.Contains(t, c.args, "-lossless")
+			}
+		}
+
+		data, err := os.ReadFile(filepath.Join(dir, "icon.webp"))
+		require.NoError(t, err)
+		assert.Equal(t, "small", string(data))
+	})
+
+	t.Run("reports formats whose tool is missing", func(t *testing.T) {
+		withLookPath(t, notFound)
+		dir := t.TempDir()
+		writeAsset(t, filepath.Join(dir, "a.webp"), []byte("webp"))
+		writeAsset(t, filepath.Join(dir, "b.webp"), []byte("webp"))
+
+		executor := &mockExecutor{}
+		report, err := OptimizeAssets(context.Background(), dir, PlatformIOS, 0, executor, out)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"webp"}, report.Skipped)
+		assert.Empty(t, executor.commands)
+		assert.Equal(t, "WEBP assets were not recompressed: install cwebp", skippedFormatsMessage(report.Skipped))
+	})
+
+	t.Run("strips iOS @1x variants", func(t *testing.T) {
+		withLookPath(t, notFound)
+		dir := t.TempDir()
+		for _, name := range []string{"logo.png", "logo@2x.png", "logo@3x.png", "icon.png"} {
+			writeAsset(t, filepath.Join(dir, "assets", "img", name), uncompressedPNG(t))
+		}
+
+		report, err := OptimizeAssets(context.Background(), dir, PlatformIOS, 0, &mockExecutor{}, out)
+		require.NoError(t, err)
+
+		assert.Equal(t, 1, report.Stripped)
+		assert.NoFileExists(t, filepath.Join(dir, "assets", "img", "logo.png"))
+		assert.FileExists(t, filepath.Join(dir, "assets", "img", "logo@2x.png"))
+		assert.FileExists(t, filepath.Join(dir, "assets", "img", "icon.png"))
+	})
+
+	t.Run("strips Android ldpi variants", func(t *testing.T) {
+		withLookPath(t, notFound)
+		dir := t.TempDir()
+		writeAsset(t, filepath.Join(dir, "drawable-ldpi", "img_logo.png"), uncompressedPNG(t))
+		writeAsset(t, filepath.Join(dir, "drawable-mdpi", "img_logo.png"), uncompressedPNG(t))
+		writeAsset(t, filepath.Join(dir, "drawable-ldpi", "img_only.png"), uncompressedPNG(t))
+
+		report, err := OptimizeAssets(context.Background(), dir, PlatformAndroid, 0, &mockExecutor{}, out)
+		require.NoError(t, err)
+
+		assert.Equal(t, 1, report.Stripped)
+		assert.NoFileExists(t, filepath.Join(dir, "drawable-ldpi", "img_logo.png"))
+		assert.FileExists(t, filepath.Join(dir, "drawable-ldpi", "img_only.png"))
+	})
+}
+
+func TestIsAnimatedPNG(t *testing.T) {
+	assert.True(t, isAnimatedPNG([]byte("\x89PNG....IHDR....acTL....IDAT....")))
+	assert.False(t, isAnimatedPNG([]byte("\x89PNG....IHDR....IDAT....acTL")))
+	assert.False(t, isAnimatedPNG(uncompressedPNG(t)))
+}
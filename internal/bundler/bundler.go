@@ -1,12 +1,17 @@
 package bundler
 
 import (
+	"context"
 	"fmt"
 	"io"
 	"os"
 	"os/exec"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/bitrise-io/bitrise-plugins-codepush-cli/internal/output"
+	"github.com/bitrise-io/bitrise-plugins-codepush-cli/internal/workspace"
 )
 
 // DefaultOutputDir is the default output directory for bundle generation.
@@ -38,8 +43,10 @@ type BundleOptions struct {
 	OutputDir        string
 	BundleName       string
 	Dev              bool
-	Minify           bool // Expo only: pass --minify to expo export:embed
-	ResetCache       bool // pass --reset-cache to the bundler (Metro/expo export:embed)
+	Minify           *bool // nil leaves minification to the bundler: Metro minifies unless Dev, Expo does not
+	RamBundle        bool  // React Native only: build a RAM bundle with "react-native ram-bundle"
+	MaxWorkers       int   // bundler worker count, 0 for the bundler's default
+	ResetCache       bool  // pass --reset-cache to the bundler (Metro/expo export:embed; ignored by Re.Pack)
 	Sourcemap        bool
 	SourcemapOutput  string // when set, overrides the auto-derived sourcemap path and implies Sourcemap=true
 	HermesMode       HermesMode
@@ -48,8 +55,16 @@ type BundleOptions struct {
 	ProjectDir       string
 	MetroConfig      string
 	SkipInstall      bool
-	GradleFile       string // override path for android/app/build.gradle (Hermes auto-detection)
-	PodFile          string // override path for ios/Podfile (Hermes auto-detection)
+	GradleFile       string               // override path for android/app/build.gradle (Hermes auto-detection)
+	PodFile          string               // override path for ios/Podfile (Hermes auto-detection)
+	OptimizeAssets   bool                 // recompress image assets and strip unused density variants
+	AssetQuality     int                  // with OptimizeAssets: lossy JPEG/WebP quality 1-100, 0 for lossless
+	CommandTimeout   time.Duration        // limit on each bundler command's run time, 0 for none
+	LogFile          string               // when set, the full output of every command is appended to this file
+	PackageManager   PackageManager       // installs dependencies and runs the bundler; detected when empty
+	SkipNodeCheck    bool                 // don't check the Node.js version against engines.node and .nvmrc
+	UseNodeVersion   bool                 // on a Node.js version mismatch, run the commands through fnm or nvm with the .nvmrc version
+	Workspace        *workspace.Workspace // holds intermediate builds and cache staging; nil uses the system temp directory
 }
 
 // BundleResult contains the output of a successful bundle operation.
@@ -61,28 +76,126 @@ type BundleResult struct {
 	HermesApplied bool
 	ProjectType   ProjectType
 	Platform      Platform
+	// RuntimeVersion is the runtime version resolved from the Expo config, or
+	// empty when it could not be determined.
+	RuntimeVersion string
+	// Assets summarizes asset optimization, nil when it did not run.
+	Assets *AssetReport
 }
 
 // Bundler is the interface for building a JS bundle.
 type Bundler interface {
-	Bundle(config *ProjectConfig, opts *BundleOptions) (*BundleResult, error)
+	Bundle(ctx context.Context, config *ProjectConfig, opts *BundleOptions) (*BundleResult, error)
 }
 
 // CommandExecutor abstracts subprocess execution for testing.
 type CommandExecutor interface {
-	Run(dir string, stdout io.Writer, stderr io.Writer, name string, args ...string) error
+	Run(ctx context.Context, dir string, stdout io.Writer, stderr io.Writer, name string, args ...string) error
 }
 
-// DefaultExecutor implements CommandExecutor using os/exec.
-type DefaultExecutor struct{}
+// DefaultExecutor implements CommandExecutor using os/exec. A command that
+// fails returns a *CommandError with the tail of its output.
+type DefaultExecutor struct {
+	// Env holds additional KEY=value pairs appended to the inherited environment.
+	Env []string
+	// Timeout limits the run time of each command; 0 means no limit.
+	Timeout time.Duration
+	// GracePeriod is how long a stopped command gets to exit after SIGTERM
+	// before it is killed; 0 means DefaultGracePeriod.
+	GracePeriod time.Duration
+}
 
-// Run executes a command with the given args in the given directory.
-func (e *DefaultExecutor) Run(dir string, stdout io.Writer, stderr io.Writer, name string, args ...string) error {
+// Run executes a command with the given args in the given directory,
+// stopping it when ctx is done.
+func (e *DefaultExecutor) Run(ctx context.Context, dir string, stdout io.Writer, stderr io.Writer, name string, args ...string) error {
 	cmd := exec.Command(name, args...)
 	cmd.Dir = dir
-	cmd.Stdout = stdout
-	cmd.Stderr = stderr
-	return cmd.Run()
+	if len(e.Env) > 0 {
+		cmd.Env = append(os.Environ(), e.Env...)
+	}
+	tail := &tailWriter{}
+	cmd.Stdout = io.MultiWriter(stdout, tail)
+	cmd.Stderr = io.MultiWriter(stderr, tail)
+	setProcessGroup(cmd)
+	return e.run(ctx, cmd, cmd.Start, tail)
+}
+
+// RunPTY runs a command on a PTY, with the environment and limits of e.
+func (e *DefaultExecutor) RunPTY(ctx context.Context, dir string, w io.Writer, name string, args ...string) error {
+	return runWithPTY(ctx, e, dir, w, name, args...)
+}
+
+// ptyRunner is implemented by executors that can run a command on a PTY,
+// writing its merged output to w.
+type ptyRunner interface {
+	RunPTY(ctx context.Context, dir string, w io.Writer, name string, args ...string) error
+}
+
+// runOnPTY runs a command on a PTY when executor supports it, and as a
+// plain command writing its stderr to w otherwise.
+func runOnPTY(ctx context.Context, executor CommandExecutor, dir string, w io.Writer, name string, args ...string) error {
+	if r, ok := executor.(ptyRunner); ok {
+		return r.RunPTY(ctx, dir, w, name, args...)
+	}
+	return executor.Run(ctx, dir, io.Discard, w, name, args...)
+}
+
+// debugExecutor logs the command line and duration of every command it runs
+// as debug messages.
+type debugExecutor struct {
+	next CommandExecutor
+	out  *output.Writer
+}
+
+func (e *debugExecutor) Run(ctx context.Context, dir string, stdout io.Writer, stderr io.Writer, name string, args ...string) error {
+	return debugRun(e.out, dir, name, args, func() error {
+		return e.next.Run(ctx, dir, stdout, stderr, name, args...)
+	})
+}
+
+func (e *debugExecutor) RunPTY(ctx context.Context, dir string, w io.Writer, name string, args ...string) error {
+	return debugRun(e.out, dir, name, args, func() error {
+		return runOnPTY(ctx, e.next, dir, w, name, args...)
+	})
+}
+
+// debugRun calls run, logging the command it runs before and after.
+func debugRun(out *output.Writer, dir, name string, args []string, run func() error) error {
+	if !out.DebugEnabled() {
+		return run()
+	}
+	command := commandLine(name, args)
+	out.Debug("running command", "dir", dir, "command", command)
+	start := time.Now()
+	err := run()
+	logArgs := []any{"command", command, "duration", time.Since(start).Round(time.Millisecond)}
+	if err != nil {
+		logArgs = append(logArgs, "error", err)
+	}
+	out.Debug("command finished", logArgs...)
+	return err
+}
+
+// commandLine formats a command for display, quoting arguments that
+// contain spaces or quotes.
+func commandLine(name string, args []string) string {
+	parts := make([]string, 0, len(args)+1)
+	for _, a := range append([]string{name}, args...) {
+		if a == "" || strings.ContainsAny(a, " \t\"'") {
+			a = strconv.Quote(a)
+		}
+		parts = append(parts, a)
+	}
+	return strings.Join(parts, " ")
+}
+
+// runBundleCommand runs a bundler command, on a PTY in an interactive
+// terminal so it shows its progress output, writing its output to w.
+func runBundleCommand(ctx context.Context, executor CommandExecutor, out *output.Writer, dir string, w io.Writer, name string, args ...string) error {
+	if out.IsInteractive() {
+		return runOnPTY(ctx, executor, dir, w, name, args...)
+	}
+	return executor.Run(ctx, dir, io.Discard, w, name, args...)
 }
 
 // NewBundler creates the appropriate Bundler implementation based on project type.
@@ -92,6 +205,8 @@ func NewBundler(projectType ProjectType, executor CommandExecutor, out *output.W
 		return &ReactNativeBundler{executor: executor, out: out}, nil
 	case ProjectTypeExpo:
 		return &ExpoBundler{executor: executor, out: out}, nil
+	case ProjectTypeRePack:
+		return &RePackBundler{executor: executor, out: out}, nil
 	default:
 		return nil, fmt.Errorf("unsupported project type: %s", projectType)
 	}
@@ -1,6 +1,9 @@
 package bundler
 
 import (
+	"bytes"
+	"context"
+	"errors"
 	"io"
 	"os"
 	"path/filepath"
@@ -18,6 +21,8 @@ type mockExecutor struct {
 	err      error
 	// onRun is called during Run, allowing tests to create output files.
 	onRun func(dir string, name string, args ...string)
+	// stdout, when set, returns the output written to the command's stdout.
+	stdout func(name string, args ...string) string
 }
 
 type executedCommand struct {
@@ -26,11 +31,14 @@ type executedCommand struct {
 	args []string
 }
 
-func (m *mockExecutor) Run(dir string, _ io.Writer, _ io.Writer, name string, args ...string) error {
+func (m *mockExecutor) Run(_ context.Context, dir string, stdout io.Writer, _ io.Writer, name string, args ...string) error {
 	m.commands = append(m.commands, executedCommand{dir: dir, name: name, args: args})
 	if m.onRun != nil {
 		m.onRun(dir, name, args...)
 	}
+	if m.stdout != nil && stdout != nil {
+		_, _ = io.WriteString(stdout, m.stdout(name, args...))
+	}
 	return m.err
 }
 
@@ -102,6 +110,11 @@ func TestNewBundler(t *testing.T) {
 			projectType: ProjectTypeExpo,
 			wantType:    "*bundler.ExpoBundler",
 		},
+		{
+			name:        "repack bundler",
+			projectType: ProjectTypeRePack,
+			wantType:    "*bundler.RePackBundler",
+		},
 		{
 			name:        "unknown project type",
 			projectType: ProjectTypeUnknown,
@@ -137,6 +150,140 @@ func TestDefaultBundleName(t *testing.T) {
 	}
 }
 
+func TestCommandLine(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+		want string
+	}{
+		{"npx", []string{"react-native", "bundle", "--dev", "false"}, "npx react-native bundle --dev false"},
+		{"npx", []string{"--entry-file", "src/my app.js"}, `npx --entry-file "src/my app.js"`},
+		{"node", []string{"-e", "console.log('hi')", ""}, `node -e "console.log('hi')" ""`},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, commandLine(tt.name, tt.args))
+	}
+}
+
+func TestDebugExecutor(t *testing.T) {
+	var buf bytes.Buffer
+	out := output.NewTest(&buf)
+	mock := &mockExecutor{err: errors.New("exit status 1")}
+	executor := &debugExecutor{next: mock, out: out}
+
+	err := executor.Run(context.Background(), "/project", io.Discard, io.Discard, "npx", "react-native", "bundle")
+	assert.EqualError(t, err, "exit status 1")
+	assert.Len(t, mock.commands, 1)
+	assert.Empty(t, buf.String(), "nothing is logged unless debug logging is enabled")
+
+	out.SetLevel(output.LevelDebug)
+	_ = executor.Run(context.Background(), "/project", io.Discard, io.Discard, "npx", "react-native", "bundle")
+	assert.Contains(t, buf.String(), `msg="running command" dir=/project command="npx react-native bundle"`)
+	assert.Contains(t, buf.String(), `msg="command finished" command="npx react-native bundle" duration=`)
+	assert.Contains(t, buf.String(), `error="exit status 1"`)
+}
+
+func TestRunOnPTY(t *testing.T) {
+	mock := &mockExecutor{}
+	var buf bytes.Buffer
+	out := output.NewTest(&buf)
+	out.SetLevel(output.LevelDebug)
+
+	require.NoError(t, runOnPTY(context.Background(), &debugExecutor{next: mock, out: out}, "/project", io.Discard, "npx", "react-native", "bundle"))
+	require.Len(t, mock.commands, 1, "executors without PTY support run the command directly")
+	assert.Equal(t, "npx", mock.commands[0].name)
+	assert.Contains(t, buf.String(), `msg="running command" dir=/project command="npx react-native bundle"`)
+}
+
+func TestRePackBundlerBundle(t *testing.T) {
+	t.Run("runs webpack-bundle with the detected config", func(t *testing.T) {
+		outputDir := t.TempDir()
+		executor := &mockExecutor{}
+
+		executor.onRun = func(_ string, _ string, _ ...string) {
+			bundlePath := filepath.Join(outputDir, "index.android.bundle")
+			os.WriteFile(bundlePath, []byte("bundle"), 0o644)
+			os.WriteFile(bundlePath+".map", []byte("sourcemap"), 0o644)
+		}
+
+		bundler := &RePackBundler{executor: executor, out: output.NewTest(io.Discard)}
+		config := &ProjectConfig{
+			ProjectDir:    "/project",
+			ProjectType:   ProjectTypeRePack,
+			Platform:      PlatformAndroid,
+			EntryFile:     "index.js",
+			MetroConfig:   "/project/metro.config.js",
+			WebpackConfig: "/project/rspack.config.mjs",
+		}
+		opts := &BundleOptions{
+			Platform:   PlatformAndroid,
+			OutputDir:  outputDir,
+			Sourcemap:  true,
+			ResetCache: true,
+		}
+
+		result, err := bundler.Bundle(context.Background(), config, opts)
+		require.NoError(t, err)
+
+		assert.Equal(t, ProjectTypeRePack, result.ProjectType)
+		assert.Equal(t, result.BundlePath+".map", result.SourcemapPath)
+
+		require.Len(t, executor.commands, 1)
+		cmd := executor.commands[0]
+		assert.Equal(t, "npx", cmd.name)
+		assert.Equal(t, []string{"react-native", "webpack-bundle"}, cmd.args[:2])
+		assertContainsArgs(t, cmd.args, "--entry-file", "index.js")
+		assertContainsArgs(t, cmd.args, "--platform", "android")
+		assertContainsArgs(t, cmd.args, "--dev", "false")
+		assertContainsArgs(t, cmd.args, "--sourcemap-output", result.BundlePath+".map")
+		assertContainsArgs(t, cmd.args, "--webpackConfig", "/project/rspack.config.mjs")
+		assert.NotContains(t, cmd.args, "--reset-cache")
+		assert.NotContains(t, cmd.args, "--config")
+	})
+
+	t.Run("config option overrides the detected config", func(t *testing.T) {
+		outputDir := t.TempDir()
+		executor := &mockExecutor{}
+
+		executor.onRun = func(_ string, _ string, _ ...string) {
+			os.WriteFile(filepath.Join(outputDir, "main.jsbundle"), []byte("bundle"), 0o644)
+		}
+
+		bundler := &RePackBundler{executor: executor, out: output.NewTest(io.Discard)}
+		config := &ProjectConfig{
+			ProjectDir:    "/project",
+			Platform:      PlatformIOS,
+			EntryFile:     "index.js",
+			WebpackConfig: "/project/webpack.config.js",
+		}
+		opts := &BundleOptions{
+			Platform:         PlatformIOS,
+			OutputDir:        outputDir,
+			Dev:              true,
+			MetroConfig:      "custom.config.js",
+			ExtraBundlerOpts: []string{"--verbose"},
+		}
+
+		_, err := bundler.Bundle(context.Background(), config, opts)
+		require.NoError(t, err)
+
+		cmd := executor.commands[0]
+		assertContainsArgs(t, cmd.args, "--dev", "true")
+		assertContainsArgs(t, cmd.args, "--webpackConfig", "custom.config.js")
+		assert.Equal(t, "--verbose", cmd.args[len(cmd.args)-1])
+	})
+
+	t.Run("missing bundle is an error", func(t *testing.T) {
+		bundler := &RePackBundler{executor: &mockExecutor{}, out: output.NewTest(io.Discard)}
+		config := &ProjectConfig{ProjectDir: "/project", EntryFile: "index.js"}
+		opts := &BundleOptions{Platform: PlatformIOS, OutputDir: t.TempDir()}
+
+		_, err := bundler.Bundle(context.Background(), config, opts)
+		assert.ErrorContains(t, err, "bundle file was not created")
+	})
+}
+
 func TestReactNativeBundlerBundle(t *testing.T) {
 	t.Run("basic iOS bundle", func(t *testing.T) {
 		outputDir := t.TempDir()
@@ -163,7 +310,7 @@ func TestReactNativeBundlerBundle(t *testing.T) {
 			Sourcemap: true,
 		}
 
-		result, err := bundler.Bundle(config, opts)
+		result, err := bundler.Bundle(context.Background(), config, opts)
 		require.NoError(t, err)
 
 		assert.Equal(t, PlatformIOS, result.Platform)
@@ -207,7 +354,7 @@ func TestReactNativeBundlerBundle(t *testing.T) {
 			Sourcemap:  false,
 		}
 
-		result, err := bundler.Bundle(config, opts)
+		result, err := bundler.Bundle(context.Background(), config, opts)
 		require.NoError(t, err)
 
 		assert.Equal(t, "custom.bundle", filepath.Base(result.BundlePath))
@@ -243,7 +390,7 @@ func TestReactNativeBundlerBundle(t *testing.T) {
 			Sourcemap: false,
 		}
 
-		_, err := bundler.Bundle(config, opts)
+		_, err := bundler.Bundle(context.Background(), config, opts)
 		require.NoError(t, err)
 
 		cmd := executor.commands[0]
@@ -271,7 +418,7 @@ func TestReactNativeBundlerBundle(t *testing.T) {
 			ExtraBundlerOpts: []string{"--max-workers", "4"},
 		}
 
-		_, err := bundler.Bundle(config, opts)
+		_, err := bundler.Bundle(context.Background(), config, opts)
 		require.NoError(t, err)
 
 		cmd := executor.commands[0]
@@ -292,7 +439,7 @@ func TestReactNativeBundlerBundle(t *testing.T) {
 		config := &ProjectConfig{ProjectDir: "/project", Platform: PlatformIOS, EntryFile: "index.js"}
 		opts := &BundleOptions{Platform: PlatformIOS, OutputDir: outputDir, ResetCache: true}
 
-		_, err := bundler.Bundle(config, opts)
+		_, err := bundler.Bundle(context.Background(), config, opts)
 		require.NoError(t, err)
 
 		cmd := executor.commands[0]
@@ -310,7 +457,7 @@ func TestReactNativeBundlerBundle(t *testing.T) {
 		config := &ProjectConfig{ProjectDir: "/project", Platform: PlatformIOS, EntryFile: "index.js"}
 		opts := &BundleOptions{Platform: PlatformIOS, OutputDir: outputDir, ResetCache: false}
 
-		_, err := bundler.Bundle(config, opts)
+		_, err := bundler.Bundle(context.Background(), config, opts)
 		require.NoError(t, err)
 
 		cmd := executor.commands[0]
@@ -333,9 +480,47 @@ func TestReactNativeBundlerBundle(t *testing.T) {
 			Sourcemap: false,
 		}
 
-		_, err := bundler.Bundle(config, opts)
+		_, err := bundler.Bundle(context.Background(), config, opts)
 		require.Error(t, err)
 	})
+
+	t.Run("RAM bundle with tuning flags", func(t *testing.T) {
+		outputDir := t.TempDir()
+		executor := &mockExecutor{}
+		executor.onRun = func(_ string, _ string, _ ...string) {
+			os.WriteFile(filepath.Join(outputDir, "main.jsbundle"), []byte("bundle"), 0o644)
+		}
+
+		bundler := &ReactNativeBundler{executor: executor, out: output.NewTest(io.Discard)}
+		config := &ProjectConfig{ProjectDir: "/project", ProjectType: ProjectTypeReactNative, Platform: PlatformIOS, EntryFile: "index.js"}
+		minify := false
+		opts := &BundleOptions{Platform: PlatformIOS, OutputDir: outputDir, RamBundle: true, Minify: &minify, MaxWorkers: 2}
+
+		_, err := bundler.Bundle(context.Background(), config, opts)
+		require.NoError(t, err)
+
+		args := executor.commands[0].args
+		assert.Equal(t, "ram-bundle", args[1])
+		assertContainsArgs(t, args, "--minify", "false")
+		assertContainsArgs(t, args, "--max-workers", "2")
+	})
+
+	t.Run("minify left to Metro by default", func(t *testing.T) {
+		outputDir := t.TempDir()
+		executor := &mockExecutor{}
+		executor.onRun = func(_ string, _ string, _ ...string) {
+			os.WriteFile(filepath.Join(outputDir, "main.jsbundle"), []byte("bundle"), 0o644)
+		}
+
+		bundler := &ReactNativeBundler{executor: executor, out: output.NewTest(io.Discard)}
+		config := &ProjectConfig{ProjectDir: "/project", ProjectType: ProjectTypeReactNative, Platform: PlatformIOS, EntryFile: "index.js"}
+		_, err := bundler.Bundle(context.Background(), config, &BundleOptions{Platform: PlatformIOS, OutputDir: outputDir})
+		require.NoError(t, err)
+
+		args := executor.commands[0].args
+		assert.NotContains(t, args, "--minify")
+		assert.NotContains(t, args, "--max-workers")
+	})
 }
 
 func TestExpoBundlerBundle(t *testing.T) {
@@ -366,7 +551,7 @@ func TestExpoBundlerBundle(t *testing.T) {
 			ResetCache: true,
 		}
 
-		result, err := bundler.Bundle(config, opts)
+		result, err := bundler.Bundle(context.Background(), config, opts)
 		require.NoError(t, err)
 
 		assert.Equal(t, ProjectTypeExpo, result.ProjectType)
@@ -405,14 +590,15 @@ func TestExpoBundlerBundle(t *testing.T) {
 			EntryFile:  "index.js",
 			BundleName: "main.jsbundle",
 		}
+		minify := true
 		opts := &BundleOptions{
 			Platform:   PlatformIOS,
 			OutputDir:  outputDir,
-			Minify:     true,
+			Minify:     &minify,
 			ResetCache: false,
 		}
 
-		_, err := bundler.Bundle(config, opts)
+		_, err := bundler.Bundle(context.Background(), config, opts)
 		require.NoError(t, err)
 
 		args := executor.commands[0].args
@@ -446,7 +632,7 @@ func TestExpoBundlerBundle(t *testing.T) {
 			BundleName: "override.jsbundle",
 		}
 
-		result, err := bundler.Bundle(config, opts)
+		result, err := bundler.Bundle(context.Background(), config, opts)
 		require.NoError(t, err)
 
 		assert.Equal(t, filepath.Join(outputDir, "override.jsbundle"), result.BundlePath)
@@ -478,7 +664,7 @@ func TestExpoBundlerBundle(t *testing.T) {
 			Dev:       true,
 		}
 
-		_, err := bundler.Bundle(config, opts)
+		_, err := bundler.Bundle(context.Background(), config, opts)
 		require.NoError(t, err)
 
 		assertContainsArgs(t, executor.commands[0].args, "--dev", "true")
@@ -509,7 +695,7 @@ func TestExpoBundlerBundle(t *testing.T) {
 			OutputDir: outputDir,
 		}
 
-		result, err := bundler.Bundle(config, opts)
+		result, err := bundler.Bundle(context.Background(), config, opts)
 		require.NoError(t, err)
 
 		assert.Contains(t, executor.commands[0].args, "--bytecode")
@@ -542,7 +728,7 @@ func TestExpoBundlerBundle(t *testing.T) {
 			Sourcemap: true,
 		}
 
-		result, err := bundler.Bundle(config, opts)
+		result, err := bundler.Bundle(context.Background(), config, opts)
 		require.NoError(t, err)
 
 		expectedMap := filepath.Join(outputDir, "main.jsbundle.map")
@@ -581,7 +767,7 @@ func TestExpoBundlerBundle(t *testing.T) {
 			SourcemapOutput: "maps/bundle.map",
 		}
 
-		result, err := bundler.Bundle(config, opts)
+		result, err := bundler.Bundle(context.Background(), config, opts)
 		require.NoError(t, err)
 
 		expectedMap := filepath.Join(projectDir, "maps", "bundle.map")
@@ -0,0 +1,378 @@
+package bundler
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/bitrise-io/bitrise-plugins-codepush-cli/internal/output"
+	"github.com/bitrise-io/bitrise-plugins-codepush-cli/internal/workspace"
+)
+
+// Cache stores bundle outputs between builds. It is implemented by
+// bundlecache.Cache.
+type Cache interface {
+	// Get copies the entry for key into dest and reports whether it was found.
+	Get(key, dest string) (bool, error)
+	// Put stores a copy of src under key.
+	Put(key, src string) error
+}
+
+// Layout of a cache entry.
+const (
+	cacheResultFile    = "result.json"
+	cacheOutputDir     = "output"
+	cacheSourcemapFile = "sourcemap"
+)
+
+// cacheSkipDirs are directories whose contents do not affect the bundle:
+// installed dependencies, which the lockfile stands in for, and native
+// build outputs.
+var cacheSkipDirs = map[string]bool{
+	"node_modules": true,
+	".git":         true,
+	"Pods":         true,
+	"build":        true,
+	".gradle":      true,
+	".cxx":         true,
+	".expo":        true,
+	"DerivedData":  true,
+}
+
+// cachedResult is the bundle result stored with a cache entry. Paths are
+// relative to the output directory, so the entry can be restored to another
+// location.
+type cachedResult struct {
+	BundlePath     string       `json:"bundle_path"`
+	AssetsDir      string       `json:"assets_dir"`
+	SourcemapPath  string       `json:"sourcemap_path,omitempty"`
+	HermesApplied  bool         `json:"hermes_applied"`
+	ProjectType    ProjectType  `json:"project_type"`
+	Platform       Platform     `json:"platform"`
+	RuntimeVersion string       `json:"runtime_version,omitempty"`
+	Assets         *AssetReport `json:"assets,omitempty"`
+}
+
+// RunCached executes the bundle pipeline like Run, unless a build with the
+// same cache key was stored in cache before, in which case its output is
+// restored to opts.OutputDir instead. A fresh build is stored in cache.
+// Cache failures are reported as warnings and never fail the build.
+func RunCached(ctx context.Context, opts *BundleOptions, cache Cache, version string, out *output.Writer) (*BundleResult, error) {
+	return RunCachedWithExecutor(ctx, opts, cache, version, &DefaultExecutor{Timeout: opts.CommandTimeout}, out)
+}
+
+// RunCachedWithExecutor is RunCached with the given executor.
+func RunCachedWithExecutor(ctx context.Context, opts *BundleOptions, cache Cache, version string, executor CommandExecutor, out *output.Writer) (*BundleResult, error) {
+	if _, err := resolveRunOptions(opts); err != nil {
+		return nil, err
+	}
+
+	key, result := lookupCache(opts, cache, version, out)
+	if result != nil {
+		return result, nil
+	}
+
+	result, err := RunWithExecutor(ctx, opts, executor, out)
+	if err != nil {
+		return nil, err
+	}
+	if key != "" {
+		storeResult(opts.Workspace, cacheSlot{cache: cache, key: key}, result, out)
+	}
+	return result, nil
+}
+
+// lookupCache computes the cache key of a build with resolved opts and
+// restores the cached output when there is one. The key is empty when it
+// could not be computed; the result is nil on a cache miss.
+func lookupCache(opts *BundleOptions, cache Cache, version string, out *output.Writer) (string, *BundleResult) {
+	var key string
+	err := out.Indeterminate("Computing bundle cache key", func() error {
+		var err error
+		key, err = CacheKey(opts, version)
+		return err
+	})
+	if err != nil {
+		out.Warning("bundle cache disabled: %v", err)
+		return "", nil
+	}
+
+	result, err := restoreFromCache(opts, cache, key)
+	switch {
+	case err != nil:
+		out.Warning("could not restore bundle from cache: %v", err)
+	case result != nil:
+		out.Info("Bundle cache hit (%s): skipped bundling", key[:12])
+		return key, result
+	default:
+		out.Info("Bundle cache miss (%s)", key[:12])
+	}
+	return key, nil
+}
+
+// cacheSlot is the entry of a build in a cache.
+type cacheSlot struct {
+	cache Cache
+	key   string
+}
+
+// storeResult stores the output of a build in slot, staging it in ws and
+// warning on failure.
+func storeResult(ws *workspace.Workspace, slot cacheSlot, result *BundleResult, out *output.Writer) {
+	if err := storeInCache(ws, slot, result); err != nil {
+		out.Warning("could not store bundle in cache: %v", err)
+	}
+}
+
+// CacheKey returns the cache key of a build with opts: a hash of the CLI
+// version, the options that affect the output, and the lockfiles and source
+// files of the project. Installed dependencies are not read; the lockfile
+// stands in for them. opts.ProjectDir must be absolute.
+func CacheKey(opts *BundleOptions, version string) (string, error) {
+	h := sha256.New()
+	fmt.Fprintf(h, "version %s\n", version)
+
+	flags := struct {
+		Platform         Platform
+		EntryFile        string
+		BundleName       string
+		Dev              bool
+		Minify           *bool
+		RamBundle        bool
+		Sourcemap        bool
+		SourcemapOutput  string
+		HermesMode       HermesMode
+		ExtraBundlerOpts []string
+		ExtraHermesFlags []string
+		MetroConfig      string
+		GradleFile       string
+		PodFile          string
+		OptimizeAssets   bool
+		AssetQuality     int
+	}{
+		opts.Platform, opts.EntryFile, opts.BundleName, opts.Dev, opts.Minify, opts.RamBundle,
+		opts.Sourcemap, opts.SourcemapOutput, opts.HermesMode, opts.ExtraBundlerOpts,
+		opts.ExtraHermesFlags, opts.MetroConfig, opts.GradleFile, opts.PodFile,
+		opts.OptimizeAssets, opts.AssetQuality,
+	}
+	data, err := json.Marshal(flags)
+	if err != nil {
+		return "", err
+	}
+	fmt.Fprintf(h, "options %s\n", data)
+
+	outputDir, err := filepath.Abs(opts.OutputDir)
+	if err != nil {
+		return "", fmt.Errorf("resolving output directory: %w", err)
+	}
+	var sourcemap string
+	if opts.SourcemapOutput != "" {
+		sourcemap = sourcemapPath(opts, "")
+	}
+	if err := hashSources(h, opts.ProjectDir, outputDir, sourcemap); err != nil {
+		return "", fmt.Errorf("hashing project sources: %w", err)
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// hashSources writes the path and content hash of every file in projectDir
+// to h, in lexical order, skipping cacheSkipDirs and the outputs of the
+// build: the output directory and the sourcemap.
+func hashSources(h hash.Hash, projectDir, outputDir, sourcemap string) error {
+	return filepath.WalkDir(projectDir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if path != projectDir && (cacheSkipDirs[d.Name()] || path == outputDir) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !d.Type().IsRegular() || d.Name() == ".DS_Store" || path == sourcemap {
+			return nil
+		}
+		rel, err := filepath.Rel(projectDir, path)
+		if err != nil {
+			return err
+		}
+		sum, err := fileSHA256(path)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(h, "file %s %s\n", filepath.ToSlash(rel), sum)
+		return nil
+	})
+}
+
+func fileSHA256(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer func() { _ = f.Close() }()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// restoreFromCache restores the entry for key to opts.OutputDir and returns
+// its result, or nil when there is no entry.
+func restoreFromCache(opts *BundleOptions, cache Cache, key string) (*BundleResult, error) {
+	tmp, err := opts.Workspace.MkdirTemp("cache-*")
+	if err != nil {
+		return nil, fmt.Errorf("creating temp directory: %w", err)
+	}
+	defer opts.Workspace.Remove(tmp)
+
+	entry := filepath.Join(tmp, "entry")
+	hit, err := cache.Get(key, entry)
+	if err != nil || !hit {
+		return nil, err
+	}
+
+	data, err := os.ReadFile(filepath.Join(entry, cacheResultFile))
+	if err != nil {
+		return nil, fmt.Errorf("reading cached result: %w", err)
+	}
+	var cached cachedResult
+	if err := json.Unmarshal(data, &cached); err != nil {
+		return nil, fmt.Errorf("parsing cached result: %w", err)
+	}
+
+	outputDir, err := filepath.Abs(opts.OutputDir)
+	if err != nil {
+		return nil, fmt.Errorf("resolving output directory: %w", err)
+	}
+	if err := os.RemoveAll(outputDir); err != nil {
+		return nil, fmt.Errorf("clearing output directory: %w", err)
+	}
+	if err := copyTree(filepath.Join(entry, cacheOutputDir), outputDir); err != nil {
+		return nil, fmt.Errorf("restoring output directory: %w", err)
+	}
+
+	result := &BundleResult{
+		BundlePath:     filepath.Join(outputDir, cached.BundlePath),
+		AssetsDir:      filepath.Join(outputDir, cached.AssetsDir),
+		OutputDir:      outputDir,
+		HermesApplied:  cached.HermesApplied,
+		ProjectType:    cached.ProjectType,
+		Platform:       cached.Platform,
+		RuntimeVersion: cached.RuntimeVersion,
+		Assets:         cached.Assets,
+	}
+	switch {
+	case cached.SourcemapPath != "":
+		result.SourcemapPath = filepath.Join(outputDir, cached.SourcemapPath)
+	case opts.SourcemapOutput != "":
+		// The sourcemap was written outside the output directory and is
+		// stored next to it in the entry.
+		result.SourcemapPath = sourcemapPath(opts, result.BundlePath)
+		if err := os.MkdirAll(filepath.Dir(result.SourcemapPath), 0o755); err != nil {
+			return nil, fmt.Errorf("creating sourcemap output directory: %w", err)
+		}
+		if err := copyFile(filepath.Join(entry, cacheSourcemapFile), result.SourcemapPath); err != nil {
+			return nil, fmt.Errorf("restoring sourcemap: %w", err)
+		}
+	}
+	return result, nil
+}
+
+// storeInCache stores the output of a build in slot, staged in ws.
+func storeInCache(ws *workspace.Workspace, slot cacheSlot, result *BundleResult) error {
+	tmp, err := ws.MkdirTemp("cache-*")
+	if err != nil {
+		return fmt.Errorf("creating temp directory: %w", err)
+	}
+	defer ws.Remove(tmp)
+
+	rel := func(path string) (string, error) {
+		if path == "" {
+			return "", nil
+		}
+		r, err := filepath.Rel(result.OutputDir, path)
+		if err != nil || r == ".." || strings.HasPrefix(r, ".."+string(filepath.Separator)) {
+			return "", errors.New("outside the output directory")
+		}
+		return r, nil
+	}
+
+	cached := cachedResult{
+		HermesApplied:  result.HermesApplied,
+		ProjectType:    result.ProjectType,
+		Platform:       result.Platform,
+		RuntimeVersion: result.RuntimeVersion,
+		Assets:         result.Assets,
+	}
+	if cached.BundlePath, err = rel(result.BundlePath); err != nil {
+		return fmt.Errorf("bundle %s is %w", result.BundlePath, err)
+	}
+	if cached.AssetsDir, err = rel(result.AssetsDir); err != nil {
+		return fmt.Errorf("assets %s are %w", result.AssetsDir, err)
+	}
+	if cached.SourcemapPath, err = rel(result.SourcemapPath); err != nil {
+		if err := copyFile(result.SourcemapPath, filepath.Join(tmp, cacheSourcemapFile)); err != nil {
+			return fmt.Errorf("copying sourcemap: %w", err)
+		}
+	}
+
+	if err := copyTree(result.OutputDir, filepath.Join(tmp, cacheOutputDir)); err != nil {
+		return fmt.Errorf("copying output directory: %w", err)
+	}
+	data, err := json.Marshal(cached)
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(filepath.Join(tmp, cacheResultFile), data, 0o644); err != nil {
+		return err
+	}
+	return slot.cache.Put(slot.key, tmp)
+}
+
+// copyTree copies the directory src to dst.
+func copyTree(src, dst string) error {
+	return filepath.WalkDir(src, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dst, rel)
+		if d.IsDir() {
+			return os.MkdirAll(target, 0o755)
+		}
+		return copyFile(path, target)
+	})
+}
+
+func copyFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = in.Close() }()
+
+	out, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		_ = out.Close()
+		return err
+	}
+	return out.Close()
+}
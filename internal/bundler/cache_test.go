@@ -0,0 +1,115 @@
+package bundler
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/bitrise-io/bitrise-plugins-codepush-cli/internal/bundlecache"
+	"github.com/bitrise-io/bitrise-plugins-codepush-cli/internal/output"
+)
+
+func TestRunCachedWithExecutor(t *testing.T) {
+	dir := t.TempDir()
+	outputDir := filepath.Join(dir, "CodePush")
+	writeFile(t, filepath.Join(dir, "package.json"), `{"dependencies": {"react-native": "0.72.0"}}`)
+	writeFile(t, filepath.Join(dir, "yarn.lock"), "# lockfile v1")
+	writeFile(t, filepath.Join(dir, "index.js"), "console.log('hello')")
+
+	executor := &mockExecutor{}
+	executor.onRun = func(_ string, _ string, args ...string) {
+		for i, arg := range args {
+			if (arg == "--bundle-output" || arg == "--sourcemap-output") && i+1 < len(args) {
+				require.NoError(t, os.MkdirAll(filepath.Dir(args[i+1]), 0o755))
+				require.NoError(t, os.WriteFile(args[i+1], []byte(arg), 0o644))
+			}
+		}
+	}
+	bundles := func() int {
+		n := 0
+		for _, c := range executor.commands {
+			if c.name == "yarn" && c.args[0] == "react-native" {
+				n++
+			}
+		}
+		return n
+	}
+
+	cache := bundlecache.New(t.TempDir(), 0)
+	run := func() *BundleResult {
+		t.Helper()
+		opts := &BundleOptions{
+			Platform:   PlatformIOS,
+			ProjectDir: dir,
+			OutputDir:  outputDir,
+			Sourcemap:  true,
+			HermesMode: HermesModeOff,
+		}
+		result, err := RunCachedWithExecutor(context.Background(), opts, cache, "1.0.0", executor, output.NewTest(io.Discard))
+		require.NoError(t, err)
+		return result
+	}
+
+	first := run()
+	require.Equal(t, 1, bundles())
+
+	require.NoError(t, os.RemoveAll(outputDir))
+	second := run()
+	assert.Equal(t, 1, bundles(), "unchanged sources must not be bundled again")
+	assert.Equal(t, first, second)
+	assert.FileExists(t, second.BundlePath)
+	assert.FileExists(t, second.SourcemapPath)
+
+	writeFile(t, filepath.Join(dir, "index.js"), "console.log('changed')")
+	run()
+	assert.Equal(t, 2, bundles(), "changed sources must be bundled")
+
+	writeFile(t, filepath.Join(dir, "yarn.lock"), "# lockfile v2")
+	run()
+	assert.Equal(t, 3, bundles(), "changed dependencies must be bundled")
+}
+
+func TestCacheKey(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "package-lock.json"), "{}")
+	writeFile(t, filepath.Join(dir, "index.js"), "code")
+	base := BundleOptions{Platform: PlatformIOS, ProjectDir: dir, OutputDir: filepath.Join(dir, "CodePush"), HermesMode: HermesModeAuto}
+
+	key := func(opts BundleOptions, version string) string {
+		t.Helper()
+		k, err := CacheKey(&opts, version)
+		require.NoError(t, err)
+		return k
+	}
+	want := key(base, "1.0.0")
+
+	t.Run("ignores dependencies, native builds, and outputs", func(t *testing.T) {
+		for _, rel := range []string{"node_modules/react/index.js", "ios/build/app.o", "android/.gradle/cache", "CodePush/main.jsbundle"} {
+			path := filepath.Join(dir, rel)
+			require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+			writeFile(t, path, "ignored")
+		}
+		opts := base
+		opts.ResetCache = true
+		opts.SkipInstall = true
+		assert.Equal(t, want, key(opts, "1.0.0"))
+	})
+
+	t.Run("depends on options and version", func(t *testing.T) {
+		android := base
+		android.Platform = PlatformAndroid
+		hermes := base
+		hermes.HermesMode = HermesModeOn
+		extra := base
+		extra.ExtraBundlerOpts = []string{"--max-workers=2"}
+
+		for _, k := range []string{key(android, "1.0.0"), key(hermes, "1.0.0"), key(extra, "1.0.0"), key(base, "1.1.0")} {
+			assert.NotEqual(t, want, k)
+		}
+	})
+}
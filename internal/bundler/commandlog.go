@@ -0,0 +1,173 @@
+package bundler
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/bitrise-io/bitrise-plugins-codepush-cli/internal/output"
+	"github.com/bitrise-io/bitrise-plugins-codepush-cli/internal/redact"
+)
+
+// commandLog is a log file holding the full output of the commands a build
+// runs. Commands running at once can share it: every line is written
+// whole, starting with the prefix of the command's build. Secrets are
+// masked as in terminal output. A nil *commandLog logs nothing.
+type commandLog struct {
+	mu sync.Mutex
+	f  *os.File
+}
+
+// openCommandLog opens the log file at path for appending, creating it and
+// its directory if needed. It returns nil for an empty path.
+func openCommandLog(path string) (*commandLog, error) {
+	if path == "" {
+		return nil, nil
+	}
+	if err := ensureDir(filepath.Dir(path)); err != nil {
+		return nil, err
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("opening bundler log: %w", err)
+	}
+	return &commandLog{f: f}, nil
+}
+
+func (l *commandLog) Close() error {
+	if l == nil {
+		return nil
+	}
+	return l.f.Close()
+}
+
+// writer returns a writer to the log that starts every line with prefix.
+func (l *commandLog) writer(prefix string) *logLineWriter {
+	if prefix != "" {
+		prefix += " "
+	}
+	return &logLineWriter{log: l, prefix: prefix}
+}
+
+// logLineWriter writes to a commandLog, holding back a partial line until
+// its end is written so lines of commands running at once don't mix.
+type logLineWriter struct {
+	log     *commandLog
+	prefix  string
+	partial []byte
+}
+
+func (w *logLineWriter) Write(p []byte) (int, error) {
+	if w.log == nil {
+		return len(p), nil
+	}
+	w.log.mu.Lock()
+	defer w.log.mu.Unlock()
+	w.partial = append(w.partial, p...)
+	for {
+		i := bytes.IndexByte(w.partial, '\n')
+		if i < 0 {
+			break
+		}
+		_, _ = fmt.Fprintf(w.log.f, "%s%s\n", w.prefix, redact.Bytes(w.partial[:i]))
+		w.partial = w.partial[i+1:]
+	}
+	return len(p), nil
+}
+
+// flush writes a held back partial line.
+func (w *logLineWriter) flush() {
+	if len(w.partial) > 0 {
+		_, _ = w.Write([]byte("\n"))
+	}
+}
+
+// logExecutor copies the output of every command it runs to a commandLog,
+// between lines naming the command and its outcome, and with debug logging
+// enabled streams it to live, each line starting with the command's name.
+type logExecutor struct {
+	next   CommandExecutor
+	log    *commandLog
+	prefix string
+	live   *output.Writer
+}
+
+// logCommands returns executor wrapped to log the output of its commands to
+// log, with every line starting with prefix, and to stream it to out with
+// debug logging enabled. It returns executor unchanged when there is
+// nothing to log to.
+func logCommands(executor CommandExecutor, log *commandLog, prefix string, out *output.Writer) CommandExecutor {
+	e := &logExecutor{next: executor, log: log, prefix: prefix}
+	if out.DebugEnabled() {
+		e.live = out
+	}
+	if e.log == nil && e.live == nil {
+		return executor
+	}
+	return e
+}
+
+func (e *logExecutor) Run(ctx context.Context, dir string, stdout io.Writer, stderr io.Writer, name string, args ...string) error {
+	return e.logRun(dir, name, args, func(w io.Writer) error {
+		return e.next.Run(ctx, dir, io.MultiWriter(stdout, w), io.MultiWriter(stderr, w), name, args...)
+	})
+}
+
+func (e *logExecutor) RunPTY(ctx context.Context, dir string, w io.Writer, name string, args ...string) error {
+	return e.logRun(dir, name, args, func(logW io.Writer) error {
+		return runOnPTY(ctx, e.next, dir, io.MultiWriter(w, logW), name, args...)
+	})
+}
+
+// logRun calls run with the writer taking the command's output.
+func (e *logExecutor) logRun(dir, name string, args []string, run func(io.Writer) error) error {
+	logW := e.log.writer(e.prefix)
+	_, _ = fmt.Fprintf(logW, "==> %s %s (in %s)\n", time.Now().UTC().Format(time.RFC3339), commandLine(name, args), dir)
+
+	w := io.Writer(logW)
+	if e.live != nil {
+		w = io.MultiWriter(logW, e.live.LineWriter("["+commandLabel(name, args)+"]"))
+	}
+	start := time.Now()
+	err := run(w)
+	logW.flush()
+
+	elapsed := time.Since(start).Round(time.Millisecond)
+	if err != nil {
+		_, _ = fmt.Fprintf(logW, "<== failed after %s: %s\n", elapsed, commandFailure(err))
+	} else {
+		_, _ = fmt.Fprintf(logW, "<== done in %s\n", elapsed)
+	}
+	return err
+}
+
+// commandFailure describes a command's error without the output tail a
+// *CommandError carries, which the log already holds in full.
+func commandFailure(err error) string {
+	var cmdErr *CommandError
+	if errors.As(err, &cmdErr) {
+		return (&CommandError{Err: cmdErr.Err, Timeout: cmdErr.Timeout}).Error()
+	}
+	return err.Error()
+}
+
+// commandLabel names a command for its live output: the tool a package
+// manager runs, or the base name of the executable.
+func commandLabel(name string, args []string) string {
+	base := filepath.Base(name)
+	switch {
+	case (base == "npx" || base == "bunx") && len(args) > 0:
+		return args[0]
+	case base == "yarn" && len(args) > 0 && args[0] != "install":
+		return args[0]
+	case base == "pnpm" && len(args) > 1 && args[0] == "exec":
+		return args[1]
+	}
+	return base
+}
@@ -0,0 +1,92 @@
+package bundler
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/bitrise-io/bitrise-plugins-codepush-cli/internal/output"
+)
+
+func TestLogExecutor(t *testing.T) {
+	readLog := func(t *testing.T, path string) []string {
+		t.Helper()
+		data, err := os.ReadFile(path)
+		require.NoError(t, err)
+		return strings.Split(strings.TrimSuffix(string(data), "\n"), "\n")
+	}
+
+	t.Run("logs commands with their output and outcome", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "logs", "bundle.log")
+		log, err := openCommandLog(path)
+		require.NoError(t, err)
+
+		mock := &mockExecutor{stdout: func(string, ...string) string { return "line one\npartial" }}
+		executor := logCommands(mock, log, "[ios]", output.NewTest(io.Discard))
+		var stdout bytes.Buffer
+		require.NoError(t, executor.Run(context.Background(), "/project", &stdout, io.Discard, "npx", "react-native", "bundle"))
+		mock.err = &CommandError{Err: errors.New("exit status 1"), Output: "line one\npartial"}
+		require.Error(t, executor.Run(context.Background(), "/project", io.Discard, io.Discard, "hermesc", "-emit-binary"))
+		require.NoError(t, log.Close())
+
+		assert.Equal(t, "line one\npartial", stdout.String(), "the command's own writers still get its output")
+		lines := readLog(t, path)
+		require.Len(t, lines, 8)
+		assert.Regexp(t, `^\[ios\] ==> \S+ npx react-native bundle \(in /project\)$`, lines[0])
+		assert.Equal(t, []string{"[ios] line one", "[ios] partial"}, lines[1:3])
+		assert.Regexp(t, `^\[ios\] <== done in \S+$`, lines[3])
+		assert.Regexp(t, `^\[ios\] ==> \S+ hermesc -emit-binary`, lines[4])
+		assert.Regexp(t, `^\[ios\] <== failed after \S+: exit status 1$`, lines[7], "the output tail of the error is not repeated")
+	})
+
+	t.Run("appends to an existing log", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "bundle.log")
+		for range 2 {
+			log, err := openCommandLog(path)
+			require.NoError(t, err)
+			require.NoError(t, logCommands(&mockExecutor{}, log, "", output.NewTest(io.Discard)).Run(context.Background(), "/project", io.Discard, io.Discard, "npm", "install"))
+			require.NoError(t, log.Close())
+		}
+		assert.Len(t, readLog(t, path), 4)
+	})
+
+	t.Run("streams output in verbose mode", func(t *testing.T) {
+		var buf bytes.Buffer
+		out := output.NewTest(&buf)
+		out.SetLevel(output.LevelDebug)
+
+		mock := &mockExecutor{stdout: func(string, ...string) string { return "Welcome to Metro\n" }}
+		require.NoError(t, logCommands(mock, nil, "", out).Run(context.Background(), "/project", io.Discard, io.Discard, "npx", "react-native", "bundle"))
+
+		assert.Equal(t, "[react-native] Welcome to Metro\n", buf.String())
+	})
+
+	t.Run("leaves the executor alone with nothing to log to", func(t *testing.T) {
+		mock := &mockExecutor{}
+		assert.Same(t, mock, logCommands(mock, nil, "", output.NewTest(io.Discard)))
+	})
+}
+
+func TestRunWithExecutorLogFile(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "package.json"), `{"dependencies": {"react-native": "0.72.0"}}`)
+	writeFile(t, filepath.Join(dir, "index.js"), "")
+	logFile := filepath.Join(t.TempDir(), "codepush-bundle.log")
+
+	opts := &BundleOptions{Platform: PlatformIOS, ProjectDir: dir, HermesMode: HermesModeOff, LogFile: logFile}
+	_, err := RunWithExecutor(context.Background(), opts, &mockExecutor{err: errors.New("exit status 1")}, output.NewTest(io.Discard))
+	require.Error(t, err)
+
+	data, err := os.ReadFile(logFile)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "install (in "+dir+")")
+	assert.Contains(t, string(data), "<== failed after")
+}
@@ -0,0 +1,195 @@
+package bundler
+
+import (
+	"bufio"
+	"cmp"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// sdkPackages lists the npm package names of CodePush SDKs compatible with
+// the Bitrise CodePush server, in detection priority order.
+var sdkPackages = []string{
+	"@code-push-next/react-native-code-push",
+	"react-native-code-push",
+}
+
+// podfileLockCodePush matches the CodePush pod entry in ios/Podfile.lock,
+// e.g. "  - CodePush (8.1.0):".
+var podfileLockCodePush = regexp.MustCompile(`(?m)^\s*-\s*CodePush\s+\(([0-9][^)]*)\)`)
+
+// Feature identifies an SDK capability required by a CLI option.
+type Feature string
+
+const (
+	// FeatureCodeSigning is on-device verification of signed bundles.
+	FeatureCodeSigning Feature = "code signing"
+)
+
+// sdkFeature describes the minimum SDK version supporting a feature and the
+// CLI flag that requests it.
+type sdkFeature struct {
+	Feature    Feature
+	Flag       string
+	MinVersion string
+}
+
+// sdkCompatMatrix is the bundled compatibility matrix between CLI features
+// and the react-native-code-push SDK.
+var sdkCompatMatrix = []sdkFeature{
+	{Feature: FeatureCodeSigning, Flag: "--private-key-path", MinVersion: "5.1.0"},
+}
+
+// SDKInfo describes the CodePush SDK installed in a project.
+type SDKInfo struct {
+	Package string
+	Version string
+	Source  string // file the version was read from
+}
+
+// CompatIssue reports a requested feature the installed SDK does not support.
+type CompatIssue struct {
+	Feature    Feature
+	Flag       string
+	MinVersion string
+	SDK        SDKInfo
+}
+
+// String returns a human-readable description of the issue.
+func (i CompatIssue) String() string {
+	return fmt.Sprintf("%s requires %s >= %s, but %s %s is installed (from %s); %s will be rejected on-device",
+		i.Flag, i.SDK.Package, i.MinVersion, i.SDK.Package, i.SDK.Version, i.SDK.Source, i.Feature)
+}
+
+// DetectSDK returns the CodePush SDK installed in projectDir, or nil when no
+// SDK can be found. The version is read from node_modules first, then from
+// ios/Podfile.lock, and finally from the package.json version range.
+func DetectSDK(projectDir string) *SDKInfo {
+	data, err := os.ReadFile(filepath.Join(projectDir, "package.json"))
+	if err != nil {
+		return nil
+	}
+	var pkg packageJSON
+	if err := json.Unmarshal(data, &pkg); err != nil {
+		return nil
+	}
+
+	for _, name := range sdkPackages {
+		declared, ok := pkg.Dependencies[name]
+		if !ok {
+			continue
+		}
+
+		if v := installedPackageVersion(projectDir, name); v != "" {
+			return &SDKInfo{Package: name, Version: v, Source: filepath.Join("node_modules", name, "package.json")}
+		}
+		if v := lockedPodVersion(projectDir); v != "" {
+			return &SDKInfo{Package: name, Version: v, Source: filepath.Join("ios", "Podfile.lock")}
+		}
+		if v := strings.TrimLeft(declared, "^~>=<v "); parseVersion(v) != nil {
+			return &SDKInfo{Package: name, Version: v, Source: "package.json"}
+		}
+		return nil
+	}
+
+	return nil
+}
+
+func installedPackageVersion(projectDir, name string) string {
+	data, err := os.ReadFile(filepath.Join(projectDir, "node_modules", name, "package.json"))
+	if err != nil {
+		return ""
+	}
+	var pkg struct {
+		Version string `json:"version"`
+	}
+	if err := json.Unmarshal(data, &pkg); err != nil {
+		return ""
+	}
+	return pkg.Version
+}
+
+func lockedPodVersion(projectDir string) string {
+	f, err := os.Open(filepath.Join(projectDir, "ios", "Podfile.lock"))
+	if err != nil {
+		return ""
+	}
+	defer func() { _ = f.Close() }()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		if m := podfileLockCodePush.FindStringSubmatch(scanner.Text()); m != nil {
+			return m[1]
+		}
+	}
+	return ""
+}
+
+// CheckSDKCompatibility returns an issue for each requested feature the SDK
+// does not support. Unparseable SDK versions are treated as compatible.
+func CheckSDKCompatibility(sdk *SDKInfo, features []Feature) []CompatIssue {
+	if sdk == nil {
+		return nil
+	}
+	have := parseVersion(sdk.Version)
+	if have == nil {
+		return nil
+	}
+
+	var issues []CompatIssue
+	for _, f := range features {
+		for _, entry := range sdkCompatMatrix {
+			if entry.Feature != f {
+				continue
+			}
+			if compareVersions(have, parseVersion(entry.MinVersion)) < 0 {
+				issues = append(issues, CompatIssue{
+					Feature:    f,
+					Flag:       entry.Flag,
+					MinVersion: entry.MinVersion,
+					SDK:        *sdk,
+				})
+			}
+		}
+	}
+	return issues
+}
+
+// parseVersion parses the numeric major.minor.patch prefix of a version.
+// Pre-release and build suffixes are ignored. Returns nil if v has no
+// leading numeric component.
+func parseVersion(v string) []int {
+	v, _, _ = strings.Cut(v, "-")
+	v, _, _ = strings.Cut(v, "+")
+
+	var parts []int
+	for _, s := range strings.SplitN(v, ".", 3) {
+		n, err := strconv.Atoi(s)
+		if err != nil {
+			break
+		}
+		parts = append(parts, n)
+	}
+	return parts
+}
+
+func compareVersions(a, b []int) int {
+	for i := range max(len(a), len(b)) {
+		var x, y int
+		if i < len(a) {
+			x = a[i]
+		}
+		if i < len(b) {
+			y = b[i]
+		}
+		if c := cmp.Compare(x, y); c != 0 {
+			return c
+		}
+	}
+	return 0
+}
@@ -0,0 +1,90 @@
+package bundler
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeProjectFile(t *testing.T, dir, rel, content string) {
+	t.Helper()
+	path := filepath.Join(dir, rel)
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+}
+
+func TestDetectSDK(t *testing.T) {
+	t.Run("prefers installed version from node_modules", func(t *testing.T) {
+		dir := t.TempDir()
+		writeProjectFile(t, dir, "package.json", `{"dependencies":{"react-native-code-push":"^5.0.0"}}`)
+		writeProjectFile(t, dir, "node_modules/react-native-code-push/package.json", `{"version":"5.2.1"}`)
+		writeProjectFile(t, dir, "ios/Podfile.lock", "PODS:\n  - CodePush (5.0.0):\n")
+
+		sdk := DetectSDK(dir)
+		require.NotNil(t, sdk)
+		assert.Equal(t, "react-native-code-push", sdk.Package)
+		assert.Equal(t, "5.2.1", sdk.Version)
+	})
+
+	t.Run("falls back to Podfile.lock", func(t *testing.T) {
+		dir := t.TempDir()
+		writeProjectFile(t, dir, "package.json", `{"dependencies":{"@code-push-next/react-native-code-push":"*"}}`)
+		writeProjectFile(t, dir, "ios/Podfile.lock", "PODS:\n  - Base64 (1.1.2)\n  - CodePush (10.0.1):\n    - Base64\n")
+
+		sdk := DetectSDK(dir)
+		require.NotNil(t, sdk)
+		assert.Equal(t, "@code-push-next/react-native-code-push", sdk.Package)
+		assert.Equal(t, "10.0.1", sdk.Version)
+		assert.Equal(t, filepath.Join("ios", "Podfile.lock"), sdk.Source)
+	})
+
+	t.Run("falls back to package.json range", func(t *testing.T) {
+		dir := t.TempDir()
+		writeProjectFile(t, dir, "package.json", `{"dependencies":{"react-native-code-push":"~4.2.0"}}`)
+
+		sdk := DetectSDK(dir)
+		require.NotNil(t, sdk)
+		assert.Equal(t, "4.2.0", sdk.Version)
+		assert.Equal(t, "package.json", sdk.Source)
+	})
+
+	t.Run("returns nil without SDK dependency", func(t *testing.T) {
+		dir := t.TempDir()
+		writeProjectFile(t, dir, "package.json", `{"dependencies":{"react-native":"0.74.0"}}`)
+		assert.Nil(t, DetectSDK(dir))
+	})
+
+	t.Run("returns nil without package.json", func(t *testing.T) {
+		assert.Nil(t, DetectSDK(t.TempDir()))
+	})
+}
+
+func TestCheckSDKCompatibility(t *testing.T) {
+	tests := []struct {
+		name       string
+		sdk        *SDKInfo
+		wantIssues int
+	}{
+		{name: "old SDK lacks code signing", sdk: &SDKInfo{Package: "react-native-code-push", Version: "5.0.9"}, wantIssues: 1},
+		{name: "minimum version supports code signing", sdk: &SDKInfo{Package: "react-native-code-push", Version: "5.1.0"}, wantIssues: 0},
+		{name: "newer major supports code signing", sdk: &SDKInfo{Package: "react-native-code-push", Version: "10.0.0-beta.1"}, wantIssues: 0},
+		{name: "unparseable version is assumed compatible", sdk: &SDKInfo{Package: "react-native-code-push", Version: "github:foo/bar"}, wantIssues: 0},
+		{name: "no SDK detected", sdk: nil, wantIssues: 0},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			issues := CheckSDKCompatibility(tc.sdk, []Feature{FeatureCodeSigning})
+			assert.Len(t, issues, tc.wantIssues)
+		})
+	}
+
+	t.Run("issue names the flag and minimum version", func(t *testing.T) {
+		issues := CheckSDKCompatibility(&SDKInfo{Package: "react-native-code-push", Version: "4.0.0", Source: "package.json"}, []Feature{FeatureCodeSigning})
+		require.Len(t, issues, 1)
+		assert.Contains(t, issues[0].String(), "--private-key-path requires react-native-code-push >= 5.1.0")
+	})
+}
@@ -2,47 +2,119 @@ package bundler
 
 import (
 	"bytes"
+	"context"
+	"encoding/json"
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 
 	"github.com/bitrise-io/bitrise-plugins-codepush-cli/internal/output"
 )
 
-// detectPackageManager checks the project directory for lock files
-// and returns the package manager name and command.
-func detectPackageManager(projectDir string) (name, cmd string) {
+// PackageManager is the JavaScript package manager of a project. It installs
+// the dependencies and runs the binaries of the project's packages.
+type PackageManager string
+
+const (
+	PackageManagerNPM  PackageManager = "npm"
+	PackageManagerYarn PackageManager = "yarn"
+	PackageManagerPNPM PackageManager = "pnpm"
+	PackageManagerBun  PackageManager = "bun"
+)
+
+// ValidatePackageManager checks that the given package manager is valid.
+// The empty string selects detection.
+func ValidatePackageManager(pm PackageManager) error {
+	switch pm {
+	case "", PackageManagerNPM, PackageManagerYarn, PackageManagerPNPM, PackageManagerBun:
+		return nil
+	}
+	return fmt.Errorf("--package-manager must be 'npm', 'yarn', 'pnpm', or 'bun', got %q", pm)
+}
+
+// Exec returns the command running the binary tool of one of the project's
+// packages: npx for npm, yarn, pnpm exec, or bunx. The zero value runs it
+// with npx.
+func (pm PackageManager) Exec(tool string, args ...string) (string, []string) {
+	switch pm {
+	case PackageManagerYarn:
+		return "yarn", append([]string{tool}, args...)
+	case PackageManagerPNPM:
+		return "pnpm", append([]string{"exec", tool}, args...)
+	case PackageManagerBun:
+		return "bunx", append([]string{tool}, args...)
+	default:
+		return "npx", append([]string{tool}, args...)
+	}
+}
+
+// DetectPackageManager returns the package manager a project uses: the one
+// named by the "packageManager" field of its package.json (Corepack), else
+// the one whose lock file it has, else npm.
+func DetectPackageManager(projectDir string) PackageManager {
+	if pm := corepackPackageManager(projectDir); pm != "" {
+		return pm
+	}
+
 	lockFiles := []struct {
 		file string
-		name string
-		cmd  string
+		pm   PackageManager
 	}{
-		{"yarn.lock", "yarn", "yarn"},
-		{"pnpm-lock.yaml", "pnpm", "pnpm"},
-		{"bun.lockb", "bun", "bun"},
-		{"bun.lock", "bun", "bun"},
+		{"yarn.lock", PackageManagerYarn},
+		{"pnpm-lock.yaml", PackageManagerPNPM},
+		{"bun.lockb", PackageManagerBun},
+		{"bun.lock", PackageManagerBun},
 	}
 
 	for _, lf := range lockFiles {
 		if _, err := os.Stat(filepath.Join(projectDir, lf.file)); err == nil {
-			return lf.name, lf.cmd
+			return lf.pm
 		}
 	}
 
-	return "npm", "npm"
+	return PackageManagerNPM
+}
+
+// corepackPackageManager returns the package manager of the "packageManager"
+// field of package.json, e.g. "pnpm@9.1.0", or "" when there is none.
+func corepackPackageManager(projectDir string) PackageManager {
+	data, err := os.ReadFile(filepath.Join(projectDir, "package.json"))
+	if err != nil {
+		return ""
+	}
+	var pkg struct {
+		PackageManager string `json:"packageManager"`
+	}
+	if json.Unmarshal(data, &pkg) != nil {
+		return ""
+	}
+	name, _, _ := strings.Cut(pkg.PackageManager, "@")
+	pm := PackageManager(name)
+	if pm == "" || ValidatePackageManager(pm) != nil {
+		return ""
+	}
+	return pm
 }
 
-// installDependencies detects the package manager and runs install.
-func installDependencies(projectDir string, executor CommandExecutor, out *output.Writer) error {
-	name, cmd := detectPackageManager(projectDir)
+// packageManagerFor returns the package manager set in opts, or the one
+// detected in projectDir.
+func packageManagerFor(projectDir string, opts *BundleOptions) PackageManager {
+	if opts != nil && opts.PackageManager != "" {
+		return opts.PackageManager
+	}
+	return DetectPackageManager(projectDir)
+}
 
-	return out.Indeterminate(fmt.Sprintf("Installing dependencies (%s)", name), func() error {
+// installDependencies runs install with the given package manager.
+func installDependencies(ctx context.Context, projectDir string, pm PackageManager, executor CommandExecutor, out *output.Writer) error {
+	return out.Indeterminate(fmt.Sprintf("Installing dependencies (%s)", pm), func() error {
 		var stderr bytes.Buffer
-		if err := executor.Run(projectDir, &bytes.Buffer{}, &stderr, cmd, "install"); err != nil {
-			if s := stderr.String(); s != "" {
+		if err := executor.Run(ctx, projectDir, &bytes.Buffer{}, &stderr, string(pm), "install"); err != nil {
+			if s := stderr.String(); s != "" && !hasOutput(err) {
 				out.Info("%s", s)
 			}
-			return fmt.Errorf("installing dependencies with %s failed: %w", name, err)
+			return fmt.Errorf("installing dependencies with %s failed: %w", pm, err)
 		}
 		return nil
 	})
@@ -1,6 +1,7 @@
 package bundler
 
 import (
+	"context"
 	"errors"
 	"io"
 	"os"
@@ -15,40 +16,27 @@ import (
 
 func TestDetectPackageManager(t *testing.T) {
 	tests := []struct {
-		name     string
-		lockFile string
-		wantName string
-		wantCmd  string
+		name        string
+		lockFile    string
+		packageJSON string
+		want        PackageManager
 	}{
+		{name: "detects yarn", lockFile: "yarn.lock", want: PackageManagerYarn},
+		{name: "detects pnpm", lockFile: "pnpm-lock.yaml", want: PackageManagerPNPM},
+		{name: "detects bun from lockb", lockFile: "bun.lockb", want: PackageManagerBun},
+		{name: "detects bun from lock", lockFile: "bun.lock", want: PackageManagerBun},
+		{name: "defaults to npm", want: PackageManagerNPM},
 		{
-			name:     "detects yarn",
-			lockFile: "yarn.lock",
-			wantName: "yarn",
-			wantCmd:  "yarn",
-		},
-		{
-			name:     "detects pnpm",
-			lockFile: "pnpm-lock.yaml",
-			wantName: "pnpm",
-			wantCmd:  "pnpm",
+			name:        "prefers the packageManager field",
+			lockFile:    "yarn.lock",
+			packageJSON: `{"packageManager": "pnpm@9.1.0+sha256.abc"}`,
+			want:        PackageManagerPNPM,
 		},
 		{
-			name:     "detects bun from lockb",
-			lockFile: "bun.lockb",
-			wantName: "bun",
-			wantCmd:  "bun",
-		},
-		{
-			name:     "detects bun from lock",
-			lockFile: "bun.lock",
-			wantName: "bun",
-			wantCmd:  "bun",
-		},
-		{
-			name:     "defaults to npm",
-			lockFile: "",
-			wantName: "npm",
-			wantCmd:  "npm",
+			name:        "ignores an unknown packageManager field",
+			lockFile:    "yarn.lock",
+			packageJSON: `{"packageManager": "deno@2.0.0"}`,
+			want:        PackageManagerYarn,
 		},
 	}
 
@@ -58,10 +46,11 @@ func TestDetectPackageManager(t *testing.T) {
 			if tt.lockFile != "" {
 				require.NoError(t, os.WriteFile(filepath.Join(dir, tt.lockFile), []byte{}, 0o644))
 			}
+			if tt.packageJSON != "" {
+				require.NoError(t, os.WriteFile(filepath.Join(dir, "package.json"), []byte(tt.packageJSON), 0o644))
+			}
 
-			name, cmd := detectPackageManager(dir)
-			assert.Equal(t, tt.wantName, name)
-			assert.Equal(t, tt.wantCmd, cmd)
+			assert.Equal(t, tt.want, DetectPackageManager(dir))
 		})
 	}
 }
@@ -74,20 +63,45 @@ func TestDetectPackageManager_PriorityOrder(t *testing.T) {
 		require.NoError(t, os.WriteFile(filepath.Join(dir, f), []byte{}, 0o644))
 	}
 
-	name, _ := detectPackageManager(dir)
-	assert.Equal(t, "yarn", name)
+	assert.Equal(t, PackageManagerYarn, DetectPackageManager(dir))
+}
+
+func TestPackageManagerExec(t *testing.T) {
+	tests := []struct {
+		pm       PackageManager
+		wantName string
+		wantArgs []string
+	}{
+		{pm: "", wantName: "npx", wantArgs: []string{"react-native", "bundle"}},
+		{pm: PackageManagerNPM, wantName: "npx", wantArgs: []string{"react-native", "bundle"}},
+		{pm: PackageManagerYarn, wantName: "yarn", wantArgs: []string{"react-native", "bundle"}},
+		{pm: PackageManagerPNPM, wantName: "pnpm", wantArgs: []string{"exec", "react-native", "bundle"}},
+		{pm: PackageManagerBun, wantName: "bunx", wantArgs: []string{"react-native", "bundle"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.pm), func(t *testing.T) {
+			name, args := tt.pm.Exec("react-native", "bundle")
+			assert.Equal(t, tt.wantName, name)
+			assert.Equal(t, tt.wantArgs, args)
+		})
+	}
+}
+
+func TestValidatePackageManager(t *testing.T) {
+	for _, pm := range []PackageManager{"", PackageManagerNPM, PackageManagerYarn, PackageManagerPNPM, PackageManagerBun} {
+		assert.NoError(t, ValidatePackageManager(pm))
+	}
+	assert.EqualError(t, ValidatePackageManager("deno"), `--package-manager must be 'npm', 'yarn', 'pnpm', or 'bun', got "deno"`)
 }
 
 func TestInstallDependencies(t *testing.T) {
 	dir := t.TempDir()
 
-	// Create yarn.lock so it detects yarn
-	require.NoError(t, os.WriteFile(filepath.Join(dir, "yarn.lock"), []byte{}, 0o644))
-
 	executor := &mockExecutor{}
 	out := output.NewTest(io.Discard)
 
-	err := installDependencies(dir, executor, out)
+	err := installDependencies(context.Background(), dir, PackageManagerYarn, executor, out)
 	require.NoError(t, err)
 
 	require.Len(t, executor.commands, 1)
@@ -103,18 +117,42 @@ func TestInstallDependencies_DefaultsToNpm(t *testing.T) {
 	executor := &mockExecutor{}
 	out := output.NewTest(io.Discard)
 
-	err := installDependencies(dir, executor, out)
+	err := installDependencies(context.Background(), dir, packageManagerFor(dir, &BundleOptions{}), executor, out)
 	require.NoError(t, err)
 
 	assert.Equal(t, "npm", executor.commands[0].name)
 }
 
+func TestInstallDependencies_PackageManagerOverride(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "yarn.lock"), []byte{}, 0o644))
+	executor := &mockExecutor{}
+	out := output.NewTest(io.Discard)
+
+	err := installDependencies(context.Background(), dir, packageManagerFor(dir, &BundleOptions{PackageManager: PackageManagerPNPM}), executor, out)
+	require.NoError(t, err)
+
+	assert.Equal(t, "pnpm", executor.commands[0].name)
+}
+
+func TestInstallDependencies_DetectsLockFile(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "bun.lock"), []byte{}, 0o644))
+	executor := &mockExecutor{}
+	out := output.NewTest(io.Discard)
+
+	err := installDependencies(context.Background(), dir, packageManagerFor(dir, nil), executor, out)
+	require.NoError(t, err)
+
+	assert.Equal(t, "bun", executor.commands[0].name)
+}
+
 func TestInstallDependencies_Error(t *testing.T) {
 	dir := t.TempDir()
 	executor := &mockExecutor{err: errors.New("command failed")}
 	out := output.NewTest(io.Discard)
 
-	err := installDependencies(dir, executor, out)
+	err := installDependencies(context.Background(), dir, PackageManagerNPM, executor, out)
 	require.Error(t, err)
 	require.ErrorContains(t, err, "installing dependencies with npm failed")
 	assert.ErrorContains(t, err, "command failed")
@@ -1,4 +1,5 @@
-// Package bundler provides JavaScript bundle generation for React Native and Expo projects.
+// Package bundler provides JavaScript bundle generation for React Native, Expo,
+// and Re.Pack projects.
 package bundler
 
 import (
@@ -22,6 +23,9 @@ const (
 	ProjectTypeReactNative
 	// ProjectTypeExpo indicates an Expo-managed project.
 	ProjectTypeExpo
+	// ProjectTypeRePack indicates a React Native project bundled with Re.Pack
+	// (webpack or Rspack) instead of Metro.
+	ProjectTypeRePack
 )
 
 // String returns the display name of the project type.
@@ -31,6 +35,8 @@ func (p ProjectType) String() string {
 		return "react-native"
 	case ProjectTypeExpo:
 		return "expo"
+	case ProjectTypeRePack:
+		return "repack"
 	default:
 		return "unknown"
 	}
@@ -65,9 +71,14 @@ type ProjectConfig struct {
 	Platform      Platform
 	EntryFile     string
 	MetroConfig   string
+	WebpackConfig string // Re.Pack only
 	HermesEnabled bool
 	HermescPath   string
 	BundleName    string // expected filename the SDK will search for (Expo only)
+	// RuntimeVersion is the runtime version resolved from the Expo config (Expo only).
+	RuntimeVersion string
+	// PackageManager runs the bundler; the zero value runs it with npx.
+	PackageManager PackageManager
 }
 
 // packageJSON represents the relevant fields of a package.json file.
@@ -79,7 +90,8 @@ type packageJSON struct {
 
 // DetectProject inspects the project directory and returns a ProjectConfig.
 // opts may be nil; when provided, GradleFile and PodFile override the default
-// paths used for Hermes auto-detection.
+// paths used for Hermes auto-detection, and PackageManager overrides the
+// detected package manager.
 func DetectProject(projectDir string, platform Platform, hermesMode HermesMode, opts *BundleOptions) (*ProjectConfig, error) {
 	absDir, err := filepath.Abs(projectDir)
 	if err != nil {
@@ -90,7 +102,7 @@ func DetectProject(projectDir string, platform Platform, hermesMode HermesMode,
 		return nil, fmt.Errorf("project directory does not exist: %w", err)
 	}
 
-	projectType, err := detectProjectType(absDir)
+	projectType, err := DetectProjectType(absDir)
 	if err != nil {
 		return nil, err
 	}
@@ -124,25 +136,32 @@ func DetectProject(projectDir string, platform Platform, hermesMode HermesMode,
 
 	metroConfig := detectMetroConfig(absDir)
 
+	webpackConfig := ""
+	if projectType == ProjectTypeRePack {
+		webpackConfig = detectWebpackConfig(absDir)
+	}
+
 	bundleName := ""
 	if projectType == ProjectTypeExpo {
 		bundleName = detectBundleName(absDir, platform, opts)
 	}
 
 	return &ProjectConfig{
-		ProjectDir:    absDir,
-		ProjectType:   projectType,
-		Platform:      platform,
-		EntryFile:     entryFile,
-		MetroConfig:   metroConfig,
-		HermesEnabled: hermesEnabled,
-		HermescPath:   hermescPath,
-		BundleName:    bundleName,
+		ProjectDir:     absDir,
+		ProjectType:    projectType,
+		Platform:       platform,
+		EntryFile:      entryFile,
+		MetroConfig:    metroConfig,
+		WebpackConfig:  webpackConfig,
+		HermesEnabled:  hermesEnabled,
+		HermescPath:    hermescPath,
+		BundleName:     bundleName,
+		PackageManager: packageManagerFor(absDir, opts),
 	}, nil
 }
 
-// detectProjectType reads package.json and determines the project type.
-func detectProjectType(projectDir string) (ProjectType, error) {
+// DetectProjectType reads package.json and determines the project type.
+func DetectProjectType(projectDir string) (ProjectType, error) {
 	pkgPath := filepath.Join(projectDir, "package.json")
 	data, err := os.ReadFile(pkgPath)
 	if err != nil {
@@ -162,6 +181,17 @@ func detectProjectType(projectDir string) (ProjectType, error) {
 		return ProjectTypeExpo, nil
 	}
 
+	// Re.Pack projects also have react-native as a dependency but replace Metro
+	if _, ok := pkg.Dependencies[repackPackage]; ok {
+		return ProjectTypeRePack, nil
+	}
+	if _, ok := pkg.DevDependencies[repackPackage]; ok {
+		return ProjectTypeRePack, nil
+	}
+	if strings.HasPrefix(filepath.Base(detectWebpackConfig(projectDir)), "repack.config.") {
+		return ProjectTypeRePack, nil
+	}
+
 	if _, ok := pkg.Dependencies["react-native"]; ok {
 		return ProjectTypeReactNative, nil
 	}
@@ -210,6 +240,19 @@ const (
 	hermesDisabled                        // explicitly disabled
 )
 
+// HermesEnabled reports whether the project's native build for platform
+// uses Hermes, as bundling detects it with --hermes auto.
+func HermesEnabled(projectDir string, platform Platform) bool {
+	return detectHermes(projectDir, platform, "", "")
+}
+
+// HermescAvailable reports whether the hermesc binary for this host is
+// installed in the project's node_modules.
+func HermescAvailable(projectDir string) bool {
+	_, err := findHermesc(projectDir)
+	return err == nil
+}
+
 // detectHermes checks the project for Hermes configuration.
 // If no explicit config is found, defaults to true for React Native >= 0.70
 // (Hermes became the default engine in that version).
@@ -435,6 +478,27 @@ func detectMetroConfig(projectDir string) string {
 	return ""
 }
 
+// repackPackage is the npm package that marks a Re.Pack project.
+const repackPackage = "@callstack/repack"
+
+// detectWebpackConfig searches for a Re.Pack config file: repack.config.*,
+// then rspack.config.* and webpack.config.* as generated by the Re.Pack
+// template.
+func detectWebpackConfig(projectDir string) string {
+	for _, name := range []string{
+		"repack.config.js", "repack.config.mjs", "repack.config.ts",
+		"rspack.config.js", "rspack.config.mjs", "rspack.config.ts",
+		"webpack.config.js", "webpack.config.mjs", "webpack.config.cjs", "webpack.config.ts",
+	} {
+		candidate := filepath.Join(projectDir, name)
+		if _, err := os.Stat(candidate); err == nil {
+			return candidate
+		}
+	}
+
+	return ""
+}
+
 // Bundle name detection regexes. Compiled once at package init to avoid repeated allocation.
 var (
 	reBundleAssetName      = regexp.MustCompile(`bundleAssetName\s*=\s*"([^"]+)"`)
@@ -37,6 +37,7 @@ func TestDetectProjectType(t *testing.T) {
 	tests := []struct {
 		name        string
 		packageJSON string
+		files       []string
 		want        ProjectType
 		wantErr     bool
 	}{
@@ -60,6 +61,28 @@ func TestDetectProjectType(t *testing.T) {
 			packageJSON: `{"devDependencies": {"react-native": "0.72.0"}}`,
 			want:        ProjectTypeReactNative,
 		},
+		{
+			name:        "repack project",
+			packageJSON: `{"dependencies": {"react-native": "0.74.0"}, "devDependencies": {"@callstack/repack": "^4.0.0"}}`,
+			want:        ProjectTypeRePack,
+		},
+		{
+			name:        "repack config without the dependency",
+			packageJSON: `{"dependencies": {"react-native": "0.74.0"}}`,
+			files:       []string{"repack.config.js"},
+			want:        ProjectTypeRePack,
+		},
+		{
+			name:        "webpack config alone is not repack",
+			packageJSON: `{"dependencies": {"react-native": "0.74.0"}}`,
+			files:       []string{"webpack.config.js"},
+			want:        ProjectTypeReactNative,
+		},
+		{
+			name:        "expo wins over repack",
+			packageJSON: `{"dependencies": {"expo": "~51.0.0", "@callstack/repack": "^4.0.0"}}`,
+			want:        ProjectTypeExpo,
+		},
 		{
 			name:        "unknown project",
 			packageJSON: `{"dependencies": {"express": "4.18.0"}}`,
@@ -83,8 +106,11 @@ func TestDetectProjectType(t *testing.T) {
 			if tt.packageJSON != "" {
 				writeFile(t, filepath.Join(dir, "package.json"), tt.packageJSON)
 			}
+			for _, f := range tt.files {
+				writeFile(t, filepath.Join(dir, f), "")
+			}
 
-			got, err := detectProjectType(dir)
+			got, err := DetectProjectType(dir)
 			if tt.wantErr {
 				require.Error(t, err)
 				return
@@ -359,6 +385,36 @@ func TestDetectProject(t *testing.T) {
 	})
 }
 
+func TestDetectWebpackConfig(t *testing.T) {
+	tests := []struct {
+		name  string
+		files []string
+		want  string
+	}{
+		{"repack config", []string{"repack.config.js"}, "repack.config.js"},
+		{"rspack config", []string{"rspack.config.mjs"}, "rspack.config.mjs"},
+		{"webpack config", []string{"webpack.config.cjs"}, "webpack.config.cjs"},
+		{"prefers repack over webpack", []string{"webpack.config.js", "repack.config.ts"}, "repack.config.ts"},
+		{"no config", nil, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			for _, f := range tt.files {
+				writeFile(t, filepath.Join(dir, f), "")
+			}
+
+			got := detectWebpackConfig(dir)
+			if tt.want == "" {
+				assert.Empty(t, got)
+				return
+			}
+			assert.Equal(t, filepath.Join(dir, tt.want), got)
+		})
+	}
+}
+
 func TestProjectTypeString(t *testing.T) {
 	tests := []struct {
 		pt   ProjectType
@@ -366,6 +422,7 @@ func TestProjectTypeString(t *testing.T) {
 	}{
 		{ProjectTypeReactNative, "react-native"},
 		{ProjectTypeExpo, "expo"},
+		{ProjectTypeRePack, "repack"},
 		{ProjectTypeUnknown, "unknown"},
 	}
 
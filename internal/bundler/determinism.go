@@ -0,0 +1,210 @@
+package bundler
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+
+	"github.com/bitrise-io/bitrise-plugins-codepush-cli/internal/output"
+)
+
+// hermeticEnv pins the environment variables that commonly leak into bundle
+// output (build timestamps, locale-dependent sorting, interactive tooling).
+var hermeticEnv = []string{
+	"SOURCE_DATE_EPOCH=0",
+	"TZ=UTC",
+	"LC_ALL=C",
+	"CI=1",
+}
+
+// Reasons reported in FileDifference.
+const (
+	DiffContent    = "content differs"
+	DiffSize       = "size differs"
+	DiffOnlyFirst  = "only in first build"
+	DiffOnlySecond = "only in second build"
+)
+
+// FileDifference describes one output file that differs between two builds.
+type FileDifference struct {
+	Path   string `json:"path"`
+	Reason string `json:"reason"`
+	// Offset is the first differing byte when both builds produced the file.
+	Offset int64 `json:"offset"`
+}
+
+// DeterminismReport is the result of comparing two builds of the same project.
+type DeterminismReport struct {
+	FilesCompared int              `json:"files_compared"`
+	Differences   []FileDifference `json:"differences"`
+}
+
+// Deterministic reports whether both builds produced identical output.
+func (r *DeterminismReport) Deterministic() bool {
+	return len(r.Differences) == 0
+}
+
+// VerifyDeterminism bundles the project twice and compares the outputs. In
+// hermetic mode both builds reset the Metro cache and run with a pinned
+// environment, which separates nondeterminism in the build itself from
+// nondeterminism caused by the machine.
+func VerifyDeterminism(ctx context.Context, opts *BundleOptions, hermetic bool, out *output.Writer) (*BundleResult, *DeterminismReport, error) {
+	executor := &DefaultExecutor{Timeout: opts.CommandTimeout}
+	if hermetic {
+		executor.Env = hermeticEnv
+	}
+	return VerifyDeterminismWithExecutor(ctx, opts, hermetic, executor, out)
+}
+
+// VerifyDeterminismWithExecutor is VerifyDeterminism with the given executor.
+// The first build is written to opts.OutputDir and returned; the second build
+// is written to a temporary directory that is removed afterwards.
+func VerifyDeterminismWithExecutor(ctx context.Context, opts *BundleOptions, hermetic bool, executor CommandExecutor, out *output.Writer) (*BundleResult, *DeterminismReport, error) {
+	if hermetic {
+		opts.ResetCache = true
+	}
+
+	out.Info("Determinism check: first build")
+	first, err := RunWithExecutor(ctx, opts, executor, out)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	tmp, err := opts.Workspace.MkdirTemp("determinism-*")
+	if err != nil {
+		return nil, nil, fmt.Errorf("creating temp directory: %w", err)
+	}
+	defer opts.Workspace.Remove(tmp)
+
+	second := *opts
+	second.SkipInstall = true
+	second.OutputDir = filepath.Join(tmp, filepath.Base(first.OutputDir))
+	if opts.SourcemapOutput != "" {
+		second.SourcemapOutput = filepath.Join(tmp, "sourcemap", filepath.Base(opts.SourcemapOutput))
+	}
+
+	out.Info("Determinism check: second build")
+	secondResult, err := RunWithExecutor(ctx, &second, executor, out)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	report, err := CompareOutputs(first.OutputDir, secondResult.OutputDir)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if opts.SourcemapOutput != "" && first.SourcemapPath != "" {
+		report.FilesCompared++
+		if d, err := compareFiles(first.SourcemapPath, secondResult.SourcemapPath); err != nil {
+			return nil, nil, err
+		} else if d != nil {
+			d.Path = filepath.Base(first.SourcemapPath) + " (sourcemap)"
+			report.Differences = append(report.Differences, *d)
+		}
+	}
+
+	return first, report, nil
+}
+
+// CompareOutputs compares two bundle output directories file by file.
+func CompareOutputs(dirA, dirB string) (*DeterminismReport, error) {
+	filesA, err := listFiles(dirA)
+	if err != nil {
+		return nil, err
+	}
+	filesB, err := listFiles(dirB)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &DeterminismReport{Differences: []FileDifference{}}
+	for _, rel := range filesA {
+		if _, ok := slices.BinarySearch(filesB, rel); !ok {
+			report.Differences = append(report.Differences, FileDifference{Path: rel, Reason: DiffOnlyFirst})
+			continue
+		}
+		report.FilesCompared++
+		d, err := compareFiles(filepath.Join(dirA, rel), filepath.Join(dirB, rel))
+		if err != nil {
+			return nil, err
+		}
+		if d != nil {
+			d.Path = rel
+			report.Differences = append(report.Differences, *d)
+		}
+	}
+	for _, rel := range filesB {
+		if _, ok := slices.BinarySearch(filesA, rel); !ok {
+			report.Differences = append(report.Differences, FileDifference{Path: rel, Reason: DiffOnlySecond})
+		}
+	}
+
+	return report, nil
+}
+
+// listFiles returns the sorted slash-separated paths of all files in dir.
+func listFiles(dir string) ([]string, error) {
+	var files []string
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		files = append(files, filepath.ToSlash(rel))
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("listing %s: %w", dir, err)
+	}
+	slices.Sort(files)
+	return files, nil
+}
+
+// compareFiles returns nil when both files are identical, or a difference
+// with the offset of the first differing byte.
+func compareFiles(pathA, pathB string) (*FileDifference, error) {
+	fa, err := os.Open(pathA)
+	if err != nil {
+		return nil, fmt.Errorf("opening %s: %w", pathA, err)
+	}
+	defer func() { _ = fa.Close() }()
+	fb, err := os.Open(pathB)
+	if err != nil {
+		return nil, fmt.Errorf("opening %s: %w", pathB, err)
+	}
+	defer func() { _ = fb.Close() }()
+
+	ra, rb := bufio.NewReader(fa), bufio.NewReader(fb)
+	for offset := int64(0); ; offset++ {
+		a, errA := ra.ReadByte()
+		b, errB := rb.ReadByte()
+		if errors.Is(errA, io.EOF) && errors.Is(errB, io.EOF) {
+			return nil, nil //nolint:nilnil // identical files
+		}
+		if errA != nil && !errors.Is(errA, io.EOF) {
+			return nil, fmt.Errorf("reading %s: %w", pathA, errA)
+		}
+		if errB != nil && !errors.Is(errB, io.EOF) {
+			return nil, fmt.Errorf("reading %s: %w", pathB, errB)
+		}
+		if errA != nil || errB != nil {
+			return &FileDifference{Reason: DiffSize, Offset: offset}, nil
+		}
+		if a != b {
+			return &FileDifference{Reason: DiffContent, Offset: offset}, nil
+		}
+	}
+}
@@ -0,0 +1,105 @@
+package bundler
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/bitrise-io/bitrise-plugins-codepush-cli/internal/output"
+)
+
+func TestCompareOutputs(t *testing.T) {
+	dirA, dirB := t.TempDir(), t.TempDir()
+	for _, dir := range []string{dirA, dirB} {
+		require.NoError(t, os.MkdirAll(filepath.Join(dir, "assets"), 0o755))
+		writeFile(t, filepath.Join(dir, "assets", "logo.png"), "png")
+	}
+	writeFile(t, filepath.Join(dirA, "main.jsbundle"), `var built="2024-01-01"`)
+	writeFile(t, filepath.Join(dirB, "main.jsbundle"), `var built="2024-01-02"`)
+	writeFile(t, filepath.Join(dirA, "main.jsbundle.map"), `{}`)
+	writeFile(t, filepath.Join(dirB, "main.jsbundle.map"), "{}\n")
+	writeFile(t, filepath.Join(dirA, "a1b2.js"), "chunk")
+	writeFile(t, filepath.Join(dirB, "c3d4.js"), "chunk")
+
+	report, err := CompareOutputs(dirA, dirB)
+	require.NoError(t, err)
+
+	assert.False(t, report.Deterministic())
+	assert.Equal(t, 3, report.FilesCompared)
+	assert.Equal(t, []FileDifference{
+		{Path: "a1b2.js", Reason: DiffOnlyFirst},
+		{Path: "main.jsbundle", Reason: DiffContent, Offset: 20},
+		{Path: "main.jsbundle.map", Reason: DiffSize, Offset: 2},
+		{Path: "c3d4.js", Reason: DiffOnlySecond},
+	}, report.Differences)
+}
+
+func TestVerifyDeterminismWithExecutor(t *testing.T) {
+	setup := func(t *testing.T, bundle func(build int) string) (*BundleOptions, *mockExecutor) {
+		t.Helper()
+		dir := t.TempDir()
+		writeFile(t, filepath.Join(dir, "package.json"), `{"dependencies": {"react-native": "0.72.0"}}`)
+		writeFile(t, filepath.Join(dir, "index.js"), "")
+
+		build := 0
+		executor := &mockExecutor{}
+		executor.onRun = func(_ string, _ string, args ...string) {
+			for i, arg := range args {
+				if arg == "--bundle-output" && i+1 < len(args) {
+					build++
+					os.MkdirAll(filepath.Dir(args[i+1]), 0o755)
+					os.WriteFile(args[i+1], []byte(bundle(build)), 0o644)
+				}
+			}
+		}
+
+		return &BundleOptions{
+			Platform:    PlatformIOS,
+			ProjectDir:  dir,
+			OutputDir:   filepath.Join(dir, "CodePush"),
+			HermesMode:  HermesModeOff,
+			SkipInstall: true,
+		}, executor
+	}
+
+	t.Run("identical builds are deterministic", func(t *testing.T) {
+		opts, executor := setup(t, func(int) string { return "bundle" })
+
+		result, report, err := VerifyDeterminismWithExecutor(context.Background(), opts, false, executor, output.NewTest(io.Discard))
+		require.NoError(t, err)
+
+		assert.True(t, report.Deterministic())
+		assert.Equal(t, 1, report.FilesCompared)
+		assert.Equal(t, opts.OutputDir, result.OutputDir)
+		assert.FileExists(t, filepath.Join(opts.OutputDir, "main.jsbundle"))
+	})
+
+	t.Run("reports differing builds", func(t *testing.T) {
+		opts, executor := setup(t, func(build int) string { return fmt.Sprintf("build %d", build) })
+
+		_, report, err := VerifyDeterminismWithExecutor(context.Background(), opts, false, executor, output.NewTest(io.Discard))
+		require.NoError(t, err)
+
+		require.Len(t, report.Differences, 1)
+		assert.Equal(t, "main.jsbundle", report.Differences[0].Path)
+		assert.Equal(t, DiffContent, report.Differences[0].Reason)
+	})
+
+	t.Run("hermetic mode resets the cache", func(t *testing.T) {
+		opts, executor := setup(t, func(int) string { return "bundle" })
+
+		_, _, err := VerifyDeterminismWithExecutor(context.Background(), opts, true, executor, output.NewTest(io.Discard))
+		require.NoError(t, err)
+
+		require.Len(t, executor.commands, 2)
+		for _, c := range executor.commands {
+			assert.Contains(t, c.args, "--reset-cache")
+		}
+	})
+}
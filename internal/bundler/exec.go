@@ -0,0 +1,155 @@
+package bundler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultGracePeriod is how long a stopped command gets to exit after
+// SIGTERM before it is killed.
+const DefaultGracePeriod = 10 * time.Second
+
+// DefaultCommandTimeout is the default limit on the run time of each bundler
+// command.
+const DefaultCommandTimeout = 30 * time.Minute
+
+// Tail of a command's output kept for errors.
+const (
+	tailBytes = 16 * 1024
+	tailLines = 20
+)
+
+// CommandError is returned by DefaultExecutor when a command fails, times
+// out, or is canceled. It carries the last lines of the command's output.
+type CommandError struct {
+	Command string
+	Err     error
+	// Timeout is set when the command was stopped for running longer than it.
+	Timeout time.Duration
+	// Output is the tail of the command's combined stdout and stderr.
+	Output string
+}
+
+func (e *CommandError) Error() string {
+	msg := e.Err.Error()
+	if e.Timeout > 0 {
+		msg = fmt.Sprintf("timed out after %s (raise it with --bundler-timeout)", e.Timeout)
+	}
+	if e.Output == "" {
+		return msg
+	}
+	return msg + "\nlast output:\n" + e.Output
+}
+
+func (e *CommandError) Unwrap() error {
+	return e.Err
+}
+
+// hasOutput reports whether err carries the output of the failed command,
+// so callers don't repeat it.
+func hasOutput(err error) bool {
+	var cmdErr *CommandError
+	return errors.As(err, &cmdErr) && cmdErr.Output != ""
+}
+
+// run starts cmd with start and waits for it. When ctx is done or the
+// executor's timeout elapses, the command's process group is sent SIGTERM
+// and, if it has not exited after the grace period, SIGKILL, so the workers
+// it spawned stop too. A failure is returned as a *CommandError with the
+// tail of the command's output.
+func (e *DefaultExecutor) run(parent context.Context, cmd *exec.Cmd, start func() error, tail *tailWriter) error {
+	if err := parent.Err(); err != nil {
+		return &CommandError{Command: commandLine(cmd.Path, cmd.Args[1:]), Err: err}
+	}
+	ctx := parent
+	if e.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(parent, e.Timeout)
+		defer cancel()
+	}
+	grace := e.GracePeriod
+	if grace <= 0 {
+		grace = DefaultGracePeriod
+	}
+	// Stop waiting for output held open by a process that outlived the command.
+	cmd.WaitDelay = grace
+
+	if err := start(); err != nil {
+		return err
+	}
+	done := make(chan error, 1)
+	go func() { done <- cmd.Wait() }()
+
+	var err error
+	select {
+	case err = <-done:
+	case <-ctx.Done():
+		_ = terminateProcessGroup(cmd.Process)
+		select {
+		case <-done:
+		case <-time.After(grace):
+			_ = killProcessGroup(cmd.Process)
+			<-done
+		}
+		err = ctx.Err()
+	}
+	if err == nil {
+		return nil
+	}
+
+	cmdErr := &CommandError{Command: commandLine(cmd.Path, cmd.Args[1:]), Err: err, Output: tail.String()}
+	if e.Timeout > 0 && errors.Is(err, context.DeadlineExceeded) && parent.Err() == nil {
+		cmdErr.Timeout = e.Timeout
+	}
+	return cmdErr
+}
+
+// ansiEscapeRe matches terminal escape sequences, which PTY output is full of.
+var ansiEscapeRe = regexp.MustCompile(`\x1b\[[0-9;?]*[A-Za-z]`)
+
+// tailWriter keeps the last tailBytes written to it. It is safe for
+// concurrent use, so it can take both stdout and stderr.
+type tailWriter struct {
+	mu  sync.Mutex
+	buf []byte
+}
+
+func (t *tailWriter) Write(p []byte) (int, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.buf = append(t.buf, p...)
+	if len(t.buf) > tailBytes {
+		t.buf = append(t.buf[:0], t.buf[len(t.buf)-tailBytes:]...)
+	}
+	return len(p), nil
+}
+
+// String returns the last tailLines non-empty lines written, without
+// terminal escapes and with progress lines redrawn with \r collapsed to
+// their final state.
+func (t *tailWriter) String() string {
+	t.mu.Lock()
+	text := string(t.buf)
+	t.mu.Unlock()
+
+	text = ansiEscapeRe.ReplaceAllString(strings.ReplaceAll(text, "\r\n", "\n"), "")
+	var lines []string
+	for _, line := range strings.Split(text, "\n") {
+		if i := strings.LastIndex(line, "\r"); i >= 0 {
+			line = line[i+1:]
+		}
+		if line = strings.TrimRight(line, " \t"); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	if len(lines) > tailLines {
+		lines = lines[len(lines)-tailLines:]
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,112 @@
+package bundler
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDefaultExecutorRun(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses sh")
+	}
+
+	t.Run("succeeds", func(t *testing.T) {
+		var stdout bytes.Buffer
+		err := (&DefaultExecutor{}).Run(context.Background(), t.TempDir(), &stdout, io.Discard, "sh", "-c", "echo ok")
+		require.NoError(t, err)
+		assert.Equal(t, "ok\n", stdout.String())
+	})
+
+	t.Run("failure carries the output tail", func(t *testing.T) {
+		err := (&DefaultExecutor{}).Run(context.Background(), t.TempDir(), io.Discard, io.Discard, "sh", "-c", "echo building; echo broken >&2; exit 3")
+		var cmdErr *CommandError
+		require.ErrorAs(t, err, &cmdErr)
+		assert.Equal(t, "building\nbroken", cmdErr.Output)
+		assert.Zero(t, cmdErr.Timeout)
+		assert.EqualError(t, err, "exit status 3\nlast output:\nbuilding\nbroken")
+		assert.True(t, hasOutput(err))
+	})
+
+	t.Run("stops a command that runs too long", func(t *testing.T) {
+		executor := &DefaultExecutor{Timeout: 100 * time.Millisecond, GracePeriod: time.Second}
+		start := time.Now()
+		err := executor.Run(context.Background(), t.TempDir(), io.Discard, io.Discard, "sh", "-c", "echo waiting; sleep 30")
+		assert.Less(t, time.Since(start), 5*time.Second)
+		var cmdErr *CommandError
+		require.ErrorAs(t, err, &cmdErr)
+		assert.Equal(t, 100*time.Millisecond, cmdErr.Timeout)
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+		assert.True(t, strings.HasPrefix(err.Error(), "timed out after 100ms"))
+		assert.Contains(t, err.Error(), "waiting")
+	})
+
+	t.Run("kills a command that ignores SIGTERM", func(t *testing.T) {
+		executor := &DefaultExecutor{Timeout: 100 * time.Millisecond, GracePeriod: 200 * time.Millisecond}
+		start := time.Now()
+		err := executor.Run(context.Background(), t.TempDir(), io.Discard, io.Discard, "sh", "-c", "trap '' TERM; echo ready; sleep 30")
+		assert.Less(t, time.Since(start), 5*time.Second)
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+	})
+
+	t.Run("stops when the context is canceled", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		time.AfterFunc(100*time.Millisecond, cancel)
+		executor := &DefaultExecutor{Timeout: time.Minute}
+		err := executor.Run(ctx, t.TempDir(), io.Discard, io.Discard, "sh", "-c", "sleep 30")
+		var cmdErr *CommandError
+		require.ErrorAs(t, err, &cmdErr)
+		assert.ErrorIs(t, err, context.Canceled)
+		assert.Zero(t, cmdErr.Timeout, "a canceled command did not time out")
+	})
+
+	t.Run("does not start when the context is already canceled", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		dir := t.TempDir()
+		executor := &DefaultExecutor{}
+		err := executor.Run(ctx, dir, io.Discard, io.Discard, "touch", "started")
+		assert.ErrorIs(t, err, context.Canceled)
+		assert.NoFileExists(t, filepath.Join(dir, "started"))
+	})
+}
+
+func TestTailWriter(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{name: "empty", input: "", want: ""},
+		{name: "lines", input: "one\ntwo\n", want: "one\ntwo"},
+		{name: "progress redraws", input: "10%\r50%\r100%\ndone\r\n", want: "100%\ndone"},
+		{name: "terminal escapes", input: "\x1b[32mgreen\x1b[0m\n\x1b[2K\x1b[1Gplain\n", want: "green\nplain"},
+		{name: "blank lines", input: "a\n\n  \nb", want: "a\nb"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tail := &tailWriter{}
+			_, _ = tail.Write([]byte(tt.input))
+			assert.Equal(t, tt.want, tail.String())
+		})
+	}
+
+	t.Run("keeps the last lines", func(t *testing.T) {
+		tail := &tailWriter{}
+		for i := 0; i < 5000; i++ {
+			_, _ = io.WriteString(tail, strings.Repeat("x", 10)+"\n")
+		}
+		_, _ = io.WriteString(tail, "last\n")
+		lines := strings.Split(tail.String(), "\n")
+		assert.Len(t, lines, tailLines)
+		assert.Equal(t, "last", lines[len(lines)-1])
+	})
+}
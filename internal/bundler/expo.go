@@ -1,6 +1,7 @@
 package bundler
 
 import (
+	"context"
 	"fmt"
 	"io"
 	"os"
@@ -19,7 +20,7 @@ type ExpoBundler struct {
 }
 
 // Bundle implements Bundler for Expo projects.
-func (b *ExpoBundler) Bundle(config *ProjectConfig, opts *BundleOptions) (*BundleResult, error) {
+func (b *ExpoBundler) Bundle(ctx context.Context, config *ProjectConfig, opts *BundleOptions) (*BundleResult, error) {
 	outputDir, err := filepath.Abs(opts.OutputDir)
 	if err != nil {
 		return nil, fmt.Errorf("resolving output directory: %w", err)
@@ -46,11 +47,12 @@ func (b *ExpoBundler) Bundle(config *ProjectConfig, opts *BundleOptions) (*Bundl
 
 	progress := b.out.NewProgress("Bundling " + string(opts.Platform))
 	mw := output.NewMetroProgressWriter(progress)
-	err = b.runBundle(config.ProjectDir, mw, "npx", args...)
+	name, args := config.PackageManager.Exec(args[0], args[1:]...)
+	err = b.runBundle(ctx, config.ProjectDir, mw, name, args...)
 	mw.Flush()
 	if err != nil {
 		progress.Cancel()
-		b.out.Info("%s", mw.Buffered())
+		b.out.Println("%s", mw.Buffered())
 		return nil, fmt.Errorf("expo export:embed failed: %w", err)
 	}
 	progress.Done("")
@@ -77,11 +79,8 @@ func (b *ExpoBundler) Bundle(config *ProjectConfig, opts *BundleOptions) (*Bundl
 }
 
 // buildArgs constructs the argument list for "npx expo export:embed".
-func (b *ExpoBundler) runBundle(dir string, w io.Writer, name string, args ...string) error {
-	if b.out.IsInteractive() {
-		return runWithPTY(dir, w, name, args...)
-	}
-	return b.executor.Run(dir, io.Discard, w, name, args...)
+func (b *ExpoBundler) runBundle(ctx context.Context, dir string, w io.Writer, name string, args ...string) error {
+	return runBundleCommand(ctx, b.executor, b.out, dir, w, name, args...)
 }
 
 func (b *ExpoBundler) buildArgs(config *ProjectConfig, opts *BundleOptions, outputDir, bundlePath, mapPath string) []string {
@@ -92,7 +91,11 @@ func (b *ExpoBundler) buildArgs(config *ProjectConfig, opts *BundleOptions, outp
 		"--bundle-output", bundlePath,
 		"--assets-dest", outputDir,
 		"--dev", strconv.FormatBool(opts.Dev),
-		"--minify", strconv.FormatBool(opts.Minify),
+		"--minify", strconv.FormatBool(opts.Minify != nil && *opts.Minify),
+	}
+
+	if opts.MaxWorkers > 0 {
+		args = append(args, "--max-workers", strconv.Itoa(opts.MaxWorkers))
 	}
 
 	if opts.ResetCache {
@@ -0,0 +1,182 @@
+package bundler
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// expoDynamicConfigFiles are the config files that must be evaluated by the
+// Expo CLI because they can contain code (and read EXPO_PUBLIC_* variables).
+var expoDynamicConfigFiles = []string{"app.config.ts", "app.config.js", "app.config.mjs", "app.config.cjs"}
+
+// ExpoConfig holds the fields of the resolved Expo app config that affect
+// bundling and release targeting.
+type ExpoConfig struct {
+	Version        string          `json:"version"`
+	SDKVersion     string          `json:"sdkVersion"`
+	RuntimeVersion json.RawMessage `json:"runtimeVersion"`
+	JSEngine       string          `json:"jsEngine"`
+	EntryPoint     string          `json:"entryPoint"`
+	IOS            expoPlatform    `json:"ios"`
+	Android        expoPlatform    `json:"android"`
+}
+
+// expoPlatform holds the platform-specific overrides of an Expo config.
+type expoPlatform struct {
+	JSEngine       string          `json:"jsEngine"`
+	RuntimeVersion json.RawMessage `json:"runtimeVersion"`
+	BuildNumber    string          `json:"buildNumber"`
+	VersionCode    json.Number     `json:"versionCode"`
+}
+
+func (c *ExpoConfig) platform(p Platform) expoPlatform {
+	if p == PlatformIOS {
+		return c.IOS
+	}
+	return c.Android
+}
+
+// EngineFor returns the JS engine configured for the platform ("hermes",
+// "jsc", or "" when unset).
+func (c *ExpoConfig) EngineFor(p Platform) string {
+	if e := c.platform(p).JSEngine; e != "" {
+		return e
+	}
+	return c.JSEngine
+}
+
+// RuntimeVersionFor resolves the runtime version the app binary reports for
+// the platform. Policies are resolved the way expo-updates does; the
+// fingerprint policy requires native project hashing and yields "".
+func (c *ExpoConfig) RuntimeVersionFor(p Platform) string {
+	raw := c.platform(p).RuntimeVersion
+	if len(raw) == 0 || string(raw) == "null" {
+		raw = c.RuntimeVersion
+	}
+	if len(raw) == 0 || string(raw) == "null" {
+		return ""
+	}
+
+	var literal string
+	if err := json.Unmarshal(raw, &literal); err == nil {
+		return literal
+	}
+
+	var policy struct {
+		Policy string `json:"policy"`
+	}
+	if err := json.Unmarshal(raw, &policy); err != nil {
+		return ""
+	}
+
+	switch policy.Policy {
+	case "appVersion":
+		return c.Version
+	case "nativeVersion":
+		build := c.platform(p).BuildNumber
+		if p == PlatformAndroid {
+			build = c.platform(p).VersionCode.String()
+		}
+		if c.Version == "" || build == "" {
+			return ""
+		}
+		return c.Version + "(" + build + ")"
+	case "sdkVersion":
+		if c.SDKVersion == "" {
+			return ""
+		}
+		return "exposdk:" + c.SDKVersion
+	default:
+		return ""
+	}
+}
+
+// hasDynamicExpoConfig reports whether the project uses an app.config.* file.
+func hasDynamicExpoConfig(projectDir string) bool {
+	for _, name := range expoDynamicConfigFiles {
+		if _, err := os.Stat(filepath.Join(projectDir, name)); err == nil {
+			return true
+		}
+	}
+	return false
+}
+
+// LoadExpoConfig returns the resolved Expo config of a project. Dynamic
+// configs (app.config.*) are evaluated with "expo config --json", run by the
+// package manager pm, which loads .env files so EXPO_PUBLIC_* variables are
+// honored; static projects are read from app.json. Returns (nil, nil) if
+// the project has neither.
+func LoadExpoConfig(ctx context.Context, projectDir string, pm PackageManager, executor CommandExecutor) (*ExpoConfig, error) {
+	if hasDynamicExpoConfig(projectDir) {
+		var stdout, stderr bytes.Buffer
+		name, args := pm.Exec("expo", "config", "--json", "--type", "public")
+		if err := executor.Run(ctx, projectDir, &stdout, &stderr, name, args...); err != nil {
+			msg := strings.TrimSpace(stderr.String())
+			if msg == "" || hasOutput(err) {
+				return nil, fmt.Errorf("evaluating Expo config: %w", err)
+			}
+			return nil, fmt.Errorf("evaluating Expo config: %w: %s", err, msg)
+		}
+
+		var cfg ExpoConfig
+		if err := json.Unmarshal(jsonObject(stdout.Bytes()), &cfg); err != nil {
+			return nil, fmt.Errorf("parsing evaluated Expo config: %w", err)
+		}
+		return &cfg, nil
+	}
+
+	data, err := os.ReadFile(filepath.Join(projectDir, "app.json"))
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil //nolint:nilnil // projects without an Expo config are valid
+		}
+		return nil, fmt.Errorf("reading app.json: %w", err)
+	}
+
+	var appJSON struct {
+		Expo *ExpoConfig `json:"expo"`
+	}
+	if err := json.Unmarshal(data, &appJSON); err != nil {
+		return nil, fmt.Errorf("parsing app.json: %w", err)
+	}
+	return appJSON.Expo, nil
+}
+
+// applyExpoConfig updates the detected project config from the resolved Expo
+// config: the configured JS engine overrides Hermes auto-detection, and a
+// custom entryPoint is used when no entry file was given.
+func applyExpoConfig(config *ProjectConfig, expo *ExpoConfig, hermesMode HermesMode, entryOverride string) {
+	config.RuntimeVersion = expo.RuntimeVersionFor(config.Platform)
+
+	if hermesMode == HermesModeAuto {
+		switch expo.EngineFor(config.Platform) {
+		case "hermes":
+			config.HermesEnabled = true
+		case "jsc":
+			config.HermesEnabled = false
+		}
+	}
+
+	if entryOverride == "" && expo.EntryPoint != "" {
+		if _, err := os.Stat(filepath.Join(config.ProjectDir, expo.EntryPoint)); err == nil {
+			config.EntryFile = expo.EntryPoint
+		}
+	}
+}
+
+// jsonObject returns the JSON object in out, dropping the lines Yarn 1
+// prints around the output of the binaries it runs.
+func jsonObject(out []byte) []byte {
+	start := bytes.IndexByte(out, '{')
+	end := bytes.LastIndexByte(out, '}')
+	if start < 0 || end < start {
+		return out
+	}
+	return out[start : end+1]
+}
@@ -0,0 +1,147 @@
+package bundler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/bitrise-io/bitrise-plugins-codepush-cli/internal/output"
+)
+
+func TestExpoConfigRuntimeVersionFor(t *testing.T) {
+	tests := []struct {
+		name     string
+		config   string
+		platform Platform
+		want     string
+	}{
+		{"literal string", `{"runtimeVersion": "2.0.0"}`, PlatformIOS, "2.0.0"},
+		{"appVersion policy", `{"version": "1.4.0", "runtimeVersion": {"policy": "appVersion"}}`, PlatformIOS, "1.4.0"},
+		{"nativeVersion policy on iOS", `{"version": "1.4.0", "runtimeVersion": {"policy": "nativeVersion"}, "ios": {"buildNumber": "12"}}`, PlatformIOS, "1.4.0(12)"},
+		{"nativeVersion policy on Android", `{"version": "1.4.0", "runtimeVersion": {"policy": "nativeVersion"}, "android": {"versionCode": 7}}`, PlatformAndroid, "1.4.0(7)"},
+		{"nativeVersion policy without build number", `{"version": "1.4.0", "runtimeVersion": {"policy": "nativeVersion"}}`, PlatformIOS, ""},
+		{"sdkVersion policy", `{"sdkVersion": "50.0.0", "runtimeVersion": {"policy": "sdkVersion"}}`, PlatformAndroid, "exposdk:50.0.0"},
+		{"fingerprint policy is unresolved", `{"runtimeVersion": {"policy": "fingerprint"}}`, PlatformIOS, ""},
+		{"platform override wins", `{"runtimeVersion": "1.0.0", "android": {"runtimeVersion": "1.0.0-android"}}`, PlatformAndroid, "1.0.0-android"},
+		{"unset", `{"version": "1.0.0"}`, PlatformIOS, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var cfg ExpoConfig
+			require.NoError(t, json.Unmarshal([]byte(tt.config), &cfg))
+			assert.Equal(t, tt.want, cfg.RuntimeVersionFor(tt.platform))
+		})
+	}
+}
+
+func TestLoadExpoConfig(t *testing.T) {
+	t.Run("evaluates dynamic config with the Expo CLI", func(t *testing.T) {
+		dir := t.TempDir()
+		writeFile(t, filepath.Join(dir, "app.config.ts"), "export default () => ({ runtimeVersion: process.env.EXPO_PUBLIC_RUNTIME })")
+		writeFile(t, filepath.Join(dir, "app.json"), `{"expo": {"runtimeVersion": "static"}}`)
+
+		executor := &mockExecutor{stdout: func(string, ...string) string {
+			return `{"runtimeVersion": "3.1.0", "jsEngine": "jsc"}`
+		}}
+
+		cfg, err := LoadExpoConfig(context.Background(), dir, "", executor)
+		require.NoError(t, err)
+		require.NotNil(t, cfg)
+
+		assert.Equal(t, "3.1.0", cfg.RuntimeVersionFor(PlatformIOS))
+		assert.Equal(t, "jsc", cfg.EngineFor(PlatformIOS))
+		require.Len(t, executor.commands, 1)
+		assert.Equal(t, "npx", executor.commands[0].name)
+		assert.Equal(t, []string{"expo", "config", "--json", "--type", "public"}, executor.commands[0].args)
+	})
+
+	t.Run("runs the Expo CLI with Yarn", func(t *testing.T) {
+		dir := t.TempDir()
+		writeFile(t, filepath.Join(dir, "app.config.js"), "module.exports = {}")
+
+		executor := &mockExecutor{stdout: func(string, ...string) string {
+			return "yarn run v1.22.19\n$ /app/node_modules/.bin/expo config --json --type public\n{\"runtimeVersion\": \"2.0.0\"}\nDone in 1.20s.\n"
+		}}
+
+		cfg, err := LoadExpoConfig(context.Background(), dir, PackageManagerYarn, executor)
+		require.NoError(t, err)
+		assert.Equal(t, "2.0.0", cfg.RuntimeVersionFor(PlatformIOS))
+		assert.Equal(t, "yarn", executor.commands[0].name)
+		assert.Equal(t, []string{"expo", "config", "--json", "--type", "public"}, executor.commands[0].args)
+	})
+
+	t.Run("reads static app.json without running the Expo CLI", func(t *testing.T) {
+		dir := t.TempDir()
+		writeFile(t, filepath.Join(dir, "app.json"), `{"expo": {"runtimeVersion": "1.0.0", "ios": {"jsEngine": "hermes"}}}`)
+
+		executor := &mockExecutor{}
+		cfg, err := LoadExpoConfig(context.Background(), dir, "", executor)
+		require.NoError(t, err)
+		require.NotNil(t, cfg)
+
+		assert.Equal(t, "1.0.0", cfg.RuntimeVersionFor(PlatformIOS))
+		assert.Equal(t, "hermes", cfg.EngineFor(PlatformIOS))
+		assert.Empty(t, cfg.EngineFor(PlatformAndroid))
+		assert.Empty(t, executor.commands)
+	})
+
+	t.Run("returns nil without a config", func(t *testing.T) {
+		cfg, err := LoadExpoConfig(context.Background(), t.TempDir(), "", &mockExecutor{})
+		require.NoError(t, err)
+		assert.Nil(t, cfg)
+	})
+
+	t.Run("returns error when evaluation fails", func(t *testing.T) {
+		dir := t.TempDir()
+		writeFile(t, filepath.Join(dir, "app.config.js"), "module.exports = {}")
+
+		_, err := LoadExpoConfig(context.Background(), dir, "", &mockExecutor{err: errors.New("exit status 1")})
+		require.Error(t, err)
+		assert.ErrorContains(t, err, "evaluating Expo config")
+	})
+}
+
+func TestRunWithExecutorExpoConfig(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "package.json"), `{"dependencies": {"expo": "~50.0.0", "react-native": "0.73.0"}}`)
+	writeFile(t, filepath.Join(dir, "index.js"), "")
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "src"), 0o755))
+	writeFile(t, filepath.Join(dir, "src", "main.tsx"), "")
+	writeFile(t, filepath.Join(dir, "app.config.ts"), "export default {}")
+
+	executor := &mockExecutor{
+		stdout: func(_ string, args ...string) string {
+			if slices.Contains(args, "config") {
+				return `{"version": "2.0.0", "runtimeVersion": {"policy": "appVersion"}, "jsEngine": "jsc", "entryPoint": "src/main.tsx"}`
+			}
+			return ""
+		},
+	}
+
+	opts := &BundleOptions{
+		Platform:    PlatformIOS,
+		ProjectDir:  dir,
+		OutputDir:   filepath.Join(dir, "output"),
+		HermesMode:  HermesModeAuto,
+		SkipInstall: true,
+	}
+
+	result, err := RunWithExecutor(context.Background(), opts, executor, output.NewTest(io.Discard))
+	require.NoError(t, err)
+
+	assert.Equal(t, "2.0.0", result.RuntimeVersion)
+	assert.False(t, result.HermesApplied)
+
+	export := executor.commands[len(executor.commands)-1]
+	assert.NotContains(t, export.args, "--bytecode")
+	assert.Contains(t, export.args, "src/main.tsx")
+}
@@ -1,22 +1,50 @@
 package bundler
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"path/filepath"
+	"slices"
+	"strings"
 
 	"github.com/bitrise-io/bitrise-plugins-codepush-cli/internal/output"
+	"github.com/bitrise-io/bitrise-plugins-codepush-cli/internal/workspace"
 )
 
+// reservedHermesFlags are set by Compile and cannot be given with
+// ParseHermesFlags.
+var reservedHermesFlags = []string{"-emit-binary", "-out"}
+
+// ParseHermesFlags splits a --hermes-flags value such as
+// "-O -output-source-map" into hermesc flags. Every flag must start with a
+// dash, and the flags that choose the output file are rejected because
+// Compile sets them.
+func ParseHermesFlags(value string) ([]string, error) {
+	flags := strings.Fields(value)
+	for _, flag := range flags {
+		if !strings.HasPrefix(flag, "-") {
+			return nil, fmt.Errorf("--hermes-flags: %q is not a flag: give hermesc flags separated by spaces, e.g. \"-O -output-source-map\"", flag)
+		}
+		name, _, _ := strings.Cut(flag, "=")
+		if slices.Contains(reservedHermesFlags, name) {
+			return nil, fmt.Errorf("--hermes-flags: %s is set by the CLI", name)
+		}
+	}
+	return flags, nil
+}
+
 // HermesCompiler handles Hermes bytecode compilation of JS bundles.
 type HermesCompiler struct {
 	executor CommandExecutor
+	ws       *workspace.Workspace
 	out      *output.Writer
 }
 
-// NewHermesCompiler creates a new HermesCompiler.
-func NewHermesCompiler(executor CommandExecutor, out *output.Writer) *HermesCompiler {
-	return &HermesCompiler{executor: executor, out: out}
+// NewHermesCompiler creates a new HermesCompiler. What a failed compilation
+// leaves next to the bundle is removed unless ws keeps artifacts.
+func NewHermesCompiler(executor CommandExecutor, ws *workspace.Workspace, out *output.Writer) *HermesCompiler {
+	return &HermesCompiler{executor: executor, ws: ws, out: out}
 }
 
 // Compile takes a JS bundle path and compiles it to Hermes bytecode.
@@ -24,7 +52,7 @@ func NewHermesCompiler(executor CommandExecutor, out *output.Writer) *HermesComp
 // expect the original filename).
 // If sourcemapPath is non-empty, attempts to compose source maps.
 // extraHermesFlags are appended to the hermesc invocation before the input file.
-func (h *HermesCompiler) Compile(hermescPath string, bundlePath string, sourcemapPath string, extraHermesFlags []string) error {
+func (h *HermesCompiler) Compile(ctx context.Context, hermescPath string, bundlePath string, sourcemapPath string, extraHermesFlags []string) error {
 	if _, err := os.Stat(hermescPath); err != nil {
 		return fmt.Errorf("hermesc binary not found at %s: %w", hermescPath, err)
 	}
@@ -42,17 +70,24 @@ func (h *HermesCompiler) Compile(hermescPath string, bundlePath string, sourcema
 		args = append(args, "-output-source-map")
 	}
 
-	args = append(args, extraHermesFlags...)
+	for _, flag := range extraHermesFlags {
+		// hermesc rejects a flag given twice
+		if !slices.Contains(args, flag) {
+			args = append(args, flag)
+		}
+	}
 	args = append(args, bundlePath)
 
 	h.out.Step("Running Hermes compilation: %s %v", hermescPath, args)
 
-	if err := h.executor.Run("", os.Stderr, os.Stderr, hermescPath, args...); err != nil {
+	if err := h.executor.Run(ctx, "", os.Stderr, os.Stderr, hermescPath, args...); err != nil {
+		h.removeOutput(hbcPath)
 		return fmt.Errorf("hermes compilation failed: %w", err)
 	}
 
 	// Replace the original JS bundle with the compiled bytecode
 	if err := os.Rename(hbcPath, bundlePath); err != nil {
+		h.removeOutput(hbcPath)
 		return fmt.Errorf("replacing bundle with Hermes bytecode: %w", err)
 	}
 
@@ -60,16 +95,23 @@ func (h *HermesCompiler) Compile(hermescPath string, bundlePath string, sourcema
 	if sourcemapPath != "" {
 		hermesMapPath := hbcPath + ".map"
 		if _, err := os.Stat(hermesMapPath); err == nil {
-			h.composeSourceMaps(bundlePath, sourcemapPath, hermesMapPath)
+			h.composeSourceMaps(ctx, bundlePath, sourcemapPath, hermesMapPath)
 		}
 	}
 
 	return nil
 }
 
+// removeOutput removes what a failed compilation left next to the bundle,
+// so it does not end up in the package, unless artifacts are kept.
+func (h *HermesCompiler) removeOutput(hbcPath string) {
+	h.ws.Remove(hbcPath)
+	h.ws.Remove(hbcPath + ".map")
+}
+
 // composeSourceMaps attempts to compose Metro and Hermes source maps.
 // This is a best-effort operation; failures are logged but not fatal.
-func (h *HermesCompiler) composeSourceMaps(bundlePath string, metroMapPath string, hermesMapPath string) {
+func (h *HermesCompiler) composeSourceMaps(ctx context.Context, bundlePath string, metroMapPath string, hermesMapPath string) {
 	projectDir := filepath.Dir(bundlePath)
 
 	// Look for the compose-source-maps script
@@ -83,7 +125,7 @@ func (h *HermesCompiler) composeSourceMaps(bundlePath string, metroMapPath strin
 	}
 
 	composedPath := metroMapPath + ".composed"
-	err := h.executor.Run("", os.Stderr, os.Stderr, "node", composeScript, metroMapPath, hermesMapPath, "-o", composedPath)
+	err := h.executor.Run(ctx, "", os.Stderr, os.Stderr, "node", composeScript, metroMapPath, hermesMapPath, "-o", composedPath)
 	if err != nil {
 		h.out.Warning("source map composition failed, using Hermes source map only")
 		if err := os.Rename(hermesMapPath, metroMapPath); err != nil {
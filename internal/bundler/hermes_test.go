@@ -1,6 +1,8 @@
 package bundler
 
 import (
+	"context"
+	"errors"
 	"io"
 	"os"
 	"path/filepath"
@@ -12,6 +14,32 @@ import (
 	"github.com/bitrise-io/bitrise-plugins-codepush-cli/internal/output"
 )
 
+func TestParseHermesFlags(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    []string
+		wantErr string
+	}{
+		{value: "-O -output-source-map", want: []string{"-O", "-output-source-map"}},
+		{value: "  -O  ", want: []string{"-O"}},
+		{value: "", want: []string{}},
+		{value: "-O fast", wantErr: `"fast" is not a flag`},
+		{value: "-out=x.hbc", wantErr: "-out is set by the CLI"},
+		{value: "-emit-binary", wantErr: "-emit-binary is set by the CLI"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			got, err := ParseHermesFlags(tt.value)
+			if tt.wantErr != "" {
+				assert.ErrorContains(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
 func TestHermesCompilerCompile(t *testing.T) {
 	t.Run("successful compilation", func(t *testing.T) {
 		dir := t.TempDir()
@@ -31,8 +59,8 @@ func TestHermesCompilerCompile(t *testing.T) {
 			}
 		}
 
-		compiler := NewHermesCompiler(executor, output.NewTest(io.Discard))
-		err := compiler.Compile(hermescPath, bundlePath, "", nil)
+		compiler := NewHermesCompiler(executor, nil, output.NewTest(io.Discard))
+		err := compiler.Compile(context.Background(), hermescPath, bundlePath, "", nil)
 		require.NoError(t, err)
 
 		// Verify the command was called correctly
@@ -69,8 +97,8 @@ func TestHermesCompilerCompile(t *testing.T) {
 			}
 		}
 
-		compiler := NewHermesCompiler(executor, output.NewTest(io.Discard))
-		err := compiler.Compile(hermescPath, bundlePath, sourcemapPath, nil)
+		compiler := NewHermesCompiler(executor, nil, output.NewTest(io.Discard))
+		err := compiler.Compile(context.Background(), hermescPath, bundlePath, sourcemapPath, nil)
 		require.NoError(t, err)
 
 		cmd := executor.commands[0]
@@ -94,8 +122,8 @@ func TestHermesCompilerCompile(t *testing.T) {
 			}
 		}
 
-		compiler := NewHermesCompiler(executor, output.NewTest(io.Discard))
-		err := compiler.Compile(hermescPath, bundlePath, "", []string{"-O", "-w"})
+		compiler := NewHermesCompiler(executor, nil, output.NewTest(io.Discard))
+		err := compiler.Compile(context.Background(), hermescPath, bundlePath, "", []string{"-O", "-w"})
 		require.NoError(t, err)
 
 		cmd := executor.commands[0]
@@ -119,15 +147,40 @@ func TestHermesCompilerCompile(t *testing.T) {
 		assert.Less(t, wIdx, inputIdx, "-w must come before input file")
 	})
 
+	t.Run("failed compilation removes partial output", func(t *testing.T) {
+		dir := t.TempDir()
+		bundlePath := filepath.Join(dir, "main.jsbundle")
+		hermescPath := filepath.Join(dir, "hermesc")
+
+		writeFile(t, bundlePath, "console.log('hello')")
+		writeFile(t, hermescPath, "")
+
+		executor := &mockExecutor{err: errors.New("exit status 1")}
+		executor.onRun = func(_ string, _ string, args ...string) {
+			for i, arg := range args {
+				if arg == "-out" && i+1 < len(args) {
+					os.WriteFile(args[i+1], []byte("partial"), 0o644)
+					os.WriteFile(args[i+1]+".map", []byte("{}"), 0o644)
+				}
+			}
+		}
+
+		compiler := NewHermesCompiler(executor, nil, output.NewTest(io.Discard))
+		err := compiler.Compile(context.Background(), hermescPath, bundlePath, "", nil)
+		require.Error(t, err)
+		assert.NoFileExists(t, bundlePath+".hbc")
+		assert.NoFileExists(t, bundlePath+".hbc.map")
+	})
+
 	t.Run("hermesc binary not found", func(t *testing.T) {
 		dir := t.TempDir()
 		bundlePath := filepath.Join(dir, "main.jsbundle")
 		writeFile(t, bundlePath, "console.log('hello')")
 
 		executor := &mockExecutor{}
-		compiler := NewHermesCompiler(executor, output.NewTest(io.Discard))
+		compiler := NewHermesCompiler(executor, nil, output.NewTest(io.Discard))
 
-		err := compiler.Compile("/nonexistent/hermesc", bundlePath, "", nil)
+		err := compiler.Compile(context.Background(), "/nonexistent/hermesc", bundlePath, "", nil)
 		require.Error(t, err)
 	})
 
@@ -137,9 +190,9 @@ func TestHermesCompilerCompile(t *testing.T) {
 		writeFile(t, hermescPath, "")
 
 		executor := &mockExecutor{}
-		compiler := NewHermesCompiler(executor, output.NewTest(io.Discard))
+		compiler := NewHermesCompiler(executor, nil, output.NewTest(io.Discard))
 
-		err := compiler.Compile(hermescPath, "/nonexistent/bundle.js", "", nil)
+		err := compiler.Compile(context.Background(), hermescPath, "/nonexistent/bundle.js", "", nil)
 		require.Error(t, err)
 	})
 
@@ -152,9 +205,9 @@ func TestHermesCompilerCompile(t *testing.T) {
 		writeFile(t, hermescPath, "")
 
 		executor := &mockExecutor{err: &mockExitError{code: 1}}
-		compiler := NewHermesCompiler(executor, output.NewTest(io.Discard))
+		compiler := NewHermesCompiler(executor, nil, output.NewTest(io.Discard))
 
-		err := compiler.Compile(hermescPath, bundlePath, "", nil)
+		err := compiler.Compile(context.Background(), hermescPath, bundlePath, "", nil)
 		require.Error(t, err)
 	})
 
@@ -181,8 +234,8 @@ func TestHermesCompilerCompile(t *testing.T) {
 			}
 		}
 
-		compiler := NewHermesCompiler(executor, output.NewTest(io.Discard))
-		err := compiler.Compile(hermescPath, bundlePath, sourcemapPath, nil)
+		compiler := NewHermesCompiler(executor, nil, output.NewTest(io.Discard))
+		err := compiler.Compile(context.Background(), hermescPath, bundlePath, sourcemapPath, nil)
 		require.NoError(t, err)
 
 		// The hermes map should have been renamed to the metro map path
@@ -209,8 +262,8 @@ func TestComposeSourceMaps(t *testing.T) {
 		writeFile(t, hermesMapPath, `{"hermes":true}`)
 
 		executor := &mockExecutor{}
-		compiler := NewHermesCompiler(executor, output.NewTest(io.Discard))
-		compiler.composeSourceMaps(bundlePath, metroMapPath, hermesMapPath)
+		compiler := NewHermesCompiler(executor, nil, output.NewTest(io.Discard))
+		compiler.composeSourceMaps(context.Background(), bundlePath, metroMapPath, hermesMapPath)
 
 		// Metro map should now contain hermes map content
 		data, err := os.ReadFile(metroMapPath)
@@ -234,8 +287,8 @@ func TestComposeSourceMaps(t *testing.T) {
 		writeFile(t, hermesMapPath, `{"hermes":true}`)
 
 		executor := &mockExecutor{err: &mockExitError{code: 1}}
-		compiler := NewHermesCompiler(executor, output.NewTest(io.Discard))
-		compiler.composeSourceMaps(bundlePath, metroMapPath, hermesMapPath)
+		compiler := NewHermesCompiler(executor, nil, output.NewTest(io.Discard))
+		compiler.composeSourceMaps(context.Background(), bundlePath, metroMapPath, hermesMapPath)
 
 		// Should fall back to hermes map on failure
 		data, err := os.ReadFile(metroMapPath)
@@ -268,8 +321,8 @@ func TestComposeSourceMaps(t *testing.T) {
 			}
 		}
 
-		compiler := NewHermesCompiler(executor, output.NewTest(io.Discard))
-		compiler.composeSourceMaps(bundlePath, metroMapPath, hermesMapPath)
+		compiler := NewHermesCompiler(executor, nil, output.NewTest(io.Discard))
+		compiler.composeSourceMaps(context.Background(), bundlePath, metroMapPath, hermesMapPath)
 
 		// Metro map should have composed content
 		data, err := os.ReadFile(metroMapPath)
@@ -0,0 +1,390 @@
+package bundler
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/bitrise-io/bitrise-plugins-codepush-cli/internal/output"
+)
+
+// nodeVersionFiles are the files naming the Node.js version of a project, in
+// order of precedence.
+var nodeVersionFiles = []string{".nvmrc", ".node-version"}
+
+// nodeRequirement is the Node.js version a project asks for.
+type nodeRequirement struct {
+	// VersionFile is the file pinning the version, e.g. ".nvmrc", and
+	// Version the version it names, e.g. "18" or "18.17.0".
+	VersionFile string
+	Version     string
+	// Engines is the engines.node range of package.json, e.g. ">=18".
+	Engines string
+}
+
+// readNodeRequirement reads the Node.js version pinned by .nvmrc or
+// .node-version and the engines.node range of package.json. Versions that
+// are not numeric, like "lts/*", are ignored.
+func readNodeRequirement(projectDir string) nodeRequirement {
+	var req nodeRequirement
+	for _, name := range nodeVersionFiles {
+		data, err := os.ReadFile(filepath.Join(projectDir, name))
+		if err != nil {
+			continue
+		}
+		line, _, _ := strings.Cut(string(data), "\n")
+		line, _, _ = strings.Cut(line, "#")
+		version := strings.TrimPrefix(strings.TrimSpace(line), "v")
+		if isNumericVersion(version) {
+			req.VersionFile, req.Version = name, version
+		}
+		break
+	}
+
+	if data, err := os.ReadFile(filepath.Join(projectDir, "package.json")); err == nil {
+		var pkg struct {
+			Engines struct {
+				Node string `json:"node"`
+			} `json:"engines"`
+		}
+		if json.Unmarshal(data, &pkg) == nil {
+			req.Engines = strings.TrimSpace(pkg.Engines.Node)
+		}
+	}
+	return req
+}
+
+// isNumericVersion reports whether v is a version like "18" or "18.17.0".
+func isNumericVersion(v string) bool {
+	if v == "" {
+		return false
+	}
+	for _, part := range strings.Split(v, ".") {
+		if _, err := strconv.Atoi(part); err != nil {
+			return false
+		}
+	}
+	return true
+}
+
+// activeNodeVersion returns the version of the node executable that
+// executor runs, e.g. "18.17.0".
+func activeNodeVersion(ctx context.Context, executor CommandExecutor, dir string) (string, error) {
+	var stdout bytes.Buffer
+	if err := executor.Run(ctx, dir, &stdout, io.Discard, "node", "--version"); err != nil {
+		return "", fmt.Errorf("running node --version: %w", err)
+	}
+	version := strings.TrimPrefix(strings.TrimSpace(stdout.String()), "v")
+	if len(parseVersion(version)) != 3 {
+		return "", fmt.Errorf("unexpected node --version output %q", stdout.String())
+	}
+	return version, nil
+}
+
+// checkNodeVersion checks that the active Node.js version satisfies the
+// project's engines.node range and .nvmrc, so a wrong Node version fails
+// before Metro does with a cryptic stack trace. A version differing from
+// .nvmrc only in minor or patch is a warning. With opts.UseNodeVersion, a
+// mismatch is fixed by running the commands through fnm or nvm with the
+// .nvmrc version; the returned executor does so.
+func checkNodeVersion(ctx context.Context, opts *BundleOptions, executor CommandExecutor, out *output.Writer) (CommandExecutor, error) {
+	if opts.SkipNodeCheck {
+		return executor, nil
+	}
+	req := readNodeRequirement(opts.ProjectDir)
+	if req.Version == "" && req.Engines == "" {
+		return executor, nil
+	}
+
+	active, err := activeNodeVersion(ctx, executor, opts.ProjectDir)
+	if err != nil {
+		out.Warning("could not check the Node.js version: %v", err)
+		return executor, nil
+	}
+	problem := req.check(active, out)
+	if problem == "" {
+		return executor, nil
+	}
+	if !opts.UseNodeVersion {
+		return nil, fmt.Errorf("%s; switch Node.js versions, pass --use-node-version to run the bundler through fnm or nvm, or --skip-node-check", problem)
+	}
+	if req.Version == "" {
+		return nil, fmt.Errorf("%s; --use-node-version needs a .nvmrc or .node-version naming the version to use", problem)
+	}
+
+	shim, err := findNodeShim(req.Version)
+	if err != nil {
+		return nil, fmt.Errorf("%s; %w", problem, err)
+	}
+	shimmed := &nodeShimExecutor{next: executor, prefix: shim.prefix}
+	active, err = activeNodeVersion(ctx, shimmed, opts.ProjectDir)
+	if err != nil {
+		return nil, fmt.Errorf("running Node.js %s through %s: %w", req.Version, shim.name, err)
+	}
+	if problem := req.check(active, out); problem != "" {
+		return nil, fmt.Errorf("through %s: %s", shim.name, problem)
+	}
+	out.Info("Using Node.js v%s through %s", active, shim.name)
+	return shimmed, nil
+}
+
+// check returns why active does not satisfy req, or "" when it does.
+func (req nodeRequirement) check(active string, out *output.Writer) string {
+	version := parseVersion(active)
+	if req.Engines != "" {
+		r, err := parseVersionRange(req.Engines)
+		switch {
+		case err != nil:
+			out.Warning("not checking engines.node %q in package.json: %v", req.Engines, err)
+		case !r.contains(version):
+			return fmt.Sprintf("Node.js v%s does not satisfy engines.node %q in package.json", active, req.Engines)
+		}
+	}
+	if req.Version != "" {
+		want := parseVersion(req.Version)
+		if want[0] != version[0] {
+			return fmt.Sprintf("Node.js v%s does not match %s (%s)", active, req.VersionFile, req.Version)
+		}
+		if compareVersions(want, version[:len(want)]) != 0 {
+			out.Warning("Node.js v%s differs from %s (%s)", active, req.VersionFile, req.Version)
+		}
+	}
+	return ""
+}
+
+// nodeShim is a command running another command with a given Node.js
+// version.
+type nodeShim struct {
+	name   string
+	prefix []string
+}
+
+// findNodeShim returns the fnm or nvm command running commands with Node.js
+// version: "fnm exec", or nvm's nvm-exec script in $NVM_DIR.
+func findNodeShim(version string) (nodeShim, error) {
+	if path, err := lookPath("fnm"); err == nil {
+		return nodeShim{name: "fnm", prefix: []string{path, "exec", "--using=" + version}}, nil
+	}
+	if dir := os.Getenv("NVM_DIR"); dir != "" {
+		script := filepath.Join(dir, "nvm-exec")
+		if _, err := os.Stat(script); err == nil {
+			return nodeShim{name: "nvm", prefix: []string{"env", "NODE_VERSION=" + version, script}}, nil
+		}
+	}
+	return nodeShim{}, errors.New("neither fnm nor nvm ($NVM_DIR/nvm-exec) was found")
+}
+
+// nodeShimExecutor runs every command through a Node.js version manager,
+// e.g. "fnm exec --using=18 npx react-native bundle".
+type nodeShimExecutor struct {
+	next   CommandExecutor
+	prefix []string
+}
+
+func (e *nodeShimExecutor) Run(ctx context.Context, dir string, stdout io.Writer, stderr io.Writer, name string, args ...string) error {
+	name, args = e.command(name, args)
+	return e.next.Run(ctx, dir, stdout, stderr, name, args...)
+}
+
+func (e *nodeShimExecutor) RunPTY(ctx context.Context, dir string, w io.Writer, name string, args ...string) error {
+	name, args = e.command(name, args)
+	return runOnPTY(ctx, e.next, dir, w, name, args...)
+}
+
+func (e *nodeShimExecutor) command(name string, args []string) (string, []string) {
+	shimmed := append(append(append([]string{}, e.prefix[1:]...), name), args...)
+	return e.prefix[0], shimmed
+}
+
+// versionRange is an npm semver range, e.g. ">=18 <21" or "^18.17.0 ||
+// >=20": any of its comparator sets, each a list of comparators that must
+// all hold.
+type versionRange [][]comparator
+
+type comparator struct {
+	op      string // one of >=, >, <, <=
+	version []int  // major, minor, patch
+}
+
+// parseVersionRange parses the npm semver ranges engines.node holds:
+// comparators (>=18.0.0), partial and x-ranges (18, 18.x, *), caret and
+// tilde ranges (^18.17.0, ~18.17), and hyphen ranges (16 - 18), joined with
+// spaces and "||". Pre-release tags are not supported.
+func parseVersionRange(s string) (versionRange, error) {
+	var r versionRange
+	for _, alt := range strings.Split(s, "||") {
+		fields := joinOperators(strings.Fields(alt))
+		var set []comparator
+		for i := 0; i < len(fields); i++ {
+			if i+2 < len(fields) && fields[i+1] == "-" {
+				lo, err := parsePartialVersion(fields[i])
+				if err != nil {
+					return nil, err
+				}
+				hi, err := parsePartialVersion(fields[i+2])
+				if err != nil {
+					return nil, err
+				}
+				set = append(set, comparator{">=", pad(lo)}, upperBound(hi))
+				i += 2
+				continue
+			}
+			cs, err := parseComparator(fields[i])
+			if err != nil {
+				return nil, err
+			}
+			set = append(set, cs...)
+		}
+		r = append(r, set)
+	}
+	return r, nil
+}
+
+// joinOperators joins operators written apart from their version, as in
+// ">= 18", to the version.
+func joinOperators(fields []string) []string {
+	var joined []string
+	for i := 0; i < len(fields); i++ {
+		f := fields[i]
+		if strings.Trim(f, "<>=^~") == "" && f != "-" && i+1 < len(fields) {
+			f += fields[i+1]
+			i++
+		}
+		joined = append(joined, f)
+	}
+	return joined
+}
+
+// parseComparator parses a single comparator into the bounds it stands
+// for.
+func parseComparator(s string) ([]comparator, error) {
+	op := ""
+	for _, prefix := range []string{">=", "<=", ">", "<", "=", "^", "~"} {
+		if strings.HasPrefix(s, prefix) {
+			op, s = prefix, strings.TrimSpace(strings.TrimPrefix(s, prefix))
+			break
+		}
+	}
+	v, err := parsePartialVersion(s)
+	if err != nil {
+		return nil, err
+	}
+
+	switch op {
+	case ">=":
+		return []comparator{{">=", pad(v)}}, nil
+	case ">":
+		if len(v) < 3 {
+			// >18 means >=19.0.0.
+			return []comparator{{">=", bump(v)}}, nil
+		}
+		return []comparator{{">", v}}, nil
+	case "<":
+		return []comparator{{"<", pad(v)}}, nil
+	case "<=":
+		if len(v) < 3 {
+			return []comparator{upperBound(v)}, nil
+		}
+		return []comparator{{"<=", v}}, nil
+	case "^":
+		if len(v) == 0 {
+			return nil, nil
+		}
+		// Bump the first nonzero part, or the last given part.
+		n := len(v)
+		for i, p := range v {
+			if p != 0 {
+				n = i + 1
+				break
+			}
+		}
+		return []comparator{{">=", pad(v)}, upperBound(v[:n])}, nil
+	case "~":
+		return []comparator{{">=", pad(v)}, upperBound(v[:min(len(v), 2)])}, nil
+	default:
+		if len(v) == 0 {
+			return nil, nil
+		}
+		return []comparator{{">=", pad(v)}, upperBound(v)}, nil
+	}
+}
+
+// parsePartialVersion parses a version with optional v prefix that may end
+// in an x or * wildcard, returning its given parts: "18.x" is [18].
+func parsePartialVersion(s string) ([]int, error) {
+	s = strings.TrimPrefix(s, "v")
+	var parts []int
+	for i, part := range strings.Split(s, ".") {
+		if part == "x" || part == "X" || part == "*" {
+			break
+		}
+		n, err := strconv.Atoi(part)
+		if err != nil || i > 2 {
+			return nil, fmt.Errorf("invalid version %q", s)
+		}
+		parts = append(parts, n)
+	}
+	return parts, nil
+}
+
+// pad fills a partial version up with zeros.
+func pad(v []int) []int {
+	return append(append([]int{}, v...), make([]int, 3-len(v))...)
+}
+
+// bump returns the lowest version above every version matching partial
+// version v: [18] gives 19.0.0 and [18, 17] gives 18.18.0.
+func bump(v []int) []int {
+	b := pad(v[:len(v)-1])
+	b[len(v)-1] = v[len(v)-1] + 1
+	return b
+}
+
+// upperBound is the exclusive upper bound of partial version v; a full
+// version is its own, inclusive, bound.
+func upperBound(v []int) comparator {
+	switch len(v) {
+	case 0:
+		return comparator{">=", pad(nil)}
+	case 3:
+		return comparator{"<=", v}
+	}
+	return comparator{"<", bump(v)}
+}
+
+func (r versionRange) contains(v []int) bool {
+	for _, set := range r {
+		ok := true
+		for _, c := range set {
+			if !c.holds(v) {
+				ok = false
+				break
+			}
+		}
+		if ok {
+			return true
+		}
+	}
+	return false
+}
+
+func (c comparator) holds(v []int) bool {
+	d := compareVersions(v, c.version)
+	switch c.op {
+	case ">=":
+		return d >= 0
+	case ">":
+		return d > 0
+	case "<":
+		return d < 0
+	default:
+		return d <= 0
+	}
+}
@@ -0,0 +1,227 @@
+package bundler
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/bitrise-io/bitrise-plugins-codepush-cli/internal/output"
+)
+
+func TestParseVersionRange(t *testing.T) {
+	tests := []struct {
+		rng string
+		in  []string
+		out []string
+	}{
+		{rng: ">=18", in: []string{"18.0.0", "22.1.0"}, out: []string{"16.20.2", "17.9.9"}},
+		{rng: ">= 18.17.0", in: []string{"18.17.0", "20.0.0"}, out: []string{"18.16.1"}},
+		{rng: ">16", in: []string{"17.0.0"}, out: []string{"16.20.2"}},
+		{rng: "<=18", in: []string{"18.20.0"}, out: []string{"19.0.0"}},
+		{rng: "18", in: []string{"18.0.0", "18.20.4"}, out: []string{"17.9.0", "19.0.0"}},
+		{rng: "18.x", in: []string{"18.5.0"}, out: []string{"20.0.0"}},
+		{rng: "18.17.0", in: []string{"18.17.0"}, out: []string{"18.17.1"}},
+		{rng: "^18.17.0", in: []string{"18.17.0", "18.20.0"}, out: []string{"18.16.0", "19.0.0"}},
+		{rng: "~18.17.0", in: []string{"18.17.5"}, out: []string{"18.18.0"}},
+		{rng: "^0.10.0", in: []string{"0.10.48"}, out: []string{"0.11.0"}},
+		{rng: ">=18 <21", in: []string{"20.11.0"}, out: []string{"21.0.0", "16.0.0"}},
+		{rng: "^18.17.0 || >=20", in: []string{"18.19.0", "22.0.0"}, out: []string{"19.0.0"}},
+		{rng: "16 - 18", in: []string{"16.0.0", "18.20.0"}, out: []string{"15.9.0", "19.0.0"}},
+		{rng: "*", in: []string{"10.0.0", "22.0.0"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.rng, func(t *testing.T) {
+			r, err := parseVersionRange(tt.rng)
+			require.NoError(t, err)
+			for _, v := range tt.in {
+				assert.True(t, r.contains(parseVersion(v)), "%s should satisfy %s", v, tt.rng)
+			}
+			for _, v := range tt.out {
+				assert.False(t, r.contains(parseVersion(v)), "%s should not satisfy %s", v, tt.rng)
+			}
+		})
+	}
+
+	for _, rng := range []string{"latest", ">=", "18.a"} {
+		_, err := parseVersionRange(rng)
+		assert.Error(t, err, rng)
+	}
+}
+
+func TestReadNodeRequirement(t *testing.T) {
+	tests := []struct {
+		name  string
+		files map[string]string
+		want  nodeRequirement
+	}{
+		{
+			name:  "nvmrc and engines",
+			files: map[string]string{".nvmrc": "v18.17.0\n", "package.json": `{"engines": {"node": ">=18"}}`},
+			want:  nodeRequirement{VersionFile: ".nvmrc", Version: "18.17.0", Engines: ">=18"},
+		},
+		{
+			name:  "node-version",
+			files: map[string]string{".node-version": "20 # LTS\n"},
+			want:  nodeRequirement{VersionFile: ".node-version", Version: "20"},
+		},
+		{
+			name:  "nvmrc takes precedence",
+			files: map[string]string{".nvmrc": "18", ".node-version": "20"},
+			want:  nodeRequirement{VersionFile: ".nvmrc", Version: "18"},
+		},
+		{
+			name:  "ignores aliases",
+			files: map[string]string{".nvmrc": "lts/hydrogen"},
+			want:  nodeRequirement{},
+		},
+		{
+			name: "nothing",
+			want: nodeRequirement{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			for name, content := range tt.files {
+				writeFile(t, filepath.Join(dir, name), content)
+			}
+			assert.Equal(t, tt.want, readNodeRequirement(dir))
+		})
+	}
+}
+
+func TestCheckNodeVersion(t *testing.T) {
+	// nodeExecutor reports version for node --version run directly and
+	// shimmed for node --version run through a version manager.
+	nodeExecutor := func(version, shimmed string) *mockExecutor {
+		return &mockExecutor{stdout: func(name string, args ...string) string {
+			if name == "node" {
+				return "v" + version + "\n"
+			}
+			return "v" + shimmed + "\n"
+		}}
+	}
+	project := func(t *testing.T, files map[string]string) *BundleOptions {
+		t.Helper()
+		dir := t.TempDir()
+		for name, content := range files {
+			writeFile(t, filepath.Join(dir, name), content)
+		}
+		return &BundleOptions{ProjectDir: dir}
+	}
+	noShims := func(t *testing.T) {
+		lookPath = func(string) (string, error) { return "", exec.ErrNotFound }
+		t.Cleanup(func() { lookPath = exec.LookPath })
+		t.Setenv("NVM_DIR", "")
+	}
+
+	t.Run("passes a matching version", func(t *testing.T) {
+		opts := project(t, map[string]string{".nvmrc": "18", "package.json": `{"engines": {"node": ">=18"}}`})
+		executor := nodeExecutor("18.19.1", "")
+		got, err := checkNodeVersion(context.Background(), opts, executor, output.NewTest(io.Discard))
+		require.NoError(t, err)
+		assert.Same(t, executor, got)
+	})
+
+	t.Run("fails a version outside engines.node", func(t *testing.T) {
+		opts := project(t, map[string]string{"package.json": `{"engines": {"node": ">=18"}}`})
+		_, err := checkNodeVersion(context.Background(), opts, nodeExecutor("16.20.2", ""), output.NewTest(io.Discard))
+		assert.ErrorContains(t, err, `Node.js v16.20.2 does not satisfy engines.node ">=18" in package.json`)
+		assert.ErrorContains(t, err, "--skip-node-check")
+	})
+
+	t.Run("fails a different major than .nvmrc", func(t *testing.T) {
+		opts := project(t, map[string]string{".nvmrc": "20.11.0"})
+		_, err := checkNodeVersion(context.Background(), opts, nodeExecutor("18.19.1", ""), output.NewTest(io.Discard))
+		assert.ErrorContains(t, err, "Node.js v18.19.1 does not match .nvmrc (20.11.0)")
+	})
+
+	t.Run("warns about a different minor than .nvmrc", func(t *testing.T) {
+		opts := project(t, map[string]string{".nvmrc": "20.11.0"})
+		var buf bytes.Buffer
+		_, err := checkNodeVersion(context.Background(), opts, nodeExecutor("20.12.2", ""), output.NewTest(&buf))
+		require.NoError(t, err)
+		assert.Contains(t, buf.String(), "Node.js v20.12.2 differs from .nvmrc (20.11.0)")
+	})
+
+	t.Run("skips without requirements or when asked to", func(t *testing.T) {
+		executor := nodeExecutor("16.0.0", "")
+		_, err := checkNodeVersion(context.Background(), project(t, nil), executor, output.NewTest(io.Discard))
+		require.NoError(t, err)
+		assert.Empty(t, executor.commands, "node is not run without requirements")
+
+		opts := project(t, map[string]string{".nvmrc": "20"})
+		opts.SkipNodeCheck = true
+		_, err = checkNodeVersion(context.Background(), opts, executor, output.NewTest(io.Discard))
+		require.NoError(t, err)
+	})
+
+	t.Run("warns when node cannot be run", func(t *testing.T) {
+		opts := project(t, map[string]string{".nvmrc": "20"})
+		var buf bytes.Buffer
+		_, err := checkNodeVersion(context.Background(), opts, &mockExecutor{err: errors.New("executable file not found")}, output.NewTest(&buf))
+		require.NoError(t, err)
+		assert.Contains(t, buf.String(), "could not check the Node.js version")
+	})
+
+	t.Run("runs commands through fnm with the .nvmrc version", func(t *testing.T) {
+		lookPath = func(name string) (string, error) { return "/usr/local/bin/" + name, nil }
+		t.Cleanup(func() { lookPath = exec.LookPath })
+
+		opts := project(t, map[string]string{".nvmrc": "20"})
+		opts.UseNodeVersion = true
+		executor := nodeExecutor("18.19.1", "20.11.0")
+		got, err := checkNodeVersion(context.Background(), opts, executor, output.NewTest(io.Discard))
+		require.NoError(t, err)
+
+		require.NoError(t, got.Run(context.Background(), opts.ProjectDir, io.Discard, io.Discard, "npx", "react-native", "bundle"))
+		last := executor.commands[len(executor.commands)-1]
+		assert.Equal(t, "/usr/local/bin/fnm", last.name)
+		assert.Equal(t, []string{"exec", "--using=20", "npx", "react-native", "bundle"}, last.args)
+	})
+
+	t.Run("runs commands through nvm-exec", func(t *testing.T) {
+		noShims(t)
+		nvmDir := t.TempDir()
+		writeFile(t, filepath.Join(nvmDir, "nvm-exec"), "#!/usr/bin/env bash")
+		t.Setenv("NVM_DIR", nvmDir)
+
+		opts := project(t, map[string]string{".nvmrc": "20"})
+		opts.UseNodeVersion = true
+		executor := nodeExecutor("18.19.1", "20.11.0")
+		got, err := checkNodeVersion(context.Background(), opts, executor, output.NewTest(io.Discard))
+		require.NoError(t, err)
+
+		require.NoError(t, got.Run(context.Background(), opts.ProjectDir, io.Discard, io.Discard, "node", "--version"))
+		last := executor.commands[len(executor.commands)-1]
+		assert.Equal(t, "env", last.name)
+		assert.Equal(t, []string{"NODE_VERSION=20", filepath.Join(nvmDir, "nvm-exec"), "node", "--version"}, last.args)
+	})
+
+	t.Run("fails without a version manager", func(t *testing.T) {
+		noShims(t)
+		opts := project(t, map[string]string{".nvmrc": "20"})
+		opts.UseNodeVersion = true
+		_, err := checkNodeVersion(context.Background(), opts, nodeExecutor("18.19.1", ""), output.NewTest(io.Discard))
+		assert.ErrorContains(t, err, "neither fnm nor nvm")
+	})
+
+	t.Run("fails when the version manager gives another version", func(t *testing.T) {
+		lookPath = func(name string) (string, error) { return "/usr/local/bin/" + name, nil }
+		t.Cleanup(func() { lookPath = exec.LookPath })
+
+		opts := project(t, map[string]string{".nvmrc": "20"})
+		opts.UseNodeVersion = true
+		_, err := checkNodeVersion(context.Background(), opts, nodeExecutor("18.19.1", "18.19.1"), output.NewTest(io.Discard))
+		assert.ErrorContains(t, err, "through fnm: Node.js v18.19.1 does not match .nvmrc (20)")
+	})
+}
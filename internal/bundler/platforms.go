@@ -0,0 +1,136 @@
+package bundler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/bitrise-io/bitrise-plugins-codepush-cli/internal/output"
+)
+
+// PlatformAll selects every platform in ParsePlatforms.
+const PlatformAll = "all"
+
+// ParsePlatforms parses a --platform value: a single platform, a
+// comma-separated list of platforms, or "all" for iOS and Android.
+func ParsePlatforms(value string) ([]Platform, error) {
+	if strings.TrimSpace(value) == PlatformAll {
+		return []Platform{PlatformIOS, PlatformAndroid}, nil
+	}
+	var platforms []Platform
+	for _, part := range strings.Split(value, ",") {
+		p := Platform(strings.TrimSpace(part))
+		if err := ValidatePlatform(p); err != nil {
+			return nil, fmt.Errorf("--platform must be 'ios', 'android', 'all', or a comma-separated list, got %q", value)
+		}
+		if !slices.Contains(platforms, p) {
+			platforms = append(platforms, p)
+		}
+	}
+	return platforms, nil
+}
+
+// PlatformOptions returns the options to build platform with as part of a
+// multi-platform build. Its output goes to a subdirectory of the output
+// directory named after the platform, ending in the output directory's own
+// name, which code signing requires: ./CodePush becomes
+// ./CodePush/ios/CodePush. An explicit sourcemap path gets the same
+// platform subdirectory.
+func PlatformOptions(opts *BundleOptions, platform Platform) *BundleOptions {
+	p := *opts
+	p.Platform = platform
+	outputDir := opts.OutputDir
+	if outputDir == "" {
+		outputDir = DefaultOutputDir
+	}
+	p.OutputDir = filepath.Join(outputDir, string(platform), filepath.Base(outputDir))
+	if opts.SourcemapOutput != "" {
+		p.SourcemapOutput = filepath.Join(filepath.Dir(opts.SourcemapOutput), string(platform), filepath.Base(opts.SourcemapOutput))
+	}
+	return &p
+}
+
+// RunPlatforms builds the bundle for each platform at once, with the
+// options given by PlatformOptions. The Node.js version is checked and
+// dependencies are installed once, before the builds start. With a non-nil
+// cache, builds are looked up and stored as RunCached does, and both only
+// happen when a build is not cached. Output of each build is prefixed with
+// its platform. Results keep the order of platforms; when any build fails,
+// the errors of all failed builds are returned. The builds share opts.LogFile, each line of a
+// build's commands starting with its platform.
+func RunPlatforms(ctx context.Context, opts *BundleOptions, platforms []Platform, cache Cache, version string, out *output.Writer) ([]*BundleResult, error) {
+	return RunPlatformsWithExecutor(ctx, opts, platforms, cache, version, &DefaultExecutor{Timeout: opts.CommandTimeout}, out)
+}
+
+// RunPlatformsWithExecutor is RunPlatforms with the given executor.
+func RunPlatformsWithExecutor(ctx context.Context, opts *BundleOptions, platforms []Platform, cache Cache, version string, executor CommandExecutor, out *output.Writer) ([]*BundleResult, error) {
+	if _, err := resolveRunOptions(opts); err != nil {
+		return nil, err
+	}
+
+	results := make([]*BundleResult, len(platforms))
+	keys := make([]string, len(platforms))
+	platformOpts := make([]*BundleOptions, len(platforms))
+	outs := make([]*output.Writer, len(platforms))
+	var pending []int
+	for i, platform := range platforms {
+		platformOpts[i] = PlatformOptions(opts, platform)
+		platformOpts[i].SkipInstall = true
+		platformOpts[i].SkipNodeCheck = true
+		outs[i] = out.WithPrefix("[" + string(platform) + "]")
+		if cache != nil {
+			keys[i], results[i] = lookupCache(platformOpts[i], cache, version, outs[i])
+		}
+		if results[i] == nil {
+			pending = append(pending, i)
+		}
+	}
+	if len(pending) == 0 {
+		return results, nil
+	}
+
+	executor, err := checkNodeVersion(ctx, opts, executor, out)
+	if err != nil {
+		return nil, err
+	}
+	log, err := openCommandLog(opts.LogFile)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = log.Close() }()
+
+	if !opts.SkipInstall {
+		if err := installDependencies(ctx, opts.ProjectDir, packageManagerFor(opts.ProjectDir, opts), logCommands(executor, log, "", out), out); err != nil {
+			return nil, err
+		}
+	}
+
+	errs := make([]error, len(platforms))
+	var wg sync.WaitGroup
+	for _, i := range pending {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			executor := logCommands(executor, log, "["+string(platforms[i])+"]", outs[i])
+			result, err := RunWithExecutor(ctx, platformOpts[i], executor, outs[i])
+			if err != nil {
+				errs[i] = fmt.Errorf("%s: %w", platforms[i], err)
+				return
+			}
+			if keys[i] != "" {
+				storeResult(opts.Workspace, cacheSlot{cache: cache, key: keys[i]}, result, outs[i])
+			}
+			results[i] = result
+		}()
+	}
+	wg.Wait()
+
+	if err := errors.Join(errs...); err != nil {
+		return nil, err
+	}
+	return results, nil
+}
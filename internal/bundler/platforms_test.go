@@ -0,0 +1,111 @@
+package bundler
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/bitrise-io/bitrise-plugins-codepush-cli/internal/output"
+)
+
+func TestParsePlatforms(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    []Platform
+		wantErr bool
+	}{
+		{value: "ios", want: []Platform{PlatformIOS}},
+		{value: "all", want: []Platform{PlatformIOS, PlatformAndroid}},
+		{value: "android, ios", want: []Platform{PlatformAndroid, PlatformIOS}},
+		{value: "ios,ios", want: []Platform{PlatformIOS}},
+		{value: "ios,windows", wantErr: true},
+		{value: "", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			got, err := ParsePlatforms(tt.value)
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestPlatformOptions(t *testing.T) {
+	opts := &BundleOptions{OutputDir: "build/CodePush", SourcemapOutput: "maps/main.map"}
+
+	got := PlatformOptions(opts, PlatformAndroid)
+	assert.Equal(t, PlatformAndroid, got.Platform)
+	assert.Equal(t, filepath.Join("build", "CodePush", "android", "CodePush"), got.OutputDir)
+	assert.Equal(t, filepath.Join("maps", "android", "main.map"), got.SourcemapOutput)
+	assert.Equal(t, "build/CodePush", opts.OutputDir, "the caller's options should not be modified")
+
+	assert.Equal(t, filepath.Join("CodePush", "ios", "CodePush"), PlatformOptions(&BundleOptions{}, PlatformIOS).OutputDir)
+}
+
+// lockedExecutor serializes the commands of concurrent builds.
+type lockedExecutor struct {
+	mu   sync.Mutex
+	next *mockExecutor
+}
+
+func (e *lockedExecutor) Run(ctx context.Context, dir string, stdout io.Writer, stderr io.Writer, name string, args ...string) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.next.Run(ctx, dir, stdout, stderr, name, args...)
+}
+
+func TestRunPlatformsWithExecutor(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "package.json"), `{"dependencies": {"react-native": "0.72.0"}}`)
+	writeFile(t, filepath.Join(dir, "index.js"), "console.log('hello')")
+
+	mock := &mockExecutor{}
+	mock.onRun = func(_ string, _ string, args ...string) {
+		for i, arg := range args {
+			if arg == "--bundle-output" && i+1 < len(args) {
+				require.NoError(t, os.MkdirAll(filepath.Dir(args[i+1]), 0o755))
+				require.NoError(t, os.WriteFile(args[i+1], []byte("bundle"), 0o644))
+			}
+		}
+	}
+	logFile := filepath.Join(t.TempDir(), "codepush-bundle.log")
+	opts := &BundleOptions{ProjectDir: dir, OutputDir: filepath.Join(dir, "CodePush"), HermesMode: HermesModeOff, LogFile: logFile}
+
+	results, err := RunPlatformsWithExecutor(context.Background(), opts, []Platform{PlatformIOS, PlatformAndroid}, nil, "1.0.0", &lockedExecutor{next: mock}, output.NewTest(io.Discard))
+	require.NoError(t, err)
+
+	require.Len(t, results, 2)
+	assert.Equal(t, PlatformIOS, results[0].Platform)
+	assert.Equal(t, filepath.Join(dir, "CodePush", "ios", "CodePush"), results[0].OutputDir)
+	assert.Equal(t, PlatformAndroid, results[1].Platform)
+	assert.Equal(t, filepath.Join(dir, "CodePush", "android", "CodePush"), results[1].OutputDir)
+	assert.FileExists(t, results[1].BundlePath)
+
+	var installs, bundles int
+	for _, c := range mock.commands {
+		if len(c.args) > 0 && c.args[0] == "install" {
+			installs++
+		}
+		if c.name == "npx" {
+			bundles++
+		}
+	}
+	assert.Equal(t, 1, installs, "dependencies should be installed once")
+	assert.Equal(t, 2, bundles)
+
+	log, err := os.ReadFile(logFile)
+	require.NoError(t, err)
+	assert.Contains(t, string(log), "install (in ")
+	assert.Contains(t, string(log), "[ios] ==> ")
+	assert.Contains(t, string(log), "[android] ==> ")
+}
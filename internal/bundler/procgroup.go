@@ -0,0 +1,27 @@
+//go:build !windows
+
+package bundler
+
+import (
+	"os"
+	"os/exec"
+	"syscall"
+
+	"golang.org/x/sys/unix"
+)
+
+// setProcessGroup starts cmd in a process group of its own, so that the
+// workers it spawns can be stopped with it.
+func setProcessGroup(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+}
+
+// terminateProcessGroup asks the process group of p to exit.
+func terminateProcessGroup(p *os.Process) error {
+	return unix.Kill(-p.Pid, unix.SIGTERM)
+}
+
+// killProcessGroup kills the process group of p.
+func killProcessGroup(p *os.Process) error {
+	return unix.Kill(-p.Pid, unix.SIGKILL)
+}
@@ -0,0 +1,22 @@
+//go:build windows
+
+package bundler
+
+import (
+	"os"
+	"os/exec"
+)
+
+// setProcessGroup does nothing on Windows, where commands are stopped by
+// killing their process.
+func setProcessGroup(*exec.Cmd) {}
+
+// terminateProcessGroup kills p: Windows has no SIGTERM.
+func terminateProcessGroup(p *os.Process) error {
+	return p.Kill()
+}
+
+// killProcessGroup kills p.
+func killProcessGroup(p *os.Process) error {
+	return p.Kill()
+}
@@ -3,8 +3,11 @@
 package bundler
 
 import (
+	"context"
 	"io"
+	"os"
 	"os/exec"
+	"time"
 
 	"github.com/creack/pty"
 )
@@ -12,19 +15,41 @@ import (
 // runWithPTY starts name with a pseudo-terminal as its controlling terminal so
 // that TTY-aware tools (e.g. Metro bundler) emit their interactive progress
 // output. stdout and stderr of the subprocess are merged on the PTY master and
-// copied to w. EIO on the master read is treated as normal EOF.
-func runWithPTY(dir string, w io.Writer, name string, args ...string) error {
+// copied to w. EIO on the master read is treated as normal EOF. The command
+// gets the environment and limits of e.
+func runWithPTY(ctx context.Context, e *DefaultExecutor, dir string, w io.Writer, name string, args ...string) error {
 	cmd := exec.Command(name, args...)
 	cmd.Dir = dir
-
-	ptmx, err := pty.StartWithSize(cmd, &pty.Winsize{Rows: 50, Cols: 200})
-	if err != nil {
-		return err
+	if len(e.Env) > 0 {
+		cmd.Env = append(os.Environ(), e.Env...)
 	}
-	defer func() { _ = ptmx.Close() }()
 
-	// Copy PTY output; EIO is expected when the slave closes — treat as EOF.
-	_, _ = io.Copy(w, ptmx)
+	tail := &tailWriter{}
+	var ptmx *os.File
+	copied := make(chan struct{})
+	start := func() error {
+		// The PTY makes the command a session leader, so it gets a process
+		// group of its own without setProcessGroup.
+		var err error
+		ptmx, err = pty.StartWithSize(cmd, &pty.Winsize{Rows: 50, Cols: 200})
+		if err != nil {
+			return err
+		}
+		go func() {
+			// Copy PTY output; EIO is expected when the slave closes — treat as EOF.
+			_, _ = io.Copy(io.MultiWriter(w, tail), ptmx)
+			close(copied)
+		}()
+		return nil
+	}
 
-	return cmd.Wait()
+	err := e.run(ctx, cmd, start, tail)
+	if ptmx != nil {
+		select {
+		case <-copied:
+		case <-time.After(time.Second):
+		}
+		_ = ptmx.Close()
+	}
+	return err
 }
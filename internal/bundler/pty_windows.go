@@ -2,10 +2,12 @@
 
 package bundler
 
-import "io"
+import (
+	"context"
+	"io"
+)
 
 // runWithPTY falls back to the standard executor on Windows where PTY is not available.
-func runWithPTY(dir string, w io.Writer, name string, args ...string) error {
-	ex := &DefaultExecutor{}
-	return ex.Run(dir, io.Discard, w, name, args...)
+func runWithPTY(ctx context.Context, e *DefaultExecutor, dir string, w io.Writer, name string, args ...string) error {
+	return e.Run(ctx, dir, io.Discard, w, name, args...)
 }
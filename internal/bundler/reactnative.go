@@ -1,10 +1,12 @@
 package bundler
 
 import (
+	"context"
 	"fmt"
 	"io"
 	"os"
 	"path/filepath"
+	"strconv"
 
 	"github.com/bitrise-io/bitrise-plugins-codepush-cli/internal/output"
 )
@@ -24,7 +26,7 @@ type ReactNativeBundler struct {
 }
 
 // Bundle implements Bundler for React Native projects.
-func (b *ReactNativeBundler) Bundle(config *ProjectConfig, opts *BundleOptions) (*BundleResult, error) {
+func (b *ReactNativeBundler) Bundle(ctx context.Context, config *ProjectConfig, opts *BundleOptions) (*BundleResult, error) {
 	outputDir, err := filepath.Abs(opts.OutputDir)
 	if err != nil {
 		return nil, fmt.Errorf("resolving output directory: %w", err)
@@ -57,10 +59,11 @@ func (b *ReactNativeBundler) Bundle(config *ProjectConfig, opts *BundleOptions)
 
 	progress := b.out.NewProgress("Bundling " + string(opts.Platform))
 	mw := output.NewMetroProgressWriter(progress)
-	if err := b.runBundle(config.ProjectDir, mw, "npx", args...); err != nil {
+	name, args := config.PackageManager.Exec(args[0], args[1:]...)
+	if err := b.runBundle(ctx, config.ProjectDir, mw, name, args...); err != nil {
 		mw.Flush()
 		progress.Cancel()
-		b.out.Info("%s", mw.Buffered())
+		b.out.Println("%s", mw.Buffered())
 		return nil, fmt.Errorf("react-native bundle failed: %w", err)
 	}
 	mw.Flush()
@@ -99,8 +102,13 @@ func (b *ReactNativeBundler) buildArgs(config *ProjectConfig, opts *BundleOption
 		devStr = "true"
 	}
 
+	command := "bundle"
+	if opts.RamBundle {
+		command = "ram-bundle"
+	}
+
 	args := []string{
-		"react-native", "bundle",
+		"react-native", command,
 		"--entry-file", entryFile,
 		"--platform", string(opts.Platform),
 		"--dev", devStr,
@@ -112,6 +120,8 @@ func (b *ReactNativeBundler) buildArgs(config *ProjectConfig, opts *BundleOption
 		args = append(args, "--sourcemap-output", paths.sourcemapPath)
 	}
 
+	args = append(args, tuningArgs(opts)...)
+
 	if opts.ResetCache {
 		args = append(args, "--reset-cache")
 	}
@@ -129,11 +139,21 @@ func (b *ReactNativeBundler) buildArgs(config *ProjectConfig, opts *BundleOption
 	return args
 }
 
-func (b *ReactNativeBundler) runBundle(dir string, w io.Writer, name string, args ...string) error {
-	if b.out.IsInteractive() {
-		return runWithPTY(dir, w, name, args...)
+func (b *ReactNativeBundler) runBundle(ctx context.Context, dir string, w io.Writer, name string, args ...string) error {
+	return runBundleCommand(ctx, b.executor, b.out, dir, w, name, args...)
+}
+
+// tuningArgs returns the --minify and --max-workers flags shared by Metro
+// and Re.Pack, for the options that were given.
+func tuningArgs(opts *BundleOptions) []string {
+	var args []string
+	if opts.Minify != nil {
+		args = append(args, "--minify", strconv.FormatBool(*opts.Minify))
+	}
+	if opts.MaxWorkers > 0 {
+		args = append(args, "--max-workers", strconv.Itoa(opts.MaxWorkers))
 	}
-	return b.executor.Run(dir, io.Discard, w, name, args...)
+	return args
 }
 
 // resolveSourcemapPath returns the absolute sourcemap path based on bundle options.
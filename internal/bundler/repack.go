@@ -0,0 +1,129 @@
+package bundler
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/bitrise-io/bitrise-plugins-codepush-cli/internal/output"
+)
+
+// RePackBundler bundles using "npx react-native webpack-bundle" (Re.Pack).
+type RePackBundler struct {
+	executor CommandExecutor
+	out      *output.Writer
+}
+
+// Bundle implements Bundler for React Native projects that use Re.Pack.
+func (b *RePackBundler) Bundle(ctx context.Context, config *ProjectConfig, opts *BundleOptions) (*BundleResult, error) {
+	outputDir, err := filepath.Abs(opts.OutputDir)
+	if err != nil {
+		return nil, fmt.Errorf("resolving output directory: %w", err)
+	}
+
+	assetsDir := filepath.Join(outputDir, "assets")
+	if err := ensureDir(assetsDir); err != nil {
+		return nil, err
+	}
+
+	bundleName := opts.BundleName
+	if bundleName == "" {
+		bundleName = DefaultBundleName(opts.Platform)
+	}
+
+	bundlePath := filepath.Join(outputDir, bundleName)
+
+	sourcemapPath, err := resolveSourcemapPath(opts, bundlePath)
+	if err != nil {
+		return nil, err
+	}
+
+	paths := bundlePaths{
+		outputDir:     outputDir,
+		bundlePath:    bundlePath,
+		assetsDir:     assetsDir,
+		sourcemapPath: sourcemapPath,
+	}
+	args := b.buildArgs(config, opts, paths)
+
+	progress := b.out.NewProgress("Bundling " + string(opts.Platform) + " (Re.Pack)")
+	mw := output.NewMetroProgressWriter(progress)
+	name, args := config.PackageManager.Exec(args[0], args[1:]...)
+	if err := b.runBundle(ctx, config.ProjectDir, mw, name, args...); err != nil {
+		mw.Flush()
+		progress.Cancel()
+		b.out.Println("%s", mw.Buffered())
+		return nil, fmt.Errorf("react-native webpack-bundle failed: %w", err)
+	}
+	mw.Flush()
+	progress.Done("")
+
+	if _, err := os.Stat(bundlePath); err != nil {
+		return nil, fmt.Errorf("bundle file was not created at %s: check the output settings of your webpack config", bundlePath)
+	}
+
+	result := &BundleResult{
+		BundlePath:  bundlePath,
+		AssetsDir:   assetsDir,
+		OutputDir:   outputDir,
+		ProjectType: ProjectTypeRePack,
+		Platform:    opts.Platform,
+	}
+
+	if sourcemapPath != "" {
+		if _, err := os.Stat(sourcemapPath); err == nil {
+			result.SourcemapPath = sourcemapPath
+		}
+	}
+
+	return result, nil
+}
+
+// buildArgs constructs the argument list for "npx react-native webpack-bundle".
+// Re.Pack takes the same bundle flags as Metro, except that the config is
+// passed with --webpackConfig and there is no --reset-cache.
+func (b *RePackBundler) buildArgs(config *ProjectConfig, opts *BundleOptions, paths bundlePaths) []string {
+	entryFile := opts.EntryFile
+	if entryFile == "" {
+		entryFile = config.EntryFile
+	}
+
+	devStr := "false"
+	if opts.Dev {
+		devStr = "true"
+	}
+
+	args := []string{
+		"react-native", "webpack-bundle",
+		"--entry-file", entryFile,
+		"--platform", string(opts.Platform),
+		"--dev", devStr,
+		"--bundle-output", paths.bundlePath,
+		"--assets-dest", paths.assetsDir,
+	}
+
+	if paths.sourcemapPath != "" {
+		args = append(args, "--sourcemap-output", paths.sourcemapPath)
+	}
+
+	args = append(args, tuningArgs(opts)...)
+
+	// --config is shared with Metro projects and names the webpack config here
+	webpackConfig := opts.MetroConfig
+	if webpackConfig == "" {
+		webpackConfig = config.WebpackConfig
+	}
+	if webpackConfig != "" {
+		args = append(args, "--webpackConfig", webpackConfig)
+	}
+
+	args = append(args, opts.ExtraBundlerOpts...)
+
+	return args
+}
+
+func (b *RePackBundler) runBundle(ctx context.Context, dir string, w io.Writer, name string, args ...string) error {
+	return runBundleCommand(ctx, b.executor, b.out, dir, w, name, args...)
+}
@@ -1,6 +1,7 @@
 package bundler
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"os"
@@ -14,20 +15,38 @@ import (
 // 2. Execute the appropriate bundler
 // 3. Compile with Hermes if applicable
 // 4. Export to Bitrise deploy directory if in Bitrise environment
-func Run(opts *BundleOptions, out *output.Writer) (*BundleResult, error) {
-	return RunWithExecutor(opts, &DefaultExecutor{}, out)
+func Run(ctx context.Context, opts *BundleOptions, out *output.Writer) (*BundleResult, error) {
+	return RunWithExecutor(ctx, opts, &DefaultExecutor{Timeout: opts.CommandTimeout}, out)
 }
 
 // RunWithExecutor executes the full bundle pipeline with the given executor.
-// This allows tests to provide a mock executor.
-func RunWithExecutor(opts *BundleOptions, executor CommandExecutor, out *output.Writer) (*BundleResult, error) {
+// This allows tests to provide a mock executor. With debug logging enabled,
+// every command it runs is logged.
+func RunWithExecutor(ctx context.Context, opts *BundleOptions, executor CommandExecutor, out *output.Writer) (*BundleResult, error) {
+	// RunPlatforms logs the commands of its builds itself.
+	if _, logged := executor.(*logExecutor); !logged {
+		log, err := openCommandLog(opts.LogFile)
+		if err != nil {
+			return nil, err
+		}
+		defer func() { _ = log.Close() }()
+		executor = logCommands(executor, log, "", out)
+	}
+	if out.DebugEnabled() {
+		executor = &debugExecutor{next: executor, out: out}
+	}
 	hermesMode, err := resolveRunOptions(opts)
 	if err != nil {
 		return nil, err
 	}
 
+	executor, err = checkNodeVersion(ctx, opts, executor, out)
+	if err != nil {
+		return nil, err
+	}
+
 	if !opts.SkipInstall {
-		if err := installDependencies(opts.ProjectDir, executor, out); err != nil {
+		if err := installDependencies(ctx, opts.ProjectDir, packageManagerFor(opts.ProjectDir, opts), executor, out); err != nil {
 			return nil, err
 		}
 	}
@@ -44,19 +63,39 @@ func RunWithExecutor(opts *BundleOptions, executor CommandExecutor, out *output.
 		config.MetroConfig = opts.MetroConfig
 	}
 
+	if config.ProjectType == ProjectTypeExpo {
+		expo, err := LoadExpoConfig(ctx, config.ProjectDir, config.PackageManager, executor)
+		if err != nil {
+			out.Warning("could not resolve Expo config, using detected defaults: %v", err)
+		} else if expo != nil {
+			applyExpoConfig(config, expo, hermesMode, opts.EntryFile)
+		}
+	}
+
+	if err := checkProjectOptions(config, opts, out); err != nil {
+		return nil, err
+	}
+
 	bundler, err := NewBundler(config.ProjectType, executor, out)
 	if err != nil {
 		return nil, err
 	}
 
-	result, err := bundler.Bundle(config, opts)
+	result, err := bundler.Bundle(ctx, config, opts)
 	if err != nil {
 		return nil, err
 	}
 
-	if err := compileWithHermes(config, result, opts.ExtraHermesFlags, executor, out); err != nil {
+	if err := compileWithHermes(ctx, config, result, opts, executor, out); err != nil {
 		return nil, err
 	}
+	result.RuntimeVersion = config.RuntimeVersion
+
+	if opts.OptimizeAssets {
+		if err := optimizeAssets(ctx, result, opts.AssetQuality, executor, out); err != nil {
+			return nil, err
+		}
+	}
 
 	return result, nil
 }
@@ -85,6 +124,25 @@ func resolveRunOptions(opts *BundleOptions) (HermesMode, error) {
 		opts.Sourcemap = true
 	}
 
+	if opts.AssetQuality < 0 || opts.AssetQuality > 100 {
+		return "", fmt.Errorf("quality must be between 1 and 100, got %d", opts.AssetQuality)
+	}
+	if opts.AssetQuality > 0 && !opts.OptimizeAssets {
+		return "", errors.New("--quality requires --optimize-assets")
+	}
+	if opts.MaxWorkers < 0 {
+		return "", fmt.Errorf("--max-workers must be positive, got %d", opts.MaxWorkers)
+	}
+	if err := ValidatePackageManager(opts.PackageManager); err != nil {
+		return "", err
+	}
+	if opts.CommandTimeout < 0 {
+		return "", fmt.Errorf("--bundler-timeout must not be negative, got %s", opts.CommandTimeout)
+	}
+	if opts.RamBundle && opts.HermesMode == HermesModeOn {
+		return "", errors.New("--ram-bundle cannot be used with --hermes on: Hermes bytecode replaces RAM bundles")
+	}
+
 	hermesMode := opts.HermesMode
 	if hermesMode == "" {
 		hermesMode = HermesModeAuto
@@ -92,16 +150,51 @@ func resolveRunOptions(opts *BundleOptions) (HermesMode, error) {
 	return hermesMode, nil
 }
 
-func compileWithHermes(config *ProjectConfig, result *BundleResult, extraFlags []string, executor CommandExecutor, out *output.Writer) error {
-	if !config.HermesEnabled || config.ProjectType != ProjectTypeReactNative {
+// checkProjectOptions rejects the options the detected project cannot build
+// with, and warns about those it ignores.
+func checkProjectOptions(config *ProjectConfig, opts *BundleOptions, out *output.Writer) error {
+	if opts.RamBundle {
+		if config.ProjectType != ProjectTypeReactNative {
+			return fmt.Errorf("--ram-bundle is only supported for React Native projects, not %s", config.ProjectType)
+		}
+		if config.HermesEnabled {
+			return errors.New("--ram-bundle cannot be used with Hermes, which is enabled for this project: pass --hermes off")
+		}
+	}
+	if len(opts.ExtraHermesFlags) > 0 && config.ProjectType == ProjectTypeExpo {
+		out.Warning("Hermes flags are ignored for Expo projects: expo export:embed runs Hermes itself")
+	}
+	return nil
+}
+
+func optimizeAssets(ctx context.Context, result *BundleResult, quality int, executor CommandExecutor, out *output.Writer) error {
+	step := out.StartStep("Optimizing assets")
+	report, err := OptimizeAssets(ctx, result.AssetsDir, result.Platform, quality, executor, out)
+	if err != nil {
+		step.Cancel()
+		return err
+	}
+	step.Done()
+
+	result.Assets = report
+	out.Info("Assets: %d recompressed, %d unused density variants removed, %s saved",
+		report.Recompressed, report.Stripped, output.HumanBytes(report.Saved()))
+	if len(report.Skipped) > 0 {
+		out.Warning("%s", skippedFormatsMessage(report.Skipped))
+	}
+	return nil
+}
+
+func compileWithHermes(ctx context.Context, config *ProjectConfig, result *BundleResult, opts *BundleOptions, executor CommandExecutor, out *output.Writer) error {
+	if !config.HermesEnabled || config.ProjectType == ProjectTypeExpo {
 		return nil
 	}
 	if config.HermescPath == "" {
 		return errors.New("hermes is enabled but hermesc was not found in node_modules: run 'npm install' or use --hermes=off")
 	}
 
-	compiler := NewHermesCompiler(executor, out)
-	if err := compiler.Compile(config.HermescPath, result.BundlePath, result.SourcemapPath, extraFlags); err != nil {
+	compiler := NewHermesCompiler(executor, opts.Workspace, out)
+	if err := compiler.Compile(ctx, config.HermescPath, result.BundlePath, result.SourcemapPath, opts.ExtraHermesFlags); err != nil {
 		return err
 	}
 	result.HermesApplied = true
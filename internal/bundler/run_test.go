@@ -1,10 +1,12 @@
 package bundler
 
 import (
+	"context"
 	"io"
 	"os"
 	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -40,7 +42,7 @@ func TestRunWithExecutor(t *testing.T) {
 			HermesMode: HermesModeOff,
 		}
 
-		result, err := RunWithExecutor(opts, executor, output.NewTest(io.Discard))
+		result, err := RunWithExecutor(context.Background(), opts, executor, output.NewTest(io.Discard))
 		require.NoError(t, err)
 
 		assert.Equal(t, ProjectTypeReactNative, result.ProjectType)
@@ -73,7 +75,7 @@ func TestRunWithExecutor(t *testing.T) {
 			HermesMode: HermesModeOff,
 		}
 
-		result, err := RunWithExecutor(opts, executor, output.NewTest(io.Discard))
+		result, err := RunWithExecutor(context.Background(), opts, executor, output.NewTest(io.Discard))
 		require.NoError(t, err)
 
 		assert.Equal(t, ProjectTypeExpo, result.ProjectType)
@@ -104,7 +106,7 @@ func TestRunWithExecutor(t *testing.T) {
 			HermesMode: HermesModeOn,
 		}
 
-		_, err := RunWithExecutor(opts, executor, output.NewTest(io.Discard))
+		_, err := RunWithExecutor(context.Background(), opts, executor, output.NewTest(io.Discard))
 		require.Error(t, err)
 	})
 
@@ -117,7 +119,7 @@ func TestRunWithExecutor(t *testing.T) {
 			HermesMode: HermesModeOff,
 		}
 
-		_, err := RunWithExecutor(opts, executor, output.NewTest(io.Discard))
+		_, err := RunWithExecutor(context.Background(), opts, executor, output.NewTest(io.Discard))
 		require.Error(t, err)
 	})
 
@@ -151,7 +153,7 @@ func TestRunWithExecutor(t *testing.T) {
 			HermesMode:  HermesModeOff,
 		}
 
-		_, err := RunWithExecutor(opts, executor, output.NewTest(io.Discard))
+		_, err := RunWithExecutor(context.Background(), opts, executor, output.NewTest(io.Discard))
 		require.NoError(t, err)
 
 		// Verify the overridden entry file was used
@@ -171,6 +173,7 @@ func TestRunWithExecutor(t *testing.T) {
 
 	t.Run("defaults output dir when empty", func(t *testing.T) {
 		dir := t.TempDir()
+		t.Chdir(t.TempDir()) // the default output dir is relative to the working directory
 
 		writeFile(t, filepath.Join(dir, "package.json"), `{"dependencies": {"react-native": "0.72.0"}}`)
 		writeFile(t, filepath.Join(dir, "index.js"), "")
@@ -193,7 +196,7 @@ func TestRunWithExecutor(t *testing.T) {
 			HermesMode: HermesModeOff,
 		}
 
-		result, err := RunWithExecutor(opts, executor, output.NewTest(io.Discard))
+		result, err := RunWithExecutor(context.Background(), opts, executor, output.NewTest(io.Discard))
 		require.NoError(t, err)
 
 		assert.NotEmpty(t, result.OutputDir)
@@ -224,7 +227,7 @@ func TestRunWithExecutor(t *testing.T) {
 			HermesMode: "",
 		}
 
-		_, err := RunWithExecutor(opts, executor, output.NewTest(io.Discard))
+		_, err := RunWithExecutor(context.Background(), opts, executor, output.NewTest(io.Discard))
 		require.NoError(t, err)
 	})
 
@@ -258,7 +261,7 @@ func TestRunWithExecutor(t *testing.T) {
 			HermesMode: HermesModeOff,
 		}
 
-		_, err := RunWithExecutor(opts, executor, output.NewTest(io.Discard))
+		_, err := RunWithExecutor(context.Background(), opts, executor, output.NewTest(io.Discard))
 		require.NoError(t, err)
 
 		// RunWithExecutor no longer exports to Bitrise deploy dir; the CLI layer handles that
@@ -266,6 +269,63 @@ func TestRunWithExecutor(t *testing.T) {
 		_, err = os.Stat(summaryPath)
 		assert.Error(t, err, "RunWithExecutor should not export summary; that responsibility moved to CLI layer")
 	})
+
+	t.Run("rejects a RAM bundle the project cannot build", func(t *testing.T) {
+		dir := t.TempDir()
+		writeFile(t, filepath.Join(dir, "package.json"), `{"dependencies": {"expo": "~49.0.0", "react-native": "0.72.0"}}`)
+		writeFile(t, filepath.Join(dir, "index.js"), "")
+
+		opts := &BundleOptions{Platform: PlatformIOS, ProjectDir: dir, HermesMode: HermesModeOff, RamBundle: true, SkipInstall: true}
+		_, err := RunWithExecutor(context.Background(), opts, &mockExecutor{}, output.NewTest(io.Discard))
+		assert.ErrorContains(t, err, "--ram-bundle is only supported for React Native projects")
+
+		opts = &BundleOptions{Platform: PlatformIOS, ProjectDir: dir, HermesMode: HermesModeOn, RamBundle: true}
+		_, err = RunWithExecutor(context.Background(), opts, &mockExecutor{}, output.NewTest(io.Discard))
+		assert.ErrorContains(t, err, "cannot be used with --hermes on")
+	})
+
+	t.Run("runs the bundler with the package manager", func(t *testing.T) {
+		dir := t.TempDir()
+		writeFile(t, filepath.Join(dir, "package.json"), `{"dependencies": {"react-native": "0.72.0"}}`)
+		writeFile(t, filepath.Join(dir, "yarn.lock"), "")
+		writeFile(t, filepath.Join(dir, "index.js"), "")
+
+		executor := &mockExecutor{}
+		executor.onRun = func(_ string, _ string, args ...string) {
+			for i, arg := range args {
+				if arg == "--bundle-output" && i+1 < len(args) {
+					require.NoError(t, os.MkdirAll(filepath.Dir(args[i+1]), 0o755))
+					require.NoError(t, os.WriteFile(args[i+1], []byte("bundle"), 0o644))
+				}
+			}
+		}
+
+		opts := &BundleOptions{Platform: PlatformIOS, ProjectDir: dir, OutputDir: filepath.Join(dir, "CodePush"), HermesMode: HermesModeOff}
+		_, err := RunWithExecutor(context.Background(), opts, executor, output.NewTest(io.Discard))
+		require.NoError(t, err)
+		require.Len(t, executor.commands, 2)
+		assert.Equal(t, "yarn", executor.commands[0].name, "detected from yarn.lock")
+		assert.Equal(t, "yarn", executor.commands[1].name)
+		assert.Equal(t, []string{"react-native", "bundle"}, executor.commands[1].args[:2])
+
+		executor.commands = nil
+		opts.PackageManager = PackageManagerPNPM
+		_, err = RunWithExecutor(context.Background(), opts, executor, output.NewTest(io.Discard))
+		require.NoError(t, err)
+		assert.Equal(t, "pnpm", executor.commands[0].name)
+		assert.Equal(t, []string{"install"}, executor.commands[0].args)
+		assert.Equal(t, []string{"exec", "react-native", "bundle"}, executor.commands[1].args[:3])
+
+		opts.PackageManager = "deno"
+		_, err = RunWithExecutor(context.Background(), opts, executor, output.NewTest(io.Discard))
+		assert.ErrorContains(t, err, "--package-manager must be")
+	})
+
+	t.Run("rejects a negative bundler timeout", func(t *testing.T) {
+		opts := &BundleOptions{Platform: PlatformIOS, ProjectDir: t.TempDir(), CommandTimeout: -time.Second}
+		_, err := RunWithExecutor(context.Background(), opts, &mockExecutor{}, output.NewTest(io.Discard))
+		assert.ErrorContains(t, err, "--bundler-timeout must not be negative")
+	})
 }
 
 func TestCompileWithHermes(t *testing.T) {
@@ -274,7 +334,7 @@ func TestCompileWithHermes(t *testing.T) {
 		config := &ProjectConfig{HermesEnabled: false, ProjectType: ProjectTypeReactNative}
 		result := &BundleResult{}
 
-		err := compileWithHermes(config, result, nil, executor, output.NewTest(io.Discard))
+		err := compileWithHermes(context.Background(), config, result, &BundleOptions{}, executor, output.NewTest(io.Discard))
 		require.NoError(t, err)
 		assert.False(t, result.HermesApplied)
 		assert.Empty(t, executor.commands)
@@ -285,12 +345,19 @@ func TestCompileWithHermes(t *testing.T) {
 		config := &ProjectConfig{HermesEnabled: true, ProjectType: ProjectTypeExpo}
 		result := &BundleResult{}
 
-		err := compileWithHermes(config, result, nil, executor, output.NewTest(io.Discard))
+		err := compileWithHermes(context.Background(), config, result, &BundleOptions{}, executor, output.NewTest(io.Discard))
 		require.NoError(t, err)
 		assert.False(t, result.HermesApplied)
 		assert.Empty(t, executor.commands)
 	})
 
+	t.Run("runs for Re.Pack projects", func(t *testing.T) {
+		config := &ProjectConfig{HermesEnabled: true, ProjectType: ProjectTypeRePack}
+
+		err := compileWithHermes(context.Background(), config, &BundleResult{}, &BundleOptions{}, &mockExecutor{}, output.NewTest(io.Discard))
+		assert.ErrorContains(t, err, "hermesc was not found")
+	})
+
 	t.Run("returns error when hermesc path is empty", func(t *testing.T) {
 		executor := &mockExecutor{}
 		config := &ProjectConfig{
@@ -300,7 +367,7 @@ func TestCompileWithHermes(t *testing.T) {
 		}
 		result := &BundleResult{}
 
-		err := compileWithHermes(config, result, nil, executor, output.NewTest(io.Discard))
+		err := compileWithHermes(context.Background(), config, result, &BundleOptions{}, executor, output.NewTest(io.Discard))
 		require.Error(t, err)
 		assert.ErrorContains(t, err, "hermesc was not found")
 	})
@@ -328,7 +395,7 @@ func TestCompileWithHermes(t *testing.T) {
 		}
 		result := &BundleResult{BundlePath: bundlePath}
 
-		err := compileWithHermes(config, result, nil, executor, output.NewTest(io.Discard))
+		err := compileWithHermes(context.Background(), config, result, &BundleOptions{}, executor, output.NewTest(io.Discard))
 		require.NoError(t, err)
 		assert.True(t, result.HermesApplied)
 		assert.Len(t, executor.commands, 1)
@@ -354,7 +421,7 @@ func TestCompileWithHermes(t *testing.T) {
 		}
 		result := &BundleResult{BundlePath: bundlePath}
 
-		err := compileWithHermes(config, result, nil, executor, output.NewTest(io.Discard))
+		err := compileWithHermes(context.Background(), config, result, &BundleOptions{}, executor, output.NewTest(io.Discard))
 		require.Error(t, err)
 		assert.False(t, result.HermesApplied)
 	})
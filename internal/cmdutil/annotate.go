@@ -0,0 +1,78 @@
+package cmdutil
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/bitrise-io/bitrise-plugins-codepush-cli/internal/bitrise"
+	"github.com/bitrise-io/bitrise-plugins-codepush-cli/internal/github"
+	"github.com/bitrise-io/bitrise-plugins-codepush-cli/internal/output"
+)
+
+// ReleaseAnnotation summarizes a release a command created or changed, for
+// the build page on Bitrise.
+type ReleaseAnnotation struct {
+	// Title says what the command did, e.g. "Pushed an update to Staging".
+	Title        string
+	AppID        string
+	DeploymentID string
+	UpdateID     string
+	Label        string
+	AppVersion   string
+	// Rollout is the rollout percentage, nil when the command does not
+	// report it.
+	Rollout *int
+	// SizeBytes is the size of the uploaded package, 0 when nothing was
+	// uploaded.
+	SizeBytes int64
+}
+
+// Markdown renders the annotation: the title, the release's details, and a
+// link to the release in Release Management.
+func (a ReleaseAnnotation) Markdown() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "**CodePush: %s**\n\n", a.Title)
+	if a.Label != "" {
+		fmt.Fprintf(&b, "- Label: `%s`\n", a.Label)
+	}
+	if a.AppVersion != "" {
+		fmt.Fprintf(&b, "- App version: `%s`\n", a.AppVersion)
+	}
+	if a.Rollout != nil {
+		fmt.Fprintf(&b, "- Rollout: %d%%\n", *a.Rollout)
+	}
+	if a.SizeBytes > 0 {
+		fmt.Fprintf(&b, "- Size: %s\n", FormatBytes(a.SizeBytes))
+	}
+	if a.UpdateID != "" {
+		fmt.Fprintf(&b, "- Release ID: `%s`\n", a.UpdateID)
+	}
+	if a.AppID != "" {
+		fmt.Fprintf(&b, "\n[Open in Release Management](%s)\n", bitrise.ReleaseManagementURL(a.AppID, a.DeploymentID, a.UpdateID))
+	}
+	return b.String()
+}
+
+// AnnotateRelease adds a to the build page on Bitrise, replacing an earlier
+// annotation of the same deployment in the build, unless
+// --no-bitrise-export is set. On GitHub Actions it adds a to the job
+// summary. It does nothing elsewhere. Failures are reported as warnings.
+func AnnotateRelease(a ReleaseAnnotation, out *output.Writer) {
+	switch CI() {
+	case CIBitrise:
+		if !bitriseExport {
+			return
+		}
+		context := "codepush"
+		if a.DeploymentID != "" {
+			context += "-" + a.DeploymentID
+		}
+		if err := bitrise.Annotate(a.Markdown(), bitrise.AnnotationSuccess, context); err != nil {
+			out.Warning("failed to add build annotation: %v", err)
+		}
+	case CIGitHub:
+		if err := github.AppendStepSummary(a.Markdown()); err != nil {
+			out.Warning("failed to add job summary: %v", err)
+		}
+	}
+}
@@ -0,0 +1,67 @@
+package cmdutil
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/bitrise-io/bitrise-plugins-codepush-cli/internal/output"
+)
+
+func TestReleaseAnnotationMarkdown(t *testing.T) {
+	rollout := 20
+	tests := []struct {
+		name       string
+		annotation ReleaseAnnotation
+		want       string
+	}{
+		{
+			name: "push",
+			annotation: ReleaseAnnotation{
+				Title:        "Pushed an update to Staging",
+				AppID:        "app-1",
+				DeploymentID: "dep-1",
+				UpdateID:     "pkg-1",
+				AppVersion:   "1.2.0",
+				Rollout:      &rollout,
+				SizeBytes:    2048,
+			},
+			want: "**CodePush: Pushed an update to Staging**\n\n" +
+				"- App version: `1.2.0`\n" +
+				"- Rollout: 20%\n" +
+				"- Size: 2.0 KB\n" +
+				"- Release ID: `pkg-1`\n" +
+				"\n[Open in Release Management](https://app.bitrise.io/release-management/apps/app-1/codepush/deployments/dep-1/releases/pkg-1)\n",
+		},
+		{
+			name:       "title and label only",
+			annotation: ReleaseAnnotation{Title: "Promoted v3 to Production", Label: "v3"},
+			want:       "**CodePush: Promoted v3 to Production**\n\n- Label: `v3`\n",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.want, tc.annotation.Markdown())
+		})
+	}
+}
+
+func TestAnnotateReleaseGitHub(t *testing.T) {
+	t.Setenv("BITRISE_BUILD_NUMBER", "")
+	t.Setenv("BITRISE_DEPLOY_DIR", "")
+	t.Setenv("GITHUB_ACTIONS", "true")
+	path := filepath.Join(t.TempDir(), "summary")
+	t.Setenv("GITHUB_STEP_SUMMARY", path)
+
+	a := ReleaseAnnotation{Title: "Promoted v3 to Production", Label: "v3"}
+	AnnotateRelease(a, output.NewTest(&bytes.Buffer{}))
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, a.Markdown()+"\n", string(data))
+}
@@ -0,0 +1,70 @@
+package cmdutil
+
+import (
+	"context"
+	"os"
+	"time"
+
+	"github.com/bitrise-io/bitrise-plugins-codepush-cli/internal/appcatalog"
+	"github.com/bitrise-io/bitrise-plugins-codepush-cli/internal/codepush"
+	"github.com/bitrise-io/bitrise-plugins-codepush-cli/internal/output"
+)
+
+// WorkspaceEnvKey is the environment variable naming the Bitrise workspace
+// whose apps are listed and offered for selection.
+const WorkspaceEnvKey = "CODEPUSH_WORKSPACE"
+
+// appsCacheTTLEnvKey overrides appcatalog.DefaultTTL; "0" disables the
+// cache.
+const appsCacheTTLEnvKey = "CODEPUSH_APPS_CACHE_TTL"
+
+// AppCatalog lists the apps of a workspace through the local app catalog
+// cache. It implements AppLister.
+type AppCatalog struct {
+	Client *codepush.HTTPClient
+	// Workspace is the workspace slug; empty lists the apps of all
+	// workspaces.
+	Workspace string
+	// Refresh fetches the list even when a fresh one is cached.
+	Refresh bool
+
+	out *output.Writer
+}
+
+// NewAppCatalog returns an AppCatalog for the apps of workspace.
+func NewAppCatalog(client *codepush.HTTPClient, workspace string, out *output.Writer) *AppCatalog {
+	return &AppCatalog{Client: client, Workspace: workspace, out: out}
+}
+
+// ListApps returns the apps of the workspace, from the cache when it is
+// fresh. Failing to use the cache is only a warning.
+func (a *AppCatalog) ListApps(ctx context.Context) ([]codepush.App, error) {
+	ttl := appcatalog.DefaultTTL
+	if v := os.Getenv(appsCacheTTLEnvKey); v != "" {
+		d, err := time.ParseDuration(v)
+		switch {
+		case v == "0":
+			ttl = 0
+		case err != nil || d < 0:
+			a.out.Warning("ignoring %s=%q: expected a duration such as 30m", appsCacheTTLEnvKey, v)
+		default:
+			ttl = d
+		}
+	}
+
+	dir, err := appcatalog.DefaultDir()
+	if err != nil {
+		a.out.Warning("not caching apps: %v", err)
+		ttl = 0
+	}
+
+	apps, cached, err := appcatalog.New(dir, ttl).Apps(ctx, a.Client, a.Client.BaseURL+"\x00"+a.Client.Token, a.Workspace, a.Refresh)
+	if err != nil && apps == nil {
+		return nil, err
+	}
+	if err != nil {
+		a.out.Warning("%v", err)
+	}
+	a.out.Debug("listed apps", "workspace", a.Workspace, "count", len(apps), "cached", cached)
+	return apps, nil
+}
@@ -0,0 +1,35 @@
+package cmdutil
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"runtime"
+)
+
+// OpenBrowser opens url in the user's default browser. It fails on machines
+// without a graphical session, such as SSH sessions and CI runners.
+func OpenBrowser(url string) error {
+	var name string
+	var args []string
+	switch runtime.GOOS {
+	case "darwin":
+		name = "open"
+	case "windows":
+		name, args = "rundll32", []string{"url.dll,FileProtocolHandler"}
+	default:
+		if os.Getenv("DISPLAY") == "" && os.Getenv("WAYLAND_DISPLAY") == "" {
+			return errors.New("no graphical session")
+		}
+		name = "xdg-open"
+	}
+	if os.Getenv("CI") != "" {
+		return errors.New("running in CI")
+	}
+
+	path, err := exec.LookPath(name)
+	if err != nil {
+		return err
+	}
+	return exec.Command(path, append(args, url)...).Start()
+}
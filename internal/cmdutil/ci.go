@@ -0,0 +1,59 @@
+package cmdutil
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/bitrise-io/bitrise-plugins-codepush-cli/internal/bitrise"
+	"github.com/bitrise-io/bitrise-plugins-codepush-cli/internal/github"
+)
+
+// CI services the CLI integrates with, set by --ci.
+const (
+	CIAuto    = "auto"
+	CIBitrise = "bitrise"
+	CIGitHub  = "github"
+	CINone    = "none"
+)
+
+// ciService is the --ci flag.
+var ciService = CIAuto
+
+// SetCI selects the CI service to export results to: CIBitrise, CIGitHub,
+// CINone, or CIAuto to detect it from the environment.
+func SetCI(service string) error {
+	switch service {
+	case "", CIAuto:
+		ciService = CIAuto
+	case CIBitrise, CIGitHub, CINone:
+		ciService = service
+	default:
+		return fmt.Errorf("invalid --ci %q: must be %s", service, strings.Join([]string{CIAuto, CIBitrise, CIGitHub, CINone}, ", "))
+	}
+	return nil
+}
+
+// CI returns the CI service the CLI runs in: the one set with SetCI, or the
+// one detected from the environment, or CINone.
+func CI() string {
+	if ciService != CIAuto {
+		return ciService
+	}
+	switch {
+	case bitrise.IsBitriseEnvironment():
+		return CIBitrise
+	case github.IsGitHubActions():
+		return CIGitHub
+	}
+	return CINone
+}
+
+// AnnotateError reports err as an error annotation of the GitHub Actions run
+// by writing a workflow command to w. It does nothing in other CI services.
+func AnnotateError(err error, w io.Writer) {
+	if CI() != CIGitHub {
+		return
+	}
+	fmt.Fprintln(w, github.ErrorCommand("CodePush", err.Error()))
+}
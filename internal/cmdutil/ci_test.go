@@ -0,0 +1,67 @@
+package cmdutil
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCI(t *testing.T) {
+	tests := []struct {
+		name    string
+		flag    string
+		envVars map[string]string
+		want    string
+		wantErr bool
+	}{
+		{name: "nothing detected", want: CINone},
+		{name: "Bitrise detected", envVars: map[string]string{"BITRISE_BUILD_NUMBER": "42"}, want: CIBitrise},
+		{name: "GitHub Actions detected", envVars: map[string]string{"GITHUB_ACTIONS": "true"}, want: CIGitHub},
+		{name: "explicit auto", flag: CIAuto, envVars: map[string]string{"GITHUB_ACTIONS": "true"}, want: CIGitHub},
+		{name: "forced github", flag: CIGitHub, envVars: map[string]string{"BITRISE_BUILD_NUMBER": "42"}, want: CIGitHub},
+		{name: "forced none", flag: CINone, envVars: map[string]string{"GITHUB_ACTIONS": "true"}, want: CINone},
+		{name: "unknown service", flag: "jenkins", wantErr: true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Setenv("BITRISE_BUILD_NUMBER", "")
+			t.Setenv("BITRISE_DEPLOY_DIR", "")
+			t.Setenv("GITHUB_ACTIONS", "")
+			for k, v := range tc.envVars {
+				t.Setenv(k, v)
+			}
+			t.Cleanup(func() { ciService = CIAuto })
+
+			err := SetCI(tc.flag)
+			if tc.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.want, CI())
+		})
+	}
+}
+
+func TestAnnotateError(t *testing.T) {
+	t.Setenv("BITRISE_BUILD_NUMBER", "")
+	t.Setenv("BITRISE_DEPLOY_DIR", "")
+
+	t.Run("writes a workflow command on GitHub Actions", func(t *testing.T) {
+		t.Setenv("GITHUB_ACTIONS", "true")
+		var buf bytes.Buffer
+		AnnotateError(errors.New("deployment not found"), &buf)
+		assert.Equal(t, "::error title=CodePush::deployment not found\n", buf.String())
+	})
+
+	t.Run("does nothing elsewhere", func(t *testing.T) {
+		t.Setenv("GITHUB_ACTIONS", "")
+		var buf bytes.Buffer
+		AnnotateError(errors.New("deployment not found"), &buf)
+		assert.Empty(t, buf.String())
+	})
+}
@@ -2,30 +2,129 @@ package cmdutil
 
 import (
 	"encoding/json"
+	"sort"
 
 	"github.com/bitrise-io/bitrise-plugins-codepush-cli/internal/bitrise"
+	"github.com/bitrise-io/bitrise-plugins-codepush-cli/internal/github"
 	"github.com/bitrise-io/bitrise-plugins-codepush-cli/internal/output"
 )
 
+// bitriseExport is turned off by --no-bitrise-export.
+var bitriseExport = true
+
+// SetBitriseExport turns exports to the Bitrise deploy directory and to
+// envman, and build annotations, on or off.
+func SetBitriseExport(enabled bool) {
+	bitriseExport = enabled
+}
+
+// StepOutputs describes the release or deployment a mutating command created
+// or changed, for later steps of a Bitrise workflow.
+type StepOutputs struct {
+	UpdateID      string
+	Label         string
+	AppVersion    string
+	DeploymentID  string
+	DeploymentKey string
+}
+
+// EnvVars returns the outputs as environment variables. Empty values are
+// left out. CODEPUSH_UPDATE_ID and CODEPUSH_LABEL are kept for workflows
+// written before CODEPUSH_PACKAGE_ID and CODEPUSH_RELEASE_LABEL.
+func (o StepOutputs) EnvVars() map[string]string {
+	vars := map[string]string{}
+	add := func(value string, keys ...string) {
+		if value == "" {
+			return
+		}
+		for _, key := range keys {
+			vars[key] = value
+		}
+	}
+	add(o.UpdateID, "CODEPUSH_PACKAGE_ID", "CODEPUSH_UPDATE_ID")
+	add(o.Label, "CODEPUSH_RELEASE_LABEL", "CODEPUSH_LABEL")
+	add(o.AppVersion, "CODEPUSH_APP_VERSION")
+	add(o.DeploymentID, "CODEPUSH_DEPLOYMENT_ID")
+	add(o.DeploymentKey, "CODEPUSH_DEPLOYMENT_KEY")
+	return vars
+}
+
+// ExportStepOutputs exports the result of a mutating command to the CI
+// service. On Bitrise, outputs become environment variables through envman,
+// and summary is written as filename in the deploy directory, unless
+// --no-bitrise-export is set. On GitHub Actions, outputs and the JSON of
+// summary, as CODEPUSH_SUMMARY, become step outputs. It does nothing
+// elsewhere.
+func ExportStepOutputs(filename string, summary any, outputs StepOutputs, out *output.Writer) {
+	switch CI() {
+	case CIBitrise:
+		if !bitriseExport {
+			return
+		}
+		ExportDeploySummary(filename, summary, out)
+		ExportEnvVars(outputs.EnvVars(), out)
+	case CIGitHub:
+		vars := outputs.EnvVars()
+		if data, err := json.Marshal(summary); err != nil {
+			out.Warning("failed to marshal %s: %v", filename, err)
+		} else {
+			vars["CODEPUSH_SUMMARY"] = string(data)
+		}
+		exportGitHubOutputs(vars, out)
+	}
+}
+
+// exportGitHubOutputs sets key-value pairs as GitHub Actions step outputs,
+// in key order.
+func exportGitHubOutputs(vars map[string]string, out *output.Writer) {
+	keys := make([]string, 0, len(vars))
+	for key := range vars {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		if err := github.SetOutput(key, vars[key]); err != nil {
+			out.Warning("failed to export %s: %v", key, err)
+			return
+		}
+	}
+}
+
 // ExportDeploySummary writes a JSON summary to the Bitrise deploy directory.
 func ExportDeploySummary(filename string, v any, out *output.Writer) {
+	if !bitriseExport {
+		return
+	}
 	data, err := json.MarshalIndent(v, "", "  ")
 	if err != nil {
 		out.Warning("failed to marshal %s: %v", filename, err)
 		return
 	}
 
+	if path := ExportDeployFile(filename, data, out); path != "" {
+		out.Info("Summary exported to: %s", path)
+	}
+}
+
+// ExportDeployFile writes a file to the Bitrise deploy directory and returns
+// its path, or "" when it was not written.
+func ExportDeployFile(filename string, data []byte, out *output.Writer) string {
+	if !bitriseExport {
+		return ""
+	}
 	path, err := bitrise.WriteToDeployDir(filename, data)
 	if err != nil {
 		out.Warning("failed to export %s: %v", filename, err)
-		return
+		return ""
 	}
-
-	out.Info("Summary exported to: %s", path)
+	return path
 }
 
 // ExportEnvVars exports key-value pairs as Bitrise environment variables via envman.
 func ExportEnvVars(vars map[string]string, out *output.Writer) {
+	if !bitriseExport {
+		return
+	}
 	for key, value := range vars {
 		if err := bitrise.ExportEnvVar(key, value); err != nil {
 			out.Warning("failed to export %s: %v", key, err)
@@ -0,0 +1,89 @@
+package cmdutil
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/bitrise-io/bitrise-plugins-codepush-cli/internal/output"
+)
+
+func TestStepOutputsEnvVars(t *testing.T) {
+	tests := []struct {
+		name    string
+		outputs StepOutputs
+		want    map[string]string
+	}{
+		{
+			name:    "release",
+			outputs: StepOutputs{UpdateID: "pkg-1", Label: "v3", AppVersion: "1.2.0", DeploymentID: "dep-1"},
+			want: map[string]string{
+				"CODEPUSH_PACKAGE_ID":    "pkg-1",
+				"CODEPUSH_UPDATE_ID":     "pkg-1",
+				"CODEPUSH_RELEASE_LABEL": "v3",
+				"CODEPUSH_LABEL":         "v3",
+				"CODEPUSH_APP_VERSION":   "1.2.0",
+				"CODEPUSH_DEPLOYMENT_ID": "dep-1",
+			},
+		},
+		{
+			name:    "deployment",
+			outputs: StepOutputs{DeploymentID: "dep-1", DeploymentKey: "key-1"},
+			want: map[string]string{
+				"CODEPUSH_DEPLOYMENT_ID":  "dep-1",
+				"CODEPUSH_DEPLOYMENT_KEY": "key-1",
+			},
+		},
+		{
+			name: "empty",
+			want: map[string]string{},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.want, tc.outputs.EnvVars())
+		})
+	}
+}
+
+func TestExportStepOutputs(t *testing.T) {
+	summary := map[string]string{"label": "v3"}
+
+	t.Run("writes the summary on Bitrise", func(t *testing.T) {
+		deployDir := t.TempDir()
+		t.Setenv("BITRISE_DEPLOY_DIR", deployDir)
+		t.Setenv("PATH", t.TempDir()) // no envman
+
+		ExportStepOutputs("codepush-test-summary.json", summary, StepOutputs{Label: "v3"}, output.NewTest(&bytes.Buffer{}))
+		assert.FileExists(t, filepath.Join(deployDir, "codepush-test-summary.json"))
+	})
+
+	t.Run("does nothing with --no-bitrise-export", func(t *testing.T) {
+		deployDir := t.TempDir()
+		t.Setenv("BITRISE_DEPLOY_DIR", deployDir)
+		SetBitriseExport(false)
+		t.Cleanup(func() { SetBitriseExport(true) })
+
+		ExportStepOutputs("codepush-test-summary.json", summary, StepOutputs{Label: "v3"}, output.NewTest(&bytes.Buffer{}))
+		entries, err := os.ReadDir(deployDir)
+		assert.NoError(t, err)
+		assert.Empty(t, entries)
+	})
+	t.Run("sets step outputs on GitHub Actions", func(t *testing.T) {
+		t.Setenv("BITRISE_BUILD_NUMBER", "")
+		t.Setenv("BITRISE_DEPLOY_DIR", "")
+		t.Setenv("GITHUB_ACTIONS", "true")
+		path := filepath.Join(t.TempDir(), "output")
+		t.Setenv("GITHUB_OUTPUT", path)
+
+		ExportStepOutputs("codepush-test-summary.json", summary, StepOutputs{Label: "v3"}, output.NewTest(&bytes.Buffer{}))
+		data, err := os.ReadFile(path)
+		require.NoError(t, err)
+		assert.Equal(t, "CODEPUSH_LABEL=v3\nCODEPUSH_RELEASE_LABEL=v3\nCODEPUSH_SUMMARY={\"label\":\"v3\"}\n", string(data))
+	})
+}
@@ -3,17 +3,23 @@ package cmdutil
 import (
 	"encoding/json"
 	"fmt"
-	"os"
+	"io"
 	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bitrise-io/bitrise-plugins-codepush-cli/internal/codepush"
+	"github.com/bitrise-io/bitrise-plugins-codepush-cli/internal/output"
+	"github.com/bitrise-io/bitrise-plugins-codepush-cli/internal/redact"
 )
 
-// OutputJSON marshals v as indented JSON to stdout. Used when --json is set.
-func OutputJSON(v any) error {
+// WriteJSON marshals v as indented JSON to w, redacting secrets.
+func WriteJSON(w io.Writer, v any) error {
 	data, err := json.MarshalIndent(v, "", "  ")
 	if err != nil {
 		return fmt.Errorf("marshaling JSON output: %w", err)
 	}
-	_, _ = fmt.Fprintln(os.Stdout, string(data))
+	_, _ = fmt.Fprintln(w, redact.String(string(data)))
 	return nil
 }
 
@@ -41,3 +47,79 @@ func FormatBytes(b int64) string {
 	}
 	return fmt.Sprintf("%.1f %cB", float64(b)/float64(div), "KMGTPE"[exp])
 }
+
+// ProvenancePairs describes where a release was pushed from, omitting
+// unknown values. It returns nil for a nil provenance.
+func ProvenancePairs(p *codepush.Provenance) []output.KeyValue {
+	if p == nil {
+		return nil
+	}
+	var pairs []output.KeyValue
+	if p.Branch != "" {
+		pairs = append(pairs, output.KeyValue{Key: "Branch", Value: p.Branch})
+	}
+	if p.CommitHash != "" {
+		commit := p.CommitHash
+		if p.Dirty {
+			commit += " (uncommitted changes)"
+		}
+		pairs = append(pairs, output.KeyValue{Key: "Commit", Value: commit})
+	}
+	if p.BuildNumber != "" {
+		build := "#" + p.BuildNumber
+		if p.Workflow != "" {
+			build += " (workflow " + p.Workflow + ")"
+		}
+		pairs = append(pairs, output.KeyValue{Key: "Build", Value: build})
+	}
+	if p.BuildURL != "" {
+		pairs = append(pairs, output.KeyValue{Key: "Build URL", Value: p.BuildURL})
+	}
+	return pairs
+}
+
+// ParseBytes parses a size such as "500MB", "5GB", or "1024" into bytes.
+// Units are binary (1 KB = 1024 B) to match FormatBytes.
+func ParseBytes(s string) (int64, error) {
+	str := strings.ToUpper(strings.TrimSpace(s))
+	i := strings.IndexFunc(str, func(r rune) bool { return (r < '0' || r > '9') && r != '.' })
+	if i < 0 {
+		i = len(str)
+	}
+
+	n, err := strconv.ParseFloat(str[:i], 64)
+	mult, ok := byteUnits[strings.TrimSpace(str[i:])]
+	if err != nil || !ok {
+		return 0, fmt.Errorf("invalid size %q: use a number with an optional unit (B, KB, MB, GB, TB)", s)
+	}
+	return int64(n * float64(mult)), nil
+}
+
+// ParseAge parses an age such as "90d", "2w", or "36h". Besides the units
+// accepted by time.ParseDuration, "d" (days) and "w" (weeks) are supported.
+func ParseAge(s string) (time.Duration, error) {
+	str := strings.TrimSpace(s)
+	for suffix, unit := range map[string]time.Duration{"d": 24 * time.Hour, "w": 7 * 24 * time.Hour} {
+		if n, ok := strings.CutSuffix(str, suffix); ok {
+			v, err := strconv.Atoi(n)
+			if err != nil || v <= 0 {
+				return 0, fmt.Errorf("invalid age %q: use a positive number of days (90d) or weeks (2w), or a duration (36h)", s)
+			}
+			return time.Duration(v) * unit, nil
+		}
+	}
+
+	d, err := time.ParseDuration(str)
+	if err != nil || d <= 0 {
+		return 0, fmt.Errorf("invalid age %q: use a positive number of days (90d) or weeks (2w), or a duration (36h)", s)
+	}
+	return d, nil
+}
+
+var byteUnits = map[string]int64{
+	"": 1, "B": 1,
+	"K": 1 << 10, "KB": 1 << 10, "KIB": 1 << 10,
+	"M": 1 << 20, "MB": 1 << 20, "MIB": 1 << 20,
+	"G": 1 << 30, "GB": 1 << 30, "GIB": 1 << 30,
+	"T": 1 << 40, "TB": 1 << 40, "TIB": 1 << 40,
+}
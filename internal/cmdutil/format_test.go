@@ -2,19 +2,24 @@ package cmdutil
 
 import (
 	"encoding/json"
+	"io"
 	"math"
+	"os"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+
+	"github.com/bitrise-io/bitrise-plugins-codepush-cli/internal/codepush"
 )
 
-func TestOutputJSON(t *testing.T) {
+func TestOutputResult(t *testing.T) {
 	data := struct {
 		Name string `json:"name"`
 	}{Name: "test"}
 
-	err := OutputJSON(data)
+	err := OutputResult(data)
 	require.NoError(t, err)
 }
 
@@ -74,21 +79,136 @@ func TestFormatBytes(t *testing.T) {
 	}
 }
 
-func TestOutputJSONFormat(t *testing.T) {
+func TestParseBytes(t *testing.T) {
+	tests := []struct {
+		name    string
+		s       string
+		want    int64
+		wantErr bool
+	}{
+		{name: "plain bytes", s: "1024", want: 1024},
+		{name: "gigabytes", s: "5GB", want: 5 << 30},
+		{name: "lowercase with space", s: "500 mb", want: 500 << 20},
+		{name: "fractional", s: "1.5G", want: 3 << 29},
+		{name: "binary suffix", s: "2GiB", want: 2 << 30},
+		{name: "unknown unit", s: "5PB", wantErr: true},
+		{name: "no number", s: "GB", wantErr: true},
+		{name: "negative", s: "-1GB", wantErr: true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := ParseBytes(tc.s)
+			if tc.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.want, got)
+		})
+	}
+}
+
+func TestParseAge(t *testing.T) {
+	tests := []struct {
+		name    string
+		s       string
+		want    time.Duration
+		wantErr bool
+	}{
+		{name: "days", s: "90d", want: 90 * 24 * time.Hour},
+		{name: "weeks", s: "2w", want: 14 * 24 * time.Hour},
+		{name: "duration", s: "36h", want: 36 * time.Hour},
+		{name: "zero days", s: "0d", wantErr: true},
+		{name: "fractional days", s: "1.5d", wantErr: true},
+		{name: "negative duration", s: "-1h", wantErr: true},
+		{name: "no unit", s: "90", wantErr: true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := ParseAge(tc.s)
+			if tc.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.want, got)
+		})
+	}
+}
+
+func TestOutputResultFormat(t *testing.T) {
 	data := map[string]string{"key": "value"}
-	err := OutputJSON(data)
+	err := OutputResult(data)
 	require.NoError(t, err)
 }
 
-func TestOutputJSONMarshalError(t *testing.T) {
+func TestOutputResultMarshalError(t *testing.T) {
 	data := struct {
 		ID   string `json:"id"`
 		Name string `json:"name"`
 	}{ID: "123", Name: "test"}
 
-	err := OutputJSON(data)
+	err := OutputResult(data)
 	require.NoError(t, err)
 
 	_, marshalErr := json.MarshalIndent(data, "", "  ")
 	require.NoError(t, marshalErr)
 }
+
+func TestOutputResultRedactsSecrets(t *testing.T) {
+	r, w, err := os.Pipe()
+	require.NoError(t, err)
+	orig := os.Stdout
+	os.Stdout = w
+	t.Cleanup(func() { os.Stdout = orig })
+
+	err = OutputResult(map[string]string{"upload_url": "https://s3.example.com/p?X-Amz-Signature=abcdef0123456789"})
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+
+	got, err := io.ReadAll(r)
+	require.NoError(t, err)
+	assert.NotContains(t, string(got), "abcdef0123456789")
+	assert.Contains(t, string(got), "[REDACTED]")
+}
+
+func TestOutputErrorReport(t *testing.T) {
+	r, w, err := os.Pipe()
+	require.NoError(t, err)
+	orig := os.Stderr
+	os.Stderr = w
+	t.Cleanup(func() { os.Stderr = orig })
+
+	OutputErrorReport(codepush.ErrorReport{Error: "push failed: API returned HTTP 500: boom", Kind: "api", ExitCode: 7, StatusCode: 500})
+	require.NoError(t, w.Close())
+
+	got, err := io.ReadAll(r)
+	require.NoError(t, err)
+	var report map[string]any
+	require.NoError(t, json.Unmarshal(got, &report))
+	assert.Equal(t, "api", report["kind"])
+	assert.InDelta(t, 7, report["exit_code"], 0)
+	assert.InDelta(t, 500, report["status_code"], 0)
+	assert.NotContains(t, report, "code")
+}
+
+func TestProvenancePairs(t *testing.T) {
+	assert.Nil(t, ProvenancePairs(nil))
+	assert.Empty(t, ProvenancePairs(&codepush.Provenance{}))
+
+	pairs := ProvenancePairs(&codepush.Provenance{
+		Branch:      "main",
+		CommitHash:  "deadbeef",
+		Dirty:       true,
+		BuildNumber: "42",
+		BuildURL:    "https://app.bitrise.io/build/abc",
+		Workflow:    "release",
+	})
+	require.Len(t, pairs, 4)
+	assert.Equal(t, "main", pairs[0].Value)
+	assert.Equal(t, "deadbeef (uncommitted changes)", pairs[1].Value)
+	assert.Equal(t, "#42 (workflow release)", pairs[2].Value)
+	assert.Equal(t, "https://app.bitrise.io/build/abc", pairs[3].Value)
+}
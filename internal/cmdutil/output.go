@@ -0,0 +1,339 @@
+package cmdutil
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/bitrise-io/bitrise-plugins-codepush-cli/internal/codepush"
+	"github.com/bitrise-io/bitrise-plugins-codepush-cli/internal/output"
+	"github.com/bitrise-io/bitrise-plugins-codepush-cli/internal/redact"
+)
+
+// Output formats selectable with --output.
+const (
+	FormatTable  = "table"
+	FormatJSON   = "json"
+	FormatNDJSON = "ndjson"
+	FormatYAML   = "yaml"
+	FormatCSV    = "csv"
+)
+
+// resultFormat is the format OutputResult writes, set by SetOutputFormat.
+var resultFormat = FormatJSON
+
+// SetOutputFormat selects the format of OutputResult and OutputErrorReport.
+// An empty format or FormatTable keeps the default JSON for --json. With
+// FormatNDJSON, the records out emits while a command runs are streamed to
+// stdout as well.
+func SetOutputFormat(format string, out *output.Writer) error {
+	switch format {
+	case "", FormatTable:
+		return nil
+	case FormatJSON, FormatYAML, FormatCSV:
+	case FormatNDJSON:
+		out.SetRecordStream(os.Stdout)
+	default:
+		return fmt.Errorf("invalid --output %q: must be table, json, ndjson, yaml, or csv", format)
+	}
+	resultFormat = format
+	return nil
+}
+
+// OutputFormat returns the format OutputResult writes.
+func OutputFormat() string {
+	return resultFormat
+}
+
+// OutputResult writes a command's result to stdout in the selected format.
+// Used when --json or a machine-readable --output is set.
+//
+//   - json: indented JSON.
+//   - ndjson: one compact JSON line, or a line per element of a slice.
+//   - yaml: YAML with the same field names and order as JSON.
+//   - csv: a header row of the JSON field names and a row per element of a
+//     slice; nested values are written as compact JSON.
+func OutputResult(v any) error {
+	switch resultFormat {
+	case FormatNDJSON:
+		return writeJSONLines(os.Stdout, v)
+	case FormatYAML:
+		return writeYAML(os.Stdout, v)
+	case FormatCSV:
+		return writeCSV(os.Stdout, v)
+	}
+	return WriteJSON(os.Stdout, v)
+}
+
+// OutputErrorReport writes a failed command's error report to stderr,
+// keeping stdout free for results: as YAML with --output yaml, a compact
+// line with ndjson, and indented JSON otherwise.
+func OutputErrorReport(report codepush.ErrorReport) {
+	write := WriteJSON
+	switch resultFormat {
+	case FormatNDJSON:
+		write = writeJSONLine
+	case FormatYAML:
+		write = writeYAML
+	}
+	if err := write(os.Stderr, report); err != nil {
+		_, _ = fmt.Fprintln(os.Stderr, report.Error)
+	}
+}
+
+func writeJSONLines(w io.Writer, v any) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Slice && rv.Kind() != reflect.Array {
+		return writeJSONLine(w, v)
+	}
+	for i := range rv.Len() {
+		if err := writeJSONLine(w, rv.Index(i).Interface()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func writeJSONLine(w io.Writer, v any) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("marshaling JSON output: %w", err)
+	}
+	_, _ = fmt.Fprintln(w, redact.String(string(data)))
+	return nil
+}
+
+func writeYAML(w io.Writer, v any) error {
+	node, err := orderedNode(v)
+	if err != nil {
+		return err
+	}
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(node.yaml()); err != nil {
+		return fmt.Errorf("marshaling YAML output: %w", err)
+	}
+	_ = enc.Close()
+	_, _ = io.WriteString(w, redact.String(buf.String()))
+	return nil
+}
+
+func writeCSV(w io.Writer, v any) error {
+	node, err := orderedNode(v)
+	if err != nil {
+		return err
+	}
+	records := []jsonNode{node}
+	if node.kind == jsonArray {
+		records = node.items
+	}
+
+	// Columns are the fields of all records, in the order they first appear.
+	var columns []string
+	seen := map[string]bool{}
+	for _, r := range records {
+		for _, f := range r.fields {
+			if !seen[f.key] {
+				seen[f.key] = true
+				columns = append(columns, f.key)
+			}
+		}
+	}
+	if len(columns) == 0 && len(records) > 0 {
+		columns = []string{"value"}
+	}
+
+	var buf bytes.Buffer
+	cw := csv.NewWriter(&buf)
+	_ = cw.Write(columns)
+	for _, r := range records {
+		row := make([]string, len(columns))
+		if r.kind != jsonObject {
+			row[0] = r.text()
+		}
+		for _, f := range r.fields {
+			row[indexOf(columns, f.key)] = f.value.text()
+		}
+		_ = cw.Write(row)
+	}
+	cw.Flush()
+	if err := cw.Error(); err != nil {
+		return fmt.Errorf("writing CSV output: %w", err)
+	}
+	_, _ = io.WriteString(w, redact.String(buf.String()))
+	return nil
+}
+
+func indexOf(values []string, v string) int {
+	for i, s := range values {
+		if s == v {
+			return i
+		}
+	}
+	return -1
+}
+
+// jsonNode is a decoded JSON value that keeps the order of object fields,
+// so YAML and CSV output list fields in the same order as JSON.
+type jsonNode struct {
+	kind   jsonKind
+	raw    string // scalars: the JSON text
+	fields []jsonField
+	items  []jsonNode
+}
+
+type jsonKind int
+
+const (
+	jsonScalar jsonKind = iota
+	jsonString
+	jsonObject
+	jsonArray
+)
+
+type jsonField struct {
+	key   string
+	value jsonNode
+}
+
+// orderedNode marshals v to JSON and decodes it into a jsonNode.
+func orderedNode(v any) (jsonNode, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return jsonNode{}, fmt.Errorf("marshaling output: %w", err)
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	return decodeNode(dec)
+}
+
+func decodeNode(dec *json.Decoder) (jsonNode, error) {
+	tok, err := dec.Token()
+	if err != nil {
+		return jsonNode{}, err
+	}
+	switch t := tok.(type) {
+	case json.Delim:
+		if t == '[' {
+			node := jsonNode{kind: jsonArray}
+			for dec.More() {
+				item, err := decodeNode(dec)
+				if err != nil {
+					return jsonNode{}, err
+				}
+				node.items = append(node.items, item)
+			}
+			_, err := dec.Token()
+			return node, err
+		}
+		node := jsonNode{kind: jsonObject}
+		for dec.More() {
+			keyTok, err := dec.Token()
+			if err != nil {
+				return jsonNode{}, err
+			}
+			value, err := decodeNode(dec)
+			if err != nil {
+				return jsonNode{}, err
+			}
+			node.fields = append(node.fields, jsonField{key: keyTok.(string), value: value})
+		}
+		_, err := dec.Token()
+		return node, err
+	case string:
+		return jsonNode{kind: jsonString, raw: t}, nil
+	case json.Number:
+		return jsonNode{raw: t.String()}, nil
+	case bool:
+		return jsonNode{raw: fmt.Sprint(t)}, nil
+	case nil:
+		return jsonNode{raw: "null"}, nil
+	}
+	return jsonNode{}, errors.New("unexpected JSON token")
+}
+
+// text renders the node for a CSV cell: strings and scalars as is, null as
+// empty, and objects and arrays as compact JSON.
+func (n jsonNode) text() string {
+	switch n.kind {
+	case jsonString:
+		return n.raw
+	case jsonScalar:
+		if n.raw == "null" {
+			return ""
+		}
+		return n.raw
+	}
+	var b strings.Builder
+	n.writeJSON(&b)
+	return b.String()
+}
+
+func (n jsonNode) writeJSON(b *strings.Builder) {
+	switch n.kind {
+	case jsonString:
+		data, _ := json.Marshal(n.raw)
+		b.Write(data)
+	case jsonScalar:
+		b.WriteString(n.raw)
+	case jsonArray:
+		b.WriteByte('[')
+		for i, item := range n.items {
+			if i > 0 {
+				b.WriteByte(',')
+			}
+			item.writeJSON(b)
+		}
+		b.WriteByte(']')
+	case jsonObject:
+		b.WriteByte('{')
+		for i, f := range n.fields {
+			if i > 0 {
+				b.WriteByte(',')
+			}
+			key, _ := json.Marshal(f.key)
+			b.Write(key)
+			b.WriteByte(':')
+			f.value.writeJSON(b)
+		}
+		b.WriteByte('}')
+	}
+}
+
+func (n jsonNode) yaml() *yaml.Node {
+	switch n.kind {
+	case jsonString:
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: n.raw}
+	case jsonArray:
+		node := &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq"}
+		for _, item := range n.items {
+			node.Content = append(node.Content, item.yaml())
+		}
+		return node
+	case jsonObject:
+		node := &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+		for _, f := range n.fields {
+			key := &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: f.key}
+			node.Content = append(node.Content, key, f.value.yaml())
+		}
+		return node
+	}
+	switch {
+	case n.raw == "null":
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!null", Value: "null"}
+	case n.raw == "true" || n.raw == "false":
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!bool", Value: n.raw}
+	case strings.ContainsAny(n.raw, ".eE"):
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!float", Value: n.raw}
+	}
+	return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!int", Value: n.raw}
+}
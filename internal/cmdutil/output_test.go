@@ -0,0 +1,113 @@
+package cmdutil
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/bitrise-io/bitrise-plugins-codepush-cli/internal/output"
+)
+
+type testRow struct {
+	Name    string            `json:"name"`
+	Rollout float64           `json:"rollout"`
+	Live    bool              `json:"live"`
+	Key     string            `json:"key,omitempty"`
+	Labels  map[string]string `json:"labels,omitempty"`
+	Creator *struct {
+		Email string `json:"email"`
+	} `json:"created_by"`
+}
+
+func TestWriteJSONLines(t *testing.T) {
+	tests := []struct {
+		name string
+		v    any
+		want string
+	}{
+		{name: "object", v: testRow{Name: "Staging"}, want: `{"name":"Staging","rollout":0,"live":false,"created_by":null}` + "\n"},
+		{name: "slice", v: []testRow{{Name: "Staging"}, {Name: "Production"}}, want: `{"name":"Staging","rollout":0,"live":false,"created_by":null}` + "\n" + `{"name":"Production","rollout":0,"live":false,"created_by":null}` + "\n"},
+		{name: "empty slice", v: []testRow{}, want: ""},
+		{name: "redacts", v: testRow{Name: "?token=tok_0123456789"}, want: `{"name":"?token=[REDACTED]","rollout":0,"live":false,"created_by":null}` + "\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			require.NoError(t, writeJSONLines(&buf, tt.v))
+			assert.Equal(t, tt.want, buf.String())
+		})
+	}
+}
+
+func TestWriteYAML(t *testing.T) {
+	rows := []testRow{
+		{Name: "Staging", Rollout: 12.5, Live: true, Labels: map[string]string{"b": "2", "a": "1"}},
+		{Name: "true", Rollout: 100},
+	}
+
+	var buf bytes.Buffer
+	require.NoError(t, writeYAML(&buf, rows))
+	assert.Equal(t, `- name: Staging
+  rollout: 12.5
+  live: true
+  labels:
+    a: "1"
+    b: "2"
+  created_by: null
+- name: "true"
+  rollout: 100
+  live: false
+  created_by: null
+`, buf.String())
+}
+
+func TestWriteCSV(t *testing.T) {
+	t.Run("slice", func(t *testing.T) {
+		creator := &struct {
+			Email string `json:"email"`
+		}{Email: "a@example.com"}
+		rows := []testRow{
+			{Name: "Staging", Rollout: 50, Creator: creator},
+			{Name: "Prod, EU", Rollout: 100, Key: "k1"},
+		}
+
+		var buf bytes.Buffer
+		require.NoError(t, writeCSV(&buf, rows))
+		assert.Equal(t, "name,rollout,live,created_by,key\n"+
+			"Staging,50,false,\"{\"\"email\"\":\"\"a@example.com\"\"}\",\n"+
+			"\"Prod, EU\",100,false,,k1\n", buf.String())
+	})
+
+	t.Run("object", func(t *testing.T) {
+		var buf bytes.Buffer
+		require.NoError(t, writeCSV(&buf, testRow{Name: "Staging"}))
+		assert.Equal(t, "name,rollout,live,created_by\nStaging,0,false,\n", buf.String())
+	})
+
+	t.Run("empty slice", func(t *testing.T) {
+		var buf bytes.Buffer
+		require.NoError(t, writeCSV(&buf, []testRow{}))
+		assert.Equal(t, "\n", buf.String())
+	})
+
+	t.Run("scalars", func(t *testing.T) {
+		var buf bytes.Buffer
+		require.NoError(t, writeCSV(&buf, []string{"a", "b"}))
+		assert.Equal(t, "value\na\nb\n", buf.String())
+	})
+}
+
+func TestSetOutputFormat(t *testing.T) {
+	t.Cleanup(func() { resultFormat = FormatJSON })
+
+	out := output.NewTest(&bytes.Buffer{})
+	require.NoError(t, SetOutputFormat("", out))
+	assert.Equal(t, FormatJSON, OutputFormat())
+	require.NoError(t, SetOutputFormat(FormatTable, out))
+	assert.Equal(t, FormatJSON, OutputFormat())
+	require.NoError(t, SetOutputFormat(FormatYAML, out))
+	assert.Equal(t, FormatYAML, OutputFormat())
+	assert.ErrorContains(t, SetOutputFormat("xml", out), "must be table, json, ndjson, yaml, or csv")
+}
@@ -0,0 +1,123 @@
+package cmdutil
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/bitrise-io/bitrise-plugins-codepush-cli/internal/auth"
+	"github.com/bitrise-io/bitrise-plugins-codepush-cli/internal/codepush"
+	"github.com/bitrise-io/bitrise-plugins-codepush-cli/internal/output"
+)
+
+// ReloginFunc runs the login flow again and stores the new token, which it
+// returns. Commands get it from cmd.Relogin; it is only offered in an
+// interactive terminal, and a nil ReloginFunc is never offered.
+type ReloginFunc func(ctx context.Context, out *output.Writer) (string, error)
+
+// expiryWarning makes sure a token that expires soon is warned about once
+// per run, however often the token is resolved.
+var expiryWarning sync.Once
+
+const expiryLayout = "Mon 2006-01-02 15:04 MST"
+
+// checkTokenExpiry returns the stored token, warning when it expires within
+// auth.ExpiryWarningPeriod. An expired token is reported as such, and in an
+// interactive terminal the login can be run again right away with relogin;
+// the new token is returned then.
+func checkTokenExpiry(ctx context.Context, stored *auth.Config, now time.Time, out *output.Writer, relogin ReloginFunc) string {
+	if out == nil || !stored.ExpiresWithin(auth.ExpiryWarningPeriod, now) {
+		return stored.Token
+	}
+
+	expiresAt := stored.ExpiresAt.Local().Format(expiryLayout)
+	if stored.ExpiresAt.After(now) {
+		expiryWarning.Do(func() {
+			days := int(math.Round(stored.ExpiresAt.Sub(now).Hours() / 24))
+			out.Warning("the stored API token expires on %s (in %s): run 'codepush auth login' to renew it", expiresAt, pluralDays(days))
+		})
+		return stored.Token
+	}
+
+	if !out.IsInteractive() || relogin == nil {
+		out.Warning("the stored API token expired on %s: run 'codepush auth login' to renew it", expiresAt)
+		return stored.Token
+	}
+	out.Warning("the stored API token expired on %s", expiresAt)
+	token, err := OfferRelogin(ctx, out, relogin)
+	if err != nil {
+		out.Warning("login failed: %v", err)
+	}
+	if token == "" {
+		return stored.Token
+	}
+	return token
+}
+
+func pluralDays(days int) string {
+	switch days {
+	case 0:
+		return "less than a day"
+	case 1:
+		return "1 day"
+	}
+	return fmt.Sprintf("%d days", days)
+}
+
+// OfferRelogin asks whether to log in again and, if so, runs relogin. It
+// returns the new token, or an empty string when the terminal is not
+// interactive, relogin is nil, or the user declined.
+func OfferRelogin(ctx context.Context, out *output.Writer, relogin ReloginFunc) (string, error) {
+	if relogin == nil || !out.IsInteractive() {
+		return "", nil
+	}
+	choice, err := out.Select("Log in again now?", []output.SelectOption{
+		{Label: "Yes, log in again", Value: "login"},
+		{Label: "No", Value: "skip"},
+	})
+	if err != nil || choice != "login" {
+		return "", err
+	}
+	return relogin(ctx, out)
+}
+
+// HandleUnauthorized explains a command failure caused by a rejected API
+// token (HTTP 401): which token was used and how to replace it. For a stored
+// token in an interactive terminal, it offers to log in again with relogin,
+// so the command can be rerun right away.
+func HandleUnauthorized(ctx context.Context, err error, out *output.Writer, relogin ReloginFunc) {
+	var apiErr *codepush.APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusUnauthorized {
+		return
+	}
+
+	if os.Getenv("BITRISE_API_TOKEN") != "" {
+		out.Info("The API token in BITRISE_API_TOKEN was rejected: it may have expired or been revoked. Generate a new one at %s", auth.TokenGenerationURL)
+		return
+	}
+	stored, loadErr := auth.LoadConfig()
+	if loadErr != nil || stored == nil || stored.Token == "" {
+		return
+	}
+
+	reason := "it may have expired or been revoked"
+	if !stored.ExpiresAt.IsZero() && !stored.ExpiresAt.After(time.Now()) {
+		reason = "it expired on " + stored.ExpiresAt.Local().Format(expiryLayout)
+	}
+	out.Info("The stored API token was rejected: %s.", reason)
+
+	token, err := OfferRelogin(ctx, out, relogin)
+	switch {
+	case err != nil:
+		out.Warning("login failed: %v", err)
+	case token != "":
+		out.Info("Run the command again to use the new token.")
+	default:
+		out.Info("Run 'codepush auth login' to store a new token.")
+	}
+}
@@ -0,0 +1,106 @@
+package cmdutil
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/bitrise-io/bitrise-plugins-codepush-cli/internal/auth"
+	"github.com/bitrise-io/bitrise-plugins-codepush-cli/internal/codepush"
+	"github.com/bitrise-io/bitrise-plugins-codepush-cli/internal/output"
+)
+
+func TestCheckTokenExpiry(t *testing.T) {
+	now := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name      string
+		expiresAt time.Time
+		want      string
+	}{
+		{name: "unknown expiry"},
+		{name: "far from expiry", expiresAt: now.Add(30 * 24 * time.Hour)},
+		{name: "expires soon", expiresAt: now.Add(3*24*time.Hour + time.Hour), want: "expires on %s (in 3 days): run 'codepush auth login' to renew it"},
+		{name: "expires today", expiresAt: now.Add(time.Hour), want: "(in less than a day)"},
+		{name: "expired", expiresAt: now.Add(-time.Hour), want: "expired on %s: run 'codepush auth login' to renew it"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			expiryWarning = sync.Once{}
+			var buf bytes.Buffer
+			stored := &auth.Config{Token: "stored-token", ExpiresAt: tt.expiresAt}
+
+			assert.Equal(t, "stored-token", checkTokenExpiry(context.Background(), stored, now, output.NewTest(&buf), nil))
+			if tt.want == "" {
+				assert.Empty(t, buf.String())
+				return
+			}
+			assert.Contains(t, buf.String(), strings.ReplaceAll(tt.want, "%s", tt.expiresAt.Local().Format(expiryLayout)))
+		})
+	}
+}
+
+func TestCheckTokenExpiryWarnsOnce(t *testing.T) {
+	now := time.Now()
+	expiryWarning = sync.Once{}
+	var buf bytes.Buffer
+	out := output.NewTest(&buf)
+	stored := &auth.Config{Token: "stored-token", ExpiresAt: now.Add(24 * time.Hour)}
+
+	checkTokenExpiry(context.Background(), stored, now, out, nil)
+	checkTokenExpiry(context.Background(), stored, now, out, nil)
+	assert.Equal(t, 1, bytes.Count(buf.Bytes(), []byte("expires on")))
+}
+
+func TestHandleUnauthorized(t *testing.T) {
+	unauthorized := &codepush.APIError{StatusCode: http.StatusUnauthorized, Message: "invalid API token"}
+
+	tests := []struct {
+		name     string
+		err      error
+		envToken string
+		stored   *auth.Config
+		want     string
+	}{
+		{name: "other error", err: &codepush.APIError{StatusCode: http.StatusForbidden}, envToken: "env-token"},
+		{name: "env token", err: unauthorized, envToken: "env-token", want: "The API token in BITRISE_API_TOKEN was rejected"},
+		{name: "stored token", err: unauthorized, stored: &auth.Config{Token: "stored"}, want: "it may have expired or been revoked.\n   Run 'codepush auth login'"},
+		{
+			name:   "expired stored token",
+			err:    unauthorized,
+			stored: &auth.Config{Token: "stored", ExpiresAt: time.Date(2026, 1, 2, 3, 4, 0, 0, time.UTC)},
+			want:   "it expired on %s.",
+		},
+		{name: "no token", err: unauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("BITRISE_API_TOKEN", tt.envToken)
+			t.Setenv("HOME", t.TempDir())
+			t.Setenv("XDG_CONFIG_HOME", "")
+			if tt.stored != nil {
+				require.NoError(t, auth.SaveConfig(*tt.stored))
+			}
+
+			var buf bytes.Buffer
+			HandleUnauthorized(context.Background(), tt.err, output.NewTest(&buf), nil)
+			if tt.want == "" {
+				assert.Empty(t, buf.String())
+				return
+			}
+			if tt.stored != nil {
+				tt.want = strings.ReplaceAll(tt.want, "%s", tt.stored.ExpiresAt.Local().Format(expiryLayout))
+			}
+			assert.Contains(t, buf.String(), tt.want)
+		})
+	}
+}
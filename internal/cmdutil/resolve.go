@@ -6,13 +6,16 @@ import (
 	"fmt"
 	"os"
 	"strings"
+	"time"
 
 	"github.com/google/uuid"
 
+	"github.com/bitrise-io/bitrise-plugins-codepush-cli/internal/appcatalog"
 	"github.com/bitrise-io/bitrise-plugins-codepush-cli/internal/auth"
 	"github.com/bitrise-io/bitrise-plugins-codepush-cli/internal/codepush"
 	"github.com/bitrise-io/bitrise-plugins-codepush-cli/internal/config"
 	"github.com/bitrise-io/bitrise-plugins-codepush-cli/internal/output"
+	"github.com/bitrise-io/bitrise-plugins-codepush-cli/internal/redact"
 )
 
 // DefaultServerURL is the default Bitrise API server base URL.
@@ -50,6 +53,26 @@ func ResolveServerURL(flagValue string, out *output.Writer) string {
 	return DefaultServerURL
 }
 
+// ResolveAPIURL returns the CodePush API base URL using the priority:
+// 1. apiURLFlag (--api-url)
+// 2. CODEPUSH_API_URL environment variable
+// 3. api_url in .codepush.json, unless --server-url or CODEPUSH_SERVER_URL is set
+// 4. APIURL of the server returned by ResolveServerURL
+//
+// Setting the API URL directly supports staging and self-hosted instances
+// that do not serve the API under the default path.
+func ResolveAPIURL(apiURLFlag, serverURLFlag string, out *output.Writer) string {
+	if v := ResolveFlag(apiURLFlag, "CODEPUSH_API_URL"); v != "" {
+		return strings.TrimRight(v, "/")
+	}
+	if ResolveFlag(serverURLFlag, "CODEPUSH_SERVER_URL") == "" {
+		if cfg, err := config.Load(); err == nil && cfg != nil && cfg.APIURL != "" {
+			return strings.TrimRight(cfg.APIURL, "/")
+		}
+	}
+	return APIURL(ResolveServerURL(serverURLFlag, out))
+}
+
 // ResolveFlag returns flagValue if non-empty, otherwise falls back to the environment variable.
 func ResolveFlag(flagValue, envKey string) string {
 	if flagValue != "" {
@@ -61,23 +84,31 @@ func ResolveFlag(flagValue, envKey string) string {
 // ResolveToken returns the API token using the priority:
 // 1. BITRISE_API_TOKEN environment variable
 // 2. Stored config file token (from 'codepush auth login')
-func ResolveToken(out *output.Writer) string {
+//
+// An expired stored token can be renewed inline with relogin.
+func ResolveToken(ctx context.Context, out *output.Writer, relogin ReloginFunc) string {
 	if envValue := os.Getenv("BITRISE_API_TOKEN"); envValue != "" {
+		redact.Register(envValue)
 		return envValue
 	}
-	storedToken, err := auth.LoadToken()
+	stored, err := auth.LoadConfig()
 	if err != nil {
 		if out != nil {
 			out.Warning("could not load stored token: %v", err)
 		}
 	}
-	return storedToken
+	if stored == nil {
+		return ""
+	}
+	token := checkTokenExpiry(ctx, stored, time.Now(), out, relogin)
+	redact.Register(token)
+	return token
 }
 
 // ResolveAppID returns the app ID using the priority:
 // 1. globalAppID flag value
 // 2. CODEPUSH_APP_ID environment variable
-// 3. .codepush.json file in current directory
+// 3. .codepush.json file in the project
 func ResolveAppID(globalAppID string, out *output.Writer) string {
 	if globalAppID != "" {
 		return globalAppID
@@ -98,16 +129,30 @@ func ResolveAppID(globalAppID string, out *output.Writer) string {
 	return ""
 }
 
-// RequireCredentials resolves and validates the app ID and API token.
-func RequireCredentials(globalAppID string, out *output.Writer) (appID, token string, err error) {
+// FirstRunFunc runs the guided first-run setup and returns the credentials
+// it set up, or empty values when the setup was skipped. Commands get it
+// from cmd.FirstRun; a nil FirstRunFunc is never run.
+type FirstRunFunc func(ctx context.Context, out *output.Writer) (appID, token string, err error)
+
+// RequireCredentials resolves and validates the app ID and API token. An
+// expired stored token can be renewed inline with relogin, and when neither
+// an app ID nor a token is configured, firstRun sets them up.
+func RequireCredentials(ctx context.Context, globalAppID string, out *output.Writer, relogin ReloginFunc, firstRun FirstRunFunc) (appID, token string, err error) {
 	appID = ResolveAppID(globalAppID, out)
-	token = ResolveToken(out)
+	token = ResolveToken(ctx, out, relogin)
+
+	if appID == "" && token == "" && firstRun != nil {
+		appID, token, err = firstRun(ctx, out)
+		if err != nil {
+			return "", "", err
+		}
+	}
 
 	if appID == "" {
-		return "", "", errors.New("app ID is required: set --app-id, CODEPUSH_APP_ID, or run 'codepush init'")
+		return "", "", codepush.Invalid(errors.New("app ID is required: set --app-id, CODEPUSH_APP_ID, or run 'codepush init'"))
 	}
 	if token == "" {
-		return "", "", errors.New("API token is required: set BITRISE_API_TOKEN or run 'codepush auth login'")
+		return "", "", fmt.Errorf("%w: set BITRISE_API_TOKEN or run 'codepush auth login'", codepush.ErrMissingToken)
 	}
 	return appID, token, nil
 }
@@ -151,7 +196,7 @@ func ResolveAppIDInteractive(globalAppID string, out *output.Writer) (string, er
 	}
 
 	if !out.IsInteractive() {
-		return "", errors.New("app ID is required: set --app-id, CODEPUSH_APP_ID, or run 'codepush init'")
+		return "", codepush.Invalid(errors.New("app ID is required: set --app-id, CODEPUSH_APP_ID, or run 'codepush init'"))
 	}
 
 	appID, err := out.Input("Enter your app ID (UUID)", "xxxxxxxx-xxxx-xxxx-xxxx-xxxxxxxxxxxx")
@@ -170,13 +215,96 @@ func ResolveAppIDInteractive(globalAppID string, out *output.Writer) (string, er
 	return appID, nil
 }
 
+// AppLister is the subset of codepush.Client needed to select an app.
+type AppLister interface {
+	ListApps(ctx context.Context) ([]codepush.App, error)
+}
+
+// SelectAppInteractive resolves the app ID like ResolveAppIDInteractive, but
+// when none is configured and the terminal is interactive, it offers the apps
+// the token can access in a selector. With many apps, a search query
+// narrows the list first, matched by appcatalog.Search. When the app list is
+// unavailable or empty it falls back to entering the UUID.
+func SelectAppInteractive(ctx context.Context, client AppLister, globalAppID string, out *output.Writer) (string, error) {
+	if !out.IsInteractive() || ResolveAppID(globalAppID, nil) != "" {
+		return ResolveAppIDInteractive(globalAppID, out)
+	}
+
+	apps, err := client.ListApps(ctx)
+	if err != nil {
+		out.Warning("could not list apps: %v", err)
+	}
+	if len(apps) == 0 {
+		return ResolveAppIDInteractive(globalAppID, out)
+	}
+
+	if len(apps) > appSearchThreshold {
+		query, err := out.Input(fmt.Sprintf("Search %d apps by name (empty lists all)", len(apps)), "e.g. shop ios")
+		if err != nil {
+			return "", err
+		}
+		if matches := appcatalog.Search(apps, query); len(matches) > 0 {
+			apps = matches
+		} else {
+			out.Warning("no app matches %q, listing all", query)
+		}
+	}
+
+	options := make([]output.SelectOption, len(apps))
+	for i, a := range apps {
+		options[i] = output.SelectOption{Label: AppLabel(a), Value: a.ID}
+	}
+	return out.Select("Select app", options)
+}
+
+// appSearchThreshold is the number of apps above which SelectAppInteractive
+// asks for a search query before listing them.
+const appSearchThreshold = 10
+
+// AppLabel returns the display name of an app: its store name (or ID when it
+// has none) followed by its platform.
+func AppLabel(a codepush.App) string {
+	label := a.Name
+	if label == "" {
+		label = a.ID
+	}
+	if a.Platform != "" {
+		label += " (" + a.Platform + ")"
+	}
+	return label
+}
+
+// DeploymentEnvKey is the environment variable naming the deployment. The
+// "deployment" default in .codepush.json applies wherever it does.
+const DeploymentEnvKey = "CODEPUSH_DEPLOYMENT"
+
+// ResolveDeploymentValue returns the flag or environment value and, for
+// DeploymentEnvKey, falls back to the default deployment in .codepush.json.
+func ResolveDeploymentValue(flagValue, envKey string, out *output.Writer) string {
+	if v := ResolveFlag(flagValue, envKey); v != "" || envKey != DeploymentEnvKey {
+		return v
+	}
+	cfg, err := config.Load()
+	if err != nil {
+		if out != nil {
+			out.Warning("could not load %s: %v", config.FileName, err)
+		}
+		return ""
+	}
+	if cfg != nil {
+		return cfg.Deployment
+	}
+	return ""
+}
+
 // ResolveDeploymentInteractive resolves a deployment using the priority:
 // 1. Flag value (passed directly)
 // 2. Environment variable
-// 3. Interactive terminal selector (fetches deployments from API)
-// 4. Non-interactive error with flag hint
+// 3. The "deployment" default in .codepush.json (for DeploymentEnvKey only)
+// 4. Interactive terminal selector (fetches deployments from API)
+// 5. Non-interactive error with flag hint
 func ResolveDeploymentInteractive(ctx context.Context, client codepush.Client, appID, flagValue, envKey string, out *output.Writer) (string, error) {
-	deployment := ResolveFlag(flagValue, envKey)
+	deployment := ResolveDeploymentValue(flagValue, envKey, out)
 
 	if deployment != "" {
 		return codepush.ResolveDeployment(ctx, client, appID, deployment, out)
@@ -223,3 +351,22 @@ func ResolvePlatformInteractive(flagValue string, out *output.Writer) (string, e
 		{Label: "Android", Value: "android"},
 	})
 }
+
+// ResolveDeploymentForWrite resolves a deployment like
+// ResolveDeploymentInteractive and, when it was given as a UUID, verifies it
+// belongs to appID. Use before destructive or mutating operations so that a
+// UUID from another app fails with a specific error instead of a bare 404.
+func ResolveDeploymentForWrite(ctx context.Context, client codepush.Client, appID, flagValue, envKey string, out *output.Writer) (string, error) {
+	deploymentID, err := ResolveDeploymentInteractive(ctx, client, appID, flagValue, envKey, out)
+	if err != nil {
+		return "", err
+	}
+
+	if _, err := uuid.Parse(ResolveDeploymentValue(flagValue, envKey, out)); err == nil {
+		if err := codepush.VerifyDeployment(ctx, client, appID, deploymentID); err != nil {
+			return "", err
+		}
+	}
+
+	return deploymentID, nil
+}
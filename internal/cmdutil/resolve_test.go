@@ -1,12 +1,16 @@
 package cmdutil
 
 import (
+	"context"
 	"io"
+	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
+	"github.com/bitrise-io/bitrise-plugins-codepush-cli/internal/codepush"
 	"github.com/bitrise-io/bitrise-plugins-codepush-cli/internal/output"
 )
 
@@ -48,6 +52,39 @@ func TestResolveServerURL(t *testing.T) {
 	})
 }
 
+func TestResolveAPIURL(t *testing.T) {
+	out := output.NewTest(io.Discard)
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "package.json"), []byte(`{}`), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, ".codepush.json"), []byte(`{"app_id":"x","api_url":"https://rm.example.com/api/"}`), 0o644))
+	t.Chdir(dir)
+	t.Setenv("CODEPUSH_API_URL", "")
+	t.Setenv("CODEPUSH_SERVER_URL", "")
+
+	t.Run("flag takes priority", func(t *testing.T) {
+		t.Setenv("CODEPUSH_API_URL", "https://from-env")
+		assert.Equal(t, "https://from-flag", ResolveAPIURL("https://from-flag/", "", out))
+	})
+
+	t.Run("env var before server url", func(t *testing.T) {
+		t.Setenv("CODEPUSH_API_URL", "https://from-env")
+		assert.Equal(t, "https://from-env", ResolveAPIURL("", "https://server", out))
+	})
+
+	t.Run("server url before config api url", func(t *testing.T) {
+		assert.Equal(t, "https://server/release-management/v1", ResolveAPIURL("", "https://server", out))
+	})
+
+	t.Run("falls back to config api url", func(t *testing.T) {
+		assert.Equal(t, "https://rm.example.com/api", ResolveAPIURL("", "", out))
+	})
+
+	t.Run("derives from default server without config", func(t *testing.T) {
+		t.Chdir(t.TempDir())
+		assert.Equal(t, APIURL(DefaultServerURL), ResolveAPIURL("", "", out))
+	})
+}
+
 func TestResolveFlag(t *testing.T) {
 	tests := []struct {
 		name      string
@@ -94,12 +131,12 @@ func TestResolveToken(t *testing.T) {
 
 	t.Run("env var takes priority", func(t *testing.T) {
 		t.Setenv("BITRISE_API_TOKEN", "env-token")
-		assert.Equal(t, "env-token", ResolveToken(out))
+		assert.Equal(t, "env-token", ResolveToken(context.Background(), out, nil))
 	})
 
 	t.Run("returns empty when nothing set", func(t *testing.T) {
 		t.Setenv("BITRISE_API_TOKEN", "")
-		_ = ResolveToken(out)
+		_ = ResolveToken(context.Background(), out, nil)
 	})
 }
 
@@ -116,19 +153,62 @@ func TestResolveAppID(t *testing.T) {
 	})
 }
 
+func TestResolveDeploymentValue(t *testing.T) {
+	out := output.NewTest(io.Discard)
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "package.json"), []byte(`{}`), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, ".codepush.json"), []byte(`{"app_id":"x","deployment":"Staging"}`), 0o644))
+	t.Chdir(dir)
+
+	t.Run("flag takes priority", func(t *testing.T) {
+		t.Setenv(DeploymentEnvKey, "env-value")
+		assert.Equal(t, "flag-value", ResolveDeploymentValue("flag-value", DeploymentEnvKey, out))
+	})
+
+	t.Run("env var before config default", func(t *testing.T) {
+		t.Setenv(DeploymentEnvKey, "env-value")
+		assert.Equal(t, "env-value", ResolveDeploymentValue("", DeploymentEnvKey, out))
+	})
+
+	t.Run("falls back to config default", func(t *testing.T) {
+		t.Setenv(DeploymentEnvKey, "")
+		assert.Equal(t, "Staging", ResolveDeploymentValue("", DeploymentEnvKey, out))
+	})
+
+	t.Run("config default ignored for other env keys", func(t *testing.T) {
+		t.Setenv("CODEPUSH_DEST_DEPLOYMENT", "")
+		assert.Empty(t, ResolveDeploymentValue("", "CODEPUSH_DEST_DEPLOYMENT", out))
+	})
+}
+
 func TestRequireCredentials(t *testing.T) {
 	out := output.NewTest(io.Discard)
 
 	t.Run("returns error when app ID missing", func(t *testing.T) {
 		t.Setenv("CODEPUSH_APP_ID", "")
-		_, _, err := RequireCredentials("", out)
+		_, _, err := RequireCredentials(context.Background(), "", out, nil, nil)
 		require.Error(t, err)
 		assert.ErrorContains(t, err, "app ID is required")
 	})
 
+	t.Run("runs first-run setup when nothing is configured", func(t *testing.T) {
+		t.Setenv("CODEPUSH_APP_ID", "")
+		t.Setenv("BITRISE_API_TOKEN", "")
+		t.Setenv("HOME", t.TempDir())
+		t.Setenv("XDG_CONFIG_HOME", "")
+		firstRun := func(context.Context, *output.Writer) (string, string, error) {
+			return "onboarded-app", "onboarded-token", nil
+		}
+
+		appID, token, err := RequireCredentials(context.Background(), "", out, nil, firstRun)
+		require.NoError(t, err)
+		assert.Equal(t, "onboarded-app", appID)
+		assert.Equal(t, "onboarded-token", token)
+	})
+
 	t.Run("returns values when both set", func(t *testing.T) {
 		t.Setenv("BITRISE_API_TOKEN", "my-token")
-		appID, token, err := RequireCredentials("my-app", out)
+		appID, token, err := RequireCredentials(context.Background(), "my-app", out, nil, nil)
 		require.NoError(t, err)
 		assert.Equal(t, "my-app", appID)
 		assert.Equal(t, "my-token", token)
@@ -174,6 +254,35 @@ func TestResolveAppIDInteractive(t *testing.T) {
 	})
 }
 
+func TestSelectAppInteractive(t *testing.T) {
+	out := output.NewTest(io.Discard)
+	client := appListerFunc(func(context.Context) ([]codepush.App, error) {
+		t.Fatal("apps must not be listed when the app ID is configured or the terminal is non-interactive")
+		return nil, nil
+	})
+
+	t.Run("returns configured app ID", func(t *testing.T) {
+		got, err := SelectAppInteractive(context.Background(), client, "550e8400-e29b-41d4-a716-446655440000", out)
+		require.NoError(t, err)
+		assert.Equal(t, "550e8400-e29b-41d4-a716-446655440000", got)
+	})
+
+	t.Run("returns error in non-interactive mode when empty", func(t *testing.T) {
+		t.Setenv("CODEPUSH_APP_ID", "")
+		_, err := SelectAppInteractive(context.Background(), client, "", out)
+		assert.ErrorContains(t, err, "app ID is required")
+	})
+}
+
+type appListerFunc func(ctx context.Context) ([]codepush.App, error)
+
+func (f appListerFunc) ListApps(ctx context.Context) ([]codepush.App, error) { return f(ctx) }
+
+func TestAppLabel(t *testing.T) {
+	assert.Equal(t, "Acme (ios)", AppLabel(codepush.App{ID: "app-1", Name: "Acme", Platform: "ios"}))
+	assert.Equal(t, "app-1", AppLabel(codepush.App{ID: "app-1"}))
+}
+
 func TestResolvePlatformInteractive(t *testing.T) {
 	out := output.NewTest(io.Discard)
 
@@ -0,0 +1,107 @@
+package codepush
+
+import (
+	"cmp"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// APIError is returned when the API responds with a non-2xx status. Use
+// errors.As to inspect it, or the IsNotFound, IsConflict, and IsUnauthorized
+// helpers, instead of matching status codes in the error text.
+type APIError struct {
+	StatusCode int
+	// Code is the machine-readable error code from the response body, such
+	// as ERR_BAD_REQUEST. Empty when the server did not send one.
+	Code string
+	// Message is the human-readable error from the response body, or the
+	// raw body when it is not a JSON error object.
+	Message string
+	// Body is the raw response body.
+	Body string
+}
+
+func (e *APIError) Error() string {
+	if e.Code != "" {
+		return fmt.Sprintf("API returned HTTP %d (%s): %s", e.StatusCode, e.Code, e.Message)
+	}
+	return fmt.Sprintf("API returned HTTP %d: %s", e.StatusCode, e.Message)
+}
+
+// Is reports whether the error matches a sentinel error, so that
+// errors.Is(err, ErrDuplicateRelease) works for any request that the server
+// rejects because of identical content.
+func (e *APIError) Is(target error) bool {
+	return target == ErrDuplicateRelease && e.isDuplicateRelease()
+}
+
+// isDuplicateRelease reports whether the server rejected a release because
+// the deployment already contains identical content. See ErrDuplicateRelease.
+func (e *APIError) isDuplicateRelease() bool {
+	return e.StatusCode == http.StatusBadRequest &&
+		strings.Contains(e.Body, "ERR_BAD_REQUEST") &&
+		strings.Contains(e.Body, "identical to the contents")
+}
+
+// newAPIError builds an APIError from a response status and body. The body
+// may carry the error as {"error": "..."}, {"message": "...", "code": "..."},
+// or {"error": {"message": "...", "code": "..."}}.
+func newAPIError(statusCode int, body []byte) *APIError {
+	e := &APIError{StatusCode: statusCode, Body: string(body)}
+
+	var parsed struct {
+		Error   json.RawMessage `json:"error"`
+		Message string          `json:"message"`
+		Code    string          `json:"code"`
+	}
+	if json.Unmarshal(body, &parsed) == nil {
+		e.Message, e.Code = parsed.Message, parsed.Code
+
+		var nested struct {
+			Message string `json:"message"`
+			Code    string `json:"code"`
+		}
+		var msg string
+		switch {
+		case json.Unmarshal(parsed.Error, &msg) == nil:
+			e.Message = cmp.Or(e.Message, msg)
+		case json.Unmarshal(parsed.Error, &nested) == nil:
+			e.Message = cmp.Or(e.Message, nested.Message)
+			e.Code = cmp.Or(e.Code, nested.Code)
+		}
+	}
+
+	if e.Message == "" {
+		e.Message = strings.TrimSpace(string(body))
+	}
+	return e
+}
+
+// statusCode returns the HTTP status of an APIError in err's chain, or 0.
+func statusCode(err error) int {
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.StatusCode
+	}
+	return 0
+}
+
+// IsNotFound reports whether err is an API error with HTTP 404.
+func IsNotFound(err error) bool {
+	return statusCode(err) == http.StatusNotFound
+}
+
+// IsConflict reports whether err is an API error with HTTP 409.
+func IsConflict(err error) bool {
+	return statusCode(err) == http.StatusConflict
+}
+
+// IsUnauthorized reports whether err is an API error with HTTP 401 or 403,
+// meaning the token is invalid or lacks access.
+func IsUnauthorized(err error) bool {
+	code := statusCode(err)
+	return code == http.StatusUnauthorized || code == http.StatusForbidden
+}
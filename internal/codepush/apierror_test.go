@@ -0,0 +1,75 @@
+package codepush
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewAPIError(t *testing.T) {
+	tests := []struct {
+		name        string
+		body        string
+		wantCode    string
+		wantMessage string
+	}{
+		{"error string", `{"error":"not found"}`, "", "not found"},
+		{"message and code", `{"code":"ERR_BAD_REQUEST","message":"invalid name"}`, "ERR_BAD_REQUEST", "invalid name"},
+		{"nested error object", `{"error":{"code":"ERR_CONFLICT","message":"already exists"}}`, "ERR_CONFLICT", "already exists"},
+		{"plain text", "upstream timeout\n", "", "upstream timeout"},
+		{"empty", "", "", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := newAPIError(http.StatusBadRequest, []byte(tt.body))
+			assert.Equal(t, http.StatusBadRequest, err.StatusCode)
+			assert.Equal(t, tt.wantCode, err.Code)
+			assert.Equal(t, tt.wantMessage, err.Message)
+			assert.Equal(t, tt.body, err.Body)
+		})
+	}
+}
+
+func TestAPIErrorMessage(t *testing.T) {
+	assert.Equal(t, "API returned HTTP 404: not found", newAPIError(404, []byte(`{"error":"not found"}`)).Error())
+	assert.Equal(t, "API returned HTTP 400 (ERR_BAD_REQUEST): bad", newAPIError(400, []byte(`{"code":"ERR_BAD_REQUEST","message":"bad"}`)).Error())
+}
+
+func TestAPIErrorHelpers(t *testing.T) {
+	wrap := func(code int) error {
+		return fmt.Errorf("doing something: %w", newAPIError(code, nil))
+	}
+
+	assert.True(t, IsNotFound(wrap(http.StatusNotFound)))
+	assert.False(t, IsNotFound(wrap(http.StatusConflict)))
+	assert.True(t, IsConflict(wrap(http.StatusConflict)))
+	assert.True(t, IsUnauthorized(wrap(http.StatusUnauthorized)))
+	assert.True(t, IsUnauthorized(wrap(http.StatusForbidden)))
+	assert.False(t, IsUnauthorized(wrap(http.StatusInternalServerError)))
+	assert.False(t, IsNotFound(fmt.Errorf("HTTP 404")))
+	assert.False(t, IsNotFound(nil))
+}
+
+func TestAPIErrorDuplicateRelease(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{"code":"ERR_BAD_REQUEST","message":"the release is identical to the contents of the target deployment"}`))
+	}))
+	defer server.Close()
+
+	client := newTestClient(server.URL, "test-token", "test")
+	_, err := client.Promote(context.Background(), "app-123", "dep-src", PromoteRequest{TargetDeploymentID: "dep-dst"})
+	require.ErrorIs(t, err, ErrDuplicateRelease)
+
+	var apiErr *APIError
+	require.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, "ERR_BAD_REQUEST", apiErr.Code)
+
+	assert.NotErrorIs(t, newAPIError(http.StatusBadRequest, []byte(`{"code":"ERR_BAD_REQUEST","message":"invalid label"}`)), ErrDuplicateRelease)
+}
@@ -0,0 +1,67 @@
+package codepush
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// versionComparator matches one comparison of a semver range, such as
+// "1.2.3", ">=1.2.0", "1.2.x", "~1.2", or "^1.0.0-beta.1".
+var versionComparator = regexp.MustCompile(`^(>=|<=|>|<|=|~|\^)?v?(\d+|[xX*])(\.(\d+|[xX*])){0,2}(-[0-9A-Za-z.-]+)?(\+[0-9A-Za-z.-]+)?$`)
+
+// operatorSpace matches whitespace between a comparison operator and its
+// version, as in ">= 1.2.0".
+var operatorSpace = regexp.MustCompile(`(>=|<=|>|<|=|~|\^)\s+`)
+
+// NormalizeAppVersion validates a target app version, which is an exact
+// version or a semver range, and returns it in canonical form: single spaces
+// between comparisons, no space after operators, and no "v" prefix.
+//
+// Accepted forms are exact versions ("1.2.3"), wildcards ("1.2.x"), tilde and
+// caret ranges ("~1.2.0", "^1.0.0"), comparisons (">=1.2.0 <1.4.0"), hyphen
+// ranges ("1.2.0 - 1.4.0"), and alternatives joined by "||".
+func NormalizeAppVersion(value string) (string, error) {
+	alternatives := strings.Split(value, "||")
+	for i, alt := range alternatives {
+		normalized, ok := normalizeVersionRange(alt)
+		if !ok {
+			return "", fmt.Errorf("invalid app version %q: use an exact version such as 1.2.3 or a semver range such as 1.2.x, ~1.2.0, or \">=1.2.0 <1.4.0\"", value)
+		}
+		alternatives[i] = normalized
+	}
+	return strings.Join(alternatives, " || "), nil
+}
+
+func normalizeVersionRange(value string) (string, bool) {
+	fields := strings.Fields(value)
+	if len(fields) == 3 && fields[1] == "-" {
+		from, okFrom := normalizePartialVersion(fields[0])
+		to, okTo := normalizePartialVersion(fields[2])
+		return from + " - " + to, okFrom && okTo
+	}
+
+	fields = strings.Fields(operatorSpace.ReplaceAllString(value, "$1"))
+	if len(fields) == 0 {
+		return "", false
+	}
+	for i, f := range fields {
+		m := versionComparator.FindStringSubmatch(f)
+		if m == nil {
+			return "", false
+		}
+		op := m[1]
+		fields[i] = op + strings.TrimPrefix(f[len(op):], "v")
+	}
+	return strings.Join(fields, " "), true
+}
+
+// normalizePartialVersion validates a bound of a hyphen range, which has no
+// operator.
+func normalizePartialVersion(value string) (string, bool) {
+	m := versionComparator.FindStringSubmatch(value)
+	if m == nil || m[1] != "" {
+		return "", false
+	}
+	return strings.TrimPrefix(value, "v"), true
+}
@@ -0,0 +1,50 @@
+package codepush
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNormalizeAppVersion(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    string
+		wantErr bool
+	}{
+		{value: "1.2.3", want: "1.2.3"},
+		{value: "v1.2.3", want: "1.2.3"},
+		{value: "1.2", want: "1.2"},
+		{value: "1.2.x", want: "1.2.x"},
+		{value: "1.*", want: "1.*"},
+		{value: "*", want: "*"},
+		{value: "~1.2.0", want: "~1.2.0"},
+		{value: "^1.0.0-beta.1", want: "^1.0.0-beta.1"},
+		{value: ">=1.2.0 <1.4.0", want: ">=1.2.0 <1.4.0"},
+		{value: " >= 1.2.0   < 1.4.0 ", want: ">=1.2.0 <1.4.0"},
+		{value: ">=v1.2.0", want: ">=1.2.0"},
+		{value: "1.2.0 - 1.4.0", want: "1.2.0 - 1.4.0"},
+		{value: "1.2.x||>=2.0.0", want: "1.2.x || >=2.0.0"},
+		{value: "", wantErr: true},
+		{value: "latest", wantErr: true},
+		{value: "1.2.3.4", wantErr: true},
+		{value: "1..2", wantErr: true},
+		{value: ">=", wantErr: true},
+		{value: ">=1.0 - 2.0", wantErr: true},
+		{value: "1.2.x ||", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			got, err := NormalizeAppVersion(tt.value)
+			if tt.wantErr {
+				require.Error(t, err)
+				assert.ErrorContains(t, err, "semver range such as")
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
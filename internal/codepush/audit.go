@@ -0,0 +1,162 @@
+package codepush
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Audit trail formats.
+const (
+	AuditFormatCSV  = "csv"
+	AuditFormatJSON = "json"
+)
+
+// AuditActionRelease is the audit action of a created release. Other actions
+// are server event types.
+const AuditActionRelease = "release"
+
+// auditClient is the subset of Client needed by BuildAuditTrail.
+type auditClient interface {
+	deploymentGetter
+	updateLister
+	ListDeploymentEvents(ctx context.Context, appID, deploymentID string) ([]DeploymentEvent, error)
+}
+
+// AuditEntry is one action in an audit trail.
+type AuditEntry struct {
+	Time string `json:"time"`
+	// Action is AuditActionRelease for a created release, or the server
+	// event type, such as "patch" or "rollback".
+	Action     string `json:"action"`
+	Label      string `json:"label,omitempty"`
+	UpdateID   string `json:"package_id,omitempty"`
+	Actor      string `json:"actor,omitempty"`
+	ActorEmail string `json:"actor_email,omitempty"`
+	// Details lists the release metadata or the changed fields as
+	// "key=value" pairs separated by "; ".
+	Details string `json:"details,omitempty"`
+}
+
+// AuditTrail is the history of who did what in a deployment.
+type AuditTrail struct {
+	AppID        string `json:"app_id"`
+	DeploymentID string `json:"deployment_id"`
+	Deployment   string `json:"deployment"`
+	// EventsAvailable is false when the server does not expose deployment
+	// events; the trail then only lists created releases.
+	EventsAvailable bool         `json:"events_available"`
+	Entries         []AuditEntry `json:"entries"`
+}
+
+// BuildAuditTrail combines the releases of a deployment with its recorded
+// events, such as patches and rollbacks, into one trail, oldest first.
+// Events the server cannot provide are left out, not treated as an error.
+func BuildAuditTrail(ctx context.Context, client auditClient, appID, deploymentID string) (*AuditTrail, error) {
+	dep, err := client.GetDeployment(ctx, appID, deploymentID)
+	if err != nil {
+		return nil, fmt.Errorf("getting deployment: %w", err)
+	}
+	updates, err := client.ListUpdates(ctx, appID, deploymentID)
+	if err != nil {
+		return nil, fmt.Errorf("listing releases: %w", err)
+	}
+	events, err := client.ListDeploymentEvents(ctx, appID, deploymentID)
+	eventsAvailable := !errors.Is(err, ErrEventsUnavailable)
+	if err != nil && eventsAvailable {
+		return nil, err
+	}
+
+	labels := make(map[string]string, len(updates))
+	entries := make([]AuditEntry, 0, len(updates)+len(events))
+	for _, u := range updates {
+		labels[u.ID] = u.Label
+		entries = append(entries, releaseAuditEntry(u))
+	}
+	for _, e := range events {
+		entry := eventAuditEntry(e)
+		if entry.Label == "" {
+			entry.Label = labels[e.UpdateID]
+		}
+		entries = append(entries, entry)
+	}
+	slices.SortStableFunc(entries, func(a, b AuditEntry) int { return compareCreatedAt(a.Time, b.Time) })
+
+	return &AuditTrail{
+		AppID:           appID,
+		DeploymentID:    deploymentID,
+		Deployment:      dep.Name,
+		EventsAvailable: eventsAvailable,
+		Entries:         entries,
+	}, nil
+}
+
+func releaseAuditEntry(u Update) AuditEntry {
+	details := []string{
+		"app_version=" + u.AppVersion,
+		"rollout=" + strconv.Itoa(int(math.Round(u.Rollout))),
+		"mandatory=" + strconv.FormatBool(u.Mandatory),
+		"disabled=" + strconv.FormatBool(u.Disabled),
+	}
+	if u.Hash != "" {
+		details = append(details, "hash="+u.Hash)
+	}
+	if u.Description != "" {
+		details = append(details, "description="+u.Description)
+	}
+	entry := AuditEntry{
+		Time:     u.CreatedAt,
+		Action:   AuditActionRelease,
+		Label:    u.Label,
+		UpdateID: u.ID,
+		Details:  strings.Join(details, "; "),
+	}
+	entry.Actor, entry.ActorEmail = auditActor(u.CreatedBy)
+	return entry
+}
+
+func eventAuditEntry(e DeploymentEvent) AuditEntry {
+	details := make([]string, 0, len(e.Changes))
+	for _, key := range slices.Sorted(maps.Keys(e.Changes)) {
+		details = append(details, fmt.Sprintf("%s=%v", key, e.Changes[key]))
+	}
+	entry := AuditEntry{
+		Time:     e.CreatedAt,
+		Action:   e.Type,
+		Label:    e.Label,
+		UpdateID: e.UpdateID,
+		Details:  strings.Join(details, "; "),
+	}
+	entry.Actor, entry.ActorEmail = auditActor(e.CreatedBy)
+	return entry
+}
+
+func auditActor(c *UpdateCreator) (name, email string) {
+	if c == nil {
+		return "", ""
+	}
+	name = c.Username
+	if name == "" {
+		name = c.Email
+	}
+	return name, c.Email
+}
+
+// WriteAuditCSV writes the trail as CSV with a header row. Every row names
+// the deployment, so trails of several deployments can be concatenated.
+func WriteAuditCSV(w io.Writer, trail *AuditTrail) error {
+	cw := csv.NewWriter(w)
+	_ = cw.Write([]string{"time", "deployment", "action", "label", "package_id", "actor", "actor_email", "details"})
+	for _, e := range trail.Entries {
+		_ = cw.Write([]string{e.Time, trail.Deployment, e.Action, e.Label, e.UpdateID, e.Actor, e.ActorEmail, e.Details})
+	}
+	cw.Flush()
+	return cw.Error()
+}
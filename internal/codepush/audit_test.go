@@ -0,0 +1,70 @@
+package codepush
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuildAuditTrail(t *testing.T) {
+	updates := []Update{
+		{ID: "u1", Label: "v1", AppVersion: "1.0.0", Rollout: 100, CreatedAt: "2026-01-01T10:00:00Z", CreatedBy: &UpdateCreator{Username: "alice", Email: "alice@example.com"}},
+		{ID: "u2", Label: "v2", AppVersion: "1.0.0", Rollout: 25, Mandatory: true, Hash: "abc", Description: "Fix login", CreatedAt: "2026-01-03T10:00:00Z", CreatedBy: &UpdateCreator{Email: "ci@example.com"}},
+	}
+	newClient := func(events []DeploymentEvent, eventsErr error) *mockClient {
+		return &mockClient{
+			getDeploymentFunc: func(_, _ string) (*Deployment, error) { return &Deployment{ID: "dep-1", Name: "Production"}, nil },
+			listUpdatesFunc:   func(_, _ string) ([]Update, error) { return updates, nil },
+			listEventsFunc:    func(_, _ string) ([]DeploymentEvent, error) { return events, eventsErr },
+		}
+	}
+
+	t.Run("merges releases and events by time", func(t *testing.T) {
+		events := []DeploymentEvent{
+			{Type: "patch", UpdateID: "u1", CreatedAt: "2026-01-02T10:00:00Z", CreatedBy: &UpdateCreator{Username: "bob", Email: "bob@example.com"}, Changes: map[string]any{"rollout": 50, "disabled": true}},
+			{Type: "delete", UpdateID: "gone", Label: "v0", CreatedAt: "2026-01-04T10:00:00Z"},
+		}
+		trail, err := BuildAuditTrail(context.Background(), newClient(events, nil), "app-1", "dep-1")
+		require.NoError(t, err)
+
+		assert.Equal(t, "Production", trail.Deployment)
+		assert.True(t, trail.EventsAvailable)
+		assert.Equal(t, []AuditEntry{
+			{Time: "2026-01-01T10:00:00Z", Action: AuditActionRelease, Label: "v1", UpdateID: "u1", Actor: "alice", ActorEmail: "alice@example.com", Details: "app_version=1.0.0; rollout=100; mandatory=false; disabled=false"},
+			{Time: "2026-01-02T10:00:00Z", Action: "patch", Label: "v1", UpdateID: "u1", Actor: "bob", ActorEmail: "bob@example.com", Details: "disabled=true; rollout=50"},
+			{Time: "2026-01-03T10:00:00Z", Action: AuditActionRelease, Label: "v2", UpdateID: "u2", Actor: "ci@example.com", ActorEmail: "ci@example.com", Details: "app_version=1.0.0; rollout=25; mandatory=true; disabled=false; hash=abc; description=Fix login"},
+			{Time: "2026-01-04T10:00:00Z", Action: "delete", Label: "v0", UpdateID: "gone"},
+		}, trail.Entries)
+	})
+
+	t.Run("lists only releases when events are unavailable", func(t *testing.T) {
+		trail, err := BuildAuditTrail(context.Background(), newClient(nil, fmt.Errorf("listing deployment events: %w", ErrEventsUnavailable)), "app-1", "dep-1")
+		require.NoError(t, err)
+		assert.False(t, trail.EventsAvailable)
+		assert.Len(t, trail.Entries, 2)
+	})
+
+	t.Run("fails on other event errors", func(t *testing.T) {
+		_, err := BuildAuditTrail(context.Background(), newClient(nil, errors.New("boom")), "app-1", "dep-1")
+		assert.ErrorContains(t, err, "boom")
+	})
+}
+
+func TestWriteAuditCSV(t *testing.T) {
+	trail := &AuditTrail{
+		Deployment: "Production",
+		Entries: []AuditEntry{
+			{Time: "2026-01-01T10:00:00Z", Action: AuditActionRelease, Label: "v1", UpdateID: "u1", Actor: "alice", ActorEmail: "alice@example.com", Details: "description=Fixes, \"quoted\""},
+		},
+	}
+
+	var buf bytes.Buffer
+	require.NoError(t, WriteAuditCSV(&buf, trail))
+	assert.Equal(t, "time,deployment,action,label,package_id,actor,actor_email,details\n"+
+		"2026-01-01T10:00:00Z,Production,release,v1,u1,alice,alice@example.com,\"description=Fixes, \"\"quoted\"\"\"\n", buf.String())
+}
@@ -0,0 +1,62 @@
+package codepush
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+
+	"github.com/bitrise-io/bitrise-plugins-codepush-cli/internal/output"
+)
+
+// DefaultClearParallelism is the number of updates ClearDeployment deletes
+// at once on servers without the bulk delete endpoint.
+const DefaultClearParallelism = 8
+
+// ClearDeployment deletes all updates of a deployment. It uses the API's
+// bulk endpoint, which deletes them in one request. Servers without the
+// endpoint get one request per update in updates, parallelism at a time
+// (DefaultClearParallelism when not positive), with a progress indicator.
+//
+// A failed delete does not stop the others. It returns the number of
+// updates deleted, and an error naming the first update, in list order,
+// that could not be deleted.
+func ClearDeployment(ctx context.Context, client Client, appID, deploymentID string, updates []Update, parallelism int, out *output.Writer) (int, error) {
+	n, err := client.ClearPackages(ctx, appID, deploymentID)
+	switch statusCode(err) {
+	case http.StatusNotFound, http.StatusMethodNotAllowed, http.StatusNotImplemented:
+	default:
+		return n, err
+	}
+	if parallelism <= 0 {
+		parallelism = DefaultClearParallelism
+	}
+
+	var (
+		mu      sync.Mutex
+		deleted int
+		errs    = make([]error, len(updates))
+	)
+	progress := out.NewProgress("Deleting releases")
+	forEachParallel(len(updates), parallelism, func(i int) {
+		err := client.DeleteUpdate(ctx, appID, deploymentID, updates[i].ID)
+
+		mu.Lock()
+		defer mu.Unlock()
+		if err != nil {
+			errs[i] = err
+			return
+		}
+		deleted++
+		progress.Update(float64(deleted)/float64(len(updates))*100, fmt.Sprintf("%d/%d", deleted, len(updates)))
+	})
+
+	for i, err := range errs {
+		if err != nil {
+			progress.Cancel()
+			return deleted, fmt.Errorf("deleted %d of %d updates: deleting update %s: %w", deleted, len(updates), updates[i].Label, err)
+		}
+	}
+	progress.Done(fmt.Sprintf("%d/%d", deleted, len(updates)))
+	return deleted, nil
+}
@@ -0,0 +1,72 @@
+package codepush
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"slices"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClearDeployment(t *testing.T) {
+	updates := []Update{{ID: "pkg-1", Label: "v1"}, {ID: "pkg-2", Label: "v2"}, {ID: "pkg-3", Label: "v3"}}
+
+	tests := []struct {
+		name        string
+		bulkErr     error
+		failing     string
+		wantN       int
+		wantDeleted []string
+		wantErr     string
+	}{
+		{name: "bulk endpoint", wantN: 3},
+		{name: "falls back without bulk endpoint", bulkErr: &APIError{StatusCode: http.StatusMethodNotAllowed}, wantN: 3, wantDeleted: []string{"pkg-1", "pkg-2", "pkg-3"}},
+		{name: "falls back on not found", bulkErr: &APIError{StatusCode: http.StatusNotFound}, wantN: 3, wantDeleted: []string{"pkg-1", "pkg-2", "pkg-3"}},
+		{name: "returns other bulk errors", bulkErr: &APIError{StatusCode: http.StatusForbidden}, wantErr: "HTTP 403"},
+		{
+			name:        "continues after a failed delete",
+			bulkErr:     &APIError{StatusCode: http.StatusMethodNotAllowed},
+			failing:     "pkg-2",
+			wantN:       2,
+			wantDeleted: []string{"pkg-1", "pkg-3"},
+			wantErr:     "deleted 2 of 3 updates: deleting update v2: HTTP 500",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var mu sync.Mutex
+			var deleted []string
+			client := &mockClient{
+				clearPackagesFunc: func(appID, deploymentID string) (int, error) {
+					if tt.bulkErr != nil {
+						return 0, tt.bulkErr
+					}
+					return len(updates), nil
+				},
+				deleteUpdateFunc: func(appID, deploymentID, updateID string) error {
+					if updateID == tt.failing {
+						return errors.New("HTTP 500")
+					}
+					mu.Lock()
+					defer mu.Unlock()
+					deleted = append(deleted, updateID)
+					return nil
+				},
+			}
+
+			n, err := ClearDeployment(context.Background(), client, "app-1", "dep-1", updates, 2, testOut)
+			if tt.wantErr != "" {
+				require.ErrorContains(t, err, tt.wantErr)
+			} else {
+				require.NoError(t, err)
+			}
+			assert.Equal(t, tt.wantN, n)
+			slices.Sort(deleted)
+			assert.Equal(t, tt.wantDeleted, deleted)
+		})
+	}
+}
@@ -7,15 +7,18 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"iter"
 	"net/http"
 	"net/url"
 	"strconv"
-	"strings"
+
+	"github.com/bitrise-io/bitrise-plugins-codepush-cli/internal/transport"
 )
 
-// ErrDuplicateRelease is returned by Promote when the target deployment already
-// contains a release with identical content. Use errors.Is to detect it and
-// implement --no-duplicate-release-error behaviour.
+// ErrDuplicateRelease matches an APIError for a request the server rejected
+// because the target deployment already contains a release with identical
+// content. Use errors.Is to detect it and implement
+// --no-duplicate-release-error behaviour.
 //
 // NOTE: detection relies on the server's current error message text. If the
 // server team changes the message in internal/service/promote.go, this
@@ -25,23 +28,109 @@ var ErrDuplicateRelease = errors.New("duplicate release")
 
 // HTTPClient implements Client using net/http.
 type HTTPClient struct {
-	BaseURL string
-	Token   string
-	version string
-	client  *http.Client
+	BaseURL     string
+	Token       string
+	version     string
+	client      *http.Client
+	retry       RetryConfig
+	uploadRetry RetryConfig
+	limiter     *RateLimiter
+}
+
+// ClientOption configures an HTTPClient created by NewHTTPClient.
+type ClientOption func(*HTTPClient)
+
+// WithHTTPClient sends the client's requests, including storage uploads
+// and downloads, with hc. The default is transport.Client().
+func WithHTTPClient(hc *http.Client) ClientOption {
+	return func(c *HTTPClient) { c.client = hc }
+}
+
+// WithRetry sets how API requests are retried on transient failures. The
+// default is DefaultAPIRetryConfig.
+func WithRetry(cfg RetryConfig) ClientOption {
+	return func(c *HTTPClient) { c.retry = cfg }
+}
+
+// WithUploadRetry sets how each upload request of a push is retried on
+// transient failures. The default is DefaultUploadRetryConfig.
+func WithUploadRetry(cfg RetryConfig) ClientOption {
+	return func(c *HTTPClient) { c.uploadRetry = cfg }
+}
+
+// WithRateLimiter paces the client's requests with l. Without it requests
+// are not limited.
+func WithRateLimiter(l *RateLimiter) ClientOption {
+	return func(c *HTTPClient) { c.limiter = l }
 }
 
 // NewHTTPClient creates a new HTTPClient.
-func NewHTTPClient(baseURL, token, version string) *HTTPClient {
+func NewHTTPClient(baseURL, token, version string, opts ...ClientOption) *HTTPClient {
 	if version == "" {
 		version = "unknown"
 	}
-	return &HTTPClient{
-		BaseURL: baseURL,
-		Token:   token,
-		version: version,
-		client:  &http.Client{},
+	c := &HTTPClient{
+		BaseURL:     baseURL,
+		Token:       token,
+		version:     version,
+		client:      transport.Client(),
+		retry:       DefaultAPIRetryConfig,
+		uploadRetry: DefaultUploadRetryConfig,
+	}
+	for _, opt := range opts {
+		opt(c)
 	}
+	return c
+}
+
+// ListApps returns the release management apps the token can access.
+func (c *HTTPClient) ListApps(ctx context.Context) ([]App, error) {
+	resp, err := c.doRequest(ctx, http.MethodGet, "/connected-apps")
+	if err != nil {
+		return nil, err
+	}
+
+	var result AppListResponse
+	if err := decodeResponse(resp, &result); err != nil {
+		return nil, fmt.Errorf("listing apps: %w", err)
+	}
+
+	return result.Items, nil
+}
+
+// ListWorkspaceApps returns the release management apps of the Bitrise
+// workspace with the given slug that the token can access. An empty
+// workspace lists the apps of all workspaces, like ListApps.
+func (c *HTTPClient) ListWorkspaceApps(ctx context.Context, workspace string) ([]App, error) {
+	if workspace == "" {
+		return c.ListApps(ctx)
+	}
+	resp, err := c.doRequest(ctx, http.MethodGet, "/connected-apps?"+url.Values{"workspace_slug": {workspace}}.Encode())
+	if err != nil {
+		return nil, err
+	}
+
+	var result AppListResponse
+	if err := decodeResponse(resp, &result); err != nil {
+		return nil, fmt.Errorf("listing apps of workspace %s: %w", workspace, err)
+	}
+
+	return result.Items, nil
+}
+
+// GetApp returns a single release management app by ID.
+func (c *HTTPClient) GetApp(ctx context.Context, appID string) (*App, error) {
+	resp, err := c.doRequest(ctx, http.MethodGet, "/connected-apps/"+appID)
+	if err != nil {
+		return nil, err
+	}
+
+	var result App
+	if err := decodeResponse(resp, &result); err != nil {
+		return nil, fmt.Errorf("getting app: %w", err)
+	}
+
+	return &result, nil
 }
 
 // ListDeployments returns all deployments for the release management app.
@@ -79,6 +168,7 @@ func (c *HTTPClient) CreateDeployment(ctx context.Context, appID string, req Cre
 }
 
 // GetDeployment returns a single deployment by ID.
+// Returns ErrDeploymentNotFound (wrapped) when the app has no such deployment.
 func (c *HTTPClient) GetDeployment(ctx context.Context, appID, deploymentID string) (*Deployment, error) {
 	path := fmt.Sprintf("/connected-apps/%s/code-push/deployments/%s", appID, deploymentID)
 
@@ -89,12 +179,32 @@ func (c *HTTPClient) GetDeployment(ctx context.Context, appID, deploymentID stri
 
 	var result Deployment
 	if err := decodeResponse(resp, &result); err != nil {
+		if IsNotFound(err) {
+			return nil, fmt.Errorf("getting deployment: %w: %w", ErrDeploymentNotFound, err)
+		}
 		return nil, fmt.Errorf("getting deployment: %w", err)
 	}
 
 	return &result, nil
 }
 
+// GetDeploymentMetrics returns per-update install statistics for a deployment.
+func (c *HTTPClient) GetDeploymentMetrics(ctx context.Context, appID, deploymentID string) ([]UpdateMetrics, error) {
+	path := fmt.Sprintf("/connected-apps/%s/code-push/deployments/%s/metrics", appID, deploymentID)
+
+	resp, err := c.doRequest(ctx, http.MethodGet, path)
+	if err != nil {
+		return nil, err
+	}
+
+	var result DeploymentMetricsResponse
+	if err := decodeResponse(resp, &result); err != nil {
+		return nil, fmt.Errorf("getting deployment metrics: %w", err)
+	}
+
+	return result.Items, nil
+}
+
 // RenameDeployment renames an existing deployment.
 func (c *HTTPClient) RenameDeployment(ctx context.Context, appID, deploymentID string, req RenameDeploymentRequest) (*Deployment, error) {
 	path := fmt.Sprintf("/connected-apps/%s/code-push/deployments/%s", appID, deploymentID)
@@ -149,6 +259,32 @@ func (c *HTTPClient) GetUploadURL(ctx context.Context, appID, deploymentID, upda
 	if req.Rollout >= 0 && req.Rollout <= 100 {
 		params.Set("rollout", strconv.Itoa(req.Rollout))
 	}
+	if req.Multipart {
+		params.Set("multipart", "true")
+	}
+	if req.RuntimeVersion != "" {
+		params.Set("runtime_version", req.RuntimeVersion)
+	}
+	if req.DiffAgainst != "" {
+		params.Set("diff_against", req.DiffAgainst)
+	}
+	req.Targeting.setParams(params)
+	if p := req.Provenance; p != nil {
+		for key, value := range map[string]string{
+			"build_number": p.BuildNumber,
+			"build_url":    p.BuildURL,
+			"commit_hash":  p.CommitHash,
+			"branch":       p.Branch,
+			"workflow":     p.Workflow,
+		} {
+			if value != "" {
+				params.Set(key, value)
+			}
+		}
+		if p.Dirty {
+			params.Set("dirty", "true")
+		}
+	}
 
 	fullPath := path + "?" + params.Encode()
 
@@ -187,7 +323,65 @@ func (c *HTTPClient) UploadFile(ctx context.Context, ufr UploadFileRequest) erro
 
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
 		respBody, _ := io.ReadAll(resp.Body)
-		return fmt.Errorf("upload failed with HTTP %d: %s", resp.StatusCode, string(respBody))
+		return &uploadStatusError{StatusCode: resp.StatusCode, Body: string(respBody)}
+	}
+
+	return nil
+}
+
+func (c *HTTPClient) uploadRetryConfig() RetryConfig {
+	return c.uploadRetry
+}
+
+// GetDownloadURL requests a signed download URL for an existing update's package.
+func (c *HTTPClient) GetDownloadURL(ctx context.Context, appID, deploymentID, updateID string) (*DownloadURLResponse, error) {
+	path := fmt.Sprintf("/connected-apps/%s/code-push/deployments/%s/packages/%s/download-url",
+		appID, deploymentID, updateID)
+
+	resp, err := c.doRequest(ctx, http.MethodGet, path)
+	if err != nil {
+		return nil, err
+	}
+
+	var result DownloadURLResponse
+	if err := decodeResponse(resp, &result); err != nil {
+		return nil, fmt.Errorf("getting download URL: %w", err)
+	}
+
+	return &result, nil
+}
+
+// uploadStatusError is returned by UploadFile when storage rejects the upload.
+type uploadStatusError struct {
+	StatusCode int
+	Body       string
+}
+
+func (e *uploadStatusError) Error() string {
+	return fmt.Sprintf("upload failed with HTTP %d: %s", e.StatusCode, e.Body)
+}
+
+// DownloadFile streams the file at the signed URL into w.
+func (c *HTTPClient) DownloadFile(ctx context.Context, fileURL string, w io.Writer) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, fileURL, nil)
+	if err != nil {
+		return fmt.Errorf("creating download request: %w", err)
+	}
+	req.Header.Set("X-Bitrise-User-Agent", "codepush-cli/"+c.version)
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("downloading file: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		respBody, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("download failed with HTTP %d: %s", resp.StatusCode, string(respBody))
+	}
+
+	if _, err := io.Copy(w, resp.Body); err != nil {
+		return fmt.Errorf("downloading file: %w", err)
 	}
 
 	return nil
@@ -211,10 +405,110 @@ func (c *HTTPClient) GetUpdateStatus(ctx context.Context, appID, deploymentID, u
 	return &result, nil
 }
 
-// ListUpdates returns all updates for a deployment.
+// ListUpdateLogs returns the server-side processing log of an update.
+// Returns ErrLogsUnavailable (wrapped) when the server does not expose logs.
+func (c *HTTPClient) ListUpdateLogs(ctx context.Context, appID, deploymentID, updateID string) ([]UpdateLogEntry, error) {
+	path := fmt.Sprintf("/connected-apps/%s/code-push/deployments/%s/packages/%s/logs",
+		appID, deploymentID, updateID)
+
+	resp, err := c.doRequest(ctx, http.MethodGet, path)
+	if err != nil {
+		return nil, err
+	}
+
+	var result UpdateLogListResponse
+	if err := decodeResponse(resp, &result); err != nil {
+		if IsNotFound(err) {
+			return nil, fmt.Errorf("listing update logs: %w", ErrLogsUnavailable)
+		}
+		return nil, fmt.Errorf("listing update logs: %w", err)
+	}
+
+	return result.Items, nil
+}
+
+// ListDeploymentEvents returns the recorded changes to a deployment and its
+// releases, oldest first. Returns ErrEventsUnavailable (wrapped) when the
+// server does not expose events.
+func (c *HTTPClient) ListDeploymentEvents(ctx context.Context, appID, deploymentID string) ([]DeploymentEvent, error) {
+	path := fmt.Sprintf("/connected-apps/%s/code-push/deployments/%s/events", appID, deploymentID)
+
+	resp, err := c.doRequest(ctx, http.MethodGet, path)
+	if err != nil {
+		return nil, err
+	}
+
+	var result DeploymentEventListResponse
+	if err := decodeResponse(resp, &result); err != nil {
+		if IsNotFound(err) {
+			return nil, fmt.Errorf("listing deployment events: %w", ErrEventsUnavailable)
+		}
+		return nil, fmt.Errorf("listing deployment events: %w", err)
+	}
+
+	return result.Items, nil
+}
+
+// ListUpdates returns all updates for a deployment, fetching every page.
 func (c *HTTPClient) ListUpdates(ctx context.Context, appID, deploymentID string) ([]Update, error) {
-	path := fmt.Sprintf("/connected-apps/%s/code-push/deployments/%s/packages", appID, deploymentID)
+	var updates []Update
+	for u, err := range c.Updates(ctx, appID, deploymentID) {
+		if err != nil {
+			return nil, err
+		}
+		updates = append(updates, u)
+	}
+	return updates, nil
+}
 
+// Updates iterates over the updates of a deployment in server order,
+// requesting the next page only when the previous one is consumed. On
+// failure it yields the error once and stops.
+func (c *HTTPClient) Updates(ctx context.Context, appID, deploymentID string) iter.Seq2[Update, error] {
+	return func(yield func(Update, error) bool) {
+		path := fmt.Sprintf("/connected-apps/%s/code-push/deployments/%s/packages", appID, deploymentID)
+		seen := map[string]bool{}
+		pagePath := path
+		for {
+			page, err := c.listUpdatesPage(ctx, pagePath)
+			if err != nil {
+				yield(Update{}, err)
+				return
+			}
+			for _, u := range page.Items {
+				if !yield(u, nil) {
+					return
+				}
+			}
+
+			params := page.Paging.nextPageParams()
+			if params == nil {
+				return
+			}
+			query := params.Encode()
+			if seen[query] {
+				yield(Update{}, fmt.Errorf("listing updates: server returned page %q twice", query))
+				return
+			}
+			seen[query] = true
+			pagePath = path + "?" + query
+		}
+	}
+}
+
+// nextPageParams returns the query parameters that request the page after
+// p, or nil on the last page.
+func (p Paging) nextPageParams() url.Values {
+	switch {
+	case p.Next != "":
+		return url.Values{"next": {p.Next}}
+	case p.Page > 0 && p.Page < p.TotalPages:
+		return url.Values{"page": {strconv.Itoa(p.Page + 1)}}
+	}
+	return nil
+}
+
+func (c *HTTPClient) listUpdatesPage(ctx context.Context, path string) (*UpdateListResponse, error) {
 	resp, err := c.doRequest(ctx, http.MethodGet, path)
 	if err != nil {
 		return nil, err
@@ -224,8 +518,7 @@ func (c *HTTPClient) ListUpdates(ctx context.Context, appID, deploymentID string
 	if err := decodeResponse(resp, &result); err != nil {
 		return nil, fmt.Errorf("listing updates: %w", err)
 	}
-
-	return result.Items, nil
+	return &result, nil
 }
 
 // GetUpdate returns a single update by ID.
@@ -281,6 +574,25 @@ func (c *HTTPClient) DeleteUpdate(ctx context.Context, appID, deploymentID, upda
 	return nil
 }
 
+// ClearPackages deletes all updates of a deployment in one request and
+// returns how many were deleted. Servers without the bulk endpoint answer
+// HTTP 404 or 405; ClearDeployment falls back to DeleteUpdate then.
+func (c *HTTPClient) ClearPackages(ctx context.Context, appID, deploymentID string) (int, error) {
+	path := fmt.Sprintf("/connected-apps/%s/code-push/deployments/%s/packages", appID, deploymentID)
+
+	resp, err := c.doRequest(ctx, http.MethodDelete, path)
+	if err != nil {
+		return 0, err
+	}
+
+	var result DeleteUpdatesResponse
+	if err := decodeResponse(resp, &result); err != nil {
+		return 0, fmt.Errorf("deleting updates: %w", err)
+	}
+
+	return result.Deleted, nil
+}
+
 // Rollback sends a rollback request for a deployment.
 func (c *HTTPClient) Rollback(ctx context.Context, appID, deploymentID string, req RollbackRequest) (*Update, error) {
 	path := fmt.Sprintf("/connected-apps/%s/code-push/deployments/%s/rollback", appID, deploymentID)
@@ -309,15 +621,6 @@ func (c *HTTPClient) Promote(ctx context.Context, appID, deploymentID string, re
 		return nil, err
 	}
 
-	if resp.StatusCode == http.StatusBadRequest {
-		body, _ := io.ReadAll(resp.Body)
-		_ = resp.Body.Close()
-		if strings.Contains(string(body), "ERR_BAD_REQUEST") && strings.Contains(string(body), "identical to the contents") {
-			return nil, fmt.Errorf("promoting deployment: %w", ErrDuplicateRelease)
-		}
-		return nil, fmt.Errorf("promoting deployment: API returned HTTP 400: %s", string(body))
-	}
-
 	var result Update
 	if err := decodeResponse(resp, &result); err != nil {
 		return nil, fmt.Errorf("promoting deployment: %w", err)
@@ -327,29 +630,33 @@ func (c *HTTPClient) Promote(ctx context.Context, appID, deploymentID string, re
 }
 
 func (c *HTTPClient) doJSONRequest(ctx context.Context, method, path string, body any) (*http.Response, error) {
-	var bodyReader io.Reader
+	var data []byte
 	if body != nil {
-		data, err := json.Marshal(body)
-		if err != nil {
+		var err error
+		if data, err = json.Marshal(body); err != nil {
 			return nil, fmt.Errorf("marshaling request body: %w", err)
 		}
-		bodyReader = bytes.NewReader(data)
 	}
 
 	reqURL := c.BaseURL + path
-	req, err := http.NewRequestWithContext(ctx, method, reqURL, bodyReader)
-	if err != nil {
-		return nil, fmt.Errorf("creating request: %w", err)
-	}
-
-	req.Header.Set("Authorization", c.Token)
-	req.Header.Set("Accept", "application/json")
-	req.Header.Set("X-Bitrise-User-Agent", "codepush-cli/"+c.version)
-	if body != nil {
-		req.Header.Set("Content-Type", "application/json")
-	}
+	resp, err := c.sendWithRetry(ctx, func() (*http.Request, error) {
+		var bodyReader io.Reader
+		if body != nil {
+			bodyReader = bytes.NewReader(data)
+		}
+		req, err := http.NewRequestWithContext(ctx, method, reqURL, bodyReader)
+		if err != nil {
+			return nil, fmt.Errorf("creating request: %w", err)
+		}
 
-	resp, err := c.client.Do(req)
+		req.Header.Set("Authorization", c.Token)
+		req.Header.Set("Accept", "application/json")
+		req.Header.Set("X-Bitrise-User-Agent", "codepush-cli/"+c.version)
+		if body != nil {
+			req.Header.Set("Content-Type", "application/json")
+		}
+		return req, nil
+	})
 	if err != nil {
 		return nil, fmt.Errorf("sending request to %s: %w", path, err)
 	}
@@ -358,23 +665,7 @@ func (c *HTTPClient) doJSONRequest(ctx context.Context, method, path string, bod
 }
 
 func (c *HTTPClient) doRequest(ctx context.Context, method, path string) (*http.Response, error) {
-	reqURL := c.BaseURL + path
-
-	req, err := http.NewRequestWithContext(ctx, method, reqURL, nil)
-	if err != nil {
-		return nil, fmt.Errorf("creating request: %w", err)
-	}
-
-	req.Header.Set("Authorization", c.Token)
-	req.Header.Set("Accept", "application/json")
-	req.Header.Set("X-Bitrise-User-Agent", "codepush-cli/"+c.version)
-
-	resp, err := c.client.Do(req)
-	if err != nil {
-		return nil, fmt.Errorf("sending request to %s: %w", path, err)
-	}
-
-	return resp, nil
+	return c.doJSONRequest(ctx, method, path, nil)
 }
 
 func decodeResponse(resp *http.Response, v any) error {
@@ -382,7 +673,7 @@ func decodeResponse(resp *http.Response, v any) error {
 
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
 		body, _ := io.ReadAll(resp.Body)
-		return fmt.Errorf("API returned HTTP %d: %s", resp.StatusCode, string(body))
+		return newAPIError(resp.StatusCode, body)
 	}
 
 	if v != nil {
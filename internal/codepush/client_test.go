@@ -3,6 +3,7 @@ package codepush
 import (
 	"context"
 	"encoding/json"
+	"fmt"
 	"io"
 	"net/http"
 	"net/http/httptest"
@@ -13,6 +14,69 @@ import (
 	"github.com/stretchr/testify/require"
 )
 
+func TestHTTPClientListApps(t *testing.T) {
+	t.Run("returns apps", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, "/connected-apps", r.URL.Path)
+
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(`{"items":[{"id":"app-1","store_app_name":"Acme","platform":"ios"}]}`))
+		}))
+		defer server.Close()
+
+		client := newTestClient(server.URL, "test-token", "test")
+		apps, err := client.ListApps(context.Background())
+		require.NoError(t, err)
+
+		require.Len(t, apps, 1)
+		assert.Equal(t, App{ID: "app-1", Name: "Acme", Platform: "ios"}, apps[0])
+	})
+
+	t.Run("handles HTTP error", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusForbidden)
+		}))
+		defer server.Close()
+
+		client := newTestClient(server.URL, "test-token", "test")
+		_, err := client.ListApps(context.Background())
+		require.Error(t, err)
+	})
+}
+
+func TestHTTPClientListWorkspaceApps(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/connected-apps", r.URL.Path)
+		assert.Equal(t, "acme-mobile", r.URL.Query().Get("workspace_slug"))
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"items":[{"id":"app-1","store_app_name":"Acme","platform":"ios","workspace_slug":"acme-mobile"}]}`))
+	}))
+	defer server.Close()
+
+	client := newTestClient(server.URL, "test-token", "test")
+	apps, err := client.ListWorkspaceApps(context.Background(), "acme-mobile")
+	require.NoError(t, err)
+
+	require.Len(t, apps, 1)
+	assert.Equal(t, "acme-mobile", apps[0].WorkspaceSlug)
+}
+
+func TestHTTPClientGetApp(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/connected-apps/app-1", r.URL.Path)
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"id":"app-1","store_app_name":"Acme","platform":"android","store_app_id":"com.acme"}`))
+	}))
+	defer server.Close()
+
+	client := newTestClient(server.URL, "test-token", "test")
+	app, err := client.GetApp(context.Background(), "app-1")
+	require.NoError(t, err)
+	assert.Equal(t, &App{ID: "app-1", Name: "Acme", Platform: "android", StoreAppID: "com.acme"}, app)
+}
+
 func TestHTTPClientListDeployments(t *testing.T) {
 	t.Run("returns deployments", func(t *testing.T) {
 		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -24,7 +88,7 @@ func TestHTTPClientListDeployments(t *testing.T) {
 		}))
 		defer server.Close()
 
-		client := NewHTTPClient(server.URL, "test-token", "test")
+		client := newTestClient(server.URL, "test-token", "test")
 		deployments, err := client.ListDeployments(context.Background(), "app-123")
 		require.NoError(t, err)
 
@@ -42,7 +106,7 @@ func TestHTTPClientListDeployments(t *testing.T) {
 		}))
 		defer server.Close()
 
-		client := NewHTTPClient(server.URL, "bad-token", "test")
+		client := newTestClient(server.URL, "bad-token", "test")
 		_, err := client.ListDeployments(context.Background(), "app-123")
 		require.Error(t, err)
 		assert.ErrorContains(t, err, "401")
@@ -55,7 +119,7 @@ func TestHTTPClientListDeployments(t *testing.T) {
 		}))
 		defer server.Close()
 
-		client := NewHTTPClient(server.URL, "test-token", "test")
+		client := newTestClient(server.URL, "test-token", "test")
 		deployments, err := client.ListDeployments(context.Background(), "app-123")
 		require.NoError(t, err)
 		assert.Empty(t, deployments)
@@ -78,7 +142,7 @@ func TestHTTPClientCreateDeployment(t *testing.T) {
 		}))
 		defer server.Close()
 
-		client := NewHTTPClient(server.URL, "test-token", "test")
+		client := newTestClient(server.URL, "test-token", "test")
 		dep, err := client.CreateDeployment(context.Background(), "app-123", CreateDeploymentRequest{Name: "QA"})
 		require.NoError(t, err)
 
@@ -93,10 +157,12 @@ func TestHTTPClientCreateDeployment(t *testing.T) {
 		}))
 		defer server.Close()
 
-		client := NewHTTPClient(server.URL, "test-token", "test")
+		client := newTestClient(server.URL, "test-token", "test")
 		_, err := client.CreateDeployment(context.Background(), "app-123", CreateDeploymentRequest{Name: "QA"})
 		require.Error(t, err)
 		assert.ErrorContains(t, err, "409")
+		assert.True(t, IsConflict(err))
+		assert.NotErrorIs(t, err, ErrDuplicateRelease)
 	})
 }
 
@@ -111,7 +177,7 @@ func TestHTTPClientGetDeployment(t *testing.T) {
 		}))
 		defer server.Close()
 
-		client := NewHTTPClient(server.URL, "test-token", "test")
+		client := newTestClient(server.URL, "test-token", "test")
 		dep, err := client.GetDeployment(context.Background(), "app-123", "dep-456")
 		require.NoError(t, err)
 
@@ -131,7 +197,7 @@ func TestHTTPClientGetDeployment(t *testing.T) {
 		}))
 		defer server.Close()
 
-		client := NewHTTPClient(server.URL, "test-token", "test")
+		client := newTestClient(server.URL, "test-token", "test")
 		dep, err := client.GetDeployment(context.Background(), "app-123", "dep-456")
 		require.NoError(t, err)
 
@@ -146,13 +212,32 @@ func TestHTTPClientGetDeployment(t *testing.T) {
 		}))
 		defer server.Close()
 
-		client := NewHTTPClient(server.URL, "test-token", "test")
+		client := newTestClient(server.URL, "test-token", "test")
 		_, err := client.GetDeployment(context.Background(), "app-123", "dep-456")
 		require.Error(t, err)
 		assert.ErrorContains(t, err, "404")
+		assert.ErrorIs(t, err, ErrDeploymentNotFound)
+		assert.True(t, IsNotFound(err))
 	})
 }
 
+func TestHTTPClientGetDeploymentMetrics(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/connected-apps/app-123/code-push/deployments/dep-456/metrics", r.URL.Path)
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"items":[{"label":"v2","active":10,"downloaded":12,"installed":11,"failed":1}]}`))
+	}))
+	defer server.Close()
+
+	client := newTestClient(server.URL, "test-token", "test")
+	metrics, err := client.GetDeploymentMetrics(context.Background(), "app-123", "dep-456")
+	require.NoError(t, err)
+
+	require.Len(t, metrics, 1)
+	assert.Equal(t, UpdateMetrics{Label: "v2", Active: 10, Downloaded: 12, Installed: 11, Failed: 1}, metrics[0])
+}
+
 func TestHTTPClientRenameDeployment(t *testing.T) {
 	t.Run("renames deployment", func(t *testing.T) {
 		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -168,7 +253,7 @@ func TestHTTPClientRenameDeployment(t *testing.T) {
 		}))
 		defer server.Close()
 
-		client := NewHTTPClient(server.URL, "test-token", "test")
+		client := newTestClient(server.URL, "test-token", "test")
 		dep, err := client.RenameDeployment(context.Background(), "app-123", "dep-456", RenameDeploymentRequest{Name: "Pre-Production"})
 		require.NoError(t, err)
 
@@ -182,7 +267,7 @@ func TestHTTPClientRenameDeployment(t *testing.T) {
 		}))
 		defer server.Close()
 
-		client := NewHTTPClient(server.URL, "test-token", "test")
+		client := newTestClient(server.URL, "test-token", "test")
 		_, err := client.RenameDeployment(context.Background(), "app-123", "dep-456", RenameDeploymentRequest{Name: ""})
 		require.Error(t, err)
 		assert.ErrorContains(t, err, "400")
@@ -199,7 +284,7 @@ func TestHTTPClientDeleteDeployment(t *testing.T) {
 		}))
 		defer server.Close()
 
-		client := NewHTTPClient(server.URL, "test-token", "test")
+		client := newTestClient(server.URL, "test-token", "test")
 		err := client.DeleteDeployment(context.Background(), "app-123", "dep-456")
 		require.NoError(t, err)
 	})
@@ -211,7 +296,7 @@ func TestHTTPClientDeleteDeployment(t *testing.T) {
 		}))
 		defer server.Close()
 
-		client := NewHTTPClient(server.URL, "test-token", "test")
+		client := newTestClient(server.URL, "test-token", "test")
 		err := client.DeleteDeployment(context.Background(), "app-123", "dep-456")
 		require.Error(t, err)
 		assert.ErrorContains(t, err, "404")
@@ -236,7 +321,7 @@ func TestHTTPClientGetUploadURL(t *testing.T) {
 		}))
 		defer server.Close()
 
-		client := NewHTTPClient(server.URL, "test-token", "test")
+		client := newTestClient(server.URL, "test-token", "test")
 		resp, err := client.GetUploadURL(context.Background(), "app-123", "dep-456", "pkg-789", UploadURLRequest{
 			AppVersion:    "1.0.0",
 			FileName:      "bundle.zip",
@@ -250,6 +335,32 @@ func TestHTTPClientGetUploadURL(t *testing.T) {
 		assert.Equal(t, "PUT", resp.Method)
 	})
 
+	t.Run("sends targeting conditions", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			query := r.URL.Query()
+			assert.Equal(t, ">=14.0 <17", query.Get("target_os_version"))
+			assert.Equal(t, []string{"iPhone14,2", "iPhone15,3"}, query["target_device_models"])
+			assert.Equal(t, []string{"DE", "AT"}, query["target_countries"])
+
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(`{"url":"https://example.com/upload","method":"PUT","headers":{}}`))
+		}))
+		defer server.Close()
+
+		client := newTestClient(server.URL, "test-token", "test")
+		_, err := client.GetUploadURL(context.Background(), "app-123", "dep-456", "pkg-789", UploadURLRequest{
+			AppVersion:    "1.0.0",
+			FileName:      "bundle.zip",
+			FileSizeBytes: 512,
+			Targeting: Targeting{
+				OSVersion:    ">=14.0 <17",
+				DeviceModels: []string{"iPhone14,2", "iPhone15,3"},
+				Countries:    []string{"DE", "AT"},
+			},
+		})
+		require.NoError(t, err)
+	})
+
 	t.Run("sends rollout=100 for full rollout", func(t *testing.T) {
 		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			query := r.URL.Query()
@@ -262,7 +373,7 @@ func TestHTTPClientGetUploadURL(t *testing.T) {
 		}))
 		defer server.Close()
 
-		client := NewHTTPClient(server.URL, "test-token", "test")
+		client := newTestClient(server.URL, "test-token", "test")
 		_, err := client.GetUploadURL(context.Background(), "app-123", "dep-456", "pkg-789", UploadURLRequest{
 			AppVersion:    "1.0.0",
 			FileName:      "bundle.zip",
@@ -281,7 +392,7 @@ func TestHTTPClientGetUploadURL(t *testing.T) {
 		}))
 		defer server.Close()
 
-		client := NewHTTPClient(server.URL, "test-token", "test")
+		client := newTestClient(server.URL, "test-token", "test")
 		_, err := client.GetUploadURL(context.Background(), "app-123", "dep-456", "pkg-789", UploadURLRequest{
 			AppVersion:    "1.0.0",
 			FileName:      "bundle.zip",
@@ -291,6 +402,72 @@ func TestHTTPClientGetUploadURL(t *testing.T) {
 		require.NoError(t, err)
 	})
 
+	t.Run("requests multipart upload and decodes parts", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, "true", r.URL.Query().Get("multipart"))
+
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(`{"url":"https://example.com/upload","method":"PUT","headers":{},` +
+				`"parts":[{"url":"https://example.com/p1","method":"PUT","offset":0,"size":256},` +
+				`{"url":"https://example.com/p2","method":"PUT","offset":256,"size":256}]}`))
+		}))
+		defer server.Close()
+
+		client := newTestClient(server.URL, "test-token", "test")
+		resp, err := client.GetUploadURL(context.Background(), "app-123", "dep-456", "pkg-789", UploadURLRequest{
+			AppVersion:    "1.0.0",
+			FileName:      "bundle.zip",
+			FileSizeBytes: 512,
+			Multipart:     true,
+		})
+		require.NoError(t, err)
+		require.Len(t, resp.Parts, 2)
+		assert.Equal(t, int64(256), resp.Parts[1].Offset)
+	})
+
+	t.Run("sends build provenance", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			q := r.URL.Query()
+			assert.Equal(t, "123", q.Get("build_number"))
+			assert.Equal(t, "deadbeef", q.Get("commit_hash"))
+			assert.Equal(t, "main", q.Get("branch"))
+			assert.Equal(t, "true", q.Get("dirty"))
+			assert.False(t, q.Has("workflow"))
+
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(`{"url":"https://example.com/upload","method":"PUT","headers":{}}`))
+		}))
+		defer server.Close()
+
+		client := newTestClient(server.URL, "test-token", "test")
+		_, err := client.GetUploadURL(context.Background(), "app-123", "dep-456", "pkg-789", UploadURLRequest{
+			AppVersion:    "1.0.0",
+			FileName:      "bundle.zip",
+			FileSizeBytes: 512,
+			Provenance:    &Provenance{BuildNumber: "123", CommitHash: "deadbeef", Branch: "main", Dirty: true},
+		})
+		require.NoError(t, err)
+	})
+
+	t.Run("sends runtime version", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, "exposdk:50.0.0", r.URL.Query().Get("runtime_version"))
+
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(`{"url":"https://example.com/upload","method":"PUT","headers":{}}`))
+		}))
+		defer server.Close()
+
+		client := newTestClient(server.URL, "test-token", "test")
+		_, err := client.GetUploadURL(context.Background(), "app-123", "dep-456", "pkg-789", UploadURLRequest{
+			AppVersion:     "1.0.0",
+			FileName:       "bundle.zip",
+			FileSizeBytes:  512,
+			RuntimeVersion: "exposdk:50.0.0",
+		})
+		require.NoError(t, err)
+	})
+
 	t.Run("handles API error", func(t *testing.T) {
 		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			w.WriteHeader(http.StatusNotFound)
@@ -298,7 +475,7 @@ func TestHTTPClientGetUploadURL(t *testing.T) {
 		}))
 		defer server.Close()
 
-		client := NewHTTPClient(server.URL, "test-token", "test")
+		client := newTestClient(server.URL, "test-token", "test")
 		_, err := client.GetUploadURL(context.Background(), "app-123", "dep-456", "pkg-789", UploadURLRequest{
 			AppVersion:    "1.0.0",
 			FileName:      "bundle.zip",
@@ -323,7 +500,7 @@ func TestHTTPClientUploadFile(t *testing.T) {
 		}))
 		defer server.Close()
 
-		client := NewHTTPClient("", "test-token", "test")
+		client := newTestClient("", "test-token", "test")
 		err := client.UploadFile(context.Background(), UploadFileRequest{
 			URL:           server.URL,
 			Method:        http.MethodPut,
@@ -341,7 +518,7 @@ func TestHTTPClientUploadFile(t *testing.T) {
 		}))
 		defer server.Close()
 
-		client := NewHTTPClient("", "test-token", "test")
+		client := newTestClient("", "test-token", "test")
 		err := client.UploadFile(context.Background(), UploadFileRequest{
 			URL:           server.URL,
 			Method:        http.MethodPut,
@@ -364,7 +541,7 @@ func TestHTTPClientGetUpdateStatus(t *testing.T) {
 		}))
 		defer server.Close()
 
-		client := NewHTTPClient(server.URL, "test-token", "test")
+		client := newTestClient(server.URL, "test-token", "test")
 		status, err := client.GetUpdateStatus(context.Background(), "app-123", "dep-456", "pkg-789")
 		require.NoError(t, err)
 
@@ -379,7 +556,7 @@ func TestHTTPClientGetUpdateStatus(t *testing.T) {
 		}))
 		defer server.Close()
 
-		client := NewHTTPClient(server.URL, "test-token", "test")
+		client := newTestClient(server.URL, "test-token", "test")
 		status, err := client.GetUpdateStatus(context.Background(), "app-123", "dep-456", "pkg-789")
 		require.NoError(t, err)
 
@@ -394,13 +571,78 @@ func TestHTTPClientGetUpdateStatus(t *testing.T) {
 		}))
 		defer server.Close()
 
-		client := NewHTTPClient(server.URL, "test-token", "test")
+		client := newTestClient(server.URL, "test-token", "test")
 		_, err := client.GetUpdateStatus(context.Background(), "app-123", "dep-456", "pkg-789")
 		require.Error(t, err)
 		assert.ErrorContains(t, err, "500")
 	})
 }
 
+func TestHTTPClientListUpdateLogs(t *testing.T) {
+	t.Run("returns log entries", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			expectedPath := "/connected-apps/app-123/code-push/deployments/dep-456/packages/pkg-789/logs"
+			assert.Equal(t, expectedPath, r.URL.Path)
+
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(`{"items":[{"timestamp":"2026-01-01T00:00:00Z","level":"error","message":"main.jsbundle: unexpected token"}]}`))
+		}))
+		defer server.Close()
+
+		client := newTestClient(server.URL, "test-token", "test")
+		entries, err := client.ListUpdateLogs(context.Background(), "app-123", "dep-456", "pkg-789")
+		require.NoError(t, err)
+
+		require.Len(t, entries, 1)
+		assert.Equal(t, "error", entries[0].Level)
+		assert.Equal(t, "main.jsbundle: unexpected token", entries[0].Message)
+	})
+
+	t.Run("returns ErrLogsUnavailable on 404", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusNotFound)
+		}))
+		defer server.Close()
+
+		client := newTestClient(server.URL, "test-token", "test")
+		_, err := client.ListUpdateLogs(context.Background(), "app-123", "dep-456", "pkg-789")
+		require.Error(t, err)
+		assert.ErrorIs(t, err, ErrLogsUnavailable)
+	})
+}
+
+func TestHTTPClientListDeploymentEvents(t *testing.T) {
+	t.Run("returns events", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, "/connected-apps/app-123/code-push/deployments/dep-456/events", r.URL.Path)
+
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(`{"items":[{"id":"ev-1","type":"patch","package_id":"pkg-789","created_at":"2026-01-01T00:00:00Z","created_by":{"username":"alice"},"changes":{"rollout":50}}]}`))
+		}))
+		defer server.Close()
+
+		client := newTestClient(server.URL, "test-token", "test")
+		events, err := client.ListDeploymentEvents(context.Background(), "app-123", "dep-456")
+		require.NoError(t, err)
+
+		require.Len(t, events, 1)
+		assert.Equal(t, "patch", events[0].Type)
+		assert.Equal(t, "alice", events[0].CreatedBy.Username)
+		assert.Equal(t, map[string]any{"rollout": float64(50)}, events[0].Changes)
+	})
+
+	t.Run("returns ErrEventsUnavailable on 404", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusNotFound)
+		}))
+		defer server.Close()
+
+		client := newTestClient(server.URL, "test-token", "test")
+		_, err := client.ListDeploymentEvents(context.Background(), "app-123", "dep-456")
+		assert.ErrorIs(t, err, ErrEventsUnavailable)
+	})
+}
+
 func TestHTTPClientListUpdates(t *testing.T) {
 	t.Run("returns updates", func(t *testing.T) {
 		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -412,7 +654,7 @@ func TestHTTPClientListUpdates(t *testing.T) {
 		}))
 		defer server.Close()
 
-		client := NewHTTPClient(server.URL, "test-token", "test")
+		client := newTestClient(server.URL, "test-token", "test")
 		updates, err := client.ListUpdates(context.Background(), "app-123", "dep-456")
 		require.NoError(t, err)
 
@@ -428,7 +670,7 @@ func TestHTTPClientListUpdates(t *testing.T) {
 		}))
 		defer server.Close()
 
-		client := NewHTTPClient(server.URL, "test-token", "test")
+		client := newTestClient(server.URL, "test-token", "test")
 		updates, err := client.ListUpdates(context.Background(), "app-123", "dep-456")
 		require.NoError(t, err)
 		assert.Empty(t, updates)
@@ -441,11 +683,83 @@ func TestHTTPClientListUpdates(t *testing.T) {
 		}))
 		defer server.Close()
 
-		client := NewHTTPClient(server.URL, "test-token", "test")
+		client := newTestClient(server.URL, "test-token", "test")
 		_, err := client.ListUpdates(context.Background(), "app-123", "dep-456")
 		require.Error(t, err)
 		assert.ErrorContains(t, err, "404")
 	})
+
+	t.Run("follows next cursors", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, "/connected-apps/app-123/code-push/deployments/dep-456/packages", r.URL.Path)
+			w.Header().Set("Content-Type", "application/json")
+			switch r.URL.Query().Get("next") {
+			case "":
+				w.Write([]byte(`{"items":[{"id":"u1","label":"v1"}],"paging":{"next":"c2"}}`))
+			case "c2":
+				w.Write([]byte(`{"items":[{"id":"u2","label":"v2"}],"paging":{"next":"c3"}}`))
+			default:
+				w.Write([]byte(`{"items":[{"id":"u3","label":"v3"}],"paging":{}}`))
+			}
+		}))
+		defer server.Close()
+
+		client := newTestClient(server.URL, "test-token", "test")
+		updates, err := client.ListUpdates(context.Background(), "app-123", "dep-456")
+		require.NoError(t, err)
+		require.Len(t, updates, 3)
+		assert.Equal(t, "v3", updates[2].Label)
+	})
+
+	t.Run("follows page numbers", func(t *testing.T) {
+		var pages []string
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			page := r.URL.Query().Get("page")
+			pages = append(pages, page)
+			if page == "" {
+				page = "1"
+			}
+			w.Header().Set("Content-Type", "application/json")
+			fmt.Fprintf(w, `{"items":[{"id":"u%s"}],"paging":{"page":%s,"total_pages":2}}`, page, page)
+		}))
+		defer server.Close()
+
+		client := newTestClient(server.URL, "test-token", "test")
+		updates, err := client.ListUpdates(context.Background(), "app-123", "dep-456")
+		require.NoError(t, err)
+		require.Len(t, updates, 2)
+		assert.Equal(t, []string{"", "2"}, pages)
+	})
+
+	t.Run("stops when a cursor repeats", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(`{"items":[{"id":"u1"}],"paging":{"next":"same"}}`))
+		}))
+		defer server.Close()
+
+		client := newTestClient(server.URL, "test-token", "test")
+		_, err := client.ListUpdates(context.Background(), "app-123", "dep-456")
+		assert.ErrorContains(t, err, "twice")
+	})
+
+	t.Run("iterator fetches pages lazily", func(t *testing.T) {
+		requests := 0
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			requests++
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(`{"items":[{"id":"u1"},{"id":"u2"}],"paging":{"next":"more"}}`))
+		}))
+		defer server.Close()
+
+		client := newTestClient(server.URL, "test-token", "test")
+		for u, err := range client.Updates(context.Background(), "app-123", "dep-456") {
+			require.NoError(t, err)
+			assert.Equal(t, "u1", u.ID)
+			break
+		}
+		assert.Equal(t, 1, requests)
+	})
 }
 
 func TestHTTPClientGetUpdate(t *testing.T) {
@@ -460,7 +774,7 @@ func TestHTTPClientGetUpdate(t *testing.T) {
 		}))
 		defer server.Close()
 
-		client := NewHTTPClient(server.URL, "test-token", "test")
+		client := newTestClient(server.URL, "test-token", "test")
 		pkg, err := client.GetUpdate(context.Background(), "app-123", "dep-456", "pkg-789")
 		require.NoError(t, err)
 
@@ -477,7 +791,7 @@ func TestHTTPClientGetUpdate(t *testing.T) {
 		}))
 		defer server.Close()
 
-		client := NewHTTPClient(server.URL, "test-token", "test")
+		client := newTestClient(server.URL, "test-token", "test")
 		_, err := client.GetUpdate(context.Background(), "app-123", "dep-456", "pkg-789")
 		require.Error(t, err)
 		assert.ErrorContains(t, err, "404")
@@ -503,7 +817,7 @@ func TestHTTPClientPatchUpdate(t *testing.T) {
 
 		rollout := 50
 		mandatory := true
-		client := NewHTTPClient(server.URL, "test-token", "test")
+		client := newTestClient(server.URL, "test-token", "test")
 		pkg, err := client.PatchUpdate(context.Background(), "app-123", "dep-456", "pkg-789", PatchRequest{
 			Rollout:   &rollout,
 			Mandatory: &mandatory,
@@ -528,7 +842,7 @@ func TestHTTPClientPatchUpdate(t *testing.T) {
 		defer server.Close()
 
 		rollout := 50
-		client := NewHTTPClient(server.URL, "test-token", "test")
+		client := newTestClient(server.URL, "test-token", "test")
 		_, err := client.PatchUpdate(context.Background(), "app-123", "dep-456", "pkg-789", PatchRequest{
 			Rollout: &rollout,
 		})
@@ -543,7 +857,7 @@ func TestHTTPClientPatchUpdate(t *testing.T) {
 		defer server.Close()
 
 		rollout := 50
-		client := NewHTTPClient(server.URL, "test-token", "test")
+		client := newTestClient(server.URL, "test-token", "test")
 		_, err := client.PatchUpdate(context.Background(), "app-123", "dep-456", "pkg-789", PatchRequest{
 			Rollout: &rollout,
 		})
@@ -562,7 +876,7 @@ func TestHTTPClientDeleteUpdate(t *testing.T) {
 		}))
 		defer server.Close()
 
-		client := NewHTTPClient(server.URL, "test-token", "test")
+		client := newTestClient(server.URL, "test-token", "test")
 		err := client.DeleteUpdate(context.Background(), "app-123", "dep-456", "pkg-789")
 		require.NoError(t, err)
 	})
@@ -574,7 +888,7 @@ func TestHTTPClientDeleteUpdate(t *testing.T) {
 		}))
 		defer server.Close()
 
-		client := NewHTTPClient(server.URL, "test-token", "test")
+		client := newTestClient(server.URL, "test-token", "test")
 		err := client.DeleteUpdate(context.Background(), "app-123", "dep-456", "pkg-789")
 		require.Error(t, err)
 		assert.ErrorContains(t, err, "404")
@@ -598,7 +912,7 @@ func TestHTTPClientRollback(t *testing.T) {
 		}))
 		defer server.Close()
 
-		client := NewHTTPClient(server.URL, "test-token", "test")
+		client := newTestClient(server.URL, "test-token", "test")
 		pkg, err := client.Rollback(context.Background(), "app-123", "dep-456", RollbackRequest{UpdateID: "pkg-target"})
 		require.NoError(t, err)
 
@@ -616,7 +930,7 @@ func TestHTTPClientRollback(t *testing.T) {
 		}))
 		defer server.Close()
 
-		client := NewHTTPClient(server.URL, "test-token", "test")
+		client := newTestClient(server.URL, "test-token", "test")
 		_, err := client.Rollback(context.Background(), "app-123", "dep-456", RollbackRequest{})
 		require.NoError(t, err)
 	})
@@ -628,7 +942,7 @@ func TestHTTPClientRollback(t *testing.T) {
 		}))
 		defer server.Close()
 
-		client := NewHTTPClient(server.URL, "test-token", "test")
+		client := newTestClient(server.URL, "test-token", "test")
 		_, err := client.Rollback(context.Background(), "app-123", "dep-456", RollbackRequest{})
 		require.Error(t, err)
 		assert.ErrorContains(t, err, "404")
@@ -654,7 +968,7 @@ func TestHTTPClientPromote(t *testing.T) {
 		}))
 		defer server.Close()
 
-		client := NewHTTPClient(server.URL, "test-token", "test")
+		client := newTestClient(server.URL, "test-token", "test")
 		pkg, err := client.Promote(context.Background(), "app-123", "dep-src", PromoteRequest{
 			TargetDeploymentID: "dep-dst",
 			AppVersion:         "3.0.0",
@@ -679,7 +993,7 @@ func TestHTTPClientPromote(t *testing.T) {
 		}))
 		defer server.Close()
 
-		client := NewHTTPClient(server.URL, "test-token", "test")
+		client := newTestClient(server.URL, "test-token", "test")
 		_, err := client.Promote(context.Background(), "app-123", "dep-src", PromoteRequest{
 			TargetDeploymentID: "dep-dst",
 		})
@@ -693,7 +1007,7 @@ func TestHTTPClientPromote(t *testing.T) {
 		}))
 		defer server.Close()
 
-		client := NewHTTPClient(server.URL, "test-token", "test")
+		client := newTestClient(server.URL, "test-token", "test")
 		_, err := client.Promote(context.Background(), "app-123", "dep-src", PromoteRequest{TargetDeploymentID: "dep-dst"})
 		require.Error(t, err)
 		assert.ErrorContains(t, err, "409")
@@ -711,7 +1025,7 @@ func TestHTTPClientSetsUserAgent(t *testing.T) {
 		}))
 		defer server.Close()
 
-		client := NewHTTPClient(server.URL, "token", "1.2.3")
+		client := newTestClient(server.URL, "token", "1.2.3")
 		_, err := client.ListDeployments(context.Background(), "app-1")
 		require.NoError(t, err)
 	})
@@ -724,7 +1038,7 @@ func TestHTTPClientSetsUserAgent(t *testing.T) {
 		}))
 		defer server.Close()
 
-		client := NewHTTPClient(server.URL, "token", "1.2.3")
+		client := newTestClient(server.URL, "token", "1.2.3")
 		_, err := client.CreateDeployment(context.Background(), "app-1", CreateDeploymentRequest{Name: "QA"})
 		require.NoError(t, err)
 	})
@@ -736,7 +1050,7 @@ func TestHTTPClientSetsUserAgent(t *testing.T) {
 		}))
 		defer server.Close()
 
-		client := NewHTTPClient("", "token", "1.2.3")
+		client := newTestClient("", "token", "1.2.3")
 		err := client.UploadFile(context.Background(), UploadFileRequest{
 			URL:           server.URL,
 			Method:        http.MethodPut,
@@ -754,8 +1068,53 @@ func TestHTTPClientSetsUserAgent(t *testing.T) {
 		}))
 		defer server.Close()
 
-		client := NewHTTPClient(server.URL, "token", "")
+		client := newTestClient(server.URL, "token", "")
 		_, err := client.ListDeployments(context.Background(), "app-1")
 		require.NoError(t, err)
 	})
 }
+
+func TestHTTPClientGetDownloadURL(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/connected-apps/app-123/code-push/deployments/dep-456/packages/pkg-789/download-url", r.URL.Path)
+		assert.Equal(t, http.MethodGet, r.Method)
+		assert.Equal(t, "test-token", r.Header.Get("Authorization"))
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"url":"https://storage.example.com/pkg.zip?sig=abc"}`))
+	}))
+	defer server.Close()
+
+	client := newTestClient(server.URL, "test-token", "test")
+	resp, err := client.GetDownloadURL(context.Background(), "app-123", "dep-456", "pkg-789")
+	require.NoError(t, err)
+	assert.Equal(t, "https://storage.example.com/pkg.zip?sig=abc", resp.URL)
+}
+
+func TestHTTPClientDownloadFile(t *testing.T) {
+	t.Run("streams body into writer", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			assert.Empty(t, r.Header.Get("Authorization"), "signed URL must not receive the API token")
+			w.Write([]byte("zip-bytes"))
+		}))
+		defer server.Close()
+
+		client := newTestClient("http://unused", "test-token", "test")
+		var buf strings.Builder
+		require.NoError(t, client.DownloadFile(context.Background(), server.URL+"/pkg.zip", &buf))
+		assert.Equal(t, "zip-bytes", buf.String())
+	})
+
+	t.Run("returns error on HTTP failure", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusForbidden)
+			w.Write([]byte("expired"))
+		}))
+		defer server.Close()
+
+		client := newTestClient("http://unused", "test-token", "test")
+		err := client.DownloadFile(context.Background(), server.URL, io.Discard)
+		require.Error(t, err)
+		assert.ErrorContains(t, err, "HTTP 403")
+	})
+}
@@ -0,0 +1,168 @@
+package codepush
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/bitrise-io/bitrise-plugins-codepush-cli/internal/output"
+)
+
+// cloneClient is the subset of Client needed by CloneDeployment.
+type cloneClient interface {
+	deploymentLister
+	updateLister
+	CreateDeployment(ctx context.Context, appID string, req CreateDeploymentRequest) (*Deployment, error)
+	Promote(ctx context.Context, appID, deploymentID string, req PromoteRequest) (*Update, error)
+}
+
+// ClonedRelease is a source release copied into a cloned deployment.
+type ClonedRelease struct {
+	SourceLabel string `json:"source_label"`
+	// Label and UpdateID identify the copy; both are empty when the copy was
+	// skipped because the new deployment already had identical content.
+	Label    string `json:"label,omitempty"`
+	UpdateID string `json:"package_id,omitempty"`
+	Skipped  bool   `json:"skipped,omitempty"`
+}
+
+// CloneResult is the output of CloneDeployment.
+type CloneResult struct {
+	Deployment         *Deployment     `json:"deployment"`
+	SourceDeploymentID string          `json:"source_deployment_id"`
+	Releases           []ClonedRelease `json:"releases"`
+}
+
+// CloneDeployment creates a deployment and copies releases of the source
+// deployment into it with the promote API. It copies the latest release, or
+// the releases with the given labels in the order they were released in the
+// source, so the newest one is live in the new deployment.
+//
+// The copies keep the metadata of their source release. The releases are
+// selected before the deployment is created, so an unknown label changes
+// nothing. If a copy fails afterwards, the error names the
+// deployment that was created.
+func CloneDeployment(ctx context.Context, client cloneClient, appID string, req CreateDeploymentRequest, source string, labels []string, out *output.Writer) (*CloneResult, error) {
+	sourceID, err := ResolveDeployment(ctx, client, appID, source, out)
+	if err != nil {
+		return nil, fmt.Errorf("resolving source deployment: %w", err)
+	}
+	updates, err := client.ListUpdates(ctx, appID, sourceID)
+	if err != nil {
+		return nil, fmt.Errorf("listing releases of %s: %w", source, err)
+	}
+	selected, err := selectCloneReleases(updates, labels)
+	if err != nil {
+		return nil, Invalid(fmt.Errorf("%w in deployment %s", err, source))
+	}
+
+	step := out.StartStep("Creating deployment %q", req.Name)
+	dep, err := client.CreateDeployment(ctx, appID, req)
+	if err != nil {
+		step.Cancel()
+		return nil, fmt.Errorf("creating deployment: %w", err)
+	}
+	step.Done()
+
+	releases, err := copyReleases(ctx, client, &releaseCopy{
+		appID:      appID,
+		sourceID:   sourceID,
+		sourceName: source,
+		target:     dep,
+		updates:    selected,
+	}, out)
+	if err != nil {
+		return nil, fmt.Errorf("deployment %q was created, but %w", dep.Name, err)
+	}
+	return &CloneResult{Deployment: dep, SourceDeploymentID: sourceID, Releases: releases}, nil
+}
+
+// releaseCopier is the subset of Client needed by copyReleases.
+type releaseCopier interface {
+	Promote(ctx context.Context, appID, deploymentID string, req PromoteRequest) (*Update, error)
+}
+
+// releaseCopy describes the updates copyReleases copies from a source
+// deployment into a target deployment.
+type releaseCopy struct {
+	appID      string
+	sourceID   string
+	sourceName string
+	target     *Deployment
+	updates    []Update
+}
+
+// copyReleases promotes the updates of the source deployment into the
+// target in order, keeping their metadata. An update the server rejects as
+// identical to the previous copy is skipped with a warning.
+func copyReleases(ctx context.Context, client releaseCopier, c *releaseCopy, out *output.Writer) ([]ClonedRelease, error) {
+	var releases []ClonedRelease
+	for _, u := range c.updates {
+		step := out.StartStep("Copying %s from %s", u.Label, c.sourceName)
+		pkg, err := client.Promote(ctx, c.appID, c.sourceID, copyRequest(c.target.ID, u))
+		if errors.Is(err, ErrDuplicateRelease) {
+			step.Cancel()
+			out.Warning("%s skipped: its content is identical to the previous copied release", u.Label)
+			releases = append(releases, ClonedRelease{SourceLabel: u.Label, Skipped: true})
+			continue
+		}
+		if err != nil {
+			step.Cancel()
+			return releases, fmt.Errorf("copying %s failed: %w", u.Label, err)
+		}
+		step.Done()
+		releases = append(releases, ClonedRelease{SourceLabel: u.Label, Label: pkg.Label, UpdateID: pkg.ID})
+	}
+	return releases, nil
+}
+
+// copyRequest returns a promote request that copies u into the target
+// deployment with its app version, description, flags, and rollout.
+func copyRequest(targetDeploymentID string, u Update) PromoteRequest {
+	return PromoteRequest{
+		TargetDeploymentID: targetDeploymentID,
+		UpdateID:           u.ID,
+		AppVersion:         u.AppVersion,
+		Description:        u.Description,
+		Mandatory:          strconv.FormatBool(u.Mandatory),
+		Disabled:           strconv.FormatBool(u.Disabled),
+		Rollout:            strconv.Itoa(int(math.Round(u.Rollout))),
+	}
+}
+
+// selectCloneReleases returns the latest update, or the updates with the
+// given labels in their release order.
+func selectCloneReleases(updates []Update, labels []string) ([]Update, error) {
+	if len(updates) == 0 {
+		return nil, errors.New("no releases to clone")
+	}
+	if len(labels) == 0 {
+		return updates[len(updates)-1:], nil
+	}
+
+	wanted := make(map[string]bool, len(labels))
+	for _, label := range labels {
+		wanted[label] = true
+	}
+	var selected []Update
+	for _, u := range updates {
+		if wanted[u.Label] {
+			selected = append(selected, u)
+			delete(wanted, u.Label)
+		}
+	}
+	if len(wanted) > 0 {
+		var missing []string
+		for _, label := range labels {
+			if wanted[label] {
+				missing = append(missing, label)
+				delete(wanted, label)
+			}
+		}
+		return nil, fmt.Errorf("release %s not found", strings.Join(missing, ", "))
+	}
+	return selected, nil
+}
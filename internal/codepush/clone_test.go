@@ -0,0 +1,116 @@
+package codepush
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCloneDeployment(t *testing.T) {
+	sourceUpdates := []Update{
+		{ID: "u3", Label: "v3"},
+		{ID: "u4", Label: "v4"},
+		{ID: "u5", Label: "v5"},
+	}
+
+	newClient := func(promoted *[]string) *mockClient {
+		return &mockClient{
+			listDeploymentsFunc: func(appID string) ([]Deployment, error) {
+				return []Deployment{{ID: "dep-staging", Name: "Staging"}}, nil
+			},
+			listUpdatesFunc: func(appID, deploymentID string) ([]Update, error) {
+				assert.Equal(t, "dep-staging", deploymentID)
+				return sourceUpdates, nil
+			},
+			createDeploymentFunc: func(appID string, req CreateDeploymentRequest) (*Deployment, error) {
+				return &Deployment{ID: "dep-qa", Name: req.Name}, nil
+			},
+			promoteFunc: func(appID, deploymentID string, req PromoteRequest) (*Update, error) {
+				assert.Equal(t, "dep-staging", deploymentID)
+				assert.Equal(t, "dep-qa", req.TargetDeploymentID)
+				*promoted = append(*promoted, req.UpdateID)
+				return &Update{ID: "copy-" + req.UpdateID, Label: fmt.Sprintf("v%d", len(*promoted))}, nil
+			},
+		}
+	}
+
+	t.Run("copies the latest release by default", func(t *testing.T) {
+		var promoted []string
+		result, err := CloneDeployment(context.Background(), newClient(&promoted), "app-1", CreateDeploymentRequest{Name: "QA"}, "Staging", nil, testOut)
+		require.NoError(t, err)
+
+		assert.Equal(t, []string{"u5"}, promoted)
+		assert.Equal(t, "dep-qa", result.Deployment.ID)
+		assert.Equal(t, "dep-staging", result.SourceDeploymentID)
+		assert.Equal(t, []ClonedRelease{{SourceLabel: "v5", Label: "v1", UpdateID: "copy-u5"}}, result.Releases)
+	})
+
+	t.Run("copies labels in release order", func(t *testing.T) {
+		var promoted []string
+		result, err := CloneDeployment(context.Background(), newClient(&promoted), "app-1", CreateDeploymentRequest{Name: "QA"}, "Staging", []string{"v5", "v3"}, testOut)
+		require.NoError(t, err)
+
+		assert.Equal(t, []string{"u3", "u5"}, promoted)
+		require.Len(t, result.Releases, 2)
+		assert.Equal(t, "v3", result.Releases[0].SourceLabel)
+		assert.Equal(t, "v2", result.Releases[1].Label)
+	})
+
+	t.Run("unknown label creates nothing", func(t *testing.T) {
+		var promoted []string
+		client := newClient(&promoted)
+		client.createDeploymentFunc = func(appID string, req CreateDeploymentRequest) (*Deployment, error) {
+			t.Fatal("deployment should not be created")
+			return nil, nil
+		}
+
+		_, err := CloneDeployment(context.Background(), client, "app-1", CreateDeploymentRequest{Name: "QA"}, "Staging", []string{"v3", "v9", "v9"}, testOut)
+		require.Error(t, err)
+		assert.EqualError(t, err, "release v9 not found in deployment Staging")
+		var validationErr *ValidationError
+		assert.ErrorAs(t, err, &validationErr)
+	})
+
+	t.Run("empty source creates nothing", func(t *testing.T) {
+		client := newClient(new([]string))
+		client.listUpdatesFunc = func(appID, deploymentID string) ([]Update, error) { return nil, nil }
+		client.createDeploymentFunc = func(appID string, req CreateDeploymentRequest) (*Deployment, error) {
+			t.Fatal("deployment should not be created")
+			return nil, nil
+		}
+
+		_, err := CloneDeployment(context.Background(), client, "app-1", CreateDeploymentRequest{Name: "QA"}, "Staging", nil, testOut)
+		assert.ErrorContains(t, err, "no releases to clone")
+	})
+
+	t.Run("skips duplicate content", func(t *testing.T) {
+		var promoted []string
+		client := newClient(&promoted)
+		promote := client.promoteFunc
+		client.promoteFunc = func(appID, deploymentID string, req PromoteRequest) (*Update, error) {
+			if req.UpdateID == "u4" {
+				return nil, &APIError{StatusCode: 400, Body: `{"code":"ERR_BAD_REQUEST","message":"identical to the contents of the deployment"}`}
+			}
+			return promote(appID, deploymentID, req)
+		}
+
+		result, err := CloneDeployment(context.Background(), client, "app-1", CreateDeploymentRequest{Name: "QA"}, "Staging", []string{"v3", "v4", "v5"}, testOut)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"u3", "u5"}, promoted)
+		assert.True(t, result.Releases[1].Skipped)
+	})
+
+	t.Run("copy failure names the created deployment", func(t *testing.T) {
+		client := newClient(new([]string))
+		client.promoteFunc = func(appID, deploymentID string, req PromoteRequest) (*Update, error) {
+			return nil, errors.New("boom")
+		}
+
+		_, err := CloneDeployment(context.Background(), client, "app-1", CreateDeploymentRequest{Name: "QA"}, "Staging", nil, testOut)
+		assert.EqualError(t, err, `deployment "QA" was created, but copying v5 failed: boom`)
+	})
+}
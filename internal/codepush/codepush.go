@@ -2,11 +2,8 @@
 package codepush
 
 import (
-	"encoding/json"
 	"errors"
-
-	"github.com/bitrise-io/bitrise-plugins-codepush-cli/internal/bitrise"
-	"github.com/bitrise-io/bitrise-plugins-codepush-cli/internal/output"
+	"fmt"
 )
 
 // validateBaseOptions checks the common required fields shared by all operations.
@@ -15,24 +12,7 @@ func validateBaseOptions(appID, token string) error {
 		return errors.New("app ID is required: set --app-id or CODEPUSH_APP_ID")
 	}
 	if token == "" {
-		return errors.New("API token is required: set --token, BITRISE_API_TOKEN, or run 'codepush auth login'")
+		return fmt.Errorf("%w: set --token, BITRISE_API_TOKEN, or run 'codepush auth login'", ErrMissingToken)
 	}
 	return nil
 }
-
-// exportSummary marshals v as JSON and writes it to the Bitrise deploy directory.
-func exportSummary(filename string, v any, out *output.Writer) {
-	data, err := json.MarshalIndent(v, "", "  ")
-	if err != nil {
-		out.Warning("failed to marshal %s: %v", filename, err)
-		return
-	}
-
-	path, err := bitrise.WriteToDeployDir(filename, data)
-	if err != nil {
-		out.Warning("failed to export %s: %v", filename, err)
-		return
-	}
-
-	out.Info("Summary exported to: %s", path)
-}
@@ -0,0 +1,183 @@
+package codepush
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/bitrise-io/bitrise-plugins-codepush-cli/internal/output"
+)
+
+// States of an app version in a DeploymentComparison.
+const (
+	// CompareInSync: both deployments serve the same content.
+	CompareInSync = "in_sync"
+	// CompareNotPromoted: the release the source serves is not in the
+	// target.
+	CompareNotPromoted = "not_promoted"
+	// CompareDiverged: the target serves other content, but the release the
+	// source serves was promoted to it earlier.
+	CompareDiverged = "diverged"
+	// CompareTargetOnly: only the target serves the app version.
+	CompareTargetOnly = "target_only"
+)
+
+// compareClient is the subset of Client needed by CompareDeployments.
+type compareClient interface {
+	deploymentLister
+	updateLister
+}
+
+// AppVersionComparison is the release each deployment serves to one app
+// version: its newest release that is not disabled.
+type AppVersionComparison struct {
+	AppVersion string  `json:"app_version"`
+	Source     *Update `json:"source,omitempty"`
+	Target     *Update `json:"target,omitempty"`
+	State      string  `json:"state"`
+}
+
+// DeploymentComparison is the output of CompareDeployments.
+type DeploymentComparison struct {
+	Source   string `json:"source"`
+	SourceID string `json:"source_id"`
+	Target   string `json:"target"`
+	TargetID string `json:"target_id"`
+	// AppVersions are ordered by their newest release, newest first.
+	AppVersions []AppVersionComparison `json:"app_versions"`
+	// NotPromoted are the releases of the source whose content is not in
+	// the target, oldest first.
+	NotPromoted []Update `json:"not_promoted"`
+	// Commands promote the release the source serves to the target, for
+	// every app version in state CompareNotPromoted.
+	Commands []string `json:"commands"`
+}
+
+// CompareDeployments compares the releases of two deployments of an app.
+// Releases are matched by package hash, so a promoted release matches its
+// source whatever its label. Releases without a hash match nothing and are
+// left out of NotPromoted.
+func CompareDeployments(ctx context.Context, client compareClient, appID, source, target string, out *output.Writer) (*DeploymentComparison, error) {
+	sourceID, err := ResolveDeployment(ctx, client, appID, source, out)
+	if err != nil {
+		return nil, fmt.Errorf("resolving %s: %w", source, err)
+	}
+	targetID, err := ResolveDeployment(ctx, client, appID, target, out)
+	if err != nil {
+		return nil, fmt.Errorf("resolving %s: %w", target, err)
+	}
+	if sourceID == targetID {
+		return nil, Invalid(fmt.Errorf("%s and %s are the same deployment", source, target))
+	}
+
+	sourceUpdates, err := client.ListUpdates(ctx, appID, sourceID)
+	if err != nil {
+		return nil, fmt.Errorf("listing releases of %s: %w", source, err)
+	}
+	targetUpdates, err := client.ListUpdates(ctx, appID, targetID)
+	if err != nil {
+		return nil, fmt.Errorf("listing releases of %s: %w", target, err)
+	}
+
+	result := compareReleases(sourceUpdates, targetUpdates)
+	result.Source, result.SourceID = source, sourceID
+	result.Target, result.TargetID = target, targetID
+	for _, v := range result.AppVersions {
+		if v.State == CompareNotPromoted {
+			result.Commands = append(result.Commands, promoteCommand(appID, source, target, v.Source.Label))
+		}
+	}
+	// Promote the oldest release first, so the newest one ends up the
+	// latest release of the target.
+	slices.Reverse(result.Commands)
+	return result, nil
+}
+
+// compareReleases compares two release histories, each ordered oldest
+// first as returned by ListUpdates.
+func compareReleases(source, target []Update) *DeploymentComparison {
+	inTarget := map[string]bool{}
+	for _, u := range target {
+		if u.Hash != "" {
+			inTarget[u.Hash] = true
+		}
+	}
+
+	result := &DeploymentComparison{NotPromoted: []Update{}, Commands: []string{}}
+	for _, u := range source {
+		if u.Hash != "" && !inTarget[u.Hash] {
+			result.NotPromoted = append(result.NotPromoted, u)
+		}
+	}
+
+	sourceLive := liveByAppVersion(source)
+	targetLive := liveByAppVersion(target)
+	for _, version := range appVersionsNewestFirst(source, target) {
+		c := AppVersionComparison{AppVersion: version, Source: sourceLive[version], Target: targetLive[version]}
+		switch {
+		case c.Source == nil && c.Target == nil:
+			continue
+		case c.Source == nil:
+			c.State = CompareTargetOnly
+		case c.Target != nil && c.Source.Hash != "" && c.Source.Hash == c.Target.Hash:
+			c.State = CompareInSync
+		case c.Source.Hash != "" && inTarget[c.Source.Hash]:
+			c.State = CompareDiverged
+		default:
+			c.State = CompareNotPromoted
+		}
+		result.AppVersions = append(result.AppVersions, c)
+	}
+	return result
+}
+
+// liveByAppVersion returns the newest release that is not disabled of every
+// app version in updates, which are ordered oldest first.
+func liveByAppVersion(updates []Update) map[string]*Update {
+	live := map[string]*Update{}
+	for i := range updates {
+		if !updates[i].Disabled {
+			live[updates[i].AppVersion] = &updates[i]
+		}
+	}
+	return live
+}
+
+// appVersionsNewestFirst returns the app versions of both histories,
+// ordered by their newest release in the source, then in the target.
+func appVersionsNewestFirst(source, target []Update) []string {
+	var versions []string
+	for _, updates := range [][]Update{source, target} {
+		for i := len(updates) - 1; i >= 0; i-- {
+			if v := updates[i].AppVersion; !slices.Contains(versions, v) {
+				versions = append(versions, v)
+			}
+		}
+	}
+	return versions
+}
+
+// promoteCommand returns the command that promotes the release labeled
+// label from source to target.
+func promoteCommand(appID, source, target, label string) string {
+	return strings.Join([]string{
+		"codepush promote",
+		"--app-id", shellQuote(appID),
+		"--source-deployment", shellQuote(source),
+		"--destination-deployment", shellQuote(target),
+		"--label", shellQuote(label),
+	}, " ")
+}
+
+// shellQuote quotes s for a POSIX shell when it contains other characters
+// than letters, digits, and -._/:@.
+func shellQuote(s string) string {
+	safe := s != "" && strings.IndexFunc(s, func(r rune) bool {
+		return !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || strings.ContainsRune("-._/:@", r))
+	}) < 0
+	if safe {
+		return s
+	}
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
@@ -0,0 +1,139 @@
+package codepush
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCompareReleases(t *testing.T) {
+	tests := []struct {
+		name        string
+		source      []Update
+		target      []Update
+		wantStates  map[string]string
+		notPromoted []string
+	}{
+		{
+			name:       "in sync",
+			source:     []Update{{Label: "v1", AppVersion: "1.0.0", Hash: "a"}},
+			target:     []Update{{Label: "v7", AppVersion: "1.0.0", Hash: "a"}},
+			wantStates: map[string]string{"1.0.0": CompareInSync},
+		},
+		{
+			name: "not promoted",
+			source: []Update{
+				{Label: "v1", AppVersion: "1.0.0", Hash: "a"},
+				{Label: "v2", AppVersion: "1.0.0", Hash: "b"},
+				{Label: "v3", AppVersion: "1.0.0", Hash: "c"},
+			},
+			target:      []Update{{Label: "v1", AppVersion: "1.0.0", Hash: "a"}},
+			wantStates:  map[string]string{"1.0.0": CompareNotPromoted},
+			notPromoted: []string{"v2", "v3"},
+		},
+		{
+			name:   "diverged",
+			source: []Update{{Label: "v1", AppVersion: "1.0.0", Hash: "a"}},
+			target: []Update{
+				{Label: "v1", AppVersion: "1.0.0", Hash: "a"},
+				{Label: "v2", AppVersion: "1.0.0", Hash: "hotfix"},
+			},
+			wantStates: map[string]string{"1.0.0": CompareDiverged},
+		},
+		{
+			name:       "only in target",
+			target:     []Update{{Label: "v1", AppVersion: "0.9.0", Hash: "a"}},
+			wantStates: map[string]string{"0.9.0": CompareTargetOnly},
+		},
+		{
+			name:        "new app version",
+			source:      []Update{{Label: "v1", AppVersion: "2.0.0", Hash: "a"}},
+			wantStates:  map[string]string{"2.0.0": CompareNotPromoted},
+			notPromoted: []string{"v1"},
+		},
+		{
+			name: "disabled releases are not served",
+			source: []Update{
+				{Label: "v1", AppVersion: "1.0.0", Hash: "a"},
+				{Label: "v2", AppVersion: "1.0.0", Hash: "b", Disabled: true},
+			},
+			target:      []Update{{Label: "v1", AppVersion: "1.0.0", Hash: "a"}},
+			wantStates:  map[string]string{"1.0.0": CompareInSync},
+			notPromoted: []string{"v2"},
+		},
+		{
+			name:       "releases without hash match nothing",
+			source:     []Update{{Label: "v1", AppVersion: "1.0.0"}},
+			target:     []Update{{Label: "v1", AppVersion: "1.0.0"}},
+			wantStates: map[string]string{"1.0.0": CompareNotPromoted},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			result := compareReleases(tc.source, tc.target)
+
+			states := map[string]string{}
+			for _, v := range result.AppVersions {
+				states[v.AppVersion] = v.State
+			}
+			assert.Equal(t, tc.wantStates, states)
+
+			var labels []string
+			for _, u := range result.NotPromoted {
+				labels = append(labels, u.Label)
+			}
+			assert.Equal(t, tc.notPromoted, labels)
+		})
+	}
+}
+
+func TestCompareDeployments(t *testing.T) {
+	releases := map[string][]Update{
+		"dep-staging": {
+			{Label: "v1", AppVersion: "1.0.0", Hash: "a"},
+			{Label: "v2", AppVersion: "1.1.0", Hash: "b"},
+			{Label: "v3", AppVersion: "1.2.0", Hash: "c"},
+		},
+		"dep-prod": {
+			{Label: "v1", AppVersion: "1.0.0", Hash: "a"},
+		},
+	}
+	client := &mockClient{
+		listDeploymentsFunc: func(appID string) ([]Deployment, error) {
+			return []Deployment{{ID: "dep-staging", Name: "Staging"}, {ID: "dep-prod", Name: "Production EU"}}, nil
+		},
+		listUpdatesFunc: func(appID, deploymentID string) ([]Update, error) {
+			return releases[deploymentID], nil
+		},
+	}
+
+	t.Run("prints promote commands oldest first", func(t *testing.T) {
+		result, err := CompareDeployments(context.Background(), client, "app-1", "Staging", "Production EU", testOut)
+		require.NoError(t, err)
+
+		assert.Equal(t, "dep-staging", result.SourceID)
+		assert.Equal(t, "dep-prod", result.TargetID)
+		require.Len(t, result.AppVersions, 3)
+		assert.Equal(t, "1.2.0", result.AppVersions[0].AppVersion)
+		assert.Equal(t, []string{
+			"codepush promote --app-id app-1 --source-deployment Staging --destination-deployment 'Production EU' --label v2",
+			"codepush promote --app-id app-1 --source-deployment Staging --destination-deployment 'Production EU' --label v3",
+		}, result.Commands)
+	})
+
+	t.Run("rejects the same deployment", func(t *testing.T) {
+		_, err := CompareDeployments(context.Background(), client, "app-1", "Staging", "Staging", testOut)
+		var validationErr *ValidationError
+		assert.ErrorAs(t, err, &validationErr)
+	})
+}
+
+func TestShellQuote(t *testing.T) {
+	assert.Equal(t, "Staging", shellQuote("Staging"))
+	assert.Equal(t, "'Production EU'", shellQuote("Production EU"))
+	assert.Equal(t, `'it'\''s'`, shellQuote("it's"))
+	assert.Equal(t, "''", shellQuote(""))
+}
@@ -0,0 +1,76 @@
+package codepush
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/bitrise-io/bitrise-plugins-codepush-cli/internal/output"
+)
+
+// ContentHash computes the content hash of a bundle directory, the one the
+// server records for a release of it. See PackageHash.
+func ContentHash(bundleDir string) (string, error) {
+	m, err := ManifestFromDir(bundleDir)
+	if err != nil {
+		return "", err
+	}
+	hash, _ := PackageHash(m)
+	return hash, nil
+}
+
+// LatestReleaseWithHash returns the latest release of the deployment when
+// its content hash is hash, and nil when the deployment has no release or
+// its latest release has other content.
+func LatestReleaseWithHash(ctx context.Context, client updateLister, appID, deploymentID, hash string) (*Update, error) {
+	updates, err := client.ListUpdates(ctx, appID, deploymentID)
+	if err != nil {
+		return nil, fmt.Errorf("listing updates: %w", err)
+	}
+	if len(updates) == 0 {
+		return nil, nil //nolint:nilnil // no release yet
+	}
+	latest := updates[len(updates)-1]
+	if latest.Hash == "" || !strings.EqualFold(latest.Hash, hash) {
+		return nil, nil //nolint:nilnil // different content
+	}
+	return &latest, nil
+}
+
+// checkDuplicate compares the bundle's content hash with the latest release
+// of the deployment before anything is uploaded. When they match, the push
+// fails with ErrDuplicateRelease or, with opts.AllowDuplicate, is skipped:
+// the existing release is then returned. A failed check is only warned
+// about, leaving duplicate detection to the server.
+func checkDuplicate(ctx context.Context, client updateLister, opts *PushOptions, deploymentID string, out *output.Writer) (*PushResult, error) {
+	hash, err := ContentHash(opts.BundlePath)
+	if err != nil {
+		out.Warning("skipping duplicate check: %v", err)
+		return nil, nil //nolint:nilnil // push as usual
+	}
+	existing, err := LatestReleaseWithHash(ctx, client, opts.AppID, deploymentID, hash)
+	if err != nil {
+		out.Warning("skipping duplicate check: %v", err)
+		return nil, nil //nolint:nilnil // push as usual
+	}
+	if existing == nil {
+		return nil, nil //nolint:nilnil // new content
+	}
+	if !opts.AllowDuplicate {
+		return nil, fmt.Errorf("%w: release %s already has this content (pass --allow-duplicate to skip the push instead)", ErrDuplicateRelease, existing.Label)
+	}
+
+	out.Info("Release %s already has this content, skipping the upload", existing.Label)
+	return &PushResult{
+		UpdateID:      existing.ID,
+		AppID:         opts.AppID,
+		DeploymentID:  deploymentID,
+		AppVersion:    existing.AppVersion,
+		Status:        StatusDuplicate,
+		FileSizeBytes: existing.FileSizeBytes,
+		Rollout:       int(existing.Rollout),
+		DuplicateOf:   existing.Label,
+		Targeting:     existing.Targeting,
+		Provenance:    existing.Provenance,
+	}, nil
+}
@@ -0,0 +1,104 @@
+package codepush
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLatestReleaseWithHash(t *testing.T) {
+	hash, err := ContentHash(createTestBundleDir(t))
+	require.NoError(t, err)
+
+	tests := []struct {
+		name      string
+		updates   []Update
+		listErr   error
+		wantLabel string
+		wantErr   string
+	}{
+		{name: "no releases"},
+		{name: "latest matches", updates: []Update{{Label: "v1", Hash: "other"}, {Label: "v2", Hash: strings.ToUpper(hash)}}, wantLabel: "v2"},
+		{name: "older release matches", updates: []Update{{Label: "v1", Hash: hash}, {Label: "v2", Hash: "other"}}},
+		{name: "latest without hash", updates: []Update{{Label: "v1"}}},
+		{name: "list error", listErr: errors.New("boom"), wantErr: "listing updates: boom"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := &mockClient{
+				listUpdatesFunc: func(_, _ string) ([]Update, error) { return tt.updates, tt.listErr },
+			}
+
+			got, err := LatestReleaseWithHash(context.Background(), client, "app-1", "dep-1", hash)
+			if tt.wantErr != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			if tt.wantLabel == "" {
+				assert.Nil(t, got)
+				return
+			}
+			require.NotNil(t, got)
+			assert.Equal(t, tt.wantLabel, got.Label)
+		})
+	}
+}
+
+func TestPushDetectsDuplicateContent(t *testing.T) {
+	bundleDir := createTestBundleDir(t)
+	hash, err := ContentHash(bundleDir)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name           string
+		allowDuplicate bool
+		wantErr        bool
+	}{
+		{name: "fails by default", wantErr: true},
+		{name: "skips with allow duplicate", allowDuplicate: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			uploadRequested := false
+			client := &mockClient{
+				listUpdatesFunc: func(_, _ string) ([]Update, error) {
+					return []Update{{ID: "upd-7", Label: "v7", AppVersion: "1.0", Rollout: 50, Hash: hash}}, nil
+				},
+				getUploadURLFunc: func(_, _, _ string, _ UploadURLRequest) (*UploadURLResponse, error) {
+					uploadRequested = true
+					return nil, errors.New("unexpected upload")
+				},
+			}
+			opts := &PushOptions{
+				AppID:          "app-1",
+				DeploymentID:   "00000000-0000-0000-0000-000000000001",
+				Token:          "test-token",
+				AppVersion:     "1.0",
+				Rollout:        100,
+				BundlePath:     bundleDir,
+				AllowDuplicate: tt.allowDuplicate,
+			}
+
+			result, err := PushWithConfig(context.Background(), client, opts, fastPollConfig, testOut)
+			assert.False(t, uploadRequested)
+			if tt.wantErr {
+				require.Error(t, err)
+				assert.ErrorIs(t, err, ErrDuplicateRelease)
+				assert.Contains(t, err.Error(), "release v7 already has this content")
+				assert.Equal(t, ExitCodeDuplicateRelease, NewErrorReport(err).ExitCode)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, "upd-7", result.UpdateID)
+			assert.Equal(t, "v7", result.DuplicateOf)
+			assert.Equal(t, StatusDuplicate, result.Status)
+			assert.Equal(t, 50, result.Rollout)
+		})
+	}
+}
@@ -0,0 +1,297 @@
+package codepush
+
+import (
+	"archive/zip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/bitrise-io/bitrise-plugins-codepush-cli/internal/output"
+	"github.com/bitrise-io/bitrise-plugins-codepush-cli/internal/workspace"
+	ziputil "github.com/bitrise-io/bitrise-plugins-codepush-cli/internal/zip"
+)
+
+// DiffManifestFile is the name of the manifest inside a delta package. It
+// lists the files to delete from the base release; every other entry in the
+// package replaces or adds a file. This matches the CodePush diff format.
+const DiffManifestFile = "hotcodepush.json"
+
+// FileManifest maps the slash-separated path of every file in a package to
+// the hex SHA-256 of its contents.
+type FileManifest map[string]string
+
+// BundleDiff is the file-level difference between two packages.
+type BundleDiff struct {
+	Changed []string `json:"changed"`
+	Deleted []string `json:"deleted"`
+}
+
+// diffManifest is the JSON content of DiffManifestFile.
+type diffManifest struct {
+	DeletedFiles []string `json:"deletedFiles"`
+}
+
+// ManifestFromDir hashes every file under dir, leaving out the OS metadata
+// that packages leave out.
+func ManifestFromDir(dir string) (FileManifest, error) {
+	m := FileManifest{}
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		if ziputil.IsMetadata(filepath.ToSlash(rel)) {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if d.IsDir() {
+			return nil
+		}
+		f, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer func() { _ = f.Close() }()
+		sum, err := hashReader(f)
+		if err != nil {
+			return fmt.Errorf("hashing %s: %w", rel, err)
+		}
+		m[filepath.ToSlash(rel)] = sum
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("reading bundle: %w", err)
+	}
+	return m, nil
+}
+
+// ManifestFromZip hashes every file in a package zip.
+func ManifestFromZip(zipPath string) (FileManifest, error) {
+	zr, err := zip.OpenReader(zipPath)
+	if err != nil {
+		return nil, fmt.Errorf("opening package: %w", err)
+	}
+	defer func() { _ = zr.Close() }()
+
+	m := FileManifest{}
+	for _, f := range zr.File {
+		if strings.HasSuffix(f.Name, "/") {
+			continue
+		}
+		rc, err := f.Open()
+		if err != nil {
+			return nil, fmt.Errorf("reading %s: %w", f.Name, err)
+		}
+		sum, err := hashReader(rc)
+		_ = rc.Close()
+		if err != nil {
+			return nil, fmt.Errorf("hashing %s: %w", f.Name, err)
+		}
+		m[f.Name] = sum
+	}
+	return m, nil
+}
+
+func hashReader(r io.Reader) (string, error) {
+	h := sha256.New()
+	if _, err := io.Copy(h, r); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// DiffManifests returns the files of next that are new or differ from base,
+// and the files of base that next no longer has. Both lists are sorted.
+func DiffManifests(base, next FileManifest) BundleDiff {
+	diff := BundleDiff{Changed: []string{}, Deleted: []string{}}
+	for name, sum := range next {
+		if base[name] != sum {
+			diff.Changed = append(diff.Changed, name)
+		}
+	}
+	for name := range base {
+		if _, ok := next[name]; !ok {
+			diff.Deleted = append(diff.Deleted, name)
+		}
+	}
+	slices.Sort(diff.Changed)
+	slices.Sort(diff.Deleted)
+	return diff
+}
+
+// writeDeltaZip packages the changed files of the bundle of opts and the
+// diff manifest into a temp zip in the workspace of opts and returns its
+// path. The caller is responsible for removing it.
+func writeDeltaZip(opts deltaOptions, diff BundleDiff) (string, error) {
+	f, err := opts.workspace.CreateTemp("delta-*.zip")
+	if err != nil {
+		return "", fmt.Errorf("creating temp file: %w", err)
+	}
+
+	if err := writeDeltaEntries(f, opts.bundleDir, diff, opts.compression); err != nil {
+		_ = f.Close()
+		opts.workspace.Remove(f.Name())
+		return "", err
+	}
+	if err := f.Close(); err != nil {
+		opts.workspace.Remove(f.Name())
+		return "", fmt.Errorf("writing delta package: %w", err)
+	}
+	return f.Name(), nil
+}
+
+func writeDeltaEntries(w io.Writer, bundleDir string, diff BundleDiff, c ziputil.Compression) error {
+	zw := c.NewWriter(w)
+
+	for _, name := range diff.Changed {
+		if err := addDeltaFile(zw, bundleDir, name, c); err != nil {
+			return err
+		}
+	}
+
+	manifest, err := json.Marshal(diffManifest{DeletedFiles: diff.Deleted})
+	if err != nil {
+		return fmt.Errorf("encoding diff manifest: %w", err)
+	}
+	mw, err := c.Create(zw, DiffManifestFile)
+	if err != nil {
+		return fmt.Errorf("creating zip entry %s: %w", DiffManifestFile, err)
+	}
+	if _, err := mw.Write(manifest); err != nil {
+		return fmt.Errorf("writing diff manifest: %w", err)
+	}
+
+	return zw.Close()
+}
+
+func addDeltaFile(zw *zip.Writer, bundleDir, name string, c ziputil.Compression) error {
+	src, err := os.Open(filepath.Join(bundleDir, filepath.FromSlash(name)))
+	if err != nil {
+		return fmt.Errorf("opening file %s: %w", name, err)
+	}
+	defer func() { _ = src.Close() }()
+
+	dst, err := c.Create(zw, name)
+	if err != nil {
+		return fmt.Errorf("creating zip entry %s: %w", name, err)
+	}
+	_, err = io.Copy(dst, src)
+	return err
+}
+
+// deltaClient is the subset of Client needed to build a delta package.
+type deltaClient interface {
+	updateLister
+	updateDownloader
+}
+
+// deltaPackage is a delta package ready for upload.
+type deltaPackage struct {
+	Path string
+	Size int64
+	Base Update
+	Diff BundleDiff
+}
+
+// deltaOptions selects the bundle prepareDelta diffs and the deployment
+// whose latest release it diffs against.
+type deltaOptions struct {
+	appID        string
+	deploymentID string
+	bundleDir    string
+	// fullSize is the size of the full package; a delta that is not
+	// smaller is dropped.
+	fullSize    int64
+	compression ziputil.Compression
+	workspace   *workspace.Workspace
+}
+
+// prepareDelta builds a delta package of the bundle against the latest
+// release in the deployment. The API does not serve release manifests, so
+// the manifest is computed from the release's package. Returns nil when
+// there is nothing to diff against or the delta would not be smaller than
+// the full package.
+func prepareDelta(ctx context.Context, client deltaClient, opts deltaOptions) (*deltaPackage, error) {
+	updates, err := client.ListUpdates(ctx, opts.appID, opts.deploymentID)
+	if err != nil {
+		return nil, fmt.Errorf("listing updates: %w", err)
+	}
+	if len(updates) == 0 {
+		return nil, nil //nolint:nilnil // first release, nothing to diff against
+	}
+	base := updates[len(updates)-1]
+
+	zipPath, err := downloadUpdate(ctx, client, opts.workspace, UpdateRef{AppID: opts.appID, DeploymentID: opts.deploymentID, UpdateID: base.ID})
+	if err != nil {
+		return nil, fmt.Errorf("downloading release %s: %w", base.Label, err)
+	}
+	defer opts.workspace.Remove(zipPath)
+
+	baseManifest, err := ManifestFromZip(zipPath)
+	if err != nil {
+		return nil, fmt.Errorf("release %s: %w", base.Label, err)
+	}
+	nextManifest, err := ManifestFromDir(opts.bundleDir)
+	if err != nil {
+		return nil, err
+	}
+	diff := DiffManifests(baseManifest, nextManifest)
+
+	deltaPath, err := writeDeltaZip(opts, diff)
+	if err != nil {
+		return nil, err
+	}
+	info, err := os.Stat(deltaPath)
+	if err != nil {
+		opts.workspace.Remove(deltaPath)
+		return nil, fmt.Errorf("reading delta package info: %w", err)
+	}
+	if info.Size() >= opts.fullSize {
+		opts.workspace.Remove(deltaPath)
+		return nil, nil //nolint:nilnil // full package is smaller
+	}
+
+	return &deltaPackage{Path: deltaPath, Size: info.Size(), Base: base, Diff: diff}, nil
+}
+
+// tryDelta runs prepareDelta as a best-effort step of the push workflow.
+// Any problem is reported as a warning and the full package is uploaded.
+func tryDelta(ctx context.Context, client deltaClient, opts *PushOptions, deploymentID string, fullSize int64, out *output.Writer) *deltaPackage {
+	step := out.StartStep("Computing delta against the latest release")
+	delta, err := prepareDelta(ctx, client, deltaOptions{
+		appID:        opts.AppID,
+		deploymentID: deploymentID,
+		bundleDir:    opts.BundlePath,
+		fullSize:     fullSize,
+		compression:  opts.Compression,
+		workspace:    opts.Workspace,
+	})
+	if err != nil {
+		step.Cancel()
+		out.Warning("uploading the full package: %v", err)
+		return nil
+	}
+	step.Done()
+
+	if delta == nil {
+		out.Info("Uploading the full package")
+		return nil
+	}
+	out.Info("Delta against %s: %d changed, %d deleted files, %s (full package %s)",
+		delta.Base.Label, len(delta.Diff.Changed), len(delta.Diff.Deleted),
+		output.HumanBytes(delta.Size), output.HumanBytes(fullSize))
+	return delta
+}
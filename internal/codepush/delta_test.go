@@ -0,0 +1,203 @@
+package codepush
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeZipFiles writes a package zip with the given entries.
+func writeZipFiles(t *testing.T, w io.Writer, files map[string]string) {
+	t.Helper()
+	zw := zip.NewWriter(w)
+	for name, content := range files {
+		fw, err := zw.Create(name)
+		require.NoError(t, err)
+		_, err = fw.Write([]byte(content))
+		require.NoError(t, err)
+	}
+	require.NoError(t, zw.Close())
+}
+
+// deltaBundleDir creates a bundle directory with the given files.
+func deltaBundleDir(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir := filepath.Join(t.TempDir(), "bundle")
+	for name, content := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+	}
+	return dir
+}
+
+// readZipFiles returns the entries of a zip as a name to content map.
+func readZipFiles(t *testing.T, data []byte) map[string]string {
+	t.Helper()
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	require.NoError(t, err)
+	files := map[string]string{}
+	for _, f := range zr.File {
+		rc, err := f.Open()
+		require.NoError(t, err)
+		content, err := io.ReadAll(rc)
+		require.NoError(t, err)
+		_ = rc.Close()
+		files[f.Name] = string(content)
+	}
+	return files
+}
+
+func TestDiffManifests(t *testing.T) {
+	base := FileManifest{"main.jsbundle": "a", "assets/logo.png": "b", "assets/old.png": "c"}
+	next := FileManifest{"main.jsbundle": "a2", "assets/logo.png": "b", "assets/new.png": "d"}
+
+	diff := DiffManifests(base, next)
+	assert.Equal(t, []string{"assets/new.png", "main.jsbundle"}, diff.Changed)
+	assert.Equal(t, []string{"assets/old.png"}, diff.Deleted)
+}
+
+func TestManifestFromDirMatchesZip(t *testing.T) {
+	files := map[string]string{"main.jsbundle": "bundle", "assets/img/logo.png": "png"}
+	dir := deltaBundleDir(t, files)
+
+	zipPath := filepath.Join(t.TempDir(), "pkg.zip")
+	f, err := os.Create(zipPath)
+	require.NoError(t, err)
+	writeZipFiles(t, f, files)
+	require.NoError(t, f.Close())
+
+	fromDir, err := ManifestFromDir(dir)
+	require.NoError(t, err)
+	fromZip, err := ManifestFromZip(zipPath)
+	require.NoError(t, err)
+
+	assert.Len(t, fromDir, 2)
+	assert.Equal(t, fromDir, fromZip)
+}
+
+func TestPushDelta(t *testing.T) {
+	largeAsset := strings.Repeat("unchanged asset data ", 4096)
+	previous := map[string]string{
+		"main.jsbundle":   "console.log('v1')",
+		"assets/big.png":  largeAsset,
+		"assets/gone.png": "removed in v2",
+	}
+	next := map[string]string{
+		"main.jsbundle":  "console.log('v2')",
+		"assets/big.png": largeAsset,
+	}
+
+	newClient := func(captured *UploadURLRequest, body *[]byte) *mockClient {
+		return &mockClient{
+			listUpdatesFunc: func(appID, deploymentID string) ([]Update, error) {
+				return []Update{{ID: "pkg-1", Label: "v1"}}, nil
+			},
+			downloadFileFunc: func(fileURL string, w io.Writer) error {
+				writeZipFiles(t, w, previous)
+				return nil
+			},
+			getUploadURLFunc: func(appID, deploymentID, updateID string, req UploadURLRequest) (*UploadURLResponse, error) {
+				*captured = req
+				return &UploadURLResponse{URL: "https://storage.example.com/upload", Method: "PUT"}, nil
+			},
+			uploadFileFunc: func(req UploadFileRequest) error {
+				*body, _ = io.ReadAll(req.Body)
+				return nil
+			},
+			getUpdateStatusFunc: func(appID, deploymentID, updateID string) (*UpdateStatus, error) {
+				return &UpdateStatus{UpdateID: updateID, Status: StatusProcessedValid}, nil
+			},
+		}
+	}
+
+	newOpts := func(full bool) *PushOptions {
+		return &PushOptions{
+			AppID:        "app-123",
+			DeploymentID: "00000000-0000-0000-0000-000000000001",
+			Token:        "test-token",
+			AppVersion:   "1.0.0",
+			Rollout:      100,
+			BundlePath:   deltaBundleDir(t, next),
+			Full:         full,
+		}
+	}
+
+	t.Run("uploads only changed files and the diff manifest", func(t *testing.T) {
+		var req UploadURLRequest
+		var body []byte
+		result, err := PushWithConfig(context.Background(), newClient(&req, &body), newOpts(false), fastPollConfig, testOut)
+		require.NoError(t, err)
+
+		assert.Equal(t, "pkg-1", req.DiffAgainst)
+		assert.Equal(t, "v1", result.DiffAgainst)
+		assert.Equal(t, int64(len(body)), result.FileSizeBytes)
+
+		files := readZipFiles(t, body)
+		assert.Len(t, files, 2)
+		assert.Equal(t, "console.log('v2')", files["main.jsbundle"])
+
+		var manifest diffManifest
+		require.NoError(t, json.Unmarshal([]byte(files[DiffManifestFile]), &manifest))
+		assert.Equal(t, []string{"assets/gone.png"}, manifest.DeletedFiles)
+	})
+
+	t.Run("full uploads the whole package", func(t *testing.T) {
+		var req UploadURLRequest
+		var body []byte
+		result, err := PushWithConfig(context.Background(), newClient(&req, &body), newOpts(true), fastPollConfig, testOut)
+		require.NoError(t, err)
+
+		assert.Empty(t, req.DiffAgainst)
+		assert.Empty(t, result.DiffAgainst)
+		files := readZipFiles(t, body)
+		assert.Contains(t, files, "assets/big.png")
+		assert.NotContains(t, files, DiffManifestFile)
+	})
+
+	t.Run("falls back to the full package when the download fails", func(t *testing.T) {
+		var req UploadURLRequest
+		var body []byte
+		client := newClient(&req, &body)
+		client.downloadFileFunc = func(fileURL string, w io.Writer) error {
+			return errors.New("download failed with HTTP 403")
+		}
+
+		result, err := PushWithConfig(context.Background(), client, newOpts(false), fastPollConfig, testOut)
+		require.NoError(t, err)
+		assert.Empty(t, req.DiffAgainst)
+		assert.Empty(t, result.DiffAgainst)
+	})
+}
+
+func TestPrepareDeltaSkipsWhenNotSmaller(t *testing.T) {
+	client := &mockClient{
+		listUpdatesFunc: func(appID, deploymentID string) ([]Update, error) {
+			return []Update{{ID: "pkg-1", Label: "v1"}}, nil
+		},
+		downloadFileFunc: func(fileURL string, w io.Writer) error {
+			writeZipFiles(t, w, map[string]string{"old.jsbundle": "old"})
+			return nil
+		},
+	}
+
+	dir := deltaBundleDir(t, map[string]string{"main.jsbundle": "new"})
+	delta, err := prepareDelta(context.Background(), client, deltaOptions{
+		appID:        "app-1",
+		deploymentID: "dep-1",
+		bundleDir:    dir,
+		fullSize:     1,
+	})
+	require.NoError(t, err)
+	assert.Nil(t, delta)
+}
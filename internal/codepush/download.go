@@ -0,0 +1,43 @@
+package codepush
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/bitrise-io/bitrise-plugins-codepush-cli/internal/workspace"
+)
+
+// updateDownloader is the subset of Client needed to fetch a released package.
+type updateDownloader interface {
+	GetDownloadURL(ctx context.Context, appID, deploymentID, updateID string) (*DownloadURLResponse, error)
+	DownloadFile(ctx context.Context, fileURL string, w io.Writer) error
+}
+
+// downloadUpdate fetches the package zip of an existing update into a temp
+// file in ws and returns its path. The caller is responsible for removing
+// it.
+func downloadUpdate(ctx context.Context, client updateDownloader, ws *workspace.Workspace, ref UpdateRef) (string, error) {
+	dl, err := client.GetDownloadURL(ctx, ref.AppID, ref.DeploymentID, ref.UpdateID)
+	if err != nil {
+		return "", fmt.Errorf("requesting download URL: %w", err)
+	}
+
+	f, err := ws.CreateTemp("package-*.zip")
+	if err != nil {
+		return "", fmt.Errorf("creating temp file: %w", err)
+	}
+
+	if err := client.DownloadFile(ctx, dl.URL, f); err != nil {
+		_ = f.Close()
+		ws.Remove(f.Name())
+		return "", err
+	}
+
+	if err := f.Close(); err != nil {
+		ws.Remove(f.Name())
+		return "", fmt.Errorf("writing package: %w", err)
+	}
+
+	return f.Name(), nil
+}
@@ -0,0 +1,170 @@
+package codepush
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+)
+
+// Process exit codes. Every command exits with one of these so CI workflows
+// can branch on the kind of failure instead of parsing error messages.
+const (
+	ExitCodeError            = 1
+	ExitCodeValidation       = 2
+	ExitCodeAssertion        = 3
+	ExitCodeTimeout          = 4
+	ExitCodeProcessingFailed = 5
+	ExitCodeAuth             = 6
+	ExitCodeAPI              = 7
+	ExitCodeDuplicateRelease = 8
+	ExitCodePolicy           = 9
+	// ExitCodeInterrupted follows the shell convention for SIGINT (128+2).
+	ExitCodeInterrupted = 130
+)
+
+// Error kinds reported in ErrorReport, one per exit code.
+const (
+	ErrorKindError            = "error"
+	ErrorKindValidation       = "validation"
+	ErrorKindAssertion        = "assertion"
+	ErrorKindTimeout          = "timeout"
+	ErrorKindProcessingFailed = "processing_failed"
+	ErrorKindAuth             = "auth"
+	ErrorKindAPI              = "api"
+	ErrorKindDuplicateRelease = "duplicate_release"
+	ErrorKindPolicy           = "policy"
+	ErrorKindInterrupted      = "interrupted"
+)
+
+// ErrMissingToken is wrapped by errors for commands run without an API token.
+var ErrMissingToken = errors.New("API token is required")
+
+// ValidationError reports invalid input, such as a missing option or a
+// malformed flag value, detected before anything was changed.
+type ValidationError struct {
+	Err error
+}
+
+func (e *ValidationError) Error() string {
+	return e.Err.Error()
+}
+
+// Unwrap returns the underlying error.
+func (e *ValidationError) Unwrap() error {
+	return e.Err
+}
+
+// ExitCode returns ExitCodeValidation.
+func (e *ValidationError) ExitCode() int {
+	return ExitCodeValidation
+}
+
+// Invalid marks err as a ValidationError. It returns nil for a nil err.
+func Invalid(err error) error {
+	if err == nil {
+		return nil
+	}
+	var validationErr *ValidationError
+	if errors.As(err, &validationErr) {
+		return err
+	}
+	return &ValidationError{Err: err}
+}
+
+// PolicyError reports a release that breaks the release policy in
+// .codepush.json. Nothing was changed.
+type PolicyError struct {
+	Violations []string
+}
+
+func (e *PolicyError) Error() string {
+	return "release policy violated: " + strings.Join(e.Violations, "; ") + " (use --override-policy in an emergency)"
+}
+
+// ExitCode returns ExitCodePolicy.
+func (e *PolicyError) ExitCode() int {
+	return ExitCodePolicy
+}
+
+// FreezeError reports a change to a deployment inside a freeze window
+// declared in .codepush.json. Nothing was changed.
+type FreezeError struct {
+	Deployment string
+	// Window describes the freeze window, e.g. "weekend (Fri 16:00 to Mon
+	// 08:00 Europe/Budapest)".
+	Window string
+	Until  time.Time
+}
+
+func (e *FreezeError) Error() string {
+	return fmt.Sprintf("%s is frozen until %s by freeze window %s (use --override-freeze \"<reason>\" in an emergency)",
+		e.Deployment, e.Until.Format("Mon 2006-01-02 15:04 MST"), e.Window)
+}
+
+// ExitCode returns ExitCodePolicy.
+func (e *FreezeError) ExitCode() int {
+	return ExitCodePolicy
+}
+
+// ErrorReport is the machine-readable form of a command failure, written to
+// stderr with --json.
+type ErrorReport struct {
+	Error    string `json:"error"`
+	Kind     string `json:"kind"`
+	ExitCode int    `json:"exit_code"`
+	// StatusCode and Code are set for failed API requests.
+	StatusCode int    `json:"status_code,omitempty"`
+	Code       string `json:"code,omitempty"`
+}
+
+// NewErrorReport classifies err into an error kind and exit code. More
+// specific kinds take precedence: a rejected duplicate release is reported
+// as duplicate_release rather than api, and a 401 response or a missing
+// token scope as auth.
+func NewErrorReport(err error) ErrorReport {
+	r := ErrorReport{Error: err.Error()}
+
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		r.StatusCode, r.Code = apiErr.StatusCode, apiErr.Code
+	}
+
+	var processingErr *ProcessingError
+	var assertionErr *AssertionError
+	var validationErr *ValidationError
+	var policyErr *PolicyError
+	var freezeErr *FreezeError
+	var scopeErr *ScopeError
+	var netErr net.Error
+	var coded interface{ ExitCode() int }
+	switch {
+	case errors.Is(err, context.Canceled):
+		r.Kind, r.ExitCode = ErrorKindInterrupted, ExitCodeInterrupted
+	case errors.Is(err, ErrMissingToken) || IsUnauthorized(err) || errors.As(err, &scopeErr):
+		r.Kind, r.ExitCode = ErrorKindAuth, ExitCodeAuth
+	case errors.Is(err, ErrDuplicateRelease):
+		r.Kind, r.ExitCode = ErrorKindDuplicateRelease, ExitCodeDuplicateRelease
+	case errors.As(err, &processingErr) && processingErr.Pending:
+		r.Kind, r.ExitCode = ErrorKindTimeout, ExitCodeTimeout
+	case processingErr != nil:
+		r.Kind, r.ExitCode = ErrorKindProcessingFailed, ExitCodeProcessingFailed
+	case errors.As(err, &assertionErr):
+		r.Kind, r.ExitCode = ErrorKindAssertion, ExitCodeAssertion
+	case errors.As(err, &policyErr) || errors.As(err, &freezeErr):
+		r.Kind, r.ExitCode = ErrorKindPolicy, ExitCodePolicy
+	case errors.As(err, &validationErr):
+		r.Kind, r.ExitCode = ErrorKindValidation, ExitCodeValidation
+	case apiErr != nil:
+		r.Kind, r.ExitCode = ErrorKindAPI, ExitCodeAPI
+	case errors.Is(err, context.DeadlineExceeded) || (errors.As(err, &netErr) && netErr.Timeout()):
+		r.Kind, r.ExitCode = ErrorKindTimeout, ExitCodeTimeout
+	case errors.As(err, &coded):
+		r.Kind, r.ExitCode = ErrorKindError, coded.ExitCode()
+	default:
+		r.Kind, r.ExitCode = ErrorKindError, ExitCodeError
+	}
+	return r
+}
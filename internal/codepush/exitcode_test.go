@@ -0,0 +1,60 @@
+package codepush
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewErrorReport(t *testing.T) {
+	duplicate := newAPIError(400, []byte(`{"code":"ERR_BAD_REQUEST","message":"The uploaded package is identical to the contents of the specified deployment"}`))
+
+	tests := []struct {
+		name string
+		err  error
+		kind string
+		code int
+	}{
+		{name: "generic", err: errors.New("boom"), kind: ErrorKindError, code: ExitCodeError},
+		{name: "validation", err: Invalid(errors.New("rollout must be between 0 and 100")), kind: ErrorKindValidation, code: ExitCodeValidation},
+		{name: "missing token", err: Invalid(fmt.Errorf("%w: set BITRISE_API_TOKEN", ErrMissingToken)), kind: ErrorKindAuth, code: ExitCodeAuth},
+		{name: "unauthorized", err: fmt.Errorf("listing deployments: %w", newAPIError(401, []byte(`{"error":"invalid token"}`))), kind: ErrorKindAuth, code: ExitCodeAuth},
+		{name: "api", err: fmt.Errorf("push failed: %w", newAPIError(500, []byte("oops"))), kind: ErrorKindAPI, code: ExitCodeAPI},
+		{name: "duplicate release", err: fmt.Errorf("promote failed: %w", duplicate), kind: ErrorKindDuplicateRelease, code: ExitCodeDuplicateRelease},
+		{name: "assertion", err: &AssertionError{Flag: "--expect-label", Expected: "v2", Actual: "v3"}, kind: ErrorKindAssertion, code: ExitCodeAssertion},
+		{name: "processing timeout", err: fmt.Errorf("push failed: %w", &ProcessingError{Pending: true}), kind: ErrorKindTimeout, code: ExitCodeTimeout},
+		{name: "processing failed", err: fmt.Errorf("push failed: %w", &ProcessingError{Reason: "bad"}), kind: ErrorKindProcessingFailed, code: ExitCodeProcessingFailed},
+		{name: "policy", err: &PolicyError{Violations: []string{"mandatory releases require a description"}}, kind: ErrorKindPolicy, code: ExitCodePolicy},
+		{name: "freeze", err: fmt.Errorf("patch failed: %w", &FreezeError{Deployment: "Production", Window: "weekend", Until: time.Date(2024, 7, 1, 8, 0, 0, 0, time.UTC)}), kind: ErrorKindPolicy, code: ExitCodePolicy},
+		{name: "deadline", err: fmt.Errorf("uploading: %w", context.DeadlineExceeded), kind: ErrorKindTimeout, code: ExitCodeTimeout},
+		{name: "interrupted", err: fmt.Errorf("checking update status: %w", context.Canceled), kind: ErrorKindInterrupted, code: ExitCodeInterrupted},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			report := NewErrorReport(tt.err)
+			assert.Equal(t, tt.kind, report.Kind)
+			assert.Equal(t, tt.code, report.ExitCode)
+			assert.Equal(t, tt.err.Error(), report.Error)
+		})
+	}
+
+	t.Run("api details", func(t *testing.T) {
+		report := NewErrorReport(fmt.Errorf("wrapped: %w", duplicate))
+		assert.Equal(t, 400, report.StatusCode)
+		assert.Equal(t, "ERR_BAD_REQUEST", report.Code)
+	})
+}
+
+func TestInvalid(t *testing.T) {
+	assert.NoError(t, Invalid(nil))
+
+	err := Invalid(errors.New("bad flag"))
+	var validationErr *ValidationError
+	assert.ErrorAs(t, err, &validationErr)
+	assert.Equal(t, "bad flag", err.Error())
+	assert.Same(t, err, Invalid(err))
+}
@@ -0,0 +1,118 @@
+package codepush
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// ErrAssertionFailed is wrapped by every AssertionError.
+var ErrAssertionFailed = errors.New("assertion failed")
+
+// AssertionError reports an --expect flag whose expectation did not hold.
+// It is returned before any mutation is made.
+type AssertionError struct {
+	Flag     string
+	Expected string
+	Actual   string
+}
+
+func (e *AssertionError) Error() string {
+	return fmt.Sprintf("%s: %s expected %s, got %s", ErrAssertionFailed, e.Flag, e.Expected, e.Actual)
+}
+
+// Unwrap allows errors.Is(err, ErrAssertionFailed).
+func (e *AssertionError) Unwrap() error {
+	return ErrAssertionFailed
+}
+
+// ExitCode returns ExitCodeAssertion.
+func (e *AssertionError) ExitCode() int {
+	return ExitCodeAssertion
+}
+
+// nextLabel predicts the label the server assigns to the next release of a
+// deployment whose releases are labeled v1, v2, ...
+func nextLabel(updates []Update) (string, error) {
+	if len(updates) == 0 {
+		return "v1", nil
+	}
+	latest := updates[len(updates)-1].Label
+	n, ok := labelNumber(latest)
+	if !ok {
+		return "", fmt.Errorf("cannot predict the next label after %q", latest)
+	}
+	return "v" + strconv.Itoa(n+1), nil
+}
+
+// checkExpectLabel asserts that the next release pushed to the deployment
+// will be labeled expected.
+func checkExpectLabel(ctx context.Context, client updateLister, appID, deploymentID, expected string) error {
+	updates, err := client.ListUpdates(ctx, appID, deploymentID)
+	if err != nil {
+		return fmt.Errorf("checking --expect-label: listing updates: %w", err)
+	}
+	next, err := nextLabel(updates)
+	if err != nil {
+		return &AssertionError{Flag: "--expect-label", Expected: expected, Actual: err.Error()}
+	}
+	if next != expected {
+		return &AssertionError{Flag: "--expect-label", Expected: expected, Actual: next}
+	}
+	return nil
+}
+
+// checkExpectRollout asserts that the current rollout of an update equals
+// expected, a percentage from 0 to 100.
+func checkExpectRollout(ctx context.Context, client Client, appID, deploymentID, updateID, expected string) error {
+	want, err := strconv.Atoi(expected)
+	if err != nil || want < 0 || want > 100 {
+		return fmt.Errorf("--expect-current-rollout must be between 0 and 100, got %q", expected)
+	}
+	pkg, err := client.GetUpdate(ctx, appID, deploymentID, updateID)
+	if err != nil {
+		return fmt.Errorf("checking --expect-current-rollout: %w", err)
+	}
+	if got := int(math.Round(pkg.Rollout)); got != want {
+		return &AssertionError{Flag: "--expect-current-rollout", Expected: expected + "%", Actual: strconv.Itoa(got) + "%"}
+	}
+	return nil
+}
+
+// checkExpectSourceHash asserts that the release being promoted (label, or
+// the latest release when empty) has the expected package hash.
+func checkExpectSourceHash(ctx context.Context, client updateLister, appID, deploymentID, label, expected string) error {
+	updates, err := client.ListUpdates(ctx, appID, deploymentID)
+	if err != nil {
+		return fmt.Errorf("checking --expect-source-hash: listing updates: %w", err)
+	}
+	if len(updates) == 0 {
+		return &AssertionError{Flag: "--expect-source-hash", Expected: expected, Actual: "no releases in source deployment"}
+	}
+
+	source := updates[len(updates)-1]
+	if label != "" {
+		found := false
+		for _, u := range updates {
+			if u.Label == label {
+				source, found = u, true
+				break
+			}
+		}
+		if !found {
+			return fmt.Errorf("release %q not found in source deployment", label)
+		}
+	}
+
+	if !strings.EqualFold(source.Hash, expected) {
+		actual := source.Hash
+		if actual == "" {
+			actual = "no hash"
+		}
+		return &AssertionError{Flag: "--expect-source-hash", Expected: expected, Actual: fmt.Sprintf("%s (release %s)", actual, source.Label)}
+	}
+	return nil
+}
@@ -0,0 +1,195 @@
+package codepush
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNextLabel(t *testing.T) {
+	tests := []struct {
+		name    string
+		updates []Update
+		want    string
+		wantErr bool
+	}{
+		{name: "empty deployment", want: "v1"},
+		{name: "increments latest", updates: []Update{{Label: "v1"}, {Label: "v12"}}, want: "v13"},
+		{name: "non-numeric label", updates: []Update{{Label: "hotfix"}}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := nextLabel(tt.updates)
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestAssertionError(t *testing.T) {
+	var err error = &AssertionError{Flag: "--expect-label", Expected: "v13", Actual: "v14"}
+	wrapped := errors.Join(errors.New("push failed"), err)
+
+	assert.ErrorIs(t, wrapped, ErrAssertionFailed)
+	assert.Equal(t, "assertion failed: --expect-label expected v13, got v14", err.Error())
+
+	var coded interface{ ExitCode() int }
+	require.ErrorAs(t, wrapped, &coded)
+	assert.Equal(t, ExitCodeAssertion, coded.ExitCode())
+}
+
+func TestPushExpectLabel(t *testing.T) {
+	updates := func(appID, deploymentID string) ([]Update, error) {
+		return []Update{{ID: "pkg-1", Label: "v11"}, {ID: "pkg-2", Label: "v12"}}, nil
+	}
+
+	t.Run("mismatch aborts before upload", func(t *testing.T) {
+		client := &mockClient{
+			listUpdatesFunc: updates,
+			getUploadURLFunc: func(appID, deploymentID, updateID string, req UploadURLRequest) (*UploadURLResponse, error) {
+				t.Fatal("upload must not start after a failed assertion")
+				return nil, nil
+			},
+		}
+		opts := &PushOptions{
+			AppID:        "app-123",
+			DeploymentID: "00000000-0000-0000-0000-000000000001",
+			Token:        "test-token",
+			AppVersion:   "1.0.0",
+			BundlePath:   createTestBundleDir(t),
+			ExpectLabel:  "v12",
+		}
+
+		_, err := PushWithConfig(context.Background(), client, opts, fastPollConfig, testOut)
+		var assertErr *AssertionError
+		require.ErrorAs(t, err, &assertErr)
+		assert.Equal(t, "v13", assertErr.Actual)
+	})
+
+	t.Run("match proceeds", func(t *testing.T) {
+		client := &mockClient{
+			listUpdatesFunc: updates,
+			getUploadURLFunc: func(appID, deploymentID, updateID string, req UploadURLRequest) (*UploadURLResponse, error) {
+				return &UploadURLResponse{URL: "https://storage.example.com/upload", Method: "PUT"}, nil
+			},
+			getUpdateStatusFunc: func(appID, deploymentID, updateID string) (*UpdateStatus, error) {
+				return &UpdateStatus{UpdateID: updateID, Status: StatusProcessedValid}, nil
+			},
+		}
+		opts := &PushOptions{
+			AppID:        "app-123",
+			DeploymentID: "00000000-0000-0000-0000-000000000001",
+			Token:        "test-token",
+			AppVersion:   "1.0.0",
+			BundlePath:   createTestBundleDir(t),
+			ExpectLabel:  "v13",
+		}
+
+		_, err := PushWithConfig(context.Background(), client, opts, fastPollConfig, testOut)
+		require.NoError(t, err)
+	})
+}
+
+func TestPatchExpectCurrentRollout(t *testing.T) {
+	tests := []struct {
+		name        string
+		expect      string
+		wantAssert  bool
+		wantErr     bool
+		wantPatched bool
+	}{
+		{name: "match", expect: "50", wantPatched: true},
+		{name: "mismatch", expect: "25", wantAssert: true, wantErr: true},
+		{name: "invalid value", expect: "half", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			patched := false
+			client := &mockClient{
+				listUpdatesFunc: func(appID, deploymentID string) ([]Update, error) {
+					return []Update{{ID: "pkg-5", Label: "v5"}}, nil
+				},
+				getUpdateFunc: func(appID, deploymentID, updateID string) (*Update, error) {
+					return &Update{ID: updateID, Label: "v5", Rollout: 50}, nil
+				},
+				patchUpdateFunc: func(appID, deploymentID, updateID string, req PatchRequest) (*Update, error) {
+					patched = true
+					return &Update{ID: updateID, Label: "v5", Rollout: 100}, nil
+				},
+			}
+			opts := &PatchOptions{
+				AppID:                "app-123",
+				DeploymentID:         "00000000-0000-0000-0000-000000000001",
+				Token:                "test-token",
+				Rollout:              "100",
+				ExpectCurrentRollout: tt.expect,
+			}
+
+			_, err := Patch(context.Background(), client, opts, testOut)
+			if tt.wantErr {
+				require.Error(t, err)
+			} else {
+				require.NoError(t, err)
+			}
+			assert.Equal(t, tt.wantAssert, errors.Is(err, ErrAssertionFailed))
+			assert.Equal(t, tt.wantPatched, patched)
+		})
+	}
+}
+
+func TestPromoteExpectSourceHash(t *testing.T) {
+	tests := []struct {
+		name         string
+		label        string
+		expect       string
+		wantAssert   bool
+		wantPromoted bool
+	}{
+		{name: "latest matches case-insensitively", expect: "ABC222", wantPromoted: true},
+		{name: "labeled release matches", label: "v1", expect: "abc111", wantPromoted: true},
+		{name: "latest mismatch", expect: "abc111", wantAssert: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			promoted := false
+			client := &mockClient{
+				listUpdatesFunc: func(appID, deploymentID string) ([]Update, error) {
+					return []Update{
+						{ID: "pkg-1", Label: "v1", Hash: "abc111"},
+						{ID: "pkg-2", Label: "v2", Hash: "abc222"},
+					}, nil
+				},
+				promoteFunc: func(appID, deploymentID string, req PromoteRequest) (*Update, error) {
+					promoted = true
+					return &Update{ID: "pkg-new", Label: "v1"}, nil
+				},
+			}
+			opts := &PromoteOptions{
+				AppID:              "app-123",
+				SourceDeploymentID: "00000000-0000-0000-0000-000000000001",
+				DestDeploymentID:   "00000000-0000-0000-0000-000000000002",
+				Token:              "test-token",
+				Label:              tt.label,
+				ExpectSourceHash:   tt.expect,
+			}
+
+			_, err := Promote(context.Background(), client, opts, testOut)
+			if tt.wantAssert {
+				require.ErrorIs(t, err, ErrAssertionFailed)
+			} else {
+				require.NoError(t, err)
+			}
+			assert.Equal(t, tt.wantPromoted, promoted)
+		})
+	}
+}
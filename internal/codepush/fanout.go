@@ -0,0 +1,127 @@
+package codepush
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/bitrise-io/bitrise-plugins-codepush-cli/internal/output"
+)
+
+// FanOutTarget is a deployment a fan-out push releases to.
+type FanOutTarget struct {
+	// Name is the deployment as given by the user, a name or UUID.
+	Name string
+	ID   string
+}
+
+// DeploymentRelease is the release of a fan-out push in one deployment.
+type DeploymentRelease struct {
+	Deployment   string `json:"deployment"`
+	DeploymentID string `json:"deployment_id"`
+	UpdateID     string `json:"package_id,omitempty"`
+	Label        string `json:"label,omitempty"`
+	// Promoted is set when the release is a copy of the one uploaded to
+	// the first deployment.
+	Promoted bool   `json:"promoted"`
+	Error    string `json:"error,omitempty"`
+}
+
+// FanOutResult is the outcome of a push released to several deployments.
+type FanOutResult struct {
+	// Push is the upload to the first deployment.
+	Push        *PushResult         `json:"push"`
+	Deployments []DeploymentRelease `json:"deployments"`
+	Succeeded   int                 `json:"succeeded"`
+	Failed      int                 `json:"failed"`
+}
+
+// FanOutError reports the deployments a fan-out push could not be released
+// to. The release exists in the others.
+type FanOutError struct {
+	Total  int
+	Failed []string
+	errs   []error
+}
+
+func (e *FanOutError) Error() string {
+	parts := make([]string, len(e.Failed))
+	for i, name := range e.Failed {
+		parts[i] = name + ": " + e.errs[i].Error()
+	}
+	return fmt.Sprintf("release failed in %d of %d deployments: %s", len(e.Failed), e.Total, strings.Join(parts, "; "))
+}
+
+// Unwrap returns the error of each failed deployment, so the exit code
+// reflects the kind of failure.
+func (e *FanOutError) Unwrap() []error {
+	return e.errs
+}
+
+// PushFanOut uploads the bundle once, to the first target, and promotes the
+// resulting release to each of the other targets with the same app version,
+// description, mandatory and disabled flags, and rollout. The upload and
+// processing happen once, so they are not repeated per deployment.
+//
+// A failed upload to the first target returns its error, as nothing was
+// released. A failed promotion does not stop the remaining ones: the result
+// is returned along with a *FanOutError listing the failures.
+func PushFanOut(ctx context.Context, client Client, opts *PushOptions, targets []FanOutTarget, pollCfg PollConfig, out *output.Writer) (*FanOutResult, error) {
+	if len(targets) == 0 {
+		return nil, Invalid(errors.New("at least one deployment is required"))
+	}
+	if opts.NoWait && len(targets) > 1 {
+		return nil, Invalid(errors.New("--no-wait cannot be used with several deployments: the release must be processed before it is promoted"))
+	}
+
+	first := targets[0]
+	pushOpts := *opts
+	pushOpts.DeploymentID = first.ID
+	pushed, err := PushWithConfig(ctx, client, &pushOpts, pollCfg, out)
+	if err != nil {
+		return nil, fmt.Errorf("push to %s failed: %w", first.Name, err)
+	}
+
+	release := DeploymentRelease{Deployment: first.Name, DeploymentID: pushed.DeploymentID, UpdateID: pushed.UpdateID}
+	if u, err := client.GetUpdate(ctx, opts.AppID, pushed.DeploymentID, pushed.UpdateID); err == nil {
+		release.Label = u.Label
+	}
+	result := &FanOutResult{Push: pushed, Deployments: []DeploymentRelease{release}, Succeeded: 1}
+
+	req := PromoteRequest{
+		UpdateID:    pushed.UpdateID,
+		AppVersion:  pushOpts.AppVersion,
+		Description: opts.Description,
+		Mandatory:   strconv.FormatBool(opts.Mandatory),
+		Disabled:    strconv.FormatBool(opts.Disabled),
+		Rollout:     strconv.Itoa(opts.Rollout),
+	}
+	fanOutErr := &FanOutError{Total: len(targets)}
+	for _, target := range targets[1:] {
+		req.TargetDeploymentID = target.ID
+		release := DeploymentRelease{Deployment: target.Name, DeploymentID: target.ID, Promoted: true}
+
+		step := out.StartStep("Releasing to %s", target.Name)
+		u, err := client.Promote(ctx, opts.AppID, pushed.DeploymentID, req)
+		if err != nil {
+			step.Cancel()
+			out.Warning("release to %s failed: %v", target.Name, err)
+			release.Error = err.Error()
+			fanOutErr.Failed = append(fanOutErr.Failed, target.Name)
+			fanOutErr.errs = append(fanOutErr.errs, err)
+			result.Failed++
+		} else {
+			step.Done()
+			release.UpdateID, release.Label = u.ID, u.Label
+			result.Succeeded++
+		}
+		result.Deployments = append(result.Deployments, release)
+	}
+
+	if len(fanOutErr.Failed) > 0 {
+		return result, fanOutErr
+	}
+	return result, nil
+}
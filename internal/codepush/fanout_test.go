@@ -0,0 +1,118 @@
+package codepush
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPushFanOut(t *testing.T) {
+	targets := []FanOutTarget{
+		{Name: "Staging", ID: "00000000-0000-0000-0000-000000000001"},
+		{Name: "QA", ID: "00000000-0000-0000-0000-000000000002"},
+		{Name: "Beta", ID: "00000000-0000-0000-0000-000000000003"},
+	}
+	newOpts := func(t *testing.T) *PushOptions {
+		return &PushOptions{
+			AppID:      "app-123",
+			Token:      "test-token",
+			AppVersion: "1.0",
+			Mandatory:  true,
+			Rollout:    25,
+			BundlePath: createTestBundleDir(t),
+			Full:       true,
+		}
+	}
+
+	t.Run("uploads once and promotes to the others", func(t *testing.T) {
+		uploads := 0
+		var promoted []PromoteRequest
+		client := &mockClient{
+			getUploadURLFunc: func(_, deploymentID, _ string, _ UploadURLRequest) (*UploadURLResponse, error) {
+				uploads++
+				assert.Equal(t, targets[0].ID, deploymentID)
+				return &UploadURLResponse{URL: "https://example.com/upload", Method: "PUT"}, nil
+			},
+			promoteFunc: func(_, deploymentID string, req PromoteRequest) (*Update, error) {
+				assert.Equal(t, targets[0].ID, deploymentID)
+				promoted = append(promoted, req)
+				return &Update{ID: "pkg-" + req.TargetDeploymentID, Label: "v7"}, nil
+			},
+		}
+
+		result, err := PushFanOut(context.Background(), client, newOpts(t), targets, fastPollConfig, testOut)
+		require.NoError(t, err)
+
+		assert.Equal(t, 1, uploads)
+		require.Len(t, promoted, 2)
+		assert.Equal(t, targets[1].ID, promoted[0].TargetDeploymentID)
+		assert.Equal(t, result.Push.UpdateID, promoted[0].UpdateID)
+		assert.Equal(t, "1.0", promoted[0].AppVersion)
+		assert.Equal(t, "true", promoted[0].Mandatory)
+		assert.Equal(t, "25", promoted[0].Rollout)
+
+		assert.Equal(t, 3, result.Succeeded)
+		assert.Zero(t, result.Failed)
+		require.Len(t, result.Deployments, 3)
+		assert.False(t, result.Deployments[0].Promoted)
+		assert.True(t, result.Deployments[2].Promoted)
+		assert.Equal(t, "Beta", result.Deployments[2].Deployment)
+	})
+
+	t.Run("continues after a failed promotion", func(t *testing.T) {
+		client := &mockClient{
+			promoteFunc: func(_, _ string, req PromoteRequest) (*Update, error) {
+				if req.TargetDeploymentID == targets[1].ID {
+					return nil, &APIError{StatusCode: 409, Message: "conflict"}
+				}
+				return &Update{ID: "pkg-beta", Label: "v3"}, nil
+			},
+		}
+
+		result, err := PushFanOut(context.Background(), client, newOpts(t), targets, fastPollConfig, testOut)
+		require.Error(t, err)
+
+		var fanOutErr *FanOutError
+		require.ErrorAs(t, err, &fanOutErr)
+		assert.Equal(t, []string{"QA"}, fanOutErr.Failed)
+		assert.Contains(t, err.Error(), "release failed in 1 of 3 deployments: QA")
+		assert.Equal(t, ExitCodeAPI, NewErrorReport(err).ExitCode)
+
+		require.NotNil(t, result)
+		assert.Equal(t, 2, result.Succeeded)
+		assert.Equal(t, 1, result.Failed)
+		assert.NotEmpty(t, result.Deployments[1].Error)
+		assert.Equal(t, "v3", result.Deployments[2].Label)
+	})
+
+	t.Run("failed upload releases nothing", func(t *testing.T) {
+		promotes := 0
+		client := &mockClient{
+			getUploadURLFunc: func(_, _, _ string, _ UploadURLRequest) (*UploadURLResponse, error) {
+				return nil, errors.New("boom")
+			},
+			promoteFunc: func(_, _ string, _ PromoteRequest) (*Update, error) {
+				promotes++
+				return &Update{}, nil
+			},
+		}
+
+		result, err := PushFanOut(context.Background(), client, newOpts(t), targets, fastPollConfig, testOut)
+		require.Error(t, err)
+		assert.Nil(t, result)
+		assert.Contains(t, err.Error(), "push to Staging failed")
+		assert.Zero(t, promotes)
+	})
+
+	t.Run("rejects no-wait", func(t *testing.T) {
+		opts := newOpts(t)
+		opts.NoWait = true
+
+		_, err := PushFanOut(context.Background(), &mockClient{}, opts, targets, fastPollConfig, testOut)
+		var validationErr *ValidationError
+		require.ErrorAs(t, err, &validationErr)
+	})
+}
@@ -0,0 +1,147 @@
+package codepush
+
+import (
+	"cmp"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Sort orders of a release history.
+const (
+	HistorySortCreated = "created"
+	HistorySortSize    = "size"
+)
+
+// HistoryFilter selects and orders the releases of a deployment history.
+// The zero value keeps every release in server order.
+type HistoryFilter struct {
+	// AppVersion keeps releases targeting exactly this app version.
+	AppVersion string
+	// MandatoryOnly keeps mandatory releases.
+	MandatoryOnly bool
+	// Since keeps releases created at or after it. Releases without a
+	// parseable creation time are dropped when it is set.
+	Since time.Time
+	// CreatedBy keeps releases whose author's username or email contains
+	// it, ignoring case.
+	CreatedBy string
+	// Tag keeps releases with this tag, ignoring case.
+	Tag string
+	// LabelFrom and LabelTo keep releases labeled vN with N in the range.
+	// 0 leaves that end open. Releases with other labels are dropped when
+	// either is set.
+	LabelFrom, LabelTo int
+	// Sort is HistorySortCreated, HistorySortSize, or "" for server order.
+	Sort string
+}
+
+// ParseSince parses a --since value: a date (2006-01-02, midnight UTC) or
+// an RFC 3339 timestamp.
+func ParseSince(s string) (time.Time, error) {
+	if t, err := time.Parse(time.DateOnly, s); err == nil {
+		return t, nil
+	}
+	t, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid date %q: use YYYY-MM-DD or an RFC 3339 timestamp", s)
+	}
+	return t, nil
+}
+
+// ParseLabelRange parses a --label-range value such as v10..v20. Either end
+// may be left out, e.g. v10.. or ..v20; a missing end is returned as 0.
+func ParseLabelRange(s string) (from, to int, err error) {
+	lo, hi, ok := strings.Cut(s, "..")
+	if !ok || (lo == "" && hi == "") {
+		return 0, 0, fmt.Errorf("invalid label range %q: use v10..v20, v10.., or ..v20", s)
+	}
+	if lo != "" {
+		if from, ok = labelNumber(lo); !ok {
+			return 0, 0, fmt.Errorf("invalid label range %q: %q is not a label like v10", s, lo)
+		}
+	}
+	if hi != "" {
+		if to, ok = labelNumber(hi); !ok {
+			return 0, 0, fmt.Errorf("invalid label range %q: %q is not a label like v20", s, hi)
+		}
+	}
+	if to > 0 && from > to {
+		return 0, 0, fmt.Errorf("invalid label range %q: %s is after %s", s, lo, hi)
+	}
+	return from, to, nil
+}
+
+// ValidateHistorySort checks a --sort value.
+func ValidateHistorySort(s string) error {
+	switch s {
+	case "", HistorySortCreated, HistorySortSize:
+		return nil
+	}
+	return fmt.Errorf("invalid --sort %q: must be %s or %s", s, HistorySortCreated, HistorySortSize)
+}
+
+// Match reports whether f keeps u.
+func (f HistoryFilter) Match(u Update) bool {
+	if f.AppVersion != "" && u.AppVersion != f.AppVersion {
+		return false
+	}
+	if f.MandatoryOnly && !u.Mandatory {
+		return false
+	}
+	if !f.Since.IsZero() {
+		created, err := time.Parse(time.RFC3339, u.CreatedAt)
+		if err != nil || created.Before(f.Since) {
+			return false
+		}
+	}
+	if f.CreatedBy != "" && !matchCreator(u.CreatedBy, f.CreatedBy) {
+		return false
+	}
+	if f.Tag != "" && !u.HasTag(f.Tag) {
+		return false
+	}
+	if f.LabelFrom > 0 || f.LabelTo > 0 {
+		n, ok := labelNumber(u.Label)
+		if !ok || n < f.LabelFrom || (f.LabelTo > 0 && n > f.LabelTo) {
+			return false
+		}
+	}
+	return true
+}
+
+// Compare orders two releases by f.Sort, oldest or smallest first. It
+// returns 0 for every pair when f.Sort is empty, so a stable sort keeps
+// server order.
+func (f HistoryFilter) Compare(a, b Update) int {
+	switch f.Sort {
+	case HistorySortCreated:
+		return compareCreatedAt(a.CreatedAt, b.CreatedAt)
+	case HistorySortSize:
+		return cmp.Compare(a.FileSizeBytes, b.FileSizeBytes)
+	}
+	return 0
+}
+
+// matchCreator reports whether the username or email of c contains s,
+// ignoring case.
+func matchCreator(c *UpdateCreator, s string) bool {
+	if c == nil {
+		return false
+	}
+	s = strings.ToLower(s)
+	return strings.Contains(strings.ToLower(c.Username), s) || strings.Contains(strings.ToLower(c.Email), s)
+}
+
+// labelNumber returns N of a release label vN.
+func labelNumber(label string) (int, bool) {
+	if !strings.HasPrefix(label, "v") {
+		return 0, false
+	}
+	n, err := strconv.Atoi(label[1:])
+	if err != nil || n < 1 {
+		return 0, false
+	}
+	return n, true
+}
@@ -0,0 +1,139 @@
+package codepush
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseSince(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		want    time.Time
+		wantErr bool
+	}{
+		{name: "date", input: "2024-06-01", want: time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)},
+		{name: "timestamp", input: "2024-06-01T12:30:00Z", want: time.Date(2024, 6, 1, 12, 30, 0, 0, time.UTC)},
+		{name: "invalid", input: "June 1st", wantErr: true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := ParseSince(tc.input)
+			if tc.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.True(t, tc.want.Equal(got), "got %s", got)
+		})
+	}
+}
+
+func TestParseLabelRange(t *testing.T) {
+	tests := []struct {
+		input    string
+		from, to int
+		wantErr  bool
+	}{
+		{input: "v10..v20", from: 10, to: 20},
+		{input: "v10..", from: 10},
+		{input: "..v20", to: 20},
+		{input: "v5..v5", from: 5, to: 5},
+		{input: "..", wantErr: true},
+		{input: "v10", wantErr: true},
+		{input: "10..20", wantErr: true},
+		{input: "v20..v10", wantErr: true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.input, func(t *testing.T) {
+			from, to, err := ParseLabelRange(tc.input)
+			if tc.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.from, from)
+			assert.Equal(t, tc.to, to)
+		})
+	}
+}
+
+func TestValidateHistorySort(t *testing.T) {
+	assert.NoError(t, ValidateHistorySort(""))
+	assert.NoError(t, ValidateHistorySort(HistorySortCreated))
+	assert.NoError(t, ValidateHistorySort(HistorySortSize))
+	assert.Error(t, ValidateHistorySort("label"))
+}
+
+func TestHistoryFilterMatch(t *testing.T) {
+	updates := []Update{
+		{Label: "v9", AppVersion: "1.1.0", CreatedAt: "2024-05-20T10:00:00Z", CreatedBy: &UpdateCreator{Email: "bob@example.com"}},
+		{Label: "v10", AppVersion: "1.2.0", Mandatory: true, CreatedAt: "2024-06-01T00:00:00Z", CreatedBy: &UpdateCreator{Email: "alice@example.com"}},
+		{Label: "v11", AppVersion: "1.2.0", CreatedAt: "2024-06-15T08:00:00Z", CreatedBy: &UpdateCreator{Username: "Alice"}, Description: "Fix crash\n\nTags: hotfix, JIRA-1234"},
+		{Label: "hotfix", AppVersion: "1.2.0", CreatedAt: "not a date"},
+	}
+
+	tests := []struct {
+		name   string
+		filter HistoryFilter
+		want   []string
+	}{
+		{name: "no filter", want: []string{"v9", "v10", "v11", "hotfix"}},
+		{name: "app version", filter: HistoryFilter{AppVersion: "1.2.0"}, want: []string{"v10", "v11", "hotfix"}},
+		{name: "mandatory only", filter: HistoryFilter{MandatoryOnly: true}, want: []string{"v10"}},
+		{name: "since", filter: HistoryFilter{Since: time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)}, want: []string{"v10", "v11"}},
+		{name: "created by", filter: HistoryFilter{CreatedBy: "alice@"}, want: []string{"v10"}},
+		{name: "created by ignores case", filter: HistoryFilter{CreatedBy: "alice"}, want: []string{"v10", "v11"}},
+		{name: "label range", filter: HistoryFilter{LabelFrom: 10, LabelTo: 20}, want: []string{"v10", "v11"}},
+		{name: "tag", filter: HistoryFilter{Tag: "jira-1234"}, want: []string{"v11"}},
+		{name: "open label range", filter: HistoryFilter{LabelTo: 10}, want: []string{"v9", "v10"}},
+		{name: "combined", filter: HistoryFilter{AppVersion: "1.2.0", CreatedBy: "alice"}, want: []string{"v10", "v11"}},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			var got []string
+			for _, u := range updates {
+				if tc.filter.Match(u) {
+					got = append(got, u.Label)
+				}
+			}
+			assert.Equal(t, tc.want, got)
+		})
+	}
+}
+
+func TestHistoryFilterCompare(t *testing.T) {
+	updates := []Update{
+		{Label: "v1", FileSizeBytes: 100, CreatedAt: "2024-06-03T00:00:00Z"},
+		{Label: "v2", FileSizeBytes: 300, CreatedAt: "2024-06-01T00:00:00Z"},
+		{Label: "v3", FileSizeBytes: 200, CreatedAt: "2024-06-02T00:00:00Z"},
+	}
+
+	tests := []struct {
+		sort string
+		want []string
+	}{
+		{sort: "", want: []string{"v1", "v2", "v3"}},
+		{sort: HistorySortCreated, want: []string{"v2", "v3", "v1"}},
+		{sort: HistorySortSize, want: []string{"v1", "v3", "v2"}},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.sort, func(t *testing.T) {
+			sorted := slices.Clone(updates)
+			slices.SortStableFunc(sorted, HistoryFilter{Sort: tc.sort}.Compare)
+			var got []string
+			for _, u := range sorted {
+				got = append(got, u.Label)
+			}
+			assert.Equal(t, tc.want, got)
+		})
+	}
+}
@@ -0,0 +1,93 @@
+package codepush
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/bitrise-io/bitrise-plugins-codepush-cli/internal/output"
+)
+
+// logFollower is the subset of Client needed by FollowLogs.
+type logFollower interface {
+	statusChecker
+	ListUpdateLogs(ctx context.Context, appID, deploymentID, updateID string) ([]UpdateLogEntry, error)
+}
+
+// FormatLogEntry renders a log entry as "[timestamp] LEVEL message".
+func FormatLogEntry(e UpdateLogEntry) string {
+	var b strings.Builder
+	if e.Timestamp != "" {
+		fmt.Fprintf(&b, "[%s] ", e.Timestamp)
+	}
+	if e.Level != "" {
+		b.WriteString(strings.ToUpper(e.Level))
+		b.WriteString(" ")
+	}
+	b.WriteString(e.Message)
+	return b.String()
+}
+
+// FollowLogs streams the processing log of an update to emit until the update
+// reaches a terminal status, which is returned. Entries are emitted once each,
+// in server order.
+func FollowLogs(ctx context.Context, client logFollower, ref UpdateRef, cfg PollConfig, emit func(UpdateLogEntry)) (*UpdateStatus, error) {
+	seen := 0
+	flush := func() error {
+		entries, err := client.ListUpdateLogs(ctx, ref.AppID, ref.DeploymentID, ref.UpdateID)
+		if err != nil {
+			return err
+		}
+		for _, e := range entries[min(seen, len(entries)):] {
+			emit(e)
+		}
+		seen = max(seen, len(entries))
+		return nil
+	}
+
+	for attempt := range cfg.MaxAttempts {
+		if err := flush(); err != nil {
+			return nil, err
+		}
+
+		status, err := client.GetUpdateStatus(ctx, ref.AppID, ref.DeploymentID, ref.UpdateID)
+		if err != nil {
+			return nil, fmt.Errorf("checking update status: %w", err)
+		}
+
+		if status.Status == StatusProcessedValid || status.Status == StatusProcessedError {
+			// Pick up entries written between the last poll and completion.
+			if err := flush(); err != nil {
+				return nil, err
+			}
+			return status, nil
+		}
+
+		if attempt < cfg.MaxAttempts-1 {
+			select {
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			case <-time.After(cfg.Interval):
+			}
+		}
+	}
+
+	totalWait := time.Duration(cfg.MaxAttempts) * cfg.Interval
+	return nil, fmt.Errorf("update processing timed out after %s", totalWait)
+}
+
+// printProcessingLogs prints the server-side log of a failed update so the
+// status reason comes with its underlying detail. Best-effort: servers that
+// do not expose logs are silently skipped.
+func printProcessingLogs(ctx context.Context, client logFollower, ref UpdateRef, out *output.Writer) {
+	entries, err := client.ListUpdateLogs(ctx, ref.AppID, ref.DeploymentID, ref.UpdateID)
+	if err != nil || len(entries) == 0 {
+		return
+	}
+
+	out.Info("Server processing log:")
+	for _, e := range entries {
+		out.Println("  %s", FormatLogEntry(e))
+	}
+}
@@ -0,0 +1,99 @@
+package codepush
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFormatLogEntry(t *testing.T) {
+	tests := []struct {
+		name  string
+		entry UpdateLogEntry
+		want  string
+	}{
+		{
+			name:  "all fields",
+			entry: UpdateLogEntry{Timestamp: "2026-01-01T00:00:00Z", Level: "warn", Message: "large asset"},
+			want:  "[2026-01-01T00:00:00Z] WARN large asset",
+		},
+		{
+			name:  "message only",
+			entry: UpdateLogEntry{Message: "validating"},
+			want:  "validating",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.want, FormatLogEntry(tc.entry))
+		})
+	}
+}
+
+func TestFollowLogs(t *testing.T) {
+	ref := UpdateRef{AppID: "app-1", DeploymentID: "dep-1", UpdateID: "pkg-1"}
+
+	t.Run("emits each entry once until terminal status", func(t *testing.T) {
+		all := []UpdateLogEntry{{Message: "unpacking"}, {Message: "validating"}, {Message: "invalid bundle format"}}
+		polls := 0
+		client := &mockClient{
+			listUpdateLogsFunc: func(appID, deploymentID, updateID string) ([]UpdateLogEntry, error) {
+				return all[:min(polls+1, len(all))], nil
+			},
+			getUpdateStatusFunc: func(appID, deploymentID, updateID string) (*UpdateStatus, error) {
+				polls++
+				if polls < 3 {
+					return &UpdateStatus{Status: "processing"}, nil
+				}
+				return &UpdateStatus{Status: StatusProcessedError, StatusReason: "invalid bundle format"}, nil
+			},
+		}
+
+		var got []string
+		status, err := FollowLogs(context.Background(), client, ref, fastPollConfig, func(e UpdateLogEntry) {
+			got = append(got, e.Message)
+		})
+		require.NoError(t, err)
+
+		assert.Equal(t, StatusProcessedError, status.Status)
+		assert.Equal(t, []string{"unpacking", "validating", "invalid bundle format"}, got)
+	})
+
+	t.Run("returns log errors", func(t *testing.T) {
+		client := &mockClient{
+			listUpdateLogsFunc: func(appID, deploymentID, updateID string) ([]UpdateLogEntry, error) {
+				return nil, ErrLogsUnavailable
+			},
+		}
+
+		_, err := FollowLogs(context.Background(), client, ref, fastPollConfig, func(UpdateLogEntry) {})
+		assert.ErrorIs(t, err, ErrLogsUnavailable)
+	})
+
+	t.Run("times out", func(t *testing.T) {
+		client := &mockClient{
+			getUpdateStatusFunc: func(appID, deploymentID, updateID string) (*UpdateStatus, error) {
+				return &UpdateStatus{Status: "processing"}, nil
+			},
+		}
+
+		_, err := FollowLogs(context.Background(), client, ref, fastPollConfig, func(UpdateLogEntry) {})
+		require.Error(t, err)
+		assert.ErrorContains(t, err, "timed out")
+	})
+
+	t.Run("returns status errors", func(t *testing.T) {
+		client := &mockClient{
+			getUpdateStatusFunc: func(appID, deploymentID, updateID string) (*UpdateStatus, error) {
+				return nil, errors.New("HTTP 500")
+			},
+		}
+
+		_, err := FollowLogs(context.Background(), client, ref, fastPollConfig, func(UpdateLogEntry) {})
+		assert.ErrorContains(t, err, "checking update status")
+	})
+}
@@ -0,0 +1,163 @@
+package codepush
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Metrics export formats.
+const (
+	MetricsFormatPrometheus  = "prometheus"
+	MetricsFormatOpenMetrics = "openmetrics"
+)
+
+// DeploymentMetrics groups the update metrics of one deployment.
+type DeploymentMetrics struct {
+	DeploymentID string          `json:"deployment_id"`
+	Deployment   string          `json:"deployment"`
+	Updates      []UpdateMetrics `json:"updates"`
+}
+
+// metricsClient is the subset of Client needed by CollectMetrics.
+type metricsClient interface {
+	deploymentLister
+	GetDeploymentMetrics(ctx context.Context, appID, deploymentID string) ([]UpdateMetrics, error)
+}
+
+// CollectMetrics fetches update metrics for every deployment of an app.
+func CollectMetrics(ctx context.Context, client metricsClient, appID string) ([]DeploymentMetrics, error) {
+	deployments, err := client.ListDeployments(ctx, appID)
+	if err != nil {
+		return nil, fmt.Errorf("listing deployments: %w", err)
+	}
+
+	result := make([]DeploymentMetrics, 0, len(deployments))
+	for _, d := range deployments {
+		updates, err := client.GetDeploymentMetrics(ctx, appID, d.ID)
+		if err != nil {
+			return nil, fmt.Errorf("deployment %s: %w", d.Name, err)
+		}
+		result = append(result, DeploymentMetrics{DeploymentID: d.ID, Deployment: d.Name, Updates: updates})
+	}
+
+	return result, nil
+}
+
+// ReleaseMetrics combines the metrics of one release with its rollout state.
+type ReleaseMetrics struct {
+	UpdateMetrics
+	AppVersion string  `json:"app_version,omitempty"`
+	Rollout    float64 `json:"rollout"`
+	Disabled   bool    `json:"disabled"`
+
+	// ActiveShare is the percentage of the deployment's active installs
+	// running this release.
+	ActiveShare float64 `json:"active_share"`
+	// FailureRate is the percentage of install attempts that failed and were
+	// rolled back on the device.
+	FailureRate float64 `json:"failure_rate"`
+}
+
+// releaseMetricsClient is the subset of Client needed by DeploymentReleaseMetrics.
+type releaseMetricsClient interface {
+	updateLister
+	GetDeploymentMetrics(ctx context.Context, appID, deploymentID string) ([]UpdateMetrics, error)
+}
+
+// DeploymentReleaseMetrics returns the metrics of every release in a
+// deployment, oldest first like the release history. Releases without
+// reported metrics have zero counts.
+func DeploymentReleaseMetrics(ctx context.Context, client releaseMetricsClient, appID, deploymentID string) ([]ReleaseMetrics, error) {
+	updates, err := client.ListUpdates(ctx, appID, deploymentID)
+	if err != nil {
+		return nil, fmt.Errorf("listing updates: %w", err)
+	}
+	metrics, err := client.GetDeploymentMetrics(ctx, appID, deploymentID)
+	if err != nil {
+		return nil, fmt.Errorf("getting deployment metrics: %w", err)
+	}
+
+	byLabel := make(map[string]UpdateMetrics, len(metrics))
+	var totalActive int64
+	for _, m := range metrics {
+		byLabel[m.Label] = m
+		totalActive += m.Active
+	}
+
+	result := make([]ReleaseMetrics, len(updates))
+	for i, u := range updates {
+		m, ok := byLabel[u.Label]
+		if !ok {
+			m = UpdateMetrics{Label: u.Label}
+		}
+		r := ReleaseMetrics{UpdateMetrics: m, AppVersion: u.AppVersion, Rollout: u.Rollout, Disabled: u.Disabled}
+		if totalActive > 0 {
+			r.ActiveShare = float64(m.Active) / float64(totalActive) * 100
+		}
+		if attempts := m.Installed + m.Failed; attempts > 0 {
+			r.FailureRate = float64(m.Failed) / float64(attempts) * 100
+		}
+		result[i] = r
+	}
+	return result, nil
+}
+
+// metricFamily describes one exported metric and how to read it from UpdateMetrics.
+type metricFamily struct {
+	name  string // without the _total suffix for counters
+	kind  string
+	help  string
+	value func(UpdateMetrics) int64
+}
+
+var metricFamilies = []metricFamily{
+	{name: "codepush_active_installs", kind: "gauge", help: "Devices currently running the update.", value: func(m UpdateMetrics) int64 { return m.Active }},
+	{name: "codepush_downloads", kind: "counter", help: "Total downloads of the update.", value: func(m UpdateMetrics) int64 { return m.Downloaded }},
+	{name: "codepush_installs", kind: "counter", help: "Total successful installs of the update.", value: func(m UpdateMetrics) int64 { return m.Installed }},
+	{name: "codepush_install_failures", kind: "counter", help: "Total failed installs (rollbacks) of the update.", value: func(m UpdateMetrics) int64 { return m.Failed }},
+}
+
+// WriteMetrics renders metrics in the Prometheus text exposition format or
+// OpenMetrics, suitable for the node_exporter textfile collector or a push
+// gateway. Every sample carries app_id, deployment, and label labels.
+func WriteMetrics(w io.Writer, format, appID string, metrics []DeploymentMetrics) error {
+	if format != MetricsFormatPrometheus && format != MetricsFormatOpenMetrics {
+		return fmt.Errorf("invalid metrics format %q: must be prometheus or openmetrics", format)
+	}
+
+	bw := bufio.NewWriter(w)
+	for _, f := range metricFamilies {
+		sample := f.name
+		if f.kind == "counter" {
+			sample += "_total"
+		}
+		family := sample
+		if format == MetricsFormatOpenMetrics {
+			family = f.name
+		}
+
+		fmt.Fprintf(bw, "# HELP %s %s\n", family, f.help)
+		fmt.Fprintf(bw, "# TYPE %s %s\n", family, f.kind)
+		for _, d := range metrics {
+			for _, u := range d.Updates {
+				fmt.Fprintf(bw, "%s{app_id=\"%s\",deployment=\"%s\",label=\"%s\"} %d\n",
+					sample, escapeLabel(appID), escapeLabel(d.Deployment), escapeLabel(u.Label), f.value(u))
+			}
+		}
+	}
+	if format == MetricsFormatOpenMetrics {
+		bw.WriteString("# EOF\n")
+	}
+
+	return bw.Flush()
+}
+
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// escapeLabel escapes a label value per the exposition format.
+func escapeLabel(v string) string {
+	return labelEscaper.Replace(v)
+}
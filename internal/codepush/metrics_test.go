@@ -0,0 +1,133 @@
+package codepush
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCollectMetrics(t *testing.T) {
+	t.Run("fetches metrics for every deployment", func(t *testing.T) {
+		client := &mockClient{
+			listDeploymentsFunc: func(appID string) ([]Deployment, error) {
+				return []Deployment{{ID: "dep-1", Name: "Staging"}, {ID: "dep-2", Name: "Production"}}, nil
+			},
+			getMetricsFunc: func(appID, deploymentID string) ([]UpdateMetrics, error) {
+				return []UpdateMetrics{{Label: "v1", Active: int64(len(deploymentID))}}, nil
+			},
+		}
+
+		got, err := CollectMetrics(context.Background(), client, "app-1")
+		require.NoError(t, err)
+		require.Len(t, got, 2)
+		assert.Equal(t, "Production", got[1].Deployment)
+		assert.Equal(t, "v1", got[1].Updates[0].Label)
+	})
+
+	t.Run("names the deployment on error", func(t *testing.T) {
+		client := &mockClient{
+			listDeploymentsFunc: func(appID string) ([]Deployment, error) {
+				return []Deployment{{ID: "dep-1", Name: "Staging"}}, nil
+			},
+			getMetricsFunc: func(appID, deploymentID string) ([]UpdateMetrics, error) {
+				return nil, errors.New("API returned HTTP 500")
+			},
+		}
+
+		_, err := CollectMetrics(context.Background(), client, "app-1")
+		assert.ErrorContains(t, err, "deployment Staging")
+	})
+}
+
+func TestDeploymentReleaseMetrics(t *testing.T) {
+	t.Run("joins metrics with releases", func(t *testing.T) {
+		client := &mockClient{
+			listUpdatesFunc: func(appID, deploymentID string) ([]Update, error) {
+				return []Update{
+					{Label: "v1", AppVersion: "1.0.0", Rollout: 100},
+					{Label: "v2", AppVersion: "1.0.0", Rollout: 25},
+					{Label: "v3", AppVersion: "1.0.0", Rollout: 100, Disabled: true},
+				}, nil
+			},
+			getMetricsFunc: func(appID, deploymentID string) ([]UpdateMetrics, error) {
+				return []UpdateMetrics{
+					{Label: "v1", Active: 300, Downloaded: 400, Installed: 400},
+					{Label: "v2", Active: 100, Downloaded: 110, Installed: 95, Failed: 5},
+				}, nil
+			},
+		}
+
+		got, err := DeploymentReleaseMetrics(context.Background(), client, "app-1", "dep-1")
+		require.NoError(t, err)
+		require.Len(t, got, 3)
+
+		assert.InDelta(t, 75, got[0].ActiveShare, 0.001)
+		assert.Zero(t, got[0].FailureRate)
+
+		assert.Equal(t, "v2", got[1].Label)
+		assert.InDelta(t, 25, got[1].Rollout, 0.001)
+		assert.InDelta(t, 25, got[1].ActiveShare, 0.001)
+		assert.InDelta(t, 5, got[1].FailureRate, 0.001)
+
+		assert.Equal(t, "v3", got[2].Label)
+		assert.True(t, got[2].Disabled)
+		assert.Zero(t, got[2].Active)
+	})
+
+	t.Run("wraps metrics errors", func(t *testing.T) {
+		client := &mockClient{
+			getMetricsFunc: func(appID, deploymentID string) ([]UpdateMetrics, error) {
+				return nil, errors.New("API returned HTTP 500")
+			},
+		}
+
+		_, err := DeploymentReleaseMetrics(context.Background(), client, "app-1", "dep-1")
+		assert.ErrorContains(t, err, "getting deployment metrics")
+	})
+}
+
+func TestWriteMetrics(t *testing.T) {
+	metrics := []DeploymentMetrics{{
+		Deployment: "Production",
+		Updates:    []UpdateMetrics{{Label: "v3", Active: 120, Downloaded: 150, Installed: 140, Failed: 2}},
+	}}
+
+	t.Run("prometheus", func(t *testing.T) {
+		var buf bytes.Buffer
+		require.NoError(t, WriteMetrics(&buf, MetricsFormatPrometheus, "app-1", metrics))
+		got := buf.String()
+
+		assert.Contains(t, got, "# TYPE codepush_active_installs gauge\n")
+		assert.Contains(t, got, `codepush_active_installs{app_id="app-1",deployment="Production",label="v3"} 120`)
+		assert.Contains(t, got, "# TYPE codepush_downloads_total counter\n")
+		assert.Contains(t, got, `codepush_install_failures_total{app_id="app-1",deployment="Production",label="v3"} 2`)
+		assert.NotContains(t, got, "# EOF")
+	})
+
+	t.Run("openmetrics", func(t *testing.T) {
+		var buf bytes.Buffer
+		require.NoError(t, WriteMetrics(&buf, MetricsFormatOpenMetrics, "app-1", metrics))
+		got := buf.String()
+
+		assert.Contains(t, got, "# TYPE codepush_downloads counter\n")
+		assert.Contains(t, got, `codepush_downloads_total{app_id="app-1",deployment="Production",label="v3"} 150`)
+		assert.True(t, strings.HasSuffix(got, "# EOF\n"))
+	})
+
+	t.Run("escapes label values", func(t *testing.T) {
+		var buf bytes.Buffer
+		escaped := []DeploymentMetrics{{Deployment: `QA "blue"`, Updates: []UpdateMetrics{{Label: "v1"}}}}
+		require.NoError(t, WriteMetrics(&buf, MetricsFormatPrometheus, "app-1", escaped))
+		assert.Contains(t, buf.String(), `deployment="QA \"blue\""`)
+	})
+
+	t.Run("rejects unknown format", func(t *testing.T) {
+		err := WriteMetrics(&bytes.Buffer{}, "influx", "app-1", metrics)
+		assert.ErrorContains(t, err, "invalid metrics format")
+	})
+}
@@ -0,0 +1,149 @@
+package codepush
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/bitrise-io/bitrise-plugins-codepush-cli/internal/output"
+)
+
+// DefaultAppParallelism is the number of apps pushed to at once by PushApps
+// when no limit is given.
+const DefaultAppParallelism = 4
+
+// AppTarget is a connected app a multi-app push releases to, with its
+// resolved deployments.
+type AppTarget struct {
+	ID string
+	// Name labels the app in output; it is the ID when no name was given.
+	Name        string
+	Deployments []FanOutTarget
+	// BundlePath and RuntimeVersion, when BundlePath is set, replace those of
+	// the push options for this app, so apps of different platforms each get
+	// the bundle built for theirs.
+	BundlePath     string
+	RuntimeVersion string
+}
+
+// AppPushResult is the outcome of a multi-app push in one app.
+type AppPushResult struct {
+	AppID       string              `json:"app_id"`
+	Name        string              `json:"name"`
+	Deployments []DeploymentRelease `json:"deployments"`
+	Error       string              `json:"error,omitempty"`
+}
+
+// MultiAppResult is the outcome of a push to several apps.
+type MultiAppResult struct {
+	Apps      []AppPushResult `json:"apps"`
+	Succeeded int             `json:"succeeded"`
+	Failed    int             `json:"failed"`
+}
+
+// MultiAppError lists the apps a multi-app push failed for. The push
+// succeeded for the others.
+type MultiAppError struct {
+	Total  int
+	Failed []string
+	errs   []error
+}
+
+func (e *MultiAppError) Error() string {
+	return fmt.Sprintf("push failed for %d of %d apps: %s", len(e.Failed), e.Total, strings.Join(e.Failed, ", "))
+}
+
+// Unwrap returns the error of each failed app, so the exit code reflects
+// the kind of failure.
+func (e *MultiAppError) Unwrap() []error {
+	return e.errs
+}
+
+// PushApps pushes the same bundle to each app, or its own bundle to an app
+// with AppTarget.BundlePath set, with at most parallelism apps in flight.
+// Each app gets its own upload, released to its deployments as PushFanOut
+// does. Output of each app is prefixed with its name. A failure in one app does not stop the others: the result is
+// returned along with a *MultiAppError listing the failed apps. Results
+// keep the order of apps.
+func PushApps(ctx context.Context, client Client, opts *PushOptions, apps []AppTarget, parallelism int, pollCfg PollConfig, out *output.Writer) (*MultiAppResult, error) {
+	if parallelism <= 0 {
+		parallelism = DefaultAppParallelism
+	}
+
+	result := &MultiAppResult{Apps: make([]AppPushResult, len(apps))}
+	errs := make([]error, len(apps))
+	forEachParallel(len(apps), parallelism, func(i int) {
+		app := apps[i]
+		appOpts := *opts
+		appOpts.AppID = app.ID
+		if app.BundlePath != "" {
+			appOpts.BundlePath, appOpts.RuntimeVersion = app.BundlePath, app.RuntimeVersion
+		}
+
+		r := AppPushResult{AppID: app.ID, Name: app.Name, Deployments: []DeploymentRelease{}}
+		fanOut, err := PushFanOut(ctx, client, &appOpts, app.Deployments, pollCfg, out.WithPrefix("["+app.Name+"]"))
+		if fanOut != nil {
+			r.Deployments = fanOut.Deployments
+		}
+		if err != nil {
+			r.Error = err.Error()
+			errs[i] = err
+		}
+		result.Apps[i] = r
+	})
+
+	multiErr := &MultiAppError{Total: len(apps)}
+	for i, err := range errs {
+		if err == nil {
+			result.Succeeded++
+			continue
+		}
+		result.Failed++
+		multiErr.Failed = append(multiErr.Failed, apps[i].Name)
+		multiErr.errs = append(multiErr.errs, err)
+	}
+	if len(multiErr.Failed) > 0 {
+		return result, multiErr
+	}
+	return result, nil
+}
+
+// LoadAppManifest reads the apps of a multi-app push from a file with one
+// app per line: its UUID, optionally followed by a name for the output.
+// Blank lines and lines starting with # are ignored.
+func LoadAppManifest(path string) ([]AppTarget, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading app manifest: %w", err)
+	}
+	defer func() { _ = f.Close() }()
+
+	var apps []AppTarget
+	seen := make(map[string]bool)
+	scanner := bufio.NewScanner(f)
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		id := strings.Fields(line)[0]
+		name := strings.TrimSpace(strings.TrimPrefix(line, id))
+		if name == "" {
+			name = id
+		}
+		if seen[id] {
+			return nil, fmt.Errorf("%s:%d: app %s is listed twice", path, n, id)
+		}
+		seen[id] = true
+		apps = append(apps, AppTarget{ID: id, Name: name})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("reading app manifest: %w", err)
+	}
+	if len(apps) == 0 {
+		return nil, fmt.Errorf("app manifest %s lists no apps", path)
+	}
+	return apps, nil
+}
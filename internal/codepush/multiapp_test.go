@@ -0,0 +1,93 @@
+package codepush
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPushApps(t *testing.T) {
+	deployment := []FanOutTarget{{Name: "Production", ID: "00000000-0000-0000-0000-000000000001"}}
+	apps := []AppTarget{
+		{ID: "app-a", Name: "Brand A", Deployments: deployment},
+		{ID: "app-b", Name: "Brand B", Deployments: deployment},
+		{ID: "app-c", Name: "Brand C", Deployments: deployment},
+	}
+	opts := &PushOptions{
+		Token:      "test-token",
+		AppVersion: "1.0.0",
+		Rollout:    100,
+		BundlePath: createTestBundleDir(t),
+		Full:       true,
+	}
+
+	var mu sync.Mutex
+	uploaded := map[string]int{}
+	client := &mockClient{
+		getUploadURLFunc: func(appID, _, _ string, _ UploadURLRequest) (*UploadURLResponse, error) {
+			if appID == "app-b" {
+				return nil, errors.New("boom")
+			}
+			mu.Lock()
+			uploaded[appID]++
+			mu.Unlock()
+			return &UploadURLResponse{URL: "https://example.com/upload", Method: "PUT"}, nil
+		},
+	}
+
+	result, err := PushApps(context.Background(), client, opts, apps, 2, fastPollConfig, testOut)
+	require.Error(t, err)
+
+	var multiErr *MultiAppError
+	require.ErrorAs(t, err, &multiErr)
+	assert.Equal(t, []string{"Brand B"}, multiErr.Failed)
+	assert.Equal(t, "push failed for 1 of 3 apps: Brand B", err.Error())
+
+	assert.Equal(t, map[string]int{"app-a": 1, "app-c": 1}, uploaded)
+	assert.Equal(t, 2, result.Succeeded)
+	assert.Equal(t, 1, result.Failed)
+	require.Len(t, result.Apps, 3)
+	assert.Equal(t, "app-a", result.Apps[0].AppID)
+	assert.Len(t, result.Apps[0].Deployments, 1)
+	assert.Contains(t, result.Apps[1].Error, "boom")
+	assert.Empty(t, result.Apps[1].Deployments)
+	assert.Empty(t, opts.AppID, "the caller's options should not be modified")
+}
+
+func TestLoadAppManifest(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    []AppTarget
+		wantErr string
+	}{
+		{
+			name:    "ids and names",
+			content: "# white-label apps\napp-a Brand A\n\n  app-b\n",
+			want:    []AppTarget{{ID: "app-a", Name: "Brand A"}, {ID: "app-b", Name: "app-b"}},
+		},
+		{name: "duplicate", content: "app-a\napp-a Again\n", wantErr: ":2: app app-a is listed twice"},
+		{name: "empty", content: "# nothing\n", wantErr: "lists no apps"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "apps.txt")
+			require.NoError(t, os.WriteFile(path, []byte(tt.content), 0o644))
+
+			apps, err := LoadAppManifest(path)
+			if tt.wantErr != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, apps)
+		})
+	}
+}
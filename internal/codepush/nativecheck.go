@@ -0,0 +1,218 @@
+package codepush
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/bitrise-io/bitrise-plugins-codepush-cli/internal/output"
+	"github.com/bitrise-io/bitrise-plugins-codepush-cli/internal/workspace"
+)
+
+// ErrNativeChange is returned by Push when FailOnNativeChange is set and the
+// new bundle references native modules that the previous release did not.
+var ErrNativeChange = errors.New("bundle references native modules not present in the previous release")
+
+// hermesMagic is the little-endian magic number at the start of Hermes bytecode files.
+var hermesMagic = []byte{0xC6, 0x1F, 0xBC, 0x03, 0xC1, 0x03, 0x19, 0x1F}
+
+// nativeModulePatterns match the common ways JS code reaches into native code.
+// Property names survive minification, so these also work on release bundles.
+var nativeModulePatterns = []*regexp.Regexp{
+	regexp.MustCompile(`NativeModules\.([A-Za-z_$][\w$]*)`),
+	regexp.MustCompile(`NativeModules\[["']([\w$]+)["']\]`),
+	regexp.MustCompile(`\.getEnforcing(?:<[^>()]*>)?\(\s*["']([\w$]+)["']`),
+	regexp.MustCompile(`TurboModuleRegistry\.get(?:<[^>()]*>)?\(\s*["']([\w$]+)["']`),
+	regexp.MustCompile(`requireNativeComponent(?:<[^>()]*>)?\(\s*["']([\w$]+)["']`),
+}
+
+// NativeChangeReport describes native modules referenced by a new bundle that
+// the previous release in the deployment did not reference.
+type NativeChangeReport struct {
+	PreviousLabel string   `json:"previous_label"`
+	Added         []string `json:"added_native_modules"`
+}
+
+// ScanNativeModules returns the sorted, de-duplicated names of native modules
+// referenced by a JavaScript bundle. Hermes bytecode cannot be analyzed and
+// yields an error.
+func ScanNativeModules(data []byte) ([]string, error) {
+	if bytes.HasPrefix(data, hermesMagic) {
+		return nil, errors.New("bundle is Hermes bytecode, native module references cannot be analyzed")
+	}
+
+	seen := make(map[string]bool)
+	for _, re := range nativeModulePatterns {
+		for _, m := range re.FindAllSubmatch(data, -1) {
+			seen[string(m[1])] = true
+		}
+	}
+
+	names := make([]string, 0, len(seen))
+	for name := range seen {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names, nil
+}
+
+// isBundleFile reports whether name looks like a JS bundle produced by Metro or Expo.
+func isBundleFile(name string) bool {
+	return strings.HasSuffix(name, ".jsbundle") || strings.HasSuffix(name, ".bundle")
+}
+
+// readLocalBundle returns the contents of the JS bundle at the top level of dir.
+func readLocalBundle(dir string) ([]byte, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("reading bundle directory: %w", err)
+	}
+	for _, e := range entries {
+		if !e.IsDir() && isBundleFile(e.Name()) {
+			return os.ReadFile(filepath.Join(dir, e.Name()))
+		}
+	}
+	return nil, fmt.Errorf("no .jsbundle or .bundle file found in %s", dir)
+}
+
+// readZipBundle returns the contents of the shallowest JS bundle in a package zip.
+func readZipBundle(zipPath string) ([]byte, error) {
+	zr, err := zip.OpenReader(zipPath)
+	if err != nil {
+		return nil, fmt.Errorf("opening package: %w", err)
+	}
+	defer func() { _ = zr.Close() }()
+
+	var best *zip.File
+	for _, f := range zr.File {
+		if !isBundleFile(f.Name) {
+			continue
+		}
+		if best == nil || strings.Count(path.Clean(f.Name), "/") < strings.Count(path.Clean(best.Name), "/") {
+			best = f
+		}
+	}
+	if best == nil {
+		return nil, errors.New("no .jsbundle or .bundle file found in package")
+	}
+
+	rc, err := best.Open()
+	if err != nil {
+		return nil, fmt.Errorf("reading %s: %w", best.Name, err)
+	}
+	defer func() { _ = rc.Close() }()
+
+	return io.ReadAll(rc)
+}
+
+// nativeCheckClient is the subset of Client needed by CheckNativeChanges.
+type nativeCheckClient interface {
+	updateLister
+	updateDownloader
+}
+
+// NativeCheckOptions selects the bundle CheckNativeChanges checks and the
+// deployment whose latest release it compares against.
+type NativeCheckOptions struct {
+	AppID        string
+	DeploymentID string
+	BundleDir    string
+	// Workspace holds the downloaded release; nil downloads it to the
+	// system temp directory.
+	Workspace *workspace.Workspace
+}
+
+// CheckNativeChanges compares the native module references of the bundle
+// against the latest release in the deployment. Returns a nil report when
+// the deployment has no releases yet.
+func CheckNativeChanges(ctx context.Context, client nativeCheckClient, opts *NativeCheckOptions) (*NativeChangeReport, error) {
+	newData, err := readLocalBundle(opts.BundleDir)
+	if err != nil {
+		return nil, err
+	}
+	newModules, err := ScanNativeModules(newData)
+	if err != nil {
+		return nil, err
+	}
+
+	updates, err := client.ListUpdates(ctx, opts.AppID, opts.DeploymentID)
+	if err != nil {
+		return nil, fmt.Errorf("listing updates: %w", err)
+	}
+	if len(updates) == 0 {
+		return nil, nil //nolint:nilnil // nothing to compare against
+	}
+	previous := updates[len(updates)-1]
+
+	zipPath, err := downloadUpdate(ctx, client, opts.Workspace, UpdateRef{AppID: opts.AppID, DeploymentID: opts.DeploymentID, UpdateID: previous.ID})
+	if err != nil {
+		return nil, fmt.Errorf("downloading release %s: %w", previous.Label, err)
+	}
+	defer opts.Workspace.Remove(zipPath)
+
+	oldData, err := readZipBundle(zipPath)
+	if err != nil {
+		return nil, fmt.Errorf("release %s: %w", previous.Label, err)
+	}
+	oldModules, err := ScanNativeModules(oldData)
+	if err != nil {
+		return nil, fmt.Errorf("release %s: %w", previous.Label, err)
+	}
+
+	report := &NativeChangeReport{PreviousLabel: previous.Label}
+	for _, m := range newModules {
+		if !slices.Contains(oldModules, m) {
+			report.Added = append(report.Added, m)
+		}
+	}
+	return report, nil
+}
+
+// warnNativeChanges runs CheckNativeChanges as a step of the push workflow
+// when opts.CheckNativeChanges or opts.FailOnNativeChange is set. Detected
+// changes are a warning, or ErrNativeChange with FailOnNativeChange. So are
+// analysis problems, such as a Hermes bytecode bundle or a failed download:
+// a warning, or an error with FailOnNativeChange.
+func warnNativeChanges(ctx context.Context, client nativeCheckClient, opts *PushOptions, deploymentID string, out *output.Writer) error {
+	if !opts.CheckNativeChanges && !opts.FailOnNativeChange {
+		return nil
+	}
+	step := out.StartStep("Checking for new native module references")
+	report, err := CheckNativeChanges(ctx, client, &NativeCheckOptions{
+		AppID:        opts.AppID,
+		DeploymentID: deploymentID,
+		BundleDir:    opts.BundlePath,
+		Workspace:    opts.Workspace,
+	})
+	if err != nil {
+		step.Cancel()
+		if opts.FailOnNativeChange {
+			return fmt.Errorf("checking for native module changes: %w", err)
+		}
+		out.Warning("skipping native module check: %v", err)
+		return nil
+	}
+	step.Done()
+
+	if report == nil || len(report.Added) == 0 {
+		return nil
+	}
+
+	added := strings.Join(report.Added, ", ")
+	if opts.FailOnNativeChange {
+		return fmt.Errorf("%w (compared to %s): %s", ErrNativeChange, report.PreviousLabel, added)
+	}
+
+	out.Warning("bundle references native modules not used by %s: %s", report.PreviousLabel, added)
+	out.Info("Binaries built without these modules may crash after installing this update. Ship a new store build first, or target its version with --app-version.")
+	return nil
+}
@@ -0,0 +1,206 @@
+package codepush
+
+import (
+	"archive/zip"
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestScanNativeModules(t *testing.T) {
+	tests := []struct {
+		name   string
+		bundle string
+		want   []string
+	}{
+		{
+			name:   "property access on NativeModules",
+			bundle: `var a=r(d[0]).NativeModules.RNCamera;var b=NativeModules.Haptics.trigger()`,
+			want:   []string{"Haptics", "RNCamera"},
+		},
+		{
+			name:   "bracket access on NativeModules",
+			bundle: `NativeModules["RNShare"].open(); NativeModules['Foo']`,
+			want:   []string{"Foo", "RNShare"},
+		},
+		{
+			name:   "TurboModule lookups",
+			bundle: `TurboModuleRegistry.get('RNMaps');r(d[1]).getEnforcing("PlatformConstants");x.getEnforcing<Spec>('Keychain')`,
+			want:   []string{"Keychain", "PlatformConstants", "RNMaps"},
+		},
+		{
+			name:   "native components",
+			bundle: `requireNativeComponent('RNSVGPath')`,
+			want:   []string{"RNSVGPath"},
+		},
+		{
+			name:   "deduplicates references",
+			bundle: `NativeModules.A;NativeModules.A;NativeModules["A"]`,
+			want:   []string{"A"},
+		},
+		{
+			name:   "no references",
+			bundle: `console.log("hello")`,
+			want:   []string{},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := ScanNativeModules([]byte(tc.bundle))
+			require.NoError(t, err)
+			assert.Equal(t, tc.want, got)
+		})
+	}
+
+	t.Run("rejects Hermes bytecode", func(t *testing.T) {
+		_, err := ScanNativeModules(append(append([]byte{}, hermesMagic...), "NativeModules.X"...))
+		require.Error(t, err)
+		assert.ErrorContains(t, err, "Hermes bytecode")
+	})
+}
+
+// writeTestPackageZip creates a package zip with the given bundle contents.
+func writeTestPackageZip(t *testing.T, w io.Writer, bundle string) {
+	t.Helper()
+	zw := zip.NewWriter(w)
+	fw, err := zw.Create("CodePush/main.jsbundle")
+	require.NoError(t, err)
+	_, err = fw.Write([]byte(bundle))
+	require.NoError(t, err)
+	require.NoError(t, zw.Close())
+}
+
+func nativeCheckBundleDir(t *testing.T, bundle string) string {
+	t.Helper()
+	dir := filepath.Join(t.TempDir(), "CodePush")
+	require.NoError(t, os.Mkdir(dir, 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "main.jsbundle"), []byte(bundle), 0o644))
+	return dir
+}
+
+func TestCheckNativeChanges(t *testing.T) {
+	previous := []Update{{ID: "pkg-1", Label: "v1"}, {ID: "pkg-2", Label: "v2"}}
+
+	t.Run("reports modules added since the latest release", func(t *testing.T) {
+		var downloadedID string
+		client := &mockClient{
+			listUpdatesFunc: func(appID, deploymentID string) ([]Update, error) { return previous, nil },
+			getDownloadURLFunc: func(appID, deploymentID, updateID string) (*DownloadURLResponse, error) {
+				downloadedID = updateID
+				return &DownloadURLResponse{URL: "https://example.com/pkg.zip"}, nil
+			},
+			downloadFileFunc: func(fileURL string, w io.Writer) error {
+				writeTestPackageZip(t, w, `NativeModules.Existing`)
+				return nil
+			},
+		}
+
+		dir := nativeCheckBundleDir(t, `NativeModules.Existing;NativeModules.NewCamera`)
+		report, err := CheckNativeChanges(context.Background(), client, &NativeCheckOptions{AppID: "app-1", DeploymentID: "dep-1", BundleDir: dir})
+		require.NoError(t, err)
+		require.NotNil(t, report)
+
+		assert.Equal(t, "pkg-2", downloadedID)
+		assert.Equal(t, "v2", report.PreviousLabel)
+		assert.Equal(t, []string{"NewCamera"}, report.Added)
+	})
+
+	t.Run("returns nil report for an empty deployment", func(t *testing.T) {
+		dir := nativeCheckBundleDir(t, `NativeModules.A`)
+		report, err := CheckNativeChanges(context.Background(), &mockClient{}, &NativeCheckOptions{AppID: "app-1", DeploymentID: "dep-1", BundleDir: dir})
+		require.NoError(t, err)
+		assert.Nil(t, report)
+	})
+
+	t.Run("returns error when download fails", func(t *testing.T) {
+		client := &mockClient{
+			listUpdatesFunc: func(appID, deploymentID string) ([]Update, error) { return previous, nil },
+			downloadFileFunc: func(fileURL string, w io.Writer) error {
+				return errors.New("download failed with HTTP 403")
+			},
+		}
+
+		dir := nativeCheckBundleDir(t, `NativeModules.A`)
+		_, err := CheckNativeChanges(context.Background(), client, &NativeCheckOptions{AppID: "app-1", DeploymentID: "dep-1", BundleDir: dir})
+		require.Error(t, err)
+		assert.ErrorContains(t, err, "downloading release v2")
+	})
+}
+
+func TestPushNativeChangeGate(t *testing.T) {
+	var downloads int
+	client := &mockClient{
+		listUpdatesFunc: func(appID, deploymentID string) ([]Update, error) {
+			return []Update{{ID: "pkg-1", Label: "v1"}}, nil
+		},
+		downloadFileFunc: func(fileURL string, w io.Writer) error {
+			downloads++
+			writeTestPackageZip(t, w, `console.log("no native")`)
+			return nil
+		},
+	}
+
+	newOpts := func(bundle string, check, fail bool) *PushOptions {
+		return &PushOptions{
+			AppID:              "app-123",
+			DeploymentID:       "00000000-0000-0000-0000-000000000001",
+			Token:              "test-token",
+			AppVersion:         "1.0.0",
+			Rollout:            100,
+			BundlePath:         nativeCheckBundleDir(t, bundle),
+			Full:               true,
+			CheckNativeChanges: check,
+			FailOnNativeChange: fail,
+		}
+	}
+	hermesBundle := string(hermesMagic) + "bytecode"
+
+	tests := []struct {
+		name          string
+		bundle        string
+		check         bool
+		fail          bool
+		wantDownloads int
+		wantErr       string
+	}{
+		{name: "skipped by default", bundle: `NativeModules.Brand`},
+		{name: "warns and continues when checking", bundle: `NativeModules.Brand`, check: true, wantDownloads: 1},
+		{name: "fails when FailOnNativeChange is set", bundle: `NativeModules.Brand`, fail: true, wantDownloads: 1, wantErr: "Brand"},
+		{name: "warns when the bundle cannot be analyzed", bundle: hermesBundle, check: true},
+		{name: "fails when the bundle cannot be analyzed and FailOnNativeChange is set", bundle: hermesBundle, fail: true, wantErr: "checking for native module changes: bundle is Hermes bytecode"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			downloads = 0
+			_, err := PushWithConfig(context.Background(), client, newOpts(tt.bundle, tt.check, tt.fail), fastPollConfig, testOut)
+			assert.Equal(t, tt.wantDownloads, downloads)
+			if tt.wantErr == "" {
+				require.NoError(t, err)
+				return
+			}
+			require.Error(t, err)
+			assert.ErrorContains(t, err, tt.wantErr)
+			if tt.bundle != hermesBundle {
+				assert.ErrorIs(t, err, ErrNativeChange)
+			}
+		})
+	}
+
+	t.Run("fails when the previous release cannot be downloaded and FailOnNativeChange is set", func(t *testing.T) {
+		failing := *client
+		failing.downloadFileFunc = func(fileURL string, w io.Writer) error {
+			return errors.New("connection reset")
+		}
+		_, err := PushWithConfig(context.Background(), &failing, newOpts(`NativeModules.Brand`, false, true), fastPollConfig, testOut)
+		require.Error(t, err)
+		assert.ErrorContains(t, err, "checking for native module changes: downloading release v1")
+	})
+}
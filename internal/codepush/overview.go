@@ -0,0 +1,95 @@
+package codepush
+
+import (
+	"context"
+	"fmt"
+	"sync"
+)
+
+// DefaultOverviewParallelism is the number of deployments fetched at once by
+// Overview when no limit is given.
+const DefaultOverviewParallelism = 4
+
+type overviewClient interface {
+	deploymentLister
+	updateLister
+	GetUpdateStatus(ctx context.Context, appID, deploymentID, updateID string) (*UpdateStatus, error)
+}
+
+// DeploymentOverview is the latest release of one deployment.
+type DeploymentOverview struct {
+	DeploymentID string  `json:"deployment_id"`
+	Deployment   string  `json:"deployment"`
+	Latest       *Update `json:"latest,omitempty"`
+	// Status is the processing status of the latest release, empty when it
+	// could not be fetched.
+	Status string `json:"status,omitempty"`
+	// Error is set when the deployment's releases could not be listed.
+	Error string `json:"error,omitempty"`
+}
+
+// Overview fetches the latest release of every deployment of an app, with at
+// most parallelism deployments in flight. A failure for one deployment is
+// recorded in its Error rather than failing the whole overview. Results keep
+// the order of ListDeployments.
+func Overview(ctx context.Context, client overviewClient, appID string, parallelism int) ([]DeploymentOverview, error) {
+	deployments, err := client.ListDeployments(ctx, appID)
+	if err != nil {
+		return nil, fmt.Errorf("listing deployments: %w", err)
+	}
+	if parallelism <= 0 {
+		parallelism = DefaultOverviewParallelism
+	}
+
+	result := make([]DeploymentOverview, len(deployments))
+	forEachParallel(len(deployments), parallelism, func(i int) {
+		result[i] = deploymentOverview(ctx, client, appID, deployments[i])
+	})
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// forEachParallel calls fn for every index below n, with at most parallelism
+// calls running at once, and returns when all calls have finished.
+func forEachParallel(n, parallelism int, fn func(i int)) {
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for range min(parallelism, n) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				fn(i)
+			}
+		}()
+	}
+
+	for i := range n {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+}
+
+func deploymentOverview(ctx context.Context, client overviewClient, appID string, d Deployment) DeploymentOverview {
+	o := DeploymentOverview{DeploymentID: d.ID, Deployment: d.Name}
+
+	updates, err := client.ListUpdates(ctx, appID, d.ID)
+	if err != nil {
+		o.Error = err.Error()
+		return o
+	}
+	if len(updates) == 0 {
+		return o
+	}
+
+	latest := updates[len(updates)-1]
+	o.Latest = &latest
+	if status, err := client.GetUpdateStatus(ctx, appID, d.ID, latest.ID); err == nil {
+		o.Status = status.Status
+	}
+	return o
+}
@@ -0,0 +1,90 @@
+package codepush
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOverview(t *testing.T) {
+	t.Run("latest release per deployment in order", func(t *testing.T) {
+		client := &mockClient{
+			listDeploymentsFunc: func(appID string) ([]Deployment, error) {
+				return []Deployment{{ID: "d1", Name: "Staging"}, {ID: "d2", Name: "Production"}, {ID: "d3", Name: "Empty"}}, nil
+			},
+			listUpdatesFunc: func(appID, deploymentID string) ([]Update, error) {
+				switch deploymentID {
+				case "d1":
+					return []Update{{ID: "u1", Label: "v1"}, {ID: "u2", Label: "v2"}}, nil
+				case "d2":
+					return nil, errors.New("forbidden")
+				}
+				return nil, nil
+			},
+			getUpdateStatusFunc: func(appID, deploymentID, updateID string) (*UpdateStatus, error) {
+				return &UpdateStatus{UpdateID: updateID, Status: StatusProcessedValid}, nil
+			},
+		}
+
+		got, err := Overview(context.Background(), client, "app-123", 2)
+		require.NoError(t, err)
+		require.Len(t, got, 3)
+
+		assert.Equal(t, "Staging", got[0].Deployment)
+		require.NotNil(t, got[0].Latest)
+		assert.Equal(t, "v2", got[0].Latest.Label)
+		assert.Equal(t, StatusProcessedValid, got[0].Status)
+
+		assert.Equal(t, "Production", got[1].Deployment)
+		assert.Equal(t, "forbidden", got[1].Error)
+
+		assert.Equal(t, "Empty", got[2].Deployment)
+		assert.Nil(t, got[2].Latest)
+	})
+
+	t.Run("bounds parallelism", func(t *testing.T) {
+		var inFlight, peak atomic.Int32
+		client := &mockClient{
+			listDeploymentsFunc: func(appID string) ([]Deployment, error) {
+				deployments := make([]Deployment, 10)
+				for i := range deployments {
+					deployments[i] = Deployment{ID: fmt.Sprintf("d%d", i), Name: fmt.Sprintf("dep-%d", i)}
+				}
+				return deployments, nil
+			},
+			listUpdatesFunc: func(appID, deploymentID string) ([]Update, error) {
+				n := inFlight.Add(1)
+				for {
+					p := peak.Load()
+					if n <= p || peak.CompareAndSwap(p, n) {
+						break
+					}
+				}
+				time.Sleep(5 * time.Millisecond)
+				inFlight.Add(-1)
+				return nil, nil
+			},
+		}
+
+		got, err := Overview(context.Background(), client, "app-123", 3)
+		require.NoError(t, err)
+		assert.Len(t, got, 10)
+		assert.LessOrEqual(t, peak.Load(), int32(3))
+	})
+
+	t.Run("list deployments error", func(t *testing.T) {
+		client := &mockClient{
+			listDeploymentsFunc: func(appID string) ([]Deployment, error) {
+				return nil, errors.New("unauthorized")
+			},
+		}
+		_, err := Overview(context.Background(), client, "app-123", 0)
+		require.ErrorContains(t, err, "listing deployments")
+	})
+}
@@ -0,0 +1,42 @@
+package codepush
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// deploymentGetter is the subset of Client needed by VerifyDeployment.
+type deploymentGetter interface {
+	deploymentLister
+	GetDeployment(ctx context.Context, appID, deploymentID string) (*Deployment, error)
+}
+
+// VerifyDeployment checks that deploymentID exists under appID. A deployment
+// UUID copied from another app otherwise only fails later with a bare 404,
+// so this runs before destructive or mutating operations. The error lists
+// the app's deployments as suggestions when they can be fetched.
+func VerifyDeployment(ctx context.Context, client deploymentGetter, appID, deploymentID string) error {
+	_, err := client.GetDeployment(ctx, appID, deploymentID)
+	if err == nil {
+		return nil
+	}
+	if !errors.Is(err, ErrDeploymentNotFound) {
+		return fmt.Errorf("verifying deployment: %w", err)
+	}
+
+	msg := fmt.Sprintf("%s does not belong to app %s", deploymentID, appID)
+	hint := "check --app-id (or CODEPUSH_APP_ID), or pass the deployment by name"
+
+	deployments, listErr := client.ListDeployments(ctx, appID)
+	if listErr != nil || len(deployments) == 0 {
+		return fmt.Errorf("%w: %s; %s", ErrDeploymentNotFound, msg, hint)
+	}
+
+	names := make([]string, len(deployments))
+	for i, d := range deployments {
+		names[i] = fmt.Sprintf("%s (%s)", d.Name, d.ID)
+	}
+	return fmt.Errorf("%w: %s; deployments in this app: %s; %s", ErrDeploymentNotFound, msg, strings.Join(names, ", "), hint)
+}
@@ -0,0 +1,68 @@
+package codepush
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVerifyDeployment(t *testing.T) {
+	notFound := func(appID, deploymentID string) (*Deployment, error) {
+		return nil, fmt.Errorf("getting deployment: %w", ErrDeploymentNotFound)
+	}
+
+	t.Run("passes when deployment exists under the app", func(t *testing.T) {
+		client := &mockClient{
+			getDeploymentFunc: func(appID, deploymentID string) (*Deployment, error) {
+				return &Deployment{ID: deploymentID, Name: "Staging"}, nil
+			},
+		}
+		require.NoError(t, VerifyDeployment(context.Background(), client, "app-1", "dep-1"))
+	})
+
+	t.Run("names the app and suggests its deployments", func(t *testing.T) {
+		client := &mockClient{
+			getDeploymentFunc: notFound,
+			listDeploymentsFunc: func(appID string) ([]Deployment, error) {
+				return []Deployment{{ID: "dep-a", Name: "Staging"}, {ID: "dep-b", Name: "Production"}}, nil
+			},
+		}
+
+		err := VerifyDeployment(context.Background(), client, "app-1", "dep-other")
+		require.Error(t, err)
+		assert.ErrorIs(t, err, ErrDeploymentNotFound)
+		assert.ErrorContains(t, err, "dep-other does not belong to app app-1")
+		assert.ErrorContains(t, err, "Staging (dep-a), Production (dep-b)")
+	})
+
+	t.Run("omits suggestions when listing fails", func(t *testing.T) {
+		client := &mockClient{
+			getDeploymentFunc: notFound,
+			listDeploymentsFunc: func(appID string) ([]Deployment, error) {
+				return nil, errors.New("HTTP 500")
+			},
+		}
+
+		err := VerifyDeployment(context.Background(), client, "app-1", "dep-other")
+		require.Error(t, err)
+		assert.ErrorContains(t, err, "does not belong to app app-1")
+		assert.NotContains(t, err.Error(), "deployments in this app")
+	})
+
+	t.Run("wraps other errors", func(t *testing.T) {
+		client := &mockClient{
+			getDeploymentFunc: func(appID, deploymentID string) (*Deployment, error) {
+				return nil, errors.New("API returned HTTP 500")
+			},
+		}
+
+		err := VerifyDeployment(context.Background(), client, "app-1", "dep-1")
+		require.Error(t, err)
+		assert.NotErrorIs(t, err, ErrDeploymentNotFound)
+		assert.ErrorContains(t, err, "verifying deployment")
+	})
+}
@@ -0,0 +1,215 @@
+package codepush
+
+import (
+	"archive/zip"
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/bitrise-io/bitrise-plugins-codepush-cli/internal/workspace"
+)
+
+// File change kinds in a PackageDiff.
+const (
+	FileAdded    = "added"
+	FileRemoved  = "removed"
+	FileModified = "modified"
+)
+
+// FileChange is the difference of one file between two packages. Sizes are
+// uncompressed.
+type FileChange struct {
+	Path     string `json:"path"`
+	Change   string `json:"change"`
+	FromSize int64  `json:"from_size"`
+	ToSize   int64  `json:"to_size"`
+	Delta    int64  `json:"delta"`
+}
+
+// PackageDiff is the file-level comparison of two released packages.
+type PackageDiff struct {
+	From Update `json:"from"`
+	To   Update `json:"to"`
+
+	// Files lists every added, removed, or modified file, largest size
+	// change first.
+	Files     []FileChange `json:"files"`
+	Added     int          `json:"added"`
+	Removed   int          `json:"removed"`
+	Modified  int          `json:"modified"`
+	Unchanged int          `json:"unchanged"`
+
+	// FromSize and ToSize are the total uncompressed sizes of the packages.
+	FromSize  int64 `json:"from_size"`
+	ToSize    int64 `json:"to_size"`
+	SizeDelta int64 `json:"size_delta"`
+}
+
+// packageDiffClient is the subset of Client needed by DiffPackages.
+type packageDiffClient interface {
+	updateLister
+	updateDownloader
+}
+
+// packageFile is the content hash and uncompressed size of a package entry.
+type packageFile struct {
+	hash string
+	size int64
+}
+
+// PackageDiffOptions selects the releases DiffPackages compares.
+type PackageDiffOptions struct {
+	AppID        string
+	DeploymentID string
+	// To is the label of the newer release, the latest release when empty.
+	// From is the label of the older release, the release before To when
+	// empty.
+	From string
+	To   string
+	// Workspace holds the downloaded releases; nil downloads them to the
+	// system temp directory.
+	Workspace *workspace.Workspace
+}
+
+// DiffPackages downloads two releases of a deployment and compares their
+// files.
+func DiffPackages(ctx context.Context, client packageDiffClient, opts *PackageDiffOptions) (*PackageDiff, error) {
+	updates, err := client.ListUpdates(ctx, opts.AppID, opts.DeploymentID)
+	if err != nil {
+		return nil, fmt.Errorf("listing updates: %w", err)
+	}
+	fromUpdate, toUpdate, err := selectDiffReleases(updates, opts.From, opts.To)
+	if err != nil {
+		return nil, err
+	}
+
+	fromFiles, err := downloadPackageFiles(ctx, client, opts, fromUpdate)
+	if err != nil {
+		return nil, err
+	}
+	toFiles, err := downloadPackageFiles(ctx, client, opts, toUpdate)
+	if err != nil {
+		return nil, err
+	}
+
+	diff := diffPackageFiles(fromFiles, toFiles)
+	diff.From = fromUpdate
+	diff.To = toUpdate
+	return diff, nil
+}
+
+// selectDiffReleases finds the releases labeled from and to, applying the
+// defaults of DiffPackages.
+func selectDiffReleases(updates []Update, from, to string) (Update, Update, error) {
+	if len(updates) == 0 {
+		return Update{}, Update{}, errors.New("no releases found in deployment: push a release first")
+	}
+
+	toIdx := len(updates) - 1
+	if to != "" {
+		toIdx = slices.IndexFunc(updates, func(u Update) bool { return u.Label == to })
+		if toIdx < 0 {
+			return Update{}, Update{}, fmt.Errorf("release label %q not found in deployment", to)
+		}
+	}
+
+	fromIdx := toIdx - 1
+	if from != "" {
+		fromIdx = slices.IndexFunc(updates, func(u Update) bool { return u.Label == from })
+		if fromIdx < 0 {
+			return Update{}, Update{}, fmt.Errorf("release label %q not found in deployment", from)
+		}
+	} else if fromIdx < 0 {
+		return Update{}, Update{}, fmt.Errorf("release %s has no earlier release to compare with: set --from", updates[toIdx].Label)
+	}
+
+	if fromIdx == toIdx {
+		return Update{}, Update{}, fmt.Errorf("--from and --to are the same release (%s)", updates[toIdx].Label)
+	}
+	return updates[fromIdx], updates[toIdx], nil
+}
+
+// downloadPackageFiles downloads the package of u and reads its entries.
+func downloadPackageFiles(ctx context.Context, client updateDownloader, opts *PackageDiffOptions, u Update) (map[string]packageFile, error) {
+	zipPath, err := downloadUpdate(ctx, client, opts.Workspace, UpdateRef{AppID: opts.AppID, DeploymentID: opts.DeploymentID, UpdateID: u.ID})
+	if err != nil {
+		return nil, fmt.Errorf("downloading release %s: %w", u.Label, err)
+	}
+	defer opts.Workspace.Remove(zipPath)
+
+	files, err := readPackageFiles(zipPath)
+	if err != nil {
+		return nil, fmt.Errorf("reading release %s: %w", u.Label, err)
+	}
+	return files, nil
+}
+
+// readPackageFiles hashes every file in a package zip and records its
+// uncompressed size.
+func readPackageFiles(zipPath string) (map[string]packageFile, error) {
+	zr, err := zip.OpenReader(zipPath)
+	if err != nil {
+		return nil, fmt.Errorf("opening package: %w", err)
+	}
+	defer func() { _ = zr.Close() }()
+
+	files := map[string]packageFile{}
+	for _, f := range zr.File {
+		if strings.HasSuffix(f.Name, "/") {
+			continue
+		}
+		rc, err := f.Open()
+		if err != nil {
+			return nil, fmt.Errorf("reading %s: %w", f.Name, err)
+		}
+		sum, err := hashReader(rc)
+		_ = rc.Close()
+		if err != nil {
+			return nil, fmt.Errorf("hashing %s: %w", f.Name, err)
+		}
+		files[f.Name] = packageFile{hash: sum, size: int64(f.UncompressedSize64)}
+	}
+	return files, nil
+}
+
+func diffPackageFiles(from, to map[string]packageFile) *PackageDiff {
+	diff := &PackageDiff{Files: []FileChange{}}
+
+	for name, f := range from {
+		diff.FromSize += f.size
+		if _, ok := to[name]; !ok {
+			diff.Files = append(diff.Files, FileChange{Path: name, Change: FileRemoved, FromSize: f.size, Delta: -f.size})
+			diff.Removed++
+		}
+	}
+	for name, t := range to {
+		diff.ToSize += t.size
+		f, ok := from[name]
+		switch {
+		case !ok:
+			diff.Files = append(diff.Files, FileChange{Path: name, Change: FileAdded, ToSize: t.size, Delta: t.size})
+			diff.Added++
+		case f.hash != t.hash:
+			diff.Files = append(diff.Files, FileChange{Path: name, Change: FileModified, FromSize: f.size, ToSize: t.size, Delta: t.size - f.size})
+			diff.Modified++
+		default:
+			diff.Unchanged++
+		}
+	}
+	diff.SizeDelta = diff.ToSize - diff.FromSize
+
+	slices.SortFunc(diff.Files, func(a, b FileChange) int {
+		return cmp.Or(cmp.Compare(abs(b.Delta), abs(a.Delta)), strings.Compare(a.Path, b.Path))
+	})
+	return diff
+}
+
+func abs(n int64) int64 {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
@@ -0,0 +1,107 @@
+package codepush
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDiffPackages(t *testing.T) {
+	packages := map[string]map[string]string{
+		"pkg-1": {"main.jsbundle": "bundle-v1", "assets/logo.png": "logo", "assets/old.png": "old"},
+		"pkg-2": {"main.jsbundle": "bundle-v2-larger", "assets/logo.png": "logo", "assets/new.png": strings.Repeat("n", 20)},
+		"pkg-3": {"main.jsbundle": "bundle-v3"},
+	}
+	newClient := func() *mockClient {
+		return &mockClient{
+			listUpdatesFunc: func(appID, deploymentID string) ([]Update, error) {
+				return []Update{{ID: "pkg-1", Label: "v1"}, {ID: "pkg-2", Label: "v2"}, {ID: "pkg-3", Label: "v3"}}, nil
+			},
+			getDownloadURLFunc: func(appID, deploymentID, updateID string) (*DownloadURLResponse, error) {
+				return &DownloadURLResponse{URL: updateID}, nil
+			},
+			downloadFileFunc: func(fileURL string, w io.Writer) error {
+				writeZipFiles(t, w, packages[fileURL])
+				return nil
+			},
+		}
+	}
+
+	t.Run("compares two labels", func(t *testing.T) {
+		diff, err := DiffPackages(context.Background(), newClient(), &PackageDiffOptions{AppID: "app-1", DeploymentID: "dep-1", From: "v1", To: "v2"})
+		require.NoError(t, err)
+
+		assert.Equal(t, "v1", diff.From.Label)
+		assert.Equal(t, "v2", diff.To.Label)
+		assert.Equal(t, []FileChange{
+			{Path: "assets/new.png", Change: FileAdded, ToSize: 20, Delta: 20},
+			{Path: "main.jsbundle", Change: FileModified, FromSize: 9, ToSize: 16, Delta: 7},
+			{Path: "assets/old.png", Change: FileRemoved, FromSize: 3, Delta: -3},
+		}, diff.Files)
+		assert.Equal(t, 1, diff.Added)
+		assert.Equal(t, 1, diff.Removed)
+		assert.Equal(t, 1, diff.Modified)
+		assert.Equal(t, 1, diff.Unchanged)
+		assert.Equal(t, int64(16), diff.FromSize)
+		assert.Equal(t, int64(40), diff.ToSize)
+		assert.Equal(t, int64(24), diff.SizeDelta)
+	})
+
+	t.Run("defaults to the latest release and the one before it", func(t *testing.T) {
+		diff, err := DiffPackages(context.Background(), newClient(), &PackageDiffOptions{AppID: "app-1", DeploymentID: "dep-1"})
+		require.NoError(t, err)
+		assert.Equal(t, "v2", diff.From.Label)
+		assert.Equal(t, "v3", diff.To.Label)
+	})
+
+	t.Run("download failure", func(t *testing.T) {
+		client := newClient()
+		client.downloadFileFunc = func(fileURL string, w io.Writer) error {
+			return errors.New("connection reset")
+		}
+		_, err := DiffPackages(context.Background(), client, &PackageDiffOptions{AppID: "app-1", DeploymentID: "dep-1", From: "v1", To: "v2"})
+		require.Error(t, err)
+		assert.ErrorContains(t, err, "downloading release v1")
+	})
+}
+
+func TestSelectDiffReleases(t *testing.T) {
+	updates := []Update{{ID: "pkg-1", Label: "v1"}, {ID: "pkg-2", Label: "v2"}}
+
+	tests := []struct {
+		name     string
+		updates  []Update
+		from, to string
+		wantFrom string
+		wantTo   string
+		wantErr  string
+	}{
+		{name: "explicit labels", updates: updates, from: "v2", to: "v1", wantFrom: "v2", wantTo: "v1"},
+		{name: "from defaults to the release before to", updates: updates, to: "v2", wantFrom: "v1", wantTo: "v2"},
+		{name: "to defaults to latest", updates: updates, from: "v1", wantFrom: "v1", wantTo: "v2"},
+		{name: "no releases", wantErr: "no releases found"},
+		{name: "unknown to", updates: updates, to: "v9", wantErr: `release label "v9" not found`},
+		{name: "unknown from", updates: updates, from: "v9", wantErr: `release label "v9" not found`},
+		{name: "no earlier release", updates: updates, to: "v1", wantErr: "no earlier release"},
+		{name: "same release", updates: updates, from: "v2", wantErr: "same release"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			from, to, err := selectDiffReleases(tt.updates, tt.from, tt.to)
+			if tt.wantErr != "" {
+				require.Error(t, err)
+				assert.ErrorContains(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantFrom, from.Label)
+			assert.Equal(t, tt.wantTo, to.Label)
+		})
+	}
+}
@@ -6,7 +6,6 @@ import (
 	"fmt"
 	"strconv"
 
-	"github.com/bitrise-io/bitrise-plugins-codepush-cli/internal/bitrise"
 	"github.com/bitrise-io/bitrise-plugins-codepush-cli/internal/output"
 )
 
@@ -14,7 +13,7 @@ import (
 // resolve label (or find latest), build request, call API, export summary.
 func Patch(ctx context.Context, client Client, opts *PatchOptions, out *output.Writer) (*PatchResult, error) {
 	if err := validatePatchOptions(opts); err != nil {
-		return nil, err
+		return nil, Invalid(err)
 	}
 
 	deploymentID, err := ResolveDeployment(ctx, client, opts.AppID, opts.DeploymentID, out)
@@ -29,7 +28,13 @@ func Patch(ctx context.Context, client Client, opts *PatchOptions, out *output.W
 
 	req, err := buildPatchRequest(opts)
 	if err != nil {
-		return nil, err
+		return nil, Invalid(err)
+	}
+
+	if opts.ExpectCurrentRollout != "" {
+		if err := checkExpectRollout(ctx, client, opts.AppID, deploymentID, updateID, opts.ExpectCurrentRollout); err != nil {
+			return nil, err
+		}
 	}
 
 	step := out.StartStep("Patching release %s", updateLabel)
@@ -50,10 +55,7 @@ func Patch(ctx context.Context, client Client, opts *PatchOptions, out *output.W
 		Disabled:     pkg.Disabled,
 		Rollout:      int(pkg.Rollout),
 		Description:  pkg.Description,
-	}
-
-	if bitrise.IsBitriseEnvironment() {
-		exportSummary("codepush-patch-summary.json", result, out)
+		Targeting:    pkg.Targeting,
 	}
 
 	return result, nil
@@ -66,8 +68,9 @@ func validatePatchOptions(opts *PatchOptions) error {
 	if opts.DeploymentID == "" {
 		return errors.New("deployment is required: set --deployment or CODEPUSH_DEPLOYMENT")
 	}
-	if opts.Rollout == "" && opts.Mandatory == "" && opts.Disabled == "" && opts.Description == "" && opts.AppVersion == "" {
-		return errors.New("at least one change is required: set --rollout, --mandatory, --disabled, --description, or --app-version")
+	if opts.Rollout == "" && opts.Mandatory == "" && opts.Disabled == "" && opts.Description == "" && opts.AppVersion == "" &&
+		opts.TargetOSVersion == nil && opts.TargetDeviceModels == nil && opts.TargetCountries == nil {
+		return errors.New("at least one change is required: set --rollout, --mandatory, --disabled, --description, --app-version, or a --target-* flag")
 	}
 	return nil
 }
@@ -105,25 +108,25 @@ func buildPatchRequest(opts *PatchOptions) (PatchRequest, error) {
 	var req PatchRequest
 
 	if opts.Rollout != "" {
-		v, err := strconv.Atoi(opts.Rollout)
-		if err != nil || v < 0 || v > 100 {
-			return req, fmt.Errorf("rollout must be between 0 and 100, got %q", opts.Rollout)
+		v, err := parseRollout(opts.Rollout)
+		if err != nil {
+			return req, err
 		}
 		req.Rollout = &v
 	}
 
 	if opts.Mandatory != "" {
-		v, err := strconv.ParseBool(opts.Mandatory)
+		v, err := parseBoolOption("mandatory", opts.Mandatory)
 		if err != nil {
-			return req, fmt.Errorf("mandatory must be true or false, got %q", opts.Mandatory)
+			return req, err
 		}
 		req.Mandatory = &v
 	}
 
 	if opts.Disabled != "" {
-		v, err := strconv.ParseBool(opts.Disabled)
+		v, err := parseBoolOption("disabled", opts.Disabled)
 		if err != nil {
-			return req, fmt.Errorf("disabled must be true or false, got %q", opts.Disabled)
+			return req, err
 		}
 		req.Disabled = &v
 	}
@@ -133,8 +136,63 @@ func buildPatchRequest(opts *PatchOptions) (PatchRequest, error) {
 	}
 
 	if opts.AppVersion != "" {
-		req.AppVersion = &opts.AppVersion
+		v, err := NormalizeAppVersion(opts.AppVersion)
+		if err != nil {
+			return req, err
+		}
+		req.AppVersion = &v
+	}
+
+	if opts.TargetOSVersion != nil {
+		v, err := normalizeOSVersion(*opts.TargetOSVersion)
+		if err != nil {
+			return req, err
+		}
+		req.TargetOSVersion = &v
+	}
+
+	if opts.TargetDeviceModels != nil {
+		v, err := normalizeDeviceModels(*opts.TargetDeviceModels)
+		if err != nil {
+			return req, err
+		}
+		req.TargetDeviceModels = emptyIfNil(v)
+	}
+
+	if opts.TargetCountries != nil {
+		v, err := normalizeCountries(*opts.TargetCountries)
+		if err != nil {
+			return req, err
+		}
+		req.TargetCountries = emptyIfNil(v)
 	}
 
 	return req, nil
 }
+
+// parseRollout parses a --rollout value, a whole percentage from 0 to 100.
+func parseRollout(value string) (int, error) {
+	v, err := strconv.Atoi(value)
+	if err != nil || v < 0 || v > 100 {
+		return 0, fmt.Errorf("rollout must be between 0 and 100, got %q", value)
+	}
+	return v, nil
+}
+
+// parseBoolOption parses a true/false flag value such as --mandatory.
+func parseBoolOption(name, value string) (bool, error) {
+	v, err := strconv.ParseBool(value)
+	if err != nil {
+		return false, fmt.Errorf("%s must be true or false, got %q", name, value)
+	}
+	return v, nil
+}
+
+// emptyIfNil returns a pointer to values, or to an empty slice when values
+// is nil, so that a removed condition is sent as [] rather than null.
+func emptyIfNil(values []string) *[]string {
+	if values == nil {
+		values = []string{}
+	}
+	return &values
+}
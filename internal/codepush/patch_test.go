@@ -2,9 +2,8 @@ package codepush
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
-	"os"
-	"path/filepath"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -221,44 +220,6 @@ func TestPatch(t *testing.T) {
 		require.Error(t, err)
 		assert.ErrorContains(t, err, "patch failed")
 	})
-
-	t.Run("bitrise environment exports summary", func(t *testing.T) {
-		deployDir := t.TempDir()
-		t.Setenv("BITRISE_DEPLOY_DIR", deployDir)
-		t.Setenv("BITRISE_BUILD_NUMBER", "42")
-
-		client := &mockClient{
-			listUpdatesFunc: func(appID, deploymentID string) ([]Update, error) {
-				return []Update{{ID: "pkg-1", Label: "v1", AppVersion: "1.0.0"}}, nil
-			},
-			patchUpdateFunc: func(appID, deploymentID, updateID string, req PatchRequest) (*Update, error) {
-				return &Update{
-					ID:         updateID,
-					Label:      "v1",
-					AppVersion: "1.0.0",
-					Rollout:    50,
-				}, nil
-			},
-		}
-
-		opts := &PatchOptions{
-			AppID:        "app-123",
-			DeploymentID: "00000000-0000-0000-0000-000000000001",
-			Token:        "test-token",
-			Rollout:      "50",
-		}
-
-		_, err := Patch(context.Background(), client, opts, testOut)
-		require.NoError(t, err)
-
-		summaryPath := filepath.Join(deployDir, "codepush-patch-summary.json")
-		data, err := os.ReadFile(summaryPath)
-		require.NoError(t, err)
-
-		content := string(data)
-		assert.Contains(t, content, `"label": "v1"`)
-		assert.Contains(t, content, `"rollout": 50`)
-	})
 }
 
 func TestValidatePatchOptions(t *testing.T) {
@@ -387,6 +348,54 @@ func TestBuildPatchRequest(t *testing.T) {
 		require.Error(t, err)
 		assert.ErrorContains(t, err, "disabled must be true or false")
 	})
+
+	t.Run("app version range is normalized", func(t *testing.T) {
+		req, err := buildPatchRequest(&PatchOptions{AppVersion: "~ 1.2.0"})
+		require.NoError(t, err)
+		require.NotNil(t, req.AppVersion)
+		assert.Equal(t, "~1.2.0", *req.AppVersion)
+	})
+
+	t.Run("invalid app version", func(t *testing.T) {
+		_, err := buildPatchRequest(&PatchOptions{AppVersion: "1.2.3.4"})
+		assert.ErrorContains(t, err, `invalid app version "1.2.3.4"`)
+	})
+
+	t.Run("targeting", func(t *testing.T) {
+		osVersion := " >=14.0 "
+		models := []string{"iPhone14,2"}
+		countries := []string{"de"}
+		opts := &PatchOptions{TargetOSVersion: &osVersion, TargetDeviceModels: &models, TargetCountries: &countries}
+
+		req, err := buildPatchRequest(opts)
+		require.NoError(t, err)
+
+		require.NotNil(t, req.TargetOSVersion)
+		assert.Equal(t, ">=14.0", *req.TargetOSVersion)
+		require.NotNil(t, req.TargetDeviceModels)
+		assert.Equal(t, []string{"iPhone14,2"}, *req.TargetDeviceModels)
+		require.NotNil(t, req.TargetCountries)
+		assert.Equal(t, []string{"DE"}, *req.TargetCountries)
+	})
+
+	t.Run("empty targeting removes the conditions", func(t *testing.T) {
+		osVersion := ""
+		var countries []string
+		opts := &PatchOptions{TargetOSVersion: &osVersion, TargetCountries: &countries}
+
+		req, err := buildPatchRequest(opts)
+		require.NoError(t, err)
+
+		body, err := json.Marshal(req)
+		require.NoError(t, err)
+		assert.JSONEq(t, `{"target_os_version":"","target_countries":[]}`, string(body))
+	})
+
+	t.Run("invalid country", func(t *testing.T) {
+		countries := []string{"Germany"}
+		_, err := buildPatchRequest(&PatchOptions{TargetCountries: &countries})
+		assert.ErrorContains(t, err, "invalid country")
+	})
 }
 
 func TestResolveUpdateForPatch(t *testing.T) {
@@ -0,0 +1,85 @@
+package codepush
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptrace"
+	"time"
+)
+
+// Response headers the API may use to report its version and serving region.
+// Both are optional: PingResult leaves the fields empty when they are absent.
+const (
+	headerAPIVersion = "X-Api-Version"
+	headerAPIRegion  = "X-Region"
+)
+
+// PingResult reports reachability and connection timings for the API.
+// Timings are zero when the phase did not happen (e.g. DNS for an IP literal
+// or TLS for a plain HTTP URL).
+type PingResult struct {
+	URL        string        `json:"url"`
+	StatusCode int           `json:"status_code"`
+	DNS        time.Duration `json:"dns_ns"`
+	Connect    time.Duration `json:"connect_ns"`
+	TLS        time.Duration `json:"tls_ns"`
+	FirstByte  time.Duration `json:"first_byte_ns"`
+	Total      time.Duration `json:"total_ns"`
+	TLSVersion string        `json:"tls_version,omitempty"`
+	APIVersion string        `json:"api_version,omitempty"`
+	Region     string        `json:"region,omitempty"`
+}
+
+// Ping sends an unauthenticated GET to the API base URL and records DNS,
+// connect, TLS, and time-to-first-byte timings. Any HTTP response below 500
+// counts as reachable; the token is deliberately not sent so that ping
+// checks connectivity independently from credentials.
+func (c *HTTPClient) Ping(ctx context.Context) (*PingResult, error) {
+	var (
+		dnsStart, connectStart, tlsStart time.Time
+		result                           = &PingResult{URL: c.BaseURL}
+	)
+
+	trace := &httptrace.ClientTrace{
+		DNSStart:          func(httptrace.DNSStartInfo) { dnsStart = time.Now() },
+		DNSDone:           func(httptrace.DNSDoneInfo) { result.DNS = time.Since(dnsStart) },
+		ConnectStart:      func(string, string) { connectStart = time.Now() },
+		ConnectDone:       func(string, string, error) { result.Connect = time.Since(connectStart) },
+		TLSHandshakeStart: func() { tlsStart = time.Now() },
+		TLSHandshakeDone: func(state tls.ConnectionState, _ error) {
+			result.TLS = time.Since(tlsStart)
+			result.TLSVersion = tls.VersionName(state.Version)
+		},
+	}
+
+	start := time.Now()
+	trace.GotFirstResponseByte = func() { result.FirstByte = time.Since(start) }
+
+	req, err := http.NewRequestWithContext(httptrace.WithClientTrace(ctx, trace), http.MethodGet, c.BaseURL+"/", nil)
+	if err != nil {
+		return nil, fmt.Errorf("creating request: %w", err)
+	}
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("X-Bitrise-User-Agent", "codepush-cli/"+c.version)
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("reaching %s: %w", c.BaseURL, err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	_, _ = io.Copy(io.Discard, resp.Body)
+
+	result.Total = time.Since(start)
+	result.StatusCode = resp.StatusCode
+	result.APIVersion = resp.Header.Get(headerAPIVersion)
+	result.Region = resp.Header.Get(headerAPIRegion)
+
+	if resp.StatusCode >= http.StatusInternalServerError {
+		return result, fmt.Errorf("API is unhealthy: HTTP %d from %s", resp.StatusCode, c.BaseURL)
+	}
+
+	return result, nil
+}
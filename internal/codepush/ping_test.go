@@ -0,0 +1,74 @@
+package codepush
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHTTPClientPing(t *testing.T) {
+	t.Run("reports status and API headers", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, "/", r.URL.Path)
+			assert.Empty(t, r.Header.Get("Authorization"))
+			w.Header().Set(headerAPIVersion, "1.4.2")
+			w.Header().Set(headerAPIRegion, "eu-west-1")
+			w.WriteHeader(http.StatusNotFound)
+		}))
+		defer server.Close()
+
+		client := newTestClient(server.URL, "secret", "test")
+		result, err := client.Ping(context.Background())
+		require.NoError(t, err)
+
+		assert.Equal(t, server.URL, result.URL)
+		assert.Equal(t, http.StatusNotFound, result.StatusCode)
+		assert.Equal(t, "1.4.2", result.APIVersion)
+		assert.Equal(t, "eu-west-1", result.Region)
+		assert.Positive(t, result.Total)
+	})
+
+	t.Run("records TLS handshake for HTTPS", func(t *testing.T) {
+		server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusOK)
+		}))
+		defer server.Close()
+
+		client := newTestClient(server.URL, "", "test")
+		client.client = server.Client()
+
+		result, err := client.Ping(context.Background())
+		require.NoError(t, err)
+		assert.NotEmpty(t, result.TLSVersion)
+		assert.Positive(t, result.TLS)
+	})
+
+	t.Run("returns error on server error", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusBadGateway)
+		}))
+		defer server.Close()
+
+		client := newTestClient(server.URL, "", "test")
+		result, err := client.Ping(context.Background())
+		require.Error(t, err)
+		assert.ErrorContains(t, err, "502")
+		require.NotNil(t, result)
+		assert.Equal(t, http.StatusBadGateway, result.StatusCode)
+	})
+
+	t.Run("returns error when unreachable", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+		url := server.URL
+		server.Close()
+
+		client := newTestClient(url, "", "test")
+		_, err := client.Ping(context.Background())
+		require.Error(t, err)
+		assert.ErrorContains(t, err, "reaching")
+	})
+}
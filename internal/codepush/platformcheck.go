@@ -0,0 +1,88 @@
+package codepush
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// Platform names as reported by DetectBundlePlatform.
+const (
+	PlatformIOS     = "ios"
+	PlatformAndroid = "android"
+)
+
+type appLister interface {
+	ListApps(ctx context.Context) ([]App, error)
+}
+
+// BundlePlatform is the platform a bundle directory was built for, with the
+// files that gave it away.
+type BundlePlatform struct {
+	Platform string
+	Evidence []string
+}
+
+// DetectBundlePlatform inspects the top level of a bundle directory for
+// platform conventions: main.jsbundle and an assets/ directory for iOS,
+// index.android.bundle and drawable-*/raw resource directories for Android.
+// Platform is empty when there is no evidence or the evidence is mixed.
+func DetectBundlePlatform(dir string) (*BundlePlatform, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("reading bundle directory: %w", err)
+	}
+
+	var ios, android []string
+	for _, e := range entries {
+		name := e.Name()
+		switch {
+		case !e.IsDir() && strings.HasSuffix(name, ".android.bundle"):
+			android = append(android, name)
+		case !e.IsDir() && (strings.HasSuffix(name, ".jsbundle") || strings.HasSuffix(name, ".ios.bundle")):
+			ios = append(ios, name)
+		case e.IsDir() && (strings.HasPrefix(name, "drawable-") || name == "raw"):
+			android = append(android, name+"/")
+		case e.IsDir() && name == "assets":
+			ios = append(ios, name+"/")
+		}
+	}
+
+	switch {
+	case len(ios) > 0 && len(android) == 0:
+		return &BundlePlatform{Platform: PlatformIOS, Evidence: ios}, nil
+	case len(android) > 0 && len(ios) == 0:
+		return &BundlePlatform{Platform: PlatformAndroid, Evidence: android}, nil
+	default:
+		return &BundlePlatform{Evidence: append(ios, android...)}, nil
+	}
+}
+
+// NormalizePlatform maps platform names as reported by the apps API or typed
+// by users ("iOS", "Android", "ios") to PlatformIOS or PlatformAndroid, or ""
+// when unrecognised.
+func NormalizePlatform(s string) string {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "ios":
+		return PlatformIOS
+	case "android":
+		return PlatformAndroid
+	}
+	return ""
+}
+
+// AppPlatform returns the normalized platform of the connected app, or "" if
+// the app is not found or its platform is unknown.
+func AppPlatform(ctx context.Context, client appLister, appID string) (string, error) {
+	apps, err := client.ListApps(ctx)
+	if err != nil {
+		return "", fmt.Errorf("listing apps: %w", err)
+	}
+	for _, a := range apps {
+		if a.ID == appID {
+			return NormalizePlatform(a.Platform), nil
+		}
+	}
+	return "", nil
+}
@@ -0,0 +1,75 @@
+package codepush
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDetectBundlePlatform(t *testing.T) {
+	tests := []struct {
+		name         string
+		files        []string
+		dirs         []string
+		wantPlatform string
+		wantEvidence []string
+	}{
+		{name: "ios bundle", files: []string{"main.jsbundle"}, dirs: []string{"assets"}, wantPlatform: PlatformIOS, wantEvidence: []string{"assets/", "main.jsbundle"}},
+		{name: "android bundle", files: []string{"index.android.bundle"}, dirs: []string{"drawable-mdpi", "raw"}, wantPlatform: PlatformAndroid, wantEvidence: []string{"drawable-mdpi/", "index.android.bundle", "raw/"}},
+		{name: "mixed artifacts", files: []string{"main.jsbundle", "index.android.bundle"}, wantEvidence: []string{"main.jsbundle", "index.android.bundle"}},
+		{name: "no evidence", files: []string{"index.bundle"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			for _, f := range tt.files {
+				require.NoError(t, os.WriteFile(filepath.Join(dir, f), []byte("x"), 0o644))
+			}
+			for _, d := range tt.dirs {
+				require.NoError(t, os.Mkdir(filepath.Join(dir, d), 0o755))
+			}
+
+			got, err := DetectBundlePlatform(dir)
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantPlatform, got.Platform)
+			assert.ElementsMatch(t, tt.wantEvidence, got.Evidence)
+		})
+	}
+
+	t.Run("missing directory", func(t *testing.T) {
+		_, err := DetectBundlePlatform(filepath.Join(t.TempDir(), "missing"))
+		require.Error(t, err)
+	})
+}
+
+func TestNormalizePlatform(t *testing.T) {
+	assert.Equal(t, PlatformIOS, NormalizePlatform("iOS"))
+	assert.Equal(t, PlatformAndroid, NormalizePlatform(" Android "))
+	assert.Empty(t, NormalizePlatform("windows"))
+}
+
+func TestAppPlatform(t *testing.T) {
+	client := &mockClient{
+		listAppsFunc: func() ([]App, error) {
+			return []App{{ID: "app-1", Platform: "android"}, {ID: "app-2", Platform: "iOS"}}, nil
+		},
+	}
+
+	got, err := AppPlatform(context.Background(), client, "app-2")
+	require.NoError(t, err)
+	assert.Equal(t, PlatformIOS, got)
+
+	got, err = AppPlatform(context.Background(), client, "app-unknown")
+	require.NoError(t, err)
+	assert.Empty(t, got)
+
+	client.listAppsFunc = func() ([]App, error) { return nil, errors.New("forbidden") }
+	_, err = AppPlatform(context.Background(), client, "app-1")
+	require.Error(t, err)
+}
@@ -4,8 +4,8 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"strconv"
 
-	"github.com/bitrise-io/bitrise-plugins-codepush-cli/internal/bitrise"
 	"github.com/bitrise-io/bitrise-plugins-codepush-cli/internal/output"
 )
 
@@ -13,7 +13,11 @@ import (
 // optionally resolve label to update ID, call API, export summary.
 func Promote(ctx context.Context, client Client, opts *PromoteOptions, out *output.Writer) (*PromoteResult, error) {
 	if err := validatePromoteOptions(opts); err != nil {
-		return nil, err
+		return nil, Invalid(err)
+	}
+	req, err := buildPromoteRequest(opts)
+	if err != nil {
+		return nil, Invalid(err)
 	}
 
 	sourceDeploymentID, err := ResolveDeployment(ctx, client, opts.AppID, opts.SourceDeploymentID, out)
@@ -26,14 +30,7 @@ func Promote(ctx context.Context, client Client, opts *PromoteOptions, out *outp
 		return nil, fmt.Errorf("resolving destination deployment: %w", err)
 	}
 
-	req := PromoteRequest{
-		TargetDeploymentID: destDeploymentID,
-		AppVersion:         opts.AppVersion,
-		Description:        opts.Description,
-		Mandatory:          opts.Mandatory,
-		Disabled:           opts.Disabled,
-		Rollout:            opts.Rollout,
-	}
+	req.TargetDeploymentID = destDeploymentID
 
 	if opts.Label != "" {
 		updateID, err := resolveUpdateLabel(ctx, client, opts.AppID, sourceDeploymentID, opts.Label, out)
@@ -43,6 +40,12 @@ func Promote(ctx context.Context, client Client, opts *PromoteOptions, out *outp
 		req.UpdateID = updateID
 	}
 
+	if opts.ExpectSourceHash != "" {
+		if err := checkExpectSourceHash(ctx, client, opts.AppID, sourceDeploymentID, opts.Label, opts.ExpectSourceHash); err != nil {
+			return nil, err
+		}
+	}
+
 	step := out.StartStep("Promoting from %s to %s", opts.SourceDeploymentID, opts.DestDeploymentID)
 	pkg, err := client.Promote(ctx, opts.AppID, sourceDeploymentID, req)
 	if err != nil {
@@ -61,11 +64,135 @@ func Promote(ctx context.Context, client Client, opts *PromoteOptions, out *outp
 		Description:      pkg.Description,
 	}
 
-	if bitrise.IsBitriseEnvironment() {
-		exportSummary("codepush-promote-summary.json", result, out)
+	return result, nil
+}
+
+// PreviewPromote resolves the release a promote with opts would create,
+// with the overrides applied, and the destination's current latest release.
+// It makes no changes.
+func PreviewPromote(ctx context.Context, client Client, opts *PromoteOptions, out *output.Writer) (*PromotePreview, error) {
+	req, err := buildPromoteRequest(opts)
+	if err != nil {
+		return nil, Invalid(err)
 	}
 
-	return result, nil
+	sourceID, err := ResolveDeployment(ctx, client, opts.AppID, opts.SourceDeploymentID, out)
+	if err != nil {
+		return nil, fmt.Errorf("resolving source deployment: %w", err)
+	}
+	destID, err := ResolveDeployment(ctx, client, opts.AppID, opts.DestDeploymentID, out)
+	if err != nil {
+		return nil, fmt.Errorf("resolving destination deployment: %w", err)
+	}
+
+	sourceUpdates, err := client.ListUpdates(ctx, opts.AppID, sourceID)
+	if err != nil {
+		return nil, fmt.Errorf("listing source releases: %w", err)
+	}
+	source, err := promoteSource(sourceUpdates, opts.Label)
+	if err != nil {
+		return nil, err
+	}
+
+	dest, err := client.GetDeployment(ctx, opts.AppID, destID)
+	if err != nil {
+		return nil, fmt.Errorf("getting destination deployment: %w", err)
+	}
+	destUpdates, err := client.ListUpdates(ctx, opts.AppID, destID)
+	if err != nil {
+		return nil, fmt.Errorf("listing destination releases: %w", err)
+	}
+
+	preview := &PromotePreview{
+		Destination: dest.Name,
+		Source:      source,
+		Release:     applyPromoteOverrides(source, req),
+	}
+	if len(destUpdates) > 0 {
+		preview.Current = &destUpdates[len(destUpdates)-1]
+	}
+	return preview, nil
+}
+
+// promoteSource returns the source release with the label, or the latest.
+func promoteSource(updates []Update, label string) (Update, error) {
+	if len(updates) == 0 {
+		return Update{}, errors.New("the source deployment has no releases to promote")
+	}
+	if label == "" {
+		return updates[len(updates)-1], nil
+	}
+	for _, u := range updates {
+		if u.Label == label {
+			return u, nil
+		}
+	}
+	return Update{}, fmt.Errorf("release label %q not found in the source deployment", label)
+}
+
+// applyPromoteOverrides returns u with the overrides of a normalized promote
+// request applied.
+func applyPromoteOverrides(u Update, req PromoteRequest) Update {
+	if req.AppVersion != "" {
+		u.AppVersion = req.AppVersion
+	}
+	if req.Description != "" {
+		u.Description = req.Description
+	}
+	if req.Mandatory != "" {
+		u.Mandatory = req.Mandatory == "true"
+	}
+	if req.Disabled != "" {
+		u.Disabled = req.Disabled == "true"
+	}
+	if req.Rollout != "" {
+		rollout, _ := strconv.Atoi(req.Rollout)
+		u.Rollout = float64(rollout)
+	}
+	return u
+}
+
+// buildPromoteRequest validates the overrides in opts and returns them in
+// the API's canonical form, so invalid values fail before any request is
+// made. TargetDeploymentID is left for the caller to set.
+func buildPromoteRequest(opts *PromoteOptions) (PromoteRequest, error) {
+	req := PromoteRequest{
+		Description: opts.Description,
+	}
+
+	if opts.AppVersion != "" {
+		v, err := NormalizeAppVersion(opts.AppVersion)
+		if err != nil {
+			return req, err
+		}
+		req.AppVersion = v
+	}
+
+	if opts.Rollout != "" {
+		v, err := parseRollout(opts.Rollout)
+		if err != nil {
+			return req, err
+		}
+		req.Rollout = strconv.Itoa(v)
+	}
+
+	if opts.Mandatory != "" {
+		v, err := parseBoolOption("mandatory", opts.Mandatory)
+		if err != nil {
+			return req, err
+		}
+		req.Mandatory = strconv.FormatBool(v)
+	}
+
+	if opts.Disabled != "" {
+		v, err := parseBoolOption("disabled", opts.Disabled)
+		if err != nil {
+			return req, err
+		}
+		req.Disabled = strconv.FormatBool(v)
+	}
+
+	return req, nil
 }
 
 func validatePromoteOptions(opts *PromoteOptions) error {
@@ -3,8 +3,6 @@ package codepush
 import (
 	"context"
 	"errors"
-	"os"
-	"path/filepath"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -72,6 +70,27 @@ func TestPromote(t *testing.T) {
 		assert.Equal(t, "50", capturedReq.Rollout)
 	})
 
+	t.Run("invalid override fails before any API call", func(t *testing.T) {
+		client := &mockClient{
+			promoteFunc: func(appID, deploymentID string, req PromoteRequest) (*Update, error) {
+				t.Fatal("promote should not be called")
+				return nil, nil
+			},
+		}
+
+		opts := &PromoteOptions{
+			AppID:              "app-123",
+			SourceDeploymentID: "00000000-0000-0000-0000-000000000001",
+			DestDeploymentID:   "00000000-0000-0000-0000-000000000002",
+			Token:              "test-token",
+			Rollout:            "250",
+		}
+
+		_, err := Promote(context.Background(), client, opts, testOut)
+		require.Error(t, err)
+		assert.ErrorContains(t, err, "rollout must be between 0 and 100")
+	})
+
 	t.Run("promote with label resolution", func(t *testing.T) {
 		var capturedReq PromoteRequest
 		client := &mockClient{
@@ -163,35 +182,82 @@ func TestPromote(t *testing.T) {
 		require.Error(t, err)
 		assert.ErrorContains(t, err, "promote failed")
 	})
+}
 
-	t.Run("bitrise environment exports summary", func(t *testing.T) {
-		deployDir := t.TempDir()
-		t.Setenv("BITRISE_DEPLOY_DIR", deployDir)
-		t.Setenv("BITRISE_BUILD_NUMBER", "42")
-
-		client := &mockClient{
+func TestPreviewPromote(t *testing.T) {
+	const (
+		sourceID = "00000000-0000-0000-0000-000000000001"
+		destID   = "00000000-0000-0000-0000-000000000002"
+	)
+	newClient := func(source, dest []Update) *mockClient {
+		return &mockClient{
+			listUpdatesFunc: func(appID, deploymentID string) ([]Update, error) {
+				if deploymentID == sourceID {
+					return source, nil
+				}
+				return dest, nil
+			},
+			getDeploymentFunc: func(appID, deploymentID string) (*Deployment, error) {
+				return &Deployment{ID: deploymentID, Name: "Production"}, nil
+			},
 			promoteFunc: func(appID, deploymentID string, req PromoteRequest) (*Update, error) {
-				return &Update{ID: "pkg-promo", Label: "v1", AppVersion: "2.0.0"}, nil
+				t.Fatal("preview must not promote")
+				return nil, nil
 			},
 		}
+	}
+	source := []Update{
+		{ID: "pkg-1", Label: "v1", AppVersion: "1.0.0", Rollout: 100},
+		{ID: "pkg-2", Label: "v2", AppVersion: "1.0.0", Rollout: 100, FileSizeBytes: 4096, Description: "Fix login"},
+	}
+	dest := []Update{{ID: "pkg-9", Label: "v9", AppVersion: "1.0.0", Rollout: 100}}
+	opts := PromoteOptions{AppID: "app-123", SourceDeploymentID: sourceID, DestDeploymentID: destID, Token: "test-token"}
 
-		opts := &PromoteOptions{
-			AppID:              "app-123",
-			SourceDeploymentID: "00000000-0000-0000-0000-000000000001",
-			DestDeploymentID:   "00000000-0000-0000-0000-000000000002",
-			Token:              "test-token",
-		}
-
-		_, err := Promote(context.Background(), client, opts, testOut)
+	t.Run("latest source release with overrides", func(t *testing.T) {
+		o := opts
+		o.Mandatory = "true"
+		o.Rollout = "25"
+		preview, err := PreviewPromote(context.Background(), newClient(source, dest), &o, testOut)
 		require.NoError(t, err)
 
-		summaryPath := filepath.Join(deployDir, "codepush-promote-summary.json")
-		data, err := os.ReadFile(summaryPath)
+		assert.Equal(t, "Production", preview.Destination)
+		require.NotNil(t, preview.Current)
+		assert.Equal(t, "v9", preview.Current.Label)
+		assert.Equal(t, "v2", preview.Source.Label)
+		assert.False(t, preview.Source.Mandatory)
+		assert.True(t, preview.Release.Mandatory)
+		assert.InDelta(t, 25, preview.Release.Rollout, 0)
+		assert.Equal(t, "Fix login", preview.Release.Description)
+	})
+
+	t.Run("labeled source release to empty destination", func(t *testing.T) {
+		o := opts
+		o.Label = "v1"
+		preview, err := PreviewPromote(context.Background(), newClient(source, nil), &o, testOut)
 		require.NoError(t, err)
 
-		content := string(data)
-		assert.Contains(t, content, `"label": "v1"`)
-		assert.Contains(t, content, `"dest_deployment_id"`)
+		assert.Nil(t, preview.Current)
+		assert.Equal(t, "pkg-1", preview.Source.ID)
+	})
+
+	t.Run("unknown label", func(t *testing.T) {
+		o := opts
+		o.Label = "v42"
+		_, err := PreviewPromote(context.Background(), newClient(source, dest), &o, testOut)
+		assert.ErrorContains(t, err, `release label "v42" not found`)
+	})
+
+	t.Run("empty source", func(t *testing.T) {
+		_, err := PreviewPromote(context.Background(), newClient(nil, dest), &opts, testOut)
+		assert.ErrorContains(t, err, "no releases to promote")
+	})
+
+	t.Run("invalid override", func(t *testing.T) {
+		o := opts
+		o.Rollout = "150"
+		_, err := PreviewPromote(context.Background(), newClient(source, dest), &o, testOut)
+		var validationErr *ValidationError
+		assert.ErrorAs(t, err, &validationErr)
 	})
 }
 
@@ -236,3 +302,55 @@ func TestValidatePromoteOptions(t *testing.T) {
 		})
 	}
 }
+
+func TestBuildPromoteRequest(t *testing.T) {
+	t.Run("normalizes overrides", func(t *testing.T) {
+		req, err := buildPromoteRequest(&PromoteOptions{
+			AppVersion:  "2.0.0",
+			Description: "release",
+			Mandatory:   "1",
+			Disabled:    "FALSE",
+			Rollout:     "075",
+		})
+		require.NoError(t, err)
+
+		assert.Equal(t, "2.0.0", req.AppVersion)
+		assert.Equal(t, "release", req.Description)
+		assert.Equal(t, "true", req.Mandatory)
+		assert.Equal(t, "false", req.Disabled)
+		assert.Equal(t, "75", req.Rollout)
+	})
+
+	t.Run("normalizes app version range", func(t *testing.T) {
+		req, err := buildPromoteRequest(&PromoteOptions{AppVersion: "v1.2.x || >= 2.0.0"})
+		require.NoError(t, err)
+		assert.Equal(t, "1.2.x || >=2.0.0", req.AppVersion)
+	})
+
+	t.Run("unset overrides are omitted", func(t *testing.T) {
+		req, err := buildPromoteRequest(&PromoteOptions{})
+		require.NoError(t, err)
+		assert.Equal(t, PromoteRequest{}, req)
+	})
+
+	tests := []struct {
+		name    string
+		opts    PromoteOptions
+		wantErr string
+	}{
+		{name: "rollout above 100", opts: PromoteOptions{Rollout: "250"}, wantErr: `rollout must be between 0 and 100, got "250"`},
+		{name: "negative rollout", opts: PromoteOptions{Rollout: "-1"}, wantErr: "rollout must be between 0 and 100"},
+		{name: "non-numeric rollout", opts: PromoteOptions{Rollout: "half"}, wantErr: "rollout must be between 0 and 100"},
+		{name: "invalid mandatory", opts: PromoteOptions{Mandatory: "yes"}, wantErr: `mandatory must be true or false, got "yes"`},
+		{name: "invalid disabled", opts: PromoteOptions{Disabled: "maybe"}, wantErr: `disabled must be true or false, got "maybe"`},
+		{name: "invalid app version", opts: PromoteOptions{AppVersion: "two"}, wantErr: `invalid app version "two"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := buildPromoteRequest(&tt.opts)
+			require.Error(t, err)
+			assert.ErrorContains(t, err, tt.wantErr)
+		})
+	}
+}
@@ -0,0 +1,145 @@
+package codepush
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/bitrise-io/bitrise-plugins-codepush-cli/internal/bitrise"
+	"github.com/bitrise-io/bitrise-plugins-codepush-cli/internal/git"
+)
+
+// CollectProvenance returns the provenance of a push from the sources in
+// dir: the branch, commit, and dirty state of its git checkout, and the CI
+// build it runs in. The checkout takes precedence over CI variables, except
+// for the branch, which CI checkouts usually leave detached. It returns nil
+// when neither is available.
+func CollectProvenance(ctx context.Context, dir string) *Provenance {
+	p := ciProvenance()
+	if info, err := git.ReadInfo(ctx, dir); err == nil {
+		p.CommitHash = info.Commit
+		p.Dirty = info.Dirty
+		if info.Branch != "" {
+			p.Branch = info.Branch
+		}
+	}
+	if p == (Provenance{}) {
+		return nil
+	}
+	return &p
+}
+
+// ciProvenance reads the build of the running CI service from its
+// environment variables.
+func ciProvenance() Provenance {
+	if bitrise.IsBitriseEnvironment() {
+		meta := bitrise.GetBuildMetadata()
+		return Provenance{
+			BuildNumber: meta.BuildNumber,
+			BuildURL:    meta.BuildURL,
+			CommitHash:  meta.CommitHash,
+			Branch:      meta.Branch,
+			Workflow:    meta.Workflow,
+		}
+	}
+
+	switch {
+	case os.Getenv("GITHUB_ACTIONS") == "true":
+		branch := os.Getenv("GITHUB_HEAD_REF")
+		if branch == "" {
+			branch = os.Getenv("GITHUB_REF_NAME")
+		}
+		return Provenance{
+			BuildNumber: os.Getenv("GITHUB_RUN_NUMBER"),
+			BuildURL:    os.Getenv("GITHUB_SERVER_URL") + "/" + os.Getenv("GITHUB_REPOSITORY") + "/actions/runs/" + os.Getenv("GITHUB_RUN_ID"),
+			CommitHash:  os.Getenv("GITHUB_SHA"),
+			Branch:      branch,
+			Workflow:    os.Getenv("GITHUB_WORKFLOW"),
+		}
+	case os.Getenv("GITLAB_CI") == "true":
+		return Provenance{
+			BuildNumber: os.Getenv("CI_PIPELINE_IID"),
+			BuildURL:    os.Getenv("CI_JOB_URL"),
+			CommitHash:  os.Getenv("CI_COMMIT_SHA"),
+			Branch:      os.Getenv("CI_COMMIT_REF_NAME"),
+		}
+	case os.Getenv("CIRCLECI") == "true":
+		return Provenance{
+			BuildNumber: os.Getenv("CIRCLE_BUILD_NUM"),
+			BuildURL:    os.Getenv("CIRCLE_BUILD_URL"),
+			CommitHash:  os.Getenv("CIRCLE_SHA1"),
+			Branch:      os.Getenv("CIRCLE_BRANCH"),
+			Workflow:    os.Getenv("CIRCLE_JOB"),
+		}
+	}
+	return Provenance{}
+}
+
+// RecentRelease is a release a deployment received within the checked window.
+type RecentRelease struct {
+	Update Update
+	Age    time.Duration
+}
+
+// String describes the release and where it came from, e.g.
+// "received release v5 2 hours ago from build #123 (workflow release)".
+func (r RecentRelease) String() string {
+	s := fmt.Sprintf("received release %s %s ago", r.Update.Label, HumanAge(r.Age))
+	if p := r.Update.Provenance; p != nil && p.BuildNumber != "" {
+		s += " from build #" + p.BuildNumber
+		if p.Workflow != "" {
+			s += " (workflow " + p.Workflow + ")"
+		}
+	} else if c := r.Update.CreatedBy; c != nil && (c.Username != "" || c.Email != "") {
+		by := c.Username
+		if by == "" {
+			by = c.Email
+		}
+		s += " from " + by
+	}
+	return s
+}
+
+// RecentReleases returns the releases of a deployment created within window
+// of now, newest first. Releases without a parseable creation time are
+// skipped. Renaming or removing such a deployment likely breaks pipelines
+// that still push to it.
+func RecentReleases(ctx context.Context, client updateLister, appID, deploymentID string, window time.Duration, now time.Time) ([]RecentRelease, error) {
+	updates, err := client.ListUpdates(ctx, appID, deploymentID)
+	if err != nil {
+		return nil, fmt.Errorf("listing updates: %w", err)
+	}
+
+	var recent []RecentRelease
+	for i := len(updates) - 1; i >= 0; i-- {
+		created, err := time.Parse(time.RFC3339, updates[i].CreatedAt)
+		if err != nil {
+			continue
+		}
+		if age := now.Sub(created); age <= window {
+			recent = append(recent, RecentRelease{Update: updates[i], Age: max(age, 0)})
+		}
+	}
+	return recent, nil
+}
+
+// HumanAge formats a duration as a coarse "N units" phrase, e.g. "3 hours".
+func HumanAge(d time.Duration) string {
+	plural := func(n int, unit string) string {
+		if n == 1 {
+			return "1 " + unit
+		}
+		return fmt.Sprintf("%d %ss", n, unit)
+	}
+	switch {
+	case d < time.Minute:
+		return "less than a minute"
+	case d < time.Hour:
+		return plural(int(d/time.Minute), "minute")
+	case d < 48*time.Hour:
+		return plural(int(d/time.Hour), "hour")
+	default:
+		return plural(int(d/(24*time.Hour)), "day")
+	}
+}
@@ -0,0 +1,181 @@
+package codepush
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// clearCIEnv unsets the variables CollectProvenance reads CI builds from.
+func clearCIEnv(t *testing.T) {
+	t.Helper()
+	for _, key := range []string{"BITRISE_BUILD_NUMBER", "BITRISE_DEPLOY_DIR", "GITHUB_ACTIONS", "GITLAB_CI", "CIRCLECI"} {
+		t.Setenv(key, "")
+	}
+}
+
+// initGitRepo creates a git repository with one committed file on branch
+// main.
+func initGitRepo(t *testing.T) string {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	dir := t.TempDir()
+	for _, args := range [][]string{
+		{"init", "-q", "-b", "main"},
+		{"config", "user.email", "dev@example.com"},
+		{"config", "user.name", "Dev"},
+		{"add", "."},
+		{"commit", "-q", "-m", "Initial"},
+	} {
+		if args[0] == "add" {
+			require.NoError(t, os.WriteFile(filepath.Join(dir, "index.js"), []byte("1"), 0o644))
+		}
+		out, err := exec.Command("git", append([]string{"-C", dir}, args...)...).CombinedOutput()
+		require.NoError(t, err, string(out))
+	}
+	return dir
+}
+
+func TestCollectProvenance(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("nil outside CI and git", func(t *testing.T) {
+		clearCIEnv(t)
+		assert.Nil(t, CollectProvenance(ctx, t.TempDir()))
+	})
+
+	t.Run("reads build metadata on Bitrise", func(t *testing.T) {
+		clearCIEnv(t)
+		t.Setenv("BITRISE_BUILD_NUMBER", "123")
+		t.Setenv("BITRISE_BUILD_URL", "https://app.bitrise.io/build/abc")
+		t.Setenv("GIT_CLONE_COMMIT_HASH", "deadbeef")
+		t.Setenv("BITRISE_GIT_BRANCH", "release/1.2")
+		t.Setenv("BITRISE_TRIGGERED_WORKFLOW_ID", "release")
+
+		assert.Equal(t, &Provenance{
+			BuildNumber: "123",
+			BuildURL:    "https://app.bitrise.io/build/abc",
+			CommitHash:  "deadbeef",
+			Branch:      "release/1.2",
+			Workflow:    "release",
+		}, CollectProvenance(ctx, t.TempDir()))
+	})
+
+	t.Run("reads GitHub Actions run", func(t *testing.T) {
+		clearCIEnv(t)
+		t.Setenv("GITHUB_ACTIONS", "true")
+		t.Setenv("GITHUB_SERVER_URL", "https://github.com")
+		t.Setenv("GITHUB_REPOSITORY", "acme/app")
+		t.Setenv("GITHUB_RUN_ID", "987")
+		t.Setenv("GITHUB_RUN_NUMBER", "12")
+		t.Setenv("GITHUB_SHA", "cafe")
+		t.Setenv("GITHUB_HEAD_REF", "")
+		t.Setenv("GITHUB_REF_NAME", "main")
+		t.Setenv("GITHUB_WORKFLOW", "OTA")
+
+		assert.Equal(t, &Provenance{
+			BuildNumber: "12",
+			BuildURL:    "https://github.com/acme/app/actions/runs/987",
+			CommitHash:  "cafe",
+			Branch:      "main",
+			Workflow:    "OTA",
+		}, CollectProvenance(ctx, t.TempDir()))
+	})
+
+	t.Run("reads git checkout", func(t *testing.T) {
+		clearCIEnv(t)
+		dir := initGitRepo(t)
+
+		p := CollectProvenance(ctx, dir)
+		require.NotNil(t, p)
+		assert.Equal(t, "main", p.Branch)
+		assert.Len(t, p.CommitHash, 40)
+		assert.False(t, p.Dirty)
+
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "index.js"), []byte("2"), 0o644))
+		assert.True(t, CollectProvenance(ctx, dir).Dirty)
+	})
+
+	t.Run("checkout commit overrides CI, detached HEAD keeps CI branch", func(t *testing.T) {
+		clearCIEnv(t)
+		dir := initGitRepo(t)
+		out, err := exec.Command("git", "-C", dir, "checkout", "-q", "--detach").CombinedOutput()
+		require.NoError(t, err, string(out))
+		t.Setenv("BITRISE_BUILD_NUMBER", "123")
+		t.Setenv("GIT_CLONE_COMMIT_HASH", "deadbeef")
+		t.Setenv("BITRISE_GIT_BRANCH", "feature/login")
+
+		p := CollectProvenance(ctx, dir)
+		require.NotNil(t, p)
+		assert.Equal(t, "feature/login", p.Branch)
+		assert.NotEqual(t, "deadbeef", p.CommitHash)
+	})
+}
+
+func TestRecentReleases(t *testing.T) {
+	now := time.Date(2024, 6, 10, 12, 0, 0, 0, time.UTC)
+	updates := []Update{
+		{Label: "v1", CreatedAt: "2024-05-01T12:00:00Z"},
+		{Label: "v2", CreatedAt: "not a time"},
+		{Label: "v3", CreatedAt: "2024-06-09T12:00:00Z", CreatedBy: &UpdateCreator{Email: "dev@example.com"}},
+		{Label: "v4", CreatedAt: "2024-06-10T10:00:00Z", Provenance: &Provenance{BuildNumber: "123", Workflow: "release"}},
+	}
+
+	t.Run("returns releases within the window, newest first", func(t *testing.T) {
+		client := &mockClient{listUpdatesFunc: func(appID, deploymentID string) ([]Update, error) { return updates, nil }}
+
+		recent, err := RecentReleases(context.Background(), client, "app-1", "dep-1", 7*24*time.Hour, now)
+		require.NoError(t, err)
+		require.Len(t, recent, 2)
+
+		assert.Equal(t, "received release v4 2 hours ago from build #123 (workflow release)", recent[0].String())
+		assert.Equal(t, "received release v3 24 hours ago from dev@example.com", recent[1].String())
+	})
+
+	t.Run("returns nothing outside the window", func(t *testing.T) {
+		client := &mockClient{listUpdatesFunc: func(appID, deploymentID string) ([]Update, error) { return updates, nil }}
+
+		recent, err := RecentReleases(context.Background(), client, "app-1", "dep-1", time.Hour, now)
+		require.NoError(t, err)
+		assert.Empty(t, recent)
+	})
+
+	t.Run("returns error when listing fails", func(t *testing.T) {
+		client := &mockClient{listUpdatesFunc: func(appID, deploymentID string) ([]Update, error) {
+			return nil, errors.New("HTTP 500")
+		}}
+
+		_, err := RecentReleases(context.Background(), client, "app-1", "dep-1", time.Hour, now)
+		require.Error(t, err)
+		assert.ErrorContains(t, err, "listing updates")
+	})
+}
+
+func TestHumanAge(t *testing.T) {
+	tests := []struct {
+		d    time.Duration
+		want string
+	}{
+		{30 * time.Second, "less than a minute"},
+		{time.Minute, "1 minute"},
+		{45 * time.Minute, "45 minutes"},
+		{2 * time.Hour, "2 hours"},
+		{47 * time.Hour, "47 hours"},
+		{72 * time.Hour, "3 days"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.want, func(t *testing.T) {
+			assert.Equal(t, tt.want, HumanAge(tt.d))
+		})
+	}
+}
@@ -0,0 +1,67 @@
+package codepush
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// PruneOptions selects the releases of a deployment to delete.
+type PruneOptions struct {
+	// Keep is the number of newest releases that are never pruned. The
+	// newest release is always kept, since it is the one being served.
+	Keep int
+	// OlderThan, when set, limits pruning to releases created longer ago.
+	// Releases without a parseable creation time are kept.
+	OlderThan time.Duration
+}
+
+// Validate checks that the options select releases by at least one rule.
+func (o PruneOptions) Validate() error {
+	if o.Keep < 0 {
+		return fmt.Errorf("--keep must not be negative, got %d", o.Keep)
+	}
+	if o.Keep == 0 && o.OlderThan == 0 {
+		return errors.New("set --keep or --older-than to choose which releases to prune")
+	}
+	return nil
+}
+
+// PruneCandidates returns the releases that opts selects for deletion,
+// oldest first. updates must be ordered oldest first, as returned by
+// ListUpdates.
+func PruneCandidates(updates []Update, opts PruneOptions, now time.Time) []Update {
+	keep := max(opts.Keep, 1)
+	if len(updates) <= keep {
+		return nil
+	}
+
+	var candidates []Update
+	for _, u := range updates[:len(updates)-keep] {
+		if opts.OlderThan > 0 {
+			created, err := time.Parse(time.RFC3339, u.CreatedAt)
+			if err != nil || now.Sub(created) < opts.OlderThan {
+				continue
+			}
+		}
+		candidates = append(candidates, u)
+	}
+	return candidates
+}
+
+// updateDeleter is the subset of Client needed to delete releases.
+type updateDeleter interface {
+	DeleteUpdate(ctx context.Context, appID, deploymentID, updateID string) error
+}
+
+// DeleteUpdates deletes updates in order, stopping at the first failure.
+// It returns the number of updates deleted.
+func DeleteUpdates(ctx context.Context, client updateDeleter, appID, deploymentID string, updates []Update) (int, error) {
+	for i, u := range updates {
+		if err := client.DeleteUpdate(ctx, appID, deploymentID, u.ID); err != nil {
+			return i, fmt.Errorf("deleting update %s: %w", u.Label, err)
+		}
+	}
+	return len(updates), nil
+}
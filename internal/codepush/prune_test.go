@@ -0,0 +1,75 @@
+package codepush
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPruneCandidates(t *testing.T) {
+	now := time.Date(2026, 6, 1, 0, 0, 0, 0, time.UTC)
+	daysAgo := func(d int) string { return now.Add(-time.Duration(d) * 24 * time.Hour).Format(time.RFC3339) }
+	updates := []Update{
+		{Label: "v1", CreatedAt: daysAgo(200)},
+		{Label: "v2", CreatedAt: ""},
+		{Label: "v3", CreatedAt: daysAgo(120)},
+		{Label: "v4", CreatedAt: daysAgo(30)},
+		{Label: "v5", CreatedAt: daysAgo(100)},
+	}
+
+	labels := func(us []Update) []string {
+		var out []string
+		for _, u := range us {
+			out = append(out, u.Label)
+		}
+		return out
+	}
+
+	tests := []struct {
+		name string
+		opts PruneOptions
+		want []string
+	}{
+		{"keep newest", PruneOptions{Keep: 2}, []string{"v1", "v2", "v3"}},
+		{"keep more than exist", PruneOptions{Keep: 10}, nil},
+		{"older than always keeps the newest", PruneOptions{OlderThan: 90 * 24 * time.Hour}, []string{"v1", "v3"}},
+		{"keep and older than", PruneOptions{Keep: 3, OlderThan: 150 * 24 * time.Hour}, []string{"v1"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, labels(PruneCandidates(updates, tt.opts, now)))
+		})
+	}
+}
+
+func TestPruneOptionsValidate(t *testing.T) {
+	assert.NoError(t, PruneOptions{Keep: 10}.Validate())
+	assert.NoError(t, PruneOptions{OlderThan: time.Hour}.Validate())
+	assert.ErrorContains(t, PruneOptions{}.Validate(), "--keep or --older-than")
+	assert.ErrorContains(t, PruneOptions{Keep: -1}.Validate(), "negative")
+}
+
+func TestDeleteUpdates(t *testing.T) {
+	var deleted []string
+	client := &mockClient{
+		deleteUpdateFunc: func(appID, deploymentID, updateID string) error {
+			if updateID == "pkg-3" {
+				return errors.New("HTTP 500")
+			}
+			deleted = append(deleted, updateID)
+			return nil
+		},
+	}
+
+	n, err := DeleteUpdates(context.Background(), client, "app-1", "dep-1", []Update{
+		{ID: "pkg-1", Label: "v1"}, {ID: "pkg-2", Label: "v2"}, {ID: "pkg-3", Label: "v3"}, {ID: "pkg-4", Label: "v4"},
+	})
+	require.ErrorContains(t, err, "deleting update v3")
+	assert.Equal(t, 2, n)
+	assert.Equal(t, []string{"pkg-1", "pkg-2"}, deleted)
+}
@@ -22,7 +22,7 @@ func Push(ctx context.Context, client Client, opts *PushOptions, out *output.Wri
 // PushWithConfig executes the push workflow with a configurable poll config.
 func PushWithConfig(ctx context.Context, client Client, opts *PushOptions, pollCfg PollConfig, out *output.Writer) (*PushResult, error) {
 	if err := validatePushOptions(opts); err != nil {
-		return nil, err
+		return nil, Invalid(err)
 	}
 
 	deploymentID, err := ResolveDeployment(ctx, client, opts.AppID, opts.DeploymentID, out)
@@ -30,91 +30,138 @@ func PushWithConfig(ctx context.Context, client Client, opts *PushOptions, pollC
 		return nil, err
 	}
 
-	updateID, fileSizeBytes, err := uploadBundle(ctx, client, opts, deploymentID, out)
-	if err != nil {
+	if existing, err := checkDuplicate(ctx, client, opts, deploymentID, out); err != nil || existing != nil {
+		return existing, err
+	}
+
+	if opts.ExpectLabel != "" {
+		if err := checkExpectLabel(ctx, client, opts.AppID, deploymentID, opts.ExpectLabel); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := warnNativeChanges(ctx, client, opts, deploymentID, out); err != nil {
 		return nil, err
 	}
 
-	var status *UpdateStatus
-	err = out.Indeterminate("Processing update", func() error {
-		var pollErr error
-		status, pollErr = pollStatus(ctx, client, UpdateRef{AppID: opts.AppID, DeploymentID: deploymentID, UpdateID: updateID}, pollCfg)
-		return pollErr
-	})
+	uploaded, err := uploadBundle(ctx, client, opts, deploymentID, out)
 	if err != nil {
 		return nil, err
 	}
 
+	ref := UpdateRef{AppID: opts.AppID, DeploymentID: deploymentID, UpdateID: uploaded.updateID}
+	status := &UpdateStatus{UpdateID: uploaded.updateID, Status: StatusUploaded}
+	out.Record("status", status)
+	if opts.NoWait {
+		out.Info("Not waiting for processing: wait for it with 'codepush package status --follow'")
+	} else {
+		start := time.Now()
+		err = out.Indeterminate("Processing update", func() error {
+			var pollErr error
+			status, pollErr = pollStatus(ctx, client, ref, pollCfg, out)
+			return pollErr
+		})
+		out.Debug("poll finished", "duration", time.Since(start).Round(time.Millisecond))
+		if err != nil {
+			if errors.Is(err, ErrProcessingFailed) {
+				printProcessingLogs(ctx, client, ref, out)
+			}
+			return nil, err
+		}
+	}
+
 	return &PushResult{
-		UpdateID:      updateID,
+		UpdateID:      uploaded.updateID,
 		AppID:         opts.AppID,
 		DeploymentID:  deploymentID,
 		AppVersion:    opts.AppVersion,
 		Status:        status.Status,
-		FileSizeBytes: fileSizeBytes,
+		FileSizeBytes: uploaded.size,
 		Rollout:       opts.Rollout,
+
+		UploadStrategy: uploaded.strategy,
+		RuntimeVersion: opts.RuntimeVersion,
+		DiffAgainst:    uploaded.diffAgainst,
+
+		ContentSizeBytes: uploaded.contentSize,
+		Compression:      opts.Compression.String(),
+		Targeting:        opts.Targeting,
+		Provenance:       opts.Provenance,
 	}, nil
 }
 
-func uploadBundle(ctx context.Context, client Client, opts *PushOptions, deploymentID string, out *output.Writer) (string, int64, error) {
-	step := out.StartStep("Packaging bundle: %s", opts.BundlePath)
-	zipPath, err := ziputil.Directory(opts.BundlePath)
-	if err != nil {
-		step.Cancel()
-		return "", 0, fmt.Errorf("packaging bundle: %w", err)
-	}
-	defer func() { _ = os.Remove(zipPath) }()
+// uploadedBundle describes a package uploaded by uploadBundle.
+type uploadedBundle struct {
+	updateID    string
+	size        int64
+	contentSize int64
+	strategy    string
+
+	// diffAgainst is the label of the base release when a delta package
+	// was uploaded, empty for a full package.
+	diffAgainst string
+}
 
-	zipInfo, err := os.Stat(zipPath)
+func uploadBundle(ctx context.Context, client Client, opts *PushOptions, deploymentID string, out *output.Writer) (*uploadedBundle, error) {
+	step := out.StartStep("Packaging bundle: %s", opts.BundlePath)
+	start := time.Now()
+	archive, err := ziputil.Package(opts.Workspace, opts.BundlePath, opts.Compression)
 	if err != nil {
 		step.Cancel()
-		return "", 0, fmt.Errorf("reading zip file info: %w", err)
+		return nil, fmt.Errorf("packaging bundle: %w", err)
 	}
+	zipPath := archive.Path
+	defer opts.Workspace.Remove(zipPath)
 	step.Done()
-	out.Info("Update size: %s", output.HumanBytes(zipInfo.Size()))
+	out.Debug("zip finished", "bytes", archive.Size, "content_bytes", archive.ContentSize, "duration", time.Since(start).Round(time.Millisecond))
+	out.Info("Update size: %s (%s uncompressed, %s)", output.HumanBytes(archive.Size),
+		output.HumanBytes(archive.ContentSize), opts.Compression)
 
-	updateID := uuid.New().String()
+	uploaded := &uploadedBundle{updateID: uuid.New().String(), size: archive.Size, contentSize: archive.ContentSize}
+	uploadPath := zipPath
+	var diffAgainstID string
+	if !opts.Full {
+		if delta := tryDelta(ctx, client, opts, deploymentID, archive.Size, out); delta != nil {
+			defer opts.Workspace.Remove(delta.Path)
+			uploadPath = delta.Path
+			uploaded.size = delta.Size
+			uploaded.diffAgainst = delta.Base.Label
+			diffAgainstID = delta.Base.ID
+		}
+	}
 
 	stepURL := out.StartStep("Requesting upload URL")
-	uploadResp, err := client.GetUploadURL(ctx, opts.AppID, deploymentID, updateID, UploadURLRequest{
-		AppVersion:    opts.AppVersion,
-		FileName:      filepath.Base(zipPath),
-		FileSizeBytes: zipInfo.Size(),
-		Description:   opts.Description,
-		Mandatory:     opts.Mandatory,
-		Disabled:      opts.Disabled,
-		Rollout:       opts.Rollout,
+	uploadResp, err := client.GetUploadURL(ctx, opts.AppID, deploymentID, uploaded.updateID, UploadURLRequest{
+		AppVersion:     opts.AppVersion,
+		FileName:       filepath.Base(opts.BundlePath) + ".zip",
+		FileSizeBytes:  uploaded.size,
+		DiffAgainst:    diffAgainstID,
+		Description:    opts.Description,
+		Mandatory:      opts.Mandatory,
+		Disabled:       opts.Disabled,
+		Rollout:        opts.Rollout,
+		Multipart:      opts.UploadStrategy != UploadStrategySingle,
+		RuntimeVersion: opts.RuntimeVersion,
+		Provenance:     opts.Provenance,
+		Targeting:      opts.Targeting,
 	})
 	if err != nil {
 		stepURL.Cancel()
-		return "", 0, fmt.Errorf("requesting upload URL: %w", err)
+		return nil, fmt.Errorf("requesting upload URL: %w", err)
 	}
 	stepURL.Done()
 
-	zipFile, err := os.Open(zipPath)
+	start = time.Now()
+	uploaded.strategy, err = uploadArchive(ctx, newArchiveUpload(client, uploadPath, uploaded.size, uploadResp), opts.UploadStrategy, out)
 	if err != nil {
-		return "", 0, fmt.Errorf("opening zip for upload: %w", err)
-	}
-	defer func() { _ = zipFile.Close() }()
-
-	progress := out.NewProgress("Uploading")
-	pr := output.NewProgressReader(zipFile, zipInfo.Size(), progress)
-	uploadErr := client.UploadFile(ctx, UploadFileRequest{
-		URL:           uploadResp.URL,
-		Method:        uploadResp.Method,
-		Headers:       uploadResp.Headers,
-		Body:          pr,
-		ContentLength: zipInfo.Size(),
-	})
-	if uploadErr != nil {
-		progress.Cancel()
-		return "", 0, fmt.Errorf("uploading update: %w", uploadErr)
+		return nil, fmt.Errorf("uploading update: %w", err)
 	}
-	progress.Done(output.HumanBytes(zipInfo.Size()))
+	out.Debug("upload finished", "bytes", uploaded.size, "strategy", uploaded.strategy, "duration", time.Since(start).Round(time.Millisecond))
 
-	return updateID, zipInfo.Size(), nil
+	return uploaded, nil
 }
 
+// validatePushOptions checks opts and normalizes its app version.
 func validatePushOptions(opts *PushOptions) error {
 	if err := validateBaseOptions(opts.AppID, opts.Token); err != nil {
 		return err
@@ -125,12 +172,24 @@ func validatePushOptions(opts *PushOptions) error {
 	if opts.AppVersion == "" {
 		return errors.New("app version is required: set --app-version")
 	}
+	appVersion, err := NormalizeAppVersion(opts.AppVersion)
+	if err != nil {
+		return err
+	}
+	opts.AppVersion = appVersion
 	if opts.BundlePath == "" {
 		return errors.New("bundle path is required: provide as argument or use --bundle")
 	}
+	if err := ValidateUploadStrategy(opts.UploadStrategy); err != nil {
+		return err
+	}
 	if opts.Rollout < 0 || opts.Rollout > 100 {
 		return fmt.Errorf("rollout must be between 0 and 100, got %d", opts.Rollout)
 	}
+	t := opts.Targeting
+	if _, err := NewTargeting(t.OSVersion, t.DeviceModels, t.Countries); err != nil {
+		return err
+	}
 
 	info, err := os.Stat(opts.BundlePath)
 	if err != nil {
@@ -180,25 +239,70 @@ type statusChecker interface {
 	GetUpdateStatus(ctx context.Context, appID, deploymentID, updateID string) (*UpdateStatus, error)
 }
 
-func pollStatus(ctx context.Context, client statusChecker, ref UpdateRef, cfg PollConfig) (*UpdateStatus, error) {
+// pollStatus waits until the update is processed, emitting each status it
+// sees as a "status" record.
+func pollStatus(ctx context.Context, client statusChecker, ref UpdateRef, cfg PollConfig, out *output.Writer) (*UpdateStatus, error) {
 	for attempt := range cfg.MaxAttempts {
 		status, err := client.GetUpdateStatus(ctx, ref.AppID, ref.DeploymentID, ref.UpdateID)
 		if err != nil {
 			return nil, fmt.Errorf("checking update status: %w", err)
 		}
+		out.Record("status", status)
+		out.Debug("update status", "attempt", attempt+1, "status", status.Status)
 
 		switch status.Status {
 		case StatusProcessedValid:
 			return status, nil
 		case StatusProcessedError:
-			return nil, fmt.Errorf("update processing failed: %s", status.StatusReason)
+			return nil, &ProcessingError{UpdateID: ref.UpdateID, Reason: status.StatusReason}
 		}
 
 		if attempt < cfg.MaxAttempts-1 {
-			time.Sleep(cfg.Interval)
+			select {
+			case <-ctx.Done():
+				return nil, fmt.Errorf("checking update status: %w", ctx.Err())
+			case <-time.After(cfg.Interval):
+			}
 		}
 	}
 
 	totalWait := time.Duration(cfg.MaxAttempts) * cfg.Interval
-	return nil, fmt.Errorf("update processing timed out after %s", totalWait)
+	return nil, &ProcessingError{UpdateID: ref.UpdateID, Pending: true, Waited: totalWait}
+}
+
+// ProcessingError reports an uploaded update that did not finish processing
+// successfully: either the server rejected it, or it was still being
+// processed when polling stopped.
+type ProcessingError struct {
+	UpdateID string
+	// Pending is set when processing had not finished after Waited.
+	Pending bool
+	Waited  time.Duration
+	// Reason is the server's explanation of a rejection.
+	Reason string
+}
+
+func (e *ProcessingError) Error() string {
+	if e.Pending {
+		return fmt.Sprintf("%s after %s: update %s was uploaded and may still be processed, check it with 'codepush update status'",
+			ErrProcessingPending, e.Waited, e.UpdateID)
+	}
+	return fmt.Sprintf("%s: %s", ErrProcessingFailed, e.Reason)
+}
+
+// Unwrap allows errors.Is(err, ErrProcessingPending) and
+// errors.Is(err, ErrProcessingFailed).
+func (e *ProcessingError) Unwrap() error {
+	if e.Pending {
+		return ErrProcessingPending
+	}
+	return ErrProcessingFailed
+}
+
+// ExitCode returns ExitCodeTimeout or ExitCodeProcessingFailed.
+func (e *ProcessingError) ExitCode() int {
+	if e.Pending {
+		return ExitCodeTimeout
+	}
+	return ExitCodeProcessingFailed
 }
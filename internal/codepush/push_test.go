@@ -1,6 +1,7 @@
 package codepush
 
 import (
+	"bytes"
 	"context"
 	"errors"
 	"io"
@@ -11,6 +12,8 @@ import (
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+
+	"github.com/bitrise-io/bitrise-plugins-codepush-cli/internal/output"
 )
 
 func TestPush(t *testing.T) {
@@ -219,9 +222,40 @@ func TestPush(t *testing.T) {
 
 		_, err := PushWithConfig(context.Background(), client, opts, fastPollConfig, testOut)
 		require.Error(t, err)
+		assert.ErrorIs(t, err, ErrProcessingFailed)
 		assert.ErrorContains(t, err, "invalid bundle format")
 	})
 
+	t.Run("poll failure prints server processing log", func(t *testing.T) {
+		bundleDir := createTestBundleDir(t)
+
+		var logsRequested bool
+		client := &mockClient{
+			getUpdateStatusFunc: func(appID, deploymentID, updateID string) (*UpdateStatus, error) {
+				return &UpdateStatus{Status: StatusProcessedError, StatusReason: "invalid bundle format"}, nil
+			},
+			listUpdateLogsFunc: func(appID, deploymentID, updateID string) ([]UpdateLogEntry, error) {
+				logsRequested = true
+				return []UpdateLogEntry{{Level: "error", Message: "missing index.android.bundle"}}, nil
+			},
+		}
+
+		opts := &PushOptions{
+			AppID:        "app-123",
+			DeploymentID: "00000000-0000-0000-0000-000000000001",
+			Token:        "test-token",
+			AppVersion:   "1.0.0",
+			Rollout:      100,
+			BundlePath:   bundleDir,
+		}
+
+		var buf bytes.Buffer
+		_, err := PushWithConfig(context.Background(), client, opts, fastPollConfig, output.NewTest(&buf))
+		require.Error(t, err)
+		assert.True(t, logsRequested)
+		assert.Contains(t, buf.String(), "ERROR missing index.android.bundle")
+	})
+
 	t.Run("poll timeout", func(t *testing.T) {
 		bundleDir := createTestBundleDir(t)
 
@@ -245,6 +279,32 @@ func TestPush(t *testing.T) {
 		assert.ErrorContains(t, err, "timed out")
 	})
 
+	t.Run("no wait skips processing", func(t *testing.T) {
+		bundleDir := createTestBundleDir(t)
+
+		client := &mockClient{
+			getUpdateStatusFunc: func(appID, deploymentID, updateID string) (*UpdateStatus, error) {
+				t.Fatal("status should not be polled with NoWait")
+				return nil, nil
+			},
+		}
+
+		opts := &PushOptions{
+			AppID:        "app-123",
+			DeploymentID: "00000000-0000-0000-0000-000000000001",
+			Token:        "test-token",
+			AppVersion:   "1.0.0",
+			Rollout:      100,
+			BundlePath:   bundleDir,
+			NoWait:       true,
+		}
+
+		result, err := Push(context.Background(), client, opts, testOut)
+		require.NoError(t, err)
+		assert.Equal(t, StatusUploaded, result.Status)
+		assert.NotEmpty(t, result.UpdateID)
+	})
+
 	t.Run("rollout is sent and captured in result", func(t *testing.T) {
 		bundleDir := createTestBundleDir(t)
 		var capturedReq UploadURLRequest
@@ -301,6 +361,29 @@ func TestPush(t *testing.T) {
 		_, err = os.Stat(summaryPath)
 		assert.Error(t, err, "push should not export summary; that responsibility moved to CLI layer")
 	})
+
+	t.Run("logs phase timings with debug enabled", func(t *testing.T) {
+		bundleDir := createTestBundleDir(t)
+		var buf bytes.Buffer
+		out := output.NewTest(&buf)
+		out.SetLevel(output.LevelDebug)
+
+		opts := &PushOptions{
+			AppID:        "app-123",
+			DeploymentID: "00000000-0000-0000-0000-000000000001",
+			Token:        "test-token",
+			AppVersion:   "2.0.0",
+			Rollout:      100,
+			BundlePath:   bundleDir,
+		}
+
+		_, err := PushWithConfig(context.Background(), &mockClient{}, opts, fastPollConfig, out)
+		require.NoError(t, err)
+
+		for _, msg := range []string{`msg="zip finished"`, `msg="upload finished"`, `msg="update status" attempt=1`, `msg="poll finished"`} {
+			assert.Contains(t, buf.String(), msg)
+		}
+	})
 }
 
 func TestValidatePushOptions(t *testing.T) {
@@ -311,6 +394,12 @@ func TestValidatePushOptions(t *testing.T) {
 		require.NoError(t, validatePushOptions(&opts))
 	})
 
+	t.Run("app version range is normalized", func(t *testing.T) {
+		opts := PushOptions{AppID: "app", DeploymentID: "dep", Token: "tok", AppVersion: ">= 1.2.0  <1.4.0", Rollout: 100, BundlePath: bundleDir}
+		require.NoError(t, validatePushOptions(&opts))
+		assert.Equal(t, ">=1.2.0 <1.4.0", opts.AppVersion)
+	})
+
 	tests := []struct {
 		name    string
 		opts    PushOptions
@@ -336,6 +425,11 @@ func TestValidatePushOptions(t *testing.T) {
 			opts:    PushOptions{AppID: "app", DeploymentID: "dep", Token: "tok", Rollout: 100, BundlePath: bundleDir},
 			wantErr: "app version is required",
 		},
+		{
+			name:    "malformed app version",
+			opts:    PushOptions{AppID: "app", DeploymentID: "dep", Token: "tok", AppVersion: "1.0-final!", Rollout: 100, BundlePath: bundleDir},
+			wantErr: `invalid app version "1.0-final!"`,
+		},
 		{
 			name:    "missing bundle path",
 			opts:    PushOptions{AppID: "app", DeploymentID: "dep", Token: "tok", AppVersion: "1.0", Rollout: 100},
@@ -428,12 +522,32 @@ func TestPollStatus(t *testing.T) {
 		}
 
 		ref := UpdateRef{AppID: "app", DeploymentID: "dep", UpdateID: "pkg"}
-		status, err := pollStatus(context.Background(), client, ref, PollConfig{MaxAttempts: 5, Interval: 1 * time.Millisecond})
+		status, err := pollStatus(context.Background(), client, ref, PollConfig{MaxAttempts: 5, Interval: 1 * time.Millisecond}, testOut)
 		require.NoError(t, err)
 		assert.Equal(t, StatusProcessedValid, status.Status)
 		assert.Equal(t, 3, callCount)
 	})
 
+	t.Run("records every status", func(t *testing.T) {
+		statuses := []string{StatusUploaded, StatusProcessedValid}
+		client := &mockClient{
+			getUpdateStatusFunc: func(_, _, updateID string) (*UpdateStatus, error) {
+				status := statuses[0]
+				statuses = statuses[1:]
+				return &UpdateStatus{UpdateID: updateID, Status: status}, nil
+			},
+		}
+		var records bytes.Buffer
+		out := output.NewTest(io.Discard)
+		out.SetRecordStream(&records)
+
+		ref := UpdateRef{AppID: "app", DeploymentID: "dep", UpdateID: "pkg"}
+		_, err := pollStatus(context.Background(), client, ref, PollConfig{MaxAttempts: 5, Interval: time.Millisecond}, out)
+		require.NoError(t, err)
+		assert.Equal(t, `{"type":"status","package_id":"pkg","status":"`+StatusUploaded+`","status_reason":""}`+"\n"+
+			`{"type":"status","package_id":"pkg","status":"`+StatusProcessedValid+`","status_reason":""}`+"\n", records.String())
+	})
+
 	t.Run("returns error on failed", func(t *testing.T) {
 		client := &mockClient{
 			getUpdateStatusFunc: func(appID, deploymentID, updateID string) (*UpdateStatus, error) {
@@ -442,9 +556,14 @@ func TestPollStatus(t *testing.T) {
 		}
 
 		ref := UpdateRef{AppID: "app", DeploymentID: "dep", UpdateID: "pkg"}
-		_, err := pollStatus(context.Background(), client, ref, fastPollConfig)
+		_, err := pollStatus(context.Background(), client, ref, fastPollConfig, testOut)
 		require.Error(t, err)
 		assert.ErrorContains(t, err, "bad format")
+		assert.ErrorIs(t, err, ErrProcessingFailed)
+
+		var procErr *ProcessingError
+		require.ErrorAs(t, err, &procErr)
+		assert.Equal(t, ExitCodeProcessingFailed, procErr.ExitCode())
 	})
 
 	t.Run("times out", func(t *testing.T) {
@@ -455,12 +574,53 @@ func TestPollStatus(t *testing.T) {
 		}
 
 		ref := UpdateRef{AppID: "app", DeploymentID: "dep", UpdateID: "pkg"}
-		_, err := pollStatus(context.Background(), client, ref, PollConfig{MaxAttempts: 2, Interval: 1 * time.Millisecond})
+		_, err := pollStatus(context.Background(), client, ref, PollConfig{MaxAttempts: 2, Interval: 1 * time.Millisecond}, testOut)
 		require.Error(t, err)
 		assert.ErrorContains(t, err, "timed out")
+		assert.ErrorContains(t, err, "pkg")
+		assert.ErrorIs(t, err, ErrProcessingPending)
+
+		var procErr *ProcessingError
+		require.ErrorAs(t, err, &procErr)
+		assert.Equal(t, ExitCodeTimeout, procErr.ExitCode())
+	})
+
+	t.Run("stops waiting when canceled", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		client := &mockClient{
+			getUpdateStatusFunc: func(appID, deploymentID, updateID string) (*UpdateStatus, error) {
+				cancel()
+				return &UpdateStatus{UpdateID: updateID, Status: StatusUploaded}, nil
+			},
+		}
+
+		ref := UpdateRef{AppID: "app", DeploymentID: "dep", UpdateID: "pkg"}
+		start := time.Now()
+		_, err := pollStatus(ctx, client, ref, PollConfig{MaxAttempts: 5, Interval: time.Minute}, testOut)
+		assert.ErrorIs(t, err, context.Canceled)
+		assert.Less(t, time.Since(start), time.Second)
 	})
 }
 
+func TestPollConfigFor(t *testing.T) {
+	tests := []struct {
+		timeout  time.Duration
+		attempts int
+	}{
+		{timeout: 2 * time.Minute, attempts: 60},
+		{timeout: 10 * time.Minute, attempts: 300},
+		{timeout: 3 * time.Second, attempts: 2},
+		{timeout: time.Millisecond, attempts: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.timeout.String(), func(t *testing.T) {
+			cfg := PollConfigFor(tt.timeout)
+			assert.Equal(t, tt.attempts, cfg.MaxAttempts)
+			assert.Equal(t, DefaultPollConfig.Interval, cfg.Interval)
+		})
+	}
+}
+
 func createTestBundleDir(t *testing.T) string {
 	t.Helper()
 	dir := t.TempDir()
@@ -0,0 +1,104 @@
+package codepush
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// DefaultAPIRateLimit is the number of API requests per second the CLI sends
+// by default, across all clients of a run.
+const DefaultAPIRateLimit = 10.0
+
+// minAPIRateLimit is the slowest rate a throttled limiter backs off to.
+const minAPIRateLimit = 1.0
+
+// RateLimiter is a token bucket holding up to one second of requests. After
+// the server answers HTTP 429, it holds back every request for the
+// server's Retry-After and halves its rate for the rest of the run.
+//
+// Clients created with the same RateLimiter share one budget, so commands
+// that fan out over deployments or apps do not multiply the request rate.
+type RateLimiter struct {
+	mu          sync.Mutex
+	rate        float64 // requests per second; 0 means unlimited
+	tokens      float64
+	last        time.Time
+	pausedUntil time.Time
+
+	now func() time.Time
+}
+
+// NewRateLimiter returns a RateLimiter allowing rps requests per second.
+// Zero disables the limit, though the limiter still pauses after HTTP 429.
+func NewRateLimiter(rps float64) *RateLimiter {
+	l := &RateLimiter{now: time.Now}
+	l.setRate(rps)
+	return l
+}
+
+func (l *RateLimiter) setRate(rps float64) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.rate = max(rps, 0)
+	l.tokens = l.burst()
+	l.last = l.now()
+}
+
+func (l *RateLimiter) burst() float64 {
+	return max(l.rate, 1)
+}
+
+// Wait blocks until a request may be sent, or returns ctx's error.
+func (l *RateLimiter) Wait(ctx context.Context) error {
+	wait := l.reserve()
+	if wait <= 0 {
+		return nil
+	}
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// reserve takes a token and returns how long to wait before using it.
+func (l *RateLimiter) reserve() time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	var wait time.Duration
+	if now.Before(l.pausedUntil) {
+		wait = l.pausedUntil.Sub(now)
+	}
+	if l.rate <= 0 {
+		return wait
+	}
+
+	l.tokens = min(l.burst(), l.tokens+now.Sub(l.last).Seconds()*l.rate)
+	l.last = now
+	l.tokens--
+	if l.tokens < 0 {
+		wait = max(wait, time.Duration(-l.tokens/l.rate*float64(time.Second)))
+	}
+	return wait
+}
+
+// Throttled records an HTTP 429: requests are held back for d, and the
+// rate is halved, down to minAPIRateLimit.
+func (l *RateLimiter) Throttled(d time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if until := l.now().Add(d); until.After(l.pausedUntil) {
+		l.pausedUntil = until
+	}
+	if l.rate > minAPIRateLimit {
+		l.rate = max(l.rate/2, minAPIRateLimit)
+		l.tokens = min(l.tokens, l.burst())
+	}
+}
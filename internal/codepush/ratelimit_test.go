@@ -0,0 +1,82 @@
+package codepush
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestLimiter(rps float64) (*RateLimiter, *time.Time) {
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	l := &RateLimiter{now: func() time.Time { return now }}
+	l.setRate(rps)
+	return l, &now
+}
+
+func TestRateLimiter(t *testing.T) {
+	t.Run("allows a burst of one second, then paces requests", func(t *testing.T) {
+		l, _ := newTestLimiter(4)
+		for range 4 {
+			assert.Zero(t, l.reserve())
+		}
+		assert.Equal(t, 250*time.Millisecond, l.reserve())
+		assert.Equal(t, 500*time.Millisecond, l.reserve())
+	})
+
+	t.Run("refills over time", func(t *testing.T) {
+		l, now := newTestLimiter(2)
+		l.reserve()
+		l.reserve()
+		*now = now.Add(500 * time.Millisecond)
+		assert.Zero(t, l.reserve())
+		assert.Equal(t, 500*time.Millisecond, l.reserve())
+	})
+
+	t.Run("zero disables the limit", func(t *testing.T) {
+		l, _ := newTestLimiter(0)
+		for range 100 {
+			assert.Zero(t, l.reserve())
+		}
+	})
+
+	t.Run("throttling pauses requests and halves the rate", func(t *testing.T) {
+		l, now := newTestLimiter(8)
+		l.Throttled(3 * time.Second)
+		assert.Equal(t, 4.0, l.rate)
+		assert.Equal(t, 3*time.Second, l.reserve())
+
+		*now = now.Add(3 * time.Second)
+		l.Throttled(time.Second)
+		l.Throttled(time.Second)
+		l.Throttled(time.Second)
+		assert.Equal(t, minAPIRateLimit, l.rate)
+	})
+
+	t.Run("throttling pauses an unlimited limiter", func(t *testing.T) {
+		l, _ := newTestLimiter(0)
+		l.Throttled(2 * time.Second)
+		assert.Equal(t, 2*time.Second, l.reserve())
+		assert.Zero(t, l.rate)
+	})
+
+	t.Run("wait returns when the context is canceled", func(t *testing.T) {
+		l, _ := newTestLimiter(1)
+		l.Throttled(time.Hour)
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		require.ErrorIs(t, l.Wait(ctx), context.Canceled)
+	})
+}
+
+func TestWithRateLimiter(t *testing.T) {
+	assert.Nil(t, NewHTTPClient("http://example.com", "", "").limiter, "clients are unlimited by default")
+
+	l := NewRateLimiter(DefaultAPIRateLimit)
+	a := NewHTTPClient("http://example.com", "", "", WithRateLimiter(l))
+	b := NewHTTPClient("http://example.com", "", "", WithRateLimiter(l))
+	assert.Same(t, a.limiter, b.limiter)
+	assert.Equal(t, DefaultAPIRateLimit, l.rate)
+}
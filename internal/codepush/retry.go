@@ -0,0 +1,112 @@
+package codepush
+
+import (
+	"context"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// maxRetryAfter is the longest Retry-After the client waits for. A server
+// asking for a longer pause gets its response returned instead.
+const maxRetryAfter = 2 * time.Minute
+
+// APIRetries returns DefaultAPIRetryConfig with the given number of retries
+// after the first attempt. Zero or less disables retries.
+func APIRetries(retries int) RetryConfig {
+	cfg := DefaultAPIRetryConfig
+	cfg.MaxAttempts = max(retries, 0) + 1
+	return cfg
+}
+
+// sendWithRetry sends the request built by newRequest, retrying transient
+// failures with jittered exponential backoff. A Retry-After header on a
+// retried response is waited for instead of the backoff delay. newRequest
+// is called for every attempt so the body can be replayed. Every attempt
+// waits for the client's rate limiter, and an HTTP 429 slows down all
+// requests that share it.
+//
+// POST requests are not idempotent, so they are only retried when the server
+// reports it did not process them (HTTP 429 or 503).
+func (c *HTTPClient) sendWithRetry(ctx context.Context, newRequest func() (*http.Request, error)) (*http.Response, error) {
+	delay := c.retry.InitialDelay
+	for attempt := 1; ; attempt++ {
+		req, err := newRequest()
+		if err != nil {
+			return nil, err
+		}
+		if c.limiter != nil {
+			if err := c.limiter.Wait(ctx); err != nil {
+				return nil, err
+			}
+		}
+
+		wait := jitter(delay)
+		resp, err := c.client.Do(req)
+		last := attempt >= c.retry.MaxAttempts || ctx.Err() != nil
+		if err != nil {
+			if last || req.Method == http.MethodPost {
+				return nil, err
+			}
+		} else {
+			if last || !isRetryableStatus(req.Method, resp.StatusCode) {
+				return resp, nil
+			}
+			if after, ok := parseRetryAfter(resp.Header.Get("Retry-After"), time.Now()); ok {
+				if after > maxRetryAfter {
+					return resp, nil
+				}
+				wait = after
+			}
+			_, _ = io.Copy(io.Discard, resp.Body)
+			_ = resp.Body.Close()
+			if resp.StatusCode == http.StatusTooManyRequests && c.limiter != nil {
+				c.limiter.Throttled(wait)
+			}
+		}
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		case <-timer.C:
+		}
+		delay = min(delay*2, c.retry.MaxDelay)
+	}
+}
+
+// isRetryableStatus reports whether a response status is worth retrying for
+// a request with the given method.
+func isRetryableStatus(method string, code int) bool {
+	if code == http.StatusTooManyRequests || code == http.StatusServiceUnavailable {
+		return true
+	}
+	return method != http.MethodPost && code >= 500
+}
+
+// parseRetryAfter parses a Retry-After header given in seconds or as an HTTP
+// date.
+func parseRetryAfter(value string, now time.Time) (time.Duration, bool) {
+	if value == "" {
+		return 0, false
+	}
+	if secs, err := strconv.Atoi(value); err == nil {
+		return max(time.Duration(secs)*time.Second, 0), true
+	}
+	if t, err := http.ParseTime(value); err == nil {
+		return max(t.Sub(now), 0), true
+	}
+	return 0, false
+}
+
+// jitter returns a random delay between half of d and d, so clients that
+// failed together do not retry in lockstep.
+func jitter(d time.Duration) time.Duration {
+	if d <= 1 {
+		return d
+	}
+	return d/2 + rand.N(d/2+1)
+}
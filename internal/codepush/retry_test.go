@@ -0,0 +1,131 @@
+package codepush
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHTTPClientRetries(t *testing.T) {
+	newServer := func(t *testing.T, statuses ...int) (*httptest.Server, *atomic.Int32) {
+		t.Helper()
+		var calls atomic.Int32
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			body, _ := io.ReadAll(r.Body)
+			if r.Method == http.MethodPost {
+				assert.JSONEq(t, `{"name":"Beta"}`, string(body))
+			}
+			n := int(calls.Add(1))
+			if status := statuses[min(n, len(statuses))-1]; status != http.StatusOK {
+				w.WriteHeader(status)
+				return
+			}
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(`{"items":[],"id":"dep-1","name":"Beta"}`))
+		}))
+		t.Cleanup(server.Close)
+		return server, &calls
+	}
+
+	t.Run("retries transient server errors", func(t *testing.T) {
+		server, calls := newServer(t, http.StatusBadGateway, http.StatusGatewayTimeout, http.StatusOK)
+
+		_, err := newTestClient(server.URL, "token", "test").ListDeployments(context.Background(), "app-1")
+		require.NoError(t, err)
+		assert.Equal(t, int32(3), calls.Load())
+	})
+
+	t.Run("gives up after max attempts", func(t *testing.T) {
+		server, calls := newServer(t, http.StatusBadGateway)
+
+		_, err := newTestClient(server.URL, "token", "test").ListDeployments(context.Background(), "app-1")
+		require.ErrorContains(t, err, "HTTP 502")
+		assert.Equal(t, int32(testRetry.MaxAttempts), calls.Load())
+	})
+
+	t.Run("does not retry client errors", func(t *testing.T) {
+		server, calls := newServer(t, http.StatusNotFound)
+
+		_, err := newTestClient(server.URL, "token", "test").ListDeployments(context.Background(), "app-1")
+		require.Error(t, err)
+		assert.Equal(t, int32(1), calls.Load())
+	})
+
+	t.Run("does not retry POST on server errors", func(t *testing.T) {
+		server, calls := newServer(t, http.StatusBadGateway)
+
+		_, err := newTestClient(server.URL, "token", "test").CreateDeployment(context.Background(), "app-1", CreateDeploymentRequest{Name: "Beta"})
+		require.Error(t, err)
+		assert.Equal(t, int32(1), calls.Load())
+	})
+
+	t.Run("retries POST when throttled and replays the body", func(t *testing.T) {
+		server, calls := newServer(t, http.StatusTooManyRequests, http.StatusOK)
+
+		dep, err := newTestClient(server.URL, "token", "test").CreateDeployment(context.Background(), "app-1", CreateDeploymentRequest{Name: "Beta"})
+		require.NoError(t, err)
+		assert.Equal(t, "Beta", dep.Name)
+		assert.Equal(t, int32(2), calls.Load())
+	})
+
+	t.Run("slows down the rate limiter when throttled", func(t *testing.T) {
+		server, calls := newServer(t, http.StatusTooManyRequests, http.StatusOK)
+		client := newTestClient(server.URL, "token", "test")
+		client.limiter, _ = newTestLimiter(10)
+
+		_, err := client.ListDeployments(context.Background(), "app-1")
+		require.NoError(t, err)
+		assert.Equal(t, int32(2), calls.Load())
+		assert.Equal(t, 5.0, client.limiter.rate)
+	})
+}
+
+func TestAPIRetries(t *testing.T) {
+	assert.Equal(t, 1, APIRetries(0).MaxAttempts)
+	assert.Equal(t, 1, APIRetries(-1).MaxAttempts)
+	assert.Equal(t, 6, APIRetries(5).MaxAttempts)
+	assert.Equal(t, DefaultAPIRetryConfig.InitialDelay, APIRetries(5).InitialDelay)
+
+	assert.Equal(t, DefaultAPIRetryConfig, NewHTTPClient("http://example.com", "", "").retry)
+	assert.Equal(t, 6, NewHTTPClient("http://example.com", "", "", WithRetry(APIRetries(5))).retry.MaxAttempts)
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2026, 1, 2, 15, 4, 5, 0, time.UTC)
+
+	tests := []struct {
+		name   string
+		value  string
+		want   time.Duration
+		wantOK bool
+	}{
+		{"seconds", "7", 7 * time.Second, true},
+		{"http date", now.Add(90 * time.Second).Format(http.TimeFormat), 90 * time.Second, true},
+		{"date in the past", now.Add(-time.Minute).Format(http.TimeFormat), 0, true},
+		{"empty", "", 0, false},
+		{"garbage", "soon", 0, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := parseRetryAfter(tt.value, now)
+			assert.Equal(t, tt.wantOK, ok)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestJitter(t *testing.T) {
+	for range 100 {
+		d := jitter(time.Second)
+		assert.GreaterOrEqual(t, d, 500*time.Millisecond)
+		assert.LessOrEqual(t, d, time.Second)
+	}
+}
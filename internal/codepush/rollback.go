@@ -5,7 +5,6 @@ import (
 	"errors"
 	"fmt"
 
-	"github.com/bitrise-io/bitrise-plugins-codepush-cli/internal/bitrise"
 	"github.com/bitrise-io/bitrise-plugins-codepush-cli/internal/output"
 )
 
@@ -13,7 +12,7 @@ import (
 // optionally resolve target label to update ID, call API, export summary.
 func Rollback(ctx context.Context, client Client, opts *RollbackOptions, out *output.Writer) (*RollbackResult, error) {
 	if err := validateRollbackOptions(opts); err != nil {
-		return nil, err
+		return nil, Invalid(err)
 	}
 
 	deploymentID, err := ResolveDeployment(ctx, client, opts.AppID, opts.DeploymentID, out)
@@ -47,11 +46,65 @@ func Rollback(ctx context.Context, client Client, opts *RollbackOptions, out *ou
 		AppVersion:   pkg.AppVersion,
 	}
 
-	if bitrise.IsBitriseEnvironment() {
-		exportSummary("codepush-rollback-summary.json", result, out)
+	return result, nil
+}
+
+// PreviewRollback resolves the release a rollback with opts would restore
+// without calling the rollback endpoint.
+func PreviewRollback(ctx context.Context, client Client, opts *RollbackOptions, out *output.Writer) (*RollbackPreview, error) {
+	if err := validateRollbackOptions(opts); err != nil {
+		return nil, Invalid(err)
 	}
 
-	return result, nil
+	deploymentID, err := ResolveDeployment(ctx, client, opts.AppID, opts.DeploymentID, out)
+	if err != nil {
+		return nil, err
+	}
+
+	updates, err := client.ListUpdates(ctx, opts.AppID, deploymentID)
+	if err != nil {
+		return nil, fmt.Errorf("listing updates: %w", err)
+	}
+	target, err := rollbackTarget(updates, opts.TargetLabel)
+	if err != nil {
+		return nil, err
+	}
+
+	// The label is informational: a deployment with custom labels has no
+	// predictable next one.
+	label, _ := nextLabel(updates)
+	return &RollbackPreview{
+		DryRun:       true,
+		AppID:        opts.AppID,
+		DeploymentID: deploymentID,
+		Label:        label,
+		Current:      updates[len(updates)-1],
+		Target:       target,
+	}, nil
+}
+
+// rollbackTarget returns the update a rollback restores: the one with the
+// target label, or the one before the latest.
+func rollbackTarget(updates []Update, targetLabel string) (Update, error) {
+	if len(updates) == 0 {
+		return Update{}, errors.New("the deployment has no releases to roll back")
+	}
+	latest := updates[len(updates)-1]
+	if targetLabel == "" {
+		if len(updates) < 2 {
+			return Update{}, fmt.Errorf("%s is the only release in the deployment: there is no previous release to roll back to", latest.Label)
+		}
+		return updates[len(updates)-2], nil
+	}
+	if targetLabel == latest.Label {
+		return Update{}, fmt.Errorf("%s is already the latest release: choose an earlier --target-release", targetLabel)
+	}
+	for _, u := range updates {
+		if u.Label == targetLabel {
+			return u, nil
+		}
+	}
+	return Update{}, fmt.Errorf("release label %q not found in deployment: check the label or omit --target-release to rollback to the previous release", targetLabel)
 }
 
 func validateRollbackOptions(opts *RollbackOptions) error {
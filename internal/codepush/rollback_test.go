@@ -3,8 +3,6 @@ package codepush
 import (
 	"context"
 	"errors"
-	"os"
-	"path/filepath"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -134,34 +132,75 @@ func TestRollback(t *testing.T) {
 		require.Error(t, err)
 		assert.ErrorContains(t, err, "rollback failed")
 	})
+}
 
-	t.Run("bitrise environment exports summary", func(t *testing.T) {
-		deployDir := t.TempDir()
-		t.Setenv("BITRISE_DEPLOY_DIR", deployDir)
-		t.Setenv("BITRISE_BUILD_NUMBER", "42")
-
-		client := &mockClient{
+func TestPreviewRollback(t *testing.T) {
+	updates := []Update{
+		{ID: "pkg-1", Label: "v1", AppVersion: "1.0.0"},
+		{ID: "pkg-2", Label: "v2", AppVersion: "1.0.0", Description: "Fix login", FileSizeBytes: 2048},
+		{ID: "pkg-3", Label: "v3", AppVersion: "1.0.0"},
+	}
+	newClient := func(updates []Update) *mockClient {
+		return &mockClient{
+			listUpdatesFunc: func(appID, deploymentID string) ([]Update, error) {
+				return updates, nil
+			},
 			rollbackFunc: func(appID, deploymentID string, req RollbackRequest) (*Update, error) {
-				return &Update{ID: "pkg-rb", Label: "v5", AppVersion: "1.0.0"}, nil
+				t.Fatal("dry run must not call the rollback endpoint")
+				return nil, nil
 			},
 		}
-
-		opts := &RollbackOptions{
+	}
+	opts := func(target string) *RollbackOptions {
+		return &RollbackOptions{
 			AppID:        "app-123",
 			DeploymentID: "00000000-0000-0000-0000-000000000001",
 			Token:        "test-token",
+			TargetLabel:  target,
 		}
+	}
 
-		_, err := Rollback(context.Background(), client, opts, testOut)
+	t.Run("previous release", func(t *testing.T) {
+		preview, err := PreviewRollback(context.Background(), newClient(updates), opts(""), testOut)
 		require.NoError(t, err)
 
-		summaryPath := filepath.Join(deployDir, "codepush-rollback-summary.json")
-		data, err := os.ReadFile(summaryPath)
+		assert.True(t, preview.DryRun)
+		assert.Equal(t, "v4", preview.Label)
+		assert.Equal(t, "v3", preview.Current.Label)
+		assert.Equal(t, updates[1], preview.Target)
+	})
+
+	t.Run("target release", func(t *testing.T) {
+		preview, err := PreviewRollback(context.Background(), newClient(updates), opts("v1"), testOut)
 		require.NoError(t, err)
+		assert.Equal(t, "pkg-1", preview.Target.ID)
+	})
 
-		content := string(data)
-		assert.Contains(t, content, `"label": "v5"`)
+	t.Run("custom labels are not predicted", func(t *testing.T) {
+		custom := []Update{{ID: "pkg-1", Label: "beta"}, {ID: "pkg-2", Label: "rc"}}
+		preview, err := PreviewRollback(context.Background(), newClient(custom), opts(""), testOut)
+		require.NoError(t, err)
+		assert.Empty(t, preview.Label)
+		assert.Equal(t, "beta", preview.Target.Label)
 	})
+
+	tests := []struct {
+		name    string
+		updates []Update
+		target  string
+		wantErr string
+	}{
+		{name: "no releases", wantErr: "no releases to roll back"},
+		{name: "single release", updates: updates[:1], wantErr: "no previous release"},
+		{name: "target is latest", updates: updates, target: "v3", wantErr: "already the latest release"},
+		{name: "target not found", updates: updates, target: "v99", wantErr: `release label "v99" not found`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := PreviewRollback(context.Background(), newClient(tt.updates), opts(tt.target), testOut)
+			assert.ErrorContains(t, err, tt.wantErr)
+		})
+	}
 }
 
 func TestValidateRollbackOptions(t *testing.T) {
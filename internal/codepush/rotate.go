@@ -0,0 +1,95 @@
+package codepush
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/bitrise-io/bitrise-plugins-codepush-cli/internal/output"
+)
+
+// retiredSuffix is appended to the name of a deployment while its key is
+// rotated, freeing the name for the replacement.
+const retiredSuffix = "-key-rotated"
+
+// rotateClient is the subset of Client needed by RotateDeploymentKey.
+type rotateClient interface {
+	deploymentGetter
+	updateLister
+	releaseCopier
+	CreateDeployment(ctx context.Context, appID string, req CreateDeploymentRequest) (*Deployment, error)
+	RenameDeployment(ctx context.Context, appID, deploymentID string, req RenameDeploymentRequest) (*Deployment, error)
+	DeleteDeployment(ctx context.Context, appID, deploymentID string) error
+}
+
+// RotateKeyResult is the output of RotateDeploymentKey.
+type RotateKeyResult struct {
+	// Deployment is the replacement deployment, carrying the new key.
+	Deployment           *Deployment     `json:"deployment"`
+	PreviousDeploymentID string          `json:"previous_deployment_id"`
+	PreviousKey          string          `json:"previous_key"`
+	Releases             []ClonedRelease `json:"releases"`
+}
+
+// RotateDeploymentKey replaces a deployment with one that has a new key. The
+// API has no key rotation endpoint, so the deployment is renamed, a new one
+// is created under its name, its releases are copied over in order, and the
+// old deployment is deleted, which invalidates its key. Apps built with the
+// old key stop receiving updates.
+//
+// On failure the error says which deployments exist: the old one is renamed
+// back if the replacement could not be created, and kept under its
+// temporary name if the releases could not be copied.
+func RotateDeploymentKey(ctx context.Context, client rotateClient, appID, deploymentID string, out *output.Writer) (*RotateKeyResult, error) {
+	old, err := client.GetDeployment(ctx, appID, deploymentID)
+	if err != nil {
+		return nil, fmt.Errorf("getting deployment: %w", err)
+	}
+	updates, err := client.ListUpdates(ctx, appID, deploymentID)
+	if err != nil {
+		return nil, fmt.Errorf("listing releases: %w", err)
+	}
+
+	retiredName := old.Name + retiredSuffix
+	step := out.StartStep("Renaming %q to %q", old.Name, retiredName)
+	if _, err := client.RenameDeployment(ctx, appID, deploymentID, RenameDeploymentRequest{Name: retiredName}); err != nil {
+		step.Cancel()
+		return nil, fmt.Errorf("renaming deployment: %w", err)
+	}
+	step.Done()
+
+	step = out.StartStep("Creating deployment %q with a new key", old.Name)
+	dep, err := client.CreateDeployment(ctx, appID, CreateDeploymentRequest{Name: old.Name})
+	if err != nil {
+		step.Cancel()
+		if _, renameErr := client.RenameDeployment(ctx, appID, deploymentID, RenameDeploymentRequest{Name: old.Name}); renameErr != nil {
+			return nil, fmt.Errorf("creating deployment: %w; renaming %q back also failed: %w", err, retiredName, renameErr)
+		}
+		return nil, fmt.Errorf("creating deployment: %w", err)
+	}
+	step.Done()
+
+	releases, err := copyReleases(ctx, client, &releaseCopy{
+		appID:      appID,
+		sourceID:   deploymentID,
+		sourceName: retiredName,
+		target:     dep,
+		updates:    updates,
+	}, out)
+	if err != nil {
+		return nil, fmt.Errorf("deployment %q was created with a new key, but %w; the previous deployment was kept as %q", dep.Name, err, retiredName)
+	}
+
+	step = out.StartStep("Deleting %q", retiredName)
+	if err := client.DeleteDeployment(ctx, appID, deploymentID); err != nil {
+		step.Cancel()
+		return nil, fmt.Errorf("deployment %q was created with a new key, but deleting %q failed, so the old key still works: %w", dep.Name, retiredName, err)
+	}
+	step.Done()
+
+	return &RotateKeyResult{
+		Deployment:           dep,
+		PreviousDeploymentID: deploymentID,
+		PreviousKey:          old.Key,
+		Releases:             releases,
+	}, nil
+}
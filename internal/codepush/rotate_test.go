@@ -0,0 +1,99 @@
+package codepush
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRotateDeploymentKey(t *testing.T) {
+	type call struct{ op, arg string }
+
+	newClient := func(calls *[]call) *mockClient {
+		return &mockClient{
+			getDeploymentFunc: func(appID, deploymentID string) (*Deployment, error) {
+				return &Deployment{ID: deploymentID, Name: "Production", Key: "old-key"}, nil
+			},
+			listUpdatesFunc: func(appID, deploymentID string) ([]Update, error) {
+				return []Update{
+					{ID: "u1", Label: "v1", AppVersion: "1.0.0", Rollout: 100},
+					{ID: "u2", Label: "v2", AppVersion: "1.0.0", Rollout: 25, Mandatory: true},
+				}, nil
+			},
+			renameDeploymentFunc: func(appID, deploymentID string, req RenameDeploymentRequest) (*Deployment, error) {
+				*calls = append(*calls, call{"rename", req.Name})
+				return &Deployment{ID: deploymentID, Name: req.Name}, nil
+			},
+			createDeploymentFunc: func(appID string, req CreateDeploymentRequest) (*Deployment, error) {
+				*calls = append(*calls, call{"create", req.Name})
+				return &Deployment{ID: "dep-new", Name: req.Name, Key: "new-key"}, nil
+			},
+			promoteFunc: func(appID, deploymentID string, req PromoteRequest) (*Update, error) {
+				*calls = append(*calls, call{"promote", req.UpdateID + "@" + req.Rollout})
+				return &Update{ID: "copy-" + req.UpdateID, Label: "v1"}, nil
+			},
+			deleteDeploymentFunc: func(appID, deploymentID string) error {
+				*calls = append(*calls, call{"delete", deploymentID})
+				return nil
+			},
+		}
+	}
+
+	t.Run("replaces the deployment and copies its history", func(t *testing.T) {
+		var calls []call
+		result, err := RotateDeploymentKey(context.Background(), newClient(&calls), "app-1", "dep-old", testOut)
+		require.NoError(t, err)
+
+		assert.Equal(t, []call{
+			{"rename", "Production-key-rotated"},
+			{"create", "Production"},
+			{"promote", "u1@100"},
+			{"promote", "u2@25"},
+			{"delete", "dep-old"},
+		}, calls)
+		assert.Equal(t, "new-key", result.Deployment.Key)
+		assert.Equal(t, "old-key", result.PreviousKey)
+		assert.Equal(t, "dep-old", result.PreviousDeploymentID)
+		assert.Len(t, result.Releases, 2)
+	})
+
+	t.Run("renames back when the replacement cannot be created", func(t *testing.T) {
+		var calls []call
+		client := newClient(&calls)
+		client.createDeploymentFunc = func(appID string, req CreateDeploymentRequest) (*Deployment, error) {
+			return nil, errors.New("boom")
+		}
+
+		_, err := RotateDeploymentKey(context.Background(), client, "app-1", "dep-old", testOut)
+		require.ErrorContains(t, err, "creating deployment: boom")
+		assert.Equal(t, []call{{"rename", "Production-key-rotated"}, {"rename", "Production"}}, calls)
+	})
+
+	t.Run("keeps the old deployment when copying fails", func(t *testing.T) {
+		var calls []call
+		client := newClient(&calls)
+		client.promoteFunc = func(appID, deploymentID string, req PromoteRequest) (*Update, error) {
+			return nil, errors.New("boom")
+		}
+
+		_, err := RotateDeploymentKey(context.Background(), client, "app-1", "dep-old", testOut)
+		require.EqualError(t, err, `deployment "Production" was created with a new key, but copying v1 failed: boom; the previous deployment was kept as "Production-key-rotated"`)
+		assert.NotContains(t, calls, call{"delete", "dep-old"})
+	})
+}
+
+func TestCopyRequest(t *testing.T) {
+	req := copyRequest("dep-new", Update{ID: "u1", AppVersion: "1.2.0", Description: "fix", Mandatory: true, Disabled: false, Rollout: 33.4})
+	assert.Equal(t, PromoteRequest{
+		TargetDeploymentID: "dep-new",
+		UpdateID:           "u1",
+		AppVersion:         "1.2.0",
+		Description:        "fix",
+		Mandatory:          "true",
+		Disabled:           "false",
+		Rollout:            "33",
+	}, req)
+}
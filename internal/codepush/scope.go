@@ -0,0 +1,106 @@
+package codepush
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+)
+
+// Token scopes checked before long-running commands.
+const (
+	// ScopeReleaseRead allows reading an app's deployments and releases.
+	ScopeReleaseRead = "release_management:read"
+	// ScopeReleaseWrite allows creating and changing releases.
+	ScopeReleaseWrite = "release_management:write"
+)
+
+// headerTokenScopes is the response header in which the API may list the
+// scopes of the request's token, comma-separated. It is optional: when it is
+// absent, TokenAccess.Scopes is nil and only access to the app is checked.
+const headerTokenScopes = "X-Token-Scopes"
+
+// ScopeError reports a token that lacks a scope needed for an app, found
+// by a pre-flight check before anything was changed.
+type ScopeError struct {
+	Scope string
+	AppID string
+}
+
+func (e *ScopeError) Error() string {
+	return fmt.Sprintf("token lacks scope %s for app %s", e.Scope, e.AppID)
+}
+
+// ExitCode returns ExitCodeAuth.
+func (e *ScopeError) ExitCode() int {
+	return ExitCodeAuth
+}
+
+// TokenAccess describes what the token may do in an app.
+type TokenAccess struct {
+	App App `json:"app"`
+	// Scopes are the token's scopes as reported by the API, or nil when
+	// the API does not report them.
+	Scopes []string `json:"scopes,omitempty"`
+}
+
+// HasScope reports whether the token has scope. known is false when the API
+// did not report the token's scopes.
+func (a *TokenAccess) HasScope(scope string) (has, known bool) {
+	if a.Scopes == nil {
+		return false, false
+	}
+	return slices.Contains(a.Scopes, scope), true
+}
+
+// accessChecker is the subset of HTTPClient used by CheckScope, so tests
+// can stub the request.
+type accessChecker interface {
+	GetAccess(ctx context.Context, appID string) (*TokenAccess, error)
+}
+
+// GetAccess fetches the app with the token and returns it with the scopes
+// the API reports for the token. It is a single cheap request.
+func (c *HTTPClient) GetAccess(ctx context.Context, appID string) (*TokenAccess, error) {
+	resp, err := c.doRequest(ctx, http.MethodGet, "/connected-apps/"+appID)
+	if err != nil {
+		return nil, err
+	}
+
+	var access TokenAccess
+	if err := decodeResponse(resp, &access.App); err != nil {
+		return nil, fmt.Errorf("getting app: %w", err)
+	}
+	if header := resp.Header.Get(headerTokenScopes); header != "" {
+		access.Scopes = []string{}
+		for s := range strings.SplitSeq(header, ",") {
+			if s = strings.TrimSpace(s); s != "" {
+				access.Scopes = append(access.Scopes, s)
+			}
+		}
+	}
+	return &access, nil
+}
+
+// CheckScope verifies before a long-running command that the token can use
+// scope in the app, so a missing permission fails in a second instead of as
+// a 403 after bundling and uploading. A 401 is returned as is; a 403 or a
+// reported scope list without scope is a *ScopeError. When the API does not
+// report scopes, only access to the app is checked.
+func CheckScope(ctx context.Context, client accessChecker, appID, scope string) (*TokenAccess, error) {
+	access, err := client.GetAccess(ctx, appID)
+	switch {
+	case statusCode(err) == http.StatusForbidden:
+		return nil, &ScopeError{Scope: ScopeReleaseRead, AppID: appID}
+	case IsNotFound(err):
+		return nil, fmt.Errorf("app %s not found, or not visible to this token: %w", appID, err)
+	case err != nil:
+		return nil, err
+	}
+
+	if has, known := access.HasScope(scope); known && !has {
+		return access, &ScopeError{Scope: scope, AppID: appID}
+	}
+	return access, nil
+}
@@ -0,0 +1,99 @@
+package codepush
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHTTPClientGetAccess(t *testing.T) {
+	tests := []struct {
+		name       string
+		header     string
+		wantScopes []string
+	}{
+		{name: "scopes reported", header: "release_management:read, release_management:write", wantScopes: []string{ScopeReleaseRead, ScopeReleaseWrite}},
+		{name: "no scopes", header: " , ", wantScopes: []string{}},
+		{name: "scopes not reported"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				assert.Equal(t, "/connected-apps/app-1", r.URL.Path)
+				assert.Equal(t, "secret", r.Header.Get("Authorization"))
+				if tt.header != "" {
+					w.Header().Set(headerTokenScopes, tt.header)
+				}
+				_, _ = w.Write([]byte(`{"id":"app-1","store_app_name":"Shop"}`))
+			}))
+			defer server.Close()
+
+			access, err := newTestClient(server.URL, "secret", "test").GetAccess(context.Background(), "app-1")
+			require.NoError(t, err)
+			assert.Equal(t, "Shop", access.App.Name)
+			assert.Equal(t, tt.wantScopes, access.Scopes)
+		})
+	}
+}
+
+type stubAccessChecker struct {
+	access *TokenAccess
+	err    error
+}
+
+func (s stubAccessChecker) GetAccess(context.Context, string) (*TokenAccess, error) {
+	return s.access, s.err
+}
+
+func TestCheckScope(t *testing.T) {
+	tests := []struct {
+		name     string
+		checker  stubAccessChecker
+		wantErr  string
+		wantKind string
+	}{
+		{name: "has scope", checker: stubAccessChecker{access: &TokenAccess{Scopes: []string{ScopeReleaseWrite}}}},
+		{name: "scopes not reported", checker: stubAccessChecker{access: &TokenAccess{}}},
+		{
+			name:     "missing scope",
+			checker:  stubAccessChecker{access: &TokenAccess{Scopes: []string{ScopeReleaseRead}}},
+			wantErr:  "token lacks scope release_management:write for app app-1",
+			wantKind: ErrorKindAuth,
+		},
+		{
+			name:     "forbidden",
+			checker:  stubAccessChecker{err: &APIError{StatusCode: http.StatusForbidden}},
+			wantErr:  "token lacks scope release_management:read for app app-1",
+			wantKind: ErrorKindAuth,
+		},
+		{
+			name:     "unauthorized",
+			checker:  stubAccessChecker{err: &APIError{StatusCode: http.StatusUnauthorized, Message: "invalid API token"}},
+			wantErr:  "invalid API token",
+			wantKind: ErrorKindAuth,
+		},
+		{
+			name:     "not found",
+			checker:  stubAccessChecker{err: &APIError{StatusCode: http.StatusNotFound, Message: "app not found"}},
+			wantErr:  "app app-1 not found, or not visible to this token",
+			wantKind: ErrorKindAPI,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := CheckScope(context.Background(), tt.checker, "app-1", ScopeReleaseWrite)
+			if tt.wantErr == "" {
+				assert.NoError(t, err)
+				return
+			}
+			require.ErrorContains(t, err, tt.wantErr)
+			assert.Equal(t, tt.wantKind, NewErrorReport(err).Kind)
+		})
+	}
+}
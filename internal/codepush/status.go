@@ -0,0 +1,74 @@
+package codepush
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// StatusChange is a processing status of an update, as first observed by
+// FollowStatus.
+type StatusChange struct {
+	Time         time.Time `json:"time"`
+	Status       string    `json:"status"`
+	StatusReason string    `json:"status_reason,omitempty"`
+}
+
+// DescribeStatus returns the processing stage a status stands for.
+func DescribeStatus(status string) string {
+	switch status {
+	case StatusCreated:
+		return "waiting for upload"
+	case StatusUploaded:
+		return "processing"
+	case StatusProcessedValid:
+		return "done"
+	case StatusProcessedError:
+		return "failed"
+	default:
+		return status
+	}
+}
+
+// FormatStatusChange renders a status change as
+// "[timestamp] status (stage): reason".
+func FormatStatusChange(c StatusChange) string {
+	line := fmt.Sprintf("[%s] %s (%s)", c.Time.UTC().Format(time.RFC3339), c.Status, DescribeStatus(c.Status))
+	if c.StatusReason != "" {
+		line += ": " + c.StatusReason
+	}
+	return line
+}
+
+// FollowStatus polls the processing status of an update until it reaches a
+// terminal status, which is returned. The first status and every change
+// after it are passed to emit. A *ProcessingError with Pending set is
+// returned when the update is still being processed after cfg's attempts.
+func FollowStatus(ctx context.Context, client statusChecker, ref UpdateRef, cfg PollConfig, emit func(StatusChange)) (*UpdateStatus, error) {
+	var last *UpdateStatus
+	for attempt := range cfg.MaxAttempts {
+		status, err := client.GetUpdateStatus(ctx, ref.AppID, ref.DeploymentID, ref.UpdateID)
+		if err != nil {
+			return nil, fmt.Errorf("checking update status: %w", err)
+		}
+		if last == nil || status.Status != last.Status || status.StatusReason != last.StatusReason {
+			emit(StatusChange{Time: time.Now(), Status: status.Status, StatusReason: status.StatusReason})
+		}
+		last = status
+
+		if status.Status == StatusProcessedValid || status.Status == StatusProcessedError {
+			return status, nil
+		}
+
+		if attempt < cfg.MaxAttempts-1 {
+			select {
+			case <-ctx.Done():
+				return nil, fmt.Errorf("checking update status: %w", ctx.Err())
+			case <-time.After(cfg.Interval):
+			}
+		}
+	}
+
+	totalWait := time.Duration(cfg.MaxAttempts) * cfg.Interval
+	return nil, &ProcessingError{UpdateID: ref.UpdateID, Pending: true, Waited: totalWait}
+}
@@ -0,0 +1,106 @@
+package codepush
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFormatStatusChange(t *testing.T) {
+	at := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name   string
+		change StatusChange
+		want   string
+	}{
+		{
+			name:   "known status",
+			change: StatusChange{Time: at, Status: StatusUploaded},
+			want:   "[2026-01-01T12:00:00Z] uploaded (processing)",
+		},
+		{
+			name:   "with reason",
+			change: StatusChange{Time: at, Status: StatusProcessedError, StatusReason: "invalid bundle format"},
+			want:   "[2026-01-01T12:00:00Z] processed_invalid (failed): invalid bundle format",
+		},
+		{
+			name:   "unknown status",
+			change: StatusChange{Time: at, Status: "scanning"},
+			want:   "[2026-01-01T12:00:00Z] scanning (scanning)",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.want, FormatStatusChange(tc.change))
+		})
+	}
+}
+
+func TestFollowStatus(t *testing.T) {
+	ref := UpdateRef{AppID: "app-1", DeploymentID: "dep-1", UpdateID: "pkg-1"}
+
+	t.Run("emits each change until terminal status", func(t *testing.T) {
+		seq := []string{StatusCreated, StatusUploaded, StatusUploaded, StatusUploaded, StatusProcessedValid}
+		polls := 0
+		client := &mockClient{
+			getUpdateStatusFunc: func(appID, deploymentID, updateID string) (*UpdateStatus, error) {
+				s := seq[polls]
+				polls++
+				return &UpdateStatus{Status: s}, nil
+			},
+		}
+
+		var got []string
+		status, err := FollowStatus(context.Background(), client, ref, PollConfig{MaxAttempts: 10, Interval: time.Millisecond}, func(c StatusChange) {
+			assert.False(t, c.Time.IsZero())
+			got = append(got, c.Status)
+		})
+		require.NoError(t, err)
+
+		assert.Equal(t, StatusProcessedValid, status.Status)
+		assert.Equal(t, []string{StatusCreated, StatusUploaded, StatusProcessedValid}, got)
+		assert.Equal(t, len(seq), polls)
+	})
+
+	t.Run("returns a rejected update", func(t *testing.T) {
+		client := &mockClient{
+			getUpdateStatusFunc: func(appID, deploymentID, updateID string) (*UpdateStatus, error) {
+				return &UpdateStatus{Status: StatusProcessedError, StatusReason: "invalid bundle format"}, nil
+			},
+		}
+
+		status, err := FollowStatus(context.Background(), client, ref, fastPollConfig, func(StatusChange) {})
+		require.NoError(t, err)
+		assert.Equal(t, "invalid bundle format", status.StatusReason)
+	})
+
+	t.Run("times out", func(t *testing.T) {
+		client := &mockClient{
+			getUpdateStatusFunc: func(appID, deploymentID, updateID string) (*UpdateStatus, error) {
+				return &UpdateStatus{Status: StatusUploaded}, nil
+			},
+		}
+
+		var changes int
+		_, err := FollowStatus(context.Background(), client, ref, fastPollConfig, func(StatusChange) { changes++ })
+		assert.ErrorIs(t, err, ErrProcessingPending)
+		assert.Equal(t, 1, changes)
+	})
+
+	t.Run("stops when canceled", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		client := &mockClient{
+			getUpdateStatusFunc: func(appID, deploymentID, updateID string) (*UpdateStatus, error) {
+				return &UpdateStatus{Status: StatusUploaded}, nil
+			},
+		}
+
+		_, err := FollowStatus(ctx, client, ref, PollConfig{MaxAttempts: 3, Interval: time.Hour}, func(StatusChange) {})
+		assert.ErrorIs(t, err, context.Canceled)
+	})
+}
@@ -0,0 +1,140 @@
+package codepush
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/bitrise-io/bitrise-plugins-codepush-cli/internal/output"
+)
+
+// tagsPrefix starts the line of a release description that holds its tags.
+// The API has no tags, so they are kept in the description, where they
+// travel with the release when it is promoted.
+const tagsPrefix = "Tags: "
+
+// Tags returns the tags of a release, in the order they were added.
+func (u Update) Tags() []string {
+	_, tags := SplitTags(u.Description)
+	return tags
+}
+
+// DescriptionText returns the description of a release without its tags.
+func (u Update) DescriptionText() string {
+	text, _ := SplitTags(u.Description)
+	return text
+}
+
+// HasTag reports whether the release has tag, ignoring case.
+func (u Update) HasTag(tag string) bool {
+	return slices.ContainsFunc(u.Tags(), func(t string) bool { return strings.EqualFold(t, tag) })
+}
+
+// SplitTags splits a release description into its text and tags. The tags
+// are on the last line, as "Tags: a, b".
+func SplitTags(description string) (string, []string) {
+	text, last := "", description
+	if i := strings.LastIndex(description, "\n"); i >= 0 {
+		text, last = description[:i], description[i+1:]
+	}
+	if !strings.HasPrefix(last, tagsPrefix) {
+		return description, nil
+	}
+
+	var tags []string
+	for _, t := range strings.Split(strings.TrimPrefix(last, tagsPrefix), ",") {
+		if t = strings.TrimSpace(t); t != "" {
+			tags = append(tags, t)
+		}
+	}
+	return strings.TrimRight(text, "\n"), tags
+}
+
+// JoinTags returns a release description with text and tags. Without tags,
+// it returns text.
+func JoinTags(text string, tags []string) string {
+	if len(tags) == 0 {
+		return text
+	}
+	line := tagsPrefix + strings.Join(tags, ", ")
+	if text == "" {
+		return line
+	}
+	return text + "\n\n" + line
+}
+
+// ValidateTag checks that a tag can be stored: letters, digits, and -_.:/#
+// only, so it cannot break the tags line.
+func ValidateTag(tag string) error {
+	if tag == "" {
+		return fmt.Errorf("tag must not be empty")
+	}
+	for _, r := range tag {
+		if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || strings.ContainsRune("-_.:/#", r)) {
+			return fmt.Errorf("invalid tag %q: use letters, digits, and - _ . : / # only", tag)
+		}
+	}
+	return nil
+}
+
+// EditTags returns tags with add appended and remove taken out, ignoring
+// case. Tags already present are not added twice.
+func EditTags(tags, add, remove []string) []string {
+	result := slices.DeleteFunc(slices.Clone(tags), func(t string) bool {
+		return slices.ContainsFunc(remove, func(r string) bool { return strings.EqualFold(t, r) })
+	})
+	for _, t := range add {
+		if !slices.ContainsFunc(result, func(have string) bool { return strings.EqualFold(have, t) }) {
+			result = append(result, t)
+		}
+	}
+	return result
+}
+
+// tagClient is the subset of Client needed by TagRelease.
+type tagClient interface {
+	GetUpdate(ctx context.Context, appID, deploymentID, updateID string) (*Update, error)
+	PatchUpdate(ctx context.Context, appID, deploymentID, updateID string, req PatchRequest) (*Update, error)
+}
+
+// TagOptions holds the parameters of TagRelease.
+type TagOptions struct {
+	AppID        string
+	DeploymentID string
+	UpdateID     string
+	// Add and Remove are the tags to add to and remove from the release.
+	Add    []string
+	Remove []string
+}
+
+// TagRelease adds and removes tags of a release and returns the release.
+// The release is only patched when its tags change.
+func TagRelease(ctx context.Context, client tagClient, opts *TagOptions, out *output.Writer) (*Update, error) {
+	for _, t := range slices.Concat(opts.Add, opts.Remove) {
+		if err := ValidateTag(t); err != nil {
+			return nil, Invalid(err)
+		}
+	}
+
+	u, err := client.GetUpdate(ctx, opts.AppID, opts.DeploymentID, opts.UpdateID)
+	if err != nil {
+		return nil, fmt.Errorf("getting update: %w", err)
+	}
+
+	text, tags := SplitTags(u.Description)
+	edited := EditTags(tags, opts.Add, opts.Remove)
+	if slices.Equal(tags, edited) {
+		return u, nil
+	}
+
+	description := JoinTags(text, edited)
+	step := out.StartStep("Tagging release %s", u.Label)
+	patched, err := client.PatchUpdate(ctx, opts.AppID, opts.DeploymentID, opts.UpdateID, PatchRequest{Description: &description})
+	if err != nil {
+		step.Cancel()
+		return nil, fmt.Errorf("patch failed: %w", err)
+	}
+	step.Done()
+	return patched, nil
+}
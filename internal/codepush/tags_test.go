@@ -0,0 +1,116 @@
+package codepush
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSplitTags(t *testing.T) {
+	tests := []struct {
+		name        string
+		description string
+		text        string
+		tags        []string
+	}{
+		{name: "no tags", description: "Fix crash", text: "Fix crash"},
+		{name: "empty", description: ""},
+		{name: "tags only", description: "Tags: hotfix", tags: []string{"hotfix"}},
+		{name: "text and tags", description: "Fix crash\non launch\n\nTags: hotfix, jira-1234", text: "Fix crash\non launch", tags: []string{"hotfix", "jira-1234"}},
+		{name: "tags line not last", description: "Tags: hotfix\nFix crash", text: "Tags: hotfix\nFix crash"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			text, tags := SplitTags(tc.description)
+			assert.Equal(t, tc.text, text)
+			assert.Equal(t, tc.tags, tags)
+		})
+	}
+}
+
+func TestJoinTags(t *testing.T) {
+	assert.Equal(t, "Fix crash", JoinTags("Fix crash", nil))
+	assert.Equal(t, "Tags: hotfix", JoinTags("", []string{"hotfix"}))
+	assert.Equal(t, "Fix crash\n\nTags: hotfix, jira-1234", JoinTags("Fix crash", []string{"hotfix", "jira-1234"}))
+
+	text, tags := SplitTags(JoinTags("Fix crash", []string{"hotfix"}))
+	assert.Equal(t, "Fix crash", text)
+	assert.Equal(t, []string{"hotfix"}, tags)
+}
+
+func TestValidateTag(t *testing.T) {
+	for _, tag := range []string{"hotfix", "JIRA-1234", "team/mobile", "gh#42", "v1.2"} {
+		assert.NoError(t, ValidateTag(tag), tag)
+	}
+	for _, tag := range []string{"", "two words", "a,b", "new\nline"} {
+		assert.Error(t, ValidateTag(tag), tag)
+	}
+}
+
+func TestEditTags(t *testing.T) {
+	tests := []struct {
+		name   string
+		tags   []string
+		add    []string
+		remove []string
+		want   []string
+	}{
+		{name: "add", tags: []string{"hotfix"}, add: []string{"jira-1234"}, want: []string{"hotfix", "jira-1234"}},
+		{name: "add existing ignores case", tags: []string{"hotfix"}, add: []string{"HOTFIX"}, want: []string{"hotfix"}},
+		{name: "remove ignores case", tags: []string{"hotfix", "jira-1234"}, remove: []string{"JIRA-1234"}, want: []string{"hotfix"}},
+		{name: "remove all", tags: []string{"hotfix"}, remove: []string{"hotfix"}, want: []string{}},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.want, EditTags(tc.tags, tc.add, tc.remove))
+		})
+	}
+}
+
+func TestTagRelease(t *testing.T) {
+	t.Run("patches the description", func(t *testing.T) {
+		var patched *PatchRequest
+		client := &mockClient{
+			getUpdateFunc: func(appID, deploymentID, updateID string) (*Update, error) {
+				return &Update{ID: updateID, Label: "v12", Description: "Fix crash\n\nTags: hotfix"}, nil
+			},
+			patchUpdateFunc: func(appID, deploymentID, updateID string, req PatchRequest) (*Update, error) {
+				patched = &req
+				return &Update{ID: updateID, Label: "v12", Description: *req.Description}, nil
+			},
+		}
+
+		u, err := TagRelease(context.Background(), client, &TagOptions{AppID: "app-1", DeploymentID: "dep-1", UpdateID: "pkg-1", Add: []string{"jira-1234"}, Remove: []string{"hotfix"}}, testOut)
+		require.NoError(t, err)
+		require.NotNil(t, patched)
+		assert.Equal(t, "Fix crash\n\nTags: jira-1234", *patched.Description)
+		assert.Equal(t, []string{"jira-1234"}, u.Tags())
+		assert.Equal(t, "Fix crash", u.DescriptionText())
+	})
+
+	t.Run("does not patch unchanged tags", func(t *testing.T) {
+		client := &mockClient{
+			getUpdateFunc: func(appID, deploymentID, updateID string) (*Update, error) {
+				return &Update{ID: updateID, Label: "v12", Description: "Tags: hotfix"}, nil
+			},
+			patchUpdateFunc: func(appID, deploymentID, updateID string, req PatchRequest) (*Update, error) {
+				t.Fatal("unexpected patch")
+				return nil, nil
+			},
+		}
+
+		u, err := TagRelease(context.Background(), client, &TagOptions{AppID: "app-1", DeploymentID: "dep-1", UpdateID: "pkg-1", Add: []string{"HotFix"}}, testOut)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"hotfix"}, u.Tags())
+	})
+
+	t.Run("rejects invalid tags", func(t *testing.T) {
+		_, err := TagRelease(context.Background(), &mockClient{}, &TagOptions{AppID: "app-1", DeploymentID: "dep-1", UpdateID: "pkg-1", Add: []string{"two words"}}, testOut)
+		var validationErr *ValidationError
+		assert.ErrorAs(t, err, &validationErr)
+	})
+}
@@ -0,0 +1,122 @@
+package codepush
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// osVersionTerm matches one comparison of an OS version range, such as
+// ">=14.0", "15.x", or "~16.2".
+var osVersionTerm = regexp.MustCompile(`^(>=|<=|>|<|=|~|\^)?\d+(\.(\d+|[xX*])){0,2}$`)
+
+// countryCode matches an ISO 3166-1 alpha-2 country code.
+var countryCode = regexp.MustCompile(`^[A-Z]{2}$`)
+
+// Targeting restricts which devices are offered a release. Each set
+// condition must match; an empty condition does not restrict. Combined with
+// a rollout percentage, a release reaches that share of matching devices.
+type Targeting struct {
+	// OSVersion is a range of OS versions, such as ">=14.0 <17".
+	OSVersion string `json:"target_os_version,omitempty"`
+	// DeviceModels are model identifiers as reported by the device, such as
+	// "iPhone14,2" or "Pixel 7".
+	DeviceModels []string `json:"target_device_models,omitempty"`
+	// Countries are ISO 3166-1 alpha-2 codes, such as "DE".
+	Countries []string `json:"target_countries,omitempty"`
+}
+
+// NewTargeting validates and normalizes targeting conditions: country codes
+// are upper-cased and duplicate entries dropped.
+func NewTargeting(osVersion string, deviceModels, countries []string) (Targeting, error) {
+	osVersion, err := normalizeOSVersion(osVersion)
+	if err != nil {
+		return Targeting{}, err
+	}
+	models, err := normalizeDeviceModels(deviceModels)
+	if err != nil {
+		return Targeting{}, err
+	}
+	codes, err := normalizeCountries(countries)
+	if err != nil {
+		return Targeting{}, err
+	}
+	return Targeting{OSVersion: osVersion, DeviceModels: models, Countries: codes}, nil
+}
+
+// Targeted reports whether any condition is set.
+func (t Targeting) Targeted() bool {
+	return t.OSVersion != "" || len(t.DeviceModels) > 0 || len(t.Countries) > 0
+}
+
+// TargetingSummary summarizes the conditions for display, e.g.
+// "OS >=14.0; countries DE, AT".
+func (t Targeting) TargetingSummary() string {
+	var parts []string
+	if t.OSVersion != "" {
+		parts = append(parts, "OS "+t.OSVersion)
+	}
+	if len(t.DeviceModels) > 0 {
+		parts = append(parts, "models "+strings.Join(t.DeviceModels, ", "))
+	}
+	if len(t.Countries) > 0 {
+		parts = append(parts, "countries "+strings.Join(t.Countries, ", "))
+	}
+	return strings.Join(parts, "; ")
+}
+
+// setParams adds the set conditions to upload URL query parameters.
+// Device models may contain commas, so list values are repeated rather than
+// joined.
+func (t Targeting) setParams(params url.Values) {
+	if t.OSVersion != "" {
+		params.Set("target_os_version", t.OSVersion)
+	}
+	for _, m := range t.DeviceModels {
+		params.Add("target_device_models", m)
+	}
+	for _, c := range t.Countries {
+		params.Add("target_countries", c)
+	}
+}
+
+func normalizeOSVersion(value string) (string, error) {
+	terms := strings.Fields(value)
+	for _, term := range terms {
+		if !osVersionTerm.MatchString(term) {
+			return "", fmt.Errorf("invalid OS version range %q: use comparisons such as \">=14.0 <17\" or \"15.x\"", value)
+		}
+	}
+	return strings.Join(terms, " "), nil
+}
+
+func normalizeDeviceModels(values []string) ([]string, error) {
+	var models []string
+	for _, v := range values {
+		v = strings.TrimSpace(v)
+		if v == "" {
+			return nil, errors.New("device model must not be empty")
+		}
+		if !slices.Contains(models, v) {
+			models = append(models, v)
+		}
+	}
+	return models, nil
+}
+
+func normalizeCountries(values []string) ([]string, error) {
+	var codes []string
+	for _, v := range values {
+		code := strings.ToUpper(strings.TrimSpace(v))
+		if !countryCode.MatchString(code) {
+			return nil, fmt.Errorf("invalid country %q: use ISO 3166-1 alpha-2 codes such as DE or US", v)
+		}
+		if !slices.Contains(codes, code) {
+			codes = append(codes, code)
+		}
+	}
+	return codes, nil
+}
@@ -0,0 +1,94 @@
+package codepush
+
+import (
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewTargeting(t *testing.T) {
+	tests := []struct {
+		name      string
+		osVersion string
+		models    []string
+		countries []string
+		want      Targeting
+		wantErr   string
+	}{
+		{
+			name: "no conditions",
+			want: Targeting{},
+		},
+		{
+			name:      "range is normalized",
+			osVersion: "  >=14.0   <17 ",
+			want:      Targeting{OSVersion: ">=14.0 <17"},
+		},
+		{
+			name:      "wildcard version",
+			osVersion: "15.x",
+			want:      Targeting{OSVersion: "15.x"},
+		},
+		{
+			name:      "invalid version",
+			osVersion: ">=fourteen",
+			wantErr:   "invalid OS version range",
+		},
+		{
+			name:   "device models keep commas and drop duplicates",
+			models: []string{"iPhone14,2", " Pixel 7 ", "iPhone14,2"},
+			want:   Targeting{DeviceModels: []string{"iPhone14,2", "Pixel 7"}},
+		},
+		{
+			name:    "empty device model",
+			models:  []string{" "},
+			wantErr: "device model must not be empty",
+		},
+		{
+			name:      "countries are upper-cased",
+			countries: []string{"de", "AT", "De"},
+			want:      Targeting{Countries: []string{"DE", "AT"}},
+		},
+		{
+			name:      "invalid country",
+			countries: []string{"DEU"},
+			wantErr:   `invalid country "DEU"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := NewTargeting(tt.osVersion, tt.models, tt.countries)
+			if tt.wantErr != "" {
+				assert.ErrorContains(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestTargetingSummary(t *testing.T) {
+	assert.False(t, Targeting{}.Targeted())
+	assert.Empty(t, Targeting{}.TargetingSummary())
+
+	target := Targeting{OSVersion: ">=14.0", DeviceModels: []string{"iPhone14,2"}, Countries: []string{"DE", "AT"}}
+	assert.True(t, target.Targeted())
+	assert.Equal(t, "OS >=14.0; models iPhone14,2; countries DE, AT", target.TargetingSummary())
+}
+
+func TestTargetingSetParams(t *testing.T) {
+	params := url.Values{}
+	Targeting{OSVersion: ">=14.0", DeviceModels: []string{"iPhone14,2", "iPhone15,3"}, Countries: []string{"DE"}}.setParams(params)
+
+	assert.Equal(t, ">=14.0", params.Get("target_os_version"))
+	assert.Equal(t, []string{"iPhone14,2", "iPhone15,3"}, params["target_device_models"])
+	assert.Equal(t, []string{"DE"}, params["target_countries"])
+
+	empty := url.Values{}
+	Targeting{}.setParams(empty)
+	assert.Empty(t, empty)
+}
@@ -9,6 +9,8 @@ import (
 )
 
 type mockClient struct {
+	listAppsFunc         func() ([]App, error)
+	getAppFunc           func(appID string) (*App, error)
 	listDeploymentsFunc  func(appID string) ([]Deployment, error)
 	createDeploymentFunc func(appID string, req CreateDeploymentRequest) (*Deployment, error)
 	getDeploymentFunc    func(appID, deploymentID string) (*Deployment, error)
@@ -16,15 +18,35 @@ type mockClient struct {
 	deleteDeploymentFunc func(appID, deploymentID string) error
 	getUploadURLFunc     func(appID, deploymentID, updateID string, req UploadURLRequest) (*UploadURLResponse, error)
 	uploadFileFunc       func(req UploadFileRequest) error
+	getDownloadURLFunc   func(appID, deploymentID, updateID string) (*DownloadURLResponse, error)
+	downloadFileFunc     func(fileURL string, w io.Writer) error
 	getUpdateStatusFunc  func(appID, deploymentID, updateID string) (*UpdateStatus, error)
+	listUpdateLogsFunc   func(appID, deploymentID, updateID string) ([]UpdateLogEntry, error)
+	listEventsFunc       func(appID, deploymentID string) ([]DeploymentEvent, error)
+	getMetricsFunc       func(appID, deploymentID string) ([]UpdateMetrics, error)
 	listUpdatesFunc      func(appID, deploymentID string) ([]Update, error)
 	getUpdateFunc        func(appID, deploymentID, updateID string) (*Update, error)
 	patchUpdateFunc      func(appID, deploymentID, updateID string, req PatchRequest) (*Update, error)
 	deleteUpdateFunc     func(appID, deploymentID, updateID string) error
+	clearPackagesFunc    func(appID, deploymentID string) (int, error)
 	rollbackFunc         func(appID, deploymentID string, req RollbackRequest) (*Update, error)
 	promoteFunc          func(appID, deploymentID string, req PromoteRequest) (*Update, error)
 }
 
+func (m *mockClient) ListApps(_ context.Context) ([]App, error) {
+	if m.listAppsFunc != nil {
+		return m.listAppsFunc()
+	}
+	return nil, nil
+}
+
+func (m *mockClient) GetApp(_ context.Context, appID string) (*App, error) {
+	if m.getAppFunc != nil {
+		return m.getAppFunc(appID)
+	}
+	return &App{ID: appID}, nil
+}
+
 func (m *mockClient) ListDeployments(_ context.Context, appID string) ([]Deployment, error) {
 	if m.listDeploymentsFunc != nil {
 		return m.listDeploymentsFunc(appID)
@@ -74,6 +96,20 @@ func (m *mockClient) UploadFile(_ context.Context, req UploadFileRequest) error
 	return nil
 }
 
+func (m *mockClient) GetDownloadURL(_ context.Context, appID, deploymentID, updateID string) (*DownloadURLResponse, error) {
+	if m.getDownloadURLFunc != nil {
+		return m.getDownloadURLFunc(appID, deploymentID, updateID)
+	}
+	return &DownloadURLResponse{URL: "https://example.com/download"}, nil
+}
+
+func (m *mockClient) DownloadFile(_ context.Context, fileURL string, w io.Writer) error {
+	if m.downloadFileFunc != nil {
+		return m.downloadFileFunc(fileURL, w)
+	}
+	return nil
+}
+
 func (m *mockClient) GetUpdateStatus(_ context.Context, appID, deploymentID, updateID string) (*UpdateStatus, error) {
 	if m.getUpdateStatusFunc != nil {
 		return m.getUpdateStatusFunc(appID, deploymentID, updateID)
@@ -81,6 +117,27 @@ func (m *mockClient) GetUpdateStatus(_ context.Context, appID, deploymentID, upd
 	return &UpdateStatus{UpdateID: updateID, Status: StatusProcessedValid}, nil
 }
 
+func (m *mockClient) ListUpdateLogs(_ context.Context, appID, deploymentID, updateID string) ([]UpdateLogEntry, error) {
+	if m.listUpdateLogsFunc != nil {
+		return m.listUpdateLogsFunc(appID, deploymentID, updateID)
+	}
+	return nil, nil
+}
+
+func (m *mockClient) ListDeploymentEvents(_ context.Context, appID, deploymentID string) ([]DeploymentEvent, error) {
+	if m.listEventsFunc != nil {
+		return m.listEventsFunc(appID, deploymentID)
+	}
+	return nil, nil
+}
+
+func (m *mockClient) GetDeploymentMetrics(_ context.Context, appID, deploymentID string) ([]UpdateMetrics, error) {
+	if m.getMetricsFunc != nil {
+		return m.getMetricsFunc(appID, deploymentID)
+	}
+	return nil, nil
+}
+
 func (m *mockClient) ListUpdates(_ context.Context, appID, deploymentID string) ([]Update, error) {
 	if m.listUpdatesFunc != nil {
 		return m.listUpdatesFunc(appID, deploymentID)
@@ -109,6 +166,13 @@ func (m *mockClient) DeleteUpdate(_ context.Context, appID, deploymentID, update
 	return nil
 }
 
+func (m *mockClient) ClearPackages(_ context.Context, appID, deploymentID string) (int, error) {
+	if m.clearPackagesFunc != nil {
+		return m.clearPackagesFunc(appID, deploymentID)
+	}
+	return 0, nil
+}
+
 func (m *mockClient) Rollback(_ context.Context, appID, deploymentID string, req RollbackRequest) (*Update, error) {
 	if m.rollbackFunc != nil {
 		return m.rollbackFunc(appID, deploymentID, req)
@@ -125,6 +189,19 @@ func (m *mockClient) Promote(_ context.Context, appID, deploymentID string, req
 
 var testOut = output.NewTest(io.Discard)
 
+// testRetry shortens the API and upload retry delays so tests of transient
+// failures run fast.
+var testRetry = RetryConfig{MaxAttempts: 3, InitialDelay: time.Millisecond, MaxDelay: 2 * time.Millisecond}
+
+// newTestClient is NewHTTPClient with testRetry.
+func newTestClient(baseURL, token, version string, opts ...ClientOption) *HTTPClient {
+	return NewHTTPClient(baseURL, token, version, append([]ClientOption{WithRetry(testRetry), WithUploadRetry(testRetry)}, opts...)...)
+}
+
+func (m *mockClient) uploadRetryConfig() RetryConfig {
+	return testRetry
+}
+
 var fastPollConfig = PollConfig{
 	MaxAttempts: 3,
 	Interval:    1 * time.Millisecond,
@@ -0,0 +1,109 @@
+package codepush
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"slices"
+	"time"
+)
+
+// TimelineEntry is one release in a cross-deployment timeline.
+type TimelineEntry struct {
+	Deployment string `json:"deployment"`
+	// Live is set on the release currently served by its deployment: the
+	// newest release that is not disabled.
+	Live bool `json:"live"`
+	Update
+}
+
+// DeploymentFailure records a deployment whose releases could not be listed.
+type DeploymentFailure struct {
+	DeploymentID string `json:"deployment_id"`
+	Deployment   string `json:"deployment"`
+	Error        string `json:"error"`
+}
+
+// Timeline is the release history of every deployment of an app, merged into
+// one list.
+type Timeline struct {
+	Entries  []TimelineEntry     `json:"entries"`
+	Failures []DeploymentFailure `json:"failures,omitempty"`
+}
+
+// FetchTimeline lists the releases of every deployment of an app, with at
+// most parallelism deployments in flight, and merges them oldest first. A
+// failure for one deployment is recorded in Failures rather than failing the
+// whole timeline.
+func FetchTimeline(ctx context.Context, client overviewClient, appID string, parallelism int) (*Timeline, error) {
+	deployments, err := client.ListDeployments(ctx, appID)
+	if err != nil {
+		return nil, fmt.Errorf("listing deployments: %w", err)
+	}
+	if parallelism <= 0 {
+		parallelism = DefaultOverviewParallelism
+	}
+
+	perDeployment := make([][]TimelineEntry, len(deployments))
+	failures := make([]*DeploymentFailure, len(deployments))
+	forEachParallel(len(deployments), parallelism, func(i int) {
+		d := deployments[i]
+		updates, err := client.ListUpdates(ctx, appID, d.ID)
+		if err != nil {
+			failures[i] = &DeploymentFailure{DeploymentID: d.ID, Deployment: d.Name, Error: err.Error()}
+			return
+		}
+		perDeployment[i] = deploymentTimeline(d, updates)
+	})
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	timeline := &Timeline{Entries: []TimelineEntry{}}
+	for i := range deployments {
+		timeline.Entries = append(timeline.Entries, perDeployment[i]...)
+		if failures[i] != nil {
+			timeline.Failures = append(timeline.Failures, *failures[i])
+		}
+	}
+	slices.SortStableFunc(timeline.Entries, func(a, b TimelineEntry) int {
+		return compareCreatedAt(a.CreatedAt, b.CreatedAt)
+	})
+	return timeline, nil
+}
+
+// deploymentTimeline converts the releases of one deployment, oldest first,
+// into timeline entries and marks the live one.
+func deploymentTimeline(d Deployment, updates []Update) []TimelineEntry {
+	entries := make([]TimelineEntry, len(updates))
+	for i, u := range updates {
+		if u.DeploymentID == "" {
+			u.DeploymentID = d.ID
+		}
+		entries[i] = TimelineEntry{Deployment: d.Name, Update: u}
+	}
+	for i := len(entries) - 1; i >= 0; i-- {
+		if !entries[i].Disabled {
+			entries[i].Live = true
+			break
+		}
+	}
+	return entries
+}
+
+// compareCreatedAt orders two release timestamps. Unparseable timestamps
+// sort before parseable ones and keep their relative order.
+func compareCreatedAt(a, b string) int {
+	ta, errA := time.Parse(time.RFC3339, a)
+	tb, errB := time.Parse(time.RFC3339, b)
+	switch {
+	case errA != nil && errB != nil:
+		return 0
+	case errA != nil:
+		return -1
+	case errB != nil:
+		return 1
+	}
+	return cmp.Compare(ta.UnixNano(), tb.UnixNano())
+}
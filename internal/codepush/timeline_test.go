@@ -0,0 +1,87 @@
+package codepush
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFetchTimeline(t *testing.T) {
+	t.Run("merges deployments by creation time", func(t *testing.T) {
+		client := &mockClient{
+			listDeploymentsFunc: func(appID string) ([]Deployment, error) {
+				return []Deployment{{ID: "d1", Name: "Staging"}, {ID: "d2", Name: "Production"}}, nil
+			},
+			listUpdatesFunc: func(appID, deploymentID string) ([]Update, error) {
+				if deploymentID == "d1" {
+					return []Update{
+						{ID: "s1", Label: "v1", CreatedAt: "2026-01-01T10:00:00Z"},
+						{ID: "s2", Label: "v2", CreatedAt: "2026-01-03T10:00:00Z"},
+						{ID: "s3", Label: "v3", CreatedAt: "2026-01-05T10:00:00Z", Disabled: true},
+					}, nil
+				}
+				return []Update{
+					{ID: "p1", Label: "v1", CreatedAt: "2026-01-02T10:00:00Z"},
+					{ID: "p2", Label: "v2", CreatedAt: "2026-01-04T12:00:00+02:00"},
+				}, nil
+			},
+		}
+
+		got, err := FetchTimeline(context.Background(), client, "app-123", 2)
+		require.NoError(t, err)
+		assert.Empty(t, got.Failures)
+
+		var ids []string
+		live := map[string]bool{}
+		for _, e := range got.Entries {
+			ids = append(ids, e.ID)
+			live[e.ID] = e.Live
+		}
+		assert.Equal(t, []string{"s1", "p1", "s2", "p2", "s3"}, ids)
+		assert.Equal(t, map[string]bool{"s1": false, "s2": true, "s3": false, "p1": false, "p2": true}, live)
+		assert.Equal(t, "Production", got.Entries[1].Deployment)
+		assert.Equal(t, "d2", got.Entries[1].DeploymentID)
+	})
+
+	t.Run("records failed deployments", func(t *testing.T) {
+		client := &mockClient{
+			listDeploymentsFunc: func(appID string) ([]Deployment, error) {
+				return []Deployment{{ID: "d1", Name: "Staging"}, {ID: "d2", Name: "Production"}}, nil
+			},
+			listUpdatesFunc: func(appID, deploymentID string) ([]Update, error) {
+				if deploymentID == "d2" {
+					return nil, errors.New("forbidden")
+				}
+				return []Update{{ID: "s1", Label: "v1"}}, nil
+			},
+		}
+
+		got, err := FetchTimeline(context.Background(), client, "app-123", 0)
+		require.NoError(t, err)
+		require.Len(t, got.Entries, 1)
+		assert.True(t, got.Entries[0].Live)
+		assert.Equal(t, []DeploymentFailure{{DeploymentID: "d2", Deployment: "Production", Error: "forbidden"}}, got.Failures)
+	})
+
+	t.Run("listing deployments fails", func(t *testing.T) {
+		client := &mockClient{
+			listDeploymentsFunc: func(appID string) ([]Deployment, error) {
+				return nil, errors.New("unauthorized")
+			},
+		}
+
+		_, err := FetchTimeline(context.Background(), client, "app-123", 2)
+		require.Error(t, err)
+		assert.ErrorContains(t, err, "listing deployments")
+	})
+}
+
+func TestCompareCreatedAt(t *testing.T) {
+	assert.Negative(t, compareCreatedAt("2026-01-01T00:00:00Z", "2026-01-02T00:00:00Z"))
+	assert.Positive(t, compareCreatedAt("2026-01-01T12:00:00Z", "2026-01-01T13:00:00+02:00"))
+	assert.Zero(t, compareCreatedAt("", "bad"))
+	assert.Negative(t, compareCreatedAt("", "2026-01-01T00:00:00Z"))
+}
@@ -3,9 +3,13 @@ package codepush
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"time"
+
+	"github.com/bitrise-io/bitrise-plugins-codepush-cli/internal/workspace"
+	ziputil "github.com/bitrise-io/bitrise-plugins-codepush-cli/internal/zip"
 )
 
 // PushOptions holds user-provided parameters for a push operation.
@@ -19,17 +23,93 @@ type PushOptions struct {
 	Disabled     bool
 	Rollout      int
 	BundlePath   string
+
+	// CheckNativeChanges warns when the bundle references native modules
+	// that the previous release did not. The previous release is downloaded
+	// for the comparison.
+	CheckNativeChanges bool
+	// FailOnNativeChange implies CheckNativeChanges and aborts the push when
+	// new references are found or the bundles cannot be compared.
+	FailOnNativeChange bool
+
+	// UploadStrategy selects how the archive is uploaded. Empty means auto.
+	UploadStrategy UploadStrategy
+
+	// RuntimeVersion is the Expo runtime version the bundle was built for,
+	// recorded as release metadata when known.
+	RuntimeVersion string
+
+	// ExpectLabel aborts the push unless the new release will get this label.
+	ExpectLabel string
+
+	// Full uploads the whole package even when a delta against the latest
+	// release would be smaller.
+	Full bool
+
+	// Compression selects how the package is compressed. The zero value is
+	// deflate at the default level.
+	Compression ziputil.Compression
+
+	// NoWait returns after the upload without waiting for the server to
+	// process the update.
+	NoWait bool
+
+	// Targeting restricts which devices are offered the release.
+	Targeting Targeting
+
+	// Provenance is recorded with the release; nil records nothing.
+	Provenance *Provenance
+
+	// AllowDuplicate skips the push, instead of failing it, when the
+	// latest release in the deployment already has the bundle's content.
+	AllowDuplicate bool
+
+	// Workspace holds the package and other temporary artifacts of the
+	// push; nil creates them in the system temp directory.
+	Workspace *workspace.Workspace
+}
+
+// UploadStrategy selects how an update archive is transferred to storage.
+type UploadStrategy string
+
+const (
+	// UploadStrategyAuto uploads parts in parallel when the server offers a
+	// multipart upload, degrading to sequential parts and then a single
+	// stream if parallel uploads keep failing.
+	UploadStrategyAuto UploadStrategy = "auto"
+	// UploadStrategySingle always uploads the archive in one request.
+	UploadStrategySingle UploadStrategy = "single"
+	// UploadStrategyParallel uploads parts in parallel without fallback.
+	UploadStrategyParallel UploadStrategy = "parallel"
+)
+
+// ValidateUploadStrategy returns an error if s is not a known strategy.
+func ValidateUploadStrategy(s UploadStrategy) error {
+	switch s {
+	case "", UploadStrategyAuto, UploadStrategySingle, UploadStrategyParallel:
+		return nil
+	default:
+		return fmt.Errorf("invalid upload strategy %q: must be auto, single, or parallel", s)
+	}
 }
 
 // UploadURLRequest represents the query parameters for requesting an upload URL.
 type UploadURLRequest struct {
-	AppVersion    string
-	FileName      string
-	FileSizeBytes int64
-	Description   string
-	Mandatory     bool
-	Disabled      bool
-	Rollout       int
+	AppVersion     string
+	FileName       string
+	FileSizeBytes  int64
+	Description    string
+	Mandatory      bool
+	Disabled       bool
+	Rollout        int
+	Multipart      bool
+	RuntimeVersion string
+	Provenance     *Provenance
+	Targeting      Targeting
+
+	// DiffAgainst is the ID of the release the uploaded file is a delta
+	// package against. Empty for a full package.
+	DiffAgainst string
 }
 
 // HeaderMap is a map[string]string that can unmarshal from either a JSON object
@@ -76,6 +156,24 @@ type UploadURLResponse struct {
 	URL     string    `json:"url"`
 	Method  string    `json:"method"`
 	Headers HeaderMap `json:"headers"`
+
+	// Parts is set when a multipart upload was requested and the server
+	// supports it. Each part covers a byte range of the archive.
+	Parts []UploadPart `json:"parts,omitempty"`
+}
+
+// UploadPart is a signed upload target for one byte range of the archive.
+type UploadPart struct {
+	URL     string    `json:"url"`
+	Method  string    `json:"method"`
+	Headers HeaderMap `json:"headers"`
+	Offset  int64     `json:"offset"`
+	Size    int64     `json:"size"`
+}
+
+// DownloadURLResponse is returned by the GET download-url endpoint.
+type DownloadURLResponse struct {
+	URL string `json:"url"`
 }
 
 // UploadFileRequest holds all parameters needed to upload a file.
@@ -101,6 +199,40 @@ type UpdateStatus struct {
 	StatusReason string `json:"status_reason"`
 }
 
+// UpdateLogEntry is a single line of the server-side processing log of an update.
+type UpdateLogEntry struct {
+	Timestamp string `json:"timestamp"`
+	Level     string `json:"level"`
+	Message   string `json:"message"`
+}
+
+// UpdateLogListResponse wraps the list update logs API response.
+type UpdateLogListResponse struct {
+	Items []UpdateLogEntry `json:"items"`
+}
+
+// ErrEventsUnavailable is returned when the server does not expose the event
+// history of a deployment.
+var ErrEventsUnavailable = errors.New("deployment events are not available from this server")
+
+// DeploymentEvent is a recorded change to a deployment or one of its
+// releases, such as a patch, rollback, or deletion.
+type DeploymentEvent struct {
+	ID        string         `json:"id"`
+	Type      string         `json:"type"`
+	UpdateID  string         `json:"package_id,omitempty"`
+	Label     string         `json:"label,omitempty"`
+	CreatedAt string         `json:"created_at"`
+	CreatedBy *UpdateCreator `json:"created_by,omitempty"`
+	// Changes maps changed fields to their new values, e.g. {"rollout": 50}.
+	Changes map[string]any `json:"changes,omitempty"`
+}
+
+// DeploymentEventListResponse wraps the list deployment events API response.
+type DeploymentEventListResponse struct {
+	Items []DeploymentEvent `json:"items"`
+}
+
 // Deployment represents a CodePush deployment.
 type Deployment struct {
 	ID           string  `json:"id"`
@@ -110,6 +242,21 @@ type Deployment struct {
 	LatestUpdate *Update `json:"latest_package,omitempty"`
 }
 
+// UpdateMetrics holds the install statistics of one update (release label)
+// in a deployment.
+type UpdateMetrics struct {
+	Label      string `json:"label"`
+	Active     int64  `json:"active"`
+	Downloaded int64  `json:"downloaded"`
+	Installed  int64  `json:"installed"`
+	Failed     int64  `json:"failed"`
+}
+
+// DeploymentMetricsResponse wraps the deployment metrics API response.
+type DeploymentMetricsResponse struct {
+	Items []UpdateMetrics `json:"items"`
+}
+
 // CreateDeploymentRequest is the JSON body for creating a deployment.
 type CreateDeploymentRequest struct {
 	Name string `json:"name"`
@@ -135,6 +282,31 @@ type PushResult struct {
 	Status        string `json:"status"`
 	FileSizeBytes int64  `json:"file_size_bytes"`
 	Rollout       int    `json:"rollout"`
+
+	// UploadStrategy records the upload strategy that succeeded:
+	// "parallel", "sequential", or "single".
+	UploadStrategy string `json:"upload_strategy,omitempty"`
+
+	// RuntimeVersion is the Expo runtime version recorded with the release.
+	RuntimeVersion string `json:"runtime_version,omitempty"`
+
+	// DiffAgainst is the label of the release a delta package was uploaded
+	// against. FileSizeBytes is then the size of the delta package.
+	DiffAgainst string `json:"diff_against,omitempty"`
+
+	// ContentSizeBytes is the uncompressed size of the bundle and
+	// Compression the compression of the package.
+	ContentSizeBytes int64  `json:"content_size_bytes,omitempty"`
+	Compression      string `json:"compression,omitempty"`
+
+	Provenance *Provenance `json:"provenance,omitempty"`
+
+	// DuplicateOf is the label of the release that already had the
+	// bundle's content when the push was skipped for it. UpdateID is then
+	// that release.
+	DuplicateOf string `json:"duplicate_of,omitempty"`
+
+	Targeting
 }
 
 // PollConfig controls the polling behavior when waiting for update processing.
@@ -149,12 +321,63 @@ var DefaultPollConfig = PollConfig{
 	Interval:    2 * time.Second,
 }
 
+// PollConfigFor returns a poll config that polls at the default interval
+// for timeout, making at least one attempt.
+func PollConfigFor(timeout time.Duration) PollConfig {
+	interval := DefaultPollConfig.Interval
+	attempts := int((timeout + interval - 1) / interval)
+	return PollConfig{MaxAttempts: max(attempts, 1), Interval: interval}
+}
+
+// RetryConfig controls how failed requests are retried. The delay between
+// attempts starts at InitialDelay and doubles up to MaxDelay.
+type RetryConfig struct {
+	MaxAttempts  int
+	InitialDelay time.Duration
+	MaxDelay     time.Duration
+}
+
+// DefaultUploadRetryConfig is used in production.
+var DefaultUploadRetryConfig = RetryConfig{
+	MaxAttempts:  5,
+	InitialDelay: 1 * time.Second,
+	MaxDelay:     30 * time.Second,
+}
+
+// DefaultAPIRetryConfig is used for API requests in production: three
+// retries after the first attempt.
+var DefaultAPIRetryConfig = RetryConfig{
+	MaxAttempts:  4,
+	InitialDelay: 1 * time.Second,
+	MaxDelay:     30 * time.Second,
+}
+
+// ErrDeploymentNotFound is returned when a deployment does not exist under
+// the requested app.
+var ErrDeploymentNotFound = errors.New("deployment not found")
+
+// ErrProcessingFailed is returned when the server rejects an uploaded update
+// during processing (status processed_invalid).
+var ErrProcessingFailed = errors.New("update processing failed")
+
+// ErrProcessingPending is returned when an uploaded update is still being
+// processed when polling stops.
+var ErrProcessingPending = errors.New("update processing timed out")
+
+// ErrLogsUnavailable is returned when the server does not expose processing
+// logs for an update.
+var ErrLogsUnavailable = errors.New("processing logs are not available from this server")
+
 // Status constants for update processing.
 const (
 	StatusCreated        = "created"
 	StatusUploaded       = "uploaded"
 	StatusProcessedValid = "processed_valid"
 	StatusProcessedError = "processed_invalid"
+
+	// StatusDuplicate is reported by a push skipped because the latest
+	// release already has the bundle's content. It is not a server status.
+	StatusDuplicate = "duplicate"
 )
 
 // UpdateCreator identifies the user who created an update.
@@ -180,11 +403,40 @@ type Update struct {
 	Hash          string         `json:"hash,omitempty"`
 	FileName      string         `json:"file_name,omitempty"`
 	CreatedBy     *UpdateCreator `json:"created_by,omitempty"`
+	Provenance    *Provenance    `json:"provenance,omitempty"`
+	Targeting
 }
 
-// UpdateListResponse wraps the list updates API response.
+// Provenance records the sources and CI build that pushed an update.
+type Provenance struct {
+	BuildNumber string `json:"build_number,omitempty"`
+	BuildURL    string `json:"build_url,omitempty"`
+	CommitHash  string `json:"commit_hash,omitempty"`
+	Branch      string `json:"branch,omitempty"`
+	// Dirty reports uncommitted changes in the checkout the update was
+	// pushed from.
+	Dirty    bool   `json:"dirty,omitempty"`
+	Workflow string `json:"workflow,omitempty"`
+}
+
+// UpdateListResponse wraps one page of the list updates API response.
 type UpdateListResponse struct {
-	Items []Update `json:"items"`
+	Items  []Update `json:"items"`
+	Paging Paging   `json:"paging"`
+}
+
+// DeleteUpdatesResponse is the response of the bulk delete updates API.
+type DeleteUpdatesResponse struct {
+	Deleted int `json:"deleted"`
+}
+
+// Paging locates the next page of a list response. Servers either return a
+// Next cursor or page numbers; an empty Paging means there are no more
+// pages.
+type Paging struct {
+	Next       string `json:"next,omitempty"`
+	Page       int    `json:"page,omitempty"`
+	TotalPages int    `json:"total_pages,omitempty"`
 }
 
 // RollbackOptions holds user-provided parameters for a rollback operation.
@@ -209,6 +461,22 @@ type RollbackResult struct {
 	AppVersion   string `json:"app_version"`
 }
 
+// RollbackPreview describes the release a rollback would create, as
+// resolved by a dry run.
+type RollbackPreview struct {
+	DryRun       bool   `json:"dry_run"`
+	AppID        string `json:"app_id"`
+	DeploymentID string `json:"deployment_id"`
+	// Label is the predicted label of the new release; empty when the
+	// deployment's labels do not follow v1, v2, ...
+	Label string `json:"label,omitempty"`
+	// Current is the latest release, which the rollback replaces.
+	Current Update `json:"current"`
+	// Target is the release whose content and metadata the new release
+	// copies.
+	Target Update `json:"target"`
+}
+
 // PromoteOptions holds user-provided parameters for a promote operation.
 type PromoteOptions struct {
 	AppID              string
@@ -221,6 +489,7 @@ type PromoteOptions struct {
 	Mandatory          string // optional: "true"/"false" override
 	Disabled           string // optional: "true"/"false" override
 	Rollout            string // optional: "0"-"100" override
+	ExpectSourceHash   string // optional: abort unless the promoted release has this hash
 }
 
 // PromoteRequest is the JSON body sent to the promote API endpoint.
@@ -245,6 +514,19 @@ type PromoteResult struct {
 	Description      string `json:"description"`
 }
 
+// PromotePreview compares the latest release of a promote's destination
+// with the release the promote would create.
+type PromotePreview struct {
+	Destination string `json:"destination"`
+	// Current is the latest release of the destination; nil when it has
+	// none.
+	Current *Update `json:"current,omitempty"`
+	// Source is the release being promoted.
+	Source Update `json:"source"`
+	// Release is Source with the promote's overrides applied.
+	Release Update `json:"release"`
+}
+
 // PatchOptions holds user-provided parameters for a patch operation.
 type PatchOptions struct {
 	AppID        string
@@ -256,6 +538,14 @@ type PatchOptions struct {
 	Disabled     string // optional: "true"/"false"
 	Description  string // optional
 	AppVersion   string // optional
+
+	ExpectCurrentRollout string // optional: abort unless the release is at this rollout
+
+	// Targeting changes: nil leaves a condition unchanged, an empty value
+	// removes it.
+	TargetOSVersion    *string
+	TargetDeviceModels *[]string
+	TargetCountries    *[]string
 }
 
 // PatchRequest is the JSON body sent to the PATCH update API endpoint.
@@ -266,6 +556,10 @@ type PatchRequest struct {
 	Disabled    *bool   `json:"disabled,omitempty"`
 	Description *string `json:"description,omitempty"`
 	AppVersion  *string `json:"app_version,omitempty"`
+
+	TargetOSVersion    *string   `json:"target_os_version,omitempty"`
+	TargetDeviceModels *[]string `json:"target_device_models,omitempty"`
+	TargetCountries    *[]string `json:"target_countries,omitempty"`
 }
 
 // PatchResult is the output of a successful patch.
@@ -279,10 +573,29 @@ type PatchResult struct {
 	Disabled     bool   `json:"disabled"`
 	Rollout      int    `json:"rollout"`
 	Description  string `json:"description"`
+	Targeting
+}
+
+// App is a release management connected app.
+type App struct {
+	ID         string `json:"id"`
+	Name       string `json:"store_app_name"`
+	Platform   string `json:"platform"`
+	StoreAppID string `json:"store_app_id,omitempty"`
+	// WorkspaceSlug identifies the Bitrise workspace (organization) that
+	// owns the app.
+	WorkspaceSlug string `json:"workspace_slug,omitempty"`
+}
+
+// AppListResponse wraps the list apps API response.
+type AppListResponse struct {
+	Items []App `json:"items"`
 }
 
 // Client defines the CodePush API operations.
 type Client interface {
+	ListApps(ctx context.Context) ([]App, error)
+	GetApp(ctx context.Context, appID string) (*App, error)
 	ListDeployments(ctx context.Context, appID string) ([]Deployment, error)
 	CreateDeployment(ctx context.Context, appID string, req CreateDeploymentRequest) (*Deployment, error)
 	GetDeployment(ctx context.Context, appID, deploymentID string) (*Deployment, error)
@@ -290,11 +603,17 @@ type Client interface {
 	DeleteDeployment(ctx context.Context, appID, deploymentID string) error
 	GetUploadURL(ctx context.Context, appID, deploymentID, updateID string, req UploadURLRequest) (*UploadURLResponse, error)
 	UploadFile(ctx context.Context, req UploadFileRequest) error
+	GetDownloadURL(ctx context.Context, appID, deploymentID, updateID string) (*DownloadURLResponse, error)
+	DownloadFile(ctx context.Context, fileURL string, w io.Writer) error
 	GetUpdateStatus(ctx context.Context, appID, deploymentID, updateID string) (*UpdateStatus, error)
+	ListUpdateLogs(ctx context.Context, appID, deploymentID, updateID string) ([]UpdateLogEntry, error)
+	ListDeploymentEvents(ctx context.Context, appID, deploymentID string) ([]DeploymentEvent, error)
+	GetDeploymentMetrics(ctx context.Context, appID, deploymentID string) ([]UpdateMetrics, error)
 	ListUpdates(ctx context.Context, appID, deploymentID string) ([]Update, error)
 	GetUpdate(ctx context.Context, appID, deploymentID, updateID string) (*Update, error)
 	PatchUpdate(ctx context.Context, appID, deploymentID, updateID string, req PatchRequest) (*Update, error)
 	DeleteUpdate(ctx context.Context, appID, deploymentID, updateID string) error
+	ClearPackages(ctx context.Context, appID, deploymentID string) (int, error)
 	Rollback(ctx context.Context, appID, deploymentID string, req RollbackRequest) (*Update, error)
 	Promote(ctx context.Context, appID, deploymentID string, req PromoteRequest) (*Update, error)
 }
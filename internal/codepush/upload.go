@@ -0,0 +1,319 @@
+package codepush
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/bitrise-io/bitrise-plugins-codepush-cli/internal/output"
+)
+
+// parallelUploadWorkers is the number of parts uploaded concurrently.
+const parallelUploadWorkers = 4
+
+// fileUploader is the subset of Client needed to upload an archive.
+type fileUploader interface {
+	UploadFile(ctx context.Context, req UploadFileRequest) error
+}
+
+// uploadRetrier is implemented by uploaders that configure how each upload
+// request is retried. Other uploaders use DefaultUploadRetryConfig.
+type uploadRetrier interface {
+	uploadRetryConfig() RetryConfig
+}
+
+func uploadRetryFor(client fileUploader) RetryConfig {
+	if r, ok := client.(uploadRetrier); ok {
+		return r.uploadRetryConfig()
+	}
+	return DefaultUploadRetryConfig
+}
+
+// archiveUpload is an archive and the target it is uploaded to, shared by
+// the upload strategies.
+type archiveUpload struct {
+	client  fileUploader
+	retry   RetryConfig
+	zipPath string
+	size    int64
+	target  *UploadURLResponse
+}
+
+// newArchiveUpload returns the upload of the archive at zipPath to target,
+// retried as configured by client.
+func newArchiveUpload(client fileUploader, zipPath string, size int64, target *UploadURLResponse) *archiveUpload {
+	return &archiveUpload{client: client, retry: uploadRetryFor(client), zipPath: zipPath, size: size, target: target}
+}
+
+// uploadArchive uploads the archive of u according to strategy. Returns
+// the name of the strategy that succeeded: "parallel", "sequential", or
+// "single".
+//
+// The server signs the part URLs and so decides the part size; the API
+// offers no way to ask for smaller parts. The sequential fallback therefore
+// retries the same parts, one at a time.
+func uploadArchive(ctx context.Context, u *archiveUpload, strategy UploadStrategy, out *output.Writer) (string, error) {
+	if strategy == UploadStrategySingle || len(u.target.Parts) == 0 {
+		if strategy == UploadStrategyParallel {
+			out.Warning("server did not offer a multipart upload, uploading as a single stream")
+		}
+		return "single", uploadSingle(ctx, u, out, "Uploading")
+	}
+
+	done := &partTracker{}
+	err := uploadParts(ctx, u, uploadPartsOptions{workers: parallelUploadWorkers, done: done, label: "Uploading"}, out)
+	if err == nil {
+		return "parallel", nil
+	}
+	if strategy == UploadStrategyParallel || ctx.Err() != nil {
+		return "", err
+	}
+
+	out.Warning("parallel upload failed: %v", err)
+	out.Info("Retrying the remaining parts with a single upload stream")
+	err = uploadParts(ctx, u, uploadPartsOptions{workers: 1, done: done, label: "Uploading (sequential)"}, out)
+	if err == nil {
+		return "sequential", nil
+	}
+	if u.target.URL == "" || ctx.Err() != nil {
+		return "", err
+	}
+
+	out.Warning("sequential upload failed: %v", err)
+	out.Info("Retrying as a single request")
+	if err := uploadSingle(ctx, u, out, "Uploading (single)"); err != nil {
+		return "", err
+	}
+	return "single", nil
+}
+
+// uploadSingle uploads the whole archive in one request, restarting it on
+// transient failures.
+func uploadSingle(ctx context.Context, u *archiveUpload, out *output.Writer, label string) error {
+	f, err := os.Open(u.zipPath)
+	if err != nil {
+		return fmt.Errorf("opening zip for upload: %w", err)
+	}
+	defer func() { _ = f.Close() }()
+
+	progress := out.NewTransfer(label, u.size)
+	err = retryUpload(ctx, u.retry, func() error {
+		if _, err := f.Seek(0, io.SeekStart); err != nil {
+			return fmt.Errorf("rewinding zip for upload: %w", err)
+		}
+		progress.Restart()
+		return u.client.UploadFile(ctx, UploadFileRequest{
+			URL:           u.target.URL,
+			Method:        u.target.Method,
+			Headers:       u.target.Headers,
+			Body:          progress.Reader(f),
+			ContentLength: u.size,
+		})
+	})
+	if err != nil {
+		progress.Cancel()
+		return err
+	}
+	progress.Done()
+	return nil
+}
+
+// uploadPartsOptions configures one pass of uploadParts.
+type uploadPartsOptions struct {
+	// workers is the number of parts uploaded concurrently.
+	workers int
+	// done records the parts already uploaded, by this or an earlier pass.
+	done *partTracker
+	// label is shown with the progress bar.
+	label string
+}
+
+// uploadParts uploads each part of the multipart upload u that opts.done
+// does not already record, using opts.workers workers and retrying each
+// part on transient failures. Uploaded parts are recorded in opts.done, so
+// a later call resumes where this one stopped.
+func uploadParts(ctx context.Context, u *archiveUpload, opts uploadPartsOptions, out *output.Writer) error {
+	var pending []UploadPart
+	var sent int64
+	for _, part := range u.target.Parts {
+		if opts.done.has(part) {
+			sent += part.Size
+		} else {
+			pending = append(pending, part)
+		}
+	}
+	if len(pending) == 0 {
+		return nil
+	}
+
+	f, err := os.Open(u.zipPath)
+	if err != nil {
+		return fmt.Errorf("opening zip for upload: %w", err)
+	}
+	defer func() { _ = f.Close() }()
+
+	partCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	progress := out.NewTransfer(opts.label, u.size)
+	if sent > 0 {
+		progress.Resume(sent)
+	}
+
+	jobs := make(chan UploadPart)
+	errs := make(chan error, len(pending))
+	var wg sync.WaitGroup
+	for range min(opts.workers, len(pending)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for part := range jobs {
+				if err := uploadPart(partCtx, u, f, part, progress); err != nil {
+					errs <- err
+					cancel()
+					continue
+				}
+				opts.done.add(part)
+			}
+		}()
+	}
+
+	for _, part := range pending {
+		if partCtx.Err() != nil {
+			break
+		}
+		jobs <- part
+	}
+	close(jobs)
+	wg.Wait()
+	close(errs)
+
+	err = ctx.Err()
+	if err == nil {
+		// Parts canceled because a sibling failed are not reported.
+		err = errors.Join(collectPartErrors(errs)...)
+	}
+	if err != nil {
+		progress.Cancel()
+		return err
+	}
+	progress.Done()
+	return nil
+}
+
+func uploadPart(ctx context.Context, u *archiveUpload, f io.ReaderAt, part UploadPart, progress *output.TransferProgress) error {
+	err := retryUpload(ctx, u.retry, func() error {
+		var sent int64
+		body := &countingReader{r: io.NewSectionReader(f, part.Offset, part.Size), add: func(n int) {
+			sent += int64(n)
+			progress.Add(int64(n))
+		}}
+		err := u.client.UploadFile(ctx, UploadFileRequest{
+			URL:           part.URL,
+			Method:        part.Method,
+			Headers:       part.Headers,
+			Body:          body,
+			ContentLength: part.Size,
+		})
+		if err != nil {
+			progress.Add(-sent)
+		}
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("part at offset %d: %w", part.Offset, err)
+	}
+	return nil
+}
+
+// retryUpload calls upload until it succeeds, fails permanently, or
+// retry.MaxAttempts is reached. The delay between attempts starts at
+// retry.InitialDelay and doubles up to retry.MaxDelay.
+func retryUpload(ctx context.Context, retry RetryConfig, upload func() error) error {
+	delay := retry.InitialDelay
+	for attempt := 1; ; attempt++ {
+		err := upload()
+		if err == nil {
+			return nil
+		}
+		if attempt >= retry.MaxAttempts || !isTransientUploadError(ctx, err) {
+			if attempt > 1 {
+				return fmt.Errorf("after %d attempts: %w", attempt, err)
+			}
+			return err
+		}
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+		delay = min(delay*2, retry.MaxDelay)
+	}
+}
+
+// isTransientUploadError reports whether a failed upload request is worth
+// retrying: network errors, timeouts, throttling, and server errors. Client
+// errors such as an expired signature (HTTP 403) are permanent.
+func isTransientUploadError(ctx context.Context, err error) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+	var statusErr *uploadStatusError
+	if errors.As(err, &statusErr) {
+		code := statusErr.StatusCode
+		return code >= 500 || code == http.StatusTooManyRequests || code == http.StatusRequestTimeout
+	}
+	return true
+}
+
+// partTracker records the parts of a multipart upload that are already
+// stored, so a fallback strategy resumes instead of starting over.
+type partTracker struct {
+	mu   sync.Mutex
+	done map[int64]bool
+}
+
+func (t *partTracker) has(part UploadPart) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.done[part.Offset]
+}
+
+func (t *partTracker) add(part UploadPart) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.done == nil {
+		t.done = map[int64]bool{}
+	}
+	t.done[part.Offset] = true
+}
+
+func collectPartErrors(errs <-chan error) []error {
+	var all []error
+	for err := range errs {
+		if !errors.Is(err, context.Canceled) {
+			all = append(all, err)
+		}
+	}
+	return all
+}
+
+// countingReader reports the number of bytes read to add.
+type countingReader struct {
+	r   io.Reader
+	add func(int)
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.add(n)
+	return n, err
+}
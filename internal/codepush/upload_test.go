@@ -0,0 +1,212 @@
+package codepush
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeTestArchive(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "update.zip")
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+	return path
+}
+
+func multipartTarget() *UploadURLResponse {
+	return &UploadURLResponse{
+		URL:    "https://example.com/whole",
+		Method: "PUT",
+		Parts: []UploadPart{
+			{URL: "https://example.com/part-1", Method: "PUT", Offset: 0, Size: 4},
+			{URL: "https://example.com/part-2", Method: "PUT", Offset: 4, Size: 4},
+			{URL: "https://example.com/part-3", Method: "PUT", Offset: 8, Size: 2},
+		},
+	}
+}
+
+// recordingUploader records the body received for each URL.
+type recordingUploader struct {
+	mu     sync.Mutex
+	bodies map[string]string
+	fail   func(url string, attempt int) error
+	calls  map[string]int
+}
+
+func (r *recordingUploader) uploadRetryConfig() RetryConfig {
+	return testRetry
+}
+
+func (r *recordingUploader) UploadFile(_ context.Context, req UploadFileRequest) error {
+	data, err := io.ReadAll(req.Body)
+	if err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.calls == nil {
+		r.calls = map[string]int{}
+		r.bodies = map[string]string{}
+	}
+	r.calls[req.URL]++
+	if r.fail != nil {
+		if err := r.fail(req.URL, r.calls[req.URL]); err != nil {
+			return err
+		}
+	}
+	r.bodies[req.URL] = string(data)
+	return nil
+}
+
+func TestUploadArchive(t *testing.T) {
+	zipPath := writeTestArchive(t, "0123456789")
+
+	t.Run("uploads parts in parallel", func(t *testing.T) {
+		up := &recordingUploader{}
+		strategy, err := uploadArchive(context.Background(), newArchiveUpload(up, zipPath, 10, multipartTarget()), UploadStrategyAuto, testOut)
+		require.NoError(t, err)
+
+		assert.Equal(t, "parallel", strategy)
+		assert.Equal(t, "0123", up.bodies["https://example.com/part-1"])
+		assert.Equal(t, "4567", up.bodies["https://example.com/part-2"])
+		assert.Equal(t, "89", up.bodies["https://example.com/part-3"])
+		assert.NotContains(t, up.bodies, "https://example.com/whole")
+	})
+
+	t.Run("retries a flaky part within the same strategy", func(t *testing.T) {
+		up := &recordingUploader{fail: func(url string, attempt int) error {
+			if url == "https://example.com/part-2" && attempt == 1 {
+				return errors.New("connection reset")
+			}
+			return nil
+		}}
+		strategy, err := uploadArchive(context.Background(), newArchiveUpload(up, zipPath, 10, multipartTarget()), UploadStrategyAuto, testOut)
+		require.NoError(t, err)
+		assert.Equal(t, "parallel", strategy)
+	})
+
+	t.Run("auto degrades to a single request when parts keep failing", func(t *testing.T) {
+		up := &recordingUploader{fail: func(url string, _ int) error {
+			if strings.Contains(url, "part-") {
+				return errors.New("connection reset")
+			}
+			return nil
+		}}
+		strategy, err := uploadArchive(context.Background(), newArchiveUpload(up, zipPath, 10, multipartTarget()), UploadStrategyAuto, testOut)
+		require.NoError(t, err)
+
+		assert.Equal(t, "single", strategy)
+		assert.Equal(t, "0123456789", up.bodies["https://example.com/whole"])
+	})
+
+	t.Run("auto degrades to sequential parts first", func(t *testing.T) {
+		up := &recordingUploader{fail: func(url string, attempt int) error {
+			if url == "https://example.com/part-1" && attempt <= testRetry.MaxAttempts {
+				return errors.New("connection reset")
+			}
+			return nil
+		}}
+		strategy, err := uploadArchive(context.Background(), newArchiveUpload(up, zipPath, 10, multipartTarget()), UploadStrategyAuto, testOut)
+		require.NoError(t, err)
+
+		assert.Equal(t, "sequential", strategy)
+		assert.Equal(t, "0123", up.bodies["https://example.com/part-1"])
+		assert.Equal(t, 1, up.calls["https://example.com/part-2"], "uploaded parts are not sent again")
+		assert.NotContains(t, up.calls, "https://example.com/whole")
+	})
+
+	t.Run("parallel does not fall back", func(t *testing.T) {
+		up := &recordingUploader{fail: func(url string, _ int) error {
+			return errors.New("connection reset")
+		}}
+		_, err := uploadArchive(context.Background(), newArchiveUpload(up, zipPath, 10, multipartTarget()), UploadStrategyParallel, testOut)
+		require.Error(t, err)
+		assert.ErrorContains(t, err, "connection reset")
+		assert.NotContains(t, up.calls, "https://example.com/whole")
+	})
+
+	t.Run("single ignores offered parts", func(t *testing.T) {
+		up := &recordingUploader{}
+		strategy, err := uploadArchive(context.Background(), newArchiveUpload(up, zipPath, 10, multipartTarget()), UploadStrategySingle, testOut)
+		require.NoError(t, err)
+
+		assert.Equal(t, "single", strategy)
+		assert.Len(t, up.calls, 1)
+	})
+
+	t.Run("uses a single request when the server offers no parts", func(t *testing.T) {
+		up := &recordingUploader{}
+		target := &UploadURLResponse{URL: "https://example.com/whole", Method: "PUT"}
+		strategy, err := uploadArchive(context.Background(), newArchiveUpload(up, zipPath, 10, target), UploadStrategyParallel, testOut)
+		require.NoError(t, err)
+		assert.Equal(t, "single", strategy)
+	})
+}
+
+func TestUploadRetry(t *testing.T) {
+	zipPath := writeTestArchive(t, "0123456789")
+	target := &UploadURLResponse{URL: "https://example.com/whole", Method: "PUT"}
+
+	t.Run("restarts a single upload after transient failures", func(t *testing.T) {
+		up := &recordingUploader{fail: func(url string, attempt int) error {
+			if attempt == 1 {
+				return errors.New("connection reset")
+			}
+			if attempt == 2 {
+				return &uploadStatusError{StatusCode: 503, Body: "slow down"}
+			}
+			return nil
+		}}
+		_, err := uploadArchive(context.Background(), newArchiveUpload(up, zipPath, 10, target), UploadStrategySingle, testOut)
+		require.NoError(t, err)
+
+		assert.Equal(t, 3, up.calls["https://example.com/whole"])
+		assert.Equal(t, "0123456789", up.bodies["https://example.com/whole"], "each attempt sends the whole archive")
+	})
+
+	t.Run("gives up after the maximum attempts", func(t *testing.T) {
+		up := &recordingUploader{fail: func(string, int) error { return errors.New("connection reset") }}
+		_, err := uploadArchive(context.Background(), newArchiveUpload(up, zipPath, 10, target), UploadStrategySingle, testOut)
+		require.Error(t, err)
+		assert.ErrorContains(t, err, "after 3 attempts")
+		assert.Equal(t, testRetry.MaxAttempts, up.calls["https://example.com/whole"])
+	})
+
+	t.Run("does not retry permanent failures", func(t *testing.T) {
+		up := &recordingUploader{fail: func(string, int) error {
+			return &uploadStatusError{StatusCode: 403, Body: "signature expired"}
+		}}
+		_, err := uploadArchive(context.Background(), newArchiveUpload(up, zipPath, 10, target), UploadStrategySingle, testOut)
+		require.Error(t, err)
+		assert.ErrorContains(t, err, "upload failed with HTTP 403")
+		assert.Equal(t, 1, up.calls["https://example.com/whole"])
+	})
+}
+
+func TestIsTransientUploadError(t *testing.T) {
+	ctx := context.Background()
+	assert.True(t, isTransientUploadError(ctx, errors.New("connection reset by peer")))
+	assert.True(t, isTransientUploadError(ctx, &uploadStatusError{StatusCode: 502}))
+	assert.True(t, isTransientUploadError(ctx, &uploadStatusError{StatusCode: 429}))
+	assert.False(t, isTransientUploadError(ctx, &uploadStatusError{StatusCode: 403}))
+
+	canceled, cancel := context.WithCancel(ctx)
+	cancel()
+	assert.False(t, isTransientUploadError(canceled, errors.New("connection reset by peer")))
+}
+
+func TestValidateUploadStrategy(t *testing.T) {
+	for _, s := range []UploadStrategy{"", UploadStrategyAuto, UploadStrategySingle, UploadStrategyParallel} {
+		assert.NoError(t, ValidateUploadStrategy(s))
+	}
+	assert.ErrorContains(t, ValidateUploadStrategy("chunked"), "invalid upload strategy")
+}
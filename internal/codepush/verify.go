@@ -0,0 +1,181 @@
+package codepush
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"slices"
+	"strings"
+
+	"github.com/bitrise-io/bitrise-plugins-codepush-cli/internal/workspace"
+)
+
+// packageHashDir is the directory name the SDK prefixes to every path when
+// hashing a package. Packages are zipped from the bundle directory's
+// contents, so the prefix is added back when hashing a downloaded zip.
+const packageHashDir = "CodePush/"
+
+// hermesHeaderSize is the number of bytes of the Hermes bytecode header read
+// by verification: magic (8), version (4), source hash (20), file length (4).
+const hermesHeaderSize = 36
+
+// VerifyResult is the outcome of verifying a released package.
+type VerifyResult struct {
+	UpdateID     string `json:"update_id"`
+	Label        string `json:"label"`
+	ExpectedHash string `json:"expected_hash"`
+	ActualHash   string `json:"actual_hash"`
+	HashMatch    bool   `json:"hash_match"`
+	Files        int    `json:"files"`
+
+	Hermes []HermesCheck `json:"hermes,omitempty"`
+}
+
+// HermesCheck is the header validation of one Hermes bytecode file.
+type HermesCheck struct {
+	Path    string `json:"path"`
+	Version uint32 `json:"version,omitempty"`
+	Error   string `json:"error,omitempty"`
+}
+
+// Valid reports whether the hash matched and every Hermes header is valid.
+func (r *VerifyResult) Valid() bool {
+	if !r.HashMatch {
+		return false
+	}
+	return !slices.ContainsFunc(r.Hermes, func(c HermesCheck) bool { return c.Error != "" })
+}
+
+// verifyClient is the subset of Client needed by VerifyPackage.
+type verifyClient interface {
+	GetUpdate(ctx context.Context, appID, deploymentID, updateID string) (*Update, error)
+	updateDownloader
+}
+
+// VerifyPackage downloads a released package into ws, recomputes its
+// content hash, and compares it to the hash recorded by the API. Bundles
+// compiled to Hermes bytecode also have their headers checked against the
+// file contents.
+func VerifyPackage(ctx context.Context, client verifyClient, ws *workspace.Workspace, ref UpdateRef) (*VerifyResult, error) {
+	update, err := client.GetUpdate(ctx, ref.AppID, ref.DeploymentID, ref.UpdateID)
+	if err != nil {
+		return nil, fmt.Errorf("getting update: %w", err)
+	}
+	if update.Hash == "" {
+		return nil, fmt.Errorf("release %s has no recorded hash to verify against", update.Label)
+	}
+
+	zipPath, err := downloadUpdate(ctx, client, ws, ref)
+	if err != nil {
+		return nil, fmt.Errorf("downloading release %s: %w", update.Label, err)
+	}
+	defer ws.Remove(zipPath)
+
+	manifest, err := ManifestFromZip(zipPath)
+	if err != nil {
+		return nil, err
+	}
+
+	hermes, err := checkHermesHeaders(zipPath)
+	if err != nil {
+		return nil, err
+	}
+
+	actual, files := PackageHash(manifest)
+	return &VerifyResult{
+		UpdateID:     update.ID,
+		Label:        update.Label,
+		ExpectedHash: update.Hash,
+		ActualHash:   actual,
+		HashMatch:    strings.EqualFold(actual, update.Hash),
+		Files:        files,
+		Hermes:       hermes,
+	}, nil
+}
+
+// PackageHash computes the content hash of a package the way the SDK does on
+// device: a sorted JSON array of "CodePush/<path>:<sha256>" entries, hashed
+// with SHA-256. Files the SDK ignores (.codepushrelease, .DS_Store, and
+// __MACOSX) are skipped. It also returns the number of files hashed.
+func PackageHash(m FileManifest) (string, int) {
+	entries := make([]string, 0, len(m))
+	for name, sum := range m {
+		base := path.Base(name)
+		if base == ".DS_Store" || base == ".codepushrelease" || strings.HasPrefix(name, "__MACOSX/") {
+			continue
+		}
+		if !strings.HasPrefix(name, packageHashDir) {
+			name = packageHashDir + name
+		}
+		entries = append(entries, name+":"+sum)
+	}
+	slices.Sort(entries)
+
+	// Marshalling a []string cannot fail.
+	data, _ := json.Marshal(entries)
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), len(entries)
+}
+
+// checkHermesHeaders validates the header of every Hermes bytecode bundle in
+// a package zip. Plain JavaScript bundles are not reported.
+func checkHermesHeaders(zipPath string) ([]HermesCheck, error) {
+	zr, err := zip.OpenReader(zipPath)
+	if err != nil {
+		return nil, fmt.Errorf("opening package: %w", err)
+	}
+	defer func() { _ = zr.Close() }()
+
+	var checks []HermesCheck
+	for _, f := range zr.File {
+		if !isBundleFile(f.Name) && !strings.HasSuffix(f.Name, ".hbc") {
+			continue
+		}
+		rc, err := f.Open()
+		if err != nil {
+			return nil, fmt.Errorf("reading %s: %w", f.Name, err)
+		}
+		header := make([]byte, hermesHeaderSize)
+		n, err := io.ReadFull(rc, header)
+		_ = rc.Close()
+		if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) && !errors.Is(err, io.EOF) {
+			return nil, fmt.Errorf("reading %s: %w", f.Name, err)
+		}
+		if check, ok := checkHermesHeader(f.Name, header[:n], f.UncompressedSize64); ok {
+			checks = append(checks, check)
+		}
+	}
+	return checks, nil
+}
+
+// checkHermesHeader validates a Hermes bytecode header against the file size.
+// It returns false when the file is not Hermes bytecode.
+func checkHermesHeader(name string, header []byte, size uint64) (HermesCheck, bool) {
+	if !bytes.HasPrefix(header, hermesMagic) {
+		return HermesCheck{}, false
+	}
+
+	check := HermesCheck{Path: name}
+	if len(header) < hermesHeaderSize {
+		check.Error = "truncated bytecode header"
+		return check, true
+	}
+
+	check.Version = binary.LittleEndian.Uint32(header[8:12])
+	if check.Version == 0 {
+		check.Error = "invalid bytecode version 0"
+		return check, true
+	}
+	if length := binary.LittleEndian.Uint32(header[32:36]); uint64(length) != size {
+		check.Error = fmt.Sprintf("header declares %d bytes, file has %d", length, size)
+	}
+	return check, true
+}
@@ -0,0 +1,150 @@
+package codepush
+
+import (
+	"context"
+	"encoding/binary"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/bitrise-io/bitrise-plugins-codepush-cli/internal/bundler"
+)
+
+// hermesBytecode returns a minimal Hermes file whose header declares size bytes.
+func hermesBytecode(version, size uint32, actual int) string {
+	data := make([]byte, actual)
+	copy(data, hermesMagic)
+	binary.LittleEndian.PutUint32(data[8:12], version)
+	binary.LittleEndian.PutUint32(data[32:36], size)
+	return string(data)
+}
+
+func TestPackageHashMatchesBundler(t *testing.T) {
+	files := map[string]string{"main.jsbundle": "bundle", "assets/img/logo.png": "png"}
+	dir := filepath.Join(t.TempDir(), "CodePush")
+	for name, content := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+	}
+	require.NoError(t, os.WriteFile(filepath.Join(dir, ".codepushrelease"), []byte("jwt"), 0o644))
+
+	want, err := bundler.ComputePackageHash(dir)
+	require.NoError(t, err)
+
+	manifest, err := ManifestFromDir(dir)
+	require.NoError(t, err)
+	got, n := PackageHash(manifest)
+	assert.Equal(t, want, got)
+	assert.Equal(t, 2, n)
+}
+
+func TestVerifyPackage(t *testing.T) {
+	expected, _ := PackageHash(FileManifest{"main.jsbundle": mustHash(t, "bundle")})
+
+	newClient := func(hash string, files map[string]string) *mockClient {
+		return &mockClient{
+			getUpdateFunc: func(appID, deploymentID, updateID string) (*Update, error) {
+				return &Update{ID: updateID, Label: "v3", Hash: hash}, nil
+			},
+			downloadFileFunc: func(fileURL string, w io.Writer) error {
+				writeZipFiles(t, w, files)
+				return nil
+			},
+		}
+	}
+	ref := UpdateRef{AppID: "app-1", DeploymentID: "dep-1", UpdateID: "pkg-3"}
+
+	t.Run("matching hash", func(t *testing.T) {
+		result, err := VerifyPackage(context.Background(), newClient(expected, map[string]string{"main.jsbundle": "bundle"}), nil, ref)
+		require.NoError(t, err)
+		assert.True(t, result.HashMatch)
+		assert.True(t, result.Valid())
+		assert.Equal(t, "v3", result.Label)
+		assert.Equal(t, 1, result.Files)
+		assert.Empty(t, result.Hermes)
+	})
+
+	t.Run("tampered package", func(t *testing.T) {
+		result, err := VerifyPackage(context.Background(), newClient(expected, map[string]string{"main.jsbundle": "evil"}), nil, ref)
+		require.NoError(t, err)
+		assert.False(t, result.HashMatch)
+		assert.False(t, result.Valid())
+		assert.NotEqual(t, result.ExpectedHash, result.ActualHash)
+	})
+
+	t.Run("validates Hermes headers", func(t *testing.T) {
+		files := map[string]string{
+			"main.jsbundle":        hermesBytecode(96, 64, 64),
+			"index.android.bundle": hermesBytecode(96, 128, 64),
+		}
+		manifest := FileManifest{}
+		for name, content := range files {
+			manifest[name] = mustHash(t, content)
+		}
+		hash, _ := PackageHash(manifest)
+
+		result, err := VerifyPackage(context.Background(), newClient(hash, files), nil, ref)
+		require.NoError(t, err)
+		assert.True(t, result.HashMatch)
+		require.Len(t, result.Hermes, 2)
+		assert.False(t, result.Valid())
+
+		byPath := map[string]HermesCheck{}
+		for _, c := range result.Hermes {
+			byPath[c.Path] = c
+		}
+		assert.Empty(t, byPath["main.jsbundle"].Error)
+		assert.Equal(t, uint32(96), byPath["main.jsbundle"].Version)
+		assert.Contains(t, byPath["index.android.bundle"].Error, "declares 128 bytes")
+	})
+
+	t.Run("release without a hash", func(t *testing.T) {
+		_, err := VerifyPackage(context.Background(), newClient("", nil), nil, ref)
+		assert.ErrorContains(t, err, "no recorded hash")
+	})
+}
+
+func TestCheckHermesHeader(t *testing.T) {
+	tests := []struct {
+		name    string
+		header  string
+		size    uint64
+		want    bool
+		wantErr string
+	}{
+		{"plain JavaScript", "var a=1;", 8, false, ""},
+		{"valid header", hermesBytecode(94, 40, 40), 40, true, ""},
+		{"truncated", string(hermesMagic) + "abc", 11, true, "truncated"},
+		{"version zero", hermesBytecode(0, 40, 40), 40, true, "version 0"},
+		{"length mismatch", hermesBytecode(94, 50, 40), 40, true, "declares 50 bytes"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			header := []byte(tt.header)
+			if len(header) > hermesHeaderSize {
+				header = header[:hermesHeaderSize]
+			}
+			check, ok := checkHermesHeader("main.jsbundle", header, tt.size)
+			assert.Equal(t, tt.want, ok)
+			if tt.wantErr == "" {
+				assert.Empty(t, check.Error)
+			} else {
+				assert.Contains(t, check.Error, tt.wantErr)
+			}
+		})
+	}
+}
+
+func mustHash(t *testing.T, content string) string {
+	t.Helper()
+	sum, err := hashReader(strings.NewReader(content))
+	require.NoError(t, err)
+	return sum
+}
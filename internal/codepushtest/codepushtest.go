@@ -0,0 +1,248 @@
+// Package codepushtest provides an in-memory implementation of the Release
+// Management API that the CLI talks to: apps, deployments, releases,
+// package upload and download, and processing status. It backs the
+// mock-server command and end-to-end tests, so workflows built on the CLI
+// can be tested without a Bitrise account.
+package codepushtest
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/bitrise-io/bitrise-plugins-codepush-cli/internal/codepush"
+)
+
+// APIPath is the path the API is served under; point the CLI's server URL
+// at the server root.
+const APIPath = "/release-management/v1"
+
+// storagePath serves uploaded packages, standing in for the signed storage
+// URLs of the real API.
+const storagePath = "/storage/"
+
+// Server is an in-memory Release Management API. The zero value is not
+// usable; create one with NewServer.
+type Server struct {
+	// Token, when set, is the only token accepted; requests with another
+	// Authorization header get 401.
+	Token string
+
+	// Scopes, when non-nil, are reported as the token's scopes in the
+	// X-Token-Scopes header, and requests other than GET need the
+	// release_management:write scope or get 403.
+	Scopes []string
+
+	// ProcessingPolls is the number of status checks that report an
+	// uploaded package as still processing before its result is reported.
+	ProcessingPolls int
+
+	// Now returns the time recorded on new releases and events.
+	Now func() time.Time
+
+	mu   sync.Mutex
+	apps []*app
+}
+
+type app struct {
+	codepush.App
+	deployments []*deployment
+}
+
+type deployment struct {
+	codepush.Deployment
+	releases  []*release
+	events    []codepush.DeploymentEvent
+	nextLabel int
+	// pending are packages with an upload URL that have not been released.
+	pending map[string]*release
+}
+
+// release is a release of a deployment, or a package being uploaded.
+type release struct {
+	codepush.Update
+	status       string
+	statusReason string
+	polls        int
+	logs         []codepush.UpdateLogEntry
+	// data is the full package zip, after a delta package is applied.
+	data []byte
+	// diffAgainst is the ID of the release a delta package applies to.
+	diffAgainst string
+}
+
+// NewServer returns an empty Server.
+func NewServer() *Server {
+	return &Server{Now: time.Now}
+}
+
+// Start serves s on a local port for the duration of the test, and returns
+// the server URL to configure the CLI with.
+func Start(t testing.TB, s *Server) string {
+	t.Helper()
+	ts := httptest.NewServer(s.Handler())
+	t.Cleanup(ts.Close)
+	return ts.URL
+}
+
+// AddApp adds a connected app and returns it with its ID set. An empty ID
+// gets a random one.
+func (s *Server) AddApp(a codepush.App) codepush.App {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if a.ID == "" {
+		a.ID = uuid.New().String()
+	}
+	s.apps = append(s.apps, &app{App: a})
+	return a
+}
+
+// AddDeployment adds a deployment named name to the app and returns it.
+func (s *Server) AddDeployment(appID, name string) (codepush.Deployment, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	a := s.findApp(appID)
+	if a == nil {
+		return codepush.Deployment{}, fmt.Errorf("app %s not found", appID)
+	}
+	d, err := s.createDeployment(a, codepush.CreateDeploymentRequest{Name: name})
+	if err != nil {
+		return codepush.Deployment{}, err
+	}
+	return d.Deployment, nil
+}
+
+// Releases returns the releases of a deployment, oldest first.
+func (s *Server) Releases(appID, deploymentID string) []codepush.Update {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	d := s.findDeployment(appID, deploymentID)
+	if d == nil {
+		return nil
+	}
+	updates := make([]codepush.Update, len(d.releases))
+	for i, r := range d.releases {
+		updates[i] = r.Update
+	}
+	return updates
+}
+
+// Package returns the full package zip of a release.
+func (s *Server) Package(appID, deploymentID, updateID string) ([]byte, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	d := s.findDeployment(appID, deploymentID)
+	if d == nil {
+		return nil, false
+	}
+	r := d.findRelease(updateID)
+	if r == nil {
+		return nil, false
+	}
+	return slices.Clone(r.data), true
+}
+
+func (s *Server) findApp(appID string) *app {
+	for _, a := range s.apps {
+		if a.ID == appID {
+			return a
+		}
+	}
+	return nil
+}
+
+func (s *Server) findDeployment(appID, deploymentID string) *deployment {
+	a := s.findApp(appID)
+	if a == nil {
+		return nil
+	}
+	for _, d := range a.deployments {
+		if d.ID == deploymentID {
+			return d
+		}
+	}
+	return nil
+}
+
+func (s *Server) createDeployment(a *app, req codepush.CreateDeploymentRequest) (*deployment, error) {
+	if req.Name == "" {
+		return nil, fmt.Errorf("deployment name is required")
+	}
+	for _, d := range a.deployments {
+		if d.Name == req.Name {
+			return nil, fmt.Errorf("deployment %q already exists", req.Name)
+		}
+	}
+	key := req.Key
+	if key == "" {
+		key = uuid.New().String()
+	}
+	d := &deployment{
+		Deployment: codepush.Deployment{
+			ID:        uuid.New().String(),
+			Name:      req.Name,
+			CreatedAt: s.timestamp(),
+			Key:       key,
+		},
+		pending: map[string]*release{},
+	}
+	a.deployments = append(a.deployments, d)
+	return d, nil
+}
+
+// view returns the deployment as the API reports it, with its latest
+// release.
+func (d *deployment) view() codepush.Deployment {
+	v := d.Deployment
+	if len(d.releases) > 0 {
+		latest := d.releases[len(d.releases)-1].Update
+		v.LatestUpdate = &latest
+	}
+	return v
+}
+
+func (d *deployment) findRelease(updateID string) *release {
+	for _, r := range d.releases {
+		if r.ID == updateID {
+			return r
+		}
+	}
+	return nil
+}
+
+// addRelease appends r as the next release of d, labelled v1, v2, ...
+func (s *Server) addRelease(d *deployment, r *release) {
+	d.nextLabel++
+	r.Label = fmt.Sprintf("v%d", d.nextLabel)
+	r.DeploymentID = d.ID
+	r.CreatedAt = s.timestamp()
+	d.releases = append(d.releases, r)
+}
+
+func (s *Server) addEvent(d *deployment, eventType string, r *release, changes map[string]any) {
+	e := codepush.DeploymentEvent{
+		ID:        uuid.New().String(),
+		Type:      eventType,
+		CreatedAt: s.timestamp(),
+		Changes:   changes,
+	}
+	if r != nil {
+		e.UpdateID, e.Label = r.ID, r.Label
+	}
+	d.events = append(d.events, e)
+}
+
+func (s *Server) timestamp() string {
+	return s.Now().UTC().Format(time.RFC3339)
+}
+
+// authorized reports whether r carries the accepted token.
+func (s *Server) authorized(r *http.Request) bool {
+	return s.Token == "" || r.Header.Get("Authorization") == s.Token
+}
@@ -0,0 +1,225 @@
+package codepushtest
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/bitrise-io/bitrise-plugins-codepush-cli/internal/codepush"
+	"github.com/bitrise-io/bitrise-plugins-codepush-cli/internal/output"
+)
+
+const testToken = "test-token"
+
+// setup starts a server with one app and Staging and Production
+// deployments, and returns a client for it.
+func setup(t *testing.T) (*Server, *codepush.HTTPClient, codepush.App, codepush.Deployment, codepush.Deployment) {
+	t.Helper()
+	s := NewServer()
+	s.Token = testToken
+	s.ProcessingPolls = 1
+	a := s.AddApp(codepush.App{Name: "Example", Platform: "ios"})
+	staging, err := s.AddDeployment(a.ID, "Staging")
+	require.NoError(t, err)
+	production, err := s.AddDeployment(a.ID, "Production")
+	require.NoError(t, err)
+	client := codepush.NewHTTPClient(Start(t, s)+APIPath, testToken, "test")
+	return s, client, a, staging, production
+}
+
+func writeBundle(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir := filepath.Join(t.TempDir(), "CodePush")
+	for name, content := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+	}
+	return dir
+}
+
+func push(t *testing.T, client codepush.Client, appID, deployment, bundle string) *codepush.PushResult {
+	t.Helper()
+	result, err := codepush.PushWithConfig(context.Background(), client, &codepush.PushOptions{
+		AppID:        appID,
+		DeploymentID: deployment,
+		Token:        testToken,
+		AppVersion:   "1.0.0",
+		Rollout:      100,
+		BundlePath:   bundle,
+	}, codepush.PollConfig{MaxAttempts: 5, Interval: time.Millisecond}, output.NewTest(io.Discard))
+	require.NoError(t, err)
+	return result
+}
+
+func TestReleaseWorkflow(t *testing.T) {
+	s, client, a, staging, production := setup(t)
+	ctx := context.Background()
+	out := output.NewTest(io.Discard)
+
+	v1 := writeBundle(t, map[string]string{"main.jsbundle": "console.log(1)", "assets/logo.png": "logo"})
+	first := push(t, client, a.ID, "Staging", v1)
+	assert.Equal(t, codepush.StatusProcessedValid, first.Status)
+	assert.Empty(t, first.DiffAgainst)
+
+	// The second push uploads a delta package, which the server applies to v1.
+	v2 := writeBundle(t, map[string]string{"main.jsbundle": "console.log(2)", "assets/logo.png": "logo"})
+	second := push(t, client, a.ID, "Staging", v2)
+	assert.Equal(t, "v1", second.DiffAgainst)
+
+	releases := s.Releases(a.ID, staging.ID)
+	require.Len(t, releases, 2)
+	assert.Equal(t, "v2", releases[1].Label)
+	wantHash, err := codepush.ContentHash(v2)
+	require.NoError(t, err)
+	assert.Equal(t, wantHash, releases[1].Hash)
+
+	verify, err := codepush.VerifyPackage(ctx, client, nil, codepush.UpdateRef{AppID: a.ID, DeploymentID: staging.ID, UpdateID: second.UpdateID})
+	require.NoError(t, err)
+	assert.True(t, verify.Valid())
+
+	promoted, err := codepush.Promote(ctx, client, &codepush.PromoteOptions{
+		AppID:              a.ID,
+		SourceDeploymentID: "Staging",
+		DestDeploymentID:   "Production",
+		Token:              testToken,
+		Rollout:            "20",
+	}, out)
+	require.NoError(t, err)
+	assert.Equal(t, "v1", promoted.Label)
+
+	_, err = client.Promote(ctx, a.ID, staging.ID, codepush.PromoteRequest{TargetDeploymentID: production.ID})
+	assert.ErrorIs(t, err, codepush.ErrDuplicateRelease)
+
+	patched, err := codepush.Patch(ctx, client, &codepush.PatchOptions{
+		AppID: a.ID, DeploymentID: "Production", Token: testToken, Rollout: "50",
+	}, out)
+	require.NoError(t, err)
+	assert.Equal(t, 50, patched.Rollout)
+
+	rolledBack, err := codepush.Rollback(ctx, client, &codepush.RollbackOptions{
+		AppID: a.ID, DeploymentID: "Staging", Token: testToken,
+	}, out)
+	require.NoError(t, err)
+	assert.Equal(t, "v3", rolledBack.Label)
+	releases = s.Releases(a.ID, staging.ID)
+	assert.Equal(t, releases[0].Hash, releases[2].Hash)
+
+	events, err := client.ListDeploymentEvents(ctx, a.ID, production.ID)
+	require.NoError(t, err)
+	require.Len(t, events, 2)
+	assert.Equal(t, "promote", events[0].Type)
+	assert.Equal(t, "patch", events[1].Type)
+	assert.Equal(t, map[string]any{"rollout": float64(50)}, events[1].Changes)
+}
+
+func TestProcessing(t *testing.T) {
+	s, client, a, staging, _ := setup(t)
+	ctx := context.Background()
+
+	t.Run("reports processing before the result", func(t *testing.T) {
+		id := "0b7f3a52-7f0e-4a3c-9d3f-6f3e0b6e2d11"
+		target, err := client.GetUploadURL(ctx, a.ID, staging.ID, id, codepush.UploadURLRequest{AppVersion: "1.0.0", FileSizeBytes: 5, Rollout: 100})
+		require.NoError(t, err)
+
+		status, err := client.GetUpdateStatus(ctx, a.ID, staging.ID, id)
+		require.NoError(t, err)
+		assert.Equal(t, codepush.StatusCreated, status.Status)
+
+		req, err := http.NewRequest(target.Method, target.URL, strings.NewReader("bogus"))
+		require.NoError(t, err)
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		resp.Body.Close()
+
+		status, err = client.GetUpdateStatus(ctx, a.ID, staging.ID, id)
+		require.NoError(t, err)
+		assert.Equal(t, codepush.StatusUploaded, status.Status)
+
+		status, err = client.GetUpdateStatus(ctx, a.ID, staging.ID, id)
+		require.NoError(t, err)
+		assert.Equal(t, codepush.StatusProcessedError, status.Status)
+		assert.Contains(t, status.StatusReason, "not a valid zip archive")
+
+		logs, err := client.ListUpdateLogs(ctx, a.ID, staging.ID, id)
+		require.NoError(t, err)
+		assert.NotEmpty(t, logs)
+		assert.Empty(t, s.Releases(a.ID, staging.ID))
+	})
+
+	t.Run("rejects other tokens", func(t *testing.T) {
+		other := codepush.NewHTTPClient(client.BaseURL, "wrong", "test")
+		_, err := other.ListApps(ctx)
+		assert.True(t, codepush.IsUnauthorized(err))
+	})
+
+	t.Run("reports missing resources", func(t *testing.T) {
+		_, err := client.GetDeployment(ctx, a.ID, "00000000-0000-0000-0000-000000000000")
+		assert.ErrorIs(t, err, codepush.ErrDeploymentNotFound)
+	})
+}
+
+func TestDeployments(t *testing.T) {
+	_, client, a, staging, _ := setup(t)
+	ctx := context.Background()
+
+	created, err := client.CreateDeployment(ctx, a.ID, codepush.CreateDeploymentRequest{Name: "QA", Key: "qa-key"})
+	require.NoError(t, err)
+	assert.Equal(t, "qa-key", created.Key)
+
+	_, err = client.CreateDeployment(ctx, a.ID, codepush.CreateDeploymentRequest{Name: "QA"})
+	assert.Error(t, err)
+
+	renamed, err := client.RenameDeployment(ctx, a.ID, staging.ID, codepush.RenameDeploymentRequest{Name: "Beta"})
+	require.NoError(t, err)
+	assert.Equal(t, "Beta", renamed.Name)
+
+	require.NoError(t, client.DeleteDeployment(ctx, a.ID, created.ID))
+	deployments, err := client.ListDeployments(ctx, a.ID)
+	require.NoError(t, err)
+	names := []string{}
+	for _, d := range deployments {
+		names = append(names, d.Name)
+	}
+	assert.Equal(t, []string{"Beta", "Production"}, names)
+}
+
+func TestScopes(t *testing.T) {
+	s := NewServer()
+	s.Scopes = []string{codepush.ScopeReleaseRead}
+	a := s.AddApp(codepush.App{Name: "Example", Platform: "ios"})
+	client := codepush.NewHTTPClient(Start(t, s)+APIPath, testToken, "test")
+	ctx := context.Background()
+
+	access, err := codepush.CheckScope(ctx, client, a.ID, codepush.ScopeReleaseRead)
+	require.NoError(t, err)
+	assert.Equal(t, []string{codepush.ScopeReleaseRead}, access.Scopes)
+
+	_, err = codepush.CheckScope(ctx, client, a.ID, codepush.ScopeReleaseWrite)
+	assert.EqualError(t, err, "token lacks scope release_management:write for app "+a.ID)
+
+	_, err = client.CreateDeployment(ctx, a.ID, codepush.CreateDeploymentRequest{Name: "QA"})
+	assert.Equal(t, http.StatusForbidden, codepush.NewErrorReport(err).StatusCode)
+}
+
+func TestClearDeployment(t *testing.T) {
+	s, client, a, staging, _ := setup(t)
+	ctx := context.Background()
+	push(t, client, a.ID, "Staging", writeBundle(t, map[string]string{"main.jsbundle": "console.log(1)"}))
+	push(t, client, a.ID, "Staging", writeBundle(t, map[string]string{"main.jsbundle": "console.log(2)"}))
+
+	updates, err := client.ListUpdates(ctx, a.ID, staging.ID)
+	require.NoError(t, err)
+	n, err := codepush.ClearDeployment(ctx, client, a.ID, staging.ID, updates, 0, output.NewTest(io.Discard))
+	require.NoError(t, err)
+	assert.Equal(t, 2, n)
+	assert.Empty(t, s.Releases(a.ID, staging.ID))
+}
@@ -0,0 +1,684 @@
+package codepushtest
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/google/uuid"
+
+	"github.com/bitrise-io/bitrise-plugins-codepush-cli/internal/codepush"
+)
+
+// authPath is the token validation endpoint used by auth login and ping.
+const authPath = "/v0.1/me"
+
+// Handler returns the HTTP handler of the API.
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	deployments := APIPath + "/connected-apps/{app}/code-push/deployments"
+	packages := deployments + "/{deployment}/packages"
+
+	routes := map[string]http.HandlerFunc{
+		"GET " + APIPath + "/connected-apps":             s.listApps,
+		"GET " + APIPath + "/connected-apps/{app}":       s.getApp,
+		"GET " + deployments:                             s.listDeployments,
+		"POST " + deployments:                            s.createDeploymentHandler,
+		"GET " + deployments + "/{deployment}":           s.getDeployment,
+		"PATCH " + deployments + "/{deployment}":         s.renameDeployment,
+		"DELETE " + deployments + "/{deployment}":        s.deleteDeployment,
+		"GET " + deployments + "/{deployment}/metrics":   s.deploymentMetrics,
+		"GET " + deployments + "/{deployment}/events":    s.deploymentEvents,
+		"POST " + deployments + "/{deployment}/rollback": s.rollback,
+		"POST " + deployments + "/{deployment}/promote":  s.promote,
+		"GET " + packages:                                s.listPackages,
+		"DELETE " + packages:                             s.deletePackages,
+		"GET " + packages + "/{package}":                 s.getPackage,
+		"PATCH " + packages + "/{package}":               s.patchPackage,
+		"DELETE " + packages + "/{package}":              s.deletePackage,
+		"GET " + packages + "/{package}/upload-url":      s.uploadURL,
+		"GET " + packages + "/{package}/download-url":    s.downloadURL,
+		"GET " + packages + "/{package}/status":          s.packageStatus,
+		"GET " + packages + "/{package}/logs":            s.packageLogs,
+		"GET " + authPath:                                s.me,
+	}
+	for pattern, handler := range routes {
+		mux.Handle(pattern, s.withAuth(handler))
+	}
+	mux.HandleFunc("PUT "+storagePath+"{app}/{deployment}/{package}", s.upload)
+	mux.HandleFunc("GET "+storagePath+"{app}/{deployment}/{package}", s.download)
+	return mux
+}
+
+// withAuth rejects requests without the accepted token or the needed scope,
+// and serializes the requests that pass.
+func (s *Server) withAuth(next http.HandlerFunc) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !s.authorized(r) {
+			writeError(w, http.StatusUnauthorized, "ERR_UNAUTHORIZED", "invalid API token")
+			return
+		}
+		if s.Scopes != nil {
+			w.Header().Set("X-Token-Scopes", strings.Join(s.Scopes, ","))
+			if r.Method != http.MethodGet && !slices.Contains(s.Scopes, codepush.ScopeReleaseWrite) {
+				writeError(w, http.StatusForbidden, "ERR_FORBIDDEN", "token lacks scope "+codepush.ScopeReleaseWrite)
+				return
+			}
+		}
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		next(w, r)
+	})
+}
+
+func (s *Server) me(w http.ResponseWriter, _ *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]any{"data": map[string]string{
+		"username": "codepush-mock",
+		"email":    "codepush-mock@example.com",
+	}})
+}
+
+func (s *Server) listApps(w http.ResponseWriter, r *http.Request) {
+	workspace := r.URL.Query().Get("workspace_slug")
+	apps := []codepush.App{}
+	for _, a := range s.apps {
+		if workspace == "" || a.WorkspaceSlug == workspace {
+			apps = append(apps, a.App)
+		}
+	}
+	writeJSON(w, http.StatusOK, codepush.AppListResponse{Items: apps})
+}
+
+func (s *Server) getApp(w http.ResponseWriter, r *http.Request) {
+	a := s.findApp(r.PathValue("app"))
+	if a == nil {
+		writeNotFound(w, "app")
+		return
+	}
+	writeJSON(w, http.StatusOK, a.App)
+}
+
+func (s *Server) listDeployments(w http.ResponseWriter, r *http.Request) {
+	a := s.findApp(r.PathValue("app"))
+	if a == nil {
+		writeNotFound(w, "app")
+		return
+	}
+	items := make([]codepush.Deployment, len(a.deployments))
+	for i, d := range a.deployments {
+		items[i] = d.view()
+	}
+	writeJSON(w, http.StatusOK, codepush.DeploymentListResponse{Items: items})
+}
+
+func (s *Server) createDeploymentHandler(w http.ResponseWriter, r *http.Request) {
+	a := s.findApp(r.PathValue("app"))
+	if a == nil {
+		writeNotFound(w, "app")
+		return
+	}
+	var req codepush.CreateDeploymentRequest
+	if !readJSON(w, r, &req) {
+		return
+	}
+	d, err := s.createDeployment(a, req)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "ERR_BAD_REQUEST", err.Error())
+		return
+	}
+	writeJSON(w, http.StatusCreated, d.view())
+}
+
+func (s *Server) getDeployment(w http.ResponseWriter, r *http.Request) {
+	d := s.deploymentOf(w, r)
+	if d == nil {
+		return
+	}
+	writeJSON(w, http.StatusOK, d.view())
+}
+
+func (s *Server) renameDeployment(w http.ResponseWriter, r *http.Request) {
+	d := s.deploymentOf(w, r)
+	if d == nil {
+		return
+	}
+	var req codepush.RenameDeploymentRequest
+	if !readJSON(w, r, &req) {
+		return
+	}
+	if req.Name == "" {
+		writeError(w, http.StatusBadRequest, "ERR_BAD_REQUEST", "deployment name is required")
+		return
+	}
+	for _, other := range s.findApp(r.PathValue("app")).deployments {
+		if other != d && other.Name == req.Name {
+			writeError(w, http.StatusConflict, "ERR_CONFLICT", fmt.Sprintf("deployment %q already exists", req.Name))
+			return
+		}
+	}
+	d.Name = req.Name
+	writeJSON(w, http.StatusOK, d.view())
+}
+
+func (s *Server) deleteDeployment(w http.ResponseWriter, r *http.Request) {
+	d := s.deploymentOf(w, r)
+	if d == nil {
+		return
+	}
+	a := s.findApp(r.PathValue("app"))
+	for i, other := range a.deployments {
+		if other == d {
+			a.deployments = append(a.deployments[:i], a.deployments[i+1:]...)
+			break
+		}
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// deploymentMetrics reports every release with no installs: the mock has
+// no devices.
+func (s *Server) deploymentMetrics(w http.ResponseWriter, r *http.Request) {
+	d := s.deploymentOf(w, r)
+	if d == nil {
+		return
+	}
+	items := make([]codepush.UpdateMetrics, len(d.releases))
+	for i, rel := range d.releases {
+		items[i] = codepush.UpdateMetrics{Label: rel.Label}
+	}
+	writeJSON(w, http.StatusOK, codepush.DeploymentMetricsResponse{Items: items})
+}
+
+func (s *Server) deploymentEvents(w http.ResponseWriter, r *http.Request) {
+	d := s.deploymentOf(w, r)
+	if d == nil {
+		return
+	}
+	writeJSON(w, http.StatusOK, codepush.DeploymentEventListResponse{Items: append([]codepush.DeploymentEvent{}, d.events...)})
+}
+
+func (s *Server) listPackages(w http.ResponseWriter, r *http.Request) {
+	d := s.deploymentOf(w, r)
+	if d == nil {
+		return
+	}
+	items := make([]codepush.Update, len(d.releases))
+	for i, rel := range d.releases {
+		items[i] = rel.Update
+	}
+	writeJSON(w, http.StatusOK, codepush.UpdateListResponse{Items: items})
+}
+
+func (s *Server) getPackage(w http.ResponseWriter, r *http.Request) {
+	_, rel := s.releaseOf(w, r)
+	if rel == nil {
+		return
+	}
+	writeJSON(w, http.StatusOK, rel.Update)
+}
+
+func (s *Server) patchPackage(w http.ResponseWriter, r *http.Request) {
+	d, rel := s.releaseOf(w, r)
+	if rel == nil {
+		return
+	}
+	var req codepush.PatchRequest
+	if !readJSON(w, r, &req) {
+		return
+	}
+
+	changes := map[string]any{}
+	if req.Rollout != nil {
+		if *req.Rollout < 0 || *req.Rollout > 100 {
+			writeError(w, http.StatusBadRequest, "ERR_BAD_REQUEST", "rollout must be between 0 and 100")
+			return
+		}
+		rel.Rollout = float64(*req.Rollout)
+		changes["rollout"] = *req.Rollout
+	}
+	if req.Mandatory != nil {
+		rel.Mandatory = *req.Mandatory
+		changes["mandatory"] = *req.Mandatory
+	}
+	if req.Disabled != nil {
+		rel.Disabled = *req.Disabled
+		changes["disabled"] = *req.Disabled
+	}
+	if req.Description != nil {
+		rel.Description = *req.Description
+		changes["description"] = *req.Description
+	}
+	if req.AppVersion != nil {
+		rel.AppVersion = *req.AppVersion
+		changes["app_version"] = *req.AppVersion
+	}
+	if req.TargetOSVersion != nil {
+		rel.OSVersion = *req.TargetOSVersion
+		changes["target_os_version"] = *req.TargetOSVersion
+	}
+	if req.TargetDeviceModels != nil {
+		rel.DeviceModels = *req.TargetDeviceModels
+		changes["target_device_models"] = *req.TargetDeviceModels
+	}
+	if req.TargetCountries != nil {
+		rel.Countries = *req.TargetCountries
+		changes["target_countries"] = *req.TargetCountries
+	}
+	s.addEvent(d, "patch", rel, changes)
+	writeJSON(w, http.StatusOK, rel.Update)
+}
+
+func (s *Server) deletePackage(w http.ResponseWriter, r *http.Request) {
+	d, rel := s.releaseOf(w, r)
+	if rel == nil {
+		return
+	}
+	for i, other := range d.releases {
+		if other == rel {
+			d.releases = append(d.releases[:i], d.releases[i+1:]...)
+			break
+		}
+	}
+	s.addEvent(d, "delete", rel, nil)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// deletePackages deletes all releases of a deployment.
+func (s *Server) deletePackages(w http.ResponseWriter, r *http.Request) {
+	d := s.deploymentOf(w, r)
+	if d == nil {
+		return
+	}
+	deleted := d.releases
+	d.releases = nil
+	for _, rel := range deleted {
+		s.addEvent(d, "delete", rel, nil)
+	}
+	writeJSON(w, http.StatusOK, codepush.DeleteUpdatesResponse{Deleted: len(deleted)})
+}
+
+// rollback adds a release copying the requested release, or the one before
+// the latest.
+func (s *Server) rollback(w http.ResponseWriter, r *http.Request) {
+	d := s.deploymentOf(w, r)
+	if d == nil {
+		return
+	}
+	var req codepush.RollbackRequest
+	if !readJSON(w, r, &req) {
+		return
+	}
+
+	var target *release
+	switch {
+	case req.UpdateID != "":
+		if target = d.findRelease(req.UpdateID); target == nil {
+			writeNotFound(w, "package")
+			return
+		}
+	case len(d.releases) < 2:
+		writeError(w, http.StatusBadRequest, "ERR_BAD_REQUEST", "the deployment has no release to roll back to")
+		return
+	default:
+		target = d.releases[len(d.releases)-2]
+	}
+
+	rel := s.copyRelease(target)
+	s.addRelease(d, rel)
+	s.addEvent(d, "rollback", rel, map[string]any{"restored": target.Label})
+	writeJSON(w, http.StatusCreated, rel.Update)
+}
+
+// promote adds a release to the target deployment copying the requested
+// release of the deployment, or its latest, with the request's overrides.
+func (s *Server) promote(w http.ResponseWriter, r *http.Request) {
+	d := s.deploymentOf(w, r)
+	if d == nil {
+		return
+	}
+	var req codepush.PromoteRequest
+	if !readJSON(w, r, &req) {
+		return
+	}
+	target := s.findDeployment(r.PathValue("app"), req.TargetDeploymentID)
+	if target == nil {
+		writeNotFound(w, "target deployment")
+		return
+	}
+
+	var source *release
+	switch {
+	case req.UpdateID != "":
+		if source = d.findRelease(req.UpdateID); source == nil {
+			writeNotFound(w, "package")
+			return
+		}
+	case len(d.releases) == 0:
+		writeError(w, http.StatusBadRequest, "ERR_BAD_REQUEST", "the source deployment has no release to promote")
+		return
+	default:
+		source = d.releases[len(d.releases)-1]
+	}
+	if n := len(target.releases); n > 0 && target.releases[n-1].Hash == source.Hash {
+		writeError(w, http.StatusBadRequest, "ERR_BAD_REQUEST",
+			"the release is identical to the contents of the latest release in the target deployment")
+		return
+	}
+
+	rel := s.copyRelease(source)
+	if req.AppVersion != "" {
+		rel.AppVersion = req.AppVersion
+	}
+	if req.Description != "" {
+		rel.Description = req.Description
+	}
+	if req.Mandatory != "" {
+		rel.Mandatory = req.Mandatory == "true"
+	}
+	if req.Disabled != "" {
+		rel.Disabled = req.Disabled == "true"
+	}
+	if req.Rollout != "" {
+		rollout, err := strconv.Atoi(req.Rollout)
+		if err != nil || rollout < 0 || rollout > 100 {
+			writeError(w, http.StatusBadRequest, "ERR_BAD_REQUEST", "rollout must be between 0 and 100")
+			return
+		}
+		rel.Rollout = float64(rollout)
+	}
+	s.addRelease(target, rel)
+	s.addEvent(target, "promote", rel, map[string]any{"source_deployment_id": d.ID, "source_label": source.Label})
+	writeJSON(w, http.StatusCreated, rel.Update)
+}
+
+// uploadURL registers a package and returns the URL to upload it to. The
+// mock never offers a multipart upload.
+func (s *Server) uploadURL(w http.ResponseWriter, r *http.Request) {
+	d := s.deploymentOf(w, r)
+	if d == nil {
+		return
+	}
+	updateID := r.PathValue("package")
+	if _, err := uuid.Parse(updateID); err != nil {
+		writeError(w, http.StatusBadRequest, "ERR_BAD_REQUEST", "package ID must be a UUID")
+		return
+	}
+	if d.findRelease(updateID) != nil || d.pending[updateID] != nil {
+		writeError(w, http.StatusConflict, "ERR_CONFLICT", "package already exists")
+		return
+	}
+
+	q := r.URL.Query()
+	size, err := strconv.ParseInt(q.Get("file_size_bytes"), 10, 64)
+	if err != nil || size <= 0 {
+		writeError(w, http.StatusBadRequest, "ERR_BAD_REQUEST", "file_size_bytes must be a positive number")
+		return
+	}
+	if q.Get("app_version") == "" {
+		writeError(w, http.StatusBadRequest, "ERR_BAD_REQUEST", "app_version is required")
+		return
+	}
+	rollout := 100
+	if v := q.Get("rollout"); v != "" {
+		if rollout, err = strconv.Atoi(v); err != nil || rollout < 0 || rollout > 100 {
+			writeError(w, http.StatusBadRequest, "ERR_BAD_REQUEST", "rollout must be between 0 and 100")
+			return
+		}
+	}
+	diffAgainst := q.Get("diff_against")
+	if diffAgainst != "" && d.findRelease(diffAgainst) == nil {
+		writeError(w, http.StatusBadRequest, "ERR_BAD_REQUEST", "diff_against is not a release of the deployment")
+		return
+	}
+
+	rel := &release{
+		Update: codepush.Update{
+			ID:            updateID,
+			AppVersion:    q.Get("app_version"),
+			Description:   q.Get("description"),
+			Mandatory:     q.Get("mandatory") == "true",
+			Disabled:      q.Get("disabled") == "true",
+			Rollout:       float64(rollout),
+			DeploymentID:  d.ID,
+			FileSizeBytes: size,
+			FileName:      q.Get("file_name"),
+			Provenance:    provenanceFromQuery(q),
+			Targeting: codepush.Targeting{
+				OSVersion:    q.Get("target_os_version"),
+				DeviceModels: q["target_device_models"],
+				Countries:    q["target_countries"],
+			},
+		},
+		status:      codepush.StatusCreated,
+		diffAgainst: diffAgainst,
+	}
+	d.pending[updateID] = rel
+
+	writeJSON(w, http.StatusOK, codepush.UploadURLResponse{
+		URL:     s.storageURL(r, d, updateID),
+		Method:  http.MethodPut,
+		Headers: codepush.HeaderMap{"Content-Type": "application/zip"},
+	})
+}
+
+func (s *Server) downloadURL(w http.ResponseWriter, r *http.Request) {
+	d, rel := s.releaseOf(w, r)
+	if rel == nil {
+		return
+	}
+	writeJSON(w, http.StatusOK, codepush.DownloadURLResponse{URL: s.storageURL(r, d, rel.ID)})
+}
+
+func (s *Server) packageStatus(w http.ResponseWriter, r *http.Request) {
+	d := s.deploymentOf(w, r)
+	if d == nil {
+		return
+	}
+	rel := s.anyRelease(d, r.PathValue("package"))
+	if rel == nil {
+		writeNotFound(w, "package")
+		return
+	}
+
+	status := codepush.UpdateStatus{UpdateID: rel.ID, Status: rel.status, StatusReason: rel.statusReason}
+	if rel.status != codepush.StatusCreated && rel.polls < s.ProcessingPolls {
+		rel.polls++
+		status = codepush.UpdateStatus{UpdateID: rel.ID, Status: codepush.StatusUploaded}
+	}
+	writeJSON(w, http.StatusOK, status)
+}
+
+func (s *Server) packageLogs(w http.ResponseWriter, r *http.Request) {
+	d := s.deploymentOf(w, r)
+	if d == nil {
+		return
+	}
+	rel := s.anyRelease(d, r.PathValue("package"))
+	if rel == nil {
+		writeNotFound(w, "package")
+		return
+	}
+	writeJSON(w, http.StatusOK, codepush.UpdateLogListResponse{Items: append([]codepush.UpdateLogEntry{}, rel.logs...)})
+}
+
+// upload stores a package and processes it right away: the release is
+// listed as soon as the upload finishes, while its status may still report
+// processing for ProcessingPolls checks.
+func (s *Server) upload(w http.ResponseWriter, r *http.Request) {
+	data, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, "reading upload: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	d := s.findDeployment(r.PathValue("app"), r.PathValue("deployment"))
+	if d == nil {
+		http.Error(w, "no such upload", http.StatusNotFound)
+		return
+	}
+	rel := d.pending[r.PathValue("package")]
+	if rel == nil || rel.status != codepush.StatusCreated {
+		http.Error(w, "no such upload", http.StatusNotFound)
+		return
+	}
+
+	s.log(rel, "info", fmt.Sprintf("Received package of %d bytes", len(data)))
+	if err := s.process(d, rel, data); err != nil {
+		rel.status, rel.statusReason = codepush.StatusProcessedError, err.Error()
+		s.log(rel, "error", "Processing failed: "+err.Error())
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+// process validates an uploaded package, applies it to its base release
+// when it is a delta package, and releases it.
+func (s *Server) process(d *deployment, rel *release, data []byte) error {
+	if int64(len(data)) != rel.FileSizeBytes {
+		return fmt.Errorf("uploaded %d bytes, expected %d", len(data), rel.FileSizeBytes)
+	}
+	if rel.diffAgainst != "" {
+		base := d.findRelease(rel.diffAgainst)
+		if base == nil {
+			return fmt.Errorf("the base release of the delta package was deleted")
+		}
+		full, err := applyDelta(base.data, data)
+		if err != nil {
+			return err
+		}
+		s.log(rel, "info", "Applied delta package against "+base.Label)
+		data = full
+	}
+
+	hash, err := packageHash(data)
+	if err != nil {
+		return err
+	}
+	rel.Hash = hash
+	rel.data = data
+	rel.status = codepush.StatusProcessedValid
+	delete(d.pending, rel.ID)
+	s.addRelease(d, rel)
+	s.addEvent(d, "release", rel, nil)
+	s.log(rel, "info", "Released as "+rel.Label)
+	return nil
+}
+
+func (s *Server) download(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	d := s.findDeployment(r.PathValue("app"), r.PathValue("deployment"))
+	if d == nil {
+		http.Error(w, "no such package", http.StatusNotFound)
+		return
+	}
+	rel := d.findRelease(r.PathValue("package"))
+	if rel == nil {
+		http.Error(w, "no such package", http.StatusNotFound)
+		return
+	}
+	w.Header().Set("Content-Type", "application/zip")
+	w.Header().Set("Content-Length", strconv.Itoa(len(rel.data)))
+	_, _ = w.Write(rel.data)
+}
+
+// copyRelease returns a new release with the content and metadata of rel.
+func (s *Server) copyRelease(rel *release) *release {
+	c := &release{Update: rel.Update, status: codepush.StatusProcessedValid, data: rel.data}
+	c.ID = uuid.New().String()
+	c.CreatedBy = nil
+	return c
+}
+
+func (s *Server) log(rel *release, level, message string) {
+	rel.logs = append(rel.logs, codepush.UpdateLogEntry{Timestamp: s.timestamp(), Level: level, Message: message})
+}
+
+func (s *Server) storageURL(r *http.Request, d *deployment, updateID string) string {
+	scheme := "http"
+	if r.TLS != nil {
+		scheme = "https"
+	}
+	return fmt.Sprintf("%s://%s%s%s/%s/%s", scheme, r.Host, storagePath, r.PathValue("app"), d.ID, updateID)
+}
+
+// deploymentOf returns the deployment of the request, writing 404 when it
+// does not exist.
+func (s *Server) deploymentOf(w http.ResponseWriter, r *http.Request) *deployment {
+	d := s.findDeployment(r.PathValue("app"), r.PathValue("deployment"))
+	if d == nil {
+		writeNotFound(w, "deployment")
+	}
+	return d
+}
+
+// releaseOf returns the release of the request and its deployment, writing
+// 404 when either does not exist.
+func (s *Server) releaseOf(w http.ResponseWriter, r *http.Request) (*deployment, *release) {
+	d := s.deploymentOf(w, r)
+	if d == nil {
+		return nil, nil
+	}
+	rel := d.findRelease(r.PathValue("package"))
+	if rel == nil {
+		writeNotFound(w, "package")
+		return nil, nil
+	}
+	return d, rel
+}
+
+// anyRelease returns the release or pending upload with the given ID.
+func (s *Server) anyRelease(d *deployment, updateID string) *release {
+	if rel := d.findRelease(updateID); rel != nil {
+		return rel
+	}
+	return d.pending[updateID]
+}
+
+func provenanceFromQuery(q map[string][]string) *codepush.Provenance {
+	get := func(key string) string {
+		if v := q[key]; len(v) > 0 {
+			return v[0]
+		}
+		return ""
+	}
+	p := codepush.Provenance{
+		BuildNumber: get("build_number"),
+		BuildURL:    get("build_url"),
+		CommitHash:  get("commit_hash"),
+		Branch:      get("branch"),
+		Workflow:    get("workflow"),
+		Dirty:       get("dirty") == "true",
+	}
+	if p == (codepush.Provenance{}) {
+		return nil
+	}
+	return &p
+}
+
+func readJSON(w http.ResponseWriter, r *http.Request, v any) bool {
+	if err := json.NewDecoder(r.Body).Decode(v); err != nil && err != io.EOF {
+		writeError(w, http.StatusBadRequest, "ERR_BAD_REQUEST", "invalid request body: "+err.Error())
+		return false
+	}
+	return true
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, code, message string) {
+	writeJSON(w, status, map[string]string{"code": code, "message": message})
+}
+
+func writeNotFound(w http.ResponseWriter, what string) {
+	writeError(w, http.StatusNotFound, "ERR_NOT_FOUND", strings.ToUpper(what[:1])+what[1:]+" not found")
+}
@@ -0,0 +1,115 @@
+package codepushtest
+
+import (
+	"archive/zip"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+
+	"github.com/bitrise-io/bitrise-plugins-codepush-cli/internal/codepush"
+)
+
+// packageHash returns the content hash of a package zip, the one the API
+// records for a release.
+func packageHash(data []byte) (string, error) {
+	files, err := readPackage(data)
+	if err != nil {
+		return "", err
+	}
+	if len(files) == 0 {
+		return "", fmt.Errorf("package is empty")
+	}
+	m := codepush.FileManifest{}
+	for name, content := range files {
+		sum := sha256.Sum256(content)
+		m[name] = hex.EncodeToString(sum[:])
+	}
+	hash, _ := codepush.PackageHash(m)
+	return hash, nil
+}
+
+// applyDelta returns the full package of a delta package applied to the
+// base package: files listed in the delta's diff manifest are deleted and
+// every other entry replaces or adds a file.
+func applyDelta(base, delta []byte) ([]byte, error) {
+	files, err := readPackage(base)
+	if err != nil {
+		return nil, fmt.Errorf("base release: %w", err)
+	}
+	changes, err := readPackage(delta)
+	if err != nil {
+		return nil, err
+	}
+
+	if manifest, ok := changes[codepush.DiffManifestFile]; ok {
+		var parsed struct {
+			DeletedFiles []string `json:"deletedFiles"`
+		}
+		if err := json.Unmarshal(manifest, &parsed); err != nil {
+			return nil, fmt.Errorf("reading %s: %w", codepush.DiffManifestFile, err)
+		}
+		for _, name := range parsed.DeletedFiles {
+			delete(files, name)
+		}
+		delete(changes, codepush.DiffManifestFile)
+	}
+	for name, content := range changes {
+		files[name] = content
+	}
+	return writePackage(files)
+}
+
+// readPackage returns the contents of every file in a package zip.
+func readPackage(data []byte) (map[string][]byte, error) {
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, fmt.Errorf("package is not a valid zip archive: %w", err)
+	}
+	files := map[string][]byte{}
+	for _, f := range zr.File {
+		if strings.HasSuffix(f.Name, "/") {
+			continue
+		}
+		rc, err := f.Open()
+		if err != nil {
+			return nil, fmt.Errorf("reading %s: %w", f.Name, err)
+		}
+		content, err := io.ReadAll(rc)
+		_ = rc.Close()
+		if err != nil {
+			return nil, fmt.Errorf("reading %s: %w", f.Name, err)
+		}
+		files[f.Name] = content
+	}
+	return files, nil
+}
+
+// writePackage zips files, sorted by name.
+func writePackage(files map[string][]byte) ([]byte, error) {
+	names := make([]string, 0, len(files))
+	for name := range files {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for _, name := range names {
+		fw, err := zw.Create(name)
+		if err != nil {
+			return nil, fmt.Errorf("writing %s: %w", name, err)
+		}
+		if _, err := fw.Write(files[name]); err != nil {
+			return nil, fmt.Errorf("writing %s: %w", name, err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		return nil, fmt.Errorf("writing package: %w", err)
+	}
+	return buf.Bytes(), nil
+}
@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 )
 
 // FileName is the project-level config file name.
@@ -15,8 +16,66 @@ const FileName = ".codepush.json"
 // ProjectConfig represents the project-level configuration file.
 type ProjectConfig struct {
 	AppID         string `json:"app_id"`
+	Deployment    string `json:"deployment,omitempty"`
 	ServerURL     string `json:"server_url,omitempty"`
+	APIURL        string `json:"api_url,omitempty"`
 	ProgressStyle string `json:"progress_style,omitempty"`
+
+	SourcemapPolicy *SourcemapPolicy `json:"sourcemap_policy,omitempty"`
+
+	// ProtectedDeployments are the deployments a promote to requires typed
+	// confirmation for. Unset means DefaultProtectedDeployments; an empty
+	// list protects none.
+	ProtectedDeployments []string `json:"protected_deployments,omitempty"`
+
+	// DeploymentDefaults holds push defaults per deployment name, matched
+	// case-insensitively.
+	DeploymentDefaults map[string]DeploymentDefaults `json:"deployment_defaults,omitempty"`
+
+	Policy *Policy `json:"policy,omitempty"`
+
+	FreezeWindows []FreezeWindow `json:"freeze_windows,omitempty"`
+}
+
+// DefaultProtectedDeployments are protected when the config does not list
+// any.
+var DefaultProtectedDeployments = []string{"Production"}
+
+// SourcemapPolicy requires pushes to the listed deployments to include a
+// sourcemap, so production crashes can always be symbolicated.
+type SourcemapPolicy struct {
+	// Deployments are the names of production-like deployments, matched
+	// case-insensitively.
+	Deployments []string `json:"deployments"`
+}
+
+// RequiresSourcemap reports whether pushes to the named deployment must
+// include a sourcemap.
+func (c *ProjectConfig) RequiresSourcemap(deployment string) bool {
+	if c == nil || c.SourcemapPolicy == nil {
+		return false
+	}
+	for _, name := range c.SourcemapPolicy.Deployments {
+		if strings.EqualFold(name, deployment) {
+			return true
+		}
+	}
+	return false
+}
+
+// IsProtected reports whether promoting to the named deployment requires
+// typed confirmation. Names are matched case-insensitively.
+func (c *ProjectConfig) IsProtected(deployment string) bool {
+	protected := DefaultProtectedDeployments
+	if c != nil && c.ProtectedDeployments != nil {
+		protected = c.ProtectedDeployments
+	}
+	for _, name := range protected {
+		if strings.EqualFold(name, deployment) {
+			return true
+		}
+	}
+	return false
 }
 
 // configDirFunc allows tests to override the directory where the config file is read from.
@@ -26,15 +85,16 @@ func defaultConfigDir() (string, error) {
 	return os.Getwd()
 }
 
-// Load reads the project config from the current directory.
+// Load reads the project config. The file is looked up in the current
+// directory and its parents, up to the project root (see FilePath).
 // Returns (nil, nil) if the file does not exist.
 func Load() (*ProjectConfig, error) {
-	dir, err := configDirFunc()
+	path, err := FilePath()
 	if err != nil {
-		return nil, fmt.Errorf("determining working directory: %w", err)
+		return nil, err
 	}
 
-	data, err := os.ReadFile(filepath.Join(dir, FileName))
+	data, err := os.ReadFile(path)
 	if err != nil {
 		if errors.Is(err, os.ErrNotExist) {
 			return nil, nil //nolint:nilnil // no config file is a valid state
@@ -65,11 +125,39 @@ func Save(dir string, cfg *ProjectConfig) error {
 	return nil
 }
 
-// FilePath returns the path of the config file in the current directory.
+// FilePath returns the path of the project config file: the nearest
+// .codepush.json in the current directory or its parents, stopping at the
+// project root. If there is none, it returns the path in the project root,
+// where init creates the file.
 func FilePath() (string, error) {
 	dir, err := configDirFunc()
 	if err != nil {
 		return "", fmt.Errorf("determining working directory: %w", err)
 	}
-	return filepath.Join(dir, FileName), nil
+
+	for d := dir; ; {
+		path := filepath.Join(d, FileName)
+		if _, err := os.Stat(path); err == nil {
+			return path, nil
+		}
+		if isProjectRoot(d) {
+			return path, nil
+		}
+		parent := filepath.Dir(d)
+		if parent == d {
+			return filepath.Join(dir, FileName), nil
+		}
+		d = parent
+	}
+}
+
+// isProjectRoot reports whether dir contains a package.json or is the root
+// of a git repository.
+func isProjectRoot(dir string) bool {
+	for _, marker := range []string{"package.json", ".git"} {
+		if _, err := os.Stat(filepath.Join(dir, marker)); err == nil {
+			return true
+		}
+	}
+	return false
 }
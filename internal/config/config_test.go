@@ -164,3 +164,86 @@ func TestFilePath(t *testing.T) {
 	want := filepath.Join(dir, FileName)
 	assert.Equal(t, want, got)
 }
+
+func TestFilePathProjectRoot(t *testing.T) {
+	t.Run("finds config in a parent directory", func(t *testing.T) {
+		root := setupTestDir(t)
+		require.NoError(t, os.WriteFile(filepath.Join(root, FileName), []byte(`{"app_id":"parent-app","deployment":"Staging"}`), 0o644))
+		sub := filepath.Join(root, "src", "screens")
+		require.NoError(t, os.MkdirAll(sub, 0o755))
+		configDirFunc = func() (string, error) { return sub, nil }
+
+		got, err := FilePath()
+		require.NoError(t, err)
+		assert.Equal(t, filepath.Join(root, FileName), got)
+
+		cfg, err := Load()
+		require.NoError(t, err)
+		require.NotNil(t, cfg)
+		assert.Equal(t, "parent-app", cfg.AppID)
+		assert.Equal(t, "Staging", cfg.Deployment)
+	})
+
+	t.Run("stops at the nearest package.json", func(t *testing.T) {
+		root := setupTestDir(t)
+		require.NoError(t, os.WriteFile(filepath.Join(root, FileName), []byte(`{"app_id":"outer"}`), 0o644))
+		app := filepath.Join(root, "packages", "app")
+		sub := filepath.Join(app, "src")
+		require.NoError(t, os.MkdirAll(sub, 0o755))
+		require.NoError(t, os.WriteFile(filepath.Join(app, "package.json"), []byte(`{}`), 0o644))
+		configDirFunc = func() (string, error) { return sub, nil }
+
+		got, err := FilePath()
+		require.NoError(t, err)
+		assert.Equal(t, filepath.Join(app, FileName), got)
+
+		cfg, err := Load()
+		require.NoError(t, err)
+		assert.Nil(t, cfg)
+	})
+}
+
+func TestRequiresSourcemap(t *testing.T) {
+	cfg := &ProjectConfig{SourcemapPolicy: &SourcemapPolicy{Deployments: []string{"Production", "hotfix"}}}
+
+	tests := []struct {
+		name       string
+		cfg        *ProjectConfig
+		deployment string
+		want       bool
+	}{
+		{"listed deployment", cfg, "Production", true},
+		{"matches case-insensitively", cfg, "HOTFIX", true},
+		{"unlisted deployment", cfg, "Staging", false},
+		{"no policy", &ProjectConfig{AppID: "x"}, "Production", false},
+		{"nil config", nil, "Production", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, tt.cfg.RequiresSourcemap(tt.deployment))
+		})
+	}
+}
+
+func TestIsProtected(t *testing.T) {
+	tests := []struct {
+		name       string
+		cfg        *ProjectConfig
+		deployment string
+		want       bool
+	}{
+		{"default protects Production", nil, "Production", true},
+		{"default matches case-insensitively", &ProjectConfig{AppID: "x"}, "production", true},
+		{"default leaves others unprotected", nil, "Staging", false},
+		{"configured list", &ProjectConfig{ProtectedDeployments: []string{"Prod-EU", "Prod-US"}}, "prod-us", true},
+		{"configured list replaces default", &ProjectConfig{ProtectedDeployments: []string{"Prod-EU"}}, "Production", false},
+		{"empty list protects none", &ProjectConfig{ProtectedDeployments: []string{}}, "Production", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, tt.cfg.IsProtected(tt.deployment))
+		})
+	}
+}
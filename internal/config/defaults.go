@@ -0,0 +1,78 @@
+package config
+
+import "strings"
+
+// DeploymentDefaults are push settings declared for one deployment, so
+// workflows do not have to repeat them as flags. Flags and environment
+// variables take precedence; unset fields keep the built-in defaults.
+type DeploymentDefaults struct {
+	Rollout   *int  `json:"rollout,omitempty"`
+	Mandatory *bool `json:"mandatory,omitempty"`
+	// Description is used when no description flag is given. Like
+	// --description, it may be a template such as "{{.CommitCount}}
+	// change(s)".
+	Description string `json:"description,omitempty"`
+	// DetectAppVersion reads the app version from the native project when
+	// --app-version is not given, like --detect-app-version.
+	DetectAppVersion *bool `json:"detect_app_version,omitempty"`
+	// Hermes is the Hermes mode for --bundle: auto, on, or off.
+	Hermes string `json:"hermes,omitempty"`
+}
+
+// DefaultsFor returns the defaults for a push to the named deployments,
+// matched case-insensitively. With several deployments, a setting only
+// applies when all of them declare the same value; the JSON names of the
+// settings that differ are returned as conflicts and left unset.
+func (c *ProjectConfig) DefaultsFor(deployments []string) (DeploymentDefaults, []string) {
+	if c == nil || len(c.DeploymentDefaults) == 0 || len(deployments) == 0 {
+		return DeploymentDefaults{}, nil
+	}
+
+	merged := c.deploymentDefaults(deployments[0])
+	var conflicts []string
+	conflict := func(name string) {
+		if !containsFold(conflicts, name) {
+			conflicts = append(conflicts, name)
+		}
+	}
+	for _, deployment := range deployments[1:] {
+		d := c.deploymentDefaults(deployment)
+		if !samePtr(merged.Rollout, d.Rollout) {
+			merged.Rollout = nil
+			conflict("rollout")
+		}
+		if !samePtr(merged.Mandatory, d.Mandatory) {
+			merged.Mandatory = nil
+			conflict("mandatory")
+		}
+		if merged.Description != d.Description {
+			merged.Description = ""
+			conflict("description")
+		}
+		if !samePtr(merged.DetectAppVersion, d.DetectAppVersion) {
+			merged.DetectAppVersion = nil
+			conflict("detect_app_version")
+		}
+		if merged.Hermes != d.Hermes {
+			merged.Hermes = ""
+			conflict("hermes")
+		}
+	}
+	return merged, conflicts
+}
+
+func (c *ProjectConfig) deploymentDefaults(deployment string) DeploymentDefaults {
+	for name, d := range c.DeploymentDefaults {
+		if strings.EqualFold(name, deployment) {
+			return d
+		}
+	}
+	return DeploymentDefaults{}
+}
+
+func samePtr[T comparable](a, b *T) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
@@ -0,0 +1,72 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDefaultsFor(t *testing.T) {
+	ten, hundred := 10, 100
+	yes := true
+	cfg := &ProjectConfig{DeploymentDefaults: map[string]DeploymentDefaults{
+		"Staging":       {Rollout: &hundred, Description: "{{.CommitCount}} change(s)", Hermes: "on"},
+		"Production":    {Rollout: &ten, Mandatory: &yes, Description: "{{.CommitCount}} change(s)", Hermes: "on"},
+		"Production-EU": {Rollout: &ten, Mandatory: &yes, Hermes: "on"},
+	}}
+
+	tests := []struct {
+		name          string
+		cfg           *ProjectConfig
+		deployments   []string
+		want          DeploymentDefaults
+		wantConflicts []string
+	}{
+		{name: "no config", deployments: []string{"Staging"}},
+		{name: "no deployment", cfg: cfg},
+		{name: "one deployment", cfg: cfg, deployments: []string{"production"}, want: cfg.DeploymentDefaults["Production"]},
+		{name: "undeclared deployment", cfg: cfg, deployments: []string{"QA"}},
+		{
+			name:          "same values",
+			cfg:           cfg,
+			deployments:   []string{"Production", "Production-EU"},
+			want:          DeploymentDefaults{Rollout: &ten, Mandatory: &yes, Hermes: "on"},
+			wantConflicts: []string{"description"},
+		},
+		{
+			name:          "different values",
+			cfg:           cfg,
+			deployments:   []string{"Staging", "Production", "Production-EU"},
+			want:          DeploymentDefaults{Hermes: "on"},
+			wantConflicts: []string{"rollout", "mandatory", "description"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, conflicts := tt.cfg.DefaultsFor(tt.deployments)
+			assert.Equal(t, tt.want, got)
+			assert.Equal(t, tt.wantConflicts, conflicts)
+		})
+	}
+}
+
+func TestLoadDeploymentDefaults(t *testing.T) {
+	dir := setupTestDir(t)
+	data := `{"deployment_defaults":{"Production":{"rollout":10,"mandatory":false,"detect_app_version":true,"hermes":"off"}}}`
+	require.NoError(t, os.WriteFile(filepath.Join(dir, FileName), []byte(data), 0o644))
+
+	cfg, err := Load()
+	require.NoError(t, err)
+	d := cfg.DeploymentDefaults["Production"]
+	require.NotNil(t, d.Rollout)
+	assert.Equal(t, 10, *d.Rollout)
+	require.NotNil(t, d.Mandatory)
+	assert.False(t, *d.Mandatory)
+	require.NotNil(t, d.DetectAppVersion)
+	assert.True(t, *d.DetectAppVersion)
+	assert.Equal(t, "off", d.Hermes)
+}
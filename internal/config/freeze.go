@@ -0,0 +1,178 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	// Embedded so freeze window time zones resolve on machines without a
+	// zoneinfo database, such as Windows.
+	_ "time/tzdata"
+)
+
+// FreezeWindow declares a period in which releases to some deployments are
+// not allowed, such as a weekend or a holiday. Push, promote and patch
+// refuse to change a frozen deployment unless --override-freeze is given.
+//
+// Start and End are either weekly ("Fri 16:00" to "Mon 08:00") or one-off
+// dates ("2024-12-23 00:00" to "2025-01-02 08:00"). Both must be of the same
+// form.
+type FreezeWindow struct {
+	// Name identifies the window in messages, e.g. "weekend".
+	Name string `json:"name,omitempty"`
+	// Deployments are the frozen deployment names, matched
+	// case-insensitively. Unset means the protected deployments.
+	Deployments []string `json:"deployments,omitempty"`
+	Start       string   `json:"start"`
+	End         string   `json:"end"`
+	// TimeZone is the IANA time zone of Start and End, e.g.
+	// "Europe/Budapest". Unset means local time.
+	TimeZone string `json:"time_zone,omitempty"`
+}
+
+// Freeze is a freeze window in effect.
+type Freeze struct {
+	Window FreezeWindow
+	// Until is when the window ends.
+	Until time.Time
+}
+
+// ActiveFreeze returns the first freeze window that covers the named
+// deployment at now, or nil when it is not frozen. It fails if a window
+// is malformed.
+func (c *ProjectConfig) ActiveFreeze(deployment string, now time.Time) (*Freeze, error) {
+	if c == nil {
+		return nil, nil //nolint:nilnil // not frozen
+	}
+	for _, w := range c.FreezeWindows {
+		if !c.freezes(w, deployment) {
+			continue
+		}
+		frozen, until, err := w.Contains(now)
+		if err != nil {
+			return nil, err
+		}
+		if frozen {
+			return &Freeze{Window: w, Until: until}, nil
+		}
+	}
+	return nil, nil //nolint:nilnil // not frozen
+}
+
+func (c *ProjectConfig) freezes(w FreezeWindow, deployment string) bool {
+	if w.Deployments == nil {
+		return c.IsProtected(deployment)
+	}
+	return containsFold(w.Deployments, deployment)
+}
+
+// Contains reports whether t is inside the window and, if so, when the
+// window ends.
+func (w FreezeWindow) Contains(t time.Time) (bool, time.Time, error) {
+	loc := time.Local
+	if w.TimeZone != "" {
+		var err error
+		if loc, err = time.LoadLocation(w.TimeZone); err != nil {
+			return false, time.Time{}, fmt.Errorf("freeze window %s: invalid time_zone %q", w.label(), w.TimeZone)
+		}
+	}
+	t = t.In(loc)
+
+	startDay, startClock, startWeekly := parseWeekly(w.Start)
+	endDay, endClock, endWeekly := parseWeekly(w.End)
+	if startWeekly && endWeekly {
+		if startDay == endDay && startClock == endClock {
+			return false, time.Time{}, fmt.Errorf("freeze window %s: start and end are the same", w.label())
+		}
+		start := atWeekly(t, startDay, startClock)
+		if start.After(t) {
+			start = start.AddDate(0, 0, -7)
+		}
+		end := atWeekly(start, endDay, endClock)
+		if !end.After(start) {
+			end = end.AddDate(0, 0, 7)
+		}
+		return t.Before(end), end, nil
+	}
+
+	start, err := parseFreezeDate(w.Start, loc)
+	if err != nil {
+		return false, time.Time{}, fmt.Errorf("freeze window %s: invalid start %q: use \"Fri 16:00\" or \"2024-12-23 00:00\"", w.label(), w.Start)
+	}
+	end, err := parseFreezeDate(w.End, loc)
+	if err != nil {
+		return false, time.Time{}, fmt.Errorf("freeze window %s: invalid end %q: use \"Mon 08:00\" or \"2025-01-02 08:00\"", w.label(), w.End)
+	}
+	if !end.After(start) {
+		return false, time.Time{}, fmt.Errorf("freeze window %s: end must be after start", w.label())
+	}
+	return !t.Before(start) && t.Before(end), end, nil
+}
+
+// String describes the window, e.g. "weekend (Fri 16:00 to Mon 08:00
+// Europe/Budapest)".
+func (w FreezeWindow) String() string {
+	s := w.Start + " to " + w.End
+	if w.TimeZone != "" {
+		s += " " + w.TimeZone
+	}
+	if w.Name == "" {
+		return s
+	}
+	return w.Name + " (" + s + ")"
+}
+
+func (w FreezeWindow) label() string {
+	if w.Name != "" {
+		return fmt.Sprintf("%q", w.Name)
+	}
+	return fmt.Sprintf("%q", w.Start+" to "+w.End)
+}
+
+var weekdays = map[string]time.Weekday{
+	"sun": time.Sunday, "sunday": time.Sunday,
+	"mon": time.Monday, "monday": time.Monday,
+	"tue": time.Tuesday, "tuesday": time.Tuesday,
+	"wed": time.Wednesday, "wednesday": time.Wednesday,
+	"thu": time.Thursday, "thursday": time.Thursday,
+	"fri": time.Friday, "friday": time.Friday,
+	"sat": time.Saturday, "saturday": time.Saturday,
+}
+
+// parseWeekly parses "Fri 16:00" into a weekday and minutes past midnight.
+func parseWeekly(s string) (time.Weekday, int, bool) {
+	fields := strings.Fields(s)
+	if len(fields) != 2 {
+		return 0, 0, false
+	}
+	day, ok := weekdays[strings.ToLower(fields[0])]
+	if !ok {
+		return 0, 0, false
+	}
+	clock, err := time.Parse("15:04", fields[1])
+	if err != nil {
+		return 0, 0, false
+	}
+	return day, clock.Hour()*60 + clock.Minute(), true
+}
+
+// atWeekly returns the time on day at clock minutes past midnight in the
+// week of t, from Sunday to Saturday.
+func atWeekly(t time.Time, day time.Weekday, clock int) time.Time {
+	offset := int(day) - int(t.Weekday())
+	return time.Date(t.Year(), t.Month(), t.Day()+offset, clock/60, clock%60, 0, 0, t.Location())
+}
+
+func parseFreezeDate(s string, loc *time.Location) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+	var err error
+	for _, layout := range []string{"2006-01-02 15:04", "2006-01-02T15:04", "2006-01-02"} {
+		var t time.Time
+		if t, err = time.ParseInLocation(layout, s, loc); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, err
+}
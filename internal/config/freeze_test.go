@@ -0,0 +1,114 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFreezeWindowContains(t *testing.T) {
+	budapest, err := time.LoadLocation("Europe/Budapest")
+	require.NoError(t, err)
+	weekend := FreezeWindow{Name: "weekend", Start: "Fri 16:00", End: "Mon 08:00", TimeZone: "Europe/Budapest"}
+	midweek := FreezeWindow{Start: "tue 10:00", End: "Thursday 18:00", TimeZone: "UTC"}
+	holidays := FreezeWindow{Start: "2024-12-23 00:00", End: "2025-01-02T08:00", TimeZone: "Europe/Budapest"}
+	mondayEnd := time.Date(2024, 7, 8, 8, 0, 0, 0, budapest)
+
+	tests := []struct {
+		name      string
+		window    FreezeWindow
+		at        time.Time
+		want      bool
+		wantUntil time.Time
+	}{
+		{name: "before weekly start", window: weekend, at: time.Date(2024, 7, 5, 15, 59, 0, 0, budapest)},
+		{name: "at weekly start", window: weekend, at: time.Date(2024, 7, 5, 16, 0, 0, 0, budapest), want: true, wantUntil: mondayEnd},
+		{name: "sunday", window: weekend, at: time.Date(2024, 7, 7, 12, 0, 0, 0, budapest), want: true, wantUntil: mondayEnd},
+		{name: "monday morning", window: weekend, at: time.Date(2024, 7, 8, 7, 59, 0, 0, budapest), want: true, wantUntil: mondayEnd},
+		{name: "at weekly end", window: weekend, at: mondayEnd},
+		{name: "other time zone", window: weekend, at: time.Date(2024, 7, 5, 14, 30, 0, 0, time.UTC), want: true, wantUntil: mondayEnd},
+		{name: "within one week", window: midweek, at: time.Date(2024, 7, 10, 0, 0, 0, 0, time.UTC), want: true, wantUntil: time.Date(2024, 7, 11, 18, 0, 0, 0, time.UTC)},
+		{name: "after end within one week", window: midweek, at: time.Date(2024, 7, 12, 0, 0, 0, 0, time.UTC)},
+		{name: "one-off", window: holidays, at: time.Date(2024, 12, 31, 12, 0, 0, 0, budapest), want: true, wantUntil: time.Date(2025, 1, 2, 8, 0, 0, 0, budapest)},
+		{name: "after one-off", window: holidays, at: time.Date(2025, 1, 2, 8, 0, 0, 0, budapest)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			frozen, until, err := tt.window.Contains(tt.at)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, frozen)
+			if tt.want {
+				assert.True(t, tt.wantUntil.Equal(until), "until %s, want %s", until, tt.wantUntil)
+			}
+		})
+	}
+}
+
+func TestFreezeWindowContainsInvalid(t *testing.T) {
+	tests := []struct {
+		name    string
+		window  FreezeWindow
+		wantErr string
+	}{
+		{name: "unknown time zone", window: FreezeWindow{Start: "Fri 16:00", End: "Mon 08:00", TimeZone: "Mars/Olympus"}, wantErr: `invalid time_zone "Mars/Olympus"`},
+		{name: "malformed start", window: FreezeWindow{Name: "weekend", Start: "Friday afternoon", End: "Mon 08:00"}, wantErr: `freeze window "weekend": invalid start "Friday afternoon"`},
+		{name: "mixed forms", window: FreezeWindow{Start: "2024-12-23", End: "Mon 08:00"}, wantErr: `invalid end "Mon 08:00"`},
+		{name: "end before start", window: FreezeWindow{Start: "2025-01-02", End: "2024-12-23"}, wantErr: "end must be after start"},
+		{name: "empty weekly window", window: FreezeWindow{Start: "Fri 16:00", End: "fri 16:00"}, wantErr: "start and end are the same"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, _, err := tt.window.Contains(time.Now())
+			assert.ErrorContains(t, err, tt.wantErr)
+		})
+	}
+}
+
+func TestActiveFreeze(t *testing.T) {
+	friday := time.Date(2024, 7, 5, 18, 0, 0, 0, time.UTC)
+	weekend := FreezeWindow{Name: "weekend", Start: "Fri 16:00", End: "Mon 08:00", TimeZone: "UTC"}
+	qa := FreezeWindow{Name: "qa", Deployments: []string{"QA"}, Start: "Fri 17:00", End: "Fri 19:00", TimeZone: "UTC"}
+
+	tests := []struct {
+		name       string
+		cfg        *ProjectConfig
+		deployment string
+		want       string
+	}{
+		{name: "no config", deployment: "Production"},
+		{name: "protected by default", cfg: &ProjectConfig{FreezeWindows: []FreezeWindow{weekend}}, deployment: "production", want: "weekend"},
+		{name: "unprotected deployment", cfg: &ProjectConfig{FreezeWindows: []FreezeWindow{weekend}}, deployment: "Staging"},
+		{name: "custom protected deployments", cfg: &ProjectConfig{ProtectedDeployments: []string{"Production-EU"}, FreezeWindows: []FreezeWindow{weekend}}, deployment: "Production"},
+		{name: "listed deployments", cfg: &ProjectConfig{FreezeWindows: []FreezeWindow{weekend, qa}}, deployment: "qa", want: "qa"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			freeze, err := tt.cfg.ActiveFreeze(tt.deployment, friday)
+			require.NoError(t, err)
+			if tt.want == "" {
+				assert.Nil(t, freeze)
+				return
+			}
+			require.NotNil(t, freeze)
+			assert.Equal(t, tt.want, freeze.Window.Name)
+		})
+	}
+}
+
+func TestLoadFreezeWindows(t *testing.T) {
+	dir := setupTestDir(t)
+	data := `{"freeze_windows":[{"name":"weekend","deployments":["Production"],"start":"Fri 16:00","end":"Mon 08:00","time_zone":"Europe/Budapest"}]}`
+	require.NoError(t, os.WriteFile(filepath.Join(dir, FileName), []byte(data), 0o644))
+
+	cfg, err := Load()
+	require.NoError(t, err)
+	require.Len(t, cfg.FreezeWindows, 1)
+	assert.Equal(t, "weekend (Fri 16:00 to Mon 08:00 Europe/Budapest)", cfg.FreezeWindows[0].String())
+}
@@ -0,0 +1,81 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Policy declares rules that new releases must follow. Push and promote
+// check them before creating a release.
+type Policy struct {
+	// MandatoryRequiresDescription rejects mandatory releases without a
+	// description, so users can see why an update was forced on them.
+	MandatoryRequiresDescription bool `json:"mandatory_requires_description,omitempty"`
+	// Deployments holds rules per deployment name, matched
+	// case-insensitively.
+	Deployments map[string]DeploymentPolicy `json:"deployments,omitempty"`
+}
+
+// DeploymentPolicy declares the rules for releases to one deployment.
+type DeploymentPolicy struct {
+	// MaxInitialRollout is the highest rollout percentage a new release may
+	// start at. Raise it afterwards with patch.
+	MaxInitialRollout *int `json:"max_initial_rollout,omitempty"`
+	// PromoteFrom lists the only deployments releases may be promoted from.
+	PromoteFrom []string `json:"promote_from,omitempty"`
+}
+
+// PolicyRelease describes a release about to be created.
+type PolicyRelease struct {
+	Deployment string
+	// SourceDeployment is the deployment a promote copies from; empty for
+	// a push.
+	SourceDeployment string
+	Rollout          int
+	Mandatory        bool
+	Description      string
+}
+
+// PolicyViolations returns a message for each policy rule r breaks, or nil
+// when there is no policy or r follows it.
+func (c *ProjectConfig) PolicyViolations(r PolicyRelease) []string {
+	if c == nil || c.Policy == nil {
+		return nil
+	}
+	p := c.Policy
+
+	var violations []string
+	if p.MandatoryRequiresDescription && r.Mandatory && strings.TrimSpace(r.Description) == "" {
+		violations = append(violations, "mandatory releases require a description")
+	}
+
+	rules, ok := p.deploymentRules(r.Deployment)
+	if !ok {
+		return violations
+	}
+	if max := rules.MaxInitialRollout; max != nil && r.Rollout > *max {
+		violations = append(violations, fmt.Sprintf("new releases to %s must start at a rollout of at most %d%%, got %d%%: set --rollout", r.Deployment, *max, r.Rollout))
+	}
+	if r.SourceDeployment != "" && len(rules.PromoteFrom) > 0 && !containsFold(rules.PromoteFrom, r.SourceDeployment) {
+		violations = append(violations, fmt.Sprintf("releases can only be promoted to %s from %s, not %s", r.Deployment, strings.Join(rules.PromoteFrom, ", "), r.SourceDeployment))
+	}
+	return violations
+}
+
+func (p *Policy) deploymentRules(deployment string) (DeploymentPolicy, bool) {
+	for name, rules := range p.Deployments {
+		if strings.EqualFold(name, deployment) {
+			return rules, true
+		}
+	}
+	return DeploymentPolicy{}, false
+}
+
+func containsFold(names []string, name string) bool {
+	for _, n := range names {
+		if strings.EqualFold(n, name) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,112 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadPolicy(t *testing.T) {
+	dir := setupTestDir(t)
+	data := `{"policy":{"mandatory_requires_description":true,"deployments":{"Production":{"max_initial_rollout":25,"promote_from":["Staging"]}}}}`
+	require.NoError(t, os.WriteFile(filepath.Join(dir, FileName), []byte(data), 0o644))
+
+	cfg, err := Load()
+	require.NoError(t, err)
+	require.NotNil(t, cfg.Policy)
+	assert.True(t, cfg.Policy.MandatoryRequiresDescription)
+	rules := cfg.Policy.Deployments["Production"]
+	require.NotNil(t, rules.MaxInitialRollout)
+	assert.Equal(t, 25, *rules.MaxInitialRollout)
+	assert.Equal(t, []string{"Staging"}, rules.PromoteFrom)
+}
+
+func TestPolicyViolations(t *testing.T) {
+	maxRollout := 25
+	cfg := &ProjectConfig{Policy: &Policy{
+		MandatoryRequiresDescription: true,
+		Deployments: map[string]DeploymentPolicy{
+			"Production": {MaxInitialRollout: &maxRollout, PromoteFrom: []string{"Staging"}},
+		},
+	}}
+
+	tests := []struct {
+		name    string
+		cfg     *ProjectConfig
+		release PolicyRelease
+		want    []string
+	}{
+		{
+			name:    "compliant push",
+			cfg:     cfg,
+			release: PolicyRelease{Deployment: "Production", Rollout: 25},
+		},
+		{
+			name:    "rollout too high",
+			cfg:     cfg,
+			release: PolicyRelease{Deployment: "Production", Rollout: 100},
+			want:    []string{"new releases to Production must start at a rollout of at most 25%, got 100%: set --rollout"},
+		},
+		{
+			name:    "deployment matches case-insensitively",
+			cfg:     cfg,
+			release: PolicyRelease{Deployment: "production", Rollout: 50},
+			want:    []string{"new releases to production must start at a rollout of at most 25%, got 50%: set --rollout"},
+		},
+		{
+			name:    "unruled deployment",
+			cfg:     cfg,
+			release: PolicyRelease{Deployment: "Staging", SourceDeployment: "Dev", Rollout: 100},
+		},
+		{
+			name:    "promote from allowed source",
+			cfg:     cfg,
+			release: PolicyRelease{Deployment: "Production", SourceDeployment: "staging", Rollout: 10},
+		},
+		{
+			name:    "promote from other source",
+			cfg:     cfg,
+			release: PolicyRelease{Deployment: "Production", SourceDeployment: "QA", Rollout: 10},
+			want:    []string{"releases can only be promoted to Production from Staging, not QA"},
+		},
+		{
+			name:    "mandatory without description",
+			cfg:     cfg,
+			release: PolicyRelease{Deployment: "Staging", Rollout: 100, Mandatory: true, Description: "  "},
+			want:    []string{"mandatory releases require a description"},
+		},
+		{
+			name:    "mandatory with description",
+			cfg:     cfg,
+			release: PolicyRelease{Deployment: "Staging", Rollout: 100, Mandatory: true, Description: "Fixes login"},
+		},
+		{
+			name:    "several violations",
+			cfg:     cfg,
+			release: PolicyRelease{Deployment: "Production", SourceDeployment: "QA", Rollout: 100, Mandatory: true},
+			want: []string{
+				"mandatory releases require a description",
+				"new releases to Production must start at a rollout of at most 25%, got 100%: set --rollout",
+				"releases can only be promoted to Production from Staging, not QA",
+			},
+		},
+		{
+			name:    "no policy",
+			cfg:     &ProjectConfig{AppID: "x"},
+			release: PolicyRelease{Deployment: "Production", Rollout: 100, Mandatory: true},
+		},
+		{
+			name:    "nil config",
+			release: PolicyRelease{Deployment: "Production", Rollout: 100, Mandatory: true},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, tt.cfg.PolicyViolations(tt.release))
+		})
+	}
+}
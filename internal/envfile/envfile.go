@@ -0,0 +1,153 @@
+// Package envfile reads .env-style files for --env-file, so CI secrets and
+// app IDs can be kept per environment instead of in the global environment.
+//
+// Each line is KEY=VALUE, optionally prefixed with "export". Blank lines and
+// lines starting with # are skipped. Values may be single-quoted (taken
+// literally) or double-quoted (with \n, \t, \r, \", and \\ escapes);
+// unquoted values end at a " #" comment. Variables are not expanded.
+package envfile
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// Var is one variable of an env file.
+type Var struct {
+	Key   string
+	Value string
+}
+
+var keyPattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// Read parses the env file at path.
+func Read(path string) ([]Var, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading env file: %w", err)
+	}
+	defer func() { _ = f.Close() }()
+
+	vars, err := Parse(f)
+	if err != nil {
+		return nil, fmt.Errorf("parsing %s: %w", path, err)
+	}
+	return vars, nil
+}
+
+// Parse parses env file content. Variables are returned in file order; a
+// key given twice appears twice.
+func Parse(r io.Reader) ([]Var, error) {
+	var vars []Var
+	scanner := bufio.NewScanner(r)
+	for n := 1; scanner.Scan(); n++ {
+		line := scanner.Text()
+		if n == 1 {
+			line = strings.TrimPrefix(line, "\ufeff")
+		}
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		line = strings.TrimPrefix(line, "export ")
+
+		key, value, ok := strings.Cut(line, "=")
+		key = strings.TrimSpace(key)
+		if !ok || !keyPattern.MatchString(key) {
+			return nil, fmt.Errorf("line %d: expected KEY=VALUE", n)
+		}
+		value, err := parseValue(strings.TrimSpace(value))
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", n, err)
+		}
+		vars = append(vars, Var{Key: key, Value: value})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return vars, nil
+}
+
+func parseValue(s string) (string, error) {
+	if s == "" {
+		return "", nil
+	}
+
+	switch quote := s[0]; quote {
+	case '\'':
+		end := strings.IndexByte(s[1:], '\'')
+		if end < 0 {
+			return "", fmt.Errorf("unterminated %c quote", quote)
+		}
+		return s[1 : end+1], checkTrailing(s[end+2:])
+	case '"':
+		var b strings.Builder
+		for i := 1; i < len(s); i++ {
+			switch c := s[i]; {
+			case c == '"':
+				return b.String(), checkTrailing(s[i+1:])
+			case c == '\\' && i+1 < len(s):
+				i++
+				switch s[i] {
+				case 'n':
+					b.WriteByte('\n')
+				case 't':
+					b.WriteByte('\t')
+				case 'r':
+					b.WriteByte('\r')
+				default:
+					b.WriteByte(s[i])
+				}
+			default:
+				b.WriteByte(c)
+			}
+		}
+		return "", fmt.Errorf("unterminated %c quote", quote)
+	}
+
+	if i := strings.Index(s, " #"); i >= 0 {
+		s = s[:i]
+	}
+	if i := strings.Index(s, "\t#"); i >= 0 {
+		s = s[:i]
+	}
+	return strings.TrimSpace(s), nil
+}
+
+// checkTrailing allows only a comment after a quoted value.
+func checkTrailing(s string) error {
+	if s = strings.TrimSpace(s); s != "" && !strings.HasPrefix(s, "#") {
+		return fmt.Errorf("unexpected %q after quoted value", s)
+	}
+	return nil
+}
+
+// Apply sets each variable that is not already set to a non-empty value in
+// the environment, so the environment takes precedence over the file. When
+// a key is given twice, the last value wins. It returns the keys it set.
+func Apply(vars []Var) ([]string, error) {
+	values := map[string]string{}
+	var keys []string
+	for _, v := range vars {
+		if _, seen := values[v.Key]; !seen {
+			keys = append(keys, v.Key)
+		}
+		values[v.Key] = v.Value
+	}
+
+	var set []string
+	for _, key := range keys {
+		if os.Getenv(key) != "" {
+			continue
+		}
+		if err := os.Setenv(key, values[key]); err != nil {
+			return set, fmt.Errorf("setting %s: %w", key, err)
+		}
+		set = append(set, key)
+	}
+	return set, nil
+}
@@ -0,0 +1,82 @@
+package envfile
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    []Var
+		wantErr string
+	}{
+		{
+			name:    "plain values",
+			content: "\ufeffCODEPUSH_APP_ID=app-1\n\n# comment\nexport BITRISE_API_TOKEN = secret \nEMPTY=\n",
+			want:    []Var{{"CODEPUSH_APP_ID", "app-1"}, {"BITRISE_API_TOKEN", "secret"}, {"EMPTY", ""}},
+		},
+		{
+			name:    "inline comments",
+			content: "A=value # comment\nB=value#not-a-comment\nC='quoted' # comment\n",
+			want:    []Var{{"A", "value"}, {"B", "value#not-a-comment"}, {"C", "quoted"}},
+		},
+		{
+			name:    "quotes",
+			content: `A="line 1\nline \"2\"" ` + "\n" + `B='no $escapes\n'` + "\n" + `C="a=b"`,
+			want:    []Var{{"A", "line 1\nline \"2\""}, {"B", `no $escapes\n`}, {"C", "a=b"}},
+		},
+		{name: "missing equals", content: "A=1\nJUSTAKEY\n", wantErr: "line 2: expected KEY=VALUE"},
+		{name: "invalid key", content: "1A=1\n", wantErr: "line 1: expected KEY=VALUE"},
+		{name: "unterminated quote", content: `A="open`, wantErr: "line 1: unterminated \" quote"},
+		{name: "text after quote", content: `A="a" b`, wantErr: `unexpected "b" after quoted value`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Parse(strings.NewReader(tt.content))
+			if tt.wantErr != "" {
+				assert.ErrorContains(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestRead(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".codepush.env")
+	require.NoError(t, os.WriteFile(path, []byte("A=1\nB\n"), 0o600))
+
+	_, err := Read(path)
+	assert.ErrorContains(t, err, path+": line 2")
+
+	_, err = Read(filepath.Join(t.TempDir(), "missing.env"))
+	assert.ErrorIs(t, err, os.ErrNotExist)
+}
+
+func TestApply(t *testing.T) {
+	t.Setenv("ENVFILE_TEST_SET", "from-env")
+	t.Setenv("ENVFILE_TEST_EMPTY", "")
+	t.Setenv("ENVFILE_TEST_UNSET", "")
+	require.NoError(t, os.Unsetenv("ENVFILE_TEST_UNSET"))
+
+	set, err := Apply([]Var{
+		{"ENVFILE_TEST_SET", "from-file"},
+		{"ENVFILE_TEST_EMPTY", "from-file"},
+		{"ENVFILE_TEST_UNSET", "first"},
+		{"ENVFILE_TEST_UNSET", "last"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"ENVFILE_TEST_EMPTY", "ENVFILE_TEST_UNSET"}, set)
+	assert.Equal(t, "from-env", os.Getenv("ENVFILE_TEST_SET"))
+	assert.Equal(t, "from-file", os.Getenv("ENVFILE_TEST_EMPTY"))
+	assert.Equal(t, "last", os.Getenv("ENVFILE_TEST_UNSET"))
+}
@@ -0,0 +1,58 @@
+// Package git runs git commands against a working directory.
+package git
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os/exec"
+	"strings"
+)
+
+// Info describes the checkout of a working directory.
+type Info struct {
+	// Branch is empty when HEAD is detached, as in most CI checkouts.
+	Branch string
+	Commit string
+	// Dirty reports uncommitted changes to tracked files.
+	Dirty bool
+}
+
+// ReadInfo returns the branch, commit, and working tree state of the
+// checkout in dir; empty for the current directory.
+func ReadInfo(ctx context.Context, dir string) (Info, error) {
+	var info Info
+	commit, err := Run(ctx, dir, "rev-parse", "HEAD")
+	if err != nil {
+		return info, err
+	}
+	info.Commit = commit
+	if branch, err := Run(ctx, dir, "rev-parse", "--abbrev-ref", "HEAD"); err == nil && branch != "HEAD" {
+		info.Branch = branch
+	}
+	status, err := Run(ctx, dir, "status", "--porcelain", "--untracked-files=no")
+	if err != nil {
+		return info, err
+	}
+	info.Dirty = status != ""
+	return info, nil
+}
+
+// Run runs git with args in dir, empty for the current directory, and
+// returns its trimmed output. A failure is reported with git's own message.
+func Run(ctx context.Context, dir string, args ...string) (string, error) {
+	if dir != "" {
+		args = append([]string{"-C", dir}, args...)
+	}
+	var stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return "", errors.New(msg)
+		}
+		return "", err
+	}
+	return strings.TrimSpace(string(out)), nil
+}
@@ -0,0 +1,45 @@
+package git
+
+import (
+	"context"
+	"os/exec"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadInfo(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	ctx := context.Background()
+
+	t.Run("not a repository", func(t *testing.T) {
+		_, err := ReadInfo(ctx, t.TempDir())
+		assert.ErrorContains(t, err, "not a git repository")
+	})
+
+	t.Run("detached HEAD has no branch", func(t *testing.T) {
+		dir := t.TempDir()
+		for _, args := range [][]string{
+			{"init", "-q", "-b", "main"},
+			{"-c", "user.email=dev@example.com", "-c", "user.name=Dev", "commit", "-q", "--allow-empty", "-m", "Initial"},
+		} {
+			_, err := Run(ctx, dir, args...)
+			require.NoError(t, err)
+		}
+
+		info, err := ReadInfo(ctx, dir)
+		require.NoError(t, err)
+		assert.Equal(t, "main", info.Branch)
+
+		_, err = Run(ctx, dir, "checkout", "-q", "--detach")
+		require.NoError(t, err)
+		info, err = ReadInfo(ctx, dir)
+		require.NoError(t, err)
+		assert.Empty(t, info.Branch)
+		assert.Len(t, info.Commit, 40)
+		assert.False(t, info.Dirty)
+	})
+}
@@ -0,0 +1,87 @@
+// Package github provides integration with the GitHub Actions environment.
+package github
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/bitrise-io/bitrise-plugins-codepush-cli/internal/redact"
+)
+
+// IsGitHubActions returns true if running inside a GitHub Actions job.
+func IsGitHubActions() bool {
+	return os.Getenv("GITHUB_ACTIONS") == "true"
+}
+
+// SetOutput sets a step output, which later steps of the job read as
+// steps.<id>.outputs.<key>. Secrets are masked.
+func SetOutput(key, value string) error {
+	value = redact.String(value)
+	line := key + "=" + value + "\n"
+	if strings.ContainsAny(value, "\r\n") {
+		delimiter, err := newDelimiter()
+		if err != nil {
+			return err
+		}
+		line = key + "<<" + delimiter + "\n" + value + "\n" + delimiter + "\n"
+	}
+	return appendToEnvFile("GITHUB_OUTPUT", line)
+}
+
+// AppendStepSummary adds markdown to the job summary shown on the run's
+// page. Secrets are masked.
+func AppendStepSummary(markdown string) error {
+	if !strings.HasSuffix(markdown, "\n") {
+		markdown += "\n"
+	}
+	return appendToEnvFile("GITHUB_STEP_SUMMARY", redact.String(markdown)+"\n")
+}
+
+// ErrorCommand returns the workflow command that adds msg as an error
+// annotation to the run, titled title. The runner reads it from the step's
+// standard output or standard error. Secrets are masked.
+func ErrorCommand(title, msg string) string {
+	return "::error title=" + escapeProperty(title) + "::" + escapeData(redact.String(msg))
+}
+
+// appendToEnvFile appends s to the file named by the environment variable
+// key, one of the files the runner reads after the step.
+func appendToEnvFile(key, s string) error {
+	path := os.Getenv(key)
+	if path == "" {
+		return errors.New(key + " is not set")
+	}
+
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return fmt.Errorf("failed to open %s: %w", key, err)
+	}
+	if _, err := f.WriteString(s); err != nil {
+		f.Close()
+		return fmt.Errorf("failed to write to %s: %w", key, err)
+	}
+	return f.Close()
+}
+
+// newDelimiter returns a random heredoc delimiter for a multiline output.
+func newDelimiter() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("generating output delimiter: %w", err)
+	}
+	return "ghadelimiter_" + hex.EncodeToString(b), nil
+}
+
+// escapeData escapes the message of a workflow command.
+func escapeData(s string) string {
+	return strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A").Replace(s)
+}
+
+// escapeProperty escapes a property value of a workflow command.
+func escapeProperty(s string) string {
+	return strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A", ":", "%3A", ",", "%2C").Replace(s)
+}
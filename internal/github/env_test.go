@@ -0,0 +1,106 @@
+package github
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIsGitHubActions(t *testing.T) {
+	tests := []struct {
+		name  string
+		value string
+		want  bool
+	}{
+		{name: "not set", value: "", want: false},
+		{name: "set", value: "true", want: true},
+		{name: "other value", value: "1", want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("GITHUB_ACTIONS", tt.value)
+			assert.Equal(t, tt.want, IsGitHubActions())
+		})
+	}
+}
+
+func TestSetOutput(t *testing.T) {
+	t.Run("appends single-line values", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "output")
+		t.Setenv("GITHUB_OUTPUT", path)
+
+		require.NoError(t, SetOutput("CODEPUSH_LABEL", "v3"))
+		require.NoError(t, SetOutput("CODEPUSH_APP_VERSION", "1.2.0"))
+
+		data, err := os.ReadFile(path)
+		require.NoError(t, err)
+		assert.Equal(t, "CODEPUSH_LABEL=v3\nCODEPUSH_APP_VERSION=1.2.0\n", string(data))
+	})
+
+	t.Run("uses a delimiter for multiline values", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "output")
+		t.Setenv("GITHUB_OUTPUT", path)
+
+		require.NoError(t, SetOutput("CODEPUSH_SUMMARY", "{\n  \"label\": \"v3\"\n}"))
+
+		data, err := os.ReadFile(path)
+		require.NoError(t, err)
+		assert.Regexp(t, regexp.MustCompile(`^CODEPUSH_SUMMARY<<(ghadelimiter_[0-9a-f]{32})\n\{\n  "label": "v3"\n\}\n(ghadelimiter_[0-9a-f]{32})\n$`), string(data))
+	})
+
+	t.Run("fails without GITHUB_OUTPUT", func(t *testing.T) {
+		t.Setenv("GITHUB_OUTPUT", "")
+		assert.Error(t, SetOutput("CODEPUSH_LABEL", "v3"))
+	})
+}
+
+func TestAppendStepSummary(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "summary")
+	t.Setenv("GITHUB_STEP_SUMMARY", path)
+
+	require.NoError(t, AppendStepSummary("**first**"))
+	require.NoError(t, AppendStepSummary("**second**\n"))
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "**first**\n\n**second**\n\n", string(data))
+}
+
+func TestErrorCommand(t *testing.T) {
+	tests := []struct {
+		name  string
+		title string
+		msg   string
+		want  string
+	}{
+		{
+			name:  "plain message",
+			title: "CodePush",
+			msg:   "deployment not found",
+			want:  "::error title=CodePush::deployment not found",
+		},
+		{
+			name:  "multiline message",
+			title: "CodePush",
+			msg:   "push failed: 100% done\nthen timed out",
+			want:  "::error title=CodePush::push failed: 100%25 done%0Athen timed out",
+		},
+		{
+			name:  "title with separators",
+			title: "CodePush: push, retry",
+			msg:   "failed",
+			want:  "::error title=CodePush%3A push%2C retry::failed",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, ErrorCommand(tt.title, tt.msg))
+		})
+	}
+}
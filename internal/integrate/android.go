@@ -0,0 +1,310 @@
+package integrate
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/bitrise-io/bitrise-plugins-codepush-cli/internal/bundler"
+)
+
+// Resource names read by the Android SDK.
+const (
+	resDeploymentKey = "CodePushDeploymentKey"
+	resServerURL     = "CodePushServerUrl"
+)
+
+// androidModule is the Gradle project name of the SDK's Android library.
+const androidModule = ":react-native-code-push"
+
+var (
+	// gradleDependencies matches the top level dependencies block of a
+	// module build file.
+	gradleDependencies = regexp.MustCompile(`(?m)^dependencies\s*\{[ \t]*$`)
+	// autolinking matches the settings of a project whose native modules
+	// are linked by the React Native Gradle plugin.
+	autolinking = regexp.MustCompile(`native_modules\.gradle|autolinkLibrariesFromCommand|com\.facebook\.react\.settings`)
+	// reactHost matches the start of the ReactNativeHost of MainApplication
+	// in Java or Kotlin.
+	reactHost   = regexp.MustCompile(`(?m)^([ \t]*).*(?:new|object\s*:)\s*(?:Default)?ReactNativeHost\(\s*this\s*\)\s*\{[ \t]*\n`)
+	javaImport  = regexp.MustCompile(`(?m)^import [^\n]+\n`)
+	stringsEnd  = regexp.MustCompile(`(?m)^([ \t]*)</resources>`)
+	stringsItem = regexp.MustCompile(`(?m)^([ \t]*)<string `)
+)
+
+// PlanAndroid plans the integration of the native Android project of a
+// brownfield or bare React Native app: the SDK's Gradle build script, and
+// without autolinking the SDK library itself, are added to the build, the
+// deployment key and server URL are set as string resources, and
+// MainApplication loads the bundle from CodePush. Modified files are backed
+// up.
+//
+// The key and server URL go to res/values/strings.xml of the app module,
+// unless gradle.properties already sets CodePushDeploymentKey, in which
+// case it is updated there. The Android project is read from android/ in
+// the project directory, or from the project directory itself when it has
+// a settings.gradle.
+func PlanAndroid(opts Options) (*Plan, error) {
+	if opts.DeploymentKey == "" {
+		return nil, errors.New("a deployment key is required")
+	}
+	plan := &Plan{ProjectDir: opts.ProjectDir, Backup: true}
+
+	androidDir := "android"
+	if gradleFile(opts.ProjectDir, ".", "settings.gradle") != "" {
+		androidDir = "."
+	}
+
+	pkg := SDKPackage
+	if sdk := bundler.DetectSDK(opts.ProjectDir); sdk != nil {
+		pkg = sdk.Package
+	} else {
+		plan.note("install the SDK: npm install %s", pkg)
+	}
+	if err := planGradle(plan, opts.ProjectDir, androidDir, pkg); err != nil {
+		return nil, err
+	}
+	if err := planAndroidConfig(plan, opts, androidDir); err != nil {
+		return nil, err
+	}
+	if err := planMainApplication(plan, opts.ProjectDir, androidDir); err != nil {
+		return nil, err
+	}
+	return plan, nil
+}
+
+// gradleFile returns the path of a Groovy or Kotlin Gradle script, relative
+// to projectDir, or "" when neither exists.
+func gradleFile(projectDir, dir, name string) string {
+	for _, n := range []string{name, name + ".kts"} {
+		path := filepath.Join(dir, n)
+		if _, err := os.Stat(filepath.Join(projectDir, path)); err == nil {
+			return path
+		}
+	}
+	return ""
+}
+
+// planGradle applies the SDK's codepush.gradle in the app module. Without
+// autolinking it also includes the SDK library in settings.gradle and adds
+// it to the app's dependencies.
+func planGradle(plan *Plan, projectDir, androidDir, pkg string) error {
+	settingsPath := gradleFile(projectDir, androidDir, "settings.gradle")
+	if settingsPath == "" {
+		return fmt.Errorf("no settings.gradle found in %s", androidDir)
+	}
+	appDir := filepath.Join(androidDir, "app")
+	buildPath := gradleFile(projectDir, appDir, "build.gradle")
+	if buildPath == "" {
+		return fmt.Errorf("no build.gradle found in %s", appDir)
+	}
+	sdkAndroid := filepath.Join("node_modules", pkg, "android")
+
+	settings, err := os.ReadFile(filepath.Join(projectDir, settingsPath))
+	if err != nil {
+		return fmt.Errorf("reading %s: %w", settingsPath, err)
+	}
+	linked := autolinking.Match(settings)
+	if !linked && !strings.Contains(string(settings), androidModule) {
+		libDir, err := filepath.Rel(androidDir, filepath.Join(sdkAndroid, "app"))
+		if err != nil {
+			return err
+		}
+		include := fmt.Sprintf("include '%s'\nproject('%s').projectDir = new File(rootProject.projectDir, '%s')\n", androidModule, androidModule, filepath.ToSlash(libDir))
+		if strings.HasSuffix(settingsPath, ".kts") {
+			include = fmt.Sprintf("include(%q)\nproject(%q).projectDir = File(rootProject.projectDir, %q)\n", androidModule, androidModule, filepath.ToSlash(libDir))
+		}
+		plan.add(settingsPath, settings, appendBlock(settings, include))
+	}
+
+	build, err := os.ReadFile(filepath.Join(projectDir, buildPath))
+	if err != nil {
+		return fmt.Errorf("reading %s: %w", buildPath, err)
+	}
+	kts := strings.HasSuffix(buildPath, ".kts")
+	s := string(build)
+	if !linked && !strings.Contains(s, androidModule) {
+		dep := fmt.Sprintf("implementation project('%s')", androidModule)
+		if kts {
+			dep = fmt.Sprintf("implementation(project(%q))", androidModule)
+		}
+		if m := gradleDependencies.FindStringIndex(s); m != nil {
+			s = s[:m[1]] + "\n    " + dep + s[m[1]:]
+		} else {
+			plan.note("add %s to the dependencies of %s", dep, buildPath)
+		}
+	}
+	if !strings.Contains(s, "codepush.gradle") {
+		script, err := filepath.Rel(appDir, filepath.Join(sdkAndroid, "codepush.gradle"))
+		if err != nil {
+			return err
+		}
+		apply := fmt.Sprintf("apply from: %q\n", filepath.ToSlash(script))
+		if kts {
+			apply = fmt.Sprintf("apply(from = %q)\n", filepath.ToSlash(script))
+		}
+		s = string(appendBlock([]byte(s), apply))
+	}
+	plan.add(buildPath, build, []byte(s))
+	return nil
+}
+
+// appendBlock appends block to data as a separate paragraph.
+func appendBlock(data []byte, block string) []byte {
+	s := strings.TrimRight(string(data), "\n")
+	if s == "" {
+		return []byte(block)
+	}
+	return []byte(s + "\n\n" + block)
+}
+
+// planAndroidConfig sets the deployment key and server URL in
+// gradle.properties when it already holds the key, or else in the app's
+// strings.xml, which is created when missing.
+func planAndroidConfig(plan *Plan, opts Options, androidDir string) error {
+	values := [][2]string{{resDeploymentKey, opts.DeploymentKey}}
+	if opts.ServerURL != "" {
+		values = append(values, [2]string{resServerURL, opts.ServerURL})
+	}
+
+	propsPath := filepath.Join(androidDir, "gradle.properties")
+	if props, err := os.ReadFile(filepath.Join(opts.ProjectDir, propsPath)); err == nil && propertyLine(resDeploymentKey).Match(props) {
+		plan.add(propsPath, props, setProperties(props, values))
+		return nil
+	}
+
+	stringsPath := filepath.Join(androidDir, "app", "src", "main", "res", "values", "strings.xml")
+	data, err := os.ReadFile(filepath.Join(opts.ProjectDir, stringsPath))
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+		data = nil
+	case err != nil:
+		return fmt.Errorf("reading %s: %w", stringsPath, err)
+	}
+	after, err := setStringResources(data, values)
+	if err != nil {
+		return fmt.Errorf("editing %s: %w", stringsPath, err)
+	}
+	plan.add(stringsPath, data, after)
+	return nil
+}
+
+func propertyLine(key string) *regexp.Regexp {
+	return regexp.MustCompile(`(?m)^[ \t]*` + regexp.QuoteMeta(key) + `[ \t]*[=:][^\n]*$`)
+}
+
+// setProperties sets values in a Java properties file, replacing existing
+// lines and appending missing ones.
+func setProperties(data []byte, values [][2]string) []byte {
+	s := string(data)
+	for _, kv := range values {
+		line := kv[0] + "=" + kv[1]
+		if re := propertyLine(kv[0]); re.MatchString(s) {
+			s = re.ReplaceAllLiteralString(s, line)
+			continue
+		}
+		if s != "" && !strings.HasSuffix(s, "\n") {
+			s += "\n"
+		}
+		s += line + "\n"
+	}
+	return []byte(s)
+}
+
+// setStringResources sets string resources in a strings.xml, replacing the
+// values of existing ones and adding missing ones at the end. The SDK
+// reads them as module configuration, so new entries are marked with
+// moduleConfig. nil data creates the file.
+func setStringResources(data []byte, values [][2]string) ([]byte, error) {
+	s := string(data)
+	if data == nil {
+		s = "<?xml version=\"1.0\" encoding=\"utf-8\"?>\n<resources>\n</resources>\n"
+	}
+	if !stringsEnd.MatchString(s) {
+		return nil, errors.New("no closing </resources> tag found")
+	}
+
+	indent := "    "
+	if m := stringsItem.FindStringSubmatch(s); m != nil {
+		indent = m[1]
+	}
+	for _, kv := range values {
+		name, value := kv[0], escapeXML(kv[1])
+		existing := regexp.MustCompile(`(<string\b[^>]*\bname="` + regexp.QuoteMeta(name) + `"[^>]*>)[^<]*(</string>)`)
+		if loc := existing.FindStringSubmatchIndex(s); loc != nil {
+			s = s[:loc[3]] + value + s[loc[4]:]
+			continue
+		}
+		locs := stringsEnd.FindAllStringIndex(s, -1)
+		at := locs[len(locs)-1][0]
+		s = s[:at] + fmt.Sprintf("%s<string moduleConfig=\"true\" name=\"%s\">%s</string>\n", indent, name, value) + s[at:]
+	}
+	return []byte(s), nil
+}
+
+// planMainApplication overrides getJSBundleFile in the ReactNativeHost of
+// MainApplication to return the CodePush bundle.
+func planMainApplication(plan *Plan, projectDir, androidDir string) error {
+	srcDir := filepath.Join(androidDir, "app", "src", "main", "java")
+	var path string
+	_ = filepath.WalkDir(filepath.Join(projectDir, srcDir), func(p string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() || path != "" {
+			return nil
+		}
+		if d.Name() == "MainApplication.kt" || d.Name() == "MainApplication.java" {
+			path, _ = filepath.Rel(projectDir, p)
+			return filepath.SkipAll
+		}
+		return nil
+	})
+	if path == "" {
+		plan.note("return CodePush.getJSBundleFile() from getJSBundleFile in your ReactNativeHost")
+		return nil
+	}
+
+	data, err := os.ReadFile(filepath.Join(projectDir, path))
+	if err != nil {
+		return fmt.Errorf("reading %s: %w", path, err)
+	}
+	s := string(data)
+	if strings.Contains(s, "CodePush.getJSBundleFile()") {
+		return nil
+	}
+	if strings.Contains(s, "getJSBundleFile") {
+		plan.note("return CodePush.getJSBundleFile() from the existing getJSBundleFile in %s", path)
+		return nil
+	}
+	m := reactHost.FindStringSubmatchIndex(s)
+	if m == nil {
+		plan.note("could not find the ReactNativeHost in %s: override getJSBundleFile to return CodePush.getJSBundleFile()", path)
+		return nil
+	}
+
+	indent := s[m[2]:m[3]] + "    "
+	if next := strings.SplitN(s[m[1]:], "\n", 2)[0]; strings.TrimSpace(next) != "" {
+		indent = next[:len(next)-len(strings.TrimLeft(next, " \t"))]
+	}
+	override := indent + "override fun getJSBundleFile(): String = CodePush.getJSBundleFile()\n\n"
+	importLine := "import com.microsoft.codepush.react.CodePush\n"
+	if filepath.Ext(path) == ".java" {
+		override = indent + "@Override\n" +
+			indent + "protected String getJSBundleFile() {\n" +
+			indent + "  return CodePush.getJSBundleFile();\n" +
+			indent + "}\n\n"
+		importLine = "import com.microsoft.codepush.react.CodePush;\n"
+	}
+	s = s[:m[1]] + override + s[m[1]:]
+	if !strings.Contains(s, importLine) {
+		at := 0
+		if locs := javaImport.FindAllStringIndex(s, -1); locs != nil {
+			at = locs[len(locs)-1][1]
+		}
+		s = s[:at] + importLine + s[at:]
+	}
+	plan.add(path, data, []byte(s))
+	return nil
+}
@@ -0,0 +1,240 @@
+package integrate
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testSettingsGradle = `rootProject.name = 'Demo'
+include ':app'
+`
+
+const testBuildGradle = `apply plugin: "com.android.application"
+
+dependencies {
+    implementation("com.facebook.react:react-android")
+}
+`
+
+const testMainApplicationKt = `package com.demo
+
+import android.app.Application
+import com.facebook.react.defaults.DefaultReactNativeHost
+
+class MainApplication : Application(), ReactApplication {
+  override val reactNativeHost: ReactNativeHost =
+      object : DefaultReactNativeHost(this) {
+        override fun getJSMainModuleName(): String = "index"
+      }
+}
+`
+
+var (
+	settingsPath        = filepath.Join("android", "settings.gradle")
+	buildPath           = filepath.Join("android", "app", "build.gradle")
+	stringsPath         = filepath.Join("android", "app", "src", "main", "res", "values", "strings.xml")
+	mainApplicationPath = filepath.Join("android", "app", "src", "main", "java", "com", "demo", "MainApplication.kt")
+)
+
+func androidProject(t *testing.T, extra map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"package.json":      `{"dependencies":{"react-native":"0.74.0","@code-push-next/react-native-code-push":"10.0.0"}}`,
+		settingsPath:        testSettingsGradle,
+		buildPath:           testBuildGradle,
+		mainApplicationPath: testMainApplicationKt,
+	})
+	writeFiles(t, dir, extra)
+	return dir
+}
+
+func TestPlanAndroid(t *testing.T) {
+	dir := androidProject(t, nil)
+	opts := Options{ProjectDir: dir, DeploymentKey: testKey, ServerURL: "https://api.example.com?a=1&b=2"}
+
+	plan, err := PlanAndroid(opts)
+	require.NoError(t, err)
+	require.Len(t, plan.Changes, 4)
+
+	assert.Equal(t, `rootProject.name = 'Demo'
+include ':app'
+
+include ':react-native-code-push'
+project(':react-native-code-push').projectDir = new File(rootProject.projectDir, '../node_modules/@code-push-next/react-native-code-push/android/app')
+`, changeOf(t, plan, settingsPath))
+
+	assert.Equal(t, `apply plugin: "com.android.application"
+
+dependencies {
+    implementation project(':react-native-code-push')
+    implementation("com.facebook.react:react-android")
+}
+
+apply from: "../../node_modules/@code-push-next/react-native-code-push/android/codepush.gradle"
+`, changeOf(t, plan, buildPath))
+
+	assert.Equal(t, `<?xml version="1.0" encoding="utf-8"?>
+<resources>
+    <string moduleConfig="true" name="CodePushDeploymentKey">dep-key-123</string>
+    <string moduleConfig="true" name="CodePushServerUrl">https://api.example.com?a=1&amp;b=2</string>
+</resources>
+`, changeOf(t, plan, stringsPath))
+
+	assert.Equal(t, `package com.demo
+
+import android.app.Application
+import com.facebook.react.defaults.DefaultReactNativeHost
+import com.microsoft.codepush.react.CodePush
+
+class MainApplication : Application(), ReactApplication {
+  override val reactNativeHost: ReactNativeHost =
+      object : DefaultReactNativeHost(this) {
+        override fun getJSBundleFile(): String = CodePush.getJSBundleFile()
+
+        override fun getJSMainModuleName(): String = "index"
+      }
+}
+`, changeOf(t, plan, mainApplicationPath))
+
+	_, err = plan.Apply()
+	require.NoError(t, err)
+	backup, err := os.ReadFile(filepath.Join(dir, buildPath+BackupSuffix))
+	require.NoError(t, err)
+	assert.Equal(t, testBuildGradle, string(backup))
+	assert.NoFileExists(t, filepath.Join(dir, stringsPath+BackupSuffix), "created files have nothing to back up")
+
+	again, err := PlanAndroid(opts)
+	require.NoError(t, err)
+	assert.Empty(t, again.Changes, "a second run should change nothing")
+}
+
+func TestPlanAndroidAutolinking(t *testing.T) {
+	dir := androidProject(t, map[string]string{
+		settingsPath: "apply from: file(\"../node_modules/@react-native-community/cli-platform-android/native_modules.gradle\")\ninclude ':app'\n",
+	})
+
+	plan, err := PlanAndroid(Options{ProjectDir: dir, DeploymentKey: testKey})
+	require.NoError(t, err)
+	build := changeOf(t, plan, buildPath)
+	assert.NotContains(t, build, "implementation project")
+	assert.Contains(t, build, "codepush.gradle")
+	for _, c := range plan.Changes {
+		assert.NotEqual(t, settingsPath, c.Path)
+	}
+}
+
+func TestPlanAndroidKotlinDSL(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"settings.gradle.kts":                 "include(\":app\")\n",
+		"app/build.gradle.kts":                "dependencies {\n}\n",
+		"app/src/main/res/values/strings.xml": "<resources>\n  <string name=\"app_name\">Demo</string>\n</resources>\n",
+	})
+
+	plan, err := PlanAndroid(Options{ProjectDir: dir, DeploymentKey: testKey})
+	require.NoError(t, err)
+	assert.Contains(t, changeOf(t, plan, "settings.gradle.kts"), `include(":react-native-code-push")`+"\n"+`project(":react-native-code-push").projectDir = File(rootProject.projectDir, "node_modules/@code-push-next/react-native-code-push/android/app")`)
+	build := changeOf(t, plan, filepath.Join("app", "build.gradle.kts"))
+	assert.Contains(t, build, `    implementation(project(":react-native-code-push"))`)
+	assert.Contains(t, build, `apply(from = "../node_modules/@code-push-next/react-native-code-push/android/codepush.gradle")`)
+	assert.Equal(t, "<resources>\n  <string name=\"app_name\">Demo</string>\n  <string moduleConfig=\"true\" name=\"CodePushDeploymentKey\">dep-key-123</string>\n</resources>\n",
+		changeOf(t, plan, filepath.Join("app", "src", "main", "res", "values", "strings.xml")))
+	assert.Contains(t, plan.Notes, "return CodePush.getJSBundleFile() from getJSBundleFile in your ReactNativeHost")
+	assert.Contains(t, plan.Notes, "install the SDK: npm install @code-push-next/react-native-code-push")
+}
+
+func TestPlanAndroidGradleProperties(t *testing.T) {
+	dir := androidProject(t, map[string]string{
+		"android/gradle.properties": "org.gradle.jvmargs=-Xmx2048m\nCodePushDeploymentKey=old",
+	})
+
+	plan, err := PlanAndroid(Options{ProjectDir: dir, DeploymentKey: testKey, ServerURL: "https://api.example.com"})
+	require.NoError(t, err)
+	assert.Equal(t, "org.gradle.jvmargs=-Xmx2048m\nCodePushDeploymentKey=dep-key-123\nCodePushServerUrl=https://api.example.com\n",
+		changeOf(t, plan, filepath.Join("android", "gradle.properties")))
+	for _, c := range plan.Changes {
+		assert.NotEqual(t, stringsPath, c.Path)
+	}
+}
+
+func TestPlanAndroidJavaMainApplication(t *testing.T) {
+	javaPath := filepath.Join("android", "app", "src", "main", "java", "com", "demo", "MainApplication.java")
+	dir := androidProject(t, map[string]string{javaPath: `package com.demo;
+
+import android.app.Application;
+
+public class MainApplication extends Application implements ReactApplication {
+  private final ReactNativeHost mReactNativeHost =
+      new DefaultReactNativeHost(this) {
+        @Override
+        protected String getJSMainModuleName() {
+          return "index";
+        }
+      };
+}
+`})
+	require.NoError(t, os.Remove(filepath.Join(dir, mainApplicationPath)))
+
+	plan, err := PlanAndroid(Options{ProjectDir: dir, DeploymentKey: testKey})
+	require.NoError(t, err)
+	assert.Equal(t, `package com.demo;
+
+import android.app.Application;
+import com.microsoft.codepush.react.CodePush;
+
+public class MainApplication extends Application implements ReactApplication {
+  private final ReactNativeHost mReactNativeHost =
+      new DefaultReactNativeHost(this) {
+        @Override
+        protected String getJSBundleFile() {
+          return CodePush.getJSBundleFile();
+        }
+
+        @Override
+        protected String getJSMainModuleName() {
+          return "index";
+        }
+      };
+}
+`, changeOf(t, plan, javaPath))
+}
+
+func TestPlanAndroidErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		files   map[string]string
+		wantErr string
+	}{
+		{
+			name:    "no settings.gradle",
+			files:   map[string]string{"android/app/build.gradle": testBuildGradle},
+			wantErr: "no settings.gradle found in android",
+		},
+		{
+			name:    "no app build.gradle",
+			files:   map[string]string{"android/settings.gradle": testSettingsGradle},
+			wantErr: "no build.gradle found in " + filepath.Join("android", "app"),
+		},
+		{
+			name:    "malformed strings.xml",
+			files:   map[string]string{"android/settings.gradle": testSettingsGradle, "android/app/build.gradle": testBuildGradle, "android/app/src/main/res/values/strings.xml": "<resources>"},
+			wantErr: "no closing </resources> tag found",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			writeFiles(t, dir, tt.files)
+
+			_, err := PlanAndroid(Options{ProjectDir: dir, DeploymentKey: testKey})
+			require.Error(t, err)
+			assert.ErrorContains(t, err, tt.wantErr)
+		})
+	}
+}
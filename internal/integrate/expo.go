@@ -0,0 +1,261 @@
+package integrate
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/bitrise-io/bitrise-plugins-codepush-cli/internal/bundler"
+)
+
+// SDKPackage is the CodePush SDK package that integrate configures. Unlike
+// the original react-native-code-push package, it provides an Expo config
+// plugin.
+const SDKPackage = "@code-push-next/react-native-code-push"
+
+// MinExpoSDK is the oldest Expo SDK the config plugin supports.
+const MinExpoSDK = 50
+
+var (
+	// jsPlugins and jsExtra match the start of the plugins array and the
+	// extra object in a dynamic Expo config, capturing the indentation.
+	jsPlugins = regexp.MustCompile(`(?m)^([ \t]*)plugins\s*:\s*\[`)
+	jsExtra   = regexp.MustCompile(`(?m)^([ \t]*)extra\s*:\s*\{`)
+)
+
+// PlanExpo plans the integration of an Expo project: the CodePush config
+// plugin is added to the Expo config, and the deployment key and server URL
+// are set under extra.codePush, where the plugin reads them during
+// prebuild. app.json and app.config.json are edited as JSON; in
+// app.config.js and app.config.ts the entries are inserted into existing
+// plugins and extra blocks, with a note for each entry that could not be.
+//
+// It fails when the Expo SDK is older than MinExpoSDK or the project uses a
+// CodePush SDK without a config plugin.
+func PlanExpo(opts Options) (*Plan, error) {
+	plan := &Plan{ProjectDir: opts.ProjectDir}
+	if err := planExpo(plan, opts); err != nil {
+		return nil, err
+	}
+	return plan, nil
+}
+
+// planExpo adds the Expo integration to plan, on top of the changes it
+// already holds.
+func planExpo(plan *Plan, opts Options) error {
+	if opts.DeploymentKey == "" {
+		return errors.New("a deployment key is required")
+	}
+	if err := checkExpoSDK(opts.ProjectDir, plan); err != nil {
+		return err
+	}
+	switch sdk := bundler.DetectSDK(opts.ProjectDir); {
+	case sdk == nil:
+		plan.note("install the SDK: npx expo install %s", SDKPackage)
+	case sdk.Package != SDKPackage:
+		return fmt.Errorf("%s has no Expo config plugin: replace it with %s", sdk.Package, SDKPackage)
+	}
+
+	path, err := expoConfigFile(opts.ProjectDir)
+	if err != nil {
+		return err
+	}
+	data, err := plan.read(path)
+	if err != nil {
+		return fmt.Errorf("reading %s: %w", path, err)
+	}
+	if filepath.Ext(path) == ".json" {
+		err = planExpoJSON(plan, path, data, opts)
+	} else {
+		planExpoJS(plan, path, data, opts)
+	}
+	if err != nil {
+		return err
+	}
+
+	plan.note("run npx expo prebuild (or an EAS build) to apply the config plugin to the native projects")
+	return nil
+}
+
+// expoConfigFile returns the Expo config file to edit. A dynamic config
+// takes precedence, as it does for Expo.
+func expoConfigFile(projectDir string) (string, error) {
+	for _, name := range []string{"app.config.ts", "app.config.js", "app.json", "app.config.json"} {
+		if _, err := os.Stat(filepath.Join(projectDir, name)); err == nil {
+			return name, nil
+		}
+	}
+	return "", errors.New("no Expo config found: expected app.json, app.config.js, or app.config.ts")
+}
+
+// codePushExtra is the extra.codePush entry read by the config plugin.
+type codePushExtra struct {
+	DeploymentKey string `json:"deploymentKey"`
+	ServerURL     string `json:"serverUrl,omitempty"`
+}
+
+func planExpoJSON(plan *Plan, path string, data []byte, opts Options) error {
+	var root jsonObject
+	if err := json.Unmarshal(data, &root); err != nil {
+		return fmt.Errorf("parsing %s: %w", path, err)
+	}
+	expo := root
+	_, wrapped := root.get("expo")
+	if wrapped {
+		var err error
+		if expo, err = root.object("expo"); err != nil {
+			return fmt.Errorf("parsing expo in %s: %w", path, err)
+		}
+	}
+
+	changed := false
+	var plugins []json.RawMessage
+	if raw, ok := expo.get("plugins"); ok {
+		if err := json.Unmarshal(raw, &plugins); err != nil {
+			return fmt.Errorf("parsing plugins in %s: %w", path, err)
+		}
+	}
+	if !hasPlugin(plugins, SDKPackage) {
+		name, _ := json.Marshal(SDKPackage)
+		if err := expo.set("plugins", append(plugins, name)); err != nil {
+			return err
+		}
+		changed = true
+	}
+
+	extra, err := expo.object("extra")
+	if err != nil {
+		return fmt.Errorf("parsing extra in %s: %w", path, err)
+	}
+	want, err := rawJSON(codePushExtra{DeploymentKey: opts.DeploymentKey, ServerURL: opts.ServerURL})
+	if err != nil {
+		return err
+	}
+	if current, ok := extra.get("codePush"); !ok || !jsonEqual(current, want) {
+		if err := extra.set("codePush", want); err != nil {
+			return err
+		}
+		if err := expo.set("extra", extra); err != nil {
+			return err
+		}
+		changed = true
+	}
+
+	if !changed {
+		return nil
+	}
+	if wrapped {
+		if err := root.set("expo", expo); err != nil {
+			return err
+		}
+	} else {
+		root = expo
+	}
+	after, err := marshalConfig(root)
+	if err != nil {
+		return err
+	}
+	plan.add(path, data, after)
+	return nil
+}
+
+// hasPlugin reports whether plugins lists name, either as a string or as
+// the first element of a [name, options] pair.
+func hasPlugin(plugins []json.RawMessage, name string) bool {
+	for _, raw := range plugins {
+		var s string
+		if json.Unmarshal(raw, &s) == nil && s == name {
+			return true
+		}
+		var pair []json.RawMessage
+		if json.Unmarshal(raw, &pair) == nil && len(pair) > 0 && json.Unmarshal(pair[0], &s) == nil && s == name {
+			return true
+		}
+	}
+	return false
+}
+
+func jsonEqual(a, b json.RawMessage) bool {
+	var x, y any
+	return json.Unmarshal(a, &x) == nil && json.Unmarshal(b, &y) == nil && reflect.DeepEqual(x, y)
+}
+
+func planExpoJS(plan *Plan, path string, data []byte, opts Options) {
+	s := string(data)
+	plugin := strconv.Quote(SDKPackage)
+	extra := "codePush: { deploymentKey: " + strconv.Quote(opts.DeploymentKey)
+	if opts.ServerURL != "" {
+		extra += ", serverUrl: " + strconv.Quote(opts.ServerURL)
+	}
+	extra += " }"
+
+	if !strings.Contains(s, SDKPackage) {
+		if m := jsPlugins.FindStringSubmatchIndex(s); m != nil {
+			s = s[:m[1]] + "\n" + s[m[2]:m[3]] + "  " + plugin + "," + s[m[1]:]
+		} else {
+			plan.note("add %s to the plugins of %s", plugin, path)
+		}
+	}
+
+	if !strings.Contains(s, opts.DeploymentKey) {
+		m := jsExtra.FindStringSubmatchIndex(s)
+		switch {
+		case strings.Contains(s, "codePush"):
+			plan.note("replace the codePush entry in the extra of %s with %s", path, extra)
+		case m != nil:
+			s = s[:m[1]] + "\n" + s[m[2]:m[3]] + "  " + extra + "," + s[m[1]:]
+		default:
+			plan.note("add extra: { %s } to %s", extra, path)
+		}
+	}
+
+	plan.add(path, data, []byte(s))
+}
+
+// checkExpoSDK fails when the project's Expo SDK is older than MinExpoSDK,
+// and notes when its version cannot be determined.
+func checkExpoSDK(projectDir string, plan *Plan) error {
+	version, source := expoVersion(projectDir)
+	major, err := strconv.Atoi(strings.SplitN(version, ".", 2)[0])
+	if err != nil {
+		plan.note("could not determine the Expo SDK version: the config plugin requires Expo SDK %d or newer", MinExpoSDK)
+		return nil
+	}
+	if major < MinExpoSDK {
+		return fmt.Errorf("the project uses Expo SDK %d (from %s), which the CodePush config plugin does not support: upgrade to Expo SDK %d or newer", major, source, MinExpoSDK)
+	}
+	return nil
+}
+
+// expoVersion returns the version of the expo package installed in
+// node_modules, or else the version range declared in package.json, and
+// the file it was read from.
+func expoVersion(projectDir string) (string, string) {
+	var installed struct {
+		Version string `json:"version"`
+	}
+	installedPath := filepath.Join("node_modules", "expo", "package.json")
+	if data, err := os.ReadFile(filepath.Join(projectDir, installedPath)); err == nil && json.Unmarshal(data, &installed) == nil && installed.Version != "" {
+		return installed.Version, installedPath
+	}
+
+	var pkg struct {
+		Dependencies    map[string]string `json:"dependencies"`
+		DevDependencies map[string]string `json:"devDependencies"`
+	}
+	data, err := os.ReadFile(filepath.Join(projectDir, "package.json"))
+	if err != nil || json.Unmarshal(data, &pkg) != nil {
+		return "", ""
+	}
+	declared := pkg.Dependencies["expo"]
+	if declared == "" {
+		declared = pkg.DevDependencies["expo"]
+	}
+	return strings.TrimLeft(declared, "^~>=<v "), "package.json"
+}
@@ -0,0 +1,244 @@
+package integrate
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testKey = "dep-key-123"
+
+func writeFiles(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+	}
+}
+
+func expoProject(t *testing.T, config map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"package.json": `{"dependencies":{"expo":"~51.0.0","@code-push-next/react-native-code-push":"^10.0.0"}}`,
+	})
+	writeFiles(t, dir, config)
+	return dir
+}
+
+func TestPlanExpoJSON(t *testing.T) {
+	dir := expoProject(t, map[string]string{"app.json": `{
+  "expo": {
+    "name": "demo",
+    "plugins": [
+      "expo-router"
+    ],
+    "extra": {
+      "eas": {
+        "projectId": "p1"
+      }
+    }
+  }
+}
+`})
+
+	plan, err := PlanExpo(Options{ProjectDir: dir, DeploymentKey: testKey, ServerURL: "https://api.example.com?a=1&b=2"})
+	require.NoError(t, err)
+	require.Len(t, plan.Changes, 1)
+	assert.Equal(t, "app.json", plan.Changes[0].Path)
+	assert.Equal(t, `{
+  "expo": {
+    "name": "demo",
+    "plugins": [
+      "expo-router",
+      "@code-push-next/react-native-code-push"
+    ],
+    "extra": {
+      "eas": {
+        "projectId": "p1"
+      },
+      "codePush": {
+        "deploymentKey": "dep-key-123",
+        "serverUrl": "https://api.example.com?a=1&b=2"
+      }
+    }
+  }
+}
+`, string(plan.Changes[0].After))
+	assert.Contains(t, plan.Diff(), `+      "@code-push-next/react-native-code-push"`)
+
+	_, err = plan.Apply()
+	require.NoError(t, err)
+	again, err := PlanExpo(Options{ProjectDir: dir, DeploymentKey: testKey, ServerURL: "https://api.example.com?a=1&b=2"})
+	require.NoError(t, err)
+	assert.Empty(t, again.Changes, "a second run should change nothing")
+}
+
+func TestPlanExpoJSONUpdatesKey(t *testing.T) {
+	dir := expoProject(t, map[string]string{"app.json": `{"name":"demo","plugins":[["@code-push-next/react-native-code-push",{}]],"extra":{"codePush":{"deploymentKey":"old"}}}`})
+
+	plan, err := PlanExpo(Options{ProjectDir: dir, DeploymentKey: testKey})
+	require.NoError(t, err)
+	require.Len(t, plan.Changes, 1)
+	after := string(plan.Changes[0].After)
+	assert.Contains(t, after, `"deploymentKey": "dep-key-123"`)
+	assert.NotContains(t, after, `"old"`)
+	assert.Equal(t, 1, strings.Count(after, SDKPackage), "the plugin should not be added twice")
+}
+
+func TestPlanExpoJS(t *testing.T) {
+	tests := []struct {
+		name      string
+		config    string
+		want      string
+		wantNotes []string
+	}{
+		{
+			name: "inserts into existing blocks",
+			config: `export default {
+  name: "demo",
+  plugins: [
+    "expo-router",
+  ],
+  extra: {
+    eas: { projectId: "p1" },
+  },
+};
+`,
+			want: `export default {
+  name: "demo",
+  plugins: [
+    "@code-push-next/react-native-code-push",
+    "expo-router",
+  ],
+  extra: {
+    codePush: { deploymentKey: "dep-key-123", serverUrl: "https://api.example.com" },
+    eas: { projectId: "p1" },
+  },
+};
+`,
+		},
+		{
+			name:   "notes missing blocks",
+			config: "export default ({ config }) => config;\n",
+			want:   "export default ({ config }) => config;\n",
+			wantNotes: []string{
+				`add "@code-push-next/react-native-code-push" to the plugins of app.config.js`,
+				`add extra: { codePush: { deploymentKey: "dep-key-123", serverUrl: "https://api.example.com" } } to app.config.js`,
+			},
+		},
+		{
+			name:   "notes a stale codePush entry",
+			config: "export default {\n  plugins: [\"@code-push-next/react-native-code-push\"],\n  extra: { codePush: { deploymentKey: \"old\" } },\n};\n",
+			want:   "export default {\n  plugins: [\"@code-push-next/react-native-code-push\"],\n  extra: { codePush: { deploymentKey: \"old\" } },\n};\n",
+			wantNotes: []string{
+				`replace the codePush entry in the extra of app.config.js with codePush: { deploymentKey: "dep-key-123", serverUrl: "https://api.example.com" }`,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := expoProject(t, map[string]string{"app.config.js": tt.config})
+
+			plan, err := PlanExpo(Options{ProjectDir: dir, DeploymentKey: testKey, ServerURL: "https://api.example.com"})
+			require.NoError(t, err)
+
+			if tt.want == tt.config {
+				assert.Empty(t, plan.Changes)
+			} else {
+				require.Len(t, plan.Changes, 1)
+				assert.Equal(t, tt.want, string(plan.Changes[0].After))
+			}
+			for _, note := range tt.wantNotes {
+				assert.Contains(t, plan.Notes, note)
+			}
+		})
+	}
+}
+
+func TestPlanExpoPrefersDynamicConfig(t *testing.T) {
+	dir := expoProject(t, map[string]string{
+		"app.json":      `{"expo":{"name":"demo"}}`,
+		"app.config.ts": "export default {\n  plugins: [],\n  extra: {},\n};\n",
+	})
+
+	plan, err := PlanExpo(Options{ProjectDir: dir, DeploymentKey: testKey})
+	require.NoError(t, err)
+	require.Len(t, plan.Changes, 1)
+	assert.Equal(t, "app.config.ts", plan.Changes[0].Path)
+}
+
+func TestPlanExpoErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		files   map[string]string
+		key     string
+		wantErr string
+	}{
+		{
+			name:    "missing key",
+			files:   map[string]string{"package.json": `{"dependencies":{"expo":"51.0.0"}}`, "app.json": `{}`},
+			wantErr: "a deployment key is required",
+		},
+		{
+			name:    "old Expo SDK",
+			files:   map[string]string{"package.json": `{"dependencies":{"expo":"~49.0.0"}}`, "app.json": `{}`},
+			key:     testKey,
+			wantErr: "the project uses Expo SDK 49 (from package.json)",
+		},
+		{
+			name:    "installed Expo SDK wins over the declared range",
+			files:   map[string]string{"package.json": `{"dependencies":{"expo":"^51.0.0"}}`, "node_modules/expo/package.json": `{"version":"48.0.1"}`, "app.json": `{}`},
+			key:     testKey,
+			wantErr: "Expo SDK 48 (from node_modules/expo/package.json)",
+		},
+		{
+			name:    "SDK without config plugin",
+			files:   map[string]string{"package.json": `{"dependencies":{"expo":"51.0.0","react-native-code-push":"8.1.0"}}`, "app.json": `{}`},
+			key:     testKey,
+			wantErr: "react-native-code-push has no Expo config plugin",
+		},
+		{
+			name:    "no config",
+			files:   map[string]string{"package.json": `{"dependencies":{"expo":"51.0.0"}}`},
+			key:     testKey,
+			wantErr: "no Expo config found",
+		},
+		{
+			name:    "invalid app.json",
+			files:   map[string]string{"package.json": `{"dependencies":{"expo":"51.0.0"}}`, "app.json": `[]`},
+			key:     testKey,
+			wantErr: "parsing app.json",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			writeFiles(t, dir, tt.files)
+
+			_, err := PlanExpo(Options{ProjectDir: dir, DeploymentKey: tt.key})
+			require.Error(t, err)
+			assert.ErrorContains(t, err, tt.wantErr)
+		})
+	}
+}
+
+func TestPlanExpoNotes(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"package.json": `{"dependencies":{"expo":"latest"}}`,
+		"app.json":     `{"expo":{}}`,
+	})
+
+	plan, err := PlanExpo(Options{ProjectDir: dir, DeploymentKey: testKey})
+	require.NoError(t, err)
+	assert.Contains(t, plan.Notes, "could not determine the Expo SDK version: the config plugin requires Expo SDK 50 or newer")
+	assert.Contains(t, plan.Notes, "install the SDK: npx expo install @code-push-next/react-native-code-push")
+}
@@ -0,0 +1,277 @@
+package integrate
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// ExpoUpdatesPackage is the Expo package that serves EAS Update updates.
+const ExpoUpdatesPackage = "expo-updates"
+
+var (
+	// jsUpdates matches the updates block in a dynamic Expo config.
+	jsUpdates = regexp.MustCompile(`(?m)^[ \t]*updates\s*:\s*\{`)
+	// easUpdateURL matches an EAS Update URL.
+	easUpdateURL = regexp.MustCompile(`https://u\.expo\.dev/[\w-]+`)
+)
+
+// ExpoUpdates is the expo-updates setup of an Expo project.
+type ExpoUpdates struct {
+	// ConfigFile is the Expo config file, relative to the project.
+	ConfigFile string `json:"config_file"`
+	// Package is the declared expo-updates version, empty when it is not a
+	// dependency.
+	Package string `json:"package,omitempty"`
+	// UpdatesURL is the EAS Update URL from the updates block.
+	UpdatesURL string `json:"updates_url,omitempty"`
+	// Configured reports whether the Expo config has an updates block that
+	// does not disable updates.
+	Configured bool `json:"configured"`
+	// Channels are the EAS Update channels of the build profiles in
+	// eas.json, sorted by name.
+	Channels []Channel `json:"channels"`
+}
+
+// Channel is an EAS Update channel and the build profiles that use it.
+type Channel struct {
+	Name     string   `json:"name"`
+	Profiles []string `json:"profiles"`
+}
+
+// DetectExpoUpdates reads the expo-updates setup of an Expo project: the
+// expo-updates dependency, the updates block of the Expo config, and the
+// channels of the EAS build profiles. It fails when none of them is found.
+func DetectExpoUpdates(projectDir string) (*ExpoUpdates, error) {
+	config, err := expoConfigFile(projectDir)
+	if err != nil {
+		return nil, err
+	}
+	setup := &ExpoUpdates{ConfigFile: config, Channels: []Channel{}}
+
+	var pkg struct {
+		Dependencies map[string]string `json:"dependencies"`
+	}
+	if data, err := os.ReadFile(filepath.Join(projectDir, "package.json")); err == nil && json.Unmarshal(data, &pkg) == nil {
+		setup.Package = pkg.Dependencies[ExpoUpdatesPackage]
+	}
+
+	data, err := os.ReadFile(filepath.Join(projectDir, config))
+	if err != nil {
+		return nil, fmt.Errorf("reading %s: %w", config, err)
+	}
+	if filepath.Ext(config) == ".json" {
+		if err := readUpdatesJSON(setup, config, data); err != nil {
+			return nil, err
+		}
+	} else {
+		setup.Configured = jsUpdates.Match(data)
+		if m := easUpdateURL.Find(data); m != nil {
+			setup.UpdatesURL = string(m)
+		}
+	}
+
+	if setup.Channels, err = easChannels(projectDir); err != nil {
+		return nil, err
+	}
+	if setup.Package == "" && !setup.Configured && len(setup.Channels) == 0 {
+		return nil, errors.New("no expo-updates setup found: expected an expo-updates dependency, an updates block in the Expo config, or channels in eas.json")
+	}
+	return setup, nil
+}
+
+func readUpdatesJSON(setup *ExpoUpdates, path string, data []byte) error {
+	var root struct {
+		Expo    *expoUpdatesConfig `json:"expo"`
+		Updates *updatesBlock      `json:"updates"`
+	}
+	if err := json.Unmarshal(data, &root); err != nil {
+		return fmt.Errorf("parsing %s: %w", path, err)
+	}
+	updates := root.Updates
+	if root.Expo != nil {
+		updates = root.Expo.Updates
+	}
+	if updates != nil {
+		setup.UpdatesURL = updates.URL
+		setup.Configured = updates.Enabled == nil || *updates.Enabled
+	}
+	return nil
+}
+
+type expoUpdatesConfig struct {
+	Updates *updatesBlock `json:"updates"`
+}
+
+type updatesBlock struct {
+	URL     string `json:"url"`
+	Enabled *bool  `json:"enabled"`
+}
+
+// easChannels returns the channels of the build profiles in eas.json.
+func easChannels(projectDir string) ([]Channel, error) {
+	data, err := os.ReadFile(filepath.Join(projectDir, "eas.json"))
+	if errors.Is(err, os.ErrNotExist) {
+		return []Channel{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading eas.json: %w", err)
+	}
+	var eas struct {
+		Build map[string]struct {
+			Channel string `json:"channel"`
+		} `json:"build"`
+	}
+	if err := json.Unmarshal(data, &eas); err != nil {
+		return nil, fmt.Errorf("parsing eas.json: %w", err)
+	}
+
+	profiles := make(map[string][]string)
+	for profile, build := range eas.Build {
+		if build.Channel != "" {
+			profiles[build.Channel] = append(profiles[build.Channel], profile)
+		}
+	}
+	channels := make([]Channel, 0, len(profiles))
+	for name, p := range profiles {
+		sort.Strings(p)
+		channels = append(channels, Channel{Name: name, Profiles: p})
+	}
+	sort.Slice(channels, func(i, j int) bool { return channels[i].Name < channels[j].Name })
+	return channels, nil
+}
+
+// MapChannels maps each channel to a deployment: the one given in
+// overrides, or else the deployment with the same name, ignoring case. It
+// returns the channels left without a deployment.
+func MapChannels(channels []Channel, deployments []string, overrides map[string]string) (map[string]string, []string) {
+	mapped := make(map[string]string, len(channels))
+	var unmapped []string
+	for _, ch := range channels {
+		if d, ok := overrides[ch.Name]; ok {
+			mapped[ch.Name] = d
+			continue
+		}
+		found := false
+		for _, d := range deployments {
+			if strings.EqualFold(d, ch.Name) {
+				mapped[ch.Name] = d
+				found = true
+				break
+			}
+		}
+		if !found {
+			unmapped = append(unmapped, ch.Name)
+		}
+	}
+	return mapped, unmapped
+}
+
+// PlanExpoUpdatesMigration plans moving an Expo project from expo-updates
+// to CodePush: updates are disabled in the Expo config, the expo-updates
+// config plugin and EAS Update URL are removed, and the project is then
+// integrated like PlanExpo does. Steps that cannot be done safely, like
+// uninstalling expo-updates, are noted. Modified files are backed up.
+func PlanExpoUpdatesMigration(opts Options, setup *ExpoUpdates) (*Plan, error) {
+	plan := &Plan{ProjectDir: opts.ProjectDir, Backup: true}
+
+	data, err := plan.read(setup.ConfigFile)
+	if err != nil {
+		return nil, fmt.Errorf("reading %s: %w", setup.ConfigFile, err)
+	}
+	if filepath.Ext(setup.ConfigFile) == ".json" {
+		if err := planDisableUpdatesJSON(plan, setup.ConfigFile, data); err != nil {
+			return nil, err
+		}
+	} else if setup.Configured {
+		plan.note("disable expo-updates in %s: set updates to { enabled: false } and remove its url", setup.ConfigFile)
+	}
+
+	if err := planExpo(plan, opts); err != nil {
+		return nil, err
+	}
+
+	if setup.Package != "" {
+		plan.note("uninstall expo-updates (npm uninstall %s), which would otherwise also manage the JS bundle", ExpoUpdatesPackage)
+	}
+	if len(setup.Channels) > 0 {
+		plan.note("the channel of each EAS build profile in eas.json no longer selects updates: the deployment key in the Expo config does, so build each deployment's key into its profile, for example with an environment variable read by app.config.js")
+	}
+	plan.note("replace eas update in scripts and workflows with codepush push")
+	return plan, nil
+}
+
+// planDisableUpdatesJSON sets updates.enabled to false, removes the EAS
+// Update URL, and removes the expo-updates config plugin.
+func planDisableUpdatesJSON(plan *Plan, path string, data []byte) error {
+	var root jsonObject
+	if err := json.Unmarshal(data, &root); err != nil {
+		return fmt.Errorf("parsing %s: %w", path, err)
+	}
+	expo := root
+	_, wrapped := root.get("expo")
+	if wrapped {
+		var err error
+		if expo, err = root.object("expo"); err != nil {
+			return fmt.Errorf("parsing expo in %s: %w", path, err)
+		}
+	}
+
+	updates, err := expo.object("updates")
+	if err != nil {
+		return fmt.Errorf("parsing updates in %s: %w", path, err)
+	}
+	changed := updates.remove("url")
+	if enabled, ok := updates.get("enabled"); !ok || string(enabled) != "false" {
+		if err := updates.set("enabled", false); err != nil {
+			return err
+		}
+		changed = true
+	}
+	if changed {
+		if err := expo.set("updates", updates); err != nil {
+			return err
+		}
+	}
+
+	if raw, ok := expo.get("plugins"); ok {
+		var plugins []json.RawMessage
+		if err := json.Unmarshal(raw, &plugins); err != nil {
+			return fmt.Errorf("parsing plugins in %s: %w", path, err)
+		}
+		kept := make([]json.RawMessage, 0, len(plugins))
+		for _, p := range plugins {
+			if !hasPlugin([]json.RawMessage{p}, ExpoUpdatesPackage) {
+				kept = append(kept, p)
+			}
+		}
+		if len(kept) != len(plugins) {
+			if err := expo.set("plugins", kept); err != nil {
+				return err
+			}
+			changed = true
+		}
+	}
+
+	if !changed {
+		return nil
+	}
+	if wrapped {
+		if err := root.set("expo", expo); err != nil {
+			return err
+		}
+	} else {
+		root = expo
+	}
+	after, err := marshalConfig(root)
+	if err != nil {
+		return err
+	}
+	plan.add(path, data, after)
+	return nil
+}
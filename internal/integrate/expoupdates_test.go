@@ -0,0 +1,120 @@
+package integrate
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const easJSON = `{
+  "build": {
+    "development": {"developmentClient": true},
+    "preview": {"channel": "preview"},
+    "internal": {"channel": "preview"},
+    "production": {"channel": "production"}
+  }
+}`
+
+func TestDetectExpoUpdates(t *testing.T) {
+	dir := expoProject(t, map[string]string{
+		"app.json": `{"expo":{"updates":{"url":"https://u.expo.dev/abc-123"},"runtimeVersion":{"policy":"appVersion"}}}`,
+		"eas.json": easJSON,
+	})
+	writeFiles(t, dir, map[string]string{"package.json": `{"dependencies":{"expo":"~51.0.0","expo-updates":"~0.25.0"}}`})
+
+	setup, err := DetectExpoUpdates(dir)
+	require.NoError(t, err)
+	assert.Equal(t, "app.json", setup.ConfigFile)
+	assert.Equal(t, "~0.25.0", setup.Package)
+	assert.Equal(t, "https://u.expo.dev/abc-123", setup.UpdatesURL)
+	assert.True(t, setup.Configured)
+	assert.Equal(t, []Channel{
+		{Name: "preview", Profiles: []string{"internal", "preview"}},
+		{Name: "production", Profiles: []string{"production"}},
+	}, setup.Channels)
+}
+
+func TestDetectExpoUpdatesJSConfig(t *testing.T) {
+	dir := expoProject(t, map[string]string{"app.config.js": `export default {
+  name: "demo",
+  updates: {
+    url: "https://u.expo.dev/abc-123",
+  },
+};
+`})
+
+	setup, err := DetectExpoUpdates(dir)
+	require.NoError(t, err)
+	assert.True(t, setup.Configured)
+	assert.Equal(t, "https://u.expo.dev/abc-123", setup.UpdatesURL)
+}
+
+func TestDetectExpoUpdatesNotFound(t *testing.T) {
+	dir := expoProject(t, map[string]string{"app.json": `{"expo":{"name":"demo"}}`})
+
+	_, err := DetectExpoUpdates(dir)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "no expo-updates setup found")
+}
+
+func TestMapChannels(t *testing.T) {
+	channels := []Channel{{Name: "preview"}, {Name: "production"}, {Name: "beta"}}
+
+	mapped, unmapped := MapChannels(channels, []string{"Staging", "Production"}, map[string]string{"preview": "Staging"})
+	assert.Equal(t, map[string]string{"preview": "Staging", "production": "Production"}, mapped)
+	assert.Equal(t, []string{"beta"}, unmapped)
+}
+
+func TestPlanExpoUpdatesMigration(t *testing.T) {
+	dir := expoProject(t, map[string]string{
+		"app.json": `{
+  "expo": {
+    "name": "demo",
+    "plugins": [
+      "expo-router",
+      ["expo-updates", {"username": "me"}]
+    ],
+    "updates": {
+      "url": "https://u.expo.dev/abc-123",
+      "fallbackToCacheTimeout": 0
+    }
+  }
+}
+`,
+		"eas.json": easJSON,
+	})
+	setup, err := DetectExpoUpdates(dir)
+	require.NoError(t, err)
+
+	plan, err := PlanExpoUpdatesMigration(Options{ProjectDir: dir, DeploymentKey: testKey}, setup)
+	require.NoError(t, err)
+	require.Len(t, plan.Changes, 1, "the Expo config should be changed once")
+	assert.True(t, plan.Backup)
+	assert.Equal(t, `{
+  "expo": {
+    "name": "demo",
+    "plugins": [
+      "expo-router",
+      "@code-push-next/react-native-code-push"
+    ],
+    "updates": {
+      "fallbackToCacheTimeout": 0,
+      "enabled": false
+    },
+    "extra": {
+      "codePush": {
+        "deploymentKey": "dep-key-123"
+      }
+    }
+  }
+}
+`, string(plan.Changes[0].After))
+	assert.Contains(t, plan.Notes, "replace eas update in scripts and workflows with codepush push")
+
+	_, err = plan.Apply()
+	require.NoError(t, err)
+	again, err := PlanExpoUpdatesMigration(Options{ProjectDir: dir, DeploymentKey: testKey}, setup)
+	require.NoError(t, err)
+	assert.Empty(t, again.Changes, "a second run should change nothing")
+}
@@ -0,0 +1,235 @@
+package integrate
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/bitrise-io/bitrise-plugins-codepush-cli/internal/bundler"
+)
+
+// Info.plist keys read by the iOS SDK.
+const (
+	plistDeploymentKey = "CodePushDeploymentKey"
+	plistServerURL     = "CodePushServerURL"
+)
+
+var (
+	// podTarget matches the first target block of a Podfile, capturing the
+	// indentation.
+	podTarget = regexp.MustCompile(`(?m)^([ \t]*)target\s+['"][^'"]+['"]\s+do[ \t]*$`)
+	// codePushPod matches a CodePush pod, whichever package provides it.
+	codePushPod = regexp.MustCompile(`(?m)^[ \t]*pod\s+['"]CodePush['"]`)
+	// plistKey matches a key line of a property list, capturing the
+	// indentation.
+	plistKey = regexp.MustCompile(`(?m)^([ \t]*)<key>`)
+
+	// swiftBundleURL and objcBundleURL match the release bundle URL that
+	// AppDelegate returns from bundleURL in a React Native app.
+	swiftBundleURL = regexp.MustCompile(`Bundle\.main\.url\(\s*forResource:\s*"main",\s*withExtension:\s*"jsbundle"\s*\)`)
+	objcBundleURL  = regexp.MustCompile(`\[\s*\[\s*NSBundle\s+mainBundle\s*\]\s+URLForResource:\s*@"main"\s+withExtension:\s*@"jsbundle"\s*\]`)
+	swiftImport    = regexp.MustCompile(`(?m)^import [^\n]+\n`)
+	objcImport     = regexp.MustCompile(`(?m)^#import [^\n]+\n`)
+)
+
+// PlanIOS plans the integration of the native iOS project of a brownfield
+// or bare React Native app: the CodePush pod is added to the Podfile, and
+// the deployment key and server URL are set in Info.plist. With
+// PatchAppDelegate, AppDelegate returns the CodePush bundle URL for release
+// builds; otherwise that step is noted. Modified files are backed up.
+//
+// The iOS project is read from ios/ in the project directory, or from the
+// project directory itself when it has a Podfile.
+func PlanIOS(opts Options) (*Plan, error) {
+	if opts.DeploymentKey == "" {
+		return nil, errors.New("a deployment key is required")
+	}
+	plan := &Plan{ProjectDir: opts.ProjectDir, Backup: true}
+
+	iosDir := "ios"
+	if _, err := os.Stat(filepath.Join(opts.ProjectDir, "Podfile")); err == nil {
+		iosDir = "."
+	}
+
+	pkg := SDKPackage
+	if sdk := bundler.DetectSDK(opts.ProjectDir); sdk != nil {
+		pkg = sdk.Package
+	} else {
+		plan.note("install the SDK: npm install %s", pkg)
+	}
+	if err := planPodfile(plan, opts.ProjectDir, iosDir, pkg); err != nil {
+		return nil, err
+	}
+
+	infoPlist, err := findInfoPlist(opts.ProjectDir, iosDir)
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(filepath.Join(opts.ProjectDir, infoPlist))
+	if err != nil {
+		return nil, fmt.Errorf("reading %s: %w", infoPlist, err)
+	}
+	values := [][2]string{{plistDeploymentKey, opts.DeploymentKey}}
+	if opts.ServerURL != "" {
+		values = append(values, [2]string{plistServerURL, opts.ServerURL})
+	}
+	after, err := setPlistStrings(data, values)
+	if err != nil {
+		return nil, fmt.Errorf("editing %s: %w", infoPlist, err)
+	}
+	plan.add(infoPlist, data, after)
+
+	if err := planAppDelegate(plan, opts, filepath.Dir(infoPlist)); err != nil {
+		return nil, err
+	}
+
+	plan.note("run pod install in %s to install the CodePush pod", iosDir)
+	return plan, nil
+}
+
+// planPodfile adds the CodePush pod, from the SDK package in node_modules,
+// to the first target of the Podfile.
+func planPodfile(plan *Plan, projectDir, iosDir, pkg string) error {
+	path := filepath.Join(iosDir, "Podfile")
+	data, err := os.ReadFile(filepath.Join(projectDir, path))
+	if err != nil {
+		return fmt.Errorf("reading %s: %w", path, err)
+	}
+	s := string(data)
+	if codePushPod.MatchString(s) {
+		return nil
+	}
+
+	podPath, err := filepath.Rel(iosDir, filepath.Join("node_modules", pkg))
+	if err != nil {
+		return err
+	}
+	m := podTarget.FindStringSubmatchIndex(s)
+	if m == nil {
+		plan.note("add pod 'CodePush', :path => '%s' to the app target of %s", filepath.ToSlash(podPath), path)
+		return nil
+	}
+	pod := fmt.Sprintf("\n%s  pod 'CodePush', :path => '%s'", s[m[2]:m[3]], filepath.ToSlash(podPath))
+	plan.add(path, data, []byte(s[:m[1]]+pod+s[m[1]:]))
+	return nil
+}
+
+// findInfoPlist returns the Info.plist of the app target: the one next to
+// the Xcode project of the same name, or the only one outside test targets.
+func findInfoPlist(projectDir, iosDir string) (string, error) {
+	dir := filepath.Join(projectDir, iosDir)
+	plists, _ := filepath.Glob(filepath.Join(dir, "*", "Info.plist"))
+	plists = slices.DeleteFunc(plists, func(p string) bool {
+		target := filepath.Base(filepath.Dir(p))
+		return strings.HasSuffix(target, "Tests") || target == "Pods"
+	})
+	rel := func(p string) string {
+		r, _ := filepath.Rel(projectDir, p)
+		return r
+	}
+
+	switch len(plists) {
+	case 0:
+		return "", fmt.Errorf("no Info.plist found in %s", filepath.Join(iosDir, "*"))
+	case 1:
+		return rel(plists[0]), nil
+	}
+	for _, p := range plists {
+		target := filepath.Base(filepath.Dir(p))
+		if _, err := os.Stat(filepath.Join(dir, target+".xcodeproj")); err == nil {
+			return rel(p), nil
+		}
+	}
+	return "", fmt.Errorf("found %d Info.plist files in %s and none matches an Xcode project name", len(plists), filepath.Join(iosDir, "*"))
+}
+
+// setPlistStrings sets string values in an XML property list, replacing the
+// values of existing keys and adding missing keys at the end of the top
+// level dictionary with the indentation of its first key.
+func setPlistStrings(data []byte, values [][2]string) ([]byte, error) {
+	s := string(data)
+	if strings.HasPrefix(s, "bplist") {
+		return nil, errors.New("binary property lists are not supported: convert it with plutil -convert xml1")
+	}
+	end := strings.LastIndex(s, "</dict>")
+	if end < 0 || !strings.Contains(s, "<plist") {
+		return nil, errors.New("not an XML property list")
+	}
+
+	indent := "\t"
+	if m := plistKey.FindStringSubmatch(s); m != nil {
+		indent = m[1]
+	}
+	for _, kv := range values {
+		key, value := kv[0], escapeXML(kv[1])
+		existing := regexp.MustCompile(`(<key>` + regexp.QuoteMeta(key) + `</key>\s*<string>)[^<]*(</string>)`)
+		if loc := existing.FindStringSubmatchIndex(s); loc != nil {
+			s = s[:loc[3]] + value + s[loc[4]:]
+			end = strings.LastIndex(s, "</dict>")
+			continue
+		}
+		// Insert before the line holding the closing tag of the top level
+		// dictionary.
+		lineStart := strings.LastIndex(s[:end], "\n") + 1
+		entry := fmt.Sprintf("%s<key>%s</key>\n%s<string>%s</string>\n", indent, key, indent, value)
+		s = s[:lineStart] + entry + s[lineStart:]
+		end += len(entry)
+	}
+	return []byte(s), nil
+}
+
+func escapeXML(s string) string {
+	return strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;").Replace(s)
+}
+
+// planAppDelegate makes AppDelegate return the CodePush bundle URL instead
+// of the bundled main.jsbundle, or notes it without PatchAppDelegate.
+func planAppDelegate(plan *Plan, opts Options, targetDir string) error {
+	var path string
+	for _, name := range []string{"AppDelegate.swift", "AppDelegate.mm", "AppDelegate.m"} {
+		if _, err := os.Stat(filepath.Join(opts.ProjectDir, targetDir, name)); err == nil {
+			path = filepath.Join(targetDir, name)
+			break
+		}
+	}
+	if path == "" {
+		plan.note("return the CodePush bundle URL from bundleURL in your AppDelegate for release builds")
+		return nil
+	}
+	data, err := os.ReadFile(filepath.Join(opts.ProjectDir, path))
+	if err != nil {
+		return fmt.Errorf("reading %s: %w", path, err)
+	}
+	s := string(data)
+
+	bundleURL, replacement, imp, importLine := objcBundleURL, "[CodePush bundleURL]", objcImport, "#import <CodePush/CodePush.h>\n"
+	if filepath.Ext(path) == ".swift" {
+		bundleURL, replacement, imp, importLine = swiftBundleURL, "CodePush.bundleURL()", swiftImport, "import CodePush\n"
+	}
+	if strings.Contains(s, replacement) {
+		return nil
+	}
+	if !opts.PatchAppDelegate {
+		plan.note("return %s from bundleURL in %s for release builds, or rerun with --patch-app-delegate", replacement, path)
+		return nil
+	}
+	if !bundleURL.MatchString(s) {
+		plan.note("could not find the main.jsbundle URL in %s: return %s from bundleURL for release builds", path, replacement)
+		return nil
+	}
+
+	s = bundleURL.ReplaceAllLiteralString(s, replacement)
+	if !strings.Contains(s, importLine) {
+		at := 0
+		if locs := imp.FindAllStringIndex(s, -1); locs != nil {
+			at = locs[len(locs)-1][1]
+		}
+		s = s[:at] + importLine + s[at:]
+	}
+	plan.add(path, data, []byte(s))
+	return nil
+}
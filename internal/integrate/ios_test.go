@@ -0,0 +1,234 @@
+package integrate
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testPodfile = `platform :ios, '15.0'
+
+target 'Demo' do
+  config = use_native_modules!
+end
+`
+
+const testInfoPlist = `<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE plist PUBLIC "-//Apple//DTD PLIST 1.0//EN" "http://www.apple.com/DTDs/PropertyList-1.0.dtd">
+<plist version="1.0">
+<dict>
+	<key>CFBundleShortVersionString</key>
+	<string>1.0</string>
+</dict>
+</plist>
+`
+
+const testSwiftDelegate = `import UIKit
+import React
+
+class AppDelegate: RCTAppDelegate {
+  override func bundleURL() -> URL? {
+#if DEBUG
+    RCTBundleURLProvider.sharedSettings().jsBundleURL(forBundleRoot: "index")
+#else
+    Bundle.main.url(forResource: "main", withExtension: "jsbundle")
+#endif
+  }
+}
+`
+
+func iosProject(t *testing.T, extra map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"package.json":             `{"dependencies":{"react-native":"0.74.0","@code-push-next/react-native-code-push":"10.0.0"}}`,
+		"ios/Podfile":              testPodfile,
+		"ios/Demo/Info.plist":      testInfoPlist,
+		"ios/DemoTests/Info.plist": testInfoPlist,
+	})
+	writeFiles(t, dir, extra)
+	return dir
+}
+
+func changeOf(t *testing.T, plan *Plan, path string) string {
+	t.Helper()
+	for _, c := range plan.Changes {
+		if c.Path == path {
+			return string(c.After)
+		}
+	}
+	t.Fatalf("no change planned for %s", path)
+	return ""
+}
+
+func TestPlanIOS(t *testing.T) {
+	dir := iosProject(t, map[string]string{"ios/Demo/AppDelegate.swift": testSwiftDelegate})
+	opts := Options{ProjectDir: dir, DeploymentKey: testKey, ServerURL: "https://api.example.com?a=1&b=2", PatchAppDelegate: true}
+
+	plan, err := PlanIOS(opts)
+	require.NoError(t, err)
+	require.Len(t, plan.Changes, 3)
+
+	assert.Equal(t, `platform :ios, '15.0'
+
+target 'Demo' do
+  pod 'CodePush', :path => '../node_modules/@code-push-next/react-native-code-push'
+  config = use_native_modules!
+end
+`, changeOf(t, plan, filepath.Join("ios", "Podfile")))
+
+	assert.Equal(t, `<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE plist PUBLIC "-//Apple//DTD PLIST 1.0//EN" "http://www.apple.com/DTDs/PropertyList-1.0.dtd">
+<plist version="1.0">
+<dict>
+	<key>CFBundleShortVersionString</key>
+	<string>1.0</string>
+	<key>CodePushDeploymentKey</key>
+	<string>dep-key-123</string>
+	<key>CodePushServerURL</key>
+	<string>https://api.example.com?a=1&amp;b=2</string>
+</dict>
+</plist>
+`, changeOf(t, plan, filepath.Join("ios", "Demo", "Info.plist")))
+
+	delegate := changeOf(t, plan, filepath.Join("ios", "Demo", "AppDelegate.swift"))
+	assert.Contains(t, delegate, "import React\nimport CodePush\n")
+	assert.Contains(t, delegate, "    CodePush.bundleURL()\n")
+	assert.NotContains(t, delegate, "main.jsbundle")
+
+	_, err = plan.Apply()
+	require.NoError(t, err)
+	backup, err := os.ReadFile(filepath.Join(dir, "ios", "Demo", "Info.plist"+BackupSuffix))
+	require.NoError(t, err)
+	assert.Equal(t, testInfoPlist, string(backup))
+
+	again, err := PlanIOS(opts)
+	require.NoError(t, err)
+	assert.Empty(t, again.Changes, "a second run should change nothing")
+}
+
+func TestPlanIOSUpdatesKey(t *testing.T) {
+	plist := `<?xml version="1.0" encoding="UTF-8"?>
+<plist version="1.0">
+<dict>
+    <key>CodePushDeploymentKey</key>
+    <string>old</string>
+</dict>
+</plist>
+`
+	dir := iosProject(t, map[string]string{"ios/Demo/Info.plist": plist})
+
+	plan, err := PlanIOS(Options{ProjectDir: dir, DeploymentKey: testKey})
+	require.NoError(t, err)
+	assert.Equal(t, `<?xml version="1.0" encoding="UTF-8"?>
+<plist version="1.0">
+<dict>
+    <key>CodePushDeploymentKey</key>
+    <string>dep-key-123</string>
+</dict>
+</plist>
+`, changeOf(t, plan, filepath.Join("ios", "Demo", "Info.plist")))
+	assert.Contains(t, plan.Notes, "return the CodePush bundle URL from bundleURL in your AppDelegate for release builds")
+}
+
+func TestPlanIOSObjCAppDelegate(t *testing.T) {
+	delegate := `#import "AppDelegate.h"
+
+#import <React/RCTBundleURLProvider.h>
+
+@implementation AppDelegate
+
+- (NSURL *)bundleURL
+{
+  return [[NSBundle mainBundle] URLForResource:@"main" withExtension:@"jsbundle"];
+}
+
+@end
+`
+	dir := iosProject(t, map[string]string{"ios/Demo/AppDelegate.mm": delegate})
+
+	plan, err := PlanIOS(Options{ProjectDir: dir, DeploymentKey: testKey, PatchAppDelegate: true})
+	require.NoError(t, err)
+	assert.Equal(t, `#import "AppDelegate.h"
+
+#import <React/RCTBundleURLProvider.h>
+#import <CodePush/CodePush.h>
+
+@implementation AppDelegate
+
+- (NSURL *)bundleURL
+{
+  return [CodePush bundleURL];
+}
+
+@end
+`, changeOf(t, plan, filepath.Join("ios", "Demo", "AppDelegate.mm")))
+}
+
+func TestPlanIOSNotesAppDelegateWithoutPatch(t *testing.T) {
+	dir := iosProject(t, map[string]string{"ios/Demo/AppDelegate.swift": testSwiftDelegate})
+
+	plan, err := PlanIOS(Options{ProjectDir: dir, DeploymentKey: testKey})
+	require.NoError(t, err)
+	assert.Len(t, plan.Changes, 2)
+	assert.Contains(t, plan.Notes, "return CodePush.bundleURL() from bundleURL in "+filepath.Join("ios", "Demo", "AppDelegate.swift")+" for release builds, or rerun with --patch-app-delegate")
+}
+
+func TestPlanIOSBrownfieldLayout(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"Podfile":                        testPodfile,
+		"Demo/Info.plist":                testInfoPlist,
+		"Widget/Info.plist":              testInfoPlist,
+		"Demo.xcodeproj/project.pbxproj": "",
+	})
+
+	plan, err := PlanIOS(Options{ProjectDir: dir, DeploymentKey: testKey})
+	require.NoError(t, err)
+	assert.Contains(t, changeOf(t, plan, "Podfile"), "pod 'CodePush', :path => 'node_modules/@code-push-next/react-native-code-push'")
+	assert.Contains(t, changeOf(t, plan, filepath.Join("Demo", "Info.plist")), "<string>dep-key-123</string>")
+	assert.Contains(t, plan.Notes, "install the SDK: npm install @code-push-next/react-native-code-push")
+}
+
+func TestPlanIOSErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		files   map[string]string
+		wantErr string
+	}{
+		{
+			name:    "no Podfile",
+			files:   map[string]string{"ios/Demo/Info.plist": testInfoPlist},
+			wantErr: "reading " + filepath.Join("ios", "Podfile"),
+		},
+		{
+			name:    "no Info.plist",
+			files:   map[string]string{"ios/Podfile": testPodfile},
+			wantErr: "no Info.plist found",
+		},
+		{
+			name:    "ambiguous Info.plist",
+			files:   map[string]string{"ios/Podfile": testPodfile, "ios/A/Info.plist": testInfoPlist, "ios/B/Info.plist": testInfoPlist},
+			wantErr: "found 2 Info.plist files",
+		},
+		{
+			name:    "binary Info.plist",
+			files:   map[string]string{"ios/Podfile": testPodfile, "ios/Demo/Info.plist": "bplist00"},
+			wantErr: "binary property lists are not supported",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			writeFiles(t, dir, tt.files)
+
+			_, err := PlanIOS(Options{ProjectDir: dir, DeploymentKey: testKey})
+			require.Error(t, err)
+			assert.ErrorContains(t, err, tt.wantErr)
+		})
+	}
+}
@@ -0,0 +1,136 @@
+package integrate
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+)
+
+// jsonObject is a JSON object that keeps the order of its members, so
+// editing a configuration file does not reorder it.
+type jsonObject []jsonMember
+
+type jsonMember struct {
+	key   string
+	value json.RawMessage
+}
+
+func (o *jsonObject) UnmarshalJSON(data []byte) error {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	tok, err := dec.Token()
+	if err != nil {
+		return err
+	}
+	if d, ok := tok.(json.Delim); !ok || d != '{' {
+		return errors.New("expected a JSON object")
+	}
+	*o = nil
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return err
+		}
+		key, _ := tok.(string)
+		var value json.RawMessage
+		if err := dec.Decode(&value); err != nil {
+			return err
+		}
+		*o = append(*o, jsonMember{key: key, value: value})
+	}
+	return nil
+}
+
+func (o jsonObject) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, m := range o {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		key, err := json.Marshal(m.key)
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(key)
+		buf.WriteByte(':')
+		buf.Write(m.value)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+// get returns the value of key.
+func (o jsonObject) get(key string) (json.RawMessage, bool) {
+	for _, m := range o {
+		if m.key == key {
+			return m.value, true
+		}
+	}
+	return nil, false
+}
+
+// set replaces the value of key, or appends key when it is missing.
+func (o *jsonObject) set(key string, v any) error {
+	value, err := rawJSON(v)
+	if err != nil {
+		return err
+	}
+	for i, m := range *o {
+		if m.key == key {
+			(*o)[i].value = value
+			return nil
+		}
+	}
+	*o = append(*o, jsonMember{key: key, value: value})
+	return nil
+}
+
+// remove deletes key and reports whether it was present.
+func (o *jsonObject) remove(key string) bool {
+	for i, m := range *o {
+		if m.key == key {
+			*o = append((*o)[:i], (*o)[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// object returns the object value of key, or an empty object when key is
+// missing.
+func (o jsonObject) object(key string) (jsonObject, error) {
+	var obj jsonObject
+	raw, ok := o.get(key)
+	if !ok || string(raw) == "null" {
+		return obj, nil
+	}
+	if err := json.Unmarshal(raw, &obj); err != nil {
+		return nil, err
+	}
+	return obj, nil
+}
+
+// rawJSON marshals v without escaping HTML characters, which are common in
+// URLs.
+func rawJSON(v any) (json.RawMessage, error) {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(v); err != nil {
+		return nil, err
+	}
+	return bytes.TrimRight(buf.Bytes(), "\n"), nil
+}
+
+// marshalConfig formats o like the JSON configuration files of JavaScript
+// projects: indented with two spaces and ending in a newline.
+func marshalConfig(o jsonObject) ([]byte, error) {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(o); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,89 @@
+// Package integrate configures mobile projects for the CodePush SDK.
+package integrate
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// maxConfigFileSize skips files too large to be project configuration.
+const maxConfigFileSize = 1 << 20
+
+// skippedDirs are dependency and build output directories that never hold
+// the project's own configuration.
+var skippedDirs = map[string]bool{
+	".git":         true,
+	".gradle":      true,
+	"build":        true,
+	"DerivedData":  true,
+	"node_modules": true,
+	"Pods":         true,
+}
+
+// isConfigFile reports whether name is a file the SDK reads its deployment
+// key from: Info.plist and xcconfig files on iOS, strings.xml, Gradle files,
+// and properties on Android, and app.json or .env files in React Native and
+// Expo projects.
+func isConfigFile(name string) bool {
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".plist", ".xcconfig", ".xml", ".gradle", ".kts", ".properties", ".json":
+		return true
+	}
+	return name == ".env" || strings.HasPrefix(name, ".env.")
+}
+
+// ReplaceDeploymentKey replaces every occurrence of oldKey with newKey in the
+// configuration files under projectDir and returns the paths of the files it
+// changed, relative to projectDir.
+func ReplaceDeploymentKey(projectDir, oldKey, newKey string) ([]string, error) {
+	if oldKey == "" || newKey == "" {
+		return nil, errors.New("both the old and the new deployment key are required")
+	}
+
+	var changed []string
+	err := filepath.WalkDir(projectDir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if path != projectDir && skippedDirs[d.Name()] {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !d.Type().IsRegular() || !isConfigFile(d.Name()) {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil || info.Size() > maxConfigFileSize {
+			return err
+		}
+
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		if !bytes.Contains(data, []byte(oldKey)) {
+			return nil
+		}
+		data = bytes.ReplaceAll(data, []byte(oldKey), []byte(newKey))
+		if err := os.WriteFile(path, data, info.Mode().Perm()); err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(projectDir, path)
+		if err != nil {
+			rel = path
+		}
+		changed = append(changed, rel)
+		return nil
+	})
+	if err != nil {
+		return changed, fmt.Errorf("updating deployment key in project files: %w", err)
+	}
+	return changed, nil
+}
@@ -0,0 +1,49 @@
+package integrate
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReplaceDeploymentKey(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"ios/App/Info.plist":                          "<key>CodePushDeploymentKey</key>\n<string>old-key</string>",
+		"android/app/src/main/res/values/strings.xml": `<string name="CodePushDeploymentKey">old-key</string>`,
+		"app.json":                           `{"codePushKey": "other-key"}`,
+		".env.production":                    "CODEPUSH_KEY=old-key\n",
+		"src/config.ts":                      "export const key = 'old-key'",
+		"node_modules/some-lib/package.json": `{"key": "old-key"}`,
+		"ios/Pods/Target Support Files/Pods.xcconfig": "KEY = old-key",
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+	}
+
+	changed, err := ReplaceDeploymentKey(dir, "old-key", "new-key")
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{
+		".env.production",
+		filepath.Join("android", "app", "src", "main", "res", "values", "strings.xml"),
+		filepath.Join("ios", "App", "Info.plist"),
+	}, changed)
+
+	read := func(name string) string {
+		data, err := os.ReadFile(filepath.Join(dir, name))
+		require.NoError(t, err)
+		return string(data)
+	}
+	assert.Contains(t, read("ios/App/Info.plist"), "<string>new-key</string>")
+	assert.Equal(t, "export const key = 'old-key'", read("src/config.ts"))
+	assert.Contains(t, read("node_modules/some-lib/package.json"), "old-key")
+	assert.Contains(t, read("ios/Pods/Target Support Files/Pods.xcconfig"), "old-key")
+
+	_, err = ReplaceDeploymentKey(dir, "", "new-key")
+	assert.Error(t, err)
+}
@@ -0,0 +1,150 @@
+package integrate
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/pmezard/go-difflib/difflib"
+)
+
+// BackupSuffix is appended to the path of a file to name its backup.
+const BackupSuffix = ".codepush.bak"
+
+// Options configures the SDK integration.
+type Options struct {
+	ProjectDir    string
+	DeploymentKey string
+	// ServerURL is the CodePush server the SDK checks for updates.
+	ServerURL string
+	// PatchAppDelegate makes the native iOS integration load the bundle
+	// from CodePush in AppDelegate instead of only noting it.
+	PatchAppDelegate bool
+}
+
+// FileChange is a planned edit of one project file.
+type FileChange struct {
+	// Path is relative to the project directory.
+	Path string
+	// Before is the current content, nil when the file is created.
+	Before []byte
+	After  []byte
+}
+
+// Diff returns the change as a unified diff.
+func (c FileChange) Diff() string {
+	from := "a/" + filepath.ToSlash(c.Path)
+	if c.Before == nil {
+		from = "/dev/null"
+	}
+	diff, _ := difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+		A:        splitLines(c.Before),
+		B:        splitLines(c.After),
+		FromFile: from,
+		ToFile:   "b/" + filepath.ToSlash(c.Path),
+		Context:  3,
+	})
+	return diff
+}
+
+// splitLines splits data into lines that each end in a newline.
+// difflib.SplitLines would add an empty last line to content that ends in a
+// newline.
+func splitLines(data []byte) []string {
+	if len(data) == 0 {
+		return nil
+	}
+	lines := strings.SplitAfter(string(data), "\n")
+	if last := lines[len(lines)-1]; last == "" {
+		lines = lines[:len(lines)-1]
+	} else {
+		lines[len(lines)-1] = last + "\n"
+	}
+	return lines
+}
+
+// Plan is the set of edits that integrate the SDK into a project, plus
+// notes on steps that have to be done by hand. Files that already have the
+// wanted content are not part of it, so running integrate twice plans no
+// changes the second time.
+type Plan struct {
+	ProjectDir string
+	Changes    []FileChange
+	Notes      []string
+	// Backup makes Apply copy each existing file to its path plus
+	// BackupSuffix before modifying it.
+	Backup bool
+}
+
+// add plans writing after to path, unless the file already has that
+// content. A second change to the same path replaces the planned content,
+// so edits by several planners combine into one change.
+func (p *Plan) add(path string, before, after []byte) {
+	for i, c := range p.Changes {
+		if c.Path != path {
+			continue
+		}
+		if c.Before != nil && bytes.Equal(c.Before, after) {
+			p.Changes = append(p.Changes[:i], p.Changes[i+1:]...)
+		} else {
+			p.Changes[i].After = after
+		}
+		return
+	}
+	if before != nil && bytes.Equal(before, after) {
+		return
+	}
+	p.Changes = append(p.Changes, FileChange{Path: path, Before: before, After: after})
+}
+
+// read returns the planned content of path, or else its content on disk.
+func (p *Plan) read(path string) ([]byte, error) {
+	for _, c := range p.Changes {
+		if c.Path == path {
+			return c.After, nil
+		}
+	}
+	return os.ReadFile(filepath.Join(p.ProjectDir, path))
+}
+
+// note records a step that has to be done by hand.
+func (p *Plan) note(format string, args ...any) {
+	p.Notes = append(p.Notes, fmt.Sprintf(format, args...))
+}
+
+// Diff returns the unified diff of all changes.
+func (p *Plan) Diff() string {
+	var b strings.Builder
+	for _, c := range p.Changes {
+		b.WriteString(c.Diff())
+	}
+	return b.String()
+}
+
+// Apply writes the planned changes and returns the paths it wrote, relative
+// to the project directory. Existing files keep their permissions.
+func (p *Plan) Apply() ([]string, error) {
+	var written []string
+	for _, c := range p.Changes {
+		path := filepath.Join(p.ProjectDir, c.Path)
+		perm := os.FileMode(0o644)
+		if info, err := os.Stat(path); err == nil {
+			perm = info.Mode().Perm()
+			if p.Backup {
+				if err := os.WriteFile(path+BackupSuffix, c.Before, perm); err != nil {
+					return written, fmt.Errorf("backing up %s: %w", c.Path, err)
+				}
+			}
+		}
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			return written, fmt.Errorf("creating directory for %s: %w", c.Path, err)
+		}
+		if err := os.WriteFile(path, c.After, perm); err != nil {
+			return written, fmt.Errorf("writing %s: %w", c.Path, err)
+		}
+		written = append(written, c.Path)
+	}
+	return written, nil
+}
@@ -0,0 +1,83 @@
+package integrate
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFileChangeDiff(t *testing.T) {
+	tests := []struct {
+		name   string
+		change FileChange
+		want   string
+	}{
+		{
+			name:   "modified file",
+			change: FileChange{Path: "app.json", Before: []byte("a\nb\n"), After: []byte("a\nc\n")},
+			want:   "--- a/app.json\n+++ b/app.json\n@@ -1,2 +1,2 @@\n a\n-b\n+c\n",
+		},
+		{
+			name:   "new file",
+			change: FileChange{Path: "ios/New.txt", After: []byte("x\n")},
+			want:   "--- /dev/null\n+++ b/ios/New.txt\n@@ -0,0 +1 @@\n+x\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, tt.change.Diff())
+		})
+	}
+}
+
+func TestPlanAddSkipsUnchanged(t *testing.T) {
+	var plan Plan
+	plan.add("same.txt", []byte("x"), []byte("x"))
+	plan.add("new.txt", nil, []byte{})
+	plan.add("changed.txt", []byte("x"), []byte("y"))
+
+	require.Len(t, plan.Changes, 2)
+	assert.Equal(t, "new.txt", plan.Changes[0].Path)
+	assert.Equal(t, "changed.txt", plan.Changes[1].Path)
+}
+
+func TestPlanApply(t *testing.T) {
+	dir := t.TempDir()
+	existing := filepath.Join(dir, "run.sh")
+	require.NoError(t, os.WriteFile(existing, []byte("old"), 0o755))
+
+	plan := Plan{ProjectDir: dir}
+	plan.add("run.sh", []byte("old"), []byte("new"))
+	plan.add(filepath.Join("sub", "created.txt"), nil, []byte("created"))
+
+	written, err := plan.Apply()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"run.sh", filepath.Join("sub", "created.txt")}, written)
+
+	data, err := os.ReadFile(existing)
+	require.NoError(t, err)
+	assert.Equal(t, "new", string(data))
+	info, err := os.Stat(existing)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0o755), info.Mode().Perm())
+
+	data, err = os.ReadFile(filepath.Join(dir, "sub", "created.txt"))
+	require.NoError(t, err)
+	assert.Equal(t, "created", string(data))
+}
+
+func TestPlanAddMergesChangesToOneFile(t *testing.T) {
+	plan := &Plan{ProjectDir: t.TempDir()}
+	plan.add("a.txt", []byte("one"), []byte("two"))
+	plan.add("a.txt", []byte("two"), []byte("three"))
+	require.Len(t, plan.Changes, 1)
+	assert.Equal(t, "one", string(plan.Changes[0].Before))
+	assert.Equal(t, "three", string(plan.Changes[0].After))
+
+	plan.add("a.txt", []byte("three"), []byte("one"))
+	assert.Empty(t, plan.Changes, "a change back to the original content should be dropped")
+}
@@ -0,0 +1,83 @@
+package integrate
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// rewriteExtensions are the extensions of files that can hold a deployment
+// key: native and Expo configs, build scripts, sources, and env files.
+var rewriteExtensions = []string{
+	".plist", ".xml", ".properties", ".json", ".js", ".jsx", ".ts", ".tsx",
+	".gradle", ".kts", ".xcconfig", ".swift", ".m", ".mm", ".java", ".kt", ".env",
+}
+
+// rewriteSkipDirs are directories that hold dependencies or build output.
+var rewriteSkipDirs = []string{"node_modules", "Pods", "build", ".git", ".gradle", ".expo"}
+
+// maxRewriteSize is the size above which a file is not searched for keys.
+const maxRewriteSize = 1 << 20
+
+// RewriteKeys plans replacing each deployment key in keys with its new
+// value in the project's configuration and source files, skipping
+// dependencies, build output, and backups. Modified files are backed up.
+func RewriteKeys(projectDir string, keys map[string]string) (*Plan, error) {
+	plan := &Plan{ProjectDir: projectDir, Backup: true}
+	if len(keys) == 0 {
+		return plan, nil
+	}
+	pairs := make([]string, 0, 2*len(keys))
+	for from, to := range keys {
+		pairs = append(pairs, from, to)
+	}
+	replacer := strings.NewReplacer(pairs...)
+
+	err := filepath.WalkDir(projectDir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if path != projectDir && slices.Contains(rewriteSkipDirs, d.Name()) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !rewritable(d) {
+			return nil
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		after := replacer.Replace(string(data))
+		if after == string(data) {
+			return nil
+		}
+		rel, err := filepath.Rel(projectDir, path)
+		if err != nil {
+			return err
+		}
+		plan.add(rel, data, []byte(after))
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("searching for deployment keys: %w", err)
+	}
+	return plan, nil
+}
+
+func rewritable(d fs.DirEntry) bool {
+	name := d.Name()
+	if strings.HasSuffix(name, BackupSuffix) {
+		return false
+	}
+	if !slices.Contains(rewriteExtensions, filepath.Ext(name)) && !strings.HasPrefix(name, ".env") {
+		return false
+	}
+	info, err := d.Info()
+	return err == nil && info.Mode().IsRegular() && info.Size() <= maxRewriteSize
+}
@@ -0,0 +1,52 @@
+package integrate
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRewriteKeys(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"app.json":                        `{"expo":{"extra":{"key":"old-key"}}}`,
+		".env.production":                 "CODEPUSH_KEY=old-key\n",
+		"ios/App/Info.plist":              "<string>old-key</string>",
+		"README.md":                       "old-key",
+		"node_modules/pkg/index.js":       "old-key",
+		"app.json" + BackupSuffix:         "old-key",
+		"android/app/src/main/res/x.json": `{"unrelated":true}`,
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+	}
+
+	plan, err := RewriteKeys(dir, map[string]string{"old-key": "new-key"})
+	require.NoError(t, err)
+
+	var paths []string
+	for _, ch := range plan.Changes {
+		paths = append(paths, ch.Path)
+	}
+	assert.ElementsMatch(t, []string{".env.production", "app.json", filepath.Join("ios", "App", "Info.plist")}, paths)
+
+	_, err = plan.Apply()
+	require.NoError(t, err)
+	data, err := os.ReadFile(filepath.Join(dir, "app.json"))
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "new-key")
+	backup, err := os.ReadFile(filepath.Join(dir, "app.json"+BackupSuffix))
+	require.NoError(t, err)
+	assert.Contains(t, string(backup), "old-key")
+}
+
+func TestRewriteKeysNoKeys(t *testing.T) {
+	plan, err := RewriteKeys(t.TempDir(), nil)
+	require.NoError(t, err)
+	assert.Empty(t, plan.Changes)
+}
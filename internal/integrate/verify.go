@@ -0,0 +1,335 @@
+package integrate
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/bitrise-io/bitrise-plugins-codepush-cli/internal/bundler"
+)
+
+// CheckStatus is the outcome of a verification check.
+type CheckStatus string
+
+const (
+	CheckPass CheckStatus = "pass"
+	CheckWarn CheckStatus = "warn"
+	CheckFail CheckStatus = "fail"
+	CheckSkip CheckStatus = "skip"
+)
+
+// Check is the result of one verification check.
+type Check struct {
+	Name    string      `json:"name"`
+	Status  CheckStatus `json:"status"`
+	Message string      `json:"message"`
+}
+
+// VerifyOptions configures the verification of an integrated project.
+type VerifyOptions struct {
+	ProjectDir string
+	// DeploymentKey is the key the project should be configured with.
+	DeploymentKey string
+	// ServerURL is the server the SDK should check for updates.
+	ServerURL string
+	// Deployments maps the keys of the app's deployments to their names,
+	// to tell which deployment a mismatching key belongs to.
+	Deployments map[string]string
+}
+
+// resPublicKey is the name of the code signing public key in Info.plist and
+// strings.xml alike.
+const resPublicKey = "CodePushPublicKey"
+
+var (
+	jsDeploymentKey = regexp.MustCompile(`deploymentKey\s*:\s*["']([^"']*)["']`)
+	jsServerURL     = regexp.MustCompile(`serverUrl\s*:\s*["']([^"']*)["']`)
+)
+
+// configuredValues are the CodePush settings read from one project file.
+type configuredValues struct {
+	Source    string
+	Key       string
+	ServerURL string
+	PublicKey bool
+}
+
+// Verify checks an integrated project: the SDK dependency and its version,
+// the deployment key and server URL in each configuration the project has
+// (the Expo config, Info.plist, strings.xml or gradle.properties), and
+// whether the native projects' Hermes settings can be bundled for.
+func Verify(opts VerifyOptions) []Check {
+	configs := readConfigs(opts.ProjectDir)
+	return []Check{
+		checkSDK(opts.ProjectDir, configs),
+		checkDeploymentKey(opts, configs),
+		checkServerURL(opts, configs),
+		checkHermes(opts.ProjectDir),
+	}
+}
+
+func checkSDK(projectDir string, configs []configuredValues) Check {
+	c := Check{Name: "SDK"}
+	sdk := bundler.DetectSDK(projectDir)
+	if sdk == nil {
+		c.Status, c.Message = CheckFail, fmt.Sprintf("no CodePush SDK with a known version found: install %s", SDKPackage)
+		return c
+	}
+	found := fmt.Sprintf("%s %s (from %s)", sdk.Package, sdk.Version, sdk.Source)
+
+	if projectType, _ := bundler.DetectProjectType(projectDir); projectType == bundler.ProjectTypeExpo && sdk.Package != SDKPackage {
+		c.Status, c.Message = CheckFail, fmt.Sprintf("%s has no Expo config plugin: replace it with %s", found, SDKPackage)
+		return c
+	}
+	for _, cfg := range configs {
+		if !cfg.PublicKey {
+			continue
+		}
+		if issues := bundler.CheckSDKCompatibility(sdk, []bundler.Feature{bundler.FeatureCodeSigning}); len(issues) > 0 {
+			c.Status, c.Message = CheckFail, fmt.Sprintf("%s sets %s, but %s", cfg.Source, resPublicKey, issues[0])
+			return c
+		}
+	}
+	c.Status, c.Message = CheckPass, found
+	return c
+}
+
+func checkDeploymentKey(opts VerifyOptions, configs []configuredValues) Check {
+	c := Check{Name: "Deployment key"}
+	if len(configs) == 0 {
+		c.Status, c.Message = CheckFail, "no CodePush configuration found: run codepush integrate"
+		return c
+	}
+	if opts.DeploymentKey == "" {
+		c.Status, c.Message = CheckSkip, "no deployment to compare with"
+		return c
+	}
+
+	var problems, sources []string
+	for _, cfg := range configs {
+		sources = append(sources, cfg.Source)
+		switch {
+		case cfg.Key == "":
+			problems = append(problems, fmt.Sprintf("%s sets no deployment key", cfg.Source))
+		case cfg.Key == opts.DeploymentKey:
+		case opts.Deployments[cfg.Key] != "":
+			problems = append(problems, fmt.Sprintf("%s has the key of deployment %s", cfg.Source, opts.Deployments[cfg.Key]))
+		default:
+			problems = append(problems, fmt.Sprintf("%s has a key of no deployment of the app", cfg.Source))
+		}
+	}
+	if len(problems) > 0 {
+		c.Status, c.Message = CheckFail, strings.Join(problems, "; ")
+		return c
+	}
+	c.Status, c.Message = CheckPass, "matches in "+strings.Join(sources, ", ")
+	return c
+}
+
+func checkServerURL(opts VerifyOptions, configs []configuredValues) Check {
+	c := Check{Name: "Server URL"}
+	if len(configs) == 0 {
+		c.Status, c.Message = CheckFail, "no CodePush configuration found: run codepush integrate"
+		return c
+	}
+
+	want := strings.TrimRight(opts.ServerURL, "/")
+	var problems []string
+	for _, cfg := range configs {
+		switch got := strings.TrimRight(cfg.ServerURL, "/"); {
+		case got == "":
+			problems = append(problems, fmt.Sprintf("%s sets no server URL, so the SDK uses its default server", cfg.Source))
+		case got != want:
+			problems = append(problems, fmt.Sprintf("%s sets %s, expected %s", cfg.Source, cfg.ServerURL, opts.ServerURL))
+		}
+	}
+	if len(problems) > 0 {
+		c.Status, c.Message = CheckFail, strings.Join(problems, "; ")
+		return c
+	}
+	c.Status, c.Message = CheckPass, opts.ServerURL
+	return c
+}
+
+// checkHermes fails when a native project enables Hermes but hermesc, which
+// the bundle has to be compiled with, is not installed, and warns when the
+// platforms disagree.
+func checkHermes(projectDir string) Check {
+	c := Check{Name: "Hermes"}
+	var enabled, disabled []string
+	for _, p := range []bundler.Platform{bundler.PlatformIOS, bundler.PlatformAndroid} {
+		if !hasNativeProject(projectDir, p) {
+			continue
+		}
+		if bundler.HermesEnabled(projectDir, p) {
+			enabled = append(enabled, string(p))
+		} else {
+			disabled = append(disabled, string(p))
+		}
+	}
+
+	switch {
+	case len(enabled)+len(disabled) == 0:
+		c.Status, c.Message = CheckSkip, "no native projects found"
+	case len(enabled) > 0 && !bundler.HermescAvailable(projectDir):
+		c.Status, c.Message = CheckFail, fmt.Sprintf("Hermes is enabled for %s, but hermesc was not found in node_modules", strings.Join(enabled, " and "))
+	case len(enabled) > 0 && len(disabled) > 0:
+		c.Status, c.Message = CheckWarn, fmt.Sprintf("Hermes is enabled for %s but not for %s: bundle each platform separately", strings.Join(enabled, " and "), strings.Join(disabled, " and "))
+	case len(enabled) > 0:
+		c.Status, c.Message = CheckPass, "enabled for "+strings.Join(enabled, " and ")
+	default:
+		c.Status, c.Message = CheckPass, "disabled for "+strings.Join(disabled, " and ")
+	}
+	return c
+}
+
+func hasNativeProject(projectDir string, p bundler.Platform) bool {
+	if p == bundler.PlatformIOS {
+		_, err := os.Stat(filepath.Join(projectDir, "ios", "Podfile"))
+		return err == nil
+	}
+	return gradleFile(projectDir, filepath.Join("android", "app"), "build.gradle") != ""
+}
+
+// readConfigs returns the CodePush settings of each configuration the
+// project has.
+func readConfigs(projectDir string) []configuredValues {
+	var configs []configuredValues
+	if projectType, _ := bundler.DetectProjectType(projectDir); projectType == bundler.ProjectTypeExpo {
+		if cfg, ok := readExpoConfig(projectDir); ok {
+			configs = append(configs, cfg)
+		}
+	}
+	if cfg, ok := readIOSConfig(projectDir); ok {
+		configs = append(configs, cfg)
+	}
+	if cfg, ok := readAndroidConfig(projectDir); ok {
+		configs = append(configs, cfg)
+	}
+	return configs
+}
+
+func readExpoConfig(projectDir string) (configuredValues, bool) {
+	path, err := expoConfigFile(projectDir)
+	if err != nil {
+		return configuredValues{}, false
+	}
+	data, err := os.ReadFile(filepath.Join(projectDir, path))
+	if err != nil {
+		return configuredValues{}, false
+	}
+	cfg := configuredValues{Source: path}
+
+	if filepath.Ext(path) != ".json" {
+		if m := jsDeploymentKey.FindSubmatch(data); m != nil {
+			cfg.Key = string(m[1])
+		}
+		if m := jsServerURL.FindSubmatch(data); m != nil {
+			cfg.ServerURL = string(m[1])
+		}
+		return cfg, true
+	}
+
+	var root jsonObject
+	if json.Unmarshal(data, &root) != nil {
+		return cfg, true
+	}
+	expo := root
+	if _, wrapped := root.get("expo"); wrapped {
+		expo, _ = root.object("expo")
+	}
+	extra, _ := expo.object("extra")
+	var codePush codePushExtra
+	if raw, ok := extra.get("codePush"); ok {
+		_ = json.Unmarshal(raw, &codePush)
+	}
+	cfg.Key, cfg.ServerURL = codePush.DeploymentKey, codePush.ServerURL
+	return cfg, true
+}
+
+func readIOSConfig(projectDir string) (configuredValues, bool) {
+	iosDir := "ios"
+	if _, err := os.Stat(filepath.Join(projectDir, "Podfile")); err == nil {
+		iosDir = "."
+	}
+	path, err := findInfoPlist(projectDir, iosDir)
+	if err != nil {
+		return configuredValues{}, false
+	}
+	data, err := os.ReadFile(filepath.Join(projectDir, path))
+	if err != nil {
+		return configuredValues{}, false
+	}
+	s := string(data)
+	key, hasKey := plistString(s, plistDeploymentKey)
+	url, _ := plistString(s, plistServerURL)
+	_, hasPublicKey := plistString(s, resPublicKey)
+	if !hasKey && url == "" {
+		return configuredValues{}, false
+	}
+	return configuredValues{Source: path, Key: key, ServerURL: url, PublicKey: hasPublicKey}, true
+}
+
+func plistString(s, key string) (string, bool) {
+	m := regexp.MustCompile(`<key>` + regexp.QuoteMeta(key) + `</key>\s*<string>([^<]*)</string>`).FindStringSubmatch(s)
+	if m == nil {
+		return "", false
+	}
+	return unescapeXML(m[1]), true
+}
+
+func stringResource(s, name string) (string, bool) {
+	m := regexp.MustCompile(`<string\b[^>]*\bname="` + regexp.QuoteMeta(name) + `"[^>]*>([^<]*)</string>`).FindStringSubmatch(s)
+	if m == nil {
+		return "", false
+	}
+	return unescapeXML(m[1]), true
+}
+
+func unescapeXML(s string) string {
+	return strings.NewReplacer("&lt;", "<", "&gt;", ">", "&amp;", "&").Replace(s)
+}
+
+func readAndroidConfig(projectDir string) (configuredValues, bool) {
+	androidDir := "android"
+	if gradleFile(projectDir, ".", "settings.gradle") != "" {
+		androidDir = "."
+	}
+
+	propsPath := filepath.Join(androidDir, "gradle.properties")
+	if props, err := os.ReadFile(filepath.Join(projectDir, propsPath)); err == nil && propertyLine(resDeploymentKey).Match(props) {
+		cfg := configuredValues{Source: propsPath}
+		cfg.Key, _ = property(props, resDeploymentKey)
+		cfg.ServerURL, _ = property(props, resServerURL)
+		_, cfg.PublicKey = property(props, resPublicKey)
+		return cfg, true
+	}
+
+	stringsPath := filepath.Join(androidDir, "app", "src", "main", "res", "values", "strings.xml")
+	data, err := os.ReadFile(filepath.Join(projectDir, stringsPath))
+	if err != nil {
+		return configuredValues{}, false
+	}
+	s := string(data)
+	key, hasKey := stringResource(s, resDeploymentKey)
+	url, _ := stringResource(s, resServerURL)
+	_, hasPublicKey := stringResource(s, resPublicKey)
+	if !hasKey && url == "" {
+		return configuredValues{}, false
+	}
+	return configuredValues{Source: stringsPath, Key: key, ServerURL: url, PublicKey: hasPublicKey}, true
+}
+
+// property returns the value of key in a Java properties file.
+func property(data []byte, key string) (string, bool) {
+	line := propertyLine(key).Find(data)
+	if line == nil {
+		return "", false
+	}
+	value := strings.TrimSpace(string(line))
+	value = strings.TrimSpace(strings.TrimPrefix(value, key))
+	return strings.TrimSpace(value[1:]), true
+}
@@ -0,0 +1,186 @@
+package integrate
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func checkNamed(t *testing.T, checks []Check, name string) Check {
+	t.Helper()
+	for _, c := range checks {
+		if c.Name == name {
+			return c
+		}
+	}
+	t.Fatalf("no %s check", name)
+	return Check{}
+}
+
+func TestVerifyIntegratedExpoProject(t *testing.T) {
+	dir := expoProject(t, map[string]string{"app.json": `{"expo":{"plugins":["@code-push-next/react-native-code-push"]}}`})
+	opts := Options{ProjectDir: dir, DeploymentKey: testKey, ServerURL: "https://api.example.com"}
+	plan, err := PlanExpo(opts)
+	require.NoError(t, err)
+	_, err = plan.Apply()
+	require.NoError(t, err)
+
+	checks := Verify(VerifyOptions{ProjectDir: dir, DeploymentKey: testKey, ServerURL: "https://api.example.com/"})
+
+	assert.Equal(t, []Check{
+		{Name: "SDK", Status: CheckPass, Message: "@code-push-next/react-native-code-push 10.0.0 (from package.json)"},
+		{Name: "Deployment key", Status: CheckPass, Message: "matches in app.json"},
+		{Name: "Server URL", Status: CheckPass, Message: "https://api.example.com/"},
+		{Name: "Hermes", Status: CheckSkip, Message: "no native projects found"},
+	}, checks)
+}
+
+func TestVerifyDeploymentKey(t *testing.T) {
+	tests := []struct {
+		name        string
+		files       map[string]string
+		want        string
+		wantStatus  CheckStatus
+		wantMessage string
+	}{
+		{
+			name:        "key of another deployment",
+			files:       map[string]string{"ios/Demo/Info.plist": "<plist><dict>\n<key>CodePushDeploymentKey</key>\n<string>staging-key</string>\n</dict></plist>"},
+			want:        "production-key",
+			wantStatus:  CheckFail,
+			wantMessage: "ios/Demo/Info.plist has the key of deployment Staging",
+		},
+		{
+			name:        "unknown key",
+			files:       map[string]string{"android/app/src/main/res/values/strings.xml": `<resources><string moduleConfig="true" name="CodePushDeploymentKey">other</string></resources>`},
+			want:        "production-key",
+			wantStatus:  CheckFail,
+			wantMessage: "android/app/src/main/res/values/strings.xml has a key of no deployment of the app",
+		},
+		{
+			name:        "matching gradle.properties",
+			files:       map[string]string{"android/gradle.properties": "CodePushDeploymentKey = production-key\n"},
+			want:        "production-key",
+			wantStatus:  CheckPass,
+			wantMessage: "matches in android/gradle.properties",
+		},
+		{
+			name:        "not integrated",
+			files:       map[string]string{"package.json": `{"dependencies":{"react-native":"0.74.0"}}`},
+			want:        "production-key",
+			wantStatus:  CheckFail,
+			wantMessage: "no CodePush configuration found: run codepush integrate",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			writeFiles(t, dir, tt.files)
+
+			checks := Verify(VerifyOptions{
+				ProjectDir:    dir,
+				DeploymentKey: tt.want,
+				Deployments:   map[string]string{"staging-key": "Staging", "production-key": "Production"},
+			})
+			got := checkNamed(t, checks, "Deployment key")
+			assert.Equal(t, tt.wantStatus, got.Status)
+			assert.Equal(t, tt.wantMessage, got.Message)
+		})
+	}
+}
+
+func TestVerifyServerURL(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"ios/Demo/Info.plist": "<plist><dict>\n<key>CodePushDeploymentKey</key>\n<string>k</string>\n</dict></plist>",
+		"android/app/src/main/res/values/strings.xml": `<resources>
+  <string name="CodePushDeploymentKey">k</string>
+  <string name="CodePushServerUrl">https://old.example.com?a=1&amp;b=2</string>
+</resources>`,
+	})
+
+	got := checkNamed(t, Verify(VerifyOptions{ProjectDir: dir, ServerURL: "https://api.example.com"}), "Server URL")
+	assert.Equal(t, CheckFail, got.Status)
+	assert.Equal(t, "ios/Demo/Info.plist sets no server URL, so the SDK uses its default server; "+
+		"android/app/src/main/res/values/strings.xml sets https://old.example.com?a=1&b=2, expected https://api.example.com", got.Message)
+}
+
+func TestVerifySDK(t *testing.T) {
+	tests := []struct {
+		name        string
+		files       map[string]string
+		wantStatus  CheckStatus
+		wantMessage string
+	}{
+		{
+			name:        "missing",
+			files:       map[string]string{"package.json": `{"dependencies":{"react-native":"0.74.0"}}`},
+			wantStatus:  CheckFail,
+			wantMessage: "no CodePush SDK with a known version found: install @code-push-next/react-native-code-push",
+		},
+		{
+			name:        "no config plugin in an Expo project",
+			files:       map[string]string{"package.json": `{"dependencies":{"expo":"51.0.0","react-native-code-push":"8.1.0"}}`},
+			wantStatus:  CheckFail,
+			wantMessage: "react-native-code-push 8.1.0 (from package.json) has no Expo config plugin: replace it with @code-push-next/react-native-code-push",
+		},
+		{
+			name: "too old for code signing",
+			files: map[string]string{
+				"package.json":        `{"dependencies":{"react-native":"0.74.0","react-native-code-push":"5.0.0"}}`,
+				"ios/Demo/Info.plist": "<plist><dict>\n<key>CodePushDeploymentKey</key>\n<string>k</string>\n<key>CodePushPublicKey</key>\n<string>pem</string>\n</dict></plist>",
+			},
+			wantStatus:  CheckFail,
+			wantMessage: "ios/Demo/Info.plist sets CodePushPublicKey, but --private-key-path requires react-native-code-push >= 5.1.0",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			writeFiles(t, dir, tt.files)
+
+			got := checkNamed(t, Verify(VerifyOptions{ProjectDir: dir}), "SDK")
+			assert.Equal(t, tt.wantStatus, got.Status)
+			assert.Contains(t, got.Message, tt.wantMessage)
+		})
+	}
+}
+
+func TestVerifyHermes(t *testing.T) {
+	tests := []struct {
+		name        string
+		files       map[string]string
+		wantStatus  CheckStatus
+		wantMessage string
+	}{
+		{
+			name:        "enabled without hermesc",
+			files:       map[string]string{"ios/Podfile": "use_react_native!(:hermes_enabled => true)\n"},
+			wantStatus:  CheckFail,
+			wantMessage: "Hermes is enabled for ios, but hermesc was not found in node_modules",
+		},
+		{
+			name: "disabled on both platforms",
+			files: map[string]string{
+				"ios/Podfile":              "use_react_native!(:hermes_enabled => false)\n",
+				"android/app/build.gradle": "project.ext.react = [enableHermes: false]\n",
+			},
+			wantStatus:  CheckPass,
+			wantMessage: "disabled for ios and android",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			writeFiles(t, dir, tt.files)
+
+			got := checkNamed(t, Verify(VerifyOptions{ProjectDir: dir}), "Hermes")
+			assert.Equal(t, tt.wantStatus, got.Status)
+			assert.Equal(t, tt.wantMessage, got.Message)
+		})
+	}
+}
@@ -3,6 +3,7 @@ package output
 import (
 	"errors"
 	"fmt"
+	"strings"
 
 	"github.com/charmbracelet/huh"
 )
@@ -12,6 +13,13 @@ import (
 // In non-interactive mode without --yes, it returns an error with a hint.
 // In interactive mode without --yes, it shows a y/N prompt.
 func (w *Writer) ConfirmDestructive(msg string, yesFlag bool) error {
+	return w.ConfirmWithFlag(msg, "--yes", yesFlag)
+}
+
+// ConfirmTyped asks the user to type expected, such as the name of the
+// affected deployment, before an operation that is easy to run against the
+// wrong target. The flag bypasses it as in ConfirmDestructive.
+func (w *Writer) ConfirmTyped(msg, expected string, yesFlag bool) error {
 	if yesFlag {
 		return nil
 	}
@@ -20,7 +28,36 @@ func (w *Writer) ConfirmDestructive(msg string, yesFlag bool) error {
 		return fmt.Errorf("%s; use --yes to confirm", msg)
 	}
 
-	w.Warning("%s", msg)
+	w.warning("%s", msg)
+
+	var typed string
+	err := huh.NewInput().
+		Title(fmt.Sprintf("Type %q to continue", expected)).
+		Value(&typed).
+		Run()
+	if err != nil {
+		return fmt.Errorf("confirmation prompt failed: %w", err)
+	}
+
+	if strings.TrimSpace(typed) != expected {
+		return fmt.Errorf("cancelled: typed %q, expected %q", typed, expected)
+	}
+
+	return nil
+}
+
+// ConfirmWithFlag is ConfirmDestructive for confirmations bypassed by a flag
+// other than --yes; flagName is named in the non-interactive error.
+func (w *Writer) ConfirmWithFlag(msg, flagName string, flagSet bool) error {
+	if flagSet {
+		return nil
+	}
+
+	if !w.interactive {
+		return fmt.Errorf("%s; use %s to confirm", msg, flagName)
+	}
+
+	w.warning("%s", msg)
 
 	var confirmed bool
 	err := huh.NewConfirm().
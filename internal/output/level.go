@@ -0,0 +1,54 @@
+package output
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Level is the minimum severity of the messages a Writer prints. Results
+// (Success, Result, Table, Println) and errors are printed at every level.
+type Level int
+
+// Log levels, from most to least verbose. The zero value is LevelInfo.
+const (
+	// LevelDebug adds Debug messages to everything printed at LevelInfo.
+	LevelDebug Level = iota - 1
+	// LevelInfo prints steps, progress indicators, and supplementary info.
+	LevelInfo
+	// LevelWarning prints warnings, but no steps, progress, or info.
+	LevelWarning
+	// LevelError prints only results and errors.
+	LevelError
+)
+
+// String returns the name accepted by ParseLevel.
+func (l Level) String() string {
+	switch l {
+	case LevelDebug:
+		return "debug"
+	case LevelInfo:
+		return "info"
+	case LevelWarning:
+		return "warning"
+	case LevelError:
+		return "error"
+	default:
+		return fmt.Sprintf("Level(%d)", int(l))
+	}
+}
+
+// ParseLevel parses a level name: debug, info, warning (or warn), or error.
+func ParseLevel(s string) (Level, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "debug":
+		return LevelDebug, nil
+	case "info":
+		return LevelInfo, nil
+	case "warning", "warn":
+		return LevelWarning, nil
+	case "error":
+		return LevelError, nil
+	default:
+		return LevelInfo, fmt.Errorf("invalid log level %q: must be debug, info, warning, or error", s)
+	}
+}
@@ -0,0 +1,104 @@
+package output
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseLevel(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    Level
+		wantErr bool
+	}{
+		{in: "debug", want: LevelDebug},
+		{in: "info", want: LevelInfo},
+		{in: "warning", want: LevelWarning},
+		{in: "WARN", want: LevelWarning},
+		{in: " error ", want: LevelError},
+		{in: "trace", wantErr: true},
+		{in: "", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			got, err := ParseLevel(tt.in)
+			if tt.wantErr {
+				assert.ErrorContains(t, err, "must be debug, info, warning, or error")
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+			assert.Equal(t, got, mustParseLevel(t, got.String()), "String round-trips")
+		})
+	}
+}
+
+func mustParseLevel(t *testing.T, s string) Level {
+	t.Helper()
+	l, err := ParseLevel(s)
+	require.NoError(t, err)
+	return l
+}
+
+func TestWriterLevel(t *testing.T) {
+	printAll := func(w *Writer) {
+		w.Debug("debug")
+		w.StartStep("step").Done()
+		w.Info("info")
+		w.NewProgress("progress").Done("")
+		_ = w.Indeterminate("indeterminate", func() error { return nil })
+		w.Warning("warning")
+		w.Error("error")
+		w.Success("success")
+		w.Result([]KeyValue{{Key: "Key", Value: "result"}})
+		w.Println("line")
+	}
+
+	tests := []struct {
+		level   Level
+		present []string
+		absent  []string
+	}{
+		{
+			level:   LevelDebug,
+			present: []string{"msg=debug", "-> step", "info", "-> progress...", "-> indeterminate...", "WARNING warning", "ERROR error", "OK success", "result", "line"},
+		},
+		{
+			level:   LevelInfo,
+			present: []string{"-> step", "info", "-> progress...", "-> indeterminate...", "WARNING warning", "ERROR error", "OK success", "result", "line"},
+			absent:  []string{"msg=debug"},
+		},
+		{
+			level:   LevelWarning,
+			present: []string{"WARNING warning", "ERROR error", "OK success", "result", "line"},
+			absent:  []string{"msg=debug", "-> ", "   info"},
+		},
+		{
+			level:   LevelError,
+			present: []string{"ERROR error", "OK success", "result", "line"},
+			absent:  []string{"msg=debug", "-> ", "   info", "WARNING"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.level.String(), func(t *testing.T) {
+			var buf bytes.Buffer
+			w := NewTest(&buf)
+			w.SetLevel(tt.level)
+			assert.Equal(t, tt.level, w.Level())
+			assert.Equal(t, tt.level == LevelDebug, w.DebugEnabled())
+
+			printAll(w)
+			for _, s := range tt.present {
+				assert.Contains(t, buf.String(), s)
+			}
+			for _, s := range tt.absent {
+				assert.NotContains(t, buf.String(), s)
+			}
+		})
+	}
+}
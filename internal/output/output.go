@@ -4,8 +4,11 @@
 package output
 
 import (
+	"context"
+	"encoding/json"
 	"fmt"
 	"io"
+	"log/slog"
 	"os"
 	"strings"
 	"sync"
@@ -13,6 +16,8 @@ import (
 	"github.com/charmbracelet/lipgloss"
 	"github.com/charmbracelet/lipgloss/table"
 	"golang.org/x/term"
+
+	"github.com/bitrise-io/bitrise-plugins-codepush-cli/internal/redact"
 )
 
 // Writer provides styled terminal output. Create one with New() for
@@ -20,9 +25,13 @@ import (
 type Writer struct {
 	mu          sync.Mutex
 	w           io.Writer
-	interactive bool     // terminal AND not CI
-	color       bool     // terminal AND not NO_COLOR
-	barStyle    BarStyle // default StyleBar (zero value)
+	interactive bool         // terminal AND not CI
+	color       bool         // terminal AND not NO_COLOR
+	barStyle    BarStyle     // default StyleBar (zero value)
+	records     io.Writer    // NDJSON record stream, nil unless enabled
+	level       Level        // minimum severity printed, default LevelInfo
+	debug       *slog.Logger // nil unless level is LevelDebug
+	logger      *slog.Logger // receives messages instead of w; see NewLogger
 }
 
 // KeyValue is a key-value pair for Result output.
@@ -82,6 +91,14 @@ func NewTest(w io.Writer) *Writer {
 	}
 }
 
+// NewLogger creates a Writer for library use that sends messages to l
+// instead of rendering them: steps, info, and success messages are logged
+// at info level, warnings and errors at their levels, and Debug messages at
+// debug level. Progress bars, tables, and results are dropped.
+func NewLogger(l *slog.Logger) *Writer {
+	return &Writer{w: io.Discard, level: LevelDebug, debug: l, logger: l}
+}
+
 // IsInteractive returns true if the writer targets an interactive terminal
 // (not CI, not piped).
 func (w *Writer) IsInteractive() bool {
@@ -101,7 +118,7 @@ func (w *Writer) StartStep(format string, args ...any) *StepHandle {
 	w.Step("%s", label)
 	return &StepHandle{
 		write:       w.write,
-		interactive: w.interactive,
+		interactive: w.interactive && w.enabled(LevelInfo),
 		color:       w.color,
 		label:       label,
 	}
@@ -125,7 +142,14 @@ func (sh *StepHandle) Done() {
 // Step prints a progress step. Color mode: "-> message" with cyan arrow.
 // Plain mode: "-> message".
 func (w *Writer) Step(format string, args ...any) {
+	if !w.enabled(LevelInfo) {
+		return
+	}
 	msg := fmt.Sprintf(format, args...)
+	if w.logger != nil {
+		w.log(slog.LevelInfo, msg)
+		return
+	}
 	if w.color {
 		arrow := lipgloss.NewStyle().Foreground(lipgloss.Color("6")).Render("->")
 		w.write(fmt.Appendf(nil, "%s %s\n", arrow, msg))
@@ -138,6 +162,10 @@ func (w *Writer) Step(format string, args ...any) {
 // Plain mode: "OK message".
 func (w *Writer) Success(format string, args ...any) {
 	msg := fmt.Sprintf(format, args...)
+	if w.logger != nil {
+		w.log(slog.LevelInfo, msg)
+		return
+	}
 	if w.color {
 		prefix := lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("2")).Render("OK")
 		w.write(fmt.Appendf(nil, "%s %s\n", prefix, msg))
@@ -150,6 +178,10 @@ func (w *Writer) Success(format string, args ...any) {
 // Plain mode: "ERROR message".
 func (w *Writer) Error(format string, args ...any) {
 	msg := fmt.Sprintf(format, args...)
+	if w.logger != nil {
+		w.log(slog.LevelError, msg)
+		return
+	}
 	if w.color {
 		prefix := lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("1")).Render("ERROR")
 		w.write(fmt.Appendf(nil, "%s %s\n", prefix, msg))
@@ -161,7 +193,19 @@ func (w *Writer) Error(format string, args ...any) {
 // Warning prints a warning message. Color mode: yellow prefix.
 // Plain mode: "WARNING message".
 func (w *Writer) Warning(format string, args ...any) {
+	if w.enabled(LevelWarning) {
+		w.warning(format, args...)
+	}
+}
+
+// warning prints a warning message at every level, for prompts that need
+// their context shown.
+func (w *Writer) warning(format string, args ...any) {
 	msg := fmt.Sprintf(format, args...)
+	if w.logger != nil {
+		w.log(slog.LevelWarn, msg)
+		return
+	}
 	if w.color {
 		prefix := lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("3")).Render("WARNING")
 		w.write(fmt.Appendf(nil, "%s %s\n", prefix, msg))
@@ -173,7 +217,14 @@ func (w *Writer) Warning(format string, args ...any) {
 // Info prints supplementary information indented under a step.
 // Color mode: dim text. Plain mode: indented text.
 func (w *Writer) Info(format string, args ...any) {
+	if !w.enabled(LevelInfo) {
+		return
+	}
 	msg := fmt.Sprintf(format, args...)
+	if w.logger != nil {
+		w.log(slog.LevelInfo, msg)
+		return
+	}
 	if w.color {
 		dim := lipgloss.NewStyle().Faint(true)
 		w.write(fmt.Appendf(nil, "   %s\n", dim.Render(msg)))
@@ -245,7 +296,93 @@ func (w *Writer) SetBarStyle(s BarStyle) {
 	w.barStyle = s
 }
 
+// SetRecordStream enables Record, writing records to r. Pass nil to
+// disable it again.
+func (w *Writer) SetRecordStream(r io.Writer) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.records = r
+}
+
+// Record writes v as one line of newline-delimited JSON to the record
+// stream, with a "type" field set to kind, so scripts can follow a
+// long-running command as it happens. v must marshal to a JSON object. It
+// is a no-op unless a record stream is set.
+func (w *Writer) Record(kind string, v any) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.records == nil {
+		return
+	}
+
+	typeField, _ := json.Marshal(kind)
+	line := append([]byte(`{"type":`), typeField...)
+	if data, err := json.Marshal(v); err == nil && len(data) > 2 && data[0] == '{' {
+		line = append(append(line, ','), data[1:]...)
+	} else {
+		line = append(line, '}')
+	}
+	_, _ = w.records.Write(append(redact.Bytes(line), '\n'))
+}
+
+// SetLevel sets the minimum severity of messages to print. LevelDebug
+// enables Debug messages; LevelError keeps only results and errors.
+func (w *Writer) SetLevel(l Level) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.level = l
+	w.debug = nil
+	if l <= LevelDebug {
+		w.debug = slog.New(slog.NewTextHandler(sinkFunc(w.write), &slog.HandlerOptions{Level: slog.LevelDebug}))
+	}
+}
+
+// Level returns the level set with SetLevel.
+func (w *Writer) Level() Level {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.level
+}
+
+// DebugEnabled reports whether Debug messages are written.
+func (w *Writer) DebugEnabled() bool {
+	return w.enabled(LevelDebug)
+}
+
+// enabled reports whether messages of severity l are printed.
+func (w *Writer) enabled(l Level) bool {
+	return l >= w.Level()
+}
+
+// Debug writes a structured diagnostic line, e.g.
+// "time=... level=DEBUG msg="http request" method=GET status=200", when
+// debug logging is enabled. args are alternating keys and values, as for
+// slog.
+func (w *Writer) Debug(msg string, args ...any) {
+	w.mu.Lock()
+	logger := w.debug
+	w.mu.Unlock()
+	if logger != nil {
+		logger.Debug(msg, args...)
+	}
+}
+
+// sinkFunc adapts a write function to io.Writer.
+type sinkFunc func([]byte)
+
+func (f sinkFunc) Write(p []byte) (int, error) {
+	f(p)
+	return len(p), nil
+}
+
+// log sends a message to the Writer's logger, with secrets masked.
+func (w *Writer) log(level slog.Level, msg string) {
+	w.logger.Log(context.Background(), level, redact.String(msg))
+}
+
+// write is the single sink for all Writer output; secrets are masked here.
 func (w *Writer) write(b []byte) {
+	b = redact.Bytes(b)
 	w.mu.Lock()
 	defer w.mu.Unlock()
 	_, _ = w.w.Write(b)
@@ -3,6 +3,8 @@ package output
 import (
 	"bytes"
 	"fmt"
+	"log/slog"
+	"regexp"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -17,6 +19,17 @@ func TestStep(t *testing.T) {
 	assert.Contains(t, buf.String(), `-> Resolving deployment "Staging"`)
 }
 
+func TestWriterRedactsSecrets(t *testing.T) {
+	var buf bytes.Buffer
+	w := NewTest(&buf)
+	w.Error("upload failed: Put %q: EOF", "https://bucket.example.com/pkg.zip?X-Amz-Signature=abcdef0123456789")
+	w.Result([]KeyValue{{Key: "URL", Value: "https://example.com/?token=tok_0123456789"}})
+
+	assert.NotContains(t, buf.String(), "abcdef0123456789")
+	assert.NotContains(t, buf.String(), "tok_0123456789")
+	assert.Contains(t, buf.String(), "X-Amz-Signature=[REDACTED]")
+}
+
 func TestSuccess(t *testing.T) {
 	var buf bytes.Buffer
 	w := NewTest(&buf)
@@ -100,6 +113,61 @@ func TestPrintln(t *testing.T) {
 	assert.Equal(t, "CodePush CLI 1.0.0\n", buf.String())
 }
 
+func TestRecord(t *testing.T) {
+	var human, records bytes.Buffer
+	w := NewTest(&human)
+
+	w.Record("status", map[string]string{"status": "processing"})
+	assert.Empty(t, records.String(), "records are dropped without a stream")
+
+	w.SetRecordStream(&records)
+	w.Record("status", map[string]string{"status": "processing"})
+	w.Record("done", struct{}{})
+	w.Record("url", map[string]string{"url": "https://example.com/?token=tok_0123456789"})
+
+	assert.Equal(t, `{"type":"status","status":"processing"}`+"\n"+
+		`{"type":"done"}`+"\n"+
+		`{"type":"url","url":"https://example.com/?token=[REDACTED]"}`+"\n", records.String())
+	assert.Empty(t, human.String())
+}
+
+func TestDebug(t *testing.T) {
+	var buf bytes.Buffer
+	w := NewTest(&buf)
+
+	w.Debug("http request", "method", "GET")
+	assert.False(t, w.DebugEnabled())
+	assert.Empty(t, buf.String(), "debug messages are dropped unless enabled")
+
+	w.SetLevel(LevelDebug)
+	assert.True(t, w.DebugEnabled())
+	w.Debug("http request", "method", "GET", "url", "https://example.com/?token=tok_0123456789", "status", 200)
+	assert.Contains(t, buf.String(), `level=DEBUG msg="http request" method=GET url="https://example.com/?token=[REDACTED]" status=200`)
+
+	buf.Reset()
+	w.SetLevel(LevelInfo)
+	w.Debug("http request")
+	assert.Empty(t, buf.String())
+}
+
+func TestNewLogger(t *testing.T) {
+	var buf bytes.Buffer
+	w := NewLogger(slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug})))
+	w.Step("Uploading update")
+	w.Warning("rollout is %d%%", 0)
+	w.Error("upload failed: ?token=tok_0123456789")
+	w.Debug("http request", "status", 200)
+	w.Table([]string{"NAME"}, [][]string{{"Staging"}})
+	w.NewProgress("Deleting releases").Update(50, "1/2")
+
+	assert.Equal(t, `level=INFO msg="Uploading update"
+level=WARN msg="rollout is 0%"
+level=ERROR msg="upload failed: ?token=[REDACTED]"
+level=DEBUG msg="http request" status=200
+level=INFO msg="Deleting releases..."
+`, stripTime(buf.String()))
+}
+
 func TestStartStepNonInteractive(t *testing.T) {
 	var buf bytes.Buffer
 	w := NewTest(&buf)
@@ -142,6 +210,26 @@ func TestConfirmDestructiveNonInteractiveNoYes(t *testing.T) {
 	assert.ErrorContains(t, err, "--yes")
 }
 
+func TestConfirmWithFlagNonInteractive(t *testing.T) {
+	w := NewTest(&bytes.Buffer{})
+
+	require.NoError(t, w.ConfirmWithFlag("Deployment is in use", "--force", true))
+
+	err := w.ConfirmWithFlag("Deployment is in use", "--force", false)
+	require.Error(t, err)
+	assert.ErrorContains(t, err, "use --force to confirm")
+}
+
+func TestConfirmTypedNonInteractive(t *testing.T) {
+	w := NewTest(&bytes.Buffer{})
+
+	require.NoError(t, w.ConfirmTyped("Production is protected", "Production", true))
+
+	err := w.ConfirmTyped("Production is protected", "Production", false)
+	require.Error(t, err)
+	assert.ErrorContains(t, err, "Production is protected; use --yes to confirm")
+}
+
 func TestIsInteractive(t *testing.T) {
 	w := NewTest(&bytes.Buffer{})
 	assert.False(t, w.IsInteractive())
@@ -185,3 +273,8 @@ func TestNew(t *testing.T) {
 	// New() targets stderr; just verify it returns a usable writer
 	w.Step("smoke test")
 }
+
+// stripTime removes the time attribute of slog text lines.
+func stripTime(s string) string {
+	return regexp.MustCompile(`(?m)^time=\S+ `).ReplaceAllString(s, "")
+}
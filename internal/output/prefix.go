@@ -0,0 +1,56 @@
+package output
+
+import (
+	"bytes"
+	"io"
+	"sync"
+)
+
+// WithPrefix returns a non-interactive Writer that writes to the same
+// destination as w, starting every line with prefix and a space, so the
+// output of tasks running at once can be told apart. It has w's level,
+// color, and bar style; its records are disabled.
+func (w *Writer) WithPrefix(prefix string) *Writer {
+	child := &Writer{
+		w:        &prefixWriter{parent: w, prefix: []byte(prefix + " "), lineStart: true},
+		color:    w.color,
+		barStyle: w.BarStyle(),
+	}
+	child.SetLevel(w.Level())
+	return child
+}
+
+// LineWriter returns an io.Writer that passes raw text, such as the output
+// of a subprocess, to w's destination, starting every line with prefix and a
+// space. Secrets are masked as in all of w's output.
+func (w *Writer) LineWriter(prefix string) io.Writer {
+	return &prefixWriter{parent: w, prefix: []byte(prefix + " "), lineStart: true}
+}
+
+// prefixWriter writes to a parent Writer, inserting a prefix at the start
+// of each line.
+type prefixWriter struct {
+	mu        sync.Mutex
+	parent    *Writer
+	prefix    []byte
+	lineStart bool
+}
+
+func (p *prefixWriter) Write(b []byte) (int, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	var buf bytes.Buffer
+	for _, line := range bytes.SplitAfter(b, []byte("\n")) {
+		if len(line) == 0 {
+			continue
+		}
+		if p.lineStart {
+			buf.Write(p.prefix)
+		}
+		buf.Write(line)
+		p.lineStart = line[len(line)-1] == '\n'
+	}
+	p.parent.write(buf.Bytes())
+	return len(b), nil
+}
@@ -0,0 +1,42 @@
+package output
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWithPrefix(t *testing.T) {
+	var buf bytes.Buffer
+	w := NewTest(&buf)
+	w.SetLevel(LevelWarning)
+	child := w.WithPrefix("[Brand A]")
+
+	child.Info("hidden below the parent's level")
+	child.Warning("slow upload")
+	child.Println("first\nsecond")
+
+	assert.Equal(t, "[Brand A] WARNING slow upload\n[Brand A] first\n[Brand A] second\n", buf.String())
+	assert.False(t, child.IsInteractive())
+}
+
+func TestPrefixWriterPartialLines(t *testing.T) {
+	var buf bytes.Buffer
+	p := &prefixWriter{parent: NewTest(&buf), prefix: []byte("> "), lineStart: true}
+
+	_, _ = p.Write([]byte("a"))
+	_, _ = p.Write([]byte("b\nc"))
+	_, _ = p.Write([]byte("\n"))
+
+	assert.Equal(t, "> ab\n> c\n", buf.String())
+}
+
+func TestLineWriter(t *testing.T) {
+	var buf bytes.Buffer
+	w := NewTest(&buf).WithPrefix("[ios]")
+
+	_, _ = w.LineWriter("[metro]").Write([]byte("bundling\ndone\n"))
+
+	assert.Equal(t, "[ios] [metro] bundling\n[ios] [metro] done\n", buf.String())
+}
@@ -85,17 +85,17 @@ type ProgressBar struct {
 // NewProgress creates a ProgressBar for the given label. In interactive mode
 // it prints "-> label" without a newline so that Update can overwrite it
 // in-place. In non-interactive mode it prints "-> label...\n" and the bar
-// is a no-op.
+// is a no-op. Below LevelInfo it prints nothing.
 func (w *Writer) NewProgress(label string) *ProgressBar {
 	pb := &ProgressBar{
 		write:       w.write,
-		interactive: w.interactive,
+		interactive: w.interactive && w.enabled(LevelInfo),
 		color:       w.color,
 		barStyle:    w.barStyle,
 		label:       label,
 		width:       30,
 	}
-	if w.interactive {
+	if pb.interactive {
 		w.write(fmt.Appendf(nil, "%s %s", renderArrow(w.color), label))
 	} else {
 		w.Step("%s...", label)
@@ -307,16 +307,17 @@ type IndeterminateBar struct {
 // NewIndeterminate creates an IndeterminateBar. In interactive mode it prints
 // "-> label" without a newline (the sweep goroutine overwrites it in-place).
 // In non-interactive mode it prints "-> label...\n" and does nothing else.
+// Below LevelInfo it prints nothing.
 func (w *Writer) NewIndeterminate(label string) *IndeterminateBar {
 	ib := &IndeterminateBar{
 		write:       w.write,
-		interactive: w.interactive,
+		interactive: w.interactive && w.enabled(LevelInfo),
 		color:       w.color,
 		barStyle:    w.barStyle,
 		label:       label,
 		width:       30,
 	}
-	if !w.interactive {
+	if !ib.interactive {
 		w.Step("%s...", label)
 		return ib
 	}
@@ -3,6 +3,7 @@ package output
 import (
 	"errors"
 	"fmt"
+	"slices"
 
 	"github.com/charmbracelet/huh"
 )
@@ -38,6 +39,32 @@ func (w *Writer) Select(title string, options []SelectOption) (string, error) {
 	return value, nil
 }
 
+// MultiSelect shows an interactive prompt for choosing any number of options,
+// with the options whose values are in selected checked initially. Returns
+// the chosen values in option order, or an error in non-interactive mode.
+func (w *Writer) MultiSelect(title string, options []SelectOption, selected []string) ([]string, error) {
+	if !w.interactive {
+		return nil, errors.New("cannot prompt for selection in non-interactive mode")
+	}
+
+	huhOpts := make([]huh.Option[string], len(options))
+	for i, opt := range options {
+		huhOpts[i] = huh.NewOption(opt.Label, opt.Value).Selected(slices.Contains(selected, opt.Value))
+	}
+
+	values := slices.Clone(selected)
+	err := huh.NewMultiSelect[string]().
+		Title(title).
+		Options(huhOpts...).
+		Value(&values).
+		Run()
+	if err != nil {
+		return nil, fmt.Errorf("selection prompt failed: %w", err)
+	}
+
+	return values, nil
+}
+
 // Input shows an interactive free-text input prompt. Returns an error in
 // non-interactive mode (CI or piped output).
 func (w *Writer) Input(title, placeholder string) (string, error) {
@@ -41,6 +41,15 @@ func TestSelect_NonInteractive(t *testing.T) {
 	}
 }
 
+func TestMultiSelect_NonInteractive(t *testing.T) {
+	w := NewTest(io.Discard)
+
+	values, err := w.MultiSelect("Select releases", []SelectOption{{Label: "v1", Value: "pkg-1"}}, []string{"pkg-1"})
+	require.Error(t, err)
+	assert.Empty(t, values)
+	assert.ErrorContains(t, err, "non-interactive")
+}
+
 func TestInput_NonInteractive(t *testing.T) {
 	w := NewTest(io.Discard)
 
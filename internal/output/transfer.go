@@ -0,0 +1,163 @@
+package output
+
+import (
+	"fmt"
+	"io"
+	"sync"
+	"time"
+)
+
+// transferLogInterval is how often a transfer logs its progress when the
+// progress bar cannot be drawn, such as on CI.
+const transferLogInterval = 10 * time.Second
+
+// TransferProgress reports the progress of a transfer of a known number of
+// bytes with throughput and an estimated time remaining. In interactive
+// mode it drives a progress bar; otherwise it prints an info line every
+// 10 seconds and a summary when done. It is safe for concurrent use, so
+// parallel part uploads can share one.
+type TransferProgress struct {
+	mu      sync.Mutex
+	w       *Writer
+	bar     *ProgressBar
+	label   string
+	total   int64
+	sent    int64
+	resumed int64 // bytes sent before start, excluded from the rate
+	start   time.Time
+	lastLog time.Time
+	now     func() time.Time
+}
+
+// NewTransfer starts reporting a transfer of total bytes.
+func (w *Writer) NewTransfer(label string, total int64) *TransferProgress {
+	t := &TransferProgress{w: w, bar: w.NewProgress(label), label: label, total: total, now: time.Now}
+	t.start = t.now()
+	t.lastLog = t.start
+	return t
+}
+
+// Resume records n bytes transferred by an earlier attempt. They count
+// towards the progress but not the throughput.
+func (t *TransferProgress) Resume(n int64) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.sent += n
+	t.resumed += n
+	t.render()
+}
+
+// Add records n more bytes transferred. n is negative when a failed
+// request's bytes have to be sent again.
+func (t *TransferProgress) Add(n int64) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.sent += n
+	t.render()
+}
+
+// Restart resets the progress for a transfer that starts over from the
+// first byte, such as a retried single-request upload.
+func (t *TransferProgress) Restart() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.sent = 0
+	t.resumed = 0
+	t.start = t.now()
+	t.render()
+}
+
+// Reader wraps r so that each Read adds the bytes read.
+func (t *TransferProgress) Reader(r io.Reader) io.Reader {
+	return &transferReader{r: r, t: t}
+}
+
+// Done finalises the transfer with its size, duration, and average
+// throughput.
+func (t *TransferProgress) Done() {
+	t.mu.Lock()
+	elapsed := t.now().Sub(t.start)
+	summary := fmt.Sprintf("%s in %s", HumanBytes(t.total), formatDuration(elapsed))
+	if rate := t.rate(elapsed); rate > 0 {
+		summary += ", " + formatRate(rate)
+	}
+	t.mu.Unlock()
+
+	if t.bar.interactive {
+		t.bar.Done(summary)
+		return
+	}
+	t.w.Info("%s: %s", t.label, summary)
+}
+
+// Cancel terminates the progress bar without marking it as done.
+func (t *TransferProgress) Cancel() {
+	t.bar.Cancel()
+}
+
+// render updates the progress bar, or logs a line when the interval has
+// passed in non-interactive mode. The caller holds t.mu.
+func (t *TransferProgress) render() {
+	pct := 0.0
+	if t.total > 0 {
+		pct = float64(t.sent) / float64(t.total) * 100
+	}
+	if t.bar.interactive {
+		t.bar.Update(pct, t.status())
+		return
+	}
+	if now := t.now(); now.Sub(t.lastLog) >= transferLogInterval {
+		t.lastLog = now
+		t.w.Info("%s: %.0f%% (%s)", t.label, pct, t.status())
+	}
+}
+
+// status describes the progress, e.g.
+// "12.0 MB / 40.0 MB, 2.0 MB/s, ETA 14s". The caller holds t.mu.
+func (t *TransferProgress) status() string {
+	s := HumanBytes(t.sent) + " / " + HumanBytes(t.total)
+	rate := t.rate(t.now().Sub(t.start))
+	if rate <= 0 {
+		return s
+	}
+	s += ", " + formatRate(rate)
+	if remaining := t.total - t.sent; remaining > 0 {
+		s += ", ETA " + formatDuration(time.Duration(float64(remaining)/rate*float64(time.Second)))
+	}
+	return s
+}
+
+// rate returns the throughput in bytes per second since the transfer
+// started. The caller holds t.mu.
+func (t *TransferProgress) rate(elapsed time.Duration) float64 {
+	if elapsed <= 0 {
+		return 0
+	}
+	return float64(t.sent-t.resumed) / elapsed.Seconds()
+}
+
+func formatRate(bytesPerSecond float64) string {
+	return HumanBytes(int64(bytesPerSecond)) + "/s"
+}
+
+// formatDuration rounds d to whole seconds, e.g. "1m5s", showing "<1s" for
+// shorter durations.
+func formatDuration(d time.Duration) string {
+	if d < time.Second {
+		return "<1s"
+	}
+	return d.Round(time.Second).String()
+}
+
+type transferReader struct {
+	r io.Reader
+	t *TransferProgress
+}
+
+func (r *transferReader) Read(p []byte) (int, error) {
+	n, err := r.r.Read(p)
+	if n > 0 {
+		r.t.Add(int64(n))
+	}
+	return n, err
+}
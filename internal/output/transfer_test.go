@@ -0,0 +1,117 @@
+package output
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeClock returns a clock for TransferProgress.now and a function to
+// advance it.
+func fakeClock() (func() time.Time, func(time.Duration)) {
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	return func() time.Time { return now }, func(d time.Duration) { now = now.Add(d) }
+}
+
+func newTestTransfer(w *Writer, label string, total int64) (*TransferProgress, func(time.Duration)) {
+	now, advance := fakeClock()
+	t := w.NewTransfer(label, total)
+	t.now = now
+	t.start = now()
+	t.lastLog = t.start
+	return t, advance
+}
+
+func TestTransferProgressNonInteractive(t *testing.T) {
+	var buf bytes.Buffer
+	w := NewTest(&buf)
+	tp, advance := newTestTransfer(w, "Uploading", 40<<20)
+	assert.Equal(t, "-> Uploading...\n", buf.String())
+	buf.Reset()
+
+	advance(5 * time.Second)
+	tp.Add(10 << 20)
+	assert.Empty(t, buf.String(), "no line before the log interval")
+
+	advance(5 * time.Second)
+	tp.Add(10 << 20)
+	assert.Equal(t, "   Uploading: 50% (20.0 MB / 40.0 MB, 2.0 MB/s, ETA 10s)\n", buf.String())
+	buf.Reset()
+
+	advance(2 * time.Second)
+	tp.Add(4 << 20)
+	assert.Empty(t, buf.String(), "lines are at least the log interval apart")
+
+	advance(8 * time.Second)
+	tp.Add(16 << 20)
+	tp.Done()
+	assert.Equal(t, "   Uploading: 100% (40.0 MB / 40.0 MB, 2.0 MB/s)\n"+
+		"   Uploading: 40.0 MB in 20s, 2.0 MB/s\n", buf.String())
+}
+
+func TestTransferProgressInteractive(t *testing.T) {
+	var buf bytes.Buffer
+	w := NewTest(&buf)
+	w.interactive = true
+	tp, advance := newTestTransfer(w, "Uploading", 4<<20)
+
+	advance(2 * time.Second)
+	tp.Add(1 << 20)
+	assert.Contains(t, buf.String(), "1.0 MB / 4.0 MB, 512.0 KB/s, ETA 6s")
+	assert.Contains(t, buf.String(), "25%")
+
+	advance(2 * time.Second)
+	tp.Add(3 << 20)
+	tp.Done()
+	lines := strings.Split(strings.TrimRight(buf.String(), "\n"), "\r")
+	assert.Contains(t, lines[len(lines)-1], "OK Uploading")
+	assert.Contains(t, lines[len(lines)-1], "4.0 MB in 4s, 1.0 MB/s")
+}
+
+func TestTransferProgressResumeAndRestart(t *testing.T) {
+	var buf bytes.Buffer
+	w := NewTest(&buf)
+	w.interactive = true
+	tp, advance := newTestTransfer(w, "Uploading", 4<<20)
+
+	tp.Resume(2 << 20)
+	advance(2 * time.Second)
+	tp.Add(1 << 20)
+	assert.Contains(t, buf.String(), "3.0 MB / 4.0 MB, 512.0 KB/s, ETA 2s", "resumed bytes count towards progress but not the rate")
+
+	buf.Reset()
+	tp.Restart()
+	advance(time.Second)
+	tp.Add(1 << 20)
+	assert.Contains(t, buf.String(), "1.0 MB / 4.0 MB, 1.0 MB/s, ETA 3s")
+}
+
+func TestTransferProgressReader(t *testing.T) {
+	w := NewTest(io.Discard)
+	tp := w.NewTransfer("Uploading", 1000)
+
+	data, err := io.ReadAll(tp.Reader(bytes.NewReader(make([]byte, 1000))))
+	require.NoError(t, err)
+	assert.Len(t, data, 1000)
+	assert.Equal(t, int64(1000), tp.sent)
+}
+
+func TestFormatDuration(t *testing.T) {
+	tests := []struct {
+		in   time.Duration
+		want string
+	}{
+		{300 * time.Millisecond, "<1s"},
+		{1400 * time.Millisecond, "1s"},
+		{65 * time.Second, "1m5s"},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, formatDuration(tt.in))
+	}
+}
@@ -0,0 +1,113 @@
+// Package policylog records releases created with --override-policy despite
+// breaking the release policy in .codepush.json, and changes made with
+// --override-freeze inside a freeze window. Each override is appended as one
+// JSON line, so the log can be reviewed or shipped elsewhere.
+package policylog
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/user"
+	"path/filepath"
+	"time"
+)
+
+// Override is a release created despite policy violations, or a change
+// made inside a freeze window.
+type Override struct {
+	Time       time.Time `json:"time"`
+	User       string    `json:"user,omitempty"`
+	Command    string    `json:"command"`
+	AppID      string    `json:"app_id"`
+	Deployment string    `json:"deployment"`
+	// SourceDeployment is set for promotes.
+	SourceDeployment string   `json:"source_deployment,omitempty"`
+	BuildURL         string   `json:"build_url,omitempty"`
+	Violations       []string `json:"violations"`
+	// Reason is the justification given with --override-freeze.
+	Reason string `json:"reason,omitempty"`
+}
+
+// fileFunc allows tests to override where the log is stored.
+var fileFunc = defaultFile
+
+func defaultFile() (string, error) {
+	base, err := os.UserConfigDir()
+	if err != nil {
+		return "", fmt.Errorf("determining config directory: %w", err)
+	}
+	return filepath.Join(base, "codepush", "policy-overrides.jsonl"), nil
+}
+
+// Record appends o to the log, filling in the time and, if unset, the
+// current OS user. It returns the path of the log.
+func Record(o Override) (string, error) {
+	path, err := fileFunc()
+	if err != nil {
+		return "", err
+	}
+
+	o.Time = time.Now().UTC()
+	if o.User == "" {
+		if u, err := user.Current(); err == nil {
+			o.User = u.Username
+		}
+	}
+	line, err := json.Marshal(o)
+	if err != nil {
+		return "", fmt.Errorf("encoding policy override: %w", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return "", fmt.Errorf("creating config directory: %w", err)
+	}
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return "", fmt.Errorf("opening policy override log: %w", err)
+	}
+	if _, err := f.Write(append(line, '\n')); err != nil {
+		_ = f.Close()
+		return "", fmt.Errorf("writing policy override log: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		return "", fmt.Errorf("writing policy override log: %w", err)
+	}
+	return path, nil
+}
+
+// List returns the recorded overrides, oldest first.
+func List() ([]Override, error) {
+	path, err := fileFunc()
+	if err != nil {
+		return nil, err
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("reading policy override log: %w", err)
+	}
+	defer func() { _ = f.Close() }()
+
+	var overrides []Override
+	scanner := bufio.NewScanner(f)
+	for line := 1; scanner.Scan(); line++ {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		var o Override
+		if err := json.Unmarshal(scanner.Bytes(), &o); err != nil {
+			return nil, fmt.Errorf("parsing %s line %d: %w", path, line, err)
+		}
+		overrides = append(overrides, o)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("reading policy override log: %w", err)
+	}
+	return overrides, nil
+}
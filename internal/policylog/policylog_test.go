@@ -0,0 +1,51 @@
+package policylog
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func useTempFile(t *testing.T) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "codepush", "policy-overrides.jsonl")
+	orig := fileFunc
+	fileFunc = func() (string, error) { return path, nil }
+	t.Cleanup(func() { fileFunc = orig })
+	return path
+}
+
+func TestRecordAndList(t *testing.T) {
+	path := useTempFile(t)
+
+	overrides, err := List()
+	require.NoError(t, err)
+	assert.Empty(t, overrides)
+
+	got, err := Record(Override{User: "alice", Command: "push", AppID: "app", Deployment: "Production", Violations: []string{"too fast"}})
+	require.NoError(t, err)
+	assert.Equal(t, path, got)
+	_, err = Record(Override{Command: "promote", AppID: "app", Deployment: "Production", SourceDeployment: "QA", Violations: []string{"frozen"}, Reason: "sev1 hotfix"})
+	require.NoError(t, err)
+
+	overrides, err = List()
+	require.NoError(t, err)
+	require.Len(t, overrides, 2)
+	assert.Equal(t, "alice", overrides[0].User)
+	assert.Equal(t, []string{"too fast"}, overrides[0].Violations)
+	assert.False(t, overrides[0].Time.IsZero())
+	assert.Equal(t, "QA", overrides[1].SourceDeployment)
+	assert.Equal(t, "sev1 hotfix", overrides[1].Reason)
+}
+
+func TestListMalformed(t *testing.T) {
+	path := useTempFile(t)
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o700))
+	require.NoError(t, os.WriteFile(path, []byte("{\"command\":\"push\"}\n\nnot json\n"), 0o600))
+
+	_, err := List()
+	assert.ErrorContains(t, err, "line 3")
+}
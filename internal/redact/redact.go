@@ -0,0 +1,120 @@
+// Package redact removes secrets from text before it reaches any output sink:
+// the console, JSON output, and files exported to the Bitrise deploy
+// directory. It masks secrets registered at runtime (such as the API token),
+// the values of secret-looking environment variables, credentials in signed
+// URLs, and Authorization headers.
+package redact
+
+import (
+	"os"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// Mask replaces every redacted value.
+const Mask = "[REDACTED]"
+
+// minSecretLength keeps short values (e.g. "true", "1") from being masked
+// everywhere they happen to appear.
+const minSecretLength = 8
+
+var (
+	mu      sync.RWMutex
+	secrets []string
+	envOnce sync.Once
+)
+
+// patterns match secrets by shape. The first submatch is kept and the rest of
+// the match is masked.
+var patterns = []*regexp.Regexp{
+	// Signed URL credentials (S3, GCS, Azure SAS) and token query parameters.
+	regexp.MustCompile(`(?i)([?&](?:x-amz-signature|x-amz-credential|x-amz-security-token|x-goog-signature|x-goog-credential|signature|sig|token|access_token|api_key|key)=)[^&\s"'\\]+`),
+	// Authorization headers.
+	regexp.MustCompile(`(?i)(authorization["']?\s*[:=]\s*["']?(?:bearer\s+|token\s+|basic\s+)?)[^\s"',]+`),
+	// PEM private keys.
+	regexp.MustCompile(`(-----BEGIN [A-Z ]*PRIVATE KEY-----)[\s\S]*?-----END [A-Z ]*PRIVATE KEY-----`),
+}
+
+// Register marks values as secrets to be masked wherever they appear. Values
+// shorter than 8 characters are ignored.
+func Register(values ...string) {
+	mu.Lock()
+	defer mu.Unlock()
+	for _, v := range values {
+		v = strings.TrimSpace(v)
+		if len(v) < minSecretLength || slices.Contains(secrets, v) {
+			continue
+		}
+		secrets = append(secrets, v)
+	}
+	// Longest first, so a secret containing another is masked whole.
+	slices.SortFunc(secrets, func(a, b string) int { return len(b) - len(a) })
+}
+
+// registerEnv registers the values of environment variables whose names
+// suggest a secret, e.g. BITRISE_API_TOKEN or CODEPUSH_DEPLOYMENT_KEY.
+// Variables naming a path, file, or directory are skipped.
+func registerEnv() {
+	var values []string
+	for _, kv := range os.Environ() {
+		name, value, ok := strings.Cut(kv, "=")
+		if ok && isSecretName(name) {
+			values = append(values, value)
+		}
+	}
+	Register(values...)
+}
+
+// RegisterVar registers the value of a variable set at runtime, such as one
+// loaded from an env file, when its name suggests a secret.
+func RegisterVar(name, value string) {
+	if isSecretName(name) {
+		Register(value)
+	}
+}
+
+func isSecretName(name string) bool {
+	upper := strings.ToUpper(name)
+	for _, suffix := range []string{"_PATH", "_FILE", "_DIR", "_URL"} {
+		if strings.HasSuffix(upper, suffix) {
+			return false
+		}
+	}
+	for _, word := range []string{"TOKEN", "SECRET", "PASSWORD", "PASSWD", "API_KEY", "PRIVATE_KEY", "DEPLOYMENT_KEY", "CREDENTIAL"} {
+		if strings.Contains(upper, word) {
+			return true
+		}
+	}
+	return false
+}
+
+// String returns s with all known secrets masked.
+func String(s string) string {
+	envOnce.Do(registerEnv)
+
+	mu.RLock()
+	for _, secret := range secrets {
+		s = strings.ReplaceAll(s, secret, Mask)
+	}
+	mu.RUnlock()
+
+	for _, p := range patterns {
+		s = p.ReplaceAllString(s, "${1}"+Mask)
+	}
+	return s
+}
+
+// Bytes returns b with all known secrets masked.
+func Bytes(b []byte) []byte {
+	return []byte(String(string(b)))
+}
+
+// reset clears registered secrets. Used by tests.
+func reset() {
+	mu.Lock()
+	defer mu.Unlock()
+	secrets = nil
+	envOnce = sync.Once{}
+}
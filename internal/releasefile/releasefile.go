@@ -0,0 +1,122 @@
+// Package releasefile reads release manifests: YAML or JSON files that
+// describe a CodePush release declaratively, for 'codepush apply'.
+package releasefile
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Release is a release manifest. Every field mirrors a push flag.
+type Release struct {
+	// App is the connected app UUID. Unset means the --app-id flag or the
+	// project config.
+	App        string `yaml:"app"`
+	Deployment string `yaml:"deployment"`
+	AppVersion string `yaml:"app_version"`
+
+	Description string `yaml:"description"`
+	Mandatory   bool   `yaml:"mandatory"`
+	Disabled    bool   `yaml:"disabled"`
+	// Rollout is the rollout percentage. Unset means 100.
+	Rollout *int `yaml:"rollout"`
+
+	Bundle    Bundle    `yaml:"bundle"`
+	Targeting Targeting `yaml:"targeting"`
+}
+
+// Bundle is the bundle a manifest releases: a bundle directory built
+// beforehand, or the settings to build one with.
+type Bundle struct {
+	// Path is a bundle directory built beforehand. When set, the build
+	// settings are not used.
+	Path string `yaml:"path"`
+
+	Platform   string `yaml:"platform"`
+	ProjectDir string `yaml:"project_dir"`
+	EntryFile  string `yaml:"entry_file"`
+	OutputDir  string `yaml:"output_dir"`
+	Hermes     string `yaml:"hermes"`
+	// Minify unset leaves minification to the bundler.
+	Minify      *bool `yaml:"minify"`
+	SkipInstall bool  `yaml:"skip_install"`
+}
+
+// Targeting restricts the devices a release is offered to.
+type Targeting struct {
+	OSVersion    string   `yaml:"os_version"`
+	DeviceModels []string `yaml:"device_models"`
+	Countries    []string `yaml:"countries"`
+}
+
+// Load reads and validates a release manifest. JSON manifests are read as
+// YAML, of which JSON is a subset. Unknown fields are rejected so typos do
+// not go unnoticed. Relative bundle paths are resolved against the
+// manifest's directory.
+func Load(path string) (*Release, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading release manifest: %w", err)
+	}
+
+	var r Release
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	if err := dec.Decode(&r); err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil, fmt.Errorf("release manifest %s is empty", path)
+		}
+		return nil, fmt.Errorf("parsing release manifest %s: %w", path, err)
+	}
+	if err := r.validate(); err != nil {
+		return nil, fmt.Errorf("release manifest %s: %w", path, err)
+	}
+
+	dir := filepath.Dir(path)
+	r.Bundle.Path = resolve(dir, r.Bundle.Path)
+	r.Bundle.ProjectDir = resolve(dir, r.Bundle.ProjectDir)
+	r.Bundle.OutputDir = resolve(dir, r.Bundle.OutputDir)
+	if r.Bundle.Path == "" && r.Bundle.ProjectDir == "" {
+		r.Bundle.ProjectDir = dir
+	}
+	return &r, nil
+}
+
+// RolloutPercent returns the rollout percentage, 100 when unset.
+func (r *Release) RolloutPercent() int {
+	if r.Rollout == nil {
+		return 100
+	}
+	return *r.Rollout
+}
+
+func (r *Release) validate() error {
+	if r.Deployment == "" {
+		return errors.New("deployment is required")
+	}
+	if r.AppVersion == "" {
+		return errors.New("app_version is required")
+	}
+	if r.Rollout != nil && (*r.Rollout < 0 || *r.Rollout > 100) {
+		return fmt.Errorf("rollout must be between 0 and 100, got %d", *r.Rollout)
+	}
+	if r.Bundle.Path == "" && r.Bundle.Platform == "" {
+		return errors.New("bundle needs a path to a built bundle, or a platform to build one for")
+	}
+	return nil
+}
+
+// resolve returns path relative to dir, leaving empty and absolute paths
+// unchanged.
+func resolve(dir, path string) string {
+	if path == "" || filepath.IsAbs(path) {
+		return path
+	}
+	return filepath.Join(dir, path)
+}
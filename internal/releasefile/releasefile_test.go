@@ -0,0 +1,84 @@
+package releasefile
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeManifest(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+	return path
+}
+
+func TestLoadYAML(t *testing.T) {
+	path := writeManifest(t, "release.yaml", `app: 3f1a6c1e-0b8a-4c39-9a4e-5b2d7c9e1f20
+deployment: Staging
+app_version: 1.2.0
+description: Fix checkout
+mandatory: true
+rollout: 25
+bundle:
+  platform: ios
+  project_dir: app
+  hermes: on
+targeting:
+  os_version: ">=16"
+  countries: [DE, AT]
+`)
+
+	r, err := Load(path)
+	require.NoError(t, err)
+
+	dir := filepath.Dir(path)
+	assert.Equal(t, "Staging", r.Deployment)
+	assert.Equal(t, "1.2.0", r.AppVersion)
+	assert.True(t, r.Mandatory)
+	assert.Equal(t, 25, r.RolloutPercent())
+	assert.Equal(t, "ios", r.Bundle.Platform)
+	assert.Equal(t, "on", r.Bundle.Hermes)
+	assert.Equal(t, filepath.Join(dir, "app"), r.Bundle.ProjectDir)
+	assert.Equal(t, []string{"DE", "AT"}, r.Targeting.Countries)
+}
+
+func TestLoadJSON(t *testing.T) {
+	path := writeManifest(t, "release.json", `{
+  "deployment": "Production",
+  "app_version": "2.0",
+  "bundle": {"path": "build/CodePush"}
+}`)
+
+	r, err := Load(path)
+	require.NoError(t, err)
+
+	assert.Equal(t, 100, r.RolloutPercent())
+	assert.Equal(t, filepath.Join(filepath.Dir(path), "build", "CodePush"), r.Bundle.Path)
+	assert.Empty(t, r.Bundle.ProjectDir)
+}
+
+func TestLoadRejectsInvalidManifests(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		wantErr string
+	}{
+		{name: "empty", content: "", wantErr: "is empty"},
+		{name: "unknown field", content: "deployment: Staging\napp_version: 1.0\nrolout: 50\nbundle: {path: b}\n", wantErr: "field rolout not found"},
+		{name: "no deployment", content: "app_version: 1.0\nbundle: {path: b}\n", wantErr: "deployment is required"},
+		{name: "no app version", content: "deployment: Staging\nbundle: {path: b}\n", wantErr: "app_version is required"},
+		{name: "rollout out of range", content: "deployment: Staging\napp_version: 1.0\nrollout: 101\nbundle: {path: b}\n", wantErr: "rollout must be between 0 and 100"},
+		{name: "no bundle", content: "deployment: Staging\napp_version: 1.0\n", wantErr: "bundle needs a path"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Load(writeManifest(t, "release.yaml", tt.content))
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.wantErr)
+		})
+	}
+}
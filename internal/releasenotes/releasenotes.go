@@ -0,0 +1,164 @@
+// Package releasenotes builds release descriptions from git history and
+// changelog files.
+package releasenotes
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+
+	"github.com/bitrise-io/bitrise-plugins-codepush-cli/internal/git"
+)
+
+// DefaultTemplate is the description generated by --description-from-git
+// when no description or description file is given.
+const DefaultTemplate = `{{.CommitCount}} change(s){{if .Tag}} since {{.Tag}}{{end}}:
+{{range .Commits}}- {{.Subject}} ({{.ShortSHA}})
+{{end}}`
+
+// Options selects the sources of a release description.
+type Options struct {
+	// Description is the --description value.
+	Description string
+	// File is a file whose content is the description. For a Markdown
+	// changelog only the newest entry is used.
+	File string
+	// FromGit generates the description from the commits since the last
+	// tag when neither Description nor File is set.
+	FromGit bool
+	// Dir is the git working directory; empty for the current directory.
+	Dir string
+}
+
+// Commit is a git commit included in a release.
+type Commit struct {
+	SHA     string
+	Subject string
+}
+
+// ShortSHA returns the abbreviated commit hash.
+func (c Commit) ShortSHA() string {
+	if len(c.SHA) > 7 {
+		return c.SHA[:7]
+	}
+	return c.SHA
+}
+
+// Data is the value description templates are executed with.
+type Data struct {
+	// Tag is the last tag before HEAD, or empty when the repository has no
+	// tags and Commits holds the whole history.
+	Tag     string
+	Commits []Commit
+}
+
+// CommitCount returns the number of commits since Tag.
+func (d Data) CommitCount() int {
+	return len(d.Commits)
+}
+
+// ShortSHAs returns the abbreviated hashes of the commits, comma-separated.
+func (d Data) ShortSHAs() string {
+	shas := make([]string, len(d.Commits))
+	for i, c := range d.Commits {
+		shas[i] = c.ShortSHA()
+	}
+	return strings.Join(shas, ", ")
+}
+
+// Resolve returns the description selected by opts. A description or file
+// containing "{{" is a text/template executed with the commits since the
+// last tag, so git is only run when a template or FromGit needs it.
+func Resolve(ctx context.Context, opts Options) (string, error) {
+	text := opts.Description
+	if opts.File != "" {
+		data, err := os.ReadFile(opts.File)
+		if err != nil {
+			return "", fmt.Errorf("reading description file: %w", err)
+		}
+		text = string(data)
+		if isMarkdown(opts.File) {
+			text = LatestChangelogEntry(text)
+		}
+	}
+	if text == "" && opts.FromGit {
+		text = DefaultTemplate
+	}
+	if !strings.Contains(text, "{{") {
+		return strings.TrimSpace(text), nil
+	}
+
+	tmpl, err := template.New("description").Option("missingkey=error").Parse(text)
+	if err != nil {
+		return "", fmt.Errorf("parsing description template: %w", err)
+	}
+	data, err := CommitsSinceLastTag(ctx, opts.Dir)
+	if err != nil {
+		return "", err
+	}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return "", fmt.Errorf("rendering description template: %w", err)
+	}
+	return strings.TrimSpace(buf.String()), nil
+}
+
+// CommitsSinceLastTag returns the commits reachable from HEAD but not from
+// the last tag, newest first.
+func CommitsSinceLastTag(ctx context.Context, dir string) (Data, error) {
+	var data Data
+	if _, err := git.Run(ctx, dir, "rev-parse", "--git-dir"); err != nil {
+		return data, fmt.Errorf("reading git history: %w", err)
+	}
+	if tag, err := git.Run(ctx, dir, "describe", "--tags", "--abbrev=0", "HEAD"); err == nil {
+		data.Tag = tag
+	}
+
+	args := []string{"log", "--format=%H%x09%s"}
+	if data.Tag != "" {
+		args = append(args, data.Tag+"..HEAD")
+	}
+	log, err := git.Run(ctx, dir, args...)
+	if err != nil {
+		return data, fmt.Errorf("reading git history: %w", err)
+	}
+	for _, line := range strings.Split(log, "\n") {
+		sha, subject, ok := strings.Cut(line, "\t")
+		if !ok {
+			continue
+		}
+		data.Commits = append(data.Commits, Commit{SHA: sha, Subject: subject})
+	}
+	return data, nil
+}
+
+// LatestChangelogEntry returns the first "## " section of a Markdown
+// changelog that has content, including its heading, so an empty
+// "## Unreleased" section is skipped. A text without sections is returned
+// unchanged.
+func LatestChangelogEntry(text string) string {
+	lines := strings.Split(text, "\n")
+	var starts []int
+	for i, line := range lines {
+		if strings.HasPrefix(line, "## ") {
+			starts = append(starts, i)
+		}
+	}
+	starts = append(starts, len(lines))
+	for i := 0; i < len(starts)-1; i++ {
+		body := strings.Join(lines[starts[i]+1:starts[i+1]], "\n")
+		if strings.TrimSpace(body) != "" {
+			return strings.TrimSpace(lines[starts[i]] + "\n" + body)
+		}
+	}
+	return text
+}
+
+func isMarkdown(path string) bool {
+	ext := strings.ToLower(filepath.Ext(path))
+	return ext == ".md" || ext == ".markdown"
+}
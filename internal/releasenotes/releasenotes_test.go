@@ -0,0 +1,131 @@
+package releasenotes
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// initRepo creates a git repository with the given commit subjects, tagging
+// v1.0.0 after the first tagAfter commits when tagAfter is positive.
+func initRepo(t *testing.T, subjects []string, tagAfter int) string {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	dir := t.TempDir()
+	run := func(args ...string) {
+		t.Helper()
+		cmd := exec.Command("git", append([]string{"-C", dir}, args...)...)
+		cmd.Env = append(os.Environ(), "GIT_CONFIG_GLOBAL=/dev/null", "GIT_CONFIG_SYSTEM=/dev/null")
+		out, err := cmd.CombinedOutput()
+		require.NoError(t, err, string(out))
+	}
+	run("init", "-q")
+	run("config", "user.email", "dev@example.com")
+	run("config", "user.name", "Dev")
+	for i, subject := range subjects {
+		run("commit", "-q", "--allow-empty", "-m", subject)
+		if i+1 == tagAfter {
+			run("tag", "v1.0.0")
+		}
+	}
+	return dir
+}
+
+func TestCommitsSinceLastTag(t *testing.T) {
+	t.Run("since tag", func(t *testing.T) {
+		dir := initRepo(t, []string{"Initial", "Fix login crash", "Speed up startup"}, 1)
+
+		data, err := CommitsSinceLastTag(context.Background(), dir)
+		require.NoError(t, err)
+
+		assert.Equal(t, "v1.0.0", data.Tag)
+		require.Len(t, data.Commits, 2)
+		assert.Equal(t, "Speed up startup", data.Commits[0].Subject)
+		assert.Equal(t, "Fix login crash", data.Commits[1].Subject)
+		assert.Len(t, data.Commits[0].ShortSHA(), 7)
+	})
+
+	t.Run("no tags uses whole history", func(t *testing.T) {
+		dir := initRepo(t, []string{"Initial", "Second"}, 0)
+
+		data, err := CommitsSinceLastTag(context.Background(), dir)
+		require.NoError(t, err)
+
+		assert.Empty(t, data.Tag)
+		assert.Equal(t, 2, data.CommitCount())
+	})
+
+	t.Run("not a repository", func(t *testing.T) {
+		if _, err := exec.LookPath("git"); err != nil {
+			t.Skip("git not installed")
+		}
+		_, err := CommitsSinceLastTag(context.Background(), t.TempDir())
+		assert.ErrorContains(t, err, "reading git history")
+	})
+}
+
+func TestResolve(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("plain description is unchanged", func(t *testing.T) {
+		got, err := Resolve(ctx, Options{Description: "Fix login crash"})
+		require.NoError(t, err)
+		assert.Equal(t, "Fix login crash", got)
+	})
+
+	t.Run("nothing set", func(t *testing.T) {
+		got, err := Resolve(ctx, Options{})
+		require.NoError(t, err)
+		assert.Empty(t, got)
+	})
+
+	t.Run("from git uses default template", func(t *testing.T) {
+		dir := initRepo(t, []string{"Initial", "Fix login crash"}, 1)
+
+		got, err := Resolve(ctx, Options{FromGit: true, Dir: dir})
+		require.NoError(t, err)
+		assert.Regexp(t, `^1 change\(s\) since v1\.0\.0:\n- Fix login crash \([0-9a-f]{7}\)$`, got)
+	})
+
+	t.Run("description template", func(t *testing.T) {
+		dir := initRepo(t, []string{"Initial", "One", "Two"}, 1)
+
+		got, err := Resolve(ctx, Options{Description: "{{.CommitCount}} commits: {{.ShortSHAs}}", Dir: dir})
+		require.NoError(t, err)
+		assert.Regexp(t, `^2 commits: [0-9a-f]{7}, [0-9a-f]{7}$`, got)
+	})
+
+	t.Run("unknown template field", func(t *testing.T) {
+		dir := initRepo(t, []string{"Initial"}, 0)
+
+		_, err := Resolve(ctx, Options{Description: "{{.Nope}}", Dir: dir})
+		assert.ErrorContains(t, err, "rendering description template")
+	})
+
+	t.Run("changelog file uses latest entry", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "CHANGELOG.md")
+		changelog := "# Changelog\n\n## Unreleased\n\n## 1.2.0\n\n- Fix login crash\n\n## 1.1.0\n\n- Old\n"
+		require.NoError(t, os.WriteFile(path, []byte(changelog), 0o644))
+
+		got, err := Resolve(ctx, Options{File: path})
+		require.NoError(t, err)
+		assert.Equal(t, "## 1.2.0\n\n- Fix login crash", got)
+	})
+
+	t.Run("missing file", func(t *testing.T) {
+		_, err := Resolve(ctx, Options{File: filepath.Join(t.TempDir(), "missing.txt")})
+		assert.ErrorContains(t, err, "reading description file")
+	})
+}
+
+func TestLatestChangelogEntry(t *testing.T) {
+	assert.Equal(t, "no sections", LatestChangelogEntry("no sections"))
+	assert.Equal(t, "## 2.0.0\n- New", LatestChangelogEntry("## 2.0.0\n- New\n## 1.0.0\n- Old"))
+}
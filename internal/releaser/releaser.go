@@ -0,0 +1,45 @@
+// Package releaser pushes, promotes, and rolls back the updates of one app
+// with the option and result types of internal/codepush. The CLI commands
+// and the pkg/codepush Releaser both release through it, so they run the
+// same code.
+package releaser
+
+import (
+	"context"
+
+	"github.com/bitrise-io/bitrise-plugins-codepush-cli/internal/codepush"
+	"github.com/bitrise-io/bitrise-plugins-codepush-cli/internal/output"
+)
+
+// Releaser runs release operations for one app.
+type Releaser struct {
+	appID  string
+	token  string
+	client codepush.Client
+	out    *output.Writer
+}
+
+// New returns a Releaser for appID that sends requests with client and
+// reports progress to out.
+func New(appID, token string, client codepush.Client, out *output.Writer) *Releaser {
+	return &Releaser{appID: appID, token: token, client: client, out: out}
+}
+
+// Push pushes the bundle of opts to the app of r, waiting for processing
+// as poll allows.
+func (r *Releaser) Push(ctx context.Context, opts *codepush.PushOptions, poll codepush.PollConfig) (*codepush.PushResult, error) {
+	opts.AppID, opts.Token = r.appID, r.token
+	return codepush.PushWithConfig(ctx, r.client, opts, poll, r.out)
+}
+
+// Promote promotes a release of the app of r to another deployment.
+func (r *Releaser) Promote(ctx context.Context, opts *codepush.PromoteOptions) (*codepush.PromoteResult, error) {
+	opts.AppID, opts.Token = r.appID, r.token
+	return codepush.Promote(ctx, r.client, opts, r.out)
+}
+
+// Rollback rolls a deployment of the app of r back to an earlier release.
+func (r *Releaser) Rollback(ctx context.Context, opts *codepush.RollbackOptions) (*codepush.RollbackResult, error) {
+	opts.AppID, opts.Token = r.appID, r.token
+	return codepush.Rollback(ctx, r.client, opts, r.out)
+}
@@ -0,0 +1,319 @@
+// Package rollout persists and advances progressive rollouts: a release's
+// rollout percentage is raised through a series of steps on a timer, with
+// optional health checks between steps that halt the rollout on failure.
+package rollout
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/bitrise-io/bitrise-plugins-codepush-cli/internal/codepush"
+)
+
+// Rollout statuses.
+const (
+	StatusRunning   = "running"
+	StatusCompleted = "completed"
+	StatusHalted    = "halted"
+	StatusAborted   = "aborted"
+)
+
+// ErrNotFound is returned when no rollout has the given ID.
+var ErrNotFound = errors.New("rollout not found")
+
+// Rollout raises the rollout of one release through Steps, one step per
+// Interval.
+type Rollout struct {
+	ID           string        `json:"id"`
+	AppID        string        `json:"app_id"`
+	DeploymentID string        `json:"deployment_id"`
+	Deployment   string        `json:"deployment,omitempty"`
+	UpdateID     string        `json:"update_id"`
+	Label        string        `json:"label"`
+	APIURL       string        `json:"api_url,omitempty"`
+	ServerURL    string        `json:"server_url,omitempty"` // written by older versions instead of APIURL
+	Steps        []int         `json:"steps"`
+	Interval     time.Duration `json:"interval"`
+
+	// MaxFailureRate halts the rollout when the release's failure rate (in
+	// percent) exceeds it. Zero disables the check.
+	MaxFailureRate float64 `json:"max_failure_rate,omitempty"`
+	// HealthURL halts the rollout unless a GET returns a 2xx status.
+	HealthURL string `json:"health_url,omitempty"`
+
+	// Step is the index of the last applied step, -1 before the first.
+	Step   int       `json:"step"`
+	NextAt time.Time `json:"next_at"`
+	Status string    `json:"status"`
+	Reason string    `json:"reason,omitempty"`
+
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// Current returns the rollout percentage applied by the last step, or 0
+// before the first.
+func (r *Rollout) Current() int {
+	if r.Step < 0 {
+		return 0
+	}
+	return r.Steps[r.Step]
+}
+
+// Next returns the percentage of the next step, or 0 when none is left.
+func (r *Rollout) Next() int {
+	if r.Step+1 >= len(r.Steps) {
+		return 0
+	}
+	return r.Steps[r.Step+1]
+}
+
+// ParseSteps parses a --steps value such as "10,25,50,100". Steps must be
+// strictly increasing percentages between 1 and 100.
+func ParseSteps(s string) ([]int, error) {
+	var steps []int
+	for _, field := range strings.Split(s, ",") {
+		field = strings.TrimSuffix(strings.TrimSpace(field), "%")
+		v, err := strconv.Atoi(field)
+		if err != nil || v < 1 || v > 100 {
+			return nil, fmt.Errorf("invalid step %q: steps are percentages between 1 and 100", field)
+		}
+		if len(steps) > 0 && v <= steps[len(steps)-1] {
+			return nil, fmt.Errorf("steps must increase, got %d after %d", v, steps[len(steps)-1])
+		}
+		steps = append(steps, v)
+	}
+	return steps, nil
+}
+
+// Client is the subset of the API client needed to advance a rollout.
+type Client interface {
+	ListUpdates(ctx context.Context, appID, deploymentID string) ([]codepush.Update, error)
+	GetDeploymentMetrics(ctx context.Context, appID, deploymentID string) ([]codepush.UpdateMetrics, error)
+	PatchUpdate(ctx context.Context, appID, deploymentID, updateID string, req codepush.PatchRequest) (*codepush.Update, error)
+}
+
+// HealthFunc checks a health-check URL, returning an error when unhealthy.
+type HealthFunc func(ctx context.Context, url string) error
+
+// Event describes what Advance did.
+type Event string
+
+// Advance events.
+const (
+	EventNone      Event = ""
+	EventStepped   Event = "stepped"
+	EventCompleted Event = "completed"
+	EventHalted    Event = "halted"
+)
+
+// Advance applies the next step of r when it is due at now, updating r in
+// place. Before every step but the first, the release is checked: the
+// rollout halts when the release was disabled or removed, its failure rate
+// exceeds MaxFailureRate, or the health check fails. API errors leave r
+// unchanged so the step is retried later.
+func Advance(ctx context.Context, client Client, r *Rollout, now time.Time, health HealthFunc) (Event, error) {
+	if r.Status != StatusRunning || (r.Step >= 0 && now.Before(r.NextAt)) {
+		return EventNone, nil
+	}
+
+	if r.Step >= 0 {
+		reason, err := check(ctx, client, r, health)
+		if err != nil {
+			return EventNone, err
+		}
+		if reason != "" {
+			r.Status = StatusHalted
+			r.Reason = reason
+			r.UpdatedAt = now
+			return EventHalted, nil
+		}
+	}
+
+	next := r.Next()
+	if _, err := client.PatchUpdate(ctx, r.AppID, r.DeploymentID, r.UpdateID, codepush.PatchRequest{Rollout: &next}); err != nil {
+		return EventNone, fmt.Errorf("setting rollout to %d%%: %w", next, err)
+	}
+	r.Step++
+	r.UpdatedAt = now
+	if r.Next() == 0 {
+		r.Status = StatusCompleted
+		return EventCompleted, nil
+	}
+	r.NextAt = now.Add(r.Interval)
+	return EventStepped, nil
+}
+
+// check returns why the rollout should halt, or "" when it may continue.
+func check(ctx context.Context, client Client, r *Rollout, health HealthFunc) (string, error) {
+	releases, err := codepush.DeploymentReleaseMetrics(ctx, client, r.AppID, r.DeploymentID)
+	if err != nil {
+		return "", err
+	}
+	i := slices.IndexFunc(releases, func(m codepush.ReleaseMetrics) bool { return m.Label == r.Label })
+	if i < 0 {
+		return fmt.Sprintf("release %s is no longer in the deployment", r.Label), nil
+	}
+	release := releases[i]
+	if release.Disabled {
+		return fmt.Sprintf("release %s was disabled", r.Label), nil
+	}
+	if r.MaxFailureRate > 0 && release.FailureRate > r.MaxFailureRate {
+		return fmt.Sprintf("failure rate %.1f%% exceeds %.1f%% (%d failed, %d installed)",
+			release.FailureRate, r.MaxFailureRate, release.Failed, release.Installed), nil
+	}
+	if r.HealthURL != "" && health != nil {
+		if err := health(ctx, r.HealthURL); err != nil {
+			return fmt.Sprintf("health check failed: %v", err), nil
+		}
+	}
+	return "", nil
+}
+
+// healthCheckTimeout bounds each health-check request.
+const healthCheckTimeout = 10 * time.Second
+
+// CheckHealth returns a HealthFunc that requests url with client and
+// returns an error unless it responds with a 2xx status.
+func CheckHealth(client *http.Client) HealthFunc {
+	return func(ctx context.Context, url string) error {
+		ctx, cancel := context.WithTimeout(ctx, healthCheckTimeout)
+		defer cancel()
+
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+		if err != nil {
+			return fmt.Errorf("creating health check request: %w", err)
+		}
+		resp, err := client.Do(req)
+		if err != nil {
+			return err
+		}
+		defer func() { _ = resp.Body.Close() }()
+		_, _ = io.Copy(io.Discard, resp.Body)
+
+		if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+			return fmt.Errorf("%s returned HTTP %d", url, resp.StatusCode)
+		}
+		return nil
+	}
+}
+
+// fileFunc allows tests to override where rollouts are stored.
+var fileFunc = defaultFile
+
+func defaultFile() (string, error) {
+	base, err := os.UserConfigDir()
+	if err != nil {
+		return "", fmt.Errorf("determining config directory: %w", err)
+	}
+	return filepath.Join(base, "codepush", "rollouts.json"), nil
+}
+
+// List returns all rollouts, oldest first.
+func List() ([]Rollout, error) {
+	path, err := fileFunc()
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("reading rollouts: %w", err)
+	}
+
+	var rollouts []Rollout
+	if err := json.Unmarshal(data, &rollouts); err != nil {
+		return nil, fmt.Errorf("parsing rollouts %s: %w", path, err)
+	}
+	slices.SortStableFunc(rollouts, func(a, b Rollout) int { return a.CreatedAt.Compare(b.CreatedAt) })
+	return rollouts, nil
+}
+
+// Get returns the rollout with the given ID, or ErrNotFound.
+func Get(id string) (*Rollout, error) {
+	rollouts, err := List()
+	if err != nil {
+		return nil, err
+	}
+	i := slices.IndexFunc(rollouts, func(r Rollout) bool { return r.ID == id })
+	if i < 0 {
+		return nil, fmt.Errorf("%w: %s", ErrNotFound, id)
+	}
+	return &rollouts[i], nil
+}
+
+// Add records a new running rollout, assigning its ID and creation time.
+// Only one rollout may be running per deployment.
+func Add(r Rollout) (*Rollout, error) {
+	rollouts, err := List()
+	if err != nil {
+		return nil, err
+	}
+	for _, existing := range rollouts {
+		if existing.Status == StatusRunning && existing.AppID == r.AppID && existing.DeploymentID == r.DeploymentID {
+			return nil, fmt.Errorf("rollout %s is already running for this deployment: abort it first", existing.ID)
+		}
+	}
+
+	r.ID = uuid.NewString()[:8]
+	r.Step = -1
+	r.Status = StatusRunning
+	r.CreatedAt = time.Now().UTC()
+	r.UpdatedAt = r.CreatedAt
+	rollouts = append(rollouts, r)
+	if err := save(rollouts); err != nil {
+		return nil, err
+	}
+	return &r, nil
+}
+
+// Update replaces the stored rollout with the same ID.
+func Update(r *Rollout) error {
+	rollouts, err := List()
+	if err != nil {
+		return err
+	}
+	i := slices.IndexFunc(rollouts, func(existing Rollout) bool { return existing.ID == r.ID })
+	if i < 0 {
+		return fmt.Errorf("%w: %s", ErrNotFound, r.ID)
+	}
+	rollouts[i] = *r
+	return save(rollouts)
+}
+
+func save(rollouts []Rollout) error {
+	path, err := fileFunc()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return fmt.Errorf("creating config directory: %w", err)
+	}
+
+	if rollouts == nil {
+		rollouts = []Rollout{}
+	}
+	data, err := json.MarshalIndent(rollouts, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encoding rollouts: %w", err)
+	}
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		return fmt.Errorf("writing rollouts: %w", err)
+	}
+	return nil
+}
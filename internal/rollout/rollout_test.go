@@ -0,0 +1,208 @@
+package rollout
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/bitrise-io/bitrise-plugins-codepush-cli/internal/codepush"
+)
+
+func useTempFile(t *testing.T) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "codepush", "rollouts.json")
+	orig := fileFunc
+	fileFunc = func() (string, error) { return path, nil }
+	t.Cleanup(func() { fileFunc = orig })
+}
+
+type fakeClient struct {
+	updates  []codepush.Update
+	metrics  []codepush.UpdateMetrics
+	patchErr error
+	patched  []int
+}
+
+func (f *fakeClient) ListUpdates(_ context.Context, _, _ string) ([]codepush.Update, error) {
+	return f.updates, nil
+}
+
+func (f *fakeClient) GetDeploymentMetrics(_ context.Context, _, _ string) ([]codepush.UpdateMetrics, error) {
+	return f.metrics, nil
+}
+
+func (f *fakeClient) PatchUpdate(_ context.Context, _, _, _ string, req codepush.PatchRequest) (*codepush.Update, error) {
+	if f.patchErr != nil {
+		return nil, f.patchErr
+	}
+	f.patched = append(f.patched, *req.Rollout)
+	return &codepush.Update{Rollout: float64(*req.Rollout)}, nil
+}
+
+func TestParseSteps(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   string
+		want    []int
+		wantErr string
+	}{
+		{name: "plain", value: "10,25,50,100", want: []int{10, 25, 50, 100}},
+		{name: "spaces and percent signs", value: "5%, 20%, 100%", want: []int{5, 20, 100}},
+		{name: "not increasing", value: "10,10,50", wantErr: "steps must increase"},
+		{name: "out of range", value: "10,150", wantErr: "between 1 and 100"},
+		{name: "empty", value: "", wantErr: "invalid step"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseSteps(tt.value)
+			if tt.wantErr != "" {
+				require.ErrorContains(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func newRollout() *Rollout {
+	return &Rollout{
+		ID:       "r1",
+		Label:    "v3",
+		Steps:    []int{10, 50, 100},
+		Interval: time.Hour,
+		Step:     -1,
+		Status:   StatusRunning,
+	}
+}
+
+func TestAdvance(t *testing.T) {
+	now := time.Date(2024, 7, 1, 9, 0, 0, 0, time.UTC)
+	healthy := []codepush.Update{{Label: "v3", Rollout: 10}}
+
+	t.Run("applies steps until complete", func(t *testing.T) {
+		client := &fakeClient{updates: healthy}
+		r := newRollout()
+
+		event, err := Advance(context.Background(), client, r, now, nil)
+		require.NoError(t, err)
+		assert.Equal(t, EventStepped, event)
+		assert.Equal(t, 10, r.Current())
+		assert.Equal(t, now.Add(time.Hour), r.NextAt)
+
+		event, err = Advance(context.Background(), client, r, now.Add(time.Minute), nil)
+		require.NoError(t, err)
+		assert.Equal(t, EventNone, event, "next step is not due yet")
+
+		_, err = Advance(context.Background(), client, r, now.Add(time.Hour), nil)
+		require.NoError(t, err)
+		event, err = Advance(context.Background(), client, r, now.Add(2*time.Hour), nil)
+		require.NoError(t, err)
+		assert.Equal(t, EventCompleted, event)
+		assert.Equal(t, StatusCompleted, r.Status)
+		assert.Equal(t, []int{10, 50, 100}, client.patched)
+	})
+
+	t.Run("halts when the failure rate is too high", func(t *testing.T) {
+		client := &fakeClient{
+			updates: healthy,
+			metrics: []codepush.UpdateMetrics{{Label: "v3", Installed: 90, Failed: 10}},
+		}
+		r := newRollout()
+		r.MaxFailureRate = 5
+		_, err := Advance(context.Background(), client, r, now, nil)
+		require.NoError(t, err)
+
+		event, err := Advance(context.Background(), client, r, r.NextAt, nil)
+		require.NoError(t, err)
+		assert.Equal(t, EventHalted, event)
+		assert.Equal(t, StatusHalted, r.Status)
+		assert.Contains(t, r.Reason, "failure rate 10.0% exceeds 5.0%")
+		assert.Equal(t, []int{10}, client.patched)
+	})
+
+	t.Run("halts when the release was disabled", func(t *testing.T) {
+		client := &fakeClient{updates: []codepush.Update{{Label: "v3", Disabled: true}}}
+		r := newRollout()
+		_, err := Advance(context.Background(), client, r, now, nil)
+		require.NoError(t, err)
+
+		event, err := Advance(context.Background(), client, r, r.NextAt, nil)
+		require.NoError(t, err)
+		assert.Equal(t, EventHalted, event)
+		assert.Equal(t, "release v3 was disabled", r.Reason)
+	})
+
+	t.Run("halts when the health check fails", func(t *testing.T) {
+		client := &fakeClient{updates: healthy}
+		r := newRollout()
+		r.HealthURL = "https://status.example.com"
+		unhealthy := func(context.Context, string) error { return errors.New("HTTP 503") }
+
+		_, err := Advance(context.Background(), client, r, now, unhealthy)
+		require.NoError(t, err, "the first step is not checked")
+
+		event, err := Advance(context.Background(), client, r, r.NextAt, unhealthy)
+		require.NoError(t, err)
+		assert.Equal(t, EventHalted, event)
+		assert.Equal(t, "health check failed: HTTP 503", r.Reason)
+	})
+
+	t.Run("leaves the rollout unchanged on API errors", func(t *testing.T) {
+		client := &fakeClient{updates: healthy, patchErr: errors.New("API returned HTTP 502")}
+		r := newRollout()
+
+		_, err := Advance(context.Background(), client, r, now, nil)
+		require.ErrorContains(t, err, "setting rollout to 10%")
+		assert.Equal(t, -1, r.Step)
+		assert.Equal(t, StatusRunning, r.Status)
+	})
+}
+
+func TestCheckHealth(t *testing.T) {
+	status := http.StatusOK
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(status)
+	}))
+	defer srv.Close()
+
+	require.NoError(t, CheckHealth(srv.Client())(context.Background(), srv.URL))
+
+	status = http.StatusServiceUnavailable
+	assert.ErrorContains(t, CheckHealth(srv.Client())(context.Background(), srv.URL), "returned HTTP 503")
+}
+
+func TestAddGetUpdate(t *testing.T) {
+	useTempFile(t)
+
+	r, err := Add(Rollout{AppID: "app", DeploymentID: "d1", Label: "v3", Steps: []int{10, 100}, Interval: time.Hour})
+	require.NoError(t, err)
+	assert.Len(t, r.ID, 8)
+	assert.Equal(t, -1, r.Step)
+	assert.Equal(t, StatusRunning, r.Status)
+
+	_, err = Add(Rollout{AppID: "app", DeploymentID: "d1", Label: "v4", Steps: []int{100}})
+	require.ErrorContains(t, err, "already running for this deployment")
+
+	r.Status = StatusAborted
+	require.NoError(t, Update(r))
+
+	got, err := Get(r.ID)
+	require.NoError(t, err)
+	assert.Equal(t, StatusAborted, got.Status)
+	assert.Equal(t, time.Hour, got.Interval)
+
+	_, err = Get("missing")
+	require.ErrorIs(t, err, ErrNotFound)
+
+	_, err = Add(Rollout{AppID: "app", DeploymentID: "d1", Label: "v4", Steps: []int{100}})
+	require.NoError(t, err, "a new rollout may start once the previous one stopped")
+}
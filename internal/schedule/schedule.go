@@ -0,0 +1,164 @@
+// Package schedule persists pending release activations: releases created
+// disabled by push or promote --activate-at, to be enabled by
+// "codepush schedule run" once their activation time has passed.
+package schedule
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ErrNotFound is returned when no activation has the given ID.
+var ErrNotFound = errors.New("scheduled activation not found")
+
+// Activation enables a disabled release at ActivateAt.
+type Activation struct {
+	ID           string    `json:"id"`
+	AppID        string    `json:"app_id"`
+	DeploymentID string    `json:"deployment_id"`
+	Deployment   string    `json:"deployment,omitempty"`
+	UpdateID     string    `json:"update_id"`
+	Label        string    `json:"label,omitempty"`
+	APIURL       string    `json:"api_url,omitempty"`
+	ServerURL    string    `json:"server_url,omitempty"` // written by older versions instead of APIURL
+	ActivateAt   time.Time `json:"activate_at"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+// Due reports whether the activation time has passed at now.
+func (a *Activation) Due(now time.Time) bool {
+	return !now.Before(a.ActivateAt)
+}
+
+// activateAtLayouts are the accepted --activate-at formats, most precise first.
+var activateAtLayouts = []string{
+	time.RFC3339,
+	"2006-01-02T15:04Z07:00",
+	"2006-01-02T15:04",
+}
+
+// ParseActivateAt parses an --activate-at value such as
+// "2024-07-01T09:00Z" or "2024-07-01T09:00:00+02:00". A time without a zone
+// is local time. The time must be after now.
+func ParseActivateAt(s string, now time.Time) (time.Time, error) {
+	for _, layout := range activateAtLayouts {
+		t, err := time.ParseInLocation(layout, s, time.Local)
+		if err != nil {
+			continue
+		}
+		if !t.After(now) {
+			return time.Time{}, fmt.Errorf("activation time %s is in the past", t.Format(time.RFC3339))
+		}
+		return t.UTC(), nil
+	}
+	return time.Time{}, fmt.Errorf("invalid activation time %q: use RFC 3339, e.g. 2024-07-01T09:00Z", s)
+}
+
+// fileFunc allows tests to override where the schedule is stored.
+var fileFunc = defaultFile
+
+func defaultFile() (string, error) {
+	base, err := os.UserConfigDir()
+	if err != nil {
+		return "", fmt.Errorf("determining config directory: %w", err)
+	}
+	return filepath.Join(base, "codepush", "schedule.json"), nil
+}
+
+// List returns all pending activations, earliest first.
+func List() ([]Activation, error) {
+	path, err := fileFunc()
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("reading schedule: %w", err)
+	}
+
+	var activations []Activation
+	if err := json.Unmarshal(data, &activations); err != nil {
+		return nil, fmt.Errorf("parsing schedule %s: %w", path, err)
+	}
+	slices.SortStableFunc(activations, func(a, b Activation) int { return a.ActivateAt.Compare(b.ActivateAt) })
+	return activations, nil
+}
+
+// Add records a pending activation, assigning its ID and creation time.
+func Add(a Activation) (*Activation, error) {
+	activations, err := List()
+	if err != nil {
+		return nil, err
+	}
+
+	a.ID = uuid.NewString()[:8]
+	a.CreatedAt = time.Now().UTC()
+	activations = append(activations, a)
+	if err := save(activations); err != nil {
+		return nil, err
+	}
+	return &a, nil
+}
+
+// Get returns the activation with the given ID, or ErrNotFound if there is
+// none.
+func Get(id string) (*Activation, error) {
+	activations, err := List()
+	if err != nil {
+		return nil, err
+	}
+
+	i := slices.IndexFunc(activations, func(a Activation) bool { return a.ID == id })
+	if i < 0 {
+		return nil, fmt.Errorf("%w: %s", ErrNotFound, id)
+	}
+	return &activations[i], nil
+}
+
+// Remove deletes the activation with the given ID, returning ErrNotFound if
+// there is none.
+func Remove(id string) error {
+	activations, err := List()
+	if err != nil {
+		return err
+	}
+
+	i := slices.IndexFunc(activations, func(a Activation) bool { return a.ID == id })
+	if i < 0 {
+		return fmt.Errorf("%w: %s", ErrNotFound, id)
+	}
+	return save(slices.Delete(activations, i, i+1))
+}
+
+func save(activations []Activation) error {
+	path, err := fileFunc()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return fmt.Errorf("creating config directory: %w", err)
+	}
+
+	if activations == nil {
+		activations = []Activation{}
+	}
+	data, err := json.MarshalIndent(activations, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encoding schedule: %w", err)
+	}
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		return fmt.Errorf("writing schedule: %w", err)
+	}
+	return nil
+}
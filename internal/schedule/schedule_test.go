@@ -0,0 +1,89 @@
+package schedule
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func useTempFile(t *testing.T) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "codepush", "schedule.json")
+	orig := fileFunc
+	fileFunc = func() (string, error) { return path, nil }
+	t.Cleanup(func() { fileFunc = orig })
+}
+
+func TestParseActivateAt(t *testing.T) {
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name    string
+		value   string
+		want    time.Time
+		wantErr string
+	}{
+		{name: "minutes with Z", value: "2024-07-01T09:00Z", want: time.Date(2024, 7, 1, 9, 0, 0, 0, time.UTC)},
+		{name: "RFC 3339 with offset", value: "2024-07-01T09:00:00+02:00", want: time.Date(2024, 7, 1, 7, 0, 0, 0, time.UTC)},
+		{name: "in the past", value: "2024-05-01T09:00Z", wantErr: "in the past"},
+		{name: "garbage", value: "next tuesday", wantErr: "invalid activation time"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseActivateAt(tt.value, now)
+			if tt.wantErr != "" {
+				require.ErrorContains(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.True(t, tt.want.Equal(got), "got %s", got)
+		})
+	}
+}
+
+func TestAddListRemove(t *testing.T) {
+	useTempFile(t)
+
+	activations, err := List()
+	require.NoError(t, err)
+	assert.Empty(t, activations)
+
+	later, err := Add(Activation{AppID: "app", DeploymentID: "d1", UpdateID: "u2", ActivateAt: time.Date(2024, 7, 2, 0, 0, 0, 0, time.UTC)})
+	require.NoError(t, err)
+	assert.Len(t, later.ID, 8)
+	assert.False(t, later.CreatedAt.IsZero())
+
+	sooner, err := Add(Activation{AppID: "app", DeploymentID: "d1", UpdateID: "u1", ActivateAt: time.Date(2024, 7, 1, 0, 0, 0, 0, time.UTC)})
+	require.NoError(t, err)
+
+	activations, err = List()
+	require.NoError(t, err)
+	require.Len(t, activations, 2)
+	assert.Equal(t, sooner.ID, activations[0].ID, "earliest activation first")
+
+	got, err := Get(later.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "u2", got.UpdateID)
+
+	require.NoError(t, Remove(sooner.ID))
+	_, err = Get(sooner.ID)
+	require.ErrorIs(t, err, ErrNotFound)
+	require.ErrorIs(t, Remove(sooner.ID), ErrNotFound)
+
+	activations, err = List()
+	require.NoError(t, err)
+	require.Len(t, activations, 1)
+	assert.Equal(t, "u2", activations[0].UpdateID)
+}
+
+func TestActivationDue(t *testing.T) {
+	at := time.Date(2024, 7, 1, 9, 0, 0, 0, time.UTC)
+	a := Activation{ActivateAt: at}
+	assert.False(t, a.Due(at.Add(-time.Second)))
+	assert.True(t, a.Due(at))
+	assert.True(t, a.Due(at.Add(time.Hour)))
+}
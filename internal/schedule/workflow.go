@@ -0,0 +1,67 @@
+package schedule
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// unsafeWorkflowChars matches characters left out of generated workflow IDs.
+var unsafeWorkflowChars = regexp.MustCompile(`[^a-z0-9_-]+`)
+
+// WorkflowID returns the ID of the Bitrise workflow that enables a's
+// release, e.g. "codepush-activate-production-v12".
+func WorkflowID(a Activation) string {
+	deployment := a.Deployment
+	if deployment == "" {
+		deployment = a.DeploymentID
+	}
+	return "codepush-activate-" + workflowIDPart(deployment) + "-" + workflowIDPart(a.Label)
+}
+
+func workflowIDPart(s string) string {
+	return strings.Trim(unsafeWorkflowChars.ReplaceAllString(strings.ToLower(s), "-"), "-")
+}
+
+// BitriseWorkflow returns a bitrise.yml workflow that enables a's release,
+// for a scheduled build at a.ActivateAt. Unlike "codepush schedule run", it
+// does not need the schedule file. The workflow fails without enabling the
+// release when it runs before the activation time. a must have a label.
+func BitriseWorkflow(a Activation) (string, error) {
+	if a.Label == "" {
+		return "", errors.New("the release label is not known yet")
+	}
+
+	at := a.ActivateAt.UTC().Format(time.RFC3339)
+	script := []string{
+		"#!/usr/bin/env bash",
+		"set -euo pipefail",
+		fmt.Sprintf(`if [ "$(date -u +%%s)" -lt %d ]; then`, a.ActivateAt.Unix()),
+		fmt.Sprintf(`  echo "Release %s is scheduled for %s, not enabling it yet"`, a.Label, at),
+		"  exit 1",
+		"fi",
+		fmt.Sprintf("bitrise :codepush patch --app-id %s --deployment %s --label %s --disabled false", a.AppID, a.DeploymentID, a.Label),
+	}
+
+	var b strings.Builder
+	deployment := a.Deployment
+	if deployment == "" {
+		deployment = a.DeploymentID
+	}
+	fmt.Fprintf(&b, "# Enables CodePush release %s in %s at %s.\n", a.Label, deployment, at)
+	b.WriteString("# Add the workflow to bitrise.yml and start it from a scheduled build at\n")
+	b.WriteString("# that time. BITRISE_API_TOKEN must be set as a secret.\n")
+	b.WriteString("workflows:\n")
+	fmt.Fprintf(&b, "  %s:\n", WorkflowID(a))
+	b.WriteString("    steps:\n")
+	b.WriteString("    - script@1:\n")
+	fmt.Fprintf(&b, "        title: %q\n", "Enable CodePush release "+a.Label+" in "+deployment)
+	b.WriteString("        inputs:\n")
+	b.WriteString("        - content: |-\n")
+	for _, line := range script {
+		b.WriteString("            " + line + "\n")
+	}
+	return b.String(), nil
+}
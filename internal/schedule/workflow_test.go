@@ -0,0 +1,63 @@
+package schedule
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWorkflowID(t *testing.T) {
+	tests := []struct {
+		name       string
+		activation Activation
+		want       string
+	}{
+		{name: "deployment name", activation: Activation{Deployment: "Production", Label: "v12"}, want: "codepush-activate-production-v12"},
+		{name: "unsafe characters", activation: Activation{Deployment: "QA (Alice)", Label: "v3"}, want: "codepush-activate-qa-alice-v3"},
+		{name: "deployment ID", activation: Activation{DeploymentID: "dep-1", Label: "v3"}, want: "codepush-activate-dep-1-v3"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, WorkflowID(tt.activation))
+		})
+	}
+}
+
+func TestBitriseWorkflow(t *testing.T) {
+	a := Activation{
+		AppID:        "app-1",
+		DeploymentID: "dep-1",
+		Deployment:   "Production",
+		UpdateID:     "pkg-1",
+		Label:        "v12",
+		ActivateAt:   time.Date(2024, 7, 1, 9, 0, 0, 0, time.UTC),
+	}
+
+	workflow, err := BitriseWorkflow(a)
+	require.NoError(t, err)
+	assert.Equal(t, `# Enables CodePush release v12 in Production at 2024-07-01T09:00:00Z.
+# Add the workflow to bitrise.yml and start it from a scheduled build at
+# that time. BITRISE_API_TOKEN must be set as a secret.
+workflows:
+  codepush-activate-production-v12:
+    steps:
+    - script@1:
+        title: "Enable CodePush release v12 in Production"
+        inputs:
+        - content: |-
+            #!/usr/bin/env bash
+            set -euo pipefail
+            if [ "$(date -u +%s)" -lt 1719824400 ]; then
+              echo "Release v12 is scheduled for 2024-07-01T09:00:00Z, not enabling it yet"
+              exit 1
+            fi
+            bitrise :codepush patch --app-id app-1 --deployment dep-1 --label v12 --disabled false
+`, workflow)
+
+	a.Label = ""
+	_, err = BitriseWorkflow(a)
+	assert.Error(t, err)
+}
@@ -0,0 +1,106 @@
+// Package session persists answers collected by interactive command wizards
+// so that an interrupted run can be resumed.
+package session
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// PushState holds the answers collected by the interactive push wizard.
+type PushState struct {
+	AppID       string    `json:"app_id,omitempty"`
+	Platform    string    `json:"platform,omitempty"`
+	Deployment  string    `json:"deployment,omitempty"`
+	AppVersion  string    `json:"app_version,omitempty"`
+	Description string    `json:"description,omitempty"`
+	BundlePath  string    `json:"bundle_path,omitempty"`
+	SavedAt     time.Time `json:"saved_at"`
+}
+
+// IsEmpty reports whether no answers have been collected yet.
+func (s *PushState) IsEmpty() bool {
+	return s.Platform == "" && s.Deployment == "" && s.AppVersion == "" && s.BundlePath == ""
+}
+
+// stateDirFunc allows tests to override the directory state files are kept in.
+var stateDirFunc = os.TempDir
+
+// workDirFunc allows tests to override the directory sessions are keyed by.
+var workDirFunc = os.Getwd
+
+// pushStatePath returns the state file for the current working directory, so
+// that sessions from different projects do not collide.
+func pushStatePath() (string, error) {
+	wd, err := workDirFunc()
+	if err != nil {
+		return "", fmt.Errorf("determining working directory: %w", err)
+	}
+	sum := sha256.Sum256([]byte(wd))
+	return filepath.Join(stateDirFunc(), "codepush-push-"+hex.EncodeToString(sum[:6])+".json"), nil
+}
+
+// LoadPush returns the saved push session for the current directory.
+// Returns (nil, nil) if there is none.
+func LoadPush() (*PushState, error) {
+	path, err := pushStatePath()
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil //nolint:nilnil // no saved session is a valid state
+		}
+		return nil, fmt.Errorf("reading push session: %w", err)
+	}
+
+	var state PushState
+	if err := json.Unmarshal(data, &state); err != nil {
+		return nil, fmt.Errorf("parsing push session %s: %w", path, err)
+	}
+
+	return &state, nil
+}
+
+// SavePush writes the push session for the current directory.
+func SavePush(state *PushState) error {
+	path, err := pushStatePath()
+	if err != nil {
+		return err
+	}
+
+	state.SavedAt = time.Now().UTC()
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encoding push session: %w", err)
+	}
+
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		return fmt.Errorf("writing push session: %w", err)
+	}
+
+	return nil
+}
+
+// ClearPush removes the push session for the current directory. Clearing a
+// missing session is not an error.
+func ClearPush() error {
+	path, err := pushStatePath()
+	if err != nil {
+		return err
+	}
+
+	if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("removing push session: %w", err)
+	}
+
+	return nil
+}
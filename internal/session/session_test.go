@@ -0,0 +1,77 @@
+package session
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func useTempDirs(t *testing.T, workDir string) {
+	t.Helper()
+	stateDir := t.TempDir()
+	oldState, oldWork := stateDirFunc, workDirFunc
+	stateDirFunc = func() string { return stateDir }
+	workDirFunc = func() (string, error) { return workDir, nil }
+	t.Cleanup(func() { stateDirFunc, workDirFunc = oldState, oldWork })
+}
+
+func TestPushSession(t *testing.T) {
+	t.Run("load returns nil without a saved session", func(t *testing.T) {
+		useTempDirs(t, "/projects/a")
+
+		state, err := LoadPush()
+		require.NoError(t, err)
+		assert.Nil(t, state)
+	})
+
+	t.Run("save, load, and clear round trip", func(t *testing.T) {
+		useTempDirs(t, "/projects/a")
+
+		require.NoError(t, SavePush(&PushState{Platform: "ios", Deployment: "dep-1", AppVersion: "1.2.0"}))
+
+		state, err := LoadPush()
+		require.NoError(t, err)
+		require.NotNil(t, state)
+		assert.Equal(t, "ios", state.Platform)
+		assert.Equal(t, "dep-1", state.Deployment)
+		assert.Equal(t, "1.2.0", state.AppVersion)
+		assert.False(t, state.SavedAt.IsZero())
+
+		require.NoError(t, ClearPush())
+		state, err = LoadPush()
+		require.NoError(t, err)
+		assert.Nil(t, state)
+	})
+
+	t.Run("sessions are keyed by working directory", func(t *testing.T) {
+		useTempDirs(t, "/projects/a")
+		require.NoError(t, SavePush(&PushState{AppVersion: "1.0.0"}))
+
+		workDirFunc = func() (string, error) { return "/projects/b", nil }
+		state, err := LoadPush()
+		require.NoError(t, err)
+		assert.Nil(t, state)
+	})
+
+	t.Run("clear without a session is a no-op", func(t *testing.T) {
+		useTempDirs(t, "/projects/a")
+		assert.NoError(t, ClearPush())
+	})
+
+	t.Run("load reports corrupt state", func(t *testing.T) {
+		useTempDirs(t, "/projects/a")
+		path, err := pushStatePath()
+		require.NoError(t, err)
+		require.NoError(t, os.WriteFile(path, []byte("{"), 0o600))
+
+		_, err = LoadPush()
+		assert.ErrorContains(t, err, "parsing push session")
+	})
+}
+
+func TestPushStateIsEmpty(t *testing.T) {
+	assert.True(t, (&PushState{AppID: "app-1"}).IsEmpty())
+	assert.False(t, (&PushState{AppVersion: "1.0.0"}).IsEmpty())
+}
@@ -0,0 +1,85 @@
+package sourcemaps
+
+import (
+	"context"
+	"errors"
+	"os"
+
+	"github.com/bitrise-io/bitrise-plugins-codepush-cli/internal/bundler"
+	"github.com/bitrise-io/bitrise-plugins-codepush-cli/internal/output"
+)
+
+// BugsnagAPIKeyEnvKey is the environment variable holding the Bugsnag API
+// key when --bugsnag-api-key is not set.
+const BugsnagAPIKeyEnvKey = "BUGSNAG_API_KEY"
+
+// BugsnagUploader uploads source maps with "bugsnag-source-maps
+// upload-react-native" from the @bugsnag/source-maps package.
+//
+// The code bundle ID defaults to "<app version>+codepush:<label>"; set the
+// same codeBundleId in the Bugsnag client for CodePush releases.
+type BugsnagUploader struct {
+	opts     Options
+	executor bundler.CommandExecutor
+	out      *output.Writer
+}
+
+// Check implements Uploader.
+func (u *BugsnagUploader) Check(projectDir string, labelKnown bool) error {
+	if _, err := findTool(projectDir, "bugsnag-source-maps", "@bugsnag/source-maps"); err != nil {
+		return err
+	}
+	if u.apiKey() == "" {
+		return errors.New("uploading source maps to Bugsnag needs an API key: set --bugsnag-api-key or " + BugsnagAPIKeyEnvKey)
+	}
+	if !labelKnown && u.opts.BugsnagCodeBundleID == "" {
+		return errors.New("uploading source maps to Bugsnag needs a code bundle ID: set --bugsnag-code-bundle-id, or use 'push --bundle' to derive it from the release label")
+	}
+	return nil
+}
+
+// Upload implements Uploader.
+func (u *BugsnagUploader) Upload(ctx context.Context, a Artifact, r Release) error {
+	cli, err := findTool(a.ProjectDir, "bugsnag-source-maps", "@bugsnag/source-maps")
+	if err != nil {
+		return err
+	}
+	if err := requireSourcemap(a); err != nil {
+		return err
+	}
+
+	codeBundleID := u.opts.BugsnagCodeBundleID
+	if codeBundleID == "" {
+		codeBundleID = codePushVersion(r)
+	}
+	if codeBundleID == "" {
+		return errors.New("bugsnag code bundle ID is unknown: set --bugsnag-code-bundle-id")
+	}
+
+	args := []string{
+		"upload-react-native",
+		"--api-key", u.apiKey(),
+		"--platform", a.Platform,
+		"--code-bundle-id", codeBundleID,
+		"--bundle", a.BundlePath,
+		"--source-map", a.SourcemapPath,
+		"--overwrite",
+	}
+	if a.ProjectDir != "" {
+		args = append(args, "--project-root", a.ProjectDir)
+	}
+
+	if err := runTool(ctx, u.executor, u.out, "Uploading source maps to Bugsnag", a.ProjectDir, cli, args...); err != nil {
+		return err
+	}
+
+	u.out.Info("Bugsnag code bundle ID: %s", codeBundleID)
+	return nil
+}
+
+func (u *BugsnagUploader) apiKey() string {
+	if u.opts.BugsnagAPIKey != "" {
+		return u.opts.BugsnagAPIKey
+	}
+	return os.Getenv(BugsnagAPIKeyEnvKey)
+}
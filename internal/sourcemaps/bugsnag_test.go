@@ -0,0 +1,50 @@
+package sourcemaps
+
+import (
+	"context"
+	"io"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/bitrise-io/bitrise-plugins-codepush-cli/internal/output"
+)
+
+func TestBugsnagUploader(t *testing.T) {
+	t.Setenv(BugsnagAPIKeyEnvKey, "")
+	dir, cli := newProject(t, "bugsnag-source-maps")
+	artifact := Artifact{
+		ProjectDir:    dir,
+		Platform:      "ios",
+		BundlePath:    filepath.Join(dir, "CodePush", "main.jsbundle"),
+		SourcemapPath: filepath.Join(dir, "CodePush", "main.jsbundle.map"),
+	}
+
+	t.Run("uploads with code bundle ID derived from the label", func(t *testing.T) {
+		exec := &mockExecutor{}
+		u := &BugsnagUploader{opts: Options{BugsnagAPIKey: "key"}, executor: exec, out: output.NewTest(io.Discard)}
+
+		require.NoError(t, u.Upload(context.Background(), artifact, Release{AppVersion: "1.2.0", Label: "v7"}))
+		assert.Equal(t, cli, exec.name)
+		assert.Equal(t, []string{
+			"upload-react-native", "--api-key", "key", "--platform", "ios",
+			"--code-bundle-id", "1.2.0+codepush:v7",
+			"--bundle", artifact.BundlePath, "--source-map", artifact.SourcemapPath,
+			"--overwrite", "--project-root", dir,
+		}, exec.args)
+	})
+
+	t.Run("check", func(t *testing.T) {
+		u := &BugsnagUploader{}
+		assert.ErrorContains(t, u.Check(dir, true), "needs an API key")
+
+		t.Setenv(BugsnagAPIKeyEnvKey, "key")
+		assert.NoError(t, u.Check(dir, true))
+		assert.ErrorContains(t, u.Check(dir, false), "--bugsnag-code-bundle-id")
+
+		u.opts.BugsnagCodeBundleID = "1.2.0-hotfix"
+		assert.NoError(t, u.Check(dir, false))
+	})
+}
@@ -0,0 +1,80 @@
+package sourcemaps
+
+import (
+	"context"
+	"errors"
+	"os"
+
+	"github.com/bitrise-io/bitrise-plugins-codepush-cli/internal/bundler"
+	"github.com/bitrise-io/bitrise-plugins-codepush-cli/internal/output"
+)
+
+// DatadogAPIKeyEnvKey is the environment variable datadog-ci reads its API
+// key from. DATADOG_SITE selects the Datadog site.
+const DatadogAPIKeyEnvKey = "DATADOG_API_KEY"
+
+// DatadogUploader uploads source maps with "datadog-ci react-native upload"
+// from the @datadog/datadog-ci package.
+//
+// The release version defaults to "<app version>-codepush.<label>", the
+// version the Datadog React Native SDK reports for CodePush updates.
+type DatadogUploader struct {
+	opts     Options
+	executor bundler.CommandExecutor
+	out      *output.Writer
+}
+
+// Check implements Uploader.
+func (u *DatadogUploader) Check(projectDir string, labelKnown bool) error {
+	if _, err := findTool(projectDir, "datadog-ci", "@datadog/datadog-ci"); err != nil {
+		return err
+	}
+	if os.Getenv(DatadogAPIKeyEnvKey) == "" {
+		return errors.New("uploading source maps to Datadog needs an API key: set " + DatadogAPIKeyEnvKey)
+	}
+	if u.opts.DatadogService == "" {
+		return errors.New("uploading source maps to Datadog needs the RUM service name: set --datadog-service")
+	}
+	if !labelKnown && u.opts.DatadogReleaseVersion == "" {
+		return errors.New("uploading source maps to Datadog needs a release version: set --datadog-release-version, or use 'push --bundle' to derive it from the release label")
+	}
+	return nil
+}
+
+// Upload implements Uploader.
+func (u *DatadogUploader) Upload(ctx context.Context, a Artifact, r Release) error {
+	cli, err := findTool(a.ProjectDir, "datadog-ci", "@datadog/datadog-ci")
+	if err != nil {
+		return err
+	}
+	if err := requireSourcemap(a); err != nil {
+		return err
+	}
+
+	version := u.opts.DatadogReleaseVersion
+	if version == "" && r.AppVersion != "" && r.Label != "" {
+		version = r.AppVersion + "-codepush." + r.Label
+	}
+	if version == "" {
+		return errors.New("datadog release version is unknown: set --datadog-release-version")
+	}
+
+	args := []string{
+		"react-native", "upload",
+		"--platform", a.Platform,
+		"--service", u.opts.DatadogService,
+		"--bundle", a.BundlePath,
+		"--sourcemap", a.SourcemapPath,
+		"--release-version", version,
+	}
+	if u.opts.DatadogBuildVersion != "" {
+		args = append(args, "--build-version", u.opts.DatadogBuildVersion)
+	}
+
+	if err := runTool(ctx, u.executor, u.out, "Uploading source maps to Datadog", a.ProjectDir, cli, args...); err != nil {
+		return err
+	}
+
+	u.out.Info("Datadog release version: %s", version)
+	return nil
+}
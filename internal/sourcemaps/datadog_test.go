@@ -0,0 +1,49 @@
+package sourcemaps
+
+import (
+	"context"
+	"io"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/bitrise-io/bitrise-plugins-codepush-cli/internal/output"
+)
+
+func TestDatadogUploader(t *testing.T) {
+	t.Setenv(DatadogAPIKeyEnvKey, "")
+	dir, cli := newProject(t, "datadog-ci")
+	artifact := Artifact{
+		ProjectDir:    dir,
+		Platform:      "android",
+		BundlePath:    filepath.Join(dir, "CodePush", "index.android.bundle"),
+		SourcemapPath: filepath.Join(dir, "CodePush", "index.android.bundle.map"),
+	}
+
+	t.Run("uploads with release version derived from the label", func(t *testing.T) {
+		exec := &mockExecutor{}
+		u := &DatadogUploader{opts: Options{DatadogService: "com.example.app", DatadogBuildVersion: "42"}, executor: exec, out: output.NewTest(io.Discard)}
+
+		require.NoError(t, u.Upload(context.Background(), artifact, Release{AppVersion: "1.2.0", Label: "v7"}))
+		assert.Equal(t, cli, exec.name)
+		assert.Equal(t, []string{
+			"react-native", "upload", "--platform", "android", "--service", "com.example.app",
+			"--bundle", artifact.BundlePath, "--sourcemap", artifact.SourcemapPath,
+			"--release-version", "1.2.0-codepush.v7", "--build-version", "42",
+		}, exec.args)
+	})
+
+	t.Run("check", func(t *testing.T) {
+		u := &DatadogUploader{}
+		assert.ErrorContains(t, u.Check(dir, true), DatadogAPIKeyEnvKey)
+
+		t.Setenv(DatadogAPIKeyEnvKey, "key")
+		assert.ErrorContains(t, u.Check(dir, true), "--datadog-service")
+
+		u.opts.DatadogService = "com.example.app"
+		assert.NoError(t, u.Check(dir, true))
+		assert.ErrorContains(t, u.Check(dir, false), "--datadog-release-version")
+	})
+}
@@ -0,0 +1,88 @@
+package sourcemaps
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/bitrise-io/bitrise-plugins-codepush-cli/internal/bundler"
+	"github.com/bitrise-io/bitrise-plugins-codepush-cli/internal/output"
+)
+
+// Environment variables read in addition to sentry-cli's own configuration
+// (SENTRY_AUTH_TOKEN, SENTRY_ORG, SENTRY_PROJECT, SENTRY_URL, or a
+// sentry.properties file).
+const (
+	SentryReleaseEnvKey = "SENTRY_RELEASE"
+	SentryDistEnvKey    = "SENTRY_DIST"
+)
+
+// SentryUploader uploads source maps with "sentry-cli sourcemaps upload".
+//
+// The release defaults to "<app version>+codepush:<label>" and the dist to
+// the label, matching what the Sentry React Native SDK reports for CodePush
+// updates when initialized with the same values.
+type SentryUploader struct {
+	executor bundler.CommandExecutor
+	out      *output.Writer
+}
+
+// Check implements Uploader.
+func (u *SentryUploader) Check(projectDir string, labelKnown bool) error {
+	if _, err := findTool(projectDir, "sentry-cli", "@sentry/cli"); err != nil {
+		return err
+	}
+	if !labelKnown && os.Getenv(SentryReleaseEnvKey) == "" {
+		return fmt.Errorf("uploading source maps to Sentry needs a release: set %s, or use 'push --bundle' to derive it from the release label", SentryReleaseEnvKey)
+	}
+	return nil
+}
+
+// Upload implements Uploader.
+func (u *SentryUploader) Upload(ctx context.Context, a Artifact, r Release) error {
+	cli, err := findTool(a.ProjectDir, "sentry-cli", "@sentry/cli")
+	if err != nil {
+		return err
+	}
+	if err := requireSourcemap(a); err != nil {
+		return err
+	}
+
+	release, dist := sentryRelease(r)
+	if release == "" {
+		return fmt.Errorf("sentry release is unknown: set %s", SentryReleaseEnvKey)
+	}
+
+	args := []string{"sourcemaps", "upload", "--release", release}
+	if dist != "" {
+		args = append(args, "--dist", dist)
+	}
+	if a.ProjectDir != "" {
+		args = append(args, "--strip-prefix", a.ProjectDir)
+	}
+	args = append(args, a.BundlePath, a.SourcemapPath)
+
+	if err := runTool(ctx, u.executor, u.out, "Uploading source maps to Sentry", a.ProjectDir, cli, args...); err != nil {
+		return err
+	}
+
+	u.out.Info("Sentry release: %s", release)
+	if dist != "" {
+		u.out.Info("Sentry dist: %s", dist)
+	}
+	return nil
+}
+
+// sentryRelease returns the Sentry release and dist for r, letting
+// SENTRY_RELEASE and SENTRY_DIST override the defaults.
+func sentryRelease(r Release) (release, dist string) {
+	release = os.Getenv(SentryReleaseEnvKey)
+	if release == "" {
+		release = codePushVersion(r)
+	}
+	dist = os.Getenv(SentryDistEnvKey)
+	if dist == "" {
+		dist = r.Label
+	}
+	return release, dist
+}
@@ -0,0 +1,79 @@
+package sourcemaps
+
+import (
+	"context"
+	"errors"
+	"io"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/bitrise-io/bitrise-plugins-codepush-cli/internal/output"
+)
+
+func TestSentryUploaderUpload(t *testing.T) {
+	t.Setenv(SentryReleaseEnvKey, "")
+	t.Setenv(SentryDistEnvKey, "")
+	dir, cli := newProject(t, "sentry-cli")
+	artifact := Artifact{
+		ProjectDir:    dir,
+		BundlePath:    filepath.Join(dir, "CodePush", "index.android.bundle"),
+		SourcemapPath: filepath.Join(dir, "CodePush", "index.android.bundle.map"),
+	}
+
+	t.Run("derives release and dist from the label", func(t *testing.T) {
+		exec := &mockExecutor{}
+		u := &SentryUploader{executor: exec, out: output.NewTest(io.Discard)}
+
+		require.NoError(t, u.Upload(context.Background(), artifact, Release{AppVersion: "1.2.0", Label: "v7"}))
+		assert.Equal(t, cli, exec.name)
+		assert.Equal(t, []string{
+			"sourcemaps", "upload", "--release", "1.2.0+codepush:v7", "--dist", "v7",
+			"--strip-prefix", dir, artifact.BundlePath, artifact.SourcemapPath,
+		}, exec.args)
+	})
+
+	t.Run("environment overrides release and dist", func(t *testing.T) {
+		t.Setenv(SentryReleaseEnvKey, "com.example@1.2.0+codepush:v7")
+		t.Setenv(SentryDistEnvKey, "42")
+		exec := &mockExecutor{}
+		u := &SentryUploader{executor: exec, out: output.NewTest(io.Discard)}
+
+		require.NoError(t, u.Upload(context.Background(), artifact, Release{}))
+		assert.Equal(t, []string{"--release", "com.example@1.2.0+codepush:v7", "--dist", "42"}, exec.args[2:6])
+	})
+
+	t.Run("missing release", func(t *testing.T) {
+		u := &SentryUploader{executor: &mockExecutor{}, out: output.NewTest(io.Discard)}
+		assert.ErrorContains(t, u.Upload(context.Background(), artifact, Release{}), SentryReleaseEnvKey)
+	})
+
+	t.Run("missing source map", func(t *testing.T) {
+		u := &SentryUploader{executor: &mockExecutor{}, out: output.NewTest(io.Discard)}
+		noMap := artifact
+		noMap.SourcemapPath = ""
+		assert.ErrorContains(t, u.Upload(context.Background(), noMap, Release{AppVersion: "1.2.0", Label: "v7"}), "no source map")
+	})
+
+	t.Run("sentry-cli failure", func(t *testing.T) {
+		u := &SentryUploader{executor: &mockExecutor{err: errors.New("exit status 1")}, out: output.NewTest(io.Discard)}
+		assert.ErrorContains(t, u.Upload(context.Background(), artifact, Release{AppVersion: "1.2.0", Label: "v7"}), "sentry-cli failed")
+	})
+}
+
+func TestSentryUploaderCheck(t *testing.T) {
+	t.Setenv(SentryReleaseEnvKey, "")
+	dir, _ := newProject(t, "sentry-cli")
+	u := &SentryUploader{}
+
+	assert.NoError(t, u.Check(dir, true))
+	assert.ErrorContains(t, u.Check(dir, false), "needs a release")
+
+	t.Setenv(SentryReleaseEnvKey, "1.2.0")
+	assert.NoError(t, u.Check(dir, false))
+
+	t.Setenv("PATH", t.TempDir())
+	assert.ErrorContains(t, u.Check(t.TempDir(), true), "sentry-cli not found")
+}
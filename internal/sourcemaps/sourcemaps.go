@@ -0,0 +1,140 @@
+// Package sourcemaps uploads bundle source maps to crash reporting services,
+// so stack traces from an OTA release can be symbolicated.
+package sourcemaps
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/bitrise-io/bitrise-plugins-codepush-cli/internal/bundler"
+	"github.com/bitrise-io/bitrise-plugins-codepush-cli/internal/output"
+)
+
+// Supported providers.
+const (
+	ProviderSentry  = "sentry"
+	ProviderBugsnag = "bugsnag"
+	ProviderDatadog = "datadog"
+)
+
+// Providers lists the accepted --sourcemap-provider values.
+var Providers = []string{ProviderSentry, ProviderBugsnag, ProviderDatadog}
+
+// ValidateProvider checks that name is a supported provider.
+func ValidateProvider(name string) error {
+	for _, p := range Providers {
+		if name == p {
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown source map provider %q: valid values are %s", name, strings.Join(Providers, ", "))
+}
+
+// Options holds the provider-specific settings. Each provider reads only
+// its own fields.
+type Options struct {
+	// BugsnagAPIKey is the Bugsnag project API key.
+	BugsnagAPIKey string
+	// BugsnagCodeBundleID overrides the code bundle ID derived from the
+	// release.
+	BugsnagCodeBundleID string
+
+	// DatadogService is the RUM application's service name.
+	DatadogService string
+	// DatadogReleaseVersion overrides the version derived from the release.
+	DatadogReleaseVersion string
+	// DatadogBuildVersion is the native build number of the app.
+	DatadogBuildVersion string
+}
+
+// Artifact is a bundle and its source map.
+type Artifact struct {
+	// ProjectDir is the project root. Source paths in the map are made
+	// relative to it.
+	ProjectDir    string
+	Platform      string
+	BundlePath    string
+	SourcemapPath string
+}
+
+// Release identifies the OTA release the source map belongs to. Label is
+// empty when the bundle has not been pushed.
+type Release struct {
+	AppVersion string
+	Label      string
+}
+
+// Uploader uploads source maps to one provider.
+type Uploader interface {
+	// Check verifies the upload can succeed before bundling starts, so a
+	// missing tool or setting does not surface after a long build. When
+	// labelKnown is false, the release must be configured explicitly.
+	Check(projectDir string, labelKnown bool) error
+	// Upload uploads the artifact and associates it with the release.
+	Upload(ctx context.Context, a Artifact, r Release) error
+}
+
+// NewUploader returns the uploader for provider.
+func NewUploader(provider string, opts Options, executor bundler.CommandExecutor, out *output.Writer) (Uploader, error) {
+	switch provider {
+	case ProviderSentry:
+		return &SentryUploader{executor: executor, out: out}, nil
+	case ProviderBugsnag:
+		return &BugsnagUploader{opts: opts, executor: executor, out: out}, nil
+	case ProviderDatadog:
+		return &DatadogUploader{opts: opts, executor: executor, out: out}, nil
+	default:
+		return nil, ValidateProvider(provider)
+	}
+}
+
+// findTool returns the project's copy of an npm CLI, or the one on PATH.
+func findTool(projectDir, name, npmPackage string) (string, error) {
+	local := filepath.Join(projectDir, "node_modules", ".bin", name)
+	if _, err := os.Stat(local); err == nil {
+		return local, nil
+	}
+	if path, err := exec.LookPath(name); err == nil {
+		return path, nil
+	}
+	return "", fmt.Errorf("%s not found: add %s to the project or install %s on PATH", name, npmPackage, name)
+}
+
+// requireSourcemap fails when the bundle has no source map to upload.
+func requireSourcemap(a Artifact) error {
+	if a.SourcemapPath == "" {
+		return errors.New("no source map was generated: bundle with --sourcemap")
+	}
+	return nil
+}
+
+// runTool runs an upload tool behind a spinner, printing its output only
+// when it fails.
+func runTool(ctx context.Context, executor bundler.CommandExecutor, out *output.Writer, label, dir, name string, args ...string) error {
+	var buf bytes.Buffer
+	err := out.Indeterminate(label, func() error {
+		return executor.Run(ctx, dir, &buf, &buf, name, args...)
+	})
+	if err != nil {
+		if s := strings.TrimSpace(buf.String()); s != "" {
+			out.Info("%s", s)
+		}
+		return fmt.Errorf("%s failed: %w", filepath.Base(name), err)
+	}
+	return nil
+}
+
+// codePushVersion returns "<app version>+codepush:<label>", the release
+// identifier used for a CodePush update, or "" when either part is unknown.
+func codePushVersion(r Release) string {
+	if r.AppVersion == "" || r.Label == "" {
+		return ""
+	}
+	return r.AppVersion + "+codepush:" + r.Label
+}
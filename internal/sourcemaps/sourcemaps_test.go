@@ -0,0 +1,46 @@
+package sourcemaps
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/bitrise-io/bitrise-plugins-codepush-cli/internal/output"
+)
+
+type mockExecutor struct {
+	name string
+	args []string
+	err  error
+}
+
+func (m *mockExecutor) Run(_ context.Context, _ string, stdout io.Writer, _ io.Writer, name string, args ...string) error {
+	m.name, m.args = name, args
+	if m.err != nil {
+		_, _ = io.WriteString(stdout, "error: API request failed")
+	}
+	return m.err
+}
+
+// newProject creates a project directory with a local copy of tool.
+func newProject(t *testing.T, tool string) (string, string) {
+	t.Helper()
+	dir := t.TempDir()
+	cli := filepath.Join(dir, "node_modules", ".bin", tool)
+	require.NoError(t, os.MkdirAll(filepath.Dir(cli), 0o755))
+	require.NoError(t, os.WriteFile(cli, []byte("#!/bin/sh\n"), 0o755))
+	return dir, cli
+}
+
+func TestValidateProvider(t *testing.T) {
+	assert.NoError(t, ValidateProvider("sentry"))
+	assert.ErrorContains(t, ValidateProvider("rollbar"), `unknown source map provider "rollbar"`)
+
+	_, err := NewUploader("rollbar", Options{}, &mockExecutor{}, output.NewTest(io.Discard))
+	assert.Error(t, err)
+}
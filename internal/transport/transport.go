@@ -0,0 +1,120 @@
+// Package transport builds the HTTP client shared by every request the CLI
+// makes, so proxy and TLS settings apply to the API, storage uploads, token
+// validation, and health checks alike.
+package transport
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/bitrise-io/bitrise-plugins-codepush-cli/internal/redact"
+)
+
+// CABundleEnvKey is the environment variable naming a custom root CA file.
+const CABundleEnvKey = "CODEPUSH_CA_BUNDLE"
+
+// Options configures the transport of a client built by New.
+type Options struct {
+	// CACertFile is a PEM file with root certificates to trust in addition
+	// to the system pool, e.g. for a proxy that intercepts TLS.
+	CACertFile string
+	// InsecureSkipVerify disables TLS certificate verification.
+	InsecureSkipVerify bool
+	// Debug, when set, is called after every request with its method, URL,
+	// status, and duration, as alternating keys and values. The value of the
+	// Authorization header is never passed.
+	Debug func(msg string, args ...any)
+}
+
+// New returns an HTTP client whose transport is configured by opts.
+// Proxies are always taken from HTTPS_PROXY, HTTP_PROXY, and NO_PROXY.
+func New(opts Options) (*http.Client, error) {
+	rt, err := configuredTransport(opts)
+	if err != nil {
+		return nil, err
+	}
+	if opts.Debug != nil {
+		rt = &debugTransport{next: rt, log: opts.Debug}
+	}
+	return &http.Client{Transport: rt}, nil
+}
+
+func configuredTransport(opts Options) (http.RoundTripper, error) {
+	if opts.CACertFile == "" && !opts.InsecureSkipVerify {
+		return newTransport(nil), nil
+	}
+
+	tlsConfig := &tls.Config{InsecureSkipVerify: opts.InsecureSkipVerify} //nolint:gosec // opt-in for lab environments
+	if opts.CACertFile != "" {
+		pool, err := loadCertPool(opts.CACertFile)
+		if err != nil {
+			return nil, err
+		}
+		tlsConfig.RootCAs = pool
+	}
+	return newTransport(tlsConfig), nil
+}
+
+// defaultTransport is the transport of Client.
+var defaultTransport http.RoundTripper = newTransport(nil)
+
+// Client returns an HTTP client with the default options, for callers that
+// were not given a client built by New.
+func Client() *http.Client {
+	return &http.Client{Transport: defaultTransport}
+}
+
+func newTransport(tlsConfig *tls.Config) *http.Transport {
+	t := http.DefaultTransport.(*http.Transport).Clone()
+	t.Proxy = http.ProxyFromEnvironment
+	if tlsConfig != nil {
+		t.TLSClientConfig = tlsConfig
+	}
+	return t
+}
+
+// debugTransport logs every request it sends.
+type debugTransport struct {
+	next http.RoundTripper
+	log  func(msg string, args ...any)
+}
+
+func (t *debugTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	start := time.Now()
+	resp, err := t.next.RoundTrip(req)
+
+	args := []any{"method", req.Method, "url", req.URL.String()}
+	if req.Header.Get("Authorization") != "" {
+		args = append(args, "authorization", redact.Mask)
+	}
+	if err != nil {
+		args = append(args, "error", err)
+	} else {
+		args = append(args, "status", resp.StatusCode)
+	}
+	args = append(args, "duration", time.Since(start).Round(time.Millisecond))
+	t.log("http request", args...)
+	return resp, err
+}
+
+// loadCertPool returns the system root pool extended with the certificates
+// in path.
+func loadCertPool(path string) (*x509.CertPool, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading CA certificate: %w", err)
+	}
+
+	pool, err := x509.SystemCertPool()
+	if err != nil {
+		pool = x509.NewCertPool()
+	}
+	if !pool.AppendCertsFromPEM(data) {
+		return nil, fmt.Errorf("reading CA certificate: no PEM certificates found in %s", path)
+	}
+	return pool, nil
+}
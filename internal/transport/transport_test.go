@@ -0,0 +1,95 @@
+package transport
+
+import (
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNew(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	get := func(t *testing.T, opts Options) error {
+		t.Helper()
+		client, err := New(opts)
+		require.NoError(t, err)
+		resp, err := client.Get(server.URL)
+		if err == nil {
+			_ = resp.Body.Close()
+		}
+		return err
+	}
+
+	dir := t.TempDir()
+	caFile := filepath.Join(dir, "ca.pem")
+	require.NoError(t, os.WriteFile(caFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw}), 0o644))
+
+	t.Run("rejects unknown certificate authority by default", func(t *testing.T) {
+		assert.ErrorContains(t, get(t, Options{}), "certificate")
+	})
+
+	t.Run("trusts custom CA", func(t *testing.T) {
+		assert.NoError(t, get(t, Options{CACertFile: caFile}))
+	})
+
+	t.Run("skips verification when insecure", func(t *testing.T) {
+		assert.NoError(t, get(t, Options{InsecureSkipVerify: true}))
+	})
+
+	t.Run("missing CA file", func(t *testing.T) {
+		_, err := New(Options{CACertFile: filepath.Join(dir, "missing.pem")})
+		assert.ErrorContains(t, err, "reading CA certificate")
+	})
+
+	t.Run("CA file without certificates", func(t *testing.T) {
+		bad := filepath.Join(dir, "bad.pem")
+		require.NoError(t, os.WriteFile(bad, []byte("not a certificate"), 0o644))
+		_, err := New(Options{CACertFile: bad})
+		assert.ErrorContains(t, err, "no PEM certificates")
+	})
+}
+
+func TestNewDebug(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTeapot)
+	}))
+	defer server.Close()
+
+	var msgs []string
+	var fields []map[string]any
+	client, err := New(Options{Debug: func(msg string, args ...any) {
+		msgs = append(msgs, msg)
+		f := map[string]any{}
+		for i := 0; i+1 < len(args); i += 2 {
+			f[args[i].(string)] = args[i+1]
+		}
+		fields = append(fields, f)
+	}})
+	require.NoError(t, err)
+
+	req, err := http.NewRequest(http.MethodPost, server.URL+"/apps", nil)
+	require.NoError(t, err)
+	req.Header.Set("Authorization", "secret-token-value")
+	resp, err := client.Do(req)
+	require.NoError(t, err)
+	_ = resp.Body.Close()
+
+	require.Equal(t, []string{"http request"}, msgs)
+	assert.Equal(t, http.MethodPost, fields[0]["method"])
+	assert.Equal(t, server.URL+"/apps", fields[0]["url"])
+	assert.Equal(t, http.StatusTeapot, fields[0]["status"])
+	assert.Equal(t, "[REDACTED]", fields[0]["authorization"])
+	assert.Contains(t, fields[0], "duration")
+	for _, v := range fields[0] {
+		assert.NotEqual(t, "secret-token-value", v)
+	}
+}
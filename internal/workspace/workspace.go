@@ -0,0 +1,157 @@
+// Package workspace manages the temporary artifacts of a command run: zip
+// archives, delta packages, downloaded packages, and intermediate builds.
+// They are created in one Workspace directory per run, which is removed
+// when the run ends, so a failed or interrupted command leaves nothing
+// behind. Workspaces of runs that were killed before they could clean up
+// are removed by Collect.
+package workspace
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Prefix starts the name of every workspace directory. Collect only
+// removes directories with this prefix.
+const Prefix = "codepush-workspace-"
+
+// DefaultMaxAge is how old a workspace must be before Collect removes it.
+const DefaultMaxAge = 7 * 24 * time.Hour
+
+// Options configures a Workspace.
+type Options struct {
+	// Root is the directory the workspace is created in, the system temp
+	// directory when empty.
+	Root string
+	// Keep leaves the workspace and the artifacts in it in place when the
+	// run ends, for debugging.
+	Keep bool
+}
+
+// Workspace is the directory the temporary artifacts of a run are created
+// in. It is created on first use. A nil *Workspace creates artifacts
+// directly in the system temp directory and always removes them.
+type Workspace struct {
+	opts Options
+
+	mu  sync.Mutex
+	dir string // created on first use
+}
+
+// New returns a workspace configured by opts. Nothing is created on disk
+// until an artifact is.
+func New(opts Options) *Workspace {
+	return &Workspace{opts: opts}
+}
+
+// Dir returns the workspace directory, creating it on first use. It returns
+// an empty string, meaning the system temp directory, for a nil workspace.
+func (w *Workspace) Dir() (string, error) {
+	if w == nil {
+		return "", nil
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.dir == "" {
+		d, err := os.MkdirTemp(w.opts.Root, Prefix+"*")
+		if err != nil {
+			return "", fmt.Errorf("creating workspace: %w", err)
+		}
+		w.dir = d
+	}
+	return w.dir, nil
+}
+
+// CreateTemp creates a temporary file in the workspace, like os.CreateTemp.
+func (w *Workspace) CreateTemp(pattern string) (*os.File, error) {
+	d, err := w.Dir()
+	if err != nil {
+		return nil, err
+	}
+	return os.CreateTemp(d, pattern)
+}
+
+// MkdirTemp creates a temporary directory in the workspace, like
+// os.MkdirTemp.
+func (w *Workspace) MkdirTemp(pattern string) (string, error) {
+	d, err := w.Dir()
+	if err != nil {
+		return "", err
+	}
+	return os.MkdirTemp(d, pattern)
+}
+
+// Remove removes an artifact that is no longer needed, unless artifacts are
+// kept. Errors are ignored: whatever is left is removed with the workspace.
+func (w *Workspace) Remove(path string) {
+	if w == nil || !w.opts.Keep {
+		_ = os.RemoveAll(path)
+	}
+}
+
+// Cleanup ends the use of the workspace. It removes the workspace
+// directory, or with Options.Keep, leaves it in place and returns its path.
+// It returns an empty path when nothing was kept.
+func (w *Workspace) Cleanup() (string, error) {
+	if w == nil {
+		return "", nil
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	d := w.dir
+	w.dir = ""
+	if d == "" {
+		return "", nil
+	}
+	if w.opts.Keep {
+		return d, nil
+	}
+	if err := os.RemoveAll(d); err != nil {
+		return "", fmt.Errorf("removing workspace: %w", err)
+	}
+	return "", nil
+}
+
+// Collect removes workspace directories next to w, in Options.Root (the
+// system temp directory when empty), that were last modified more than
+// maxAge before now, left by runs that were killed, or kept with
+// Options.Keep. The directory of w itself is never removed. It returns the
+// removed directories.
+func (w *Workspace) Collect(maxAge time.Duration, now time.Time) ([]string, error) {
+	root := w.opts.Root
+	if root == "" {
+		root = os.TempDir()
+	}
+	entries, err := os.ReadDir(root)
+	if err != nil {
+		return nil, fmt.Errorf("reading %s: %w", root, err)
+	}
+
+	w.mu.Lock()
+	current := w.dir
+	w.mu.Unlock()
+
+	var removed []string
+	for _, entry := range entries {
+		if !entry.IsDir() || !strings.HasPrefix(entry.Name(), Prefix) {
+			continue
+		}
+		path := filepath.Join(root, entry.Name())
+		if path == current {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil || now.Sub(info.ModTime()) <= maxAge {
+			continue
+		}
+		if err := os.RemoveAll(path); err != nil {
+			return removed, fmt.Errorf("removing stale workspace: %w", err)
+		}
+		removed = append(removed, path)
+	}
+	return removed, nil
+}
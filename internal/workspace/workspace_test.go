@@ -0,0 +1,115 @@
+package workspace
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWorkspace(t *testing.T) {
+	t.Run("creates artifacts in the system temp directory without a workspace", func(t *testing.T) {
+		var w *Workspace
+		d, err := w.Dir()
+		require.NoError(t, err)
+		assert.Empty(t, d)
+
+		kept, err := w.Cleanup()
+		require.NoError(t, err)
+		assert.Empty(t, kept)
+	})
+
+	t.Run("removes the workspace on cleanup", func(t *testing.T) {
+		w := New(Options{Root: t.TempDir()})
+
+		f, err := w.CreateTemp("package-*.zip")
+		require.NoError(t, err)
+		require.NoError(t, f.Close())
+		sub, err := w.MkdirTemp("build-*")
+		require.NoError(t, err)
+
+		d, err := w.Dir()
+		require.NoError(t, err)
+		assert.Equal(t, d, filepath.Dir(f.Name()))
+		assert.Equal(t, d, filepath.Dir(sub))
+		assert.True(t, strings.HasPrefix(filepath.Base(d), Prefix))
+
+		kept, err := w.Cleanup()
+		require.NoError(t, err)
+		assert.Empty(t, kept)
+		assert.NoDirExists(t, d)
+	})
+
+	t.Run("keeps artifacts", func(t *testing.T) {
+		w := New(Options{Root: t.TempDir(), Keep: true})
+
+		f, err := w.CreateTemp("package-*.zip")
+		require.NoError(t, err)
+		require.NoError(t, f.Close())
+		w.Remove(f.Name())
+		assert.FileExists(t, f.Name())
+
+		kept, err := w.Cleanup()
+		require.NoError(t, err)
+		assert.Equal(t, filepath.Dir(f.Name()), kept)
+		assert.FileExists(t, f.Name())
+	})
+
+	t.Run("cleans up only its own directory", func(t *testing.T) {
+		root := t.TempDir()
+		first, second := New(Options{Root: root}), New(Options{Root: root})
+		firstDir, err := first.Dir()
+		require.NoError(t, err)
+		secondDir, err := second.Dir()
+		require.NoError(t, err)
+
+		_, err = first.Cleanup()
+		require.NoError(t, err)
+		assert.NoDirExists(t, firstDir)
+		assert.DirExists(t, secondDir)
+	})
+
+	t.Run("does not create a workspace that is never used", func(t *testing.T) {
+		root := t.TempDir()
+		w := New(Options{Root: root})
+
+		kept, err := w.Cleanup()
+		require.NoError(t, err)
+		assert.Empty(t, kept)
+		entries, err := os.ReadDir(root)
+		require.NoError(t, err)
+		assert.Empty(t, entries)
+	})
+}
+
+func TestCollect(t *testing.T) {
+	root := t.TempDir()
+	now := time.Now()
+
+	mkdir := func(name string, age time.Duration) string {
+		path := filepath.Join(root, name)
+		require.NoError(t, os.Mkdir(path, 0o755))
+		require.NoError(t, os.Chtimes(path, now.Add(-age), now.Add(-age)))
+		return path
+	}
+	stale := mkdir(Prefix+"stale", 8*24*time.Hour)
+	recent := mkdir(Prefix+"recent", time.Hour)
+	other := mkdir("other-tool-stale", 30*24*time.Hour)
+
+	w := New(Options{Root: root})
+	current, err := w.Dir()
+	require.NoError(t, err)
+	require.NoError(t, os.Chtimes(current, now.Add(-30*24*time.Hour), now.Add(-30*24*time.Hour)))
+
+	removed, err := w.Collect(DefaultMaxAge, now)
+	require.NoError(t, err)
+	assert.Equal(t, []string{stale}, removed)
+	assert.NoDirExists(t, stale)
+	assert.DirExists(t, recent)
+	assert.DirExists(t, other)
+	assert.DirExists(t, current, "the workspace of the run is never collected")
+}
@@ -0,0 +1,98 @@
+package zip
+
+import (
+	"archive/zip"
+	"compress/flate"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// defaultDeflateLevel is the level of the deflate compressor archive/zip
+// registers by default (flate.NewWriter(w, 5) in archive/zip's register.go),
+// not flate.DefaultCompression, which is level 6. Compressing at it keeps
+// packages byte-identical to the ones written through zip.Writer.Create
+// before entries were compressed on a worker pool.
+const defaultDeflateLevel = 5
+
+// Compression selects how package entries are compressed. The zero value is
+// deflate at the default level.
+type Compression struct {
+	// Store writes entries without compression.
+	Store bool
+	// Level is the deflate level, from 1 (fastest) to 9 (smallest). Zero
+	// selects the default level.
+	Level int
+}
+
+// ParseCompression parses a --compression value: "deflate", "deflate:<level>"
+// with a level from 1 to 9, or "store". An empty value is the default.
+func ParseCompression(value string) (Compression, error) {
+	method, level, hasLevel := strings.Cut(strings.ToLower(value), ":")
+	switch method {
+	case "", "deflate":
+	case "store":
+		if hasLevel {
+			return Compression{}, fmt.Errorf("compression %q takes no level", value)
+		}
+		return Compression{Store: true}, nil
+	case "zstd", "brotli":
+		return Compression{}, fmt.Errorf("compression %q is not supported: the CodePush SDK only installs zip packages, use deflate:9 for the smallest package", value)
+	default:
+		return Compression{}, fmt.Errorf("unknown compression %q: use deflate, deflate:<1-9>, or store", value)
+	}
+
+	if !hasLevel {
+		return Compression{}, nil
+	}
+	n, err := strconv.Atoi(level)
+	if err != nil || n < 1 || n > 9 {
+		return Compression{}, fmt.Errorf("deflate level must be between 1 and 9, got %q", level)
+	}
+	return Compression{Level: n}, nil
+}
+
+// String returns the --compression value that selects c.
+func (c Compression) String() string {
+	switch {
+	case c.Store:
+		return "store"
+	case c.Level != 0:
+		return "deflate:" + strconv.Itoa(c.Level)
+	default:
+		return "deflate"
+	}
+}
+
+// deflateLevel returns the deflate level of c.
+func (c Compression) deflateLevel() int {
+	if c.Level == 0 {
+		return defaultDeflateLevel
+	}
+	return c.Level
+}
+
+// NewWriter returns a zip writer to w that compresses entries created with
+// Create according to c.
+func (c Compression) NewWriter(w io.Writer) *zip.Writer {
+	zw := zip.NewWriter(w)
+	if !c.Store && c.Level != 0 {
+		zw.RegisterCompressor(zip.Deflate, func(out io.Writer) (io.WriteCloser, error) {
+			return flate.NewWriter(out, c.Level)
+		})
+	}
+	return zw
+}
+
+// Create adds a file entry to a writer from NewWriter, with the fixed
+// modification time and permissions of reproducible archives.
+func (c Compression) Create(zw *zip.Writer, name string) (io.Writer, error) {
+	method := zip.Deflate
+	if c.Store {
+		method = zip.Store
+	}
+	header := &zip.FileHeader{Name: name, Method: method}
+	normalizeHeader(header, false)
+	return zw.CreateHeader(header)
+}
@@ -0,0 +1,86 @@
+package zip
+
+import (
+	"archive/zip"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseCompression(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    Compression
+		wantErr string
+	}{
+		{value: "", want: Compression{}},
+		{value: "deflate", want: Compression{}},
+		{value: "deflate:9", want: Compression{Level: 9}},
+		{value: "DEFLATE:1", want: Compression{Level: 1}},
+		{value: "store", want: Compression{Store: true}},
+		{value: "deflate:0", wantErr: "between 1 and 9"},
+		{value: "deflate:max", wantErr: "between 1 and 9"},
+		{value: "store:1", wantErr: "takes no level"},
+		{value: "zstd", wantErr: "only installs zip packages"},
+		{value: "brotli:11", wantErr: "only installs zip packages"},
+		{value: "lzma", wantErr: "unknown compression"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			got, err := ParseCompression(tt.value)
+			if tt.wantErr != "" {
+				require.Error(t, err)
+				assert.ErrorContains(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestCompressionString(t *testing.T) {
+	assert.Equal(t, "deflate", Compression{}.String())
+	assert.Equal(t, "deflate:9", Compression{Level: 9}.String())
+	assert.Equal(t, "store", Compression{Store: true}.String())
+}
+
+func TestPackage(t *testing.T) {
+	srcDir := filepath.Join(t.TempDir(), "bundle")
+	require.NoError(t, os.Mkdir(srcDir, 0o755))
+	content := strings.Repeat("var codepush = require('react-native-code-push');\n", 200)
+	writeFile(t, filepath.Join(srcDir, "main.jsbundle"), content)
+
+	pack := func(c Compression) *Archive {
+		t.Helper()
+		archive, err := Package(nil, srcDir, c)
+		require.NoError(t, err)
+		info, err := os.Stat(archive.Path)
+		require.NoError(t, err)
+		assert.Equal(t, info.Size(), archive.Size)
+		assert.Equal(t, int64(len(content)), archive.ContentSize)
+		return archive
+	}
+
+	stored := pack(Compression{Store: true})
+	r, err := zip.OpenReader(stored.Path)
+	require.NoError(t, err)
+	require.Len(t, r.File, 1)
+	assert.Equal(t, zip.Store, r.File[0].Method)
+	rc, err := r.File[0].Open()
+	require.NoError(t, err)
+	data, err := io.ReadAll(rc)
+	require.NoError(t, err)
+	_ = rc.Close()
+	_ = r.Close()
+	assert.Equal(t, content, string(data))
+
+	best := pack(Compression{Level: 9})
+	assert.Less(t, best.Size, stored.Size)
+}
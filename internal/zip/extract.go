@@ -0,0 +1,62 @@
+package zip
+
+import (
+	"archive/zip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Extract unpacks the zip archive at zipPath into destDir, which is created
+// if needed. Entries that would be written outside destDir are rejected.
+func Extract(zipPath, destDir string) error {
+	zr, err := zip.OpenReader(zipPath)
+	if err != nil {
+		return fmt.Errorf("opening archive: %w", err)
+	}
+	defer func() { _ = zr.Close() }()
+
+	absDir, err := filepath.Abs(destDir)
+	if err != nil {
+		return fmt.Errorf("resolving destination: %w", err)
+	}
+	for _, f := range zr.File {
+		target := filepath.Join(absDir, filepath.FromSlash(f.Name))
+		if target != absDir && !strings.HasPrefix(target, absDir+string(filepath.Separator)) {
+			return fmt.Errorf("archive entry %q is outside the destination", f.Name)
+		}
+		if f.FileInfo().IsDir() {
+			if err := os.MkdirAll(target, 0o755); err != nil {
+				return fmt.Errorf("creating %s: %w", f.Name, err)
+			}
+			continue
+		}
+		if err := extractFile(f, target); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func extractFile(f *zip.File, target string) error {
+	if err := os.MkdirAll(filepath.Dir(target), 0o755); err != nil {
+		return fmt.Errorf("creating directory for %s: %w", f.Name, err)
+	}
+	rc, err := f.Open()
+	if err != nil {
+		return fmt.Errorf("reading %s: %w", f.Name, err)
+	}
+	defer func() { _ = rc.Close() }()
+
+	w, err := os.Create(target)
+	if err != nil {
+		return fmt.Errorf("creating %s: %w", f.Name, err)
+	}
+	if _, err := io.Copy(w, rc); err != nil {
+		_ = w.Close()
+		return fmt.Errorf("extracting %s: %w", f.Name, err)
+	}
+	return w.Close()
+}
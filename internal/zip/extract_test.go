@@ -0,0 +1,59 @@
+package zip
+
+import (
+	"archive/zip"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeZip(t *testing.T, path string, files map[string]string) {
+	t.Helper()
+	f, err := os.Create(path)
+	require.NoError(t, err)
+	zw := zip.NewWriter(f)
+	for name, content := range files {
+		w, err := zw.Create(name)
+		require.NoError(t, err)
+		_, err = w.Write([]byte(content))
+		require.NoError(t, err)
+	}
+	require.NoError(t, zw.Close())
+	require.NoError(t, f.Close())
+}
+
+func TestExtract(t *testing.T) {
+	t.Run("round trips a packaged directory", func(t *testing.T) {
+		dir := t.TempDir()
+		srcDir := filepath.Join(dir, "bundle")
+		require.NoError(t, os.MkdirAll(filepath.Join(srcDir, "assets"), 0o755))
+		writeFile(t, filepath.Join(srcDir, "main.jsbundle"), "bundle content")
+		writeFile(t, filepath.Join(srcDir, "assets", "logo.png"), "image data")
+		zipPath, err := Directory(nil, srcDir)
+		require.NoError(t, err)
+
+		destDir := filepath.Join(dir, "out")
+		require.NoError(t, Extract(zipPath, destDir))
+
+		data, err := os.ReadFile(filepath.Join(destDir, "main.jsbundle"))
+		require.NoError(t, err)
+		assert.Equal(t, "bundle content", string(data))
+		data, err = os.ReadFile(filepath.Join(destDir, "assets", "logo.png"))
+		require.NoError(t, err)
+		assert.Equal(t, "image data", string(data))
+	})
+
+	t.Run("rejects entries outside the destination", func(t *testing.T) {
+		dir := t.TempDir()
+		zipPath := filepath.Join(dir, "evil.zip")
+		writeZip(t, zipPath, map[string]string{"../escape.txt": "x"})
+
+		err := Extract(zipPath, filepath.Join(dir, "out"))
+		require.Error(t, err)
+		assert.ErrorContains(t, err, "outside the destination")
+		assert.NoFileExists(t, filepath.Join(dir, "escape.txt"))
+	})
+}
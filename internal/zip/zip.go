@@ -3,14 +3,154 @@ package zip
 
 import (
 	"archive/zip"
+	"bytes"
+	"compress/flate"
 	"fmt"
+	"hash/crc32"
 	"io"
+	"io/fs"
 	"os"
+	"path"
 	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+
+	"github.com/bitrise-io/bitrise-plugins-codepush-cli/internal/workspace"
 )
 
-func addFileToZip(w *zip.Writer, baseDir string) filepath.WalkFunc {
-	return func(path string, info os.FileInfo, err error) error {
+// dosEpoch is 1980-01-01 00:00, the earliest MS-DOS date, in MS-DOS date
+// format. Every entry of an archive gets it as its modification time.
+const dosEpoch = 1<<5 | 1
+
+// Archive is a zip archive created from a directory.
+type Archive struct {
+	Path string
+	// Size is the size of the archive file.
+	Size int64
+	// ContentSize is the total size of the archived files, uncompressed.
+	ContentSize int64
+}
+
+// entry is a file or directory to archive.
+type entry struct {
+	name string // slash-separated path inside the archive
+	path string
+	dir  bool
+}
+
+// compressedEntry is a file read and compressed by a worker, ready to be
+// written to the archive.
+type compressedEntry struct {
+	header *zip.FileHeader
+	data   []byte
+	err    error
+}
+
+// Directory creates a zip archive from the contents of srcDir.
+// The zip file is created in ws, named after srcDir with a unique suffix
+// and a .zip extension, so concurrent calls for the same directory do not
+// collide. Returns the path to the created zip file.
+func Directory(ws *workspace.Workspace, srcDir string) (string, error) {
+	archive, err := Package(ws, srcDir, Compression{})
+	if err != nil {
+		return "", err
+	}
+	return archive.Path, nil
+}
+
+// Package creates a zip archive from the contents of srcDir like Directory,
+// compressing entries according to c. Files are read and compressed
+// concurrently, one worker per CPU.
+//
+// Archives are reproducible: entries are sorted by path, get a fixed
+// modification time and permissions, and OS metadata files (see IsMetadata)
+// are left out, so the same files always yield a byte-identical archive.
+func Package(ws *workspace.Workspace, srcDir string, c Compression) (*Archive, error) {
+	return packer{ws: ws, compression: c, workers: runtime.GOMAXPROCS(0)}.pack(srcDir)
+}
+
+// packer creates archives in ws with the given number of compression
+// workers.
+type packer struct {
+	ws          *workspace.Workspace
+	compression Compression
+	workers     int
+}
+
+func (p packer) pack(srcDir string) (*Archive, error) {
+	absDir, err := filepath.Abs(srcDir)
+	if err != nil {
+		return nil, fmt.Errorf("resolving directory path: %w", err)
+	}
+
+	info, err := os.Stat(absDir)
+	if err != nil {
+		return nil, fmt.Errorf("source directory does not exist: %w", err)
+	}
+	if !info.IsDir() {
+		return nil, fmt.Errorf("source path is not a directory: %s", absDir)
+	}
+
+	entries, err := listEntries(absDir)
+	if err != nil {
+		return nil, fmt.Errorf("adding files to zip: %w", err)
+	}
+
+	f, err := p.ws.CreateTemp(filepath.Base(absDir) + "-*.zip")
+	if err != nil {
+		return nil, fmt.Errorf("creating zip file: %w", err)
+	}
+	archive := &Archive{Path: f.Name()}
+	defer func() { _ = f.Close() }()
+	ok := false
+	defer func() {
+		if !ok {
+			p.ws.Remove(archive.Path)
+		}
+	}()
+
+	w := zip.NewWriter(f)
+	archive.ContentSize, err = writeEntries(w, entries, p.compression, max(p.workers, 1))
+	if err != nil {
+		return nil, fmt.Errorf("adding files to zip: %w", err)
+	}
+	if err := w.Close(); err != nil {
+		return nil, fmt.Errorf("writing zip file: %w", err)
+	}
+
+	size, err := f.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return nil, fmt.Errorf("reading zip file size: %w", err)
+	}
+	archive.Size = size
+	ok = true
+	return archive, nil
+}
+
+// IsMetadata reports whether a slash-separated path in a bundle is OS
+// metadata rather than content: a .DS_Store file or anything under a
+// __MACOSX directory. The SDK ignores them when hashing a package.
+func IsMetadata(name string) bool {
+	return path.Base(name) == ".DS_Store" || name == "__MACOSX" || strings.HasPrefix(name, "__MACOSX/")
+}
+
+// normalizeHeader gives h the fixed modification time and permissions of
+// reproducible archives.
+func normalizeHeader(h *zip.FileHeader, dir bool) {
+	h.ModifiedDate, h.ModifiedTime = dosEpoch, 0
+	if dir {
+		h.SetMode(fs.ModeDir | 0o755)
+	} else {
+		h.SetMode(0o644)
+	}
+}
+
+// listEntries returns the files and directories under baseDir, sorted by
+// path, leaving out OS metadata.
+func listEntries(baseDir string) ([]entry, error) {
+	var entries []entry
+	err := filepath.WalkDir(baseDir, func(path string, d fs.DirEntry, err error) error {
 		if err != nil {
 			return err
 		}
@@ -19,66 +159,132 @@ func addFileToZip(w *zip.Writer, baseDir string) filepath.WalkFunc {
 		if err != nil {
 			return fmt.Errorf("computing relative path: %w", err)
 		}
-
 		if relPath == "." {
 			return nil
 		}
 
 		// Zip spec requires forward slashes
-		zipEntryName := filepath.ToSlash(relPath)
+		name := filepath.ToSlash(relPath)
+		if IsMetadata(name) {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		entries = append(entries, entry{name: name, path: path, dir: d.IsDir()})
+		return nil
+	})
+	sort.Slice(entries, func(i, j int) bool { return entries[i].name < entries[j].name })
+	return entries, err
+}
 
-		if info.IsDir() {
-			_, err := w.Create(zipEntryName + "/")
-			return err
+// writeEntries compresses entries on a pool of workers and writes them to w
+// in order. At most 2*workers compressed files are held in memory at once.
+// Returns the total uncompressed size of the files.
+func writeEntries(w *zip.Writer, entries []entry, c Compression, workers int) (int64, error) {
+	results := make([]chan compressedEntry, len(entries))
+	for i := range results {
+		results[i] = make(chan compressedEntry, 1)
+	}
+
+	jobs := make(chan int)
+	window := make(chan struct{}, 2*workers)
+	stop := make(chan struct{})
+	defer close(stop)
+
+	go func() {
+		defer close(jobs)
+		for i := range entries {
+			select {
+			case window <- struct{}{}:
+			case <-stop:
+				return
+			}
+			select {
+			case jobs <- i:
+			case <-stop:
+				return
+			}
 		}
+	}()
 
-		writer, err := w.Create(zipEntryName)
-		if err != nil {
-			return fmt.Errorf("creating zip entry %s: %w", zipEntryName, err)
+	for range min(workers, len(entries)) {
+		go func() {
+			var fw *flate.Writer
+			for i := range jobs {
+				results[i] <- compressEntry(entries[i], c, &fw)
+			}
+		}()
+	}
+
+	var contentSize int64
+	for i, e := range entries {
+		res := <-results[i]
+		<-window
+		if res.err != nil {
+			return 0, res.err
 		}
 
-		file, err := os.Open(path)
-		if err != nil {
-			return fmt.Errorf("opening file %s: %w", path, err)
+		if e.dir {
+			header := &zip.FileHeader{Name: e.name + "/"}
+			normalizeHeader(header, true)
+			if _, err := w.CreateHeader(header); err != nil {
+				return 0, err
+			}
+			continue
 		}
-		defer func() { _ = file.Close() }()
 
-		_, err = io.Copy(writer, file)
-		return err
+		dst, err := w.CreateRaw(res.header)
+		if err != nil {
+			return 0, fmt.Errorf("creating zip entry %s: %w", e.name, err)
+		}
+		if _, err := dst.Write(res.data); err != nil {
+			return 0, fmt.Errorf("writing zip entry %s: %w", e.name, err)
+		}
+		contentSize += int64(res.header.UncompressedSize64)
 	}
+	return contentSize, nil
 }
 
-// Directory creates a zip archive from the contents of srcDir.
-// The zip file is created as a sibling to srcDir with a .zip extension.
-// Returns the path to the created zip file.
-func Directory(srcDir string) (string, error) {
-	absDir, err := filepath.Abs(srcDir)
-	if err != nil {
-		return "", fmt.Errorf("resolving directory path: %w", err)
+// compressEntry reads a file and compresses it according to c. fw is the
+// calling worker's deflate writer, created on first use and reused.
+func compressEntry(e entry, c Compression, fw **flate.Writer) compressedEntry {
+	if e.dir {
+		return compressedEntry{}
 	}
 
-	info, err := os.Stat(absDir)
+	raw, err := os.ReadFile(e.path)
 	if err != nil {
-		return "", fmt.Errorf("source directory does not exist: %w", err)
-	}
-	if !info.IsDir() {
-		return "", fmt.Errorf("source path is not a directory: %s", absDir)
+		return compressedEntry{err: fmt.Errorf("opening file %s: %w", e.path, err)}
 	}
 
-	zipPath := absDir + ".zip"
-	f, err := os.Create(zipPath)
-	if err != nil {
-		return "", fmt.Errorf("creating zip file: %w", err)
+	header := &zip.FileHeader{
+		Name:               e.name,
+		Method:             zip.Store,
+		CRC32:              crc32.ChecksumIEEE(raw),
+		UncompressedSize64: uint64(len(raw)),
 	}
-	defer func() { _ = f.Close() }()
-
-	w := zip.NewWriter(f)
-	defer func() { _ = w.Close() }()
-
-	err = filepath.Walk(absDir, addFileToZip(w, absDir))
-	if err != nil {
-		return "", fmt.Errorf("adding files to zip: %w", err)
+	normalizeHeader(header, false)
+	data := raw
+	if !c.Store {
+		var buf bytes.Buffer
+		if *fw == nil {
+			*fw, err = flate.NewWriter(&buf, c.deflateLevel())
+			if err != nil {
+				return compressedEntry{err: fmt.Errorf("compressing %s: %w", e.name, err)}
+			}
+		} else {
+			(*fw).Reset(&buf)
+		}
+		if _, err := (*fw).Write(raw); err != nil {
+			return compressedEntry{err: fmt.Errorf("compressing %s: %w", e.name, err)}
+		}
+		if err := (*fw).Close(); err != nil {
+			return compressedEntry{err: fmt.Errorf("compressing %s: %w", e.name, err)}
+		}
+		header.Method = zip.Deflate
+		data = buf.Bytes()
 	}
-
-	return zipPath, nil
+	header.CompressedSize64 = uint64(len(data))
+	return compressedEntry{header: header, data: data}
 }
@@ -2,10 +2,16 @@ package zip
 
 import (
 	"archive/zip"
+	"bytes"
+	"fmt"
+	"math/rand/v2"
 	"os"
 	"path/filepath"
+	"slices"
 	"sort"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -20,11 +26,12 @@ func TestDirectory(t *testing.T) {
 		writeFile(t, filepath.Join(srcDir, "main.jsbundle"), "bundle content")
 		writeFile(t, filepath.Join(srcDir, "main.jsbundle.map"), "sourcemap content")
 
-		zipPath, err := Directory(srcDir)
+		zipPath, err := Directory(nil, srcDir)
 		require.NoError(t, err)
 		defer os.Remove(zipPath)
 
-		assert.Equal(t, srcDir+".zip", zipPath)
+		assert.Equal(t, filepath.Clean(os.TempDir()), filepath.Dir(zipPath))
+		assert.Regexp(t, `^bundle-\d+\.zip$`, filepath.Base(zipPath))
 
 		entries := readZipEntries(t, zipPath)
 		require.Len(t, entries, 2)
@@ -42,7 +49,7 @@ func TestDirectory(t *testing.T) {
 		writeFile(t, filepath.Join(srcDir, "index.js"), "code")
 		writeFile(t, filepath.Join(srcDir, "assets", "images", "logo.png"), "image data")
 
-		zipPath, err := Directory(srcDir)
+		zipPath, err := Directory(nil, srcDir)
 		require.NoError(t, err)
 		defer os.Remove(zipPath)
 
@@ -61,7 +68,7 @@ func TestDirectory(t *testing.T) {
 		content := "console.log('hello world')"
 		writeFile(t, filepath.Join(srcDir, "app.js"), content)
 
-		zipPath, err := Directory(srcDir)
+		zipPath, err := Directory(nil, srcDir)
 		require.NoError(t, err)
 		defer os.Remove(zipPath)
 
@@ -85,7 +92,7 @@ func TestDirectory(t *testing.T) {
 	})
 
 	t.Run("nonexistent directory", func(t *testing.T) {
-		_, err := Directory("/nonexistent/path")
+		_, err := Directory(nil, "/nonexistent/path")
 		require.Error(t, err)
 	})
 
@@ -94,7 +101,7 @@ func TestDirectory(t *testing.T) {
 		filePath := filepath.Join(dir, "notadir")
 		writeFile(t, filePath, "content")
 
-		_, err := Directory(filePath)
+		_, err := Directory(nil, filePath)
 		require.Error(t, err)
 	})
 
@@ -103,7 +110,7 @@ func TestDirectory(t *testing.T) {
 		srcDir := filepath.Join(dir, "empty")
 		os.Mkdir(srcDir, 0o755)
 
-		zipPath, err := Directory(srcDir)
+		zipPath, err := Directory(nil, srcDir)
 		require.NoError(t, err)
 		defer os.Remove(zipPath)
 
@@ -112,6 +119,82 @@ func TestDirectory(t *testing.T) {
 	})
 }
 
+func TestPackageWorkers(t *testing.T) {
+	srcDir := filepath.Join(t.TempDir(), "bundle")
+	writeBundleTree(t, srcDir, 50)
+
+	single, err := packer{workers: 1}.pack(srcDir)
+	require.NoError(t, err)
+	want, err := os.ReadFile(single.Path)
+	require.NoError(t, err)
+
+	for _, workers := range []int{2, 8, 64} {
+		archive, err := packer{workers: workers}.pack(srcDir)
+		require.NoError(t, err)
+		got, err := os.ReadFile(archive.Path)
+		require.NoError(t, err)
+		assert.Equal(t, want, got, "archive with %d workers differs", workers)
+	}
+
+	entries := readZipEntries(t, single.Path)
+	assert.True(t, sort.StringsAreSorted(entries), "entries are not sorted")
+}
+
+func TestPackageUnreadableFile(t *testing.T) {
+	if os.Getuid() == 0 {
+		t.Skip("file permissions are not enforced for root")
+	}
+	srcDir := filepath.Join(t.TempDir(), "bundle")
+	writeBundleTree(t, srcDir, 20)
+	require.NoError(t, os.Chmod(filepath.Join(srcDir, "assets", "img_0005.png"), 0o000))
+
+	_, err := packer{workers: 4}.pack(srcDir)
+	require.Error(t, err)
+	assert.ErrorContains(t, err, "img_0005.png")
+}
+
+// BenchmarkPackage archives a bundle with many asset files, as produced by
+// large React Native projects, with an increasing number of workers.
+func BenchmarkPackage(b *testing.B) {
+	srcDir := filepath.Join(b.TempDir(), "bundle")
+	writeBundleTree(b, srcDir, 2000)
+
+	for _, workers := range []int{1, 2, 4, 8} {
+		b.Run(fmt.Sprintf("workers=%d", workers), func(b *testing.B) {
+			for b.Loop() {
+				archive, err := packer{workers: workers}.pack(srcDir)
+				if err != nil {
+					b.Fatal(err)
+				}
+				_ = os.Remove(archive.Path)
+			}
+		})
+	}
+}
+
+// writeBundleTree writes a bundle directory with a JS bundle and n assets of
+// mixed compressibility.
+func writeBundleTree(tb testing.TB, dir string, n int) {
+	tb.Helper()
+	require.NoError(tb, os.MkdirAll(filepath.Join(dir, "assets"), 0o755))
+	bundle := strings.Repeat("__d(function(g,r,i,a,m,e,d){'use strict';},0,[]);\n", 20000)
+	require.NoError(tb, os.WriteFile(filepath.Join(dir, "main.jsbundle"), []byte(bundle), 0o644))
+
+	rng := rand.New(rand.NewPCG(1, 2))
+	for i := range n {
+		data := make([]byte, 8*1024)
+		for j := range data {
+			if j%4 == 0 {
+				data[j] = byte(rng.IntN(256))
+			} else {
+				data[j] = byte(j)
+			}
+		}
+		name := filepath.Join(dir, "assets", fmt.Sprintf("img_%04d.png", i))
+		require.NoError(tb, os.WriteFile(name, data, 0o644))
+	}
+}
+
 func writeFile(t *testing.T, path, content string) {
 	t.Helper()
 	require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
@@ -129,3 +212,107 @@ func readZipEntries(t *testing.T, zipPath string) []string {
 	}
 	return entries
 }
+
+func TestPackageIsReproducible(t *testing.T) {
+	files := map[string]string{
+		"main.jsbundle":            "bundle",
+		"assets/a.png":             "png",
+		"assets.json":              "{}",
+		"assets/img/b.png":         "png2",
+		".DS_Store":                "finder",
+		"assets/.DS_Store":         "finder",
+		"__MACOSX/._main.jsbundle": "resource fork",
+	}
+	build := func(order []string, mtime time.Time) []byte {
+		srcDir := filepath.Join(t.TempDir(), "CodePush")
+		for _, name := range order {
+			path := filepath.Join(srcDir, filepath.FromSlash(name))
+			require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+			writeFile(t, path, files[name])
+			require.NoError(t, os.Chtimes(path, mtime, mtime))
+		}
+		archive, err := Package(nil, srcDir, Compression{})
+		require.NoError(t, err)
+		t.Cleanup(func() { _ = os.Remove(archive.Path) })
+		data, err := os.ReadFile(archive.Path)
+		require.NoError(t, err)
+		return data
+	}
+
+	names := make([]string, 0, len(files))
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	reversed := slices.Clone(names)
+	slices.Reverse(reversed)
+
+	first := build(names, time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC))
+	second := build(reversed, time.Now())
+	assert.Equal(t, first, second)
+
+	r, err := zip.NewReader(bytes.NewReader(first), int64(len(first)))
+	require.NoError(t, err)
+	var entries []string
+	for _, f := range r.File {
+		entries = append(entries, f.Name)
+		assert.Equal(t, 1980, f.Modified.Year(), f.Name)
+	}
+	assert.Equal(t, []string{"assets/", "assets.json", "assets/a.png", "assets/img/", "assets/img/b.png", "main.jsbundle"}, entries)
+}
+
+// TestPackageDefaultMatchesArchiveZip checks that the worker pool compresses
+// at the level of archive/zip's own deflate compressor, which packages were
+// written with before it, so the default packages keep their exact bytes.
+func TestPackageDefaultMatchesArchiveZip(t *testing.T) {
+	srcDir := filepath.Join(t.TempDir(), "CodePush")
+	rng := rand.New(rand.NewPCG(1, 2))
+	var text strings.Builder
+	for range 20000 {
+		fmt.Fprintf(&text, "var v%d = %d;\n", rng.IntN(500), rng.IntN(1000))
+	}
+	require.NoError(t, os.MkdirAll(filepath.Join(srcDir, "assets"), 0o755))
+	writeFile(t, filepath.Join(srcDir, "main.jsbundle"), text.String())
+	writeFile(t, filepath.Join(srcDir, "assets", "data.json"), strings.Repeat(`{"key":"value"},`, 5000))
+
+	archive, err := Package(nil, srcDir, Compression{})
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = os.Remove(archive.Path) })
+	got, err := zip.OpenReader(archive.Path)
+	require.NoError(t, err)
+	defer got.Close()
+
+	// The reference archive is written the way packages were before the
+	// worker pool: through zip.Writer.Create with its default compressor.
+	var ref bytes.Buffer
+	zw := zip.NewWriter(&ref)
+	for _, name := range []string{"assets/data.json", "main.jsbundle"} {
+		data, err := os.ReadFile(filepath.Join(srcDir, filepath.FromSlash(name)))
+		require.NoError(t, err)
+		w, err := zw.Create(name)
+		require.NoError(t, err)
+		_, err = w.Write(data)
+		require.NoError(t, err)
+	}
+	require.NoError(t, zw.Close())
+	want, err := zip.NewReader(bytes.NewReader(ref.Bytes()), int64(ref.Len()))
+	require.NoError(t, err)
+
+	compressed := func(files []*zip.File, name string) []byte {
+		for _, f := range files {
+			if f.Name == name {
+				r, err := f.OpenRaw()
+				require.NoError(t, err)
+				var b bytes.Buffer
+				_, err = b.ReadFrom(r)
+				require.NoError(t, err)
+				return b.Bytes()
+			}
+		}
+		t.Fatalf("no entry %s", name)
+		return nil
+	}
+	for _, name := range []string{"assets/data.json", "main.jsbundle"} {
+		assert.Equal(t, compressed(want.File, name), compressed(got.File, name), name)
+	}
+}
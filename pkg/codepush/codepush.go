@@ -0,0 +1,173 @@
+// Package codepush is the Go API for Bitrise CodePush releases. It pushes,
+// promotes, and rolls back updates with the same implementation as the
+// codepush CLI, for release tools that embed these operations instead of
+// running the binary.
+//
+// A Releaser runs the operations for one app:
+//
+//	r, err := codepush.New(codepush.Config{AppID: appID, Token: token})
+//	if err != nil {
+//		return err
+//	}
+//	result, err := r.Push(ctx, codepush.PushOptions{
+//		Deployment: "Staging",
+//		BundlePath: "build/CodePush",
+//		AppVersion: "1.4.0",
+//	})
+//
+// The exported API of this package is kept stable across minor releases of
+// the module. Packages under internal/ may change in any release.
+package codepush
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	core "github.com/bitrise-io/bitrise-plugins-codepush-cli/internal/codepush"
+	"github.com/bitrise-io/bitrise-plugins-codepush-cli/internal/output"
+	"github.com/bitrise-io/bitrise-plugins-codepush-cli/internal/releaser"
+	"github.com/bitrise-io/bitrise-plugins-codepush-cli/internal/workspace"
+)
+
+// DefaultAPIURL is the CodePush API of Bitrise, used when Config.APIURL is
+// empty.
+const DefaultAPIURL = "https://api.bitrise.io/release-management/v1"
+
+// defaultUserAgent identifies library requests in the API's
+// X-Bitrise-User-Agent header, as codepush-cli/<version>.
+const defaultUserAgent = "library"
+
+// Config configures a Releaser.
+type Config struct {
+	// AppID is the release management app UUID. Required.
+	AppID string
+	// Token is the Bitrise API token. Required.
+	Token string
+	// APIURL is the CodePush API base URL, for staging and self-hosted
+	// instances. Empty uses DefaultAPIURL.
+	APIURL string
+	// Version is reported to the API in the user agent. Empty reports
+	// "library".
+	Version string
+	// HTTPClient sends the API requests. Nil uses a client that honors the
+	// HTTPS_PROXY environment variables; set it to substitute a fake
+	// transport in tests or to wrap requests. Either way, failed requests
+	// are retried, and after an HTTP 429 response later requests wait for
+	// the server's Retry-After.
+	HTTPClient *http.Client
+	// Logger receives progress messages, such as "Uploading update".
+	// Nil discards them.
+	Logger *slog.Logger
+}
+
+// Client is the set of operations of a Releaser, for release tools that
+// substitute a stub for it in their tests.
+type Client interface {
+	Push(ctx context.Context, opts PushOptions) (*PushResult, error)
+	Promote(ctx context.Context, opts PromoteOptions) (*PromoteResult, error)
+	Rollback(ctx context.Context, opts RollbackOptions) (*RollbackResult, error)
+	Deployments(ctx context.Context) ([]Deployment, error)
+	Releases(ctx context.Context, deployment string) ([]Release, error)
+	Close() error
+}
+
+var _ Client = (*Releaser)(nil)
+
+// Releaser runs release operations for one app. It is safe for concurrent
+// use.
+type Releaser struct {
+	appID  string
+	client core.Client
+	out    *output.Writer
+	// ws holds the temporary files of the operations of this Releaser.
+	ws *workspace.Workspace
+	// releaser runs push, promote, and rollback as the CLI does.
+	releaser *releaser.Releaser
+}
+
+// New returns a Releaser for cfg. Call Close when done with it.
+func New(cfg Config) (*Releaser, error) {
+	if cfg.AppID == "" {
+		return nil, errors.New("app ID is required")
+	}
+	if cfg.Token == "" {
+		return nil, errors.New("API token is required")
+	}
+
+	apiURL := strings.TrimRight(cfg.APIURL, "/")
+	if apiURL == "" {
+		apiURL = DefaultAPIURL
+	}
+	version := cfg.Version
+	if version == "" {
+		version = defaultUserAgent
+	}
+	// The limiter sets no fixed rate, but holds back requests after HTTP
+	// 429 as the CLI does.
+	opts := []core.ClientOption{core.WithRateLimiter(core.NewRateLimiter(0))}
+	if cfg.HTTPClient != nil {
+		opts = append(opts, core.WithHTTPClient(cfg.HTTPClient))
+	}
+
+	logger := cfg.Logger
+	if logger == nil {
+		logger = slog.New(slog.NewTextHandler(io.Discard, nil))
+	}
+	client := core.NewHTTPClient(apiURL, cfg.Token, version, opts...)
+	out := output.NewLogger(logger)
+	return &Releaser{
+		appID:    cfg.AppID,
+		client:   client,
+		out:      out,
+		ws:       workspace.New(workspace.Options{}),
+		releaser: releaser.New(cfg.AppID, cfg.Token, client, out),
+	}, nil
+}
+
+// Close removes the temporary files that operations of r left behind, such
+// as zip archives of interrupted pushes. Other Releasers are not affected.
+func (r *Releaser) Close() error {
+	_, err := r.ws.Cleanup()
+	return err
+}
+
+// Deployments returns the deployments of the app.
+func (r *Releaser) Deployments(ctx context.Context) ([]Deployment, error) {
+	deployments, err := r.client.ListDeployments(ctx, r.appID)
+	if err != nil {
+		return nil, publicError(err)
+	}
+	result := make([]Deployment, 0, len(deployments))
+	for _, d := range deployments {
+		result = append(result, fromCoreDeployment(d))
+	}
+	return result, nil
+}
+
+// Releases returns the releases of a deployment, given by name or UUID.
+func (r *Releaser) Releases(ctx context.Context, deployment string) ([]Release, error) {
+	deploymentID, err := core.ResolveDeployment(ctx, r.client, r.appID, deployment, r.out)
+	if err != nil {
+		return nil, publicError(err)
+	}
+	updates, err := r.client.ListUpdates(ctx, r.appID, deploymentID)
+	if err != nil {
+		return nil, publicError(err)
+	}
+	result := make([]Release, 0, len(updates))
+	for _, u := range updates {
+		result = append(result, fromCoreUpdate(u))
+	}
+	return result, nil
+}
+
+// ExitCode returns the exit code the codepush CLI uses for err, such as 6
+// for authentication failures or 9 for policy violations, so wrappers can
+// report failures the same way.
+func ExitCode(err error) int {
+	return core.NewErrorReport(err).ExitCode
+}
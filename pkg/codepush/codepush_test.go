@@ -0,0 +1,167 @@
+package codepush_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	core "github.com/bitrise-io/bitrise-plugins-codepush-cli/internal/codepush"
+	"github.com/bitrise-io/bitrise-plugins-codepush-cli/internal/codepushtest"
+	"github.com/bitrise-io/bitrise-plugins-codepush-cli/pkg/codepush"
+)
+
+const testToken = "test-token"
+
+func writeBundle(t *testing.T, content string) string {
+	t.Helper()
+	dir := filepath.Join(t.TempDir(), "CodePush")
+	require.NoError(t, os.MkdirAll(dir, 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "main.jsbundle"), []byte(content), 0o644))
+	return dir
+}
+
+func TestReleaser(t *testing.T) {
+	s := codepushtest.NewServer()
+	s.Token = testToken
+	a := s.AddApp(core.App{Name: "Example", Platform: "ios"})
+	staging, err := s.AddDeployment(a.ID, "Staging")
+	require.NoError(t, err)
+	production, err := s.AddDeployment(a.ID, "Production")
+	require.NoError(t, err)
+
+	var logs bytes.Buffer
+	r, err := codepush.New(codepush.Config{
+		AppID:  a.ID,
+		Token:  testToken,
+		APIURL: codepushtest.Start(t, s) + codepushtest.APIPath,
+		Logger: slog.New(slog.NewTextHandler(&logs, nil)),
+	})
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	first, err := r.Push(ctx, codepush.PushOptions{Deployment: "Staging", BundlePath: writeBundle(t, "console.log(1)"), AppVersion: "1.0.0"})
+	require.NoError(t, err)
+	assert.Equal(t, 100, first.Rollout)
+	second, err := r.Push(ctx, codepush.PushOptions{Deployment: "Staging", BundlePath: writeBundle(t, "console.log(2)"), AppVersion: "1.0.0", Rollout: 20})
+	require.NoError(t, err)
+	assert.Equal(t, 20, second.Rollout)
+	assert.Contains(t, logs.String(), "level=INFO")
+	assert.NotContains(t, logs.String(), "\x1b[", "log messages should not contain terminal escapes")
+
+	mandatory := true
+	promoted, err := r.Promote(ctx, codepush.PromoteOptions{From: "Staging", To: "Production", Label: "v1", Mandatory: &mandatory})
+	require.NoError(t, err)
+	assert.Equal(t, production.ID, promoted.DestDeployment)
+	assert.True(t, s.Releases(a.ID, production.ID)[0].Mandatory)
+
+	rolledBack, err := r.Rollback(ctx, codepush.RollbackOptions{Deployment: staging.ID})
+	require.NoError(t, err)
+	assert.Equal(t, "v3", rolledBack.Label)
+
+	deployments, err := r.Deployments(ctx)
+	require.NoError(t, err)
+	require.Len(t, deployments, 2)
+	releases, err := r.Releases(ctx, "Staging")
+	require.NoError(t, err)
+	assert.Len(t, releases, 3)
+
+	_, err = r.Push(ctx, codepush.PushOptions{Deployment: "QA", BundlePath: writeBundle(t, "console.log(3)"), AppVersion: "1.0.0"})
+	assert.ErrorContains(t, err, `deployment "QA" not found`)
+	require.NoError(t, r.Close())
+}
+
+// roundTripFunc fakes the transport of Config.HTTPClient.
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+func TestAPIError(t *testing.T) {
+	var requests int
+	r, err := codepush.New(codepush.Config{
+		AppID:  "app-1",
+		Token:  testToken,
+		APIURL: "https://codepush.example.com/v1",
+		HTTPClient: &http.Client{Transport: roundTripFunc(func(req *http.Request) (*http.Response, error) {
+			requests++
+			assert.Equal(t, "/v1/connected-apps/app-1/code-push/deployments", req.URL.Path)
+			return &http.Response{
+				StatusCode: http.StatusForbidden,
+				Header:     http.Header{"Content-Type": {"application/json"}},
+				Body:       http.NoBody,
+				Request:    req,
+			}, nil
+		})},
+	})
+	require.NoError(t, err)
+
+	_, err = r.Deployments(context.Background())
+	require.Error(t, err)
+	assert.Equal(t, 1, requests, "the injected client sends the request")
+
+	var apiErr *codepush.APIError
+	require.True(t, errors.As(err, &apiErr))
+	assert.Equal(t, http.StatusForbidden, apiErr.StatusCode)
+	assert.Equal(t, err.Error(), apiErr.Error())
+	assert.Equal(t, 6, codepush.ExitCode(err))
+}
+
+func TestNewValidation(t *testing.T) {
+	_, err := codepush.New(codepush.Config{Token: testToken})
+	assert.EqualError(t, err, "app ID is required")
+	_, err = codepush.New(codepush.Config{AppID: "app-1"})
+	assert.EqualError(t, err, "API token is required")
+}
+
+func TestOptionValidation(t *testing.T) {
+	r, err := codepush.New(codepush.Config{AppID: "app-1", Token: testToken})
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	_, err = r.Push(ctx, codepush.PushOptions{BundlePath: "build", AppVersion: "1.0.0"})
+	assert.EqualError(t, err, "deployment is required")
+	assert.Equal(t, 2, codepush.ExitCode(err))
+	_, err = r.Push(ctx, codepush.PushOptions{Deployment: "Staging", AppVersion: "1.0.0"})
+	assert.EqualError(t, err, "bundle path is required")
+	_, err = r.Push(ctx, codepush.PushOptions{Deployment: "Staging", BundlePath: "build"})
+	assert.EqualError(t, err, "app version is required")
+	_, err = r.Push(ctx, codepush.PushOptions{Deployment: "Staging", BundlePath: "build", AppVersion: "1.0.0", Compression: "zstd"})
+	assert.ErrorContains(t, err, "compression: ")
+	assert.Equal(t, 2, codepush.ExitCode(err))
+	_, err = r.Promote(ctx, codepush.PromoteOptions{From: "Staging"})
+	assert.EqualError(t, err, "source and destination deployments are required")
+	_, err = r.Rollback(ctx, codepush.RollbackOptions{})
+	assert.EqualError(t, err, "deployment is required")
+}
+
+func TestReleaserCloseKeepsOtherReleasers(t *testing.T) {
+	s := codepushtest.NewServer()
+	s.Token = testToken
+	a := s.AddApp(core.App{Name: "Example", Platform: "ios"})
+	_, err := s.AddDeployment(a.ID, "Staging")
+	require.NoError(t, err)
+	apiURL := codepushtest.Start(t, s) + codepushtest.APIPath
+
+	first, err := codepush.New(codepush.Config{AppID: a.ID, Token: testToken, APIURL: apiURL})
+	require.NoError(t, err)
+	second, err := codepush.New(codepush.Config{AppID: a.ID, Token: testToken, APIURL: apiURL})
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	_, err = first.Push(ctx, codepush.PushOptions{Deployment: "Staging", BundlePath: writeBundle(t, "console.log(1)"), AppVersion: "1.0.0"})
+	require.NoError(t, err)
+	require.NoError(t, first.Close())
+
+	_, err = second.Push(ctx, codepush.PushOptions{Deployment: "Staging", BundlePath: writeBundle(t, "console.log(2)"), AppVersion: "1.0.0"})
+	require.NoError(t, err)
+	require.NoError(t, second.Close())
+}
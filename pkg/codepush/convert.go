@@ -0,0 +1,126 @@
+package codepush
+
+import (
+	"errors"
+
+	core "github.com/bitrise-io/bitrise-plugins-codepush-cli/internal/codepush"
+)
+
+// The exported types of this package are converted from and to the ones
+// of internal/codepush here, so that internal changes do not change the
+// public API.
+
+func coreTargeting(t Targeting) core.Targeting {
+	return core.Targeting{OSVersion: t.OSVersion, DeviceModels: t.DeviceModels, Countries: t.Countries}
+}
+
+func fromCoreTargeting(t core.Targeting) Targeting {
+	return Targeting{OSVersion: t.OSVersion, DeviceModels: t.DeviceModels, Countries: t.Countries}
+}
+
+func coreProvenance(p *Provenance) *core.Provenance {
+	if p == nil {
+		return nil
+	}
+	return &core.Provenance{
+		BuildNumber: p.BuildNumber,
+		BuildURL:    p.BuildURL,
+		CommitHash:  p.CommitHash,
+		Branch:      p.Branch,
+		Dirty:       p.Dirty,
+		Workflow:    p.Workflow,
+	}
+}
+
+func fromCoreProvenance(p *core.Provenance) *Provenance {
+	if p == nil {
+		return nil
+	}
+	return &Provenance{
+		BuildNumber: p.BuildNumber,
+		BuildURL:    p.BuildURL,
+		CommitHash:  p.CommitHash,
+		Branch:      p.Branch,
+		Dirty:       p.Dirty,
+		Workflow:    p.Workflow,
+	}
+}
+
+func fromCoreDeployment(d core.Deployment) Deployment {
+	deployment := Deployment{ID: d.ID, Name: d.Name, Key: d.Key, CreatedAt: d.CreatedAt}
+	if d.LatestUpdate != nil {
+		latest := fromCoreUpdate(*d.LatestUpdate)
+		deployment.Latest = &latest
+	}
+	return deployment
+}
+
+func fromCoreUpdate(u core.Update) Release {
+	return Release{
+		ID:            u.ID,
+		Label:         u.Label,
+		AppVersion:    u.AppVersion,
+		Description:   u.Description,
+		Mandatory:     u.Mandatory,
+		Disabled:      u.Disabled,
+		Rollout:       u.Rollout,
+		FileSizeBytes: u.FileSizeBytes,
+		Hash:          u.Hash,
+		CreatedAt:     u.CreatedAt,
+		Provenance:    fromCoreProvenance(u.Provenance),
+		Targeting:     fromCoreTargeting(u.Targeting),
+	}
+}
+
+func fromCorePushResult(r *core.PushResult) *PushResult {
+	return &PushResult{
+		UpdateID:         r.UpdateID,
+		AppID:            r.AppID,
+		DeploymentID:     r.DeploymentID,
+		AppVersion:       r.AppVersion,
+		Status:           r.Status,
+		FileSizeBytes:    r.FileSizeBytes,
+		Rollout:          r.Rollout,
+		UploadStrategy:   r.UploadStrategy,
+		RuntimeVersion:   r.RuntimeVersion,
+		DiffAgainst:      r.DiffAgainst,
+		ContentSizeBytes: r.ContentSizeBytes,
+		Compression:      r.Compression,
+		Provenance:       fromCoreProvenance(r.Provenance),
+		DuplicateOf:      r.DuplicateOf,
+		Targeting:        fromCoreTargeting(r.Targeting),
+	}
+}
+
+func fromCorePromoteResult(r *core.PromoteResult) *PromoteResult {
+	return &PromoteResult{
+		UpdateID:         r.UpdateID,
+		AppID:            r.AppID,
+		SourceDeployment: r.SourceDeployment,
+		DestDeployment:   r.DestDeployment,
+		Label:            r.Label,
+		AppVersion:       r.AppVersion,
+		Description:      r.Description,
+	}
+}
+
+func fromCoreRollbackResult(r *core.RollbackResult) *RollbackResult {
+	return &RollbackResult{
+		UpdateID:     r.UpdateID,
+		AppID:        r.AppID,
+		DeploymentID: r.DeploymentID,
+		Label:        r.Label,
+		AppVersion:   r.AppVersion,
+	}
+}
+
+// publicError returns err with an APIError in its chain when the API
+// rejected a request, keeping the original chain for errors.Is and
+// ExitCode.
+func publicError(err error) error {
+	var apiErr *core.APIError
+	if !errors.As(err, &apiErr) {
+		return err
+	}
+	return &APIError{StatusCode: apiErr.StatusCode, Code: apiErr.Code, Message: apiErr.Message, err: err}
+}
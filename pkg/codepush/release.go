@@ -0,0 +1,211 @@
+package codepush
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	core "github.com/bitrise-io/bitrise-plugins-codepush-cli/internal/codepush"
+	"github.com/bitrise-io/bitrise-plugins-codepush-cli/internal/zip"
+)
+
+// DefaultProcessingTimeout is how long Push waits for the server to
+// process an update when PushOptions.Timeout is not set.
+const DefaultProcessingTimeout = 2 * time.Minute
+
+// PushOptions configures Push.
+type PushOptions struct {
+	// Deployment is the deployment name or UUID. Required.
+	Deployment string
+	// BundlePath is the directory with the JavaScript bundle and its
+	// assets, as written by the React Native or Expo bundler. Required.
+	BundlePath string
+	// AppVersion is the binary version range the release targets, such as
+	// "1.4.0" or "^1.4.0". Required.
+	AppVersion string
+
+	Description string
+	Mandatory   bool
+	Disabled    bool
+	// Rollout is the percentage of devices offered the release, 1 to 100.
+	// Zero means 100; use Disabled to release to no device.
+	Rollout int
+	// Targeting restricts which devices are offered the release.
+	Targeting Targeting
+
+	// ExpectLabel fails the push unless the release gets this label.
+	ExpectLabel string
+	// Full uploads the whole package even when a delta against the latest
+	// release would be smaller.
+	Full bool
+	// AllowDuplicate skips the push, instead of failing it, when the latest
+	// release already has the bundle's content. PushResult.DuplicateOf is
+	// then set.
+	AllowDuplicate bool
+	// Provenance is recorded with the release; nil records nothing.
+	Provenance *Provenance
+	// RuntimeVersion is the Expo runtime version the bundle was built for,
+	// recorded with the release.
+	RuntimeVersion string
+
+	// CheckNativeChanges warns when the bundle references native modules
+	// that the previous release did not. The previous release is downloaded
+	// for the comparison.
+	CheckNativeChanges bool
+	// FailOnNativeChange implies CheckNativeChanges and fails the push when
+	// new references are found or the bundles cannot be compared.
+	FailOnNativeChange bool
+
+	// UploadStrategy is "auto" (the default), "single", or "parallel".
+	UploadStrategy string
+	// Compression is "deflate" (the default), "deflate:<1-9>", or "store".
+	Compression string
+
+	// NoWait returns after the upload without waiting for the server to
+	// process the update.
+	NoWait bool
+	// Timeout is how long to wait for processing. Zero uses
+	// DefaultProcessingTimeout.
+	Timeout time.Duration
+}
+
+// Push uploads the bundle at opts.BundlePath and releases it to a
+// deployment, as 'codepush push' does. It uploads a delta package when
+// that is smaller, and waits until the server has processed the update
+// unless opts.NoWait is set.
+func (r *Releaser) Push(ctx context.Context, opts PushOptions) (*PushResult, error) {
+	switch {
+	case opts.Deployment == "":
+		return nil, core.Invalid(errors.New("deployment is required"))
+	case opts.BundlePath == "":
+		return nil, core.Invalid(errors.New("bundle path is required"))
+	case opts.AppVersion == "":
+		return nil, core.Invalid(errors.New("app version is required"))
+	}
+	compression, err := zip.ParseCompression(opts.Compression)
+	if err != nil {
+		return nil, core.Invalid(fmt.Errorf("compression: %w", err))
+	}
+	rollout := opts.Rollout
+	if rollout == 0 {
+		rollout = 100
+	}
+	timeout := opts.Timeout
+	if timeout <= 0 {
+		timeout = DefaultProcessingTimeout
+	}
+
+	result, err := r.releaser.Push(ctx, &core.PushOptions{
+		DeploymentID:       opts.Deployment,
+		AppVersion:         opts.AppVersion,
+		Description:        opts.Description,
+		Mandatory:          opts.Mandatory,
+		Disabled:           opts.Disabled,
+		Rollout:            rollout,
+		BundlePath:         opts.BundlePath,
+		CheckNativeChanges: opts.CheckNativeChanges,
+		FailOnNativeChange: opts.FailOnNativeChange,
+		UploadStrategy:     core.UploadStrategy(opts.UploadStrategy),
+		RuntimeVersion:     opts.RuntimeVersion,
+		ExpectLabel:        opts.ExpectLabel,
+		Full:               opts.Full,
+		Compression:        compression,
+		NoWait:             opts.NoWait,
+		Targeting:          coreTargeting(opts.Targeting),
+		Provenance:         coreProvenance(opts.Provenance),
+		AllowDuplicate:     opts.AllowDuplicate,
+		Workspace:          r.ws,
+	}, core.PollConfigFor(timeout))
+	if err != nil {
+		return nil, publicError(err)
+	}
+	return fromCorePushResult(result), nil
+}
+
+// PromoteOptions configures Promote. Unset overrides keep the value of the
+// promoted release.
+type PromoteOptions struct {
+	// From and To are the source and destination deployment names or
+	// UUIDs. Required.
+	From string
+	To   string
+	// Label is the source release to promote; empty promotes the latest.
+	Label string
+
+	AppVersion  string
+	Description string
+	Mandatory   *bool
+	Disabled    *bool
+	// Rollout is the percentage of devices offered the release, 0 to 100.
+	Rollout *int
+
+	// ExpectSourceHash fails the promote unless the promoted release has
+	// this content hash.
+	ExpectSourceHash string
+}
+
+// Promote copies a release from one deployment to another, as
+// 'codepush promote' does.
+func (r *Releaser) Promote(ctx context.Context, opts PromoteOptions) (*PromoteResult, error) {
+	if opts.From == "" || opts.To == "" {
+		return nil, core.Invalid(errors.New("source and destination deployments are required"))
+	}
+	result, err := r.releaser.Promote(ctx, &core.PromoteOptions{
+		SourceDeploymentID: opts.From,
+		DestDeploymentID:   opts.To,
+		Label:              opts.Label,
+		AppVersion:         opts.AppVersion,
+		Description:        opts.Description,
+		Mandatory:          formatBool(opts.Mandatory),
+		Disabled:           formatBool(opts.Disabled),
+		Rollout:            formatInt(opts.Rollout),
+		ExpectSourceHash:   opts.ExpectSourceHash,
+	})
+	if err != nil {
+		return nil, publicError(err)
+	}
+	return fromCorePromoteResult(result), nil
+}
+
+// RollbackOptions configures Rollback.
+type RollbackOptions struct {
+	// Deployment is the deployment name or UUID. Required.
+	Deployment string
+	// Label is the release to roll back to; empty rolls back to the one
+	// before the latest.
+	Label string
+}
+
+// Rollback releases an earlier release of a deployment again, as
+// 'codepush rollback' does.
+func (r *Releaser) Rollback(ctx context.Context, opts RollbackOptions) (*RollbackResult, error) {
+	if opts.Deployment == "" {
+		return nil, core.Invalid(errors.New("deployment is required"))
+	}
+	result, err := r.releaser.Rollback(ctx, &core.RollbackOptions{
+		DeploymentID: opts.Deployment,
+		TargetLabel:  opts.Label,
+	})
+	if err != nil {
+		return nil, publicError(err)
+	}
+	return fromCoreRollbackResult(result), nil
+}
+
+// formatBool and formatInt encode optional overrides as the internal
+// options expect them, with "" for unset.
+func formatBool(b *bool) string {
+	if b == nil {
+		return ""
+	}
+	return strconv.FormatBool(*b)
+}
+
+func formatInt(n *int) string {
+	if n == nil {
+		return ""
+	}
+	return strconv.Itoa(*n)
+}
@@ -0,0 +1,128 @@
+package codepush
+
+// Targeting restricts which devices are offered a release. Empty fields
+// do not restrict.
+type Targeting struct {
+	// OSVersion is a range of OS versions, such as ">=14.0 <17".
+	OSVersion string `json:"target_os_version,omitempty"`
+	// DeviceModels are model identifiers as reported by the device, such as
+	// "iPhone14,2" or "Pixel 7".
+	DeviceModels []string `json:"target_device_models,omitempty"`
+	// Countries are ISO 3166-1 alpha-2 codes, such as "DE".
+	Countries []string `json:"target_countries,omitempty"`
+}
+
+// Provenance records where a release was built, such as the commit and CI
+// build.
+type Provenance struct {
+	BuildNumber string `json:"build_number,omitempty"`
+	BuildURL    string `json:"build_url,omitempty"`
+	CommitHash  string `json:"commit_hash,omitempty"`
+	Branch      string `json:"branch,omitempty"`
+	// Dirty reports uncommitted changes in the checkout the release was
+	// pushed from.
+	Dirty    bool   `json:"dirty,omitempty"`
+	Workflow string `json:"workflow,omitempty"`
+}
+
+// Deployment is a release channel of an app, such as Staging or
+// Production.
+type Deployment struct {
+	ID        string `json:"id"`
+	Name      string `json:"name"`
+	Key       string `json:"key,omitempty"`
+	CreatedAt string `json:"created_at,omitempty"`
+	// Latest is the latest release of the deployment; nil when it has none.
+	Latest *Release `json:"latest_package,omitempty"`
+}
+
+// Release is an update released to a deployment.
+type Release struct {
+	ID            string      `json:"id"`
+	Label         string      `json:"label"`
+	AppVersion    string      `json:"app_version"`
+	Description   string      `json:"description"`
+	Mandatory     bool        `json:"mandatory"`
+	Disabled      bool        `json:"disabled"`
+	Rollout       float64     `json:"rollout"`
+	FileSizeBytes int64       `json:"file_size_bytes"`
+	Hash          string      `json:"hash,omitempty"`
+	CreatedAt     string      `json:"created_at,omitempty"`
+	Provenance    *Provenance `json:"provenance,omitempty"`
+	Targeting
+}
+
+// PushResult is the release created by Push.
+type PushResult struct {
+	UpdateID      string `json:"package_id"`
+	AppID         string `json:"app_id"`
+	DeploymentID  string `json:"deployment_id"`
+	AppVersion    string `json:"app_version"`
+	Status        string `json:"status"`
+	FileSizeBytes int64  `json:"file_size_bytes"`
+	Rollout       int    `json:"rollout"`
+
+	// UploadStrategy records the upload strategy that succeeded:
+	// "parallel", "sequential", or "single".
+	UploadStrategy string `json:"upload_strategy,omitempty"`
+	// RuntimeVersion is the Expo runtime version recorded with the release.
+	RuntimeVersion string `json:"runtime_version,omitempty"`
+	// DiffAgainst is the label of the release a delta package was uploaded
+	// against. FileSizeBytes is then the size of the delta package.
+	DiffAgainst string `json:"diff_against,omitempty"`
+	// ContentSizeBytes is the uncompressed size of the bundle and
+	// Compression the compression of the package.
+	ContentSizeBytes int64  `json:"content_size_bytes,omitempty"`
+	Compression      string `json:"compression,omitempty"`
+
+	Provenance *Provenance `json:"provenance,omitempty"`
+
+	// DuplicateOf is the label of the release that already had the
+	// bundle's content when the push was skipped for it. UpdateID is then
+	// that release.
+	DuplicateOf string `json:"duplicate_of,omitempty"`
+
+	Targeting
+}
+
+// PromoteResult is the release created by Promote.
+type PromoteResult struct {
+	UpdateID         string `json:"package_id"`
+	AppID            string `json:"app_id"`
+	SourceDeployment string `json:"source_deployment_id"`
+	DestDeployment   string `json:"dest_deployment_id"`
+	Label            string `json:"label"`
+	AppVersion       string `json:"app_version"`
+	Description      string `json:"description"`
+}
+
+// RollbackResult is the release created by Rollback.
+type RollbackResult struct {
+	UpdateID     string `json:"package_id"`
+	AppID        string `json:"app_id"`
+	DeploymentID string `json:"deployment_id"`
+	Label        string `json:"label"`
+	AppVersion   string `json:"app_version"`
+}
+
+// APIError is a request the CodePush API rejected, found with errors.As
+// in an error returned by a Releaser. It wraps the whole failure, so its
+// message is the one of the returned error.
+type APIError struct {
+	StatusCode int
+	// Code is the machine-readable error code of the response, such as
+	// ERR_BAD_REQUEST. Empty when the server did not send one.
+	Code string
+	// Message is the error message of the response.
+	Message string
+
+	err error
+}
+
+func (e *APIError) Error() string {
+	return e.err.Error()
+}
+
+func (e *APIError) Unwrap() error {
+	return e.err
+}